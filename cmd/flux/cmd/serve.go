@@ -0,0 +1,399 @@
+package cmd
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/controller"
+	"github.com/InfluxCommunity/flux/csv"
+	"github.com/InfluxCommunity/flux/dependency"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/lang"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.uber.org/zap"
+)
+
+// maxQueryRequestSize is the maximum size of a query request body.
+const maxQueryRequestSize = 16 * 1024 * 1024
+
+// QueryRequest is the body of a request to the query endpoint.
+// It is compatible with the InfluxDB /api/v2/query endpoint.
+type QueryRequest struct {
+	// Query is the Flux script to execute.
+	// It is ignored when an AST is present.
+	Query string `json:"query"`
+
+	// AST is a JSON encoded package to execute.
+	AST json.RawMessage `json:"ast,omitempty"`
+
+	// Type is the type of query. Only flux is supported.
+	Type string `json:"type,omitempty"`
+
+	// Extern is a JSON encoded file with statements
+	// that are executed before the query.
+	Extern json.RawMessage `json:"extern,omitempty"`
+
+	// Now is the time used for now() in the query.
+	// The current time is used when it is zero.
+	Now time.Time `json:"now"`
+
+	// Dialect describes how the results are encoded.
+	// The csv dialect is used when it is not set.
+	Dialect json.RawMessage `json:"dialect,omitempty"`
+}
+
+// Compiler returns the compiler for the query.
+func (r *QueryRequest) Compiler() (flux.Compiler, error) {
+	if r.Type != "" && r.Type != "flux" {
+		return nil, errors.Newf(codes.Invalid, "unsupported query type %q", r.Type)
+	}
+
+	now := r.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	if len(r.AST) > 0 {
+		return lang.ASTCompiler{
+			AST:    r.AST,
+			Extern: r.Extern,
+			Now:    now,
+		}, nil
+	}
+	if r.Query == "" {
+		return nil, errors.New(codes.Invalid, "query request requires a query or an ast")
+	}
+	return lang.FluxCompiler{
+		Query:  r.Query,
+		Extern: r.Extern,
+		Now:    now,
+	}, nil
+}
+
+// DialectFor creates the dialect for the query from the mappings.
+func (r *QueryRequest) DialectFor(mappings flux.DialectMappings) (flux.Dialect, error) {
+	var typ struct {
+		Type flux.DialectType `json:"type"`
+	}
+	if len(r.Dialect) > 0 {
+		if err := json.Unmarshal(r.Dialect, &typ); err != nil {
+			return nil, errors.Wrap(err, codes.Invalid, "invalid dialect")
+		}
+	}
+	if typ.Type == "" {
+		typ.Type = csv.DialectType
+	}
+
+	create, ok := mappings[typ.Type]
+	if !ok {
+		return nil, errors.Newf(codes.Invalid, "unknown dialect type %q", typ.Type)
+	}
+	dialect := create()
+	if len(r.Dialect) > 0 {
+		if err := json.Unmarshal(r.Dialect, dialect); err != nil {
+			return nil, errors.Wrap(err, codes.Invalid, "invalid dialect")
+		}
+	}
+	return dialect, nil
+}
+
+// InjectFunc adds the dependencies used to execute a query to the context.
+type InjectFunc func(ctx context.Context) (context.Context, *dependency.Span, error)
+
+// QueryHandler serves an HTTP query API that is compatible
+// with the InfluxDB /api/v2/query and /health endpoints.
+type QueryHandler struct {
+	controller *controller.Controller
+	dialects   flux.DialectMappings
+	inject     InjectFunc
+	logger     *zap.Logger
+	mux        *http.ServeMux
+	token      string
+}
+
+// NewQueryHandler creates a QueryHandler that executes queries
+// with the controller and the injected dependencies.
+// Results may be encoded with any of the dialects. The csv dialect
+// is required because it is used when a request does not set one.
+func NewQueryHandler(controller *controller.Controller, inject InjectFunc, dialects flux.DialectMappings, logger *zap.Logger) (*QueryHandler, error) {
+	if _, ok := dialects[csv.DialectType]; !ok {
+		return nil, errors.Newf(codes.Invalid, "query handler requires the %s dialect", csv.DialectType)
+	}
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+
+	h := &QueryHandler{
+		controller: controller,
+		dialects:   dialects,
+		inject:     inject,
+		logger:     logger,
+		mux:        http.NewServeMux(),
+	}
+	h.mux.HandleFunc("/api/v2/query", h.handleQuery)
+	h.mux.HandleFunc("/health", h.handleHealth)
+	return h, nil
+}
+
+// RequireToken requires requests to the query endpoint to be
+// authorized with the token in an InfluxDB "Authorization: Token"
+// or an "Authorization: Bearer" header.
+func (h *QueryHandler) RequireToken(token string) {
+	h.token = token
+}
+
+// authorized reports whether the request carries the required token.
+func (h *QueryHandler) authorized(r *http.Request) bool {
+	if h.token == "" {
+		return true
+	}
+	scheme, token, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !strings.EqualFold(scheme, "Token") && !strings.EqualFold(scheme, "Bearer") {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) == 1
+}
+
+func (h *QueryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *QueryHandler) handleQuery(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		h.writeError(w, errors.Newf(codes.Invalid, "method %s is not allowed", r.Method), http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.authorized(r) {
+		h.writeError(w, errors.New(codes.Unauthenticated, "unauthorized access"), 0)
+		return
+	}
+
+	req, err := decodeQueryRequest(r)
+	if err != nil {
+		h.writeError(w, err, 0)
+		return
+	}
+	compiler, err := req.Compiler()
+	if err != nil {
+		h.writeError(w, err, 0)
+		return
+	}
+	dialect, err := req.DialectFor(h.dialects)
+	if err != nil {
+		h.writeError(w, err, 0)
+		return
+	}
+
+	// Continue the trace of the client when the request carries one.
+	ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	ctx, span, err := h.inject(ctx)
+	if err != nil {
+		h.writeError(w, err, 0)
+		return
+	}
+	defer span.Finish()
+
+	query, err := h.controller.Query(ctx, compiler)
+	if err != nil {
+		h.writeError(w, err, 0)
+		return
+	}
+
+	results := flux.NewResultIteratorFromQuery(query)
+	defer results.Release()
+
+	rw := &resultWriter{ResponseWriter: w, dialect: dialect}
+	if n, err := dialect.Encoder().Encode(rw, results); err != nil {
+		if n == 0 {
+			// Nothing has been written so the error
+			// can still be reported with the status code.
+			h.writeError(w, err, 0)
+			return
+		}
+		h.logger.Info("Error encoding query results", zap.Error(err))
+	}
+	rw.writeHeader()
+}
+
+// decodeQueryRequest reads the query request from the body.
+// A body with the application/vnd.flux content type is the query itself.
+func decodeQueryRequest(r *http.Request) (*QueryRequest, error) {
+	body := io.LimitReader(r.Body, maxQueryRequestSize)
+
+	var req QueryRequest
+	mediatype, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediatype == "application/vnd.flux" {
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return nil, errors.Wrap(err, codes.Invalid, "failed to read query")
+		}
+		req.Query = string(data)
+		return &req, nil
+	}
+
+	if err := json.NewDecoder(body).Decode(&req); err != nil {
+		return nil, errors.Wrap(err, codes.Invalid, "failed to decode query request")
+	}
+	return &req, nil
+}
+
+// resultWriter sets the response headers of the dialect
+// when the results are first written.
+type resultWriter struct {
+	http.ResponseWriter
+	dialect     flux.Dialect
+	wroteHeader bool
+}
+
+func (w *resultWriter) writeHeader() {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	if d, ok := w.dialect.(interface{ SetHeaders(w http.ResponseWriter) }); ok {
+		d.SetHeaders(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(http.StatusOK)
+}
+
+func (w *resultWriter) Write(p []byte) (int, error) {
+	w.writeHeader()
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *resultWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		w.writeHeader()
+		f.Flush()
+	}
+}
+
+// writeError writes the error as an InfluxDB error response.
+// The status code is derived from the error code when it is zero.
+func (h *QueryHandler) writeError(w http.ResponseWriter, err error, status int) {
+	code := errors.Code(err)
+	if status == 0 {
+		status = statusCode(code)
+	}
+	if status >= http.StatusInternalServerError {
+		h.logger.Info("Error executing query", zap.Error(err))
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("X-Platform-Error-Code", errorCode(code))
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}{
+		Code:    errorCode(code),
+		Message: err.Error(),
+	})
+}
+
+// statusCode returns the HTTP status code for an error code.
+func statusCode(code codes.Code) int {
+	switch code {
+	case codes.Invalid, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.FailedPrecondition:
+		return http.StatusUnprocessableEntity
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Canceled:
+		// The status code nginx uses when the client closes the request.
+		return 499
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// errorCode returns the InfluxDB error code for an error code.
+func errorCode(code codes.Code) string {
+	switch code {
+	case codes.Invalid, codes.OutOfRange:
+		return "invalid"
+	case codes.Unauthenticated:
+		return "unauthorized"
+	case codes.PermissionDenied:
+		return "forbidden"
+	case codes.NotFound:
+		return "not found"
+	case codes.AlreadyExists, codes.Aborted:
+		return "conflict"
+	case codes.FailedPrecondition:
+		return "unprocessable entity"
+	case codes.ResourceExhausted:
+		return "too many requests"
+	case codes.Canceled:
+		return "canceled"
+	case codes.Unimplemented:
+		return "not implemented"
+	case codes.Unavailable:
+		return "unavailable"
+	case codes.DeadlineExceeded:
+		return "request timeout"
+	default:
+		return "internal error"
+	}
+}
+
+func (h *QueryHandler) handleHealth(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(struct {
+		Name    string   `json:"name"`
+		Message string   `json:"message"`
+		Status  string   `json:"status"`
+		Checks  []string `json:"checks"`
+		Version string   `json:"version,omitempty"`
+	}{
+		Name:    "flux",
+		Message: "ready for queries",
+		Status:  "pass",
+		Checks:  []string{},
+		Version: version(),
+	})
+}
+
+// version returns the version of the flux module in the binary.
+func version() string {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	const modulePath = "github.com/InfluxCommunity/flux"
+	if bi.Main.Path == modulePath {
+		return bi.Main.Version
+	}
+	for _, dep := range bi.Deps {
+		if dep.Path == modulePath {
+			return dep.Version
+		}
+	}
+	return ""
+}
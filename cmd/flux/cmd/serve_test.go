@@ -0,0 +1,196 @@
+package cmd_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/cmd/flux/cmd"
+	"github.com/InfluxCommunity/flux/controller"
+	"github.com/InfluxCommunity/flux/csv"
+	"github.com/InfluxCommunity/flux/dependency"
+	"github.com/InfluxCommunity/flux/fluxinit"
+	"github.com/InfluxCommunity/flux/runtime"
+	"go.uber.org/zap/zaptest"
+)
+
+// newQueryServer starts a server for a QueryHandler
+// that encodes results with the csv dialect.
+func newQueryServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	return newQueryServerWithToken(t, "")
+}
+
+// newQueryServerWithToken starts a server for a QueryHandler
+// that requires the token when it is not empty.
+func newQueryServerWithToken(t *testing.T, token string) *httptest.Server {
+	t.Helper()
+	fluxinit.FluxInit()
+
+	ctrl, err := controller.New(controller.Config{
+		ConcurrencyQuota: 1,
+		QueueSize:        1,
+	}, runtime.Default)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ctrl.Shutdown(context.Background()) })
+
+	inject := func(ctx context.Context) (context.Context, *dependency.Span, error) {
+		ctx, span := dependency.Inject(ctx, flux.NewDefaultDependencies())
+		return ctx, span, nil
+	}
+	dialects := make(flux.DialectMappings)
+	if err := csv.AddDialectMappings(dialects); err != nil {
+		t.Fatal(err)
+	}
+	h, err := cmd.NewQueryHandler(ctrl, inject, dialects, zaptest.NewLogger(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "" {
+		h.RequireToken(token)
+	}
+	ts := httptest.NewServer(h)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// postQuery sends the body to the query endpoint
+// and returns the response with its body.
+func postQuery(t *testing.T, ts *httptest.Server, body string) (*http.Response, string) {
+	t.Helper()
+	resp, err := http.Post(ts.URL+"/api/v2/query", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, string(data)
+}
+
+// errorResponse is the body of an error response.
+type errorResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func decodeError(t *testing.T, body string) errorResponse {
+	t.Helper()
+	var e errorResponse
+	if err := json.Unmarshal([]byte(body), &e); err != nil {
+		t.Fatalf("invalid error response %q: %s", body, err)
+	}
+	return e
+}
+
+func TestQueryHandler(t *testing.T) {
+	ts := newQueryServer(t)
+
+	query, _ := json.Marshal(map[string]string{
+		"query": `import "array"
+
+array.from(rows: [{_value: 1}, {_value: 2}])`,
+	})
+	resp, body := postQuery(t, ts, string(query))
+	if want, got := http.StatusOK, resp.StatusCode; want != got {
+		t.Fatalf("unexpected status code -want/+got:\n\t- %d\n\t+ %d\n%s", want, got, body)
+	}
+	if want, got := "text/csv; charset=utf-8", resp.Header.Get("Content-Type"); want != got {
+		t.Errorf("unexpected content type -want/+got:\n\t- %s\n\t+ %s", want, got)
+	}
+	for _, want := range []string{",_result,0,1\r\n", ",_result,0,2\r\n"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected the results to contain %q, got:\n%s", want, body)
+		}
+	}
+}
+
+func TestQueryHandler_BadRequest(t *testing.T) {
+	ts := newQueryServer(t)
+
+	resp, body := postQuery(t, ts, `{"query":`)
+	if want, got := http.StatusBadRequest, resp.StatusCode; want != got {
+		t.Fatalf("unexpected status code -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+	if want, got := "invalid", decodeError(t, body).Code; want != got {
+		t.Errorf("unexpected error code -want/+got:\n\t- %s\n\t+ %s", want, got)
+	}
+}
+
+func TestQueryHandler_UnknownDialect(t *testing.T) {
+	ts := newQueryServer(t)
+
+	resp, body := postQuery(t, ts, `{"query": "1", "dialect": {"type": "xml"}}`)
+	if want, got := http.StatusBadRequest, resp.StatusCode; want != got {
+		t.Fatalf("unexpected status code -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+	if e := decodeError(t, body); !strings.Contains(e.Message, `unknown dialect type "xml"`) {
+		t.Errorf("unexpected error message: %s", e.Message)
+	}
+}
+
+func TestQueryHandler_QueryError(t *testing.T) {
+	ts := newQueryServer(t)
+
+	resp, body := postQuery(t, ts, `{"query": "die(msg: \"boom\")"}`)
+	if want, got := http.StatusBadRequest, resp.StatusCode; want != got {
+		t.Fatalf("unexpected status code -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+	e := decodeError(t, body)
+	if want, got := "invalid", e.Code; want != got {
+		t.Errorf("unexpected error code -want/+got:\n\t- %s\n\t+ %s", want, got)
+	}
+	if !strings.Contains(e.Message, "boom") {
+		t.Errorf("expected the error message to contain the query error, got: %s", e.Message)
+	}
+}
+
+func TestQueryHandler_Token(t *testing.T) {
+	ts := newQueryServerWithToken(t, "mytoken")
+
+	for _, tc := range []struct {
+		name          string
+		authorization string
+		want          int
+	}{
+		{name: "missing", want: http.StatusUnauthorized},
+		{name: "wrong", authorization: "Token other", want: http.StatusUnauthorized},
+		{name: "token", authorization: "Token mytoken", want: http.StatusOK},
+		{name: "bearer", authorization: "Bearer mytoken", want: http.StatusOK},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest("POST", ts.URL+"/api/v2/query", strings.NewReader(`{"query": "import \"array\"\narray.from(rows: [{_value: 1}])"}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+			if tc.authorization != "" {
+				req.Header.Set("Authorization", tc.authorization)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_ = resp.Body.Close()
+			if want, got := tc.want, resp.StatusCode; want != got {
+				t.Errorf("unexpected status code -want/+got:\n\t- %d\n\t+ %d", want, got)
+			}
+		})
+	}
+}
+
+func TestNewQueryHandler_RequiresCSV(t *testing.T) {
+	if _, err := cmd.NewQueryHandler(nil, nil, flux.DialectMappings{}, nil); err == nil {
+		t.Fatal("expected an error without the csv dialect")
+	}
+}
@@ -16,6 +16,7 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/InfluxCommunity/flux"
 	"github.com/InfluxCommunity/flux/ast"
@@ -29,6 +30,7 @@ import (
 	"github.com/InfluxCommunity/flux/execute/executetest"
 	"github.com/InfluxCommunity/flux/fluxinit"
 	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/interpreter"
 	"github.com/InfluxCommunity/flux/parser"
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
@@ -46,6 +48,9 @@ type TestFlags struct {
 	parallel      bool
 	verbosity     int
 	noinit        bool
+	reports       []string
+	update        bool
+	coverage      bool
 }
 
 type failedTests struct{}
@@ -66,6 +71,19 @@ func TestCommand(setup TestSetupFunc) *cobra.Command {
 An exit code of 0 means that no tests failed.
 Any other exit code means that either, there was an
 error running the tests or at least one test failed.
+
+Reports of the results can be written for other tools with
+--report format=path, where the format is junit, tap or json.
+The flag may be given more than once.
+
+With --update, the want data of failed tests is rewritten with
+the output of their got stream. This works for tests that call
+testing.diff with want set to a call with a csv argument such as
+csv.from(csv: data), where data is a string literal defined in
+the test file.
+
+With --coverage, the stdlib builtins and Flux functions called
+by each test are recorded and summarized per package.
 `,
 		SilenceErrors: true,
 		SilenceUsage:  true,
@@ -94,6 +112,9 @@ error running the tests or at least one test failed.
 	testCommand.Flags().BoolVarP(&flags.parallel, "parallel", "", false, "Enables parallel test execution.")
 	testCommand.Flags().CountVarP(&flags.verbosity, "verbose", "v", "verbose (-v, -vv, or -vvv)")
 	testCommand.Flags().BoolVarP(&flags.noinit, "noinit", "", false, "Disables Flux initialization, used for testing this command.")
+	testCommand.Flags().StringArrayVar(&flags.reports, "report", nil, "Write a report of the results as format=path. Supported formats are junit, tap and json.")
+	testCommand.Flags().BoolVar(&flags.update, "update", false, "Rewrite the want data of failed tests with their actual output.")
+	testCommand.Flags().BoolVar(&flags.coverage, "coverage", false, "Report the stdlib functions called by the tests.")
 
 	testCommand.SetOutput(color.Output)
 
@@ -107,12 +128,19 @@ func runFluxTests(out io.Writer, setup TestSetupFunc, flags TestFlags) (bool, er
 		flags.paths = []string{"."}
 	}
 
+	reports, err := parseTestReports(flags.reports)
+	if err != nil {
+		return false, err
+	}
+
 	reporter := TestReporter{
 		out:       out,
 		verbosity: flags.verbosity,
 	}
 
 	runner := NewTestRunner(reporter)
+	runner.update = flags.update
+	runner.coverage = flags.coverage
 	if err := runner.Gather(flags.paths); err != nil {
 		return false, err
 	}
@@ -123,9 +151,7 @@ func runFluxTests(out io.Writer, setup TestSetupFunc, flags TestFlags) (bool, er
 
 	runner.MarkSkipped(flags.testNames, flags.skipTestCases, flags.testTags, flags.skipUntagged)
 
-	ctx := context.Background()
-
-	ctx, err := WithFeatureFlags(ctx, flags.features)
+	ctx, err := WithFeatureFlags(context.Background(), flags.features)
 	if err != nil {
 		return false, err
 	}
@@ -136,12 +162,32 @@ func runFluxTests(out io.Writer, setup TestSetupFunc, flags TestFlags) (bool, er
 	}
 	defer func() { _ = executor.Close() }()
 
+	if _, ok := executor.(TestContextExecutor); flags.coverage && !ok {
+		return false, errors.New(codes.Unimplemented, "the test executor does not support coverage")
+	}
+
 	if flags.parallel {
 		runner.RunParallel(executor, flags.verbosity)
 	} else {
 		runner.Run(executor, flags.verbosity)
 	}
-	return runner.Finish(), nil
+	if flags.update {
+		if err := runner.ApplyUpdates(); err != nil {
+			return false, err
+		}
+	}
+	passed := runner.Finish()
+	if flags.coverage {
+		if err := runner.SummarizeCoverage(); err != nil {
+			return false, err
+		}
+	}
+	for _, r := range reports {
+		if err := r.write(runner.tests); err != nil {
+			return false, err
+		}
+	}
+	return passed, nil
 }
 
 var defaultCmdFeatureFlags = executetest.TestFlagger{
@@ -170,6 +216,15 @@ type Test struct {
 	// indicates if the test should be skipped
 	skip bool
 	err  error
+	// time taken to run the test
+	duration time.Duration
+	// indicates if the test file can be rewritten by --update
+	updatable bool
+	// rewrite of the want data prepared by --update
+	update *goldenUpdate
+	// package functions called by the test, recorded by --coverage
+	calls     *testCoverage
+	functions []string
 }
 
 // NewTest creates a new Test instance from an ast.Package.
@@ -202,8 +257,36 @@ func (t *Test) Error() error {
 
 // Run the test, saving the error to the err property of the struct.
 func (t *Test) Run(executor TestExecutor) {
-	t.err = executor.Run(t.ast, t.consume)
+	t.run(context.Background(), executor)
+}
 
+func (t *Test) run(ctx context.Context, executor TestExecutor) {
+	start := time.Now()
+	if ce, ok := executor.(TestContextExecutor); ok {
+		t.err = ce.RunContext(ctx, t.ast, t.consume)
+	} else {
+		t.err = executor.Run(t.ast, t.consume)
+	}
+	t.duration = time.Since(start)
+}
+
+// Updated reports whether the want data of the test was rewritten.
+func (t *Test) Updated() bool {
+	return t.update != nil && t.err == nil
+}
+
+// status returns the outcome of the test as used in the reports.
+func (t *Test) status() string {
+	switch {
+	case t.skip:
+		return "skipped"
+	case t.err != nil:
+		return "failed"
+	case t.Updated():
+		return "updated"
+	default:
+		return "passed"
+	}
 }
 
 func (t *Test) consume(ctx context.Context, results flux.ResultIterator) error {
@@ -303,6 +386,8 @@ type TestRunner struct {
 	tests     []*Test
 	validTags []string
 	reporter  TestReporter
+	update    bool
+	coverage  bool
 }
 
 // NewTestRunner returns a new TestRunner.
@@ -343,6 +428,8 @@ func (t *TestRunner) Gather(roots []string) error {
 			return err
 		}
 		defer func() { _ = fs.Close() }()
+		// Only tests read from the system filesystem can be updated.
+		_, updatable := fs.(systemfs)
 
 		// Gather valid tags from modules
 		for _, m := range mods {
@@ -387,6 +474,7 @@ func (t *TestRunner) Gather(roots []string) error {
 					return errors.Newf(codes.AlreadyExists, "duplicate testcase name %q, found in package %q, at locations %v and %v", tcidens[i].Name, pkg, seen[pkgTest].loc.String(), tcidens[i].Loc.String())
 				}
 				test := NewTest(tcidens[i].Name, astf, tags, pkg)
+				test.updatable = updatable
 				t.tests = append(t.tests, &test)
 				seen[pkgTest] = testcaseLoc{tcidens[i].Loc}
 			}
@@ -922,7 +1010,7 @@ func (t *TestRunner) RunParallel(executor TestExecutor, verbosity int) {
 				go func(i int, test *Test) {
 					defer wg.Done()
 
-					test.Run(executor)
+					t.runTest(executor, test)
 
 					// Send the index of this test to show that it is finished
 					results <- i
@@ -957,12 +1045,31 @@ func (t *TestRunner) Run(executor TestExecutor, verbosity int) {
 	for _, test := range t.tests {
 		if test.skip {
 		} else {
-			test.Run(executor)
+			t.runTest(executor, test)
 		}
 		t.reporter.ReportTestRun(test)
 	}
 }
 
+// runTest runs a single test, recording its coverage
+// and preparing the update of its want data when enabled.
+func (t *TestRunner) runTest(executor TestExecutor, test *Test) {
+	ctx := context.Background()
+	if t.coverage {
+		test.calls = &testCoverage{}
+		ctx = interpreter.WithCallRecorder(ctx, test.calls.record)
+	}
+	test.run(ctx, executor)
+	if t.update && test.err != nil {
+		update, err := test.prepareUpdate(executor)
+		if err != nil {
+			test.err = errors.Newf(codes.FailedPrecondition, "%s\ncannot update want data: %s", test.err, err)
+			return
+		}
+		test.update, test.err = update, nil
+	}
+}
+
 // Finish summarizes the test run, and returns an
 // error in the event of a failure.
 func (t *TestRunner) Finish() bool {
@@ -982,6 +1089,8 @@ func (t *TestReporter) ReportTestRun(test *Test) {
 		if test.skip {
 		} else if test.Error() != nil {
 			fmt.Fprint(t.out, color.RedString("x"))
+		} else if test.Updated() {
+			fmt.Fprint(t.out, color.YellowString("u"))
 		} else {
 			fmt.Fprint(t.out, color.GreenString("."))
 		}
@@ -997,6 +1106,8 @@ func (t *TestReporter) ReportTestRun(test *Test) {
 		if test.skip {
 		} else if err := test.Error(); err != nil {
 			fmt.Fprintf(t.out, "%s ... %s: %s\n", test.FullName(), color.RedString("fail"), err)
+		} else if test.Updated() {
+			fmt.Fprintf(t.out, "%s ... %s\n", test.FullName(), color.YellowString("updated"))
 		} else {
 			fmt.Fprintf(t.out, "%s ... %s\n", test.FullName(), color.GreenString("success"))
 		}
@@ -1007,11 +1118,14 @@ func (t *TestReporter) ReportTestRun(test *Test) {
 func (t *TestReporter) Summarize(tests []*Test) bool {
 	failures := 0
 	skips := 0
+	updates := 0
 	for _, test := range tests {
 		if test.skip {
 			skips = skips + 1
 		} else if test.Error() != nil {
 			failures = failures + 1
+		} else if test.Updated() {
+			updates = updates + 1
 		}
 	}
 	if failures > 0 {
@@ -1023,8 +1137,12 @@ func (t *TestReporter) Summarize(tests []*Test) bool {
 		}
 	}
 
-	passed := len(tests) - skips - failures
-	fmt.Fprintf(t.out, "\n---\nFound %d tests: passed %d, failed %d, skipped %d\n", len(tests), passed, failures, skips)
+	passed := len(tests) - skips - failures - updates
+	fmt.Fprintf(t.out, "\n---\nFound %d tests: passed %d, failed %d, skipped %d", len(tests), passed, failures, skips)
+	if updates > 0 {
+		fmt.Fprintf(t.out, ", updated %d", updates)
+	}
+	fmt.Fprintln(t.out)
 	return failures == 0
 }
 
@@ -1043,6 +1161,13 @@ type TestExecutor interface {
 	io.Closer
 }
 
+// TestContextExecutor is a TestExecutor that can run a package with a context.
+// The context must be used to evaluate the package. It is required by --coverage.
+type TestContextExecutor interface {
+	TestExecutor
+	RunContext(ctx context.Context, pkg *ast.Package, fn TestResultFunc) error
+}
+
 type fs interface {
 	filesystem.Service
 	io.Closer
@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/InfluxCommunity/flux/interpreter"
+	"github.com/InfluxCommunity/flux/runtime"
+	"github.com/InfluxCommunity/flux/semantic"
+	"github.com/InfluxCommunity/flux/values"
+)
+
+// testCoverage records the package functions called by a test.
+type testCoverage struct {
+	mu    sync.Mutex
+	calls map[interpreter.CalledFunction]bool
+}
+
+func (c *testCoverage) record(cf interpreter.CalledFunction) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.calls == nil {
+		c.calls = make(map[interpreter.CalledFunction]bool)
+	}
+	c.calls[cf] = true
+}
+
+// stdlibIndex resolves the functions of the prelude
+// to their package and lists the functions of packages.
+type stdlibIndex struct {
+	importer interpreter.Importer
+	pkgs     map[string]*interpreter.Package
+}
+
+func newStdlibIndex() *stdlibIndex {
+	return &stdlibIndex{
+		importer: runtime.StdLib(),
+		pkgs:     make(map[string]*interpreter.Package),
+	}
+}
+
+func (idx *stdlibIndex) pkg(path string) (*interpreter.Package, error) {
+	if p, ok := idx.pkgs[path]; ok {
+		return p, nil
+	}
+	p, err := idx.importer.ImportPackageObject(path)
+	if err != nil {
+		return nil, err
+	}
+	idx.pkgs[path] = p
+	return p, nil
+}
+
+// qualify returns the package qualified name of the function.
+func (idx *stdlibIndex) qualify(cf interpreter.CalledFunction) (string, error) {
+	path := cf.Package
+	if path == "" {
+		// Later packages of the prelude shadow the earlier ones.
+		for i := len(runtime.PreludeList) - 1; i >= 0; i-- {
+			p, err := idx.pkg(runtime.PreludeList[i])
+			if err != nil {
+				return "", err
+			}
+			if _, ok := p.Get(cf.Name); ok {
+				path = runtime.PreludeList[i]
+				break
+			}
+		}
+	}
+	return path + "." + cf.Name, nil
+}
+
+// functions returns the number of exported functions of the package.
+func (idx *stdlibIndex) functions(path string) (int, error) {
+	p, err := idx.pkg(path)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	p.Range(func(name string, v values.Value) {
+		if !strings.HasPrefix(name, "_") && v.Type().Nature() == semantic.Function {
+			n++
+		}
+	})
+	return n, nil
+}
+
+// SummarizeCoverage resolves the functions called by each test and
+// reports the functions exercised by the tests of each package.
+func (t *TestRunner) SummarizeCoverage() error {
+	idx := newStdlibIndex()
+	builtins := make(map[string]bool)
+	for _, test := range t.tests {
+		if test.calls == nil {
+			continue
+		}
+		for cf := range test.calls.calls {
+			name, err := idx.qualify(cf)
+			if err != nil {
+				return err
+			}
+			test.functions = append(test.functions, name)
+			builtins[name] = cf.Builtin
+		}
+		sort.Strings(test.functions)
+	}
+	return t.reporter.SummarizeCoverage(t.tests, builtins, idx)
+}
+
+// SummarizeCoverage reports the package functions called by the tests.
+// The functions called by each test are only listed when verbose.
+func (t *TestReporter) SummarizeCoverage(tests []*Test, builtins map[string]bool, idx *stdlibIndex) error {
+	exercised := make(map[string][]string)
+	seen := make(map[string]bool)
+	for _, test := range tests {
+		if t.verbosity > 0 && test.calls != nil {
+			fmt.Fprintf(t.out, "%s ... %s\n", test.FullName(), strings.Join(test.functions, ", "))
+		}
+		for _, name := range test.functions {
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			i := strings.LastIndexByte(name, '.')
+			exercised[name[:i]] = append(exercised[name[:i]], name[i+1:])
+		}
+	}
+
+	paths := make([]string, 0, len(exercised))
+	for path := range exercised {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var nbuiltins int
+	for _, b := range builtins {
+		if b {
+			nbuiltins++
+		}
+	}
+	fmt.Fprintf(t.out, "\ncoverage: %d functions exercised (%d builtins, %d Flux functions)\n\n",
+		len(seen), nbuiltins, len(seen)-nbuiltins)
+	for _, path := range paths {
+		total, err := idx.functions(path)
+		if err != nil {
+			return err
+		}
+		n := len(exercised[path])
+		if total < n {
+			// Internal functions, whose names start with an
+			// underscore, are not counted but may be called.
+			total = n
+		}
+		fmt.Fprintf(t.out, "\t%s: %d/%d (%.1f%%)\n", path, n, total, 100*float64(n)/float64(total))
+	}
+	return nil
+}
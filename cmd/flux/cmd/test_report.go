@@ -0,0 +1,249 @@
+package cmd
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/internal/errors"
+)
+
+// testReportWriters are the formats supported by --report.
+var testReportWriters = map[string]func(w io.Writer, tests []*Test) error{
+	"junit": writeJUnitReport,
+	"tap":   writeTAPReport,
+	"json":  writeJSONReport,
+}
+
+// testReport is a report of the test results written to a file.
+type testReport struct {
+	format string
+	path   string
+}
+
+// parseTestReports parses the values of --report, each of
+// which has the form format=path.
+func parseTestReports(specs []string) ([]testReport, error) {
+	reports := make([]testReport, 0, len(specs))
+	for _, spec := range specs {
+		format, path, ok := strings.Cut(spec, "=")
+		if !ok || path == "" {
+			return nil, errors.Newf(codes.Invalid, "report %q must have the form format=path", spec)
+		}
+		if _, ok := testReportWriters[format]; !ok {
+			return nil, errors.Newf(codes.Invalid, "unknown report format %q, valid formats are junit, tap and json", format)
+		}
+		reports = append(reports, testReport{format: format, path: path})
+	}
+	return reports, nil
+}
+
+func (r testReport) write(tests []*Test) error {
+	f, err := os.Create(r.path)
+	if err != nil {
+		return err
+	}
+	if err := testReportWriters[r.format](f, tests); err != nil {
+		_ = f.Close()
+		return errors.Wrapf(err, codes.Inherit, "failed to write %s report", r.format)
+	}
+	return f.Close()
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	File      string        `xml:"file,attr,omitempty"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *struct{}     `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// writeJUnitReport writes a JUnit XML report
+// with a test suite for each Flux package.
+func writeJUnitReport(w io.Writer, tests []*Test) error {
+	var (
+		report junitTestSuites
+		total  float64
+		suites = make(map[string]*junitTestSuite)
+		order  []string
+		times  = make(map[string]float64)
+	)
+	for _, test := range tests {
+		suite, ok := suites[test.pkg]
+		if !ok {
+			suite = &junitTestSuite{Name: test.pkg}
+			suites[test.pkg] = suite
+			order = append(order, test.pkg)
+		}
+		tc := junitTestCase{
+			Name:      test.name,
+			Classname: test.pkg,
+			File:      test.ast.Files[0].Name,
+			Time:      seconds(test.duration.Seconds()),
+		}
+		switch test.status() {
+		case "skipped":
+			tc.Skipped = &struct{}{}
+			suite.Skipped++
+		case "failed":
+			msg := test.err.Error()
+			first, _, _ := strings.Cut(msg, "\n")
+			tc.Failure = &junitFailure{Message: first, Text: msg}
+			suite.Failures++
+		case "updated":
+			tc.SystemOut = "want data updated"
+		}
+		if len(test.functions) > 0 {
+			if tc.SystemOut != "" {
+				tc.SystemOut += "\n"
+			}
+			tc.SystemOut += "functions: " + strings.Join(test.functions, ", ")
+		}
+		suite.Tests++
+		suite.Cases = append(suite.Cases, tc)
+		times[test.pkg] += test.duration.Seconds()
+		total += test.duration.Seconds()
+	}
+	for _, name := range order {
+		suite := suites[name]
+		suite.Time = seconds(times[name])
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Skipped += suite.Skipped
+		report.Suites = append(report.Suites, *suite)
+	}
+	report.Time = seconds(total)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func seconds(s float64) string {
+	return fmt.Sprintf("%.3f", s)
+}
+
+// writeTAPReport writes a report in the Test Anything Protocol version 13.
+func writeTAPReport(w io.Writer, tests []*Test) error {
+	var sb strings.Builder
+	sb.WriteString("TAP version 13\n")
+	fmt.Fprintf(&sb, "1..%d\n", len(tests))
+	for i, test := range tests {
+		name := test.pkg + "." + test.name
+		switch test.status() {
+		case "skipped":
+			fmt.Fprintf(&sb, "ok %d - %s # SKIP\n", i+1, name)
+		case "failed":
+			fmt.Fprintf(&sb, "not ok %d - %s\n", i+1, name)
+			sb.WriteString("  ---\n")
+			fmt.Fprintf(&sb, "  file: %q\n", test.ast.Files[0].Name)
+			sb.WriteString("  message: |\n")
+			for _, line := range strings.Split(strings.TrimRight(test.err.Error(), "\n"), "\n") {
+				sb.WriteString("    " + line + "\n")
+			}
+			sb.WriteString("  ...\n")
+		case "updated":
+			fmt.Fprintf(&sb, "ok %d - %s # want data updated\n", i+1, name)
+		default:
+			fmt.Fprintf(&sb, "ok %d - %s\n", i+1, name)
+		}
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+type jsonTestReport struct {
+	Summary jsonTestSummary `json:"summary"`
+	Tests   []jsonTest      `json:"tests"`
+}
+
+type jsonTestSummary struct {
+	Found   int `json:"found"`
+	Passed  int `json:"passed"`
+	Failed  int `json:"failed"`
+	Skipped int `json:"skipped"`
+	Updated int `json:"updated"`
+}
+
+type jsonTest struct {
+	Name      string   `json:"name"`
+	Package   string   `json:"package"`
+	File      string   `json:"file"`
+	Tags      []string `json:"tags,omitempty"`
+	Status    string   `json:"status"`
+	Duration  float64  `json:"duration"`
+	Error     string   `json:"error,omitempty"`
+	Functions []string `json:"functions,omitempty"`
+}
+
+// writeJSONReport writes a JSON report with the summary
+// and the result of every test. Durations are in seconds.
+func writeJSONReport(w io.Writer, tests []*Test) error {
+	report := jsonTestReport{
+		Summary: jsonTestSummary{Found: len(tests)},
+		Tests:   make([]jsonTest, 0, len(tests)),
+	}
+	for _, test := range tests {
+		jt := jsonTest{
+			Name:      test.name,
+			Package:   test.pkg,
+			File:      test.ast.Files[0].Name,
+			Tags:      test.tags,
+			Status:    test.status(),
+			Duration:  test.duration.Seconds(),
+			Functions: test.functions,
+		}
+		switch jt.Status {
+		case "skipped":
+			report.Summary.Skipped++
+		case "failed":
+			jt.Error = test.err.Error()
+			report.Summary.Failed++
+		case "updated":
+			report.Summary.Updated++
+		default:
+			report.Summary.Passed++
+		}
+		report.Tests = append(report.Tests, jt)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
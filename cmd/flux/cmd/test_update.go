@@ -0,0 +1,369 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/ast"
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/csv"
+	"github.com/InfluxCommunity/flux/internal/errors"
+)
+
+// updateResult is the name of the result that the
+// got stream of a test is yielded to by --update.
+const updateResult = "_result"
+
+// goldenUpdate is a rewrite of the string literal
+// that holds the want data of a test.
+type goldenUpdate struct {
+	file  string
+	lit   *ast.StringLiteral
+	value string
+}
+
+// prepareUpdate runs the got stream of the testing.diff call of the
+// test and returns the rewrite of the want data to match its output.
+func (t *Test) prepareUpdate(executor TestExecutor) (*goldenUpdate, error) {
+	if !t.updatable {
+		return nil, errors.New(codes.Unimplemented, "only tests read from the filesystem can be updated")
+	}
+	file := t.ast.Files[0]
+	index, got, want, err := findDiff(file)
+	if err != nil {
+		return nil, err
+	}
+	lit, err := resolveWantData(file.Body[:index], want)
+	if err != nil {
+		return nil, err
+	}
+	if lit.Loc == nil || lit.Loc.File != file.Name {
+		return nil, errors.Newf(codes.Unimplemented, "want data is not defined in %s", file.Name)
+	}
+
+	// Replace the diff with a yield of the got stream.
+	pkg := t.ast.Copy().(*ast.Package)
+	pkg.Files[0].Body[index] = &ast.ExpressionStatement{
+		Expression: &ast.PipeExpression{
+			Argument: got,
+			Call: &ast.CallExpression{
+				Callee: &ast.Identifier{Name: "yield"},
+				Arguments: []ast.Expression{&ast.ObjectExpression{
+					Properties: []*ast.Property{{
+						Key:   &ast.Identifier{Name: "name"},
+						Value: &ast.StringLiteral{Value: updateResult},
+					}},
+				}},
+			},
+		},
+	}
+
+	var (
+		buf   strings.Builder
+		found bool
+	)
+	if err := executor.Run(pkg, func(ctx context.Context, results flux.ResultIterator) error {
+		defer results.Release()
+		for results.More() {
+			res := results.Next()
+			if res.Name() != updateResult {
+				if err := res.Tables().Do(func(flux.Table) error { return nil }); err != nil {
+					return err
+				}
+				continue
+			}
+			found = true
+			if _, err := csv.NewResultEncoder(csv.DefaultEncoderConfig()).Encode(&buf, res); err != nil {
+				return err
+			}
+		}
+		return results.Err()
+	}); err != nil {
+		return nil, errors.Wrap(err, codes.Inherit, "failed to run got")
+	}
+	if !found {
+		return nil, errors.New(codes.Internal, "got did not produce a result")
+	}
+
+	// Match the layout of the want data in the test files,
+	// which starts and ends with a newline.
+	value := strings.ReplaceAll(buf.String(), "\r\n", "\n")
+	value = "\n" + strings.TrimRight(value, "\n") + "\n"
+	if value == lit.Value {
+		return nil, errors.New(codes.FailedPrecondition, "want data already matches the output of got")
+	}
+	return &goldenUpdate{file: file.Name, lit: lit, value: value}, nil
+}
+
+// findDiff finds the last call to testing.diff in the file and returns
+// the index of its statement and its got and want expressions.
+func findDiff(file *ast.File) (int, ast.Expression, ast.Expression, error) {
+	testing := "testing"
+	for _, imp := range file.Imports {
+		if imp.Path.Value == "testing" && imp.As != nil {
+			testing = imp.As.Name
+		}
+	}
+
+	for i := len(file.Body) - 1; i >= 0; i-- {
+		stmt, ok := file.Body[i].(*ast.ExpressionStatement)
+		if !ok {
+			continue
+		}
+		call, pipe := diffCall(stmt.Expression, testing)
+		if call == nil {
+			continue
+		}
+		got := callArgument(call, "got")
+		if pipe != nil {
+			got = pipe
+		}
+		want := callArgument(call, "want")
+		if got == nil || want == nil {
+			return 0, nil, nil, errors.New(codes.Invalid, "testing.diff must be called with got and want")
+		}
+		return i, got, want, nil
+	}
+	return 0, nil, nil, errors.New(codes.NotFound, "test does not call testing.diff")
+}
+
+// diffCall returns the testing.diff call of the expression and the
+// expression piped into it, if any. The diff may be piped to yield.
+func diffCall(expr ast.Expression, testing string) (*ast.CallExpression, ast.Expression) {
+	switch e := expr.(type) {
+	case *ast.CallExpression:
+		if isMemberCall(e, testing, "diff") {
+			return e, nil
+		}
+	case *ast.PipeExpression:
+		if isMemberCall(e.Call, testing, "diff") {
+			return e.Call, e.Argument
+		}
+		if id, ok := e.Call.Callee.(*ast.Identifier); ok && id.Name == "yield" {
+			return diffCall(e.Argument, testing)
+		}
+	}
+	return nil, nil
+}
+
+func isMemberCall(call *ast.CallExpression, pkg, name string) bool {
+	m, ok := call.Callee.(*ast.MemberExpression)
+	if !ok {
+		return false
+	}
+	obj, ok := m.Object.(*ast.Identifier)
+	return ok && obj.Name == pkg && m.Property.Key() == name
+}
+
+// callArgument returns the value of the named argument of a call.
+func callArgument(call *ast.CallExpression, name string) ast.Expression {
+	if len(call.Arguments) == 0 {
+		return nil
+	}
+	obj, ok := call.Arguments[0].(*ast.ObjectExpression)
+	if !ok {
+		return nil
+	}
+	for _, p := range obj.Properties {
+		if p.Key.Key() != name {
+			continue
+		}
+		if p.Value == nil {
+			// The property uses the shorthand syntax.
+			return &ast.Identifier{Name: name}
+		}
+		return p.Value
+	}
+	return nil
+}
+
+// resolveWantData resolves the want expression to the string literal
+// passed as the csv argument of a call such as csv.from(csv: data).
+// Identifiers are resolved from the variable assignments in body.
+func resolveWantData(body []ast.Statement, want ast.Expression) (*ast.StringLiteral, error) {
+	call, ok := resolveIdentifier(body, want).(*ast.CallExpression)
+	if !ok {
+		return nil, errors.New(codes.Unimplemented, "want must be a call with a csv argument such as csv.from(csv: data)")
+	}
+	data := callArgument(call, "csv")
+	if data == nil {
+		return nil, errors.New(codes.Unimplemented, "want must be a call with a csv argument such as csv.from(csv: data)")
+	}
+	lit, ok := resolveIdentifier(body, data).(*ast.StringLiteral)
+	if !ok {
+		return nil, errors.New(codes.Unimplemented, "the csv argument of want must be a string literal")
+	}
+	return lit, nil
+}
+
+// resolveIdentifier returns the value last assigned to an identifier
+// in body or the expression itself if it is not an identifier.
+func resolveIdentifier(body []ast.Statement, expr ast.Expression) ast.Expression {
+	for seen := 0; seen < len(body); seen++ {
+		id, ok := expr.(*ast.Identifier)
+		if !ok {
+			return expr
+		}
+		var init ast.Expression
+		for i := len(body) - 1; i >= 0 && init == nil; i-- {
+			if va, ok := body[i].(*ast.VariableAssignment); ok && va.ID.Name == id.Name {
+				init = va.Init
+			}
+		}
+		if init == nil {
+			return expr
+		}
+		expr = init
+	}
+	return expr
+}
+
+// ApplyUpdates rewrites the want data of the tests that were updated.
+// Want data that is shared by several tests is only rewritten when all
+// of them produced the same output, otherwise the tests are marked as
+// failed instead.
+func (t *TestRunner) ApplyUpdates() error {
+	var (
+		literals []*ast.StringLiteral
+		byLit    = make(map[*ast.StringLiteral][]*Test)
+	)
+	for _, test := range t.tests {
+		if test.update == nil {
+			continue
+		}
+		lit := test.update.lit
+		if _, ok := byLit[lit]; !ok {
+			literals = append(literals, lit)
+		}
+		byLit[lit] = append(byLit[lit], test)
+	}
+	// Passing tests that share want data with an updated
+	// test would fail after the rewrite.
+	passing := make(map[*ast.StringLiteral]*Test)
+	for _, test := range t.tests {
+		if test.skip || test.err != nil || test.update != nil {
+			continue
+		}
+		file := test.ast.Files[0]
+		if index, _, want, err := findDiff(file); err == nil {
+			if lit, err := resolveWantData(file.Body[:index], want); err == nil {
+				passing[lit] = test
+			}
+		}
+	}
+
+	updates := make(map[string][]*goldenUpdate)
+	for _, lit := range literals {
+		tests := byLit[lit]
+		var err error
+		if other, ok := passing[lit]; ok {
+			err = errors.Newf(codes.FailedPrecondition, "want data at %s is shared with the passing test %s", lit.Loc, other.FullName())
+		}
+		for _, test := range tests[1:] {
+			if test.update.value != tests[0].update.value {
+				err = errors.Newf(codes.FailedPrecondition, "tests %s and %s share want data at %s but produced different output",
+					tests[0].FullName(), test.FullName(), lit.Loc)
+				break
+			}
+		}
+		if err != nil {
+			for _, test := range tests {
+				test.err = err
+			}
+			continue
+		}
+		update := tests[0].update
+		updates[update.file] = append(updates[update.file], update)
+	}
+	for file, updates := range updates {
+		if err := rewriteFile(file, updates); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rewriteFile replaces the string literals of the updates in the file.
+func rewriteFile(file string, updates []*goldenUpdate) error {
+	info, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+	src, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	type edit struct {
+		start, end int
+		text       string
+	}
+	edits := make([]edit, 0, len(updates))
+	for _, u := range updates {
+		start, end, err := literalOffsets(src, u.lit)
+		if err != nil {
+			return errors.Wrapf(err, codes.Inherit, "cannot update %s", file)
+		}
+		edits = append(edits, edit{start: start, end: end, text: quoteString(u.value)})
+	}
+	// Apply the edits from the end of the file so that
+	// the offsets of the remaining edits stay valid.
+	sort.Slice(edits, func(i, j int) bool {
+		return edits[i].start > edits[j].start
+	})
+	for _, e := range edits {
+		src = append(src[:e.start:e.start], append([]byte(e.text), src[e.end:]...)...)
+	}
+	return os.WriteFile(file, src, info.Mode())
+}
+
+// literalOffsets returns the byte offsets of a string literal in src.
+func literalOffsets(src []byte, lit *ast.StringLiteral) (int, int, error) {
+	start, ok := positionOffset(src, lit.Loc.Start)
+	if !ok {
+		return 0, 0, errors.Newf(codes.Internal, "invalid location %s", lit.Loc)
+	}
+	end, ok := positionOffset(src, lit.Loc.End)
+	if !ok || end < start {
+		return 0, 0, errors.Newf(codes.Internal, "invalid location %s", lit.Loc)
+	}
+	text := string(src[start:end])
+	if (lit.Loc.Source != "" && text != lit.Loc.Source) || !strings.HasPrefix(text, `"`) || !strings.HasSuffix(text, `"`) {
+		return 0, 0, errors.Newf(codes.FailedPrecondition, "file changed since the test was read, the string literal at %s was not found", lit.Loc)
+	}
+	return start, end, nil
+}
+
+// positionOffset converts a line and column,
+// both starting at 1, to a byte offset in src.
+func positionOffset(src []byte, pos ast.Position) (int, bool) {
+	offset := 0
+	for line := 1; line < pos.Line; line++ {
+		i := bytes.IndexByte(src[offset:], '\n')
+		if i < 0 {
+			return 0, false
+		}
+		offset += i + 1
+	}
+	for col := 1; col < pos.Column; col++ {
+		if offset >= len(src) {
+			return 0, false
+		}
+		_, size := utf8.DecodeRune(src[offset:])
+		offset += size
+	}
+	return offset, true
+}
+
+// quoteString returns a Flux string literal with the value.
+// Flux string literals may span multiple lines, so only quotes,
+// backslashes and the start of interpolations are escaped.
+func quoteString(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "${", `\${`)
+	return `"` + r.Replace(s) + `"`
+}
@@ -2,14 +2,12 @@ package main
 
 import (
 	"context"
-	"fmt"
 	"os"
 
 	"github.com/InfluxCommunity/flux"
-	"github.com/InfluxCommunity/flux/csv"
-	"github.com/InfluxCommunity/flux/execute"
 	"github.com/InfluxCommunity/flux/lang"
 	"github.com/InfluxCommunity/flux/memory"
+	"github.com/InfluxCommunity/flux/repl"
 	"github.com/InfluxCommunity/flux/runtime"
 )
 
@@ -31,24 +29,8 @@ func executeE(ctx context.Context, script, format string) error {
 	results := flux.NewResultIteratorFromQuery(q)
 	defer results.Release()
 
-	if format == "cli" {
-		for results.More() {
-			res := results.Next()
-			fmt.Println("Result:", res.Name())
-			if err := res.Tables().Do(func(table flux.Table) error {
-				_, err := execute.NewFormatter(table, nil).WriteTo(os.Stdout)
-				return err
-			}); err != nil {
-				return err
-			}
-		}
-	} else if format == "csv" {
-		config := csv.DefaultEncoderConfig()
-		encoder := csv.NewMultiResultEncoder(config)
-		_, err := encoder.Encode(os.Stdout, results)
-		if err != nil {
-			return err
-		}
+	if err := repl.WriteResults(os.Stdout, results, format); err != nil {
+		return err
 	}
 	results.Release()
 	return results.Err()
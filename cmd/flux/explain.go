@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"os"
+	"time"
+
+	fluxcmd "github.com/InfluxCommunity/flux/cmd/flux/cmd"
+	"github.com/InfluxCommunity/flux/fluxinit"
+	"github.com/InfluxCommunity/flux/lang"
+	"github.com/InfluxCommunity/flux/memory"
+	"github.com/InfluxCommunity/flux/runtime"
+	"github.com/spf13/cobra"
+)
+
+var explainFlags struct {
+	Analyze bool
+}
+
+func explainE(cmd *cobra.Command, args []string) error {
+	script := args[0]
+	if !flags.ExecScript {
+		content, err := os.ReadFile(args[0])
+		if err != nil {
+			return err
+		}
+		script = string(content)
+	}
+
+	ctx, close, err := configureTracing(context.Background())
+	if err != nil {
+		return err
+	}
+	defer close()
+
+	fluxinit.FluxInit()
+	ctx, span, err := injectDependencies(ctx)
+	if err != nil {
+		return err
+	}
+	defer span.Finish()
+
+	ctx, err = fluxcmd.WithFeatureFlags(ctx, flags.Features)
+	if err != nil {
+		return err
+	}
+
+	prog, err := lang.Compile(ctx, script, runtime.Default, time.Now())
+	if err != nil {
+		return err
+	}
+	return prog.Explain(ctx, os.Stdout, &memory.ResourceAllocator{}, explainFlags.Analyze)
+}
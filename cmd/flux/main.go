@@ -4,17 +4,18 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
+	"time"
 
 	fluxcmd "github.com/InfluxCommunity/flux/cmd/flux/cmd"
-	"github.com/InfluxCommunity/flux/codes"
 	"github.com/InfluxCommunity/flux/dependencies"
+	"github.com/InfluxCommunity/flux/dependencies/http"
+	"github.com/InfluxCommunity/flux/dependencies/url"
 	"github.com/InfluxCommunity/flux/dependency"
 	"github.com/InfluxCommunity/flux/fluxinit"
-	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/internal/scheduler"
 	"github.com/InfluxCommunity/flux/repl"
-	"github.com/opentracing/opentracing-go"
 	"github.com/spf13/cobra"
-	jaegercfg "github.com/uber/jaeger-client-go/config"
 
 	// Include the sqlite3 driver for vanilla Flux
 	_ "github.com/mattn/go-sqlite3"
@@ -23,9 +24,16 @@ import (
 var flags struct {
 	ExecScript        bool
 	Trace             string
+	OTLPProtocol      string
+	OTLPEndpoint      string
+	TraceFile         string
 	Format            string
 	Features          string
 	EnableSuggestions bool
+	HTTPMaxAttempts   int
+	HTTPRateLimit     float64
+	HTTPRateBurst     int
+	URLPolicy         string
 }
 
 func runE(cmd *cobra.Command, args []string) error {
@@ -52,7 +60,10 @@ func runE(cmd *cobra.Command, args []string) error {
 	// have already passed to avoid a long load time
 	// for a simple unrelated error.
 	fluxinit.FluxInit()
-	ctx, span := injectDependencies(ctx)
+	ctx, span, err := injectDependencies(ctx)
+	if err != nil {
+		return err
+	}
 	defer span.Finish()
 
 	ctx, err = fluxcmd.WithFeatureFlags(ctx, flags.Features)
@@ -60,10 +71,13 @@ func runE(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	var opts []repl.Option
+	opts := []repl.Option{repl.WithFormat(flags.Format)}
 	if flags.EnableSuggestions {
 		opts = append(opts, repl.EnableSuggestions())
 	}
+	if home, err := os.UserHomeDir(); err == nil {
+		opts = append(opts, repl.WithHistoryFile(filepath.Join(home, ".flux_history")))
+	}
 
 	if len(args) == 0 {
 		return replE(ctx, opts...)
@@ -71,43 +85,37 @@ func runE(cmd *cobra.Command, args []string) error {
 	return executeE(ctx, script, flags.Format)
 }
 
-func configureTracing(ctx context.Context) (context.Context, func(), error) {
-	if flags.Trace == "" {
-		return ctx, func() {}, nil
-	} else if flags.Trace != "jaeger" {
-		return nil, nil, errors.Newf(codes.Invalid, "unknown tracer name: %s", flags.Trace)
-	}
-
-	cfg, err := jaegercfg.FromEnv()
-	if err != nil {
-		return nil, nil, err
-	}
-	if cfg.ServiceName == "" {
-		cfg.ServiceName = "flux"
-	}
-	if cfg.Sampler.Type == "" {
-		cfg.Sampler.Type = "const"
-		cfg.Sampler.Param = 1.0
-	}
+const DefaultInfluxDBHost = "http://localhost:9999"
 
-	tracer, closer, err := cfg.NewTracer()
+func injectDependencies(ctx context.Context) (context.Context, *dependency.Span, error) {
+	deps, err := newDependencies(url.PassValidator{})
 	if err != nil {
 		return nil, nil, err
 	}
-
-	opentracing.SetGlobalTracer(tracer)
-	return ctx, func() {
-		if err := closer.Close(); err != nil {
-			fmt.Printf("error closing tracer: %s.\n", err)
-		}
-	}, nil
+	ctx, span := dependency.Inject(ctx, deps)
+	return ctx, span, nil
 }
 
-const DefaultInfluxDBHost = "http://localhost:9999"
-
-func injectDependencies(ctx context.Context) (context.Context, *dependency.Span) {
+// newDependencies creates the dependencies configured by the flags.
+// URLs are validated with the policy of the --url-policy flag when
+// it is set and with the validator otherwise.
+func newDependencies(validator url.Validator) (dependencies.Dependencies, error) {
 	deps := dependencies.NewDefaultDependencies(DefaultInfluxDBHost)
-	return dependency.Inject(ctx, deps)
+	if flags.URLPolicy != "" {
+		v, err := url.LoadPolicyValidator(flags.URLPolicy)
+		if err != nil {
+			return dependencies.Dependencies{}, err
+		}
+		validator = v
+	}
+	deps.Deps.Deps.URLValidator = validator
+	var client http.Client = http.NewLimitedDefaultClient(validator)
+	policy := http.DefaultRetryPolicy()
+	policy.MaxAttempts = flags.HTTPMaxAttempts
+	client = http.WithRetry(client, policy)
+	client = http.WithRateLimit(client, flags.HTTPRateLimit, flags.HTTPRateBurst)
+	deps.Deps.Deps.HTTPClient = client
+	return deps, nil
 }
 
 func main() {
@@ -120,9 +128,16 @@ func main() {
 	}
 	fluxCmd.Flags().BoolVarP(&flags.ExecScript, "exec", "e", false, "Interpret file argument as a raw flux script")
 	fluxCmd.Flags().BoolVarP(&flags.EnableSuggestions, "enable-suggestions", "", false, "enable suggestions in the repl")
-	fluxCmd.Flags().StringVar(&flags.Trace, "trace", "", "Trace query execution")
-	fluxCmd.Flags().StringVarP(&flags.Format, "format", "", "cli", "Output format one of: cli,csv. Defaults to cli")
-	fluxCmd.Flag("trace").NoOptDefVal = "jaeger"
+	fluxCmd.PersistentFlags().StringVar(&flags.Trace, "trace", "", "Trace query execution and export its telemetry, one of: jaeger,otlp,file")
+	fluxCmd.Flags().StringVarP(&flags.Format, "format", "", "cli", "Output format one of: cli,csv,json. Defaults to cli")
+	fluxCmd.PersistentFlags().Lookup("trace").NoOptDefVal = "jaeger"
+	fluxCmd.PersistentFlags().StringVar(&flags.OTLPProtocol, "otlp-protocol", "http", "Protocol used to export telemetry with --trace otlp, one of: http,grpc")
+	fluxCmd.PersistentFlags().StringVar(&flags.OTLPEndpoint, "otlp-endpoint", "", "URL of the collector telemetry is exported to with --trace otlp. Defaults to the OTEL_EXPORTER_OTLP_ENDPOINT environment variable")
+	fluxCmd.PersistentFlags().StringVar(&flags.TraceFile, "trace-file", "", "Path to the file telemetry is written to with --trace file")
+	fluxCmd.PersistentFlags().IntVar(&flags.HTTPMaxAttempts, "http-max-attempts", 1, "Maximum number of attempts for HTTP requests that fail with a transient error. One disables retries")
+	fluxCmd.PersistentFlags().Float64Var(&flags.HTTPRateLimit, "http-rate-limit", 0, "Maximum number of HTTP requests per second to a single host. Zero means no limit")
+	fluxCmd.PersistentFlags().IntVar(&flags.HTTPRateBurst, "http-rate-burst", 1, "Number of HTTP requests to a single host allowed to exceed the rate limit in a burst")
+	fluxCmd.PersistentFlags().StringVar(&flags.URLPolicy, "url-policy", "", "Path to a YAML file with the policy restricting the URLs that may be reached")
 	fluxCmd.Flags().StringVar(&flags.Features, "features", "", "JSON object specifying the features to execute with. See internal/feature/flags.yml for a list of the current features")
 
 	fmtCmd := &cobra.Command{
@@ -139,6 +154,82 @@ func main() {
 	testCmd := fluxcmd.TestCommand(NewTestExecutor)
 	fluxCmd.AddCommand(testCmd)
 
+	serveCmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve an HTTP query API",
+		Long:  "Serve an HTTP query API that is compatible with the InfluxDB /api/v2/query endpoint",
+		Args:  cobra.NoArgs,
+		RunE:  serveE,
+	}
+	serveCmd.Flags().StringVar(&serveFlags.Addr, "addr", "127.0.0.1:8086", "Address to listen on for HTTP requests")
+	serveCmd.Flags().StringVar(&serveFlags.Token, "token", "", "Token that query requests must be authorized with in an \"Authorization: Token <token>\" header. Defaults to the FLUX_SERVE_TOKEN environment variable")
+	serveCmd.Flags().BoolVar(&serveFlags.AllowFilesystem, "allow-filesystem", false, "Allow queries to read and write local files")
+	serveCmd.Flags().BoolVar(&serveFlags.AllowPrivateIPs, "allow-private-ips", false, "Allow queries to reach private and loopback addresses. Ignored when --url-policy is set")
+	serveCmd.Flags().IntVar(&serveFlags.ConcurrencyQuota, "query-concurrency", 10, "Number of queries allowed to execute concurrently")
+	serveCmd.Flags().IntVar(&serveFlags.QueueSize, "query-queue-size", 10, "Number of queries allowed to wait for execution. Queries are rejected when the queue is full")
+	serveCmd.Flags().Int64Var(&serveFlags.InitialMemoryBytesQuota, "query-initial-memory-bytes", 0, "Number of bytes of memory each query is guaranteed when it starts executing")
+	serveCmd.Flags().Int64Var(&serveFlags.MemoryBytesQuotaPerQuery, "query-memory-bytes", 0, "Maximum number of bytes of memory a single query may use. Zero means no limit")
+	serveCmd.Flags().Int64Var(&serveFlags.MaxMemoryBytes, "query-max-memory-bytes", 0, "Maximum number of bytes of memory all executing queries may use. Zero means no limit")
+	serveCmd.Flags().StringVar(&flags.Features, "features", "", "JSON object specifying the features to execute with. See internal/feature/flags.yml for a list of the current features")
+	fluxCmd.AddCommand(serveCmd)
+
+	explainCmd := &cobra.Command{
+		Use:   "explain [flags] script.flux",
+		Short: "Explain how a query is planned",
+		Long:  "Explain how a query is planned by writing its logical plan, its physical plan and the planner rules that were applied",
+		Args:  cobra.ExactArgs(1),
+		RunE:  explainE,
+	}
+	explainCmd.Flags().BoolVarP(&explainFlags.Analyze, "analyze", "a", false, "Execute the query and include the runtime statistics of each node in the plan")
+	explainCmd.Flags().BoolVarP(&flags.ExecScript, "exec", "e", false, "Interpret file argument as a raw flux script")
+	explainCmd.Flags().StringVar(&flags.Features, "features", "", "JSON object specifying the features to execute with. See internal/feature/flags.yml for a list of the current features")
+	fluxCmd.AddCommand(explainCmd)
+
+	watchCmd := &cobra.Command{
+		Use:   "watch [flags] script.flux",
+		Short: "Run a query continuously",
+		Long:  "Run a query every interval with now set to the time of the run and write only the rows that were not written by earlier runs",
+		Args:  cobra.ExactArgs(1),
+		RunE:  watchE,
+	}
+	watchCmd.Flags().DurationVar(&watchFlags.Every, "every", 10*time.Second, "Interval the query is run on")
+	watchCmd.Flags().BoolVar(&watchFlags.Anchor, "anchor", false, "Keep the start of ranges relative to now at its time on the first run so that state carries across runs. Otherwise the whole range moves with now")
+	watchCmd.Flags().Int64Var(&watchFlags.CacheBytes, "cache-bytes", 256<<20, "Maximum number of bytes of source output kept between runs. Zero means no limit")
+	watchCmd.Flags().DurationVar(&watchFlags.CacheTTL, "cache-ttl", 10*time.Minute, "Age after which the cached source output is read again in full. Zero means it does not expire")
+	watchCmd.Flags().DurationVar(&watchFlags.LateData, "late-data", time.Minute, "Trailing window of the cached source output that is read again on every run to pick up late data")
+	watchCmd.Flags().BoolVarP(&flags.ExecScript, "exec", "e", false, "Interpret file argument as a raw flux script")
+	watchCmd.Flags().StringVarP(&flags.Format, "format", "", "cli", "Output format one of: cli,csv,json. Defaults to cli")
+	watchCmd.Flags().StringVar(&flags.Features, "features", "", "JSON object specifying the features to execute with. See internal/feature/flags.yml for a list of the current features")
+	fluxCmd.AddCommand(watchCmd)
+
+	tasksCmd := &cobra.Command{
+		Use:   "tasks",
+		Short: "Run Flux tasks on their schedule",
+		Long:  "Run the Flux scripts in a directory that set option task on the schedule the option describes",
+	}
+	tasksCmd.PersistentFlags().StringVar(&tasksFlags.StateDir, "state-dir", "", "Directory the run history of the tasks is kept in. Defaults to "+defaultTaskStateDir+" within the directory of the tasks")
+	tasksCmd.PersistentFlags().IntVar(&tasksFlags.MaxRuns, "max-runs", scheduler.DefaultMaxRuns, "Number of runs kept in the history of each task")
+	tasksRunCmd := &cobra.Command{
+		Use:   "run [flags] directory",
+		Short: "Schedule the tasks in a directory",
+		Args:  cobra.ExactArgs(1),
+		RunE:  tasksRunE,
+	}
+	tasksRunCmd.Flags().IntVar(&tasksFlags.MaxRetries, "max-retries", 3, "Number of times a failed run is retried")
+	tasksRunCmd.Flags().DurationVar(&tasksFlags.RetryInterval, "retry-interval", scheduler.DefaultRetryInterval, "Time waited before retrying a failed run. It doubles with every retry")
+	tasksRunCmd.Flags().StringVar(&flags.Features, "features", "", "JSON object specifying the features to execute with. See internal/feature/flags.yml for a list of the current features")
+	tasksCmd.AddCommand(tasksRunCmd)
+	tasksLogsCmd := &cobra.Command{
+		Use:   "logs [flags] directory",
+		Short: "Show the runs and logs of the tasks in a directory",
+		Args:  cobra.ExactArgs(1),
+		RunE:  tasksLogsE,
+	}
+	tasksLogsCmd.Flags().StringVar(&tasksFlags.Task, "task", "", "Name of the task to show. Defaults to all of the tasks")
+	tasksLogsCmd.Flags().IntVar(&tasksFlags.Limit, "limit", 10, "Number of most recent runs to show for each task. Zero shows all of them")
+	tasksCmd.AddCommand(tasksLogsCmd)
+	fluxCmd.AddCommand(tasksCmd)
+
 	if err := fluxCmd.Execute(); err != nil {
 		if _, ok := err.(silentError); !ok {
 			fmt.Fprintln(fluxCmd.OutOrStderr(), err)
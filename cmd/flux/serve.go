@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/InfluxCommunity/flux"
+	fluxcmd "github.com/InfluxCommunity/flux/cmd/flux/cmd"
+	"github.com/InfluxCommunity/flux/controller"
+	"github.com/InfluxCommunity/flux/csv"
+	"github.com/InfluxCommunity/flux/dependencies"
+	"github.com/InfluxCommunity/flux/dependencies/url"
+	"github.com/InfluxCommunity/flux/dependency"
+	"github.com/InfluxCommunity/flux/fluxinit"
+	"github.com/InfluxCommunity/flux/runtime"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// shutdownTimeout is how long running queries are given
+// to finish when the server is stopped.
+const shutdownTimeout = 30 * time.Second
+
+var serveFlags struct {
+	Addr                     string
+	Token                    string
+	AllowFilesystem          bool
+	AllowPrivateIPs          bool
+	ConcurrencyQuota         int
+	QueueSize                int
+	InitialMemoryBytesQuota  int64
+	MemoryBytesQuotaPerQuery int64
+	MaxMemoryBytes           int64
+}
+
+func serveE(cmd *cobra.Command, args []string) error {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	ctx, close, err := configureTracing(ctx)
+	if err != nil {
+		return err
+	}
+	defer close()
+
+	logger, err := zap.NewProduction()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = logger.Sync() }()
+
+	fluxinit.FluxInit()
+
+	// The dependencies are created once so that state such as
+	// the rate limits is shared by all of the queries.
+	deps, err := newServeDependencies()
+	if err != nil {
+		return err
+	}
+	if _, err := fluxcmd.WithFeatureFlags(ctx, flags.Features); err != nil {
+		return err
+	}
+	inject := func(ctx context.Context) (context.Context, *dependency.Span, error) {
+		ctx, span := dependency.Inject(ctx, deps)
+		ctx, err := fluxcmd.WithFeatureFlags(ctx, flags.Features)
+		if err != nil {
+			span.Finish()
+			return nil, nil, err
+		}
+		return ctx, span, nil
+	}
+
+	ctrl, err := controller.New(controller.Config{
+		ConcurrencyQuota:                serveFlags.ConcurrencyQuota,
+		QueueSize:                       serveFlags.QueueSize,
+		InitialMemoryBytesQuotaPerQuery: serveFlags.InitialMemoryBytesQuota,
+		MemoryBytesQuotaPerQuery:        serveFlags.MemoryBytesQuotaPerQuery,
+		MaxMemoryBytes:                  serveFlags.MaxMemoryBytes,
+	}, runtime.Default)
+	if err != nil {
+		return err
+	}
+	dialects := make(flux.DialectMappings)
+	if err := csv.AddDialectMappings(dialects); err != nil {
+		return err
+	}
+	handler, err := fluxcmd.NewQueryHandler(ctrl, inject, dialects, logger)
+	if err != nil {
+		return err
+	}
+	token := serveFlags.Token
+	if token == "" {
+		token = os.Getenv("FLUX_SERVE_TOKEN")
+	}
+	if token != "" {
+		handler.RequireToken(token)
+	} else {
+		logger.Warn("Serving queries without authentication, use --token to require a token")
+	}
+	server := &http.Server{
+		Addr:              serveFlags.Addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		// Requests use the context with the tracer. It is not canceled
+		// by a signal so running queries can finish during shutdown.
+		BaseContext: func(net.Listener) context.Context {
+			return context.WithoutCancel(ctx)
+		},
+	}
+
+	errC := make(chan error, 1)
+	go func() {
+		logger.Info("Listening", zap.String("addr", serveFlags.Addr))
+		errC <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errC:
+		return err
+	case <-ctx.Done():
+	}
+
+	logger.Info("Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := ctrl.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errC; err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// newServeDependencies creates the dependencies of the served queries.
+// The queries come from the network so, unless the flags allow it,
+// they cannot access local files or reach private addresses.
+func newServeDependencies() (dependencies.Dependencies, error) {
+	var validator url.Validator = url.PrivateIPValidator{}
+	if serveFlags.AllowPrivateIPs {
+		validator = url.PassValidator{}
+	}
+	deps, err := newDependencies(validator)
+	if err != nil {
+		return dependencies.Dependencies{}, err
+	}
+	if !serveFlags.AllowFilesystem {
+		deps.Deps.Deps.FilesystemService = nil
+	}
+	return deps, nil
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/ast"
+	fluxcmd "github.com/InfluxCommunity/flux/cmd/flux/cmd"
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/dependencies"
+	"github.com/InfluxCommunity/flux/dependencies/url"
+	"github.com/InfluxCommunity/flux/dependency"
+	"github.com/InfluxCommunity/flux/fluxinit"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/internal/scheduler"
+	"github.com/InfluxCommunity/flux/lang"
+	"github.com/InfluxCommunity/flux/memory"
+	"github.com/InfluxCommunity/flux/runtime"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// defaultTaskStateDir is the directory within the directory of
+// the tasks where their state is kept unless --state-dir is set.
+const defaultTaskStateDir = ".flux-tasks"
+
+var tasksFlags struct {
+	StateDir      string
+	MaxRetries    int
+	RetryInterval time.Duration
+	MaxRuns       int
+	Task          string
+	Limit         int
+}
+
+func taskStateDir(dir string) string {
+	if tasksFlags.StateDir != "" {
+		return tasksFlags.StateDir
+	}
+	return filepath.Join(dir, defaultTaskStateDir)
+}
+
+func tasksRunE(cmd *cobra.Command, args []string) error {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	ctx, close, err := configureTracing(ctx)
+	if err != nil {
+		return err
+	}
+	defer close()
+
+	logger, err := zap.NewProduction()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = logger.Sync() }()
+
+	fluxinit.FluxInit()
+
+	tasks, err := scheduler.LoadTasks(args[0])
+	if err != nil {
+		return err
+	}
+	store, err := scheduler.OpenStore(taskStateDir(args[0]), tasksFlags.MaxRuns)
+	if err != nil {
+		return err
+	}
+
+	// The dependencies are created once so that state such as
+	// the rate limits is shared by all of the runs.
+	deps, err := newDependencies(url.PassValidator{})
+	if err != nil {
+		return err
+	}
+	if _, err := fluxcmd.WithFeatureFlags(ctx, flags.Features); err != nil {
+		return err
+	}
+
+	s := scheduler.New(store, newTaskRunner(deps), logger)
+	s.MaxRetries = tasksFlags.MaxRetries
+	s.RetryInterval = tasksFlags.RetryInterval
+	for _, t := range tasks {
+		logger.Info("Scheduling task",
+			zap.String("task", t.Name),
+			zap.String("path", t.Path),
+			zap.Duration("every", t.Every),
+			zap.String("cron", t.Cron),
+			zap.Duration("offset", t.Offset),
+		)
+	}
+	return s.Run(ctx, tasks)
+}
+
+// newTaskRunner returns a function that runs a task with now set to the
+// time the run was scheduled for and reads the results of the script.
+func newTaskRunner(deps dependencies.Dependencies) scheduler.RunFunc {
+	return func(ctx context.Context, req scheduler.RunRequest) error {
+		ctx, span := dependency.Inject(ctx, deps)
+		defer span.Finish()
+		ctx, err := fluxcmd.WithFeatureFlags(ctx, flags.Features)
+		if err != nil {
+			return err
+		}
+
+		pkg := &ast.Package{
+			Package: "main",
+			Files:   []*ast.File{req.Task.File(req.LastSuccessTime)},
+		}
+		data, err := json.Marshal(pkg)
+		if err != nil {
+			return err
+		}
+		hdl, err := runtime.Default.JSONToHandle(data)
+		if err != nil {
+			return err
+		}
+		prog := lang.CompileAST(hdl, runtime.Default, req.ScheduledFor)
+		q, err := prog.Start(ctx, &memory.ResourceAllocator{})
+		if err != nil {
+			return err
+		}
+
+		results := flux.NewResultIteratorFromQuery(q)
+		defer results.Release()
+		for results.More() {
+			res := results.Next()
+			var tables, rows int
+			if err := res.Tables().Do(func(tbl flux.Table) error {
+				tables++
+				return tbl.Do(func(cr flux.ColReader) error {
+					rows += cr.Len()
+					return nil
+				})
+			}); err != nil {
+				return err
+			}
+			req.Log(fmt.Sprintf("Result %q: %d tables, %d rows", res.Name(), tables, rows))
+		}
+		results.Release()
+		return results.Err()
+	}
+}
+
+func tasksLogsE(cmd *cobra.Command, args []string) error {
+	store, err := scheduler.OpenStore(taskStateDir(args[0]), tasksFlags.MaxRuns)
+	if err != nil {
+		return err
+	}
+	names := []string{tasksFlags.Task}
+	if tasksFlags.Task == "" {
+		if names, err = store.Tasks(); err != nil {
+			return err
+		}
+		if len(names) == 0 {
+			return errors.Newf(codes.NotFound, "no task has run in %s", args[0])
+		}
+	}
+
+	w := cmd.OutOrStdout()
+	for _, name := range names {
+		state, err := store.State(name)
+		if err != nil {
+			return err
+		}
+		writeTaskState(w, state, tasksFlags.Limit)
+	}
+	return nil
+}
+
+// writeTaskState writes the most recent runs of a task and their logs.
+func writeTaskState(w io.Writer, state *scheduler.TaskState, limit int) {
+	lastSuccess := "never"
+	if state.LastSuccessTime != nil {
+		lastSuccess = state.LastSuccessTime.Format(time.RFC3339)
+	}
+	fmt.Fprintf(w, "Task %q (last success: %s)\n", state.Name, lastSuccess)
+
+	runs := state.Runs
+	if limit > 0 && len(runs) > limit {
+		runs = runs[len(runs)-limit:]
+	}
+	if len(runs) == 0 {
+		fmt.Fprintln(w, "  no runs")
+	}
+	for _, run := range runs {
+		fmt.Fprintf(w, "  run %d scheduled for %s: %s after %d attempt(s) in %s\n",
+			run.ID, run.ScheduledFor.Format(time.RFC3339), run.Status, run.Attempts,
+			run.FinishedAt.Sub(run.StartedAt))
+		for _, l := range run.Logs {
+			fmt.Fprintf(w, "    %s %s\n", l.Time.Format(time.RFC3339Nano), l.Message)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/internal/telemetry"
+	"github.com/opentracing/opentracing-go"
+	jaegercfg "github.com/uber/jaeger-client-go/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutmetric"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// shutdownTelemetryTimeout is how long the exporters are given
+// to send the remaining telemetry when the program exits.
+const shutdownTelemetryTimeout = 5 * time.Second
+
+// configureTracing configures where the traces and metrics of the
+// queries are exported to based on the --trace flag. The returned
+// function flushes the telemetry and must be called before exiting.
+func configureTracing(ctx context.Context) (context.Context, func(), error) {
+	switch flags.Trace {
+	case "":
+		return ctx, func() {}, nil
+	case "jaeger":
+		return configureJaeger(ctx)
+	case "otlp", "file":
+		return configureOpenTelemetry(ctx)
+	default:
+		return nil, nil, errors.Newf(codes.Invalid, "unknown tracer name: %s", flags.Trace)
+	}
+}
+
+// configureJaeger sends the traces to jaeger. Metrics are not exported.
+func configureJaeger(ctx context.Context) (context.Context, func(), error) {
+	cfg, err := jaegercfg.FromEnv()
+	if err != nil {
+		return nil, nil, err
+	}
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = "flux"
+	}
+	if cfg.Sampler.Type == "" {
+		cfg.Sampler.Type = "const"
+		cfg.Sampler.Param = 1.0
+	}
+
+	tracer, closer, err := cfg.NewTracer()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	opentracing.SetGlobalTracer(tracer)
+	otel.SetTracerProvider(telemetry.NewOpenTracingProvider(tracer))
+	return ctx, func() {
+		if err := closer.Close(); err != nil {
+			fmt.Printf("error closing tracer: %s.\n", err)
+		}
+	}, nil
+}
+
+// configureOpenTelemetry exports the traces and metrics with OTLP
+// or writes them to a file.
+func configureOpenTelemetry(ctx context.Context) (context.Context, func(), error) {
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName("flux")),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		spanExporter   sdktrace.SpanExporter
+		metricExporter sdkmetric.Exporter
+		file           io.Closer
+	)
+	if flags.Trace == "file" {
+		if flags.TraceFile == "" {
+			return nil, nil, errors.New(codes.Invalid, "--trace-file must be set to write telemetry to a file")
+		}
+		f, err := os.Create(flags.TraceFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		file = f
+		if spanExporter, err = stdouttrace.New(stdouttrace.WithWriter(f)); err != nil {
+			_ = f.Close()
+			return nil, nil, err
+		}
+		if metricExporter, err = stdoutmetric.New(stdoutmetric.WithWriter(f)); err != nil {
+			_ = f.Close()
+			return nil, nil, err
+		}
+	} else {
+		spanExporter, metricExporter, err = newOTLPExporters(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spanExporter),
+		sdktrace.WithResource(res),
+	)
+	mp := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExporter)),
+		sdkmetric.WithResource(res),
+	)
+	otel.SetTracerProvider(tp)
+	otel.SetMeterProvider(mp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	return ctx, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTelemetryTimeout)
+		defer cancel()
+		if err := tp.Shutdown(ctx); err != nil {
+			fmt.Printf("error closing tracer: %s.\n", err)
+		}
+		if err := mp.Shutdown(ctx); err != nil {
+			fmt.Printf("error closing meter: %s.\n", err)
+		}
+		if file != nil {
+			_ = file.Close()
+		}
+	}, nil
+}
+
+// newOTLPExporters creates the exporters for the protocol chosen with
+// --otlp-protocol. The endpoint is read from the environment when it is
+// not set with --otlp-endpoint.
+func newOTLPExporters(ctx context.Context) (sdktrace.SpanExporter, sdkmetric.Exporter, error) {
+	switch flags.OTLPProtocol {
+	case "http":
+		var (
+			traceOpts  []otlptracehttp.Option
+			metricOpts []otlpmetrichttp.Option
+		)
+		if flags.OTLPEndpoint != "" {
+			traceOpts = append(traceOpts, otlptracehttp.WithEndpointURL(flags.OTLPEndpoint))
+			metricOpts = append(metricOpts, otlpmetrichttp.WithEndpointURL(flags.OTLPEndpoint))
+		}
+		spanExporter, err := otlptracehttp.New(ctx, traceOpts...)
+		if err != nil {
+			return nil, nil, err
+		}
+		metricExporter, err := otlpmetrichttp.New(ctx, metricOpts...)
+		if err != nil {
+			return nil, nil, err
+		}
+		return spanExporter, metricExporter, nil
+	case "grpc":
+		var (
+			traceOpts  []otlptracegrpc.Option
+			metricOpts []otlpmetricgrpc.Option
+		)
+		if flags.OTLPEndpoint != "" {
+			traceOpts = append(traceOpts, otlptracegrpc.WithEndpointURL(flags.OTLPEndpoint))
+			metricOpts = append(metricOpts, otlpmetricgrpc.WithEndpointURL(flags.OTLPEndpoint))
+		}
+		spanExporter, err := otlptracegrpc.New(ctx, traceOpts...)
+		if err != nil {
+			return nil, nil, err
+		}
+		metricExporter, err := otlpmetricgrpc.New(ctx, metricOpts...)
+		if err != nil {
+			return nil, nil, err
+		}
+		return spanExporter, metricExporter, nil
+	default:
+		return nil, nil, errors.Newf(codes.Invalid, "unknown OTLP protocol: %s", flags.OTLPProtocol)
+	}
+}
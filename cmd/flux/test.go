@@ -23,14 +23,18 @@ func NewTestExecutor(ctx context.Context) (cmd.TestExecutor, error) {
 
 type testExecutor struct{}
 
-func (testExecutor) Run(pkg *ast.Package, fn cmd.TestResultFunc) error {
+func (e testExecutor) Run(pkg *ast.Package, fn cmd.TestResultFunc) error {
+	return e.RunContext(context.Background(), pkg, fn)
+}
+
+func (testExecutor) RunContext(ctx context.Context, pkg *ast.Package, fn cmd.TestResultFunc) error {
 	jsonAST, err := json.Marshal(pkg)
 	if err != nil {
 		return err
 	}
 	c := lang.ASTCompiler{AST: jsonAST}
 
-	ctx, span := dependency.Inject(context.Background(),
+	ctx, span := dependency.Inject(ctx,
 		executetest.NewTestExecuteDependencies(),
 		testing.FrameworkConfig{},
 	)
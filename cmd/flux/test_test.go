@@ -5,6 +5,8 @@ import (
 	"archive/zip"
 	"bufio"
 	"bytes"
+	"encoding/json"
+	"encoding/xml"
 	"errors"
 	"io"
 	"io/fs"
@@ -25,6 +27,7 @@ type Summary struct {
 	Passed  int64
 	Failed  int64
 	Skipped int64
+	Updated int64
 }
 
 var (
@@ -34,7 +37,7 @@ var (
 )
 
 func TestMain(m *testing.M) {
-	summaryPattern = regexp.MustCompile("^Found ([0-9]+) tests: passed ([0-9]+), failed ([0-9]+), skipped ([0-9]+)$")
+	summaryPattern = regexp.MustCompile("^Found ([0-9]+) tests: passed ([0-9]+), failed ([0-9]+), skipped ([0-9]+)(?:, updated ([0-9]+))?$")
 	fluxinit.FluxInit()
 
 	// Create temp zip file
@@ -195,11 +198,18 @@ func runForPath(t *testing.T, path string, wantErr error, args ...string) Summar
 			if err != nil {
 				t.Fatal(err)
 			}
+			var updated int64
+			if matches[5] != "" {
+				if updated, err = strconv.ParseInt(matches[5], 10, 64); err != nil {
+					t.Fatal(err)
+				}
+			}
 			return Summary{
 				Found:   found,
 				Passed:  passed,
 				Failed:  failed,
 				Skipped: skipped,
+				Updated: updated,
 			}
 		}
 	}
@@ -421,3 +431,118 @@ func Test_TestCmd_SkipUntagged(t *testing.T) {
 		}
 	}
 }
+
+func Test_TestCmd_Report(t *testing.T) {
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "report.json")
+	junitPath := filepath.Join(dir, "report.xml")
+	runForPath(t, "./testdata", errors.New("tests failed"),
+		"--tags", "fail",
+		"--report", "json="+jsonPath,
+		"--report", "junit="+junitPath,
+	)
+
+	var report struct {
+		Summary struct {
+			Found, Passed, Failed, Skipped int
+		}
+		Tests []struct {
+			Name, Package, Status, Error string
+		}
+	}
+	data, err := os.ReadFile(jsonPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatal(err)
+	}
+	if s := report.Summary; s.Found != 9 || s.Passed != 3 || s.Failed != 1 || s.Skipped != 5 {
+		t.Errorf("unexpected summary %+v", s)
+	}
+	for _, test := range report.Tests {
+		if test.Name == "fails" && (test.Status != "failed" || test.Error == "") {
+			t.Errorf("unexpected result for failed test %+v", test)
+		}
+	}
+
+	var suites struct {
+		Tests    int `xml:"tests,attr"`
+		Failures int `xml:"failures,attr"`
+		Suites   []struct {
+			Name string `xml:"name,attr"`
+		} `xml:"testsuite"`
+	}
+	data, err = os.ReadFile(junitPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := xml.Unmarshal(data, &suites); err != nil {
+		t.Fatal(err)
+	}
+	if suites.Tests != 9 || suites.Failures != 1 || len(suites.Suites) != 3 {
+		t.Errorf("unexpected junit report %+v", suites)
+	}
+}
+
+func Test_TestCmd_Update(t *testing.T) {
+	const src = `package update_test
+
+
+import "array"
+import "csv"
+import "testing"
+
+data = "
+#datatype,string,long,long
+#group,false,false,false
+#default,_result,,
+,result,table,_value
+,,0,1
+"
+
+testcase update {
+    got = array.from(rows: [{_value: 2}])
+    want = csv.from(csv: data)
+
+    testing.diff(got, want)
+}
+`
+	path := filepath.Join(t.TempDir(), "update_test.flux")
+	if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := runForPath(t, path, nil, "--update"), (Summary{Found: 1, Updated: 1}); got != want {
+		t.Errorf("unexpected summary got %+v want %+v", got, want)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := strings.Replace(src, ",,0,1", ",,0,2", 1); string(data) != want {
+		t.Errorf("unexpected updated test file:\n%s", data)
+	}
+	if got, want := runForPath(t, path, nil), (Summary{Found: 1, Passed: 1}); got != want {
+		t.Errorf("unexpected summary after update got %+v want %+v", got, want)
+	}
+}
+
+func Test_TestCmd_Coverage(t *testing.T) {
+	tcmd := cmd.TestCommand(NewTestExecutor)
+	b := bytes.NewBuffer(nil)
+	tcmd.SetOutput(b)
+	tcmd.SetArgs([]string{"--noinit", "-p", "./testdata", "--coverage", "-v"})
+	if err := tcmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"test_test.flux: a ... array.from",
+		"\tarray: 1/",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected coverage output to contain %q, got:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/InfluxCommunity/flux"
+	fluxcmd "github.com/InfluxCommunity/flux/cmd/flux/cmd"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/fluxinit"
+	"github.com/InfluxCommunity/flux/internal/watch"
+	"github.com/InfluxCommunity/flux/lang"
+	"github.com/InfluxCommunity/flux/memory"
+	"github.com/InfluxCommunity/flux/plan"
+	"github.com/InfluxCommunity/flux/repl"
+	"github.com/InfluxCommunity/flux/runtime"
+	"github.com/InfluxCommunity/flux/stdlib/universe"
+	"github.com/spf13/cobra"
+)
+
+var watchFlags struct {
+	Every      time.Duration
+	Anchor     bool
+	CacheBytes int64
+	CacheTTL   time.Duration
+	LateData   time.Duration
+}
+
+// watchE runs a script every interval and writes the rows
+// that each run adds to the results of the earlier runs.
+//
+// The script is parsed once and evaluated again for every run
+// with now set to the time of the run. With --anchor, the start
+// of a range stays where it was on the first run, so every run
+// reads the whole history since then. Functions that carry
+// state from row to row, such as stateDuration, movingAverage or
+// derivative, then continue from the rows of the earlier runs
+// instead of starting over.
+//
+// The output of the sources is cached so only the data added since
+// the last run, along with the trailing --late-data window of the
+// cached data, is read again. Cached output is read again in full
+// once it is older than --cache-ttl.
+func watchE(cmd *cobra.Command, args []string) error {
+	script := args[0]
+	if !flags.ExecScript {
+		content, err := os.ReadFile(args[0])
+		if err != nil {
+			return err
+		}
+		script = string(content)
+	}
+
+	ctx, close, err := configureTracing(context.Background())
+	if err != nil {
+		return err
+	}
+	defer close()
+
+	fluxinit.FluxInit()
+	ctx, span, err := injectDependencies(ctx)
+	if err != nil {
+		return err
+	}
+	defer span.Finish()
+
+	ctx, err = fluxcmd.WithFeatureFlags(ctx, flags.Features)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	opts := []lang.CompileOption{
+		lang.WithSourceCache(execute.NewSourceCache(execute.SourceCacheConfig{
+			TTL:       watchFlags.CacheTTL,
+			LateData:  watchFlags.LateData,
+			MaxMemory: watchFlags.CacheBytes,
+		})),
+	}
+	anchor := &anchorRangeRule{}
+	if watchFlags.Anchor {
+		opts = append(opts, lang.WithLogPlanOpts(plan.AddLogicalRules(anchor)))
+	}
+	prog, err := lang.Compile(ctx, script, runtime.Default, time.Now(), opts...)
+	if err != nil {
+		return err
+	}
+
+	tracker := watch.NewTracker()
+	return watch.Run(ctx, watchFlags.Every, func(ctx context.Context, now time.Time) error {
+		if anchor.now.IsZero() {
+			anchor.now = now
+		}
+		if err := watchRun(ctx, prog, tracker, now); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			// A failed run, such as one where the data source
+			// is not reachable, does not stop the watch.
+			fmt.Fprintf(os.Stderr, "%s: %v\n", now.UTC().Format(time.RFC3339), err)
+		}
+		return nil
+	})
+}
+
+// watchRun executes a run of the program and writes its new rows.
+func watchRun(ctx context.Context, prog *lang.AstProgram, tracker *watch.Tracker, now time.Time) error {
+	prog.Now = now
+	mem := &memory.ResourceAllocator{}
+	q, err := prog.Start(ctx, mem)
+	if err != nil {
+		return err
+	}
+	results, err := tracker.Filter(flux.NewResultIteratorFromQuery(q), mem)
+	if err != nil {
+		return err
+	}
+	if len(results) == 0 {
+		return nil
+	}
+	return repl.WriteResults(os.Stdout, flux.NewSliceResultIterator(results), flags.Format)
+}
+
+// anchorRangeRule fixes a range start that is relative to now
+// at the time it resolved to on the first run of a watch.
+// The stop of the range keeps moving with now.
+type anchorRangeRule struct {
+	now time.Time
+}
+
+func (r *anchorRangeRule) Name() string {
+	return "anchorRangeRule"
+}
+
+func (r *anchorRangeRule) Pattern() plan.Pattern {
+	return plan.MultiSuccessor(universe.RangeKind)
+}
+
+func (r *anchorRangeRule) Rewrite(ctx context.Context, node plan.Node) (plan.Node, bool, error) {
+	spec := node.ProcedureSpec().(*universe.RangeProcedureSpec)
+	if r.now.IsZero() || !spec.Bounds.Start.IsRelative {
+		return node, false, nil
+	}
+	newSpec := spec.Copy().(*universe.RangeProcedureSpec)
+	newSpec.Bounds.Start = flux.Time{Absolute: spec.Bounds.Start.Time(r.now)}
+	if err := node.ReplaceSpec(newSpec); err != nil {
+		return nil, false, err
+	}
+	return node, true, nil
+}
@@ -195,15 +195,27 @@ func (t *TableObject) Sort(f func(i, j values.Value) bool) {
 }
 
 type Administration struct {
+	ctx     context.Context
 	parents []*TableObject
 }
 
-func newAdministration() *Administration {
+func newAdministration(ctx context.Context) *Administration {
 	return &Administration{
+		ctx:     ctx,
 		parents: make([]*TableObject, 0, 8),
 	}
 }
 
+// Context returns the context of the call that creates the operation.
+// Functions passed as arguments should be called with it so they have
+// access to the dependencies and are canceled with the query.
+func (a *Administration) Context() context.Context {
+	if a.ctx == nil {
+		return context.Background()
+	}
+	return a.ctx
+}
+
 // AddParentFromArgs reads the args for the `table` argument and adds the value as a parent.
 func (a *Administration) AddParentFromArgs(args Arguments) error {
 	parent, ok := args.Get(TablesParameter)
@@ -313,7 +325,7 @@ func (f *function) call(ctx context.Context, args interpreter.Arguments) (values
 		return nil, err
 	}
 
-	a := newAdministration()
+	a := newAdministration(ctx)
 	arguments := Arguments{Arguments: args}
 	spec, err := f.createOpSpec(arguments, a)
 	if err != nil {
@@ -0,0 +1,288 @@
+// Package controller admits and executes queries within
+// a concurrency limit and a shared memory budget.
+package controller
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/memory"
+	arrowmemory "github.com/apache/arrow/go/v7/arrow/memory"
+)
+
+// Config holds the limits of a Controller.
+type Config struct {
+	// ConcurrencyQuota is the number of queries that may execute at the same time.
+	ConcurrencyQuota int
+
+	// QueueSize is the number of queries that may wait to execute.
+	// Queries are rejected when the queue is full.
+	QueueSize int
+
+	// InitialMemoryBytesQuotaPerQuery is the number of bytes
+	// each query is guaranteed when it starts executing.
+	InitialMemoryBytesQuotaPerQuery int64
+
+	// MemoryBytesQuotaPerQuery is the maximum number of bytes
+	// a single query may use. A zero value means a query
+	// is only limited by the memory that is available.
+	MemoryBytesQuotaPerQuery int64
+
+	// MaxMemoryBytes is the maximum number of bytes all of the
+	// executing queries may use. A zero value means unlimited.
+	MaxMemoryBytes int64
+
+	// Allocator is the allocator used by the queries.
+	// If this is unset, the default allocator is used.
+	Allocator arrowmemory.Allocator
+}
+
+// Validate reports whether the config is valid.
+func (c Config) Validate() error {
+	if c.ConcurrencyQuota <= 0 {
+		return errors.New(codes.Invalid, "ConcurrencyQuota must be greater than zero")
+	}
+	if c.QueueSize < 0 {
+		return errors.New(codes.Invalid, "QueueSize must not be negative")
+	}
+	if c.InitialMemoryBytesQuotaPerQuery < 0 || c.MemoryBytesQuotaPerQuery < 0 || c.MaxMemoryBytes < 0 {
+		return errors.New(codes.Invalid, "memory quotas must not be negative")
+	}
+	if c.MemoryBytesQuotaPerQuery > 0 && c.InitialMemoryBytesQuotaPerQuery > c.MemoryBytesQuotaPerQuery {
+		return errors.New(codes.Invalid, "InitialMemoryBytesQuotaPerQuery must not be greater than MemoryBytesQuotaPerQuery")
+	}
+	if c.MaxMemoryBytes > 0 {
+		if reserved := int64(c.ConcurrencyQuota) * c.InitialMemoryBytesQuotaPerQuery; reserved > c.MaxMemoryBytes {
+			return errors.Newf(codes.Invalid, "MaxMemoryBytes must be at least ConcurrencyQuota * InitialMemoryBytesQuotaPerQuery (%d bytes)", reserved)
+		}
+	}
+	return nil
+}
+
+// limited reports whether the memory of the queries is limited.
+func (c Config) limited() bool {
+	return c.MemoryBytesQuotaPerQuery > 0 || c.MaxMemoryBytes > 0
+}
+
+// Metrics reports the state of a Controller.
+type Metrics struct {
+	// Queued is the number of queries waiting to execute.
+	Queued int
+	// Executing is the number of queries that are executing.
+	Executing int
+	// Admitted is the total number of queries that started executing.
+	Admitted int64
+	// Rejected is the total number of queries rejected because the queue was full.
+	Rejected int64
+	// QueueDuration is the total time the admitted queries spent in the queue.
+	QueueDuration time.Duration
+	// MemoryBytesReserved is the number of bytes reserved by the executing queries.
+	MemoryBytesReserved int64
+	// MaxMemoryBytes is the limit of the memory reserved by the queries.
+	// A zero value means unlimited.
+	MaxMemoryBytes int64
+}
+
+// Controller queues queries and executes them
+// once there is capacity to do so.
+type Controller struct {
+	config  Config
+	runtime flux.Runtime
+	pool    *memoryPool
+
+	mu       sync.Mutex
+	lastID   ID
+	queries  map[ID]*Query
+	queue    []*Query
+	running  int
+	shutdown bool
+	wg       sync.WaitGroup
+
+	admitted      int64
+	rejected      int64
+	queueDuration time.Duration
+}
+
+// New creates a Controller that compiles queries with the runtime.
+func New(config Config, runtime flux.Runtime) (*Controller, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	// The initial memory of every query that may execute is set aside
+	// so the pool holds only the memory the queries may share.
+	pool := &memoryPool{
+		limited: config.MaxMemoryBytes > 0,
+		limit:   config.MaxMemoryBytes - int64(config.ConcurrencyQuota)*config.InitialMemoryBytesQuotaPerQuery,
+	}
+	return &Controller{
+		config:  config,
+		runtime: runtime,
+		pool:    pool,
+		queries: make(map[ID]*Query),
+	}, nil
+}
+
+// Query compiles the query and queues it for execution.
+// The query starts executing once there is capacity.
+// The context of the query is canceled if ctx is canceled.
+//
+// Done must be called on the returned query to free its resources.
+func (c *Controller) Query(ctx context.Context, compiler flux.Compiler) (*Query, error) {
+	program, err := compiler.Compile(ctx, c.runtime)
+	if err != nil {
+		return nil, errors.Wrap(err, codes.Invalid, "failed to compile query")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.shutdown {
+		return nil, errors.New(codes.Unavailable, "query controller is shut down")
+	}
+	if c.running >= c.config.ConcurrencyQuota && len(c.queue) >= c.config.QueueSize {
+		c.rejected++
+		return nil, errors.New(codes.ResourceExhausted, "query queue is full")
+	}
+
+	c.lastID++
+	q := newQuery(ctx, c, c.lastID, program)
+	c.queries[q.id] = q
+	c.wg.Add(1)
+	if c.running < c.config.ConcurrencyQuota {
+		c.admit(q)
+	} else {
+		c.queue = append(c.queue, q)
+	}
+	return q, nil
+}
+
+// admit starts executing the query. The lock must be held.
+func (c *Controller) admit(q *Query) {
+	c.running++
+	c.admitted++
+	queueDuration := time.Since(q.queuedAt)
+	c.queueDuration += queueDuration
+	go q.run(c.allocatorFor(q), queueDuration)
+}
+
+// allocatorFor creates the allocator for the memory of an admitted query.
+func (c *Controller) allocatorFor(q *Query) *memory.ResourceAllocator {
+	alloc := &memory.ResourceAllocator{
+		Allocator: c.config.Allocator,
+	}
+	if c.config.limited() {
+		limit := c.config.InitialMemoryBytesQuotaPerQuery
+		alloc.Limit = &limit
+		alloc.Manager = q.mem
+	}
+	return alloc
+}
+
+// dequeue removes a queued query and reports whether it was queued.
+func (c *Controller) dequeue(q *Query) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, queued := range c.queue {
+		if queued == q {
+			c.queue = append(c.queue[:i], c.queue[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// finish removes the query from the controller and
+// admits the next query if the finished query was executing.
+func (c *Controller) finish(q *Query, executed bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.queries, q.id)
+	if executed {
+		c.running--
+		if len(c.queue) > 0 {
+			next := c.queue[0]
+			c.queue = c.queue[1:]
+			c.admit(next)
+		}
+	}
+	c.wg.Done()
+}
+
+// Cancel cancels the query with the given ID.
+func (c *Controller) Cancel(id ID) error {
+	c.mu.Lock()
+	q, ok := c.queries[id]
+	c.mu.Unlock()
+	if !ok {
+		return errors.Newf(codes.NotFound, "query %s not found", id)
+	}
+	q.Cancel()
+	return nil
+}
+
+// Queries returns the queries that are queued or executing.
+func (c *Controller) Queries() []*Query {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	queries := make([]*Query, 0, len(c.queries))
+	for _, q := range c.queries {
+		queries = append(queries, q)
+	}
+	sort.Slice(queries, func(i, j int) bool {
+		return queries[i].id < queries[j].id
+	})
+	return queries
+}
+
+// Metrics reports the current state of the controller.
+func (c *Controller) Metrics() Metrics {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return Metrics{
+		Queued:              len(c.queue),
+		Executing:           c.running,
+		Admitted:            c.admitted,
+		Rejected:            c.rejected,
+		QueueDuration:       c.queueDuration,
+		MemoryBytesReserved: int64(c.running)*c.config.InitialMemoryBytesQuotaPerQuery + c.pool.inUse(),
+		MaxMemoryBytes:      c.config.MaxMemoryBytes,
+	}
+}
+
+// Shutdown stops accepting queries, cancels the queued queries
+// and waits for the executing queries to be done.
+// The remaining queries are canceled if ctx is canceled first.
+func (c *Controller) Shutdown(ctx context.Context) error {
+	c.mu.Lock()
+	c.shutdown = true
+	queued := make([]*Query, len(c.queue))
+	copy(queued, c.queue)
+	c.mu.Unlock()
+
+	for _, q := range queued {
+		q.Cancel()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		c.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		for _, q := range c.Queries() {
+			q.Cancel()
+		}
+		return ctx.Err()
+	}
+}
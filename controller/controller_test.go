@@ -0,0 +1,225 @@
+package controller_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/controller"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/memory"
+	"github.com/InfluxCommunity/flux/mock"
+)
+
+// blockingCompiler compiles programs that execute until
+// their context is canceled or release is closed.
+func blockingCompiler(release <-chan struct{}, allocate int) flux.Compiler {
+	return mock.Compiler{
+		CompileFn: func(ctx context.Context) (flux.Program, error) {
+			return &mock.Program{
+				ExecuteFn: func(ctx context.Context, q *mock.Query, alloc memory.Allocator) {
+					if allocate > 0 {
+						if err := alloc.Account(allocate); err != nil {
+							q.SetErr(err)
+							return
+						}
+					}
+					select {
+					case <-release:
+					case <-ctx.Done():
+					}
+				},
+			}, nil
+		},
+	}
+}
+
+func newController(t *testing.T, config controller.Config) *controller.Controller {
+	t.Helper()
+	c, err := controller.New(config, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// waitForState waits for the query to reach the state.
+func waitForState(t *testing.T, q *controller.Query, want controller.State) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for q.State() != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("unexpected state -want/+got:\n\t- %s\n\t+ %s", want, q.State())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestController_Queue(t *testing.T) {
+	c := newController(t, controller.Config{
+		ConcurrencyQuota: 1,
+		QueueSize:        1,
+	})
+
+	release := make(chan struct{})
+	first, err := c.Query(context.Background(), blockingCompiler(release, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitForState(t, first, controller.Executing)
+
+	second, err := c.Query(context.Background(), blockingCompiler(release, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := controller.Queued, second.State(); want != got {
+		t.Fatalf("unexpected state -want/+got:\n\t- %s\n\t+ %s", want, got)
+	}
+
+	if _, err := c.Query(context.Background(), blockingCompiler(release, 0)); err == nil {
+		t.Fatal("expected the query to be rejected")
+	} else if want, got := codes.ResourceExhausted, errors.Code(err); want != got {
+		t.Fatalf("unexpected error code -want/+got:\n\t- %s\n\t+ %s", want, got)
+	}
+
+	m := c.Metrics()
+	if m.Queued != 1 || m.Executing != 1 || m.Rejected != 1 {
+		t.Errorf("unexpected metrics: %+v", m)
+	}
+
+	// The queued query is admitted once the first one is done.
+	close(release)
+	first.Done()
+	waitForState(t, second, controller.Executing)
+	second.Done()
+	if err := second.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if second.Statistics().QueueDuration <= 0 {
+		t.Error("expected the queued query to report its queue duration")
+	}
+
+	if m := c.Metrics(); m.Queued != 0 || m.Executing != 0 || m.Admitted != 2 {
+		t.Errorf("unexpected metrics: %+v", m)
+	}
+}
+
+func TestController_Cancel(t *testing.T) {
+	c := newController(t, controller.Config{
+		ConcurrencyQuota: 1,
+		QueueSize:        1,
+	})
+
+	first, err := c.Query(context.Background(), blockingCompiler(nil, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := c.Query(context.Background(), blockingCompiler(nil, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Canceling a queued query removes it from the queue.
+	if err := c.Cancel(second.ID()); err != nil {
+		t.Fatal(err)
+	}
+	second.Done()
+	if want, got := controller.Canceled, second.State(); want != got {
+		t.Errorf("unexpected state -want/+got:\n\t- %s\n\t+ %s", want, got)
+	}
+	if want, got := codes.Canceled, errors.Code(second.Err()); want != got {
+		t.Errorf("unexpected error code -want/+got:\n\t- %s\n\t+ %s", want, got)
+	}
+
+	waitForState(t, first, controller.Executing)
+	if err := c.Cancel(first.ID()); err != nil {
+		t.Fatal(err)
+	}
+	first.Done()
+	if want, got := controller.Canceled, first.State(); want != got {
+		t.Errorf("unexpected state -want/+got:\n\t- %s\n\t+ %s", want, got)
+	}
+
+	if err := c.Cancel(first.ID()); errors.Code(err) != codes.NotFound {
+		t.Errorf("expected a not found error for a finished query, got: %v", err)
+	}
+	if queries := c.Queries(); len(queries) != 0 {
+		t.Errorf("expected no queries, got %d", len(queries))
+	}
+}
+
+func TestController_Memory(t *testing.T) {
+	c := newController(t, controller.Config{
+		ConcurrencyQuota:                2,
+		QueueSize:                       0,
+		InitialMemoryBytesQuotaPerQuery: 100,
+		MemoryBytesQuotaPerQuery:        400,
+		MaxMemoryBytes:                  500,
+	})
+
+	release := make(chan struct{})
+	first, err := c.Query(context.Background(), blockingCompiler(release, 300))
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitForState(t, first, controller.Executing)
+	deadline := time.Now().Add(5 * time.Second)
+	for c.Metrics().MemoryBytesReserved != 300 {
+		if time.Now().After(deadline) {
+			t.Fatalf("unexpected reserved memory: %d", c.Metrics().MemoryBytesReserved)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// The pool only has 100 bytes left for the second query
+	// in addition to its initial memory.
+	second, err := c.Query(context.Background(), blockingCompiler(release, 250))
+	if err != nil {
+		t.Fatal(err)
+	}
+	close(release)
+
+	// The second query is read first so the first query
+	// holds its memory while the second one allocates.
+	for _, r := range []flux.ResultIterator{
+		flux.NewResultIteratorFromQuery(second),
+		flux.NewResultIteratorFromQuery(first),
+	} {
+		for r.More() {
+			r.Next()
+		}
+		r.Release()
+	}
+	if err := first.Err(); err != nil {
+		t.Errorf("unexpected error for the first query: %s", err)
+	}
+	if want, got := codes.ResourceExhausted, errors.Code(second.Err()); want != got {
+		t.Errorf("unexpected error code -want/+got:\n\t- %s\n\t+ %s", want, got)
+	}
+
+	if got := c.Metrics().MemoryBytesReserved; got != 0 {
+		t.Errorf("expected the memory to be released, got %d bytes", got)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	for name, config := range map[string]controller.Config{
+		"no concurrency": {},
+		"initial greater than quota": {
+			ConcurrencyQuota:                1,
+			InitialMemoryBytesQuotaPerQuery: 2,
+			MemoryBytesQuotaPerQuery:        1,
+		},
+		"initial exceeds max memory": {
+			ConcurrencyQuota:                2,
+			InitialMemoryBytesQuotaPerQuery: 2,
+			MaxMemoryBytes:                  3,
+		},
+	} {
+		if err := config.Validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
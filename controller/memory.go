@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"sync"
+
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/memory"
+)
+
+// memoryPool is the memory shared by all of the queries of a controller
+// in addition to the initial memory each query is guaranteed.
+// An unlimited pool only tracks the memory that is reserved.
+type memoryPool struct {
+	limited bool
+	limit   int64
+
+	mu       sync.Mutex
+	reserved int64
+}
+
+// reserve reserves n bytes and reports whether they were available.
+func (p *memoryPool) reserve(n int64) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.limited && p.reserved+n > p.limit {
+		return false
+	}
+	p.reserved += n
+	return true
+}
+
+func (p *memoryPool) release(n int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.reserved -= n
+}
+
+func (p *memoryPool) inUse() int64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.reserved
+}
+
+// queryMemoryManager grants memory from the pool to a single query
+// once it has used its initial memory and until it reaches its quota.
+type queryMemoryManager struct {
+	pool *memoryPool
+
+	// initial is the number of bytes the query starts with.
+	initial int64
+
+	// quota is the maximum number of bytes the query may use.
+	// A quota of zero means the query is only limited by the pool.
+	quota int64
+
+	mu    sync.Mutex
+	given int64
+}
+
+var _ memory.Manager = (*queryMemoryManager)(nil)
+
+func (m *queryMemoryManager) RequestMemory(want int64) (got int64, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.quota > 0 && m.initial+m.given+want > m.quota {
+		return 0, errors.Newf(codes.ResourceExhausted, "query memory quota of %d bytes exceeded", m.quota)
+	}
+	if !m.pool.reserve(want) {
+		return 0, errors.New(codes.ResourceExhausted, "not enough memory available for the query")
+	}
+	m.given += want
+	return want, nil
+}
+
+func (m *queryMemoryManager) FreeMemory(bytes int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if bytes > m.given {
+		bytes = m.given
+	}
+	m.given -= bytes
+	m.pool.release(bytes)
+}
+
+// releaseAll returns all of the memory given to the query to the pool.
+func (m *queryMemoryManager) releaseAll() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.pool.release(m.given)
+	m.given = 0
+}
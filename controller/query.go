@@ -0,0 +1,245 @@
+package controller
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/memory"
+)
+
+// ID identifies a query of a Controller.
+type ID uint64
+
+func (id ID) String() string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+// State is the state of a query.
+type State int
+
+const (
+	Queued State = iota
+	Executing
+	Finished
+	Canceled
+)
+
+func (s State) String() string {
+	switch s {
+	case Queued:
+		return "queued"
+	case Executing:
+		return "executing"
+	case Finished:
+		return "finished"
+	case Canceled:
+		return "canceled"
+	default:
+		return "unknown"
+	}
+}
+
+var _ flux.Query = (*Query)(nil)
+
+// Query is a query that is queued or executed by a Controller.
+type Query struct {
+	id       ID
+	c        *Controller
+	program  flux.Program
+	ctx      context.Context
+	cancel   context.CancelFunc
+	queuedAt time.Time
+	mem      *queryMemoryManager
+	results  chan flux.Result
+
+	doneOnce sync.Once
+	// doneCh is closed when Done is called.
+	doneCh chan struct{}
+	// finished is closed once the query no longer holds any resources.
+	finished chan struct{}
+
+	mu       sync.Mutex
+	state    State
+	canceled bool
+	query    flux.Query
+	err      error
+	stats    flux.Statistics
+}
+
+func newQuery(ctx context.Context, c *Controller, id ID, program flux.Program) *Query {
+	ctx, cancel := context.WithCancel(ctx)
+	return &Query{
+		id:       id,
+		c:        c,
+		program:  program,
+		ctx:      ctx,
+		cancel:   cancel,
+		queuedAt: time.Now(),
+		mem: &queryMemoryManager{
+			pool:    c.pool,
+			initial: c.config.InitialMemoryBytesQuotaPerQuery,
+			quota:   c.config.MemoryBytesQuotaPerQuery,
+		},
+		results:  make(chan flux.Result),
+		doneCh:   make(chan struct{}),
+		finished: make(chan struct{}),
+	}
+}
+
+// ID returns the ID of the query.
+func (q *Query) ID() ID {
+	return q.id
+}
+
+// State returns the current state of the query.
+func (q *Query) State() State {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.state
+}
+
+// run executes the query after it has been admitted.
+func (q *Query) run(alloc *memory.ResourceAllocator, queueDuration time.Duration) {
+	defer close(q.finished)
+	defer q.c.finish(q, true)
+	defer q.mem.releaseAll()
+
+	q.mu.Lock()
+	q.state = Executing
+	q.stats.QueueDuration = queueDuration
+	q.mu.Unlock()
+
+	query, err := q.start(alloc)
+	if err != nil {
+		q.mu.Lock()
+		q.state = Finished
+		q.err = err
+		q.mu.Unlock()
+		close(q.results)
+		return
+	}
+
+	q.mu.Lock()
+	q.query = query
+	q.mu.Unlock()
+
+	q.forward(query.Results())
+	close(q.results)
+
+	// The query executes while its results are read so it
+	// keeps its place until the caller is done with it.
+	<-q.doneCh
+	query.Done()
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.stats = query.Statistics()
+	q.stats.QueueDuration = queueDuration
+	q.err = query.Err()
+	q.state = Finished
+	if q.canceled {
+		q.state = Canceled
+		if q.err == nil {
+			q.err = errors.New(codes.Canceled, "query was canceled")
+		}
+	}
+}
+
+func (q *Query) start(alloc *memory.ResourceAllocator) (flux.Query, error) {
+	// The context may have been canceled while the query was queued.
+	if err := q.ctx.Err(); err != nil {
+		return nil, errors.Wrap(err, codes.Canceled, "query was canceled")
+	}
+	return q.program.Start(q.ctx, alloc)
+}
+
+// forward passes the results of the executing query to the caller.
+func (q *Query) forward(results <-chan flux.Result) {
+	for {
+		select {
+		case r, ok := <-results:
+			if !ok {
+				return
+			}
+			select {
+			case q.results <- r:
+			case <-q.ctx.Done():
+				return
+			}
+		case <-q.ctx.Done():
+			return
+		}
+	}
+}
+
+func (q *Query) Results() <-chan flux.Result {
+	return q.results
+}
+
+// Done waits for the query to finish and frees its resources.
+// A query that is still queued is removed from the queue.
+func (q *Query) Done() {
+	q.doneOnce.Do(func() {
+		q.stop()
+		close(q.doneCh)
+	})
+	<-q.finished
+}
+
+// Cancel cancels the query whether it is queued or executing.
+// Done must still be called to free resources.
+func (q *Query) Cancel() {
+	q.mu.Lock()
+	q.canceled = true
+	q.mu.Unlock()
+	q.stop()
+}
+
+// stop cancels the context of the query and
+// finishes the query if it was still queued.
+func (q *Query) stop() {
+	q.cancel()
+	if !q.c.dequeue(q) {
+		return
+	}
+
+	q.mu.Lock()
+	q.state = Canceled
+	q.err = errors.New(codes.Canceled, "query was canceled before it started executing")
+	q.mu.Unlock()
+	close(q.results)
+	q.c.finish(q, false)
+	close(q.finished)
+}
+
+func (q *Query) Err() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.err == nil && q.query != nil {
+		return q.query.Err()
+	}
+	return q.err
+}
+
+// Statistics reports the statistics for the query.
+// The statistics are not complete until Done is called.
+func (q *Query) Statistics() flux.Statistics {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.stats
+}
+
+func (q *Query) ProfilerResults() (flux.ResultIterator, error) {
+	q.mu.Lock()
+	query := q.query
+	q.mu.Unlock()
+	if query == nil {
+		return nil, nil
+	}
+	return query.ProfilerResults()
+}
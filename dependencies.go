@@ -99,6 +99,10 @@ func GetDependencies(ctx context.Context) Dependencies {
 
 // NewDefaultDependencies produces a set of dependencies.
 // Not all dependencies have valid defaults and will not be set.
+//
+// The HTTP client makes a single attempt for each request and does not
+// rate limit them. Wrap it with http.WithRetry and http.WithRateLimit
+// to retry and limit requests.
 func NewDefaultDependencies() Deps {
 	validator := url.PassValidator{}
 	return Deps{
@@ -120,12 +124,15 @@ func NewEmptyDependencies() Deps {
 
 // GetDialer will return a net.Dialer using the injected dependencies
 // within the context.Context.
+// The dialer validates connections for the function recorded
+// in the context with url.WithFunction.
 func GetDialer(ctx context.Context) (*net.Dialer, error) {
 	deps := GetDependencies(ctx)
-	url, err := deps.URLValidator()
+	validator, err := deps.URLValidator()
 	if err != nil {
 		return nil, err
 	}
+	validator = url.ValidatorFor(validator, url.FunctionFromContext(ctx))
 
 	// Control is called after DNS lookup, but before the
 	// network connection is initiated.
@@ -136,7 +143,7 @@ func GetDialer(ctx context.Context) (*net.Dialer, error) {
 		}
 
 		ip := net.ParseIP(host)
-		return url.ValidateIP(ip)
+		return validator.ValidateIP(ip)
 	}
 
 	return &net.Dialer{
@@ -5,6 +5,7 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"sync"
 	"syscall"
 	"time"
 
@@ -62,7 +63,22 @@ func (l roundTripLimiter) RoundTrip(r *http.Request) (*http.Response, error) {
 }
 
 // NewDefaultClient creates a client with sane defaults.
+//
+// When the validator is a url.FunctionValidator, connections are validated
+// using the validator of the function recorded in the request context
+// with url.WithFunction.
 func NewDefaultClient(urlValidator url.Validator) *http.Client {
+	if fv, ok := urlValidator.(url.FunctionValidator); ok {
+		return &http.Client{
+			Transport: newFunctionTransport(fv, nil),
+		}
+	}
+	return &http.Client{
+		Transport: newDefaultTransport(urlValidator),
+	}
+}
+
+func newDefaultTransport(urlValidator url.Validator) *http.Transport {
 	// Control is called after DNS lookup, but before the network connection is
 	// initiated.
 	control := func(network, address string, c syscall.RawConn) error {
@@ -83,27 +99,75 @@ func NewDefaultClient(urlValidator url.Validator) *http.Client {
 	}
 
 	// These defaults are copied from http.DefaultTransport.
-	return &http.Client{
-		Transport: &http.Transport{
-			Proxy:                 http.ProxyFromEnvironment,
-			DialContext:           dialer.DialContext,
-			MaxIdleConns:          100,
-			IdleConnTimeout:       10 * time.Second,
-			TLSHandshakeTimeout:   10 * time.Second,
-			ExpectContinueTimeout: 1 * time.Second,
-			// Fields below are NOT part of Go's defaults
-			MaxIdleConnsPerHost: 100,
-		},
+	return &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       10 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+		// Fields below are NOT part of Go's defaults
+		MaxIdleConnsPerHost: 100,
 	}
 }
 
+// functionTransport uses a separate transport for each function
+// so that a connection validated for one function is never
+// reused by another function with a different policy.
+type functionTransport struct {
+	validator url.FunctionValidator
+	tlsConfig *tls.Config
+
+	mu         sync.Mutex
+	transports map[string]*http.Transport
+}
+
+func newFunctionTransport(validator url.FunctionValidator, tlsConfig *tls.Config) *functionTransport {
+	return &functionTransport{
+		validator:  validator,
+		tlsConfig:  tlsConfig,
+		transports: make(map[string]*http.Transport),
+	}
+}
+
+func (t *functionTransport) transport(name string) *http.Transport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	transport, ok := t.transports[name]
+	if !ok {
+		transport = newDefaultTransport(url.ValidatorFor(t.validator, name))
+		transport.TLSClientConfig = t.tlsConfig
+		t.transports[name] = transport
+	}
+	return transport
+}
+
+func (t *functionTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	name := url.FunctionFromContext(req.Context())
+	return t.transport(name).RoundTrip(req)
+}
+
 // NewLimitedDefaultClient creates a client with a limit on the response body size.
 func NewLimitedDefaultClient(urlValidator url.Validator) *http.Client {
 	cli := NewDefaultClient(urlValidator)
 	return LimitHTTPBody(*cli, maxResponseBody)
 }
 
+// clientWrapper is implemented by clients that wrap another client
+// so options can be applied to the client they wrap.
+type clientWrapper interface {
+	unwrap() Client
+	rewrap(Client) Client
+}
+
 func WithTimeout(c Client, t time.Duration) (Client, error) {
+	if w, ok := c.(clientWrapper); ok {
+		cli, err := WithTimeout(w.unwrap(), t)
+		if err != nil {
+			return nil, err
+		}
+		return w.rewrap(cli), nil
+	}
 	cli, ok := c.(*http.Client)
 	if !ok {
 		return nil, errors.New(codes.Internal, "cannot set timeout on client")
@@ -113,7 +177,15 @@ func WithTimeout(c Client, t time.Duration) (Client, error) {
 	newClient.Timeout = t
 	return &newClient, nil
 }
+
 func WithTLSConfig(c Client, config *tls.Config) (Client, error) {
+	if w, ok := c.(clientWrapper); ok {
+		cli, err := WithTLSConfig(w.unwrap(), config)
+		if err != nil {
+			return nil, err
+		}
+		return w.rewrap(cli), nil
+	}
 	cli, ok := c.(*http.Client)
 	if !ok {
 		return nil, errors.New(codes.Internal, "cannot set timeout on client")
@@ -124,25 +196,37 @@ func WithTLSConfig(c Client, config *tls.Config) (Client, error) {
 	// We control the clients so we can safely deconstruct the client
 	// to change its transport config.
 	switch t := newClient.Transport.(type) {
-	case *http.Transport:
-		newTransport := t.Clone()
-		newTransport.TLSClientConfig = config
-		newClient.Transport = newTransport
 	case roundTripLimiter:
-		transport, ok := t.RoundTripper.(*http.Transport)
+		transport, ok := withTLSConfig(t.RoundTripper, config)
 		if !ok {
 			return nil, errors.New(codes.Internal, "roundTripLimiter does not have http a known transport")
 		}
-		newTransport := transport.Clone()
-		newTransport.TLSClientConfig = config
-		t.RoundTripper = newTransport
+		t.RoundTripper = transport
 		newClient.Transport = t
 	default:
-		return nil, errors.New(codes.Internal, "http client does not have http a known transport")
+		transport, ok := withTLSConfig(t, config)
+		if !ok {
+			return nil, errors.New(codes.Internal, "http client does not have http a known transport")
+		}
+		newClient.Transport = transport
 	}
 	return &newClient, nil
 }
 
+// withTLSConfig returns a copy of a known transport that uses the TLS config.
+func withTLSConfig(rt http.RoundTripper, config *tls.Config) (http.RoundTripper, bool) {
+	switch t := rt.(type) {
+	case *http.Transport:
+		newTransport := t.Clone()
+		newTransport.TLSClientConfig = config
+		return newTransport, true
+	case *functionTransport:
+		return newFunctionTransport(t.validator, config), true
+	default:
+		return nil, false
+	}
+}
+
 // privateClient is an http client that obscures error messages that may contain
 // sensitive information
 type privateClient struct {
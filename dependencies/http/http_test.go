@@ -155,3 +155,39 @@ func TestInvalidRedirects(t *testing.T) {
 
 	})
 }
+
+func TestFunctionValidation(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	validator, err := depsUrl.NewPolicyValidator(depsUrl.PolicyConfig{
+		Functions: map[string]depsUrl.Policy{
+			"http.post": {DenyCIDRs: []string{"127.0.0.0/8"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewDefaultClient(validator)
+
+	// The first request opens a connection that must not be
+	// reused by a function with a different policy.
+	req, err := http.NewRequest("POST", ts.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+
+	req = req.WithContext(depsUrl.WithFunction(req.Context(), "http.post"))
+	if _, err := client.Do(req); err == nil {
+		t.Fatal("expected validation error, but client.Do succeeded")
+	} else if !strings.HasSuffix(err.Error(), "no such host") {
+		t.Fatalf("expected validation error, but got %v", err)
+	}
+}
@@ -0,0 +1,119 @@
+package http
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// tokenBucket is a token bucket that refills at a constant rate
+// up to a maximum burst size.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int, now time.Time) *tokenBucket {
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   now,
+	}
+}
+
+// reserve takes a token from the bucket and returns how long
+// the caller must wait before the token becomes available.
+// Tokens may be borrowed ahead of time so that concurrent
+// callers are served in the order they reserved.
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.last = now
+	}
+
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// rateLimitedClient is a client that limits the rate of requests
+// made to each host.
+type rateLimitedClient struct {
+	client Client
+	rate   float64
+	burst  int
+
+	mu      *sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+// WithRateLimit wraps the client so that requests to each host are
+// limited to rate requests per second with bursts of up to burst requests.
+// Requests that exceed the limit wait until they are allowed to proceed.
+// If the client retries requests, the limit is applied to each attempt.
+func WithRateLimit(c Client, rate float64, burst int) Client {
+	if rate <= 0 {
+		return c
+	}
+	if rc, ok := c.(*retryClient); ok {
+		return rc.rewrap(WithRateLimit(rc.client, rate, burst))
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimitedClient{
+		client:  c,
+		rate:    rate,
+		burst:   burst,
+		mu:      &sync.Mutex{},
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+func (c *rateLimitedClient) unwrap() Client {
+	return c.client
+}
+
+func (c *rateLimitedClient) rewrap(cli Client) Client {
+	// Share the buckets so the limit is still applied
+	// to the derived client.
+	return &rateLimitedClient{
+		client:  cli,
+		rate:    c.rate,
+		burst:   c.burst,
+		mu:      c.mu,
+		buckets: c.buckets,
+	}
+}
+
+func (c *rateLimitedClient) bucket(host string, now time.Time) *tokenBucket {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	b, ok := c.buckets[host]
+	if !ok {
+		b = newTokenBucket(c.rate, c.burst, now)
+		c.buckets[host] = b
+	}
+	return b
+}
+
+func (c *rateLimitedClient) Do(req *http.Request) (*http.Response, error) {
+	now := time.Now()
+	delay := c.bucket(req.URL.Host, now).reserve(now)
+	if err := sleep(req.Context(), delay); err != nil {
+		return nil, err
+	}
+	return c.client.Do(req)
+}
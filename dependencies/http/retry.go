@@ -0,0 +1,238 @@
+package http
+
+import (
+	"context"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/internal/errors"
+)
+
+// maxDrainBody is the maximum amount of a failed response body we will read
+// before retrying so that the connection can be reused.
+const maxDrainBody = 64 * 1024
+
+// RetryPolicy configures how a client retries failed requests.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts made for a request,
+	// including the first one. A value less than or equal to one disables retries.
+	MaxAttempts int
+
+	// InitialBackoff is the delay before the first retry.
+	// Each subsequent retry doubles the previous delay.
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the delay between two attempts.
+	// This cap also applies to delays requested with a Retry-After header.
+	MaxBackoff time.Duration
+
+	// RetryOn is the list of response status codes that will be retried.
+	RetryOn []int
+}
+
+// DefaultRetryPolicy returns a retry policy that makes up to three attempts
+// and retries rate limited requests and unavailable upstream servers.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     30 * time.Second,
+		RetryOn: []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+	}
+}
+
+func (p RetryPolicy) shouldRetryStatus(code int) bool {
+	for _, c := range p.RetryOn {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff computes the delay before the given retry attempt.
+// The first retry is attempt one. The delay grows exponentially
+// and is jittered between half and the full computed delay.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := float64(p.InitialBackoff) * math.Pow(2, float64(attempt-1))
+	if p.MaxBackoff > 0 && d > float64(p.MaxBackoff) {
+		d = float64(p.MaxBackoff)
+	}
+	if d <= 0 {
+		return 0
+	}
+	half := d / 2
+	return time.Duration(half + rand.Float64()*half)
+}
+
+// retryAfter reads the Retry-After header from the response.
+// The header may either be a number of seconds or an HTTP date.
+func retryAfter(resp *http.Response, now time.Time) (time.Duration, bool) {
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		d := t.Sub(now)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+// retryClient is a client that retries requests according to a RetryPolicy.
+type retryClient struct {
+	client Client
+	policy RetryPolicy
+}
+
+// WithRetry wraps the client so that requests which fail with a transient
+// error or a retriable status code are retried according to the policy.
+// Requests that are not idempotent, such as a POST, are only retried when
+// they could not be sent or the server responds that it did not process them.
+// If the client already retries requests, its policy is replaced.
+func WithRetry(c Client, policy RetryPolicy) Client {
+	if rc, ok := c.(*retryClient); ok {
+		c = rc.client
+	}
+	if policy.MaxAttempts <= 1 {
+		return c
+	}
+	return &retryClient{client: c, policy: policy}
+}
+
+func (c *retryClient) unwrap() Client {
+	return c.client
+}
+
+func (c *retryClient) rewrap(cli Client) Client {
+	return &retryClient{client: cli, policy: c.policy}
+}
+
+func (c *retryClient) Do(req *http.Request) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 {
+			// The body was consumed by the previous attempt so obtain a new one.
+			if req.Body != nil && req.Body != http.NoBody {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				req = req.Clone(req.Context())
+				req.Body = body
+			}
+		}
+
+		resp, err := c.client.Do(req)
+		if attempt >= c.policy.MaxAttempts || !c.canRetry(req) {
+			return resp, err
+		}
+
+		var delay time.Duration
+		if err != nil {
+			if !isRetriableError(req.Context(), err) || !isIdempotent(req) && !isDialError(err) {
+				return nil, err
+			}
+			delay = c.policy.backoff(attempt)
+		} else {
+			if !c.policy.shouldRetryStatus(resp.StatusCode) || !isIdempotent(req) && !isUnprocessed(resp.StatusCode) {
+				return resp, nil
+			}
+			delay = c.policy.backoff(attempt)
+			if d, ok := retryAfter(resp, time.Now()); ok {
+				delay = d
+				if c.policy.MaxBackoff > 0 && delay > c.policy.MaxBackoff {
+					delay = c.policy.MaxBackoff
+				}
+			}
+			_, _ = io.CopyN(io.Discard, resp.Body, maxDrainBody)
+			_ = resp.Body.Close()
+		}
+
+		if err := sleep(req.Context(), delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// canRetry reports whether the request can be sent again.
+// Requests with a body can only be retried when the body can be recreated.
+func (c *retryClient) canRetry(req *http.Request) bool {
+	if req.Body == nil || req.Body == http.NoBody {
+		return true
+	}
+	return req.GetBody != nil
+}
+
+// isIdempotent reports whether sending the request more than once has
+// the same effect as sending it once. Other requests are only retried
+// when they did not reach the server or it did not process them.
+// Like net/http, requests with an idempotency key are idempotent.
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	if _, ok := req.Header["Idempotency-Key"]; ok {
+		return true
+	}
+	_, ok := req.Header["X-Idempotency-Key"]
+	return ok
+}
+
+// isDialError reports whether the error happened while connecting
+// to the server so the request was never sent.
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// isUnprocessed reports whether a response with the status code
+// means that the server did not process the request.
+func isUnprocessed(code int) bool {
+	return code == http.StatusTooManyRequests || code == http.StatusServiceUnavailable
+}
+
+// isRetriableError reports whether an error returned by a client
+// is transient. Errors produced by flux itself, such as a rejected
+// url, and canceled requests are not retried.
+func isRetriableError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var ferr *errors.Error
+	return !errors.As(err, &ferr)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), codes.Canceled, "http request canceled while waiting to be sent")
+	}
+}
@@ -0,0 +1,320 @@
+package http
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	depsUrl "github.com/InfluxCommunity/flux/dependencies/url"
+)
+
+func testRetryPolicy(maxAttempts int) RetryPolicy {
+	policy := DefaultRetryPolicy()
+	policy.MaxAttempts = maxAttempts
+	policy.InitialBackoff = time.Millisecond
+	policy.MaxBackoff = 10 * time.Millisecond
+	return policy
+}
+
+func TestWithRetry(t *testing.T) {
+	var attempts int32
+	var bodies []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	c := WithRetry(NewDefaultClient(depsUrl.PassValidator{}), testRetryPolicy(3))
+	req, err := http.NewRequest("POST", ts.URL, bytes.NewReader([]byte("body")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+
+	if want, got := http.StatusNoContent, resp.StatusCode; want != got {
+		t.Errorf("unexpected status code -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+	if want, got := int32(3), atomic.LoadInt32(&attempts); want != got {
+		t.Errorf("unexpected number of attempts -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+	for i, body := range bodies {
+		if body != "body" {
+			t.Errorf("unexpected body for attempt %d: %q", i+1, body)
+		}
+	}
+}
+
+func TestWithRetry_MaxAttempts(t *testing.T) {
+	var attempts int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer ts.Close()
+
+	c := WithRetry(NewDefaultClient(depsUrl.PassValidator{}), testRetryPolicy(2))
+	req, err := http.NewRequest("GET", ts.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+
+	if want, got := http.StatusTooManyRequests, resp.StatusCode; want != got {
+		t.Errorf("unexpected status code -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+	if want, got := int32(2), atomic.LoadInt32(&attempts); want != got {
+		t.Errorf("unexpected number of attempts -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+}
+
+func TestWithRetry_NotRetriable(t *testing.T) {
+	var attempts int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer ts.Close()
+
+	c := WithRetry(NewDefaultClient(depsUrl.PassValidator{}), testRetryPolicy(3))
+	req, err := http.NewRequest("GET", ts.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+
+	if want, got := int32(1), atomic.LoadInt32(&attempts); want != got {
+		t.Errorf("unexpected number of attempts -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+}
+
+func TestWithRetry_NotIdempotent(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		method string
+		header string
+		status int
+		want   int32
+	}{
+		{name: "post bad gateway", method: "POST", status: http.StatusBadGateway, want: 1},
+		{name: "post unavailable", method: "POST", status: http.StatusServiceUnavailable, want: 3},
+		{name: "post idempotency key", method: "POST", header: "Idempotency-Key", status: http.StatusBadGateway, want: 3},
+		{name: "put bad gateway", method: "PUT", status: http.StatusBadGateway, want: 3},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var attempts int32
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&attempts, 1)
+				w.WriteHeader(tc.status)
+			}))
+			defer ts.Close()
+
+			c := WithRetry(NewDefaultClient(depsUrl.PassValidator{}), testRetryPolicy(3))
+			req, err := http.NewRequest(tc.method, ts.URL, bytes.NewReader([]byte("body")))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.header != "" {
+				req.Header.Set(tc.header, "key")
+			}
+			resp, err := c.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_ = resp.Body.Close()
+
+			if want, got := tc.want, atomic.LoadInt32(&attempts); want != got {
+				t.Errorf("unexpected number of attempts -want/+got:\n\t- %d\n\t+ %d", want, got)
+			}
+		})
+	}
+}
+
+func TestWithRetry_NotIdempotentTransportError(t *testing.T) {
+	// The server closes the connection after it reads the request,
+	// so the request may have been processed.
+	var attempts int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		_, _ = io.ReadAll(r.Body)
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		_ = conn.Close()
+	}))
+	defer ts.Close()
+
+	c := WithRetry(NewDefaultClient(depsUrl.PassValidator{}), testRetryPolicy(3))
+	req, err := http.NewRequest("POST", ts.URL, bytes.NewReader([]byte("body")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Do(req); err == nil {
+		t.Fatal("expected an error when the connection is closed")
+	}
+	if want, got := int32(1), atomic.LoadInt32(&attempts); want != got {
+		t.Errorf("unexpected number of attempts -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+}
+
+func TestWithRetry_ValidationError(t *testing.T) {
+	var attempts int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+	}))
+	defer ts.Close()
+
+	c := WithRetry(NewDefaultClient(depsUrl.PrivateIPValidator{}), testRetryPolicy(3))
+	req, err := http.NewRequest("GET", ts.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Do(req); err == nil {
+		t.Fatal("expected private IP validation error, but client.Do succeeded")
+	}
+	if want, got := int32(0), atomic.LoadInt32(&attempts); want != got {
+		t.Errorf("unexpected number of attempts -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+}
+
+func TestWithRetry_WithTimeout(t *testing.T) {
+	c := WithRetry(NewLimitedDefaultClient(depsUrl.PassValidator{}), testRetryPolicy(3))
+	c, err := WithTimeout(c, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc, ok := c.(*retryClient)
+	if !ok {
+		t.Fatalf("expected a retry client, got %T", c)
+	}
+	if want, got := time.Second, rc.client.(*http.Client).Timeout; want != got {
+		t.Errorf("unexpected timeout -want/+got:\n\t- %v\n\t+ %v", want, got)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		header string
+		want   time.Duration
+		ok     bool
+	}{
+		{header: "", ok: false},
+		{header: "5", want: 5 * time.Second, ok: true},
+		{header: "Fri, 01 Jan 2021 00:00:10 GMT", want: 10 * time.Second, ok: true},
+		{header: "Thu, 31 Dec 2020 23:59:50 GMT", want: 0, ok: true},
+		{header: "soon", ok: false},
+	}
+	for _, tc := range testCases {
+		resp := &http.Response{Header: make(http.Header)}
+		if tc.header != "" {
+			resp.Header.Set("Retry-After", tc.header)
+		}
+		got, ok := retryAfter(resp, now)
+		if ok != tc.ok || got != tc.want {
+			t.Errorf("unexpected retry after for %q: got %v, %t want %v, %t", tc.header, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+	}
+	for attempt, max := range []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		400 * time.Millisecond,
+		800 * time.Millisecond,
+		time.Second,
+		time.Second,
+	} {
+		d := policy.backoff(attempt + 1)
+		if d < max/2 || d > max {
+			t.Errorf("backoff for attempt %d out of range [%v, %v]: %v", attempt+1, max/2, max, d)
+		}
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Now()
+	b := newTokenBucket(2, 2, now)
+	for i, want := range []time.Duration{0, 0, 500 * time.Millisecond, time.Second} {
+		if got := b.reserve(now); got != want {
+			t.Errorf("unexpected delay for reservation %d -want/+got:\n\t- %v\n\t+ %v", i, want, got)
+		}
+	}
+	// After two seconds, the borrowed tokens are paid back and
+	// the next reservation can proceed immediately.
+	if got := b.reserve(now.Add(2 * time.Second)); got != 0 {
+		t.Errorf("unexpected delay after refill: %v", got)
+	}
+}
+
+func TestWithRateLimit_PerHost(t *testing.T) {
+	c := WithRateLimit(NewDefaultClient(depsUrl.PassValidator{}), 1, 1).(*rateLimitedClient)
+	now := time.Now()
+	if d := c.bucket("a.example.com", now).reserve(now); d != 0 {
+		t.Errorf("unexpected delay for first host: %v", d)
+	}
+	if d := c.bucket("b.example.com", now).reserve(now); d != 0 {
+		t.Errorf("unexpected delay for second host: %v", d)
+	}
+	if d := c.bucket("a.example.com", now).reserve(now); d != time.Second {
+		t.Errorf("unexpected delay for first host: %v", d)
+	}
+}
+
+func TestWithRetry_ReplacesPolicy(t *testing.T) {
+	base := NewDefaultClient(depsUrl.PassValidator{})
+	c := WithRetry(WithRetry(base, testRetryPolicy(3)), testRetryPolicy(5))
+	rc, ok := c.(*retryClient)
+	if !ok {
+		t.Fatalf("unexpected client type %T", c)
+	}
+	if rc.client != base {
+		t.Errorf("retry client wraps another retry client")
+	}
+	if want, got := 5, rc.policy.MaxAttempts; want != got {
+		t.Errorf("unexpected max attempts -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+
+	if c := WithRetry(c, testRetryPolicy(1)); c != base {
+		t.Errorf("expected retries to be disabled, got %T", c)
+	}
+}
+
+func TestWithRateLimit_Retry(t *testing.T) {
+	c := WithRateLimit(WithRetry(NewDefaultClient(depsUrl.PassValidator{}), testRetryPolicy(3)), 1, 1)
+	rc, ok := c.(*retryClient)
+	if !ok {
+		t.Fatalf("expected the rate limit to be applied beneath the retries, got %T", c)
+	}
+	if _, ok := rc.client.(*rateLimitedClient); !ok {
+		t.Errorf("unexpected wrapped client type %T", rc.client)
+	}
+}
@@ -0,0 +1,447 @@
+package influxdb
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	stdhttp "net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/dependencies/http"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/influxdata/line-protocol/v2/lineprotocol"
+)
+
+// DefaultWriteBatchSize is the batch size used when
+// the write options do not set one.
+const DefaultWriteBatchSize = 5000
+
+// maxWriteErrorBody is the maximum size of an error response we will read.
+const maxWriteErrorBody = 64 * 1024
+
+// batchWriter writes points to the write endpoint in batches.
+//
+// A batch that is rejected by the server is counted as rejected
+// and does not stop the remaining batches from being written.
+// Any other failure stops the writer.
+type batchWriter struct {
+	ctx    context.Context
+	client http.Client
+	url    string
+	token  string
+	opts   WriteOptions
+
+	// mu guards the buffered batch, the summary and the errors.
+	mu      sync.Mutex
+	enc     lineprotocol.Encoder
+	batch   *writeBatch
+	summary map[string]*WriteSummary
+	err     error
+
+	// rejectErr is the error for the first rejected batch.
+	rejectErr error
+
+	// sendMu is held while a batch is sent so batches are sent one
+	// at a time and in the order they were buffered. It is acquired
+	// while mu is held and released once the batch has been sent.
+	sendMu sync.Mutex
+
+	done      chan struct{}
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// writeBatch holds the points sent in a single request.
+type writeBatch struct {
+	buf bytes.Buffer
+
+	// names holds the measurement of each line in buf.
+	names []string
+}
+
+func newBatchWriter(ctx context.Context, c *HttpClient, opts WriteOptions) (*batchWriter, error) {
+	if opts.BatchSize < 0 || opts.FlushInterval < 0 || opts.MaxRetries < 0 {
+		return nil, errors.New(codes.Invalid, "write options must not be negative")
+	}
+	if opts.BatchSize == 0 {
+		opts.BatchSize = DefaultWriteBatchSize
+	}
+
+	u, err := url.Parse(c.Config.Host)
+	if err != nil {
+		return nil, err
+	}
+	u.Path += "/api/v2/write"
+	params := make(url.Values)
+	params.Set("org", c.Config.Org.IdOrName())
+	params.Set("bucket", c.Config.Bucket.IdOrName())
+	params.Set("precision", "ns")
+	u.RawQuery = params.Encode()
+
+	// The retries of the options replace any retries of the client
+	// so that a batch is not written more often than requested.
+	client := http.WithRetry(c.Client, http.RetryPolicy{
+		MaxAttempts:    opts.MaxRetries + 1,
+		InitialBackoff: time.Second,
+		MaxBackoff:     time.Minute,
+		RetryOn: []int{
+			stdhttp.StatusTooManyRequests,
+			stdhttp.StatusServiceUnavailable,
+		},
+	})
+
+	w := &batchWriter{
+		ctx:     ctx,
+		client:  client,
+		url:     u.String(),
+		token:   c.Config.Token,
+		opts:    opts,
+		batch:   new(writeBatch),
+		summary: make(map[string]*WriteSummary),
+		done:    make(chan struct{}),
+	}
+	if opts.FlushInterval > 0 {
+		w.wg.Add(1)
+		go w.flushPeriodically()
+	}
+	return w, nil
+}
+
+var _ SummaryWriter = (*batchWriter)(nil)
+
+func (w *batchWriter) Write(metrics ...Metric) error {
+	for _, m := range metrics {
+		b, err := w.add(m)
+		if err != nil {
+			return err
+		} else if b == nil {
+			continue
+		}
+		if err := w.flush(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// add encodes the metric into the buffered batch. When the batch
+// is full, it is returned and the caller must flush it.
+func (w *batchWriter) add(m Metric) (*writeBatch, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.err != nil {
+		return nil, w.err
+	}
+	w.enc.Reset()
+	record, err := encodeMetric(&w.enc, m)
+	if err != nil {
+		// Wrap the error with failed precondition because
+		// the error was caused by user data.
+		return nil, errors.Wrap(err, codes.FailedPrecondition)
+	} else if len(record) == 0 {
+		return nil, nil
+	}
+	w.batch.buf.Write(record)
+	w.batch.names = append(w.batch.names, m.Name())
+
+	if len(w.batch.names) < w.opts.BatchSize {
+		return nil, nil
+	}
+	return w.takeBatch(), nil
+}
+
+// takeBatch replaces the buffered batch with an empty one and returns it.
+// It returns nil if no points are buffered. Otherwise, sendMu is acquired
+// and the caller must flush the batch. The lock must be held.
+func (w *batchWriter) takeBatch() *writeBatch {
+	if len(w.batch.names) == 0 {
+		return nil
+	}
+	b := w.batch
+	w.batch = new(writeBatch)
+	w.sendMu.Lock()
+	return b
+}
+
+func (w *batchWriter) flushPeriodically() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(w.opts.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			w.mu.Lock()
+			var b *writeBatch
+			if w.err == nil {
+				b = w.takeBatch()
+			}
+			w.mu.Unlock()
+			if b != nil {
+				_ = w.flush(b)
+			}
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// flush sends a batch returned by takeBatch and adds the result to
+// the summary. It releases sendMu once the batch has been sent.
+func (w *batchWriter) flush(b *writeBatch) error {
+	rejected, rejectErr, err := w.send(b)
+	w.sendMu.Unlock()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err != nil {
+		if w.err == nil {
+			w.err = err
+		}
+		return err
+	}
+	if rejectErr != nil && w.rejectErr == nil {
+		w.rejectErr = rejectErr
+	}
+	for i, name := range b.names {
+		s, ok := w.summary[name]
+		if !ok {
+			s = &WriteSummary{Measurement: name}
+			w.summary[name] = s
+		}
+		if rejected != nil && rejected[i] {
+			s.Rejected++
+		} else {
+			s.Written++
+		}
+	}
+	return nil
+}
+
+// send writes the batch to the write endpoint. If the server rejected
+// any of the points, rejectErr is non-nil and rejected reports which
+// lines of the batch were rejected. A non-nil err is returned if the
+// points could not be written for any other reason.
+func (w *batchWriter) send(b *writeBatch) (rejected []bool, rejectErr, err error) {
+	body := b.buf.Bytes()
+	if w.opts.Gzip {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(body); err != nil {
+			return nil, nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, nil, err
+		}
+		body = buf.Bytes()
+	}
+
+	req, err := stdhttp.NewRequestWithContext(w.ctx, "POST", w.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, err
+	}
+	if w.token != "" {
+		req.Header.Set("Authorization", "Token "+w.token)
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if w.opts.Gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode/100 == 2 {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil, nil, nil
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxWriteErrorBody))
+	if err != nil {
+		return nil, nil, errors.Newf(codes.Invalid, "error when reading response body: %s", err)
+	}
+	respErr := parseWriteError(resp.StatusCode, data)
+	switch resp.StatusCode {
+	case stdhttp.StatusBadRequest:
+		return rejectedLines(writeErrorMessage(data), b.names), respErr, nil
+	case stdhttp.StatusRequestEntityTooLarge, stdhttp.StatusUnprocessableEntity:
+		return rejectAll(len(b.names)), respErr, nil
+	default:
+		return nil, nil, respErr
+	}
+}
+
+var (
+	partialWriteRegexp = regexp.MustCompile(`(?i)partial write`)
+	lineErrorRegexp    = regexp.MustCompile(`\bline (\d+):`)
+	droppedRegexp      = regexp.MustCompile(`\bdropped=(\d+)`)
+	measurementRegexp  = regexp.MustCompile(`\bmeasurement "([^"]*)"`)
+)
+
+// rejectedLines reports which lines of a batch were rejected
+// given the message of a 400 response.
+//
+// The server accepts the valid points and rejects the rest when the
+// message reports a partial write. The rejected points are either
+// listed by line number or counted with dropped=N. Counted points are
+// attributed to the measurement named in the message, and otherwise
+// to the first lines of the batch. Any other message means that
+// the whole batch was rejected.
+func rejectedLines(msg string, names []string) []bool {
+	rejected := make([]bool, len(names))
+	if !partialWriteRegexp.MatchString(msg) {
+		return rejectAll(len(names))
+	}
+
+	found := false
+	for _, m := range lineErrorRegexp.FindAllStringSubmatch(msg, -1) {
+		// Lines are numbered from one.
+		if n, err := strconv.Atoi(m[1]); err == nil && n >= 1 && n <= len(names) {
+			rejected[n-1], found = true, true
+		}
+	}
+	if found {
+		return rejected
+	}
+
+	var dropped int
+	for _, m := range droppedRegexp.FindAllStringSubmatch(msg, -1) {
+		if n, err := strconv.Atoi(m[1]); err == nil {
+			dropped += n
+		}
+	}
+	if dropped == 0 {
+		return rejectAll(len(names))
+	}
+	if m := measurementRegexp.FindStringSubmatch(msg); m != nil {
+		for i, name := range names {
+			if dropped > 0 && name == m[1] {
+				rejected[i] = true
+				dropped--
+			}
+		}
+	}
+	for i := range rejected {
+		if dropped > 0 && !rejected[i] {
+			rejected[i] = true
+			dropped--
+		}
+	}
+	return rejected
+}
+
+// rejectAll reports that all n lines of a batch were rejected.
+func rejectAll(n int) []bool {
+	rejected := make([]bool, n)
+	for i := range rejected {
+		rejected[i] = true
+	}
+	return rejected
+}
+
+// writeErrorMessage returns the message of an influxdb error
+// response or the body itself if it is not a valid error.
+func writeErrorMessage(data []byte) string {
+	var e struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(data, &e); err == nil && e.Message != "" {
+		return e.Message
+	}
+	return string(data)
+}
+
+// parseWriteError parses an influxdb error from the response body.
+// If the body is not a valid error, the error code is
+// derived from the status code.
+func parseWriteError(statusCode int, data []byte) error {
+	var e interface{}
+	if err := json.Unmarshal(data, &e); err == nil {
+		if err := handleError(e); err != nil {
+			return err
+		}
+	}
+	code := codes.Unknown
+	switch statusCode {
+	case stdhttp.StatusBadRequest, stdhttp.StatusUnprocessableEntity, stdhttp.StatusRequestEntityTooLarge:
+		code = codes.Invalid
+	case stdhttp.StatusUnauthorized:
+		code = codes.Unauthenticated
+	case stdhttp.StatusForbidden:
+		code = codes.PermissionDenied
+	case stdhttp.StatusNotFound:
+		code = codes.NotFound
+	case stdhttp.StatusTooManyRequests:
+		code = codes.ResourceExhausted
+	case stdhttp.StatusServiceUnavailable:
+		code = codes.Unavailable
+	}
+	return errors.Newf(code, "write failed with status %d: %s", statusCode, bytes.TrimSpace(data))
+}
+
+// Close writes any buffered points. It returns an error if
+// any of the points were rejected. Calling Close more than
+// once returns the same error.
+func (w *batchWriter) Close() error {
+	w.closeOnce.Do(func() {
+		close(w.done)
+		w.wg.Wait()
+		w.closeErr = w.close()
+	})
+	return w.closeErr
+}
+
+func (w *batchWriter) close() error {
+	w.mu.Lock()
+	var b *writeBatch
+	if w.err == nil {
+		b = w.takeBatch()
+	}
+	w.mu.Unlock()
+	if b != nil {
+		if err := w.flush(b); err != nil {
+			return err
+		}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return w.err
+	}
+	if w.rejectErr != nil {
+		var rejected int64
+		for _, s := range w.summary {
+			rejected += s.Rejected
+		}
+		return errors.Wrapf(w.rejectErr, codes.FailedPrecondition, "influxdb rejected %d points", rejected)
+	}
+	return nil
+}
+
+func (w *batchWriter) Summary() []WriteSummary {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	summary := make([]WriteSummary, 0, len(w.summary))
+	for _, s := range w.summary {
+		summary = append(summary, *s)
+	}
+	sort.Slice(summary, func(i, j int) bool {
+		return summary[i].Measurement < summary[j].Measurement
+	})
+	return summary
+}
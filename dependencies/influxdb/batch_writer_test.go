@@ -0,0 +1,354 @@
+package influxdb_test
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/dependencies/dependenciestest"
+	fluxhttp "github.com/InfluxCommunity/flux/dependencies/http"
+	"github.com/InfluxCommunity/flux/dependencies/influxdb"
+	"github.com/InfluxCommunity/flux/dependency"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/google/go-cmp/cmp"
+)
+
+// writeServer records the line protocol of each write request.
+type writeServer struct {
+	mu       sync.Mutex
+	bodies   []string
+	encoding []string
+
+	// handler may reply to a request instead of accepting it.
+	handler func(w http.ResponseWriter, attempt int) bool
+}
+
+func (s *writeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	body := io.Reader(r.Body)
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		body = zr
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	s.bodies = append(s.bodies, string(data))
+	s.encoding = append(s.encoding, r.Header.Get("Content-Encoding"))
+	if s.handler != nil && s.handler(w, len(s.bodies)) {
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func newBatchWriter(t *testing.T, url string, opts influxdb.WriteOptions) influxdb.SummaryWriter {
+	t.Helper()
+	deps := dependenciestest.Default()
+	deps.Deps.Deps.HTTPClient = http.DefaultClient
+	ctx, span := dependency.Inject(context.Background(), deps)
+	t.Cleanup(span.Finish)
+
+	h := influxdb.HttpProvider{}
+	w, err := h.WriterWithOptionsFor(ctx, influxdb.Config{
+		Org:    influxdb.NameOrID{Name: "myorg"},
+		Bucket: influxdb.NameOrID{Name: "mybucket"},
+		Host:   url,
+		Token:  "mytoken",
+	}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w.(influxdb.SummaryWriter)
+}
+
+func TestBatchWriter(t *testing.T) {
+	s := &writeServer{}
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	w := newBatchWriter(t, ts.URL, influxdb.WriteOptions{
+		BatchSize: 2,
+		Gzip:      true,
+	})
+	if err := w.Write(cpuMetric(95, 1), cpuMetric(96, 2), diskMetric(45, 1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	wantBodies := []string{
+		"cpu,host=localhost,id=cpua usage_user=95,log=\"message\" 1510876800000000001\n" +
+			"cpu,host=localhost,id=cpua usage_user=96,log=\"message\" 1510876800000000002\n",
+		"disk,id=/dev/sdb usage_disk=45,log=\"disk message\" 1510876800000000001\n",
+	}
+	if !cmp.Equal(wantBodies, s.bodies) {
+		t.Errorf("unexpected bodies -want/+got:\n%s", cmp.Diff(wantBodies, s.bodies))
+	}
+	if want := []string{"gzip", "gzip"}; !cmp.Equal(want, s.encoding) {
+		t.Errorf("unexpected content encoding -want/+got:\n%s", cmp.Diff(want, s.encoding))
+	}
+
+	wantSummary := []influxdb.WriteSummary{
+		{Measurement: "cpu", Written: 2},
+		{Measurement: "disk", Written: 1},
+	}
+	if got := w.Summary(); !cmp.Equal(wantSummary, got) {
+		t.Errorf("unexpected summary -want/+got:\n%s", cmp.Diff(wantSummary, got))
+	}
+}
+
+func TestBatchWriter_Retry(t *testing.T) {
+	s := &writeServer{
+		handler: func(w http.ResponseWriter, attempt int) bool {
+			if attempt > 1 {
+				return false
+			}
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return true
+		},
+	}
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	w := newBatchWriter(t, ts.URL, influxdb.WriteOptions{MaxRetries: 1})
+	if err := w.Write(cpuMetric(95, 1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 2, len(s.bodies); want != got {
+		t.Fatalf("unexpected number of requests -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+	wantSummary := []influxdb.WriteSummary{{Measurement: "cpu", Written: 1}}
+	if got := w.Summary(); !cmp.Equal(wantSummary, got) {
+		t.Errorf("unexpected summary -want/+got:\n%s", cmp.Diff(wantSummary, got))
+	}
+}
+
+func TestBatchWriter_NoRetries(t *testing.T) {
+	s := &writeServer{
+		handler: func(w http.ResponseWriter, attempt int) bool {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return true
+		},
+	}
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	// The client of the dependencies retries requests
+	// as it does when the flux command is run with retries.
+	deps := flux.NewDefaultDependencies()
+	client, err := deps.HTTPClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	deps.Deps.HTTPClient = fluxhttp.WithRetry(client, fluxhttp.DefaultRetryPolicy())
+	ctx := deps.Inject(context.Background())
+
+	h := influxdb.HttpProvider{}
+	w, err := h.WriterWithOptionsFor(ctx, influxdb.Config{
+		Org:    influxdb.NameOrID{Name: "myorg"},
+		Bucket: influxdb.NameOrID{Name: "mybucket"},
+		Host:   ts.URL,
+		Token:  "mytoken",
+	}, influxdb.WriteOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = w.Write(cpuMetric(95, 1))
+	_ = w.Close()
+	if want, got := 1, len(s.bodies); want != got {
+		t.Fatalf("unexpected number of requests -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+}
+
+func TestBatchWriter_Rejected(t *testing.T) {
+	s := &writeServer{
+		handler: func(w http.ResponseWriter, attempt int) bool {
+			if attempt != 1 {
+				return false
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = io.WriteString(w, `{"code":"invalid","message":"unable to parse points"}`)
+			return true
+		},
+	}
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	w := newBatchWriter(t, ts.URL, influxdb.WriteOptions{BatchSize: 1})
+	if err := w.Write(cpuMetric(95, 1), diskMetric(45, 1)); err != nil {
+		t.Fatal(err)
+	}
+
+	err := w.Close()
+	if err == nil {
+		t.Fatal("expected an error for the rejected points")
+	}
+	if want, got := codes.FailedPrecondition, errors.Code(err); want != got {
+		t.Errorf("unexpected error code -want/+got:\n\t- %s\n\t+ %s", want, got)
+	}
+	if !strings.Contains(err.Error(), "unable to parse points") {
+		t.Errorf("expected the error to contain the rejection message, got: %s", err)
+	}
+
+	// The batch after the rejected one is still written.
+	wantSummary := []influxdb.WriteSummary{
+		{Measurement: "cpu", Rejected: 1},
+		{Measurement: "disk", Written: 1},
+	}
+	if got := w.Summary(); !cmp.Equal(wantSummary, got) {
+		t.Errorf("unexpected summary -want/+got:\n%s", cmp.Diff(wantSummary, got))
+	}
+}
+
+func TestBatchWriter_PartialWrite(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		message string
+		want    []influxdb.WriteSummary
+	}{
+		{
+			name:    "lines",
+			message: `partial write has occurred, errors encountered on line(s):\nline 2: invalid field value`,
+			want: []influxdb.WriteSummary{
+				{Measurement: "cpu", Written: 1, Rejected: 1},
+				{Measurement: "disk", Written: 1},
+			},
+		},
+		{
+			name:    "dropped",
+			message: `partial write: field type conflict: input field \"usage_disk\" on measurement \"disk\" is type float, already exists as type integer dropped=1`,
+			want: []influxdb.WriteSummary{
+				{Measurement: "cpu", Written: 2},
+				{Measurement: "disk", Rejected: 1},
+			},
+		},
+		{
+			name:    "dropped without measurement",
+			message: `partial write: points beyond retention policy dropped=2`,
+			want: []influxdb.WriteSummary{
+				{Measurement: "cpu", Rejected: 2},
+				{Measurement: "disk", Written: 1},
+			},
+		},
+		{
+			name:    "not partial",
+			message: `unable to parse points`,
+			want: []influxdb.WriteSummary{
+				{Measurement: "cpu", Rejected: 2},
+				{Measurement: "disk", Rejected: 1},
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &writeServer{
+				handler: func(w http.ResponseWriter, attempt int) bool {
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusBadRequest)
+					_, _ = io.WriteString(w, `{"code":"invalid","message":"`+tt.message+`"}`)
+					return true
+				},
+			}
+			ts := httptest.NewServer(s)
+			defer ts.Close()
+
+			w := newBatchWriter(t, ts.URL, influxdb.WriteOptions{})
+			if err := w.Write(cpuMetric(95, 1), cpuMetric(96, 2), diskMetric(45, 1)); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.Close(); err == nil {
+				t.Fatal("expected an error for the rejected points")
+			}
+			if got := w.Summary(); !cmp.Equal(tt.want, got) {
+				t.Errorf("unexpected summary -want/+got:\n%s", cmp.Diff(tt.want, got))
+			}
+		})
+	}
+}
+
+func TestBatchWriter_FlushInterval(t *testing.T) {
+	s := &writeServer{}
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	w := newBatchWriter(t, ts.URL, influxdb.WriteOptions{
+		BatchSize:     3,
+		FlushInterval: time.Millisecond,
+	})
+	for i := 0; i < 100; i++ {
+		if err := w.Write(cpuMetric(float64(i), i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// Closing the writer again returns the same result.
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Every point is sent once and in the order it was written.
+	var lines []string
+	for _, body := range s.bodies {
+		lines = append(lines, strings.Split(strings.TrimSuffix(body, "\n"), "\n")...)
+	}
+	if want, got := 100, len(lines); want != got {
+		t.Fatalf("unexpected number of points -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+	for i, line := range lines {
+		if want := fmt.Sprintf("usage_user=%d,", i); !strings.Contains(line, want) {
+			t.Fatalf("line %d is out of order: %s", i, line)
+		}
+	}
+	wantSummary := []influxdb.WriteSummary{{Measurement: "cpu", Written: 100}}
+	if got := w.Summary(); !cmp.Equal(wantSummary, got) {
+		t.Errorf("unexpected summary -want/+got:\n%s", cmp.Diff(wantSummary, got))
+	}
+}
+
+func TestBatchWriter_CloseTwice(t *testing.T) {
+	s := &writeServer{
+		handler: func(w http.ResponseWriter, attempt int) bool {
+			w.WriteHeader(http.StatusBadRequest)
+			return true
+		},
+	}
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	w := newBatchWriter(t, ts.URL, influxdb.WriteOptions{FlushInterval: time.Hour})
+	if err := w.Write(cpuMetric(95, 1)); err != nil {
+		t.Fatal(err)
+	}
+	err := w.Close()
+	if err == nil {
+		t.Fatal("expected an error for the rejected points")
+	}
+	if got := w.Close(); got != err {
+		t.Errorf("unexpected error from the second close -want/+got:\n\t- %v\n\t+ %v", err, got)
+	}
+}
@@ -34,17 +34,25 @@ type HttpProvider struct {
 	DefaultConfig Config
 }
 
-var _ Provider = HttpProvider{}
+var (
+	_ TransformedReaderProvider = HttpProvider{}
+	_ WriterWithOptionsProvider = HttpProvider{}
+)
 
 func (h HttpProvider) ReaderFor(ctx context.Context, conf Config, bounds flux.Bounds, predicateSet PredicateSet) (Reader, error) {
+	return h.TransformedReaderFor(ctx, conf, bounds, predicateSet, nil)
+}
+
+func (h HttpProvider) TransformedReaderFor(ctx context.Context, conf Config, bounds flux.Bounds, predicateSet PredicateSet, transformations Transformations) (Reader, error) {
 	c, err := h.clientFor(ctx, conf)
 	if err != nil {
 		return nil, err
 	}
 	return filteredHttpReader{
-		HttpClient:   c,
-		Bounds:       bounds,
-		PredicateSet: predicateSet,
+		HttpClient:      c,
+		Bounds:          bounds,
+		PredicateSet:    predicateSet,
+		Transformations: transformations,
 	}, nil
 }
 
@@ -84,6 +92,14 @@ func (h HttpProvider) WriterFor(ctx context.Context, conf Config) (Writer, error
 	return newHttpWriter(writer)
 }
 
+func (h HttpProvider) WriterWithOptionsFor(ctx context.Context, conf Config, opts WriteOptions) (Writer, error) {
+	httpClient, err := h.clientFor(ctx, conf)
+	if err != nil {
+		return nil, err
+	}
+	return newBatchWriter(ctx, httpClient, opts)
+}
+
 func (h HttpProvider) clientFor(ctx context.Context, conf Config) (*HttpClient, error) {
 	deps := flux.GetDependencies(ctx)
 	var (
@@ -353,8 +369,9 @@ func (h *HttpClient) includeImport(imports map[string]*ast.ImportDeclaration, na
 
 type filteredHttpReader struct {
 	*HttpClient
-	Bounds       flux.Bounds
-	PredicateSet PredicateSet
+	Bounds          flux.Bounds
+	PredicateSet    PredicateSet
+	Transformations Transformations
 }
 
 func (h filteredHttpReader) Read(ctx context.Context, f func(flux.Table) error, mem memory.Allocator) error {
@@ -401,6 +418,20 @@ func (h filteredHttpReader) Read(ctx context.Context, f func(flux.Table) error,
 		}
 	}
 
+	for _, t := range h.Transformations {
+		query = &ast.PipeExpression{
+			Argument: query,
+			Call: &ast.CallExpression{
+				Callee: &ast.Identifier{Name: t.Kind},
+				Arguments: []ast.Expression{
+					&ast.ObjectExpression{
+						Properties: t.Arguments,
+					},
+				},
+			},
+		}
+	}
+
 	file := h.newFile(imports)
 	file.Body = []ast.Statement{
 		&ast.ExpressionStatement{Expression: query},
@@ -507,7 +538,7 @@ var _ Writer = &httpWriter{}
 func (h *httpWriter) Write(metric ...Metric) error {
 	var enc lineprotocol.Encoder
 	for _, m := range metric {
-		record, err := h.encodeMetric(&enc, m)
+		record, err := encodeMetric(&enc, m)
 		if err != nil {
 			h.writer.Flush()
 
@@ -530,7 +561,9 @@ func (h *httpWriter) Write(metric ...Metric) error {
 	return nil
 }
 
-func (h *httpWriter) encodeMetric(enc *lineprotocol.Encoder, m Metric) ([]byte, error) {
+// encodeMetric encodes the metric as a line of line protocol.
+// It returns nil if the metric has no fields that can be written.
+func encodeMetric(enc *lineprotocol.Encoder, m Metric) ([]byte, error) {
 	enc.StartLine(m.Name())
 
 	// The line protocol encoder checks for ordering so we need to ensure that
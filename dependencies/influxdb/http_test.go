@@ -3,6 +3,7 @@ package influxdb_test
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"math"
@@ -12,11 +13,13 @@ import (
 	"time"
 
 	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/ast"
 	"github.com/InfluxCommunity/flux/codes"
 	"github.com/InfluxCommunity/flux/dependencies/dependenciestest"
 	"github.com/InfluxCommunity/flux/dependencies/influxdb"
 	"github.com/InfluxCommunity/flux/dependency"
 	influxdb2 "github.com/InfluxCommunity/flux/stdlib/influxdata/influxdb"
+	"github.com/apache/arrow/go/v7/arrow/memory"
 	"github.com/google/go-cmp/cmp"
 )
 
@@ -300,3 +303,76 @@ func TestHttpWriter_Write_Error(t *testing.T) {
 		}
 	}
 }
+
+func TestHttpProvider_TransformedReaderFor(t *testing.T) {
+	h := influxdb.HttpProvider{
+		DefaultConfig: influxdb.Config{
+			Host:  "http://myhost.com:8085",
+			Token: "mytoken",
+		},
+	}
+	deps := dependenciestest.Default()
+	roundTripper := &RoundTrip{
+		RequestValidator: func(req *http.Request) error {
+			if val, exp := req.URL.Path, "/api/v2/query"; val != exp {
+				return fmt.Errorf("path does not match, expected %s, got %s", exp, val)
+			}
+			return nil
+		},
+	}
+	deps.Deps.Deps.HTTPClient = &http.Client{
+		Transport: roundTripper,
+	}
+	ctx, span := dependency.Inject(context.Background(), deps)
+	defer span.Finish()
+
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	reader, err := h.TransformedReaderFor(ctx, influxdb.Config{
+		Org:    influxdb.NameOrID{Name: "myorg"},
+		Bucket: influxdb.NameOrID{Name: "mybucket"},
+	}, flux.Bounds{
+		Start: flux.Time{IsRelative: true, Relative: -time.Hour},
+		Stop:  flux.Time{IsRelative: true},
+		Now:   now,
+	}, nil, influxdb.Transformations{
+		{
+			Kind: "group",
+			Arguments: []*ast.Property{{
+				Key: &ast.Identifier{Name: "columns"},
+				Value: &ast.ArrayExpression{
+					Elements: []ast.Expression{&ast.StringLiteral{Value: "host"}},
+				},
+			}},
+		},
+		{
+			Kind: "count",
+			Arguments: []*ast.Property{{
+				Key:   &ast.Identifier{Name: "column"},
+				Value: &ast.StringLiteral{Value: "_value"},
+			}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := reader.Read(ctx, func(flux.Table) error { return nil }, memory.DefaultAllocator); err != nil {
+		t.Fatal(err)
+	}
+	if roundTripper.RequestValidatorError != nil {
+		t.Errorf("Query validation error = %v", roundTripper.RequestValidatorError)
+	}
+
+	var body struct {
+		Query string `json:"query"`
+	}
+	if err := json.Unmarshal(roundTripper.Bodies.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	// The transformations are applied in order after the range.
+	rangeIdx := strings.Index(body.Query, "|> range(")
+	groupIdx := strings.Index(body.Query, `|> group(columns: ["host"])`)
+	countIdx := strings.Index(body.Query, `|> count(column: "_value")`)
+	if rangeIdx < 0 || groupIdx < rangeIdx || countIdx < groupIdx {
+		t.Errorf("unexpected query:\n%s", body.Query)
+	}
+}
@@ -3,8 +3,10 @@ package influxdb
 import (
 	"context"
 	"io"
+	"time"
 
 	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/ast"
 	"github.com/InfluxCommunity/flux/codes"
 	"github.com/InfluxCommunity/flux/internal/errors"
 	"github.com/InfluxCommunity/flux/interpreter"
@@ -77,6 +79,32 @@ func (ps PredicateSet) Copy() PredicateSet {
 	return nps
 }
 
+// Transformation is a function call that the influxdb instance
+// applies to the filtered data before returning it.
+type Transformation struct {
+	// Kind is the name of the function to call.
+	Kind string
+
+	// Arguments are the arguments passed to the function.
+	Arguments []*ast.Property
+}
+
+// Transformations holds a list of transformations that are applied
+// in order after the predicates.
+type Transformations []Transformation
+
+// Copy produces a copy of the Transformations.
+// The arguments are immutable and are shared with the copy.
+func (ts Transformations) Copy() Transformations {
+	if ts == nil {
+		return nil
+	}
+
+	nts := make([]Transformation, len(ts))
+	copy(nts, ts)
+	return nts
+}
+
 // Provider is an interface for creating a Reader that will read
 // data from an influxdb instance.
 //
@@ -100,6 +128,32 @@ type Provider interface {
 	WriterFor(ctx context.Context, conf Config) (Writer, error)
 }
 
+// TransformedReaderProvider is a Provider that can construct a Reader
+// that has the influxdb instance apply transformations to the data.
+//
+// This is an optional interface. The planner only pushes transformations
+// into a remote from when the Provider implements it.
+type TransformedReaderProvider interface {
+	Provider
+
+	// TransformedReaderFor will construct a Reader that applies the
+	// transformations to the data after it has been filtered.
+	TransformedReaderFor(ctx context.Context, conf Config, bounds flux.Bounds, predicateSet PredicateSet, transformations Transformations) (Reader, error)
+}
+
+// WriterWithOptionsProvider is a Provider that can construct a Writer
+// that batches, compresses and retries writes according to WriteOptions.
+//
+// This is an optional interface. Write options can only be
+// used when the Provider implements it.
+type WriterWithOptionsProvider interface {
+	Provider
+
+	// WriterWithOptionsFor will construct a Writer that sends points
+	// according to the write options.
+	WriterWithOptionsFor(ctx context.Context, conf Config, opts WriteOptions) (Writer, error)
+}
+
 // Reader reads tables from an influxdb instance.
 type Reader interface {
 	// Read will produce flux.Table values using the memory.Allocator
@@ -128,6 +182,46 @@ type Writer interface {
 	Write(...Metric) error
 }
 
+// WriteOptions configures how a Writer sends points to an influxdb instance.
+type WriteOptions struct {
+	// BatchSize is the maximum number of points sent in a single request.
+	BatchSize int
+
+	// FlushInterval is the maximum time points are buffered before they are sent.
+	// When it is zero, points are only sent when a batch is full or the writer is closed.
+	FlushInterval time.Duration
+
+	// Gzip compresses the request bodies.
+	Gzip bool
+
+	// MaxRetries is the number of times a request that is rejected
+	// because of rate limiting or an unavailable server is retried.
+	MaxRetries int
+}
+
+// IsZero reports whether none of the write options have been set.
+func (o WriteOptions) IsZero() bool {
+	return o == WriteOptions{}
+}
+
+// WriteSummary reports the number of points written and
+// rejected for a measurement.
+type WriteSummary struct {
+	Measurement string
+	Written     int64
+	Rejected    int64
+}
+
+// SummaryWriter is a Writer that reports how many points
+// it has written for each measurement.
+type SummaryWriter interface {
+	Writer
+
+	// Summary returns the summary for each measurement sorted
+	// by measurement. It should be called after Close.
+	Summary() []WriteSummary
+}
+
 // UnimplementedProvider provides default implementations for a Provider.
 // This implements all of the Provider methods by returning an error
 // with the code codes.Unimplemented.
@@ -0,0 +1,306 @@
+package url
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// Policy is a set of rules that restrict which URLs may be reached.
+//
+// A URL must satisfy both the host rules and the IP rules.
+// Deny rules always take precedence over allow rules, and an
+// empty allow list allows everything that is not denied.
+type Policy struct {
+	// Schemes is the list of URL schemes that are allowed.
+	Schemes []string `yaml:"schemes"`
+
+	// AllowHosts and DenyHosts are lists of hostnames.
+	// A name prefixed with "*." matches the name and all of its subdomains.
+	// No other use of "*" is allowed.
+	AllowHosts []string `yaml:"allow_hosts"`
+	DenyHosts  []string `yaml:"deny_hosts"`
+
+	// AllowCIDRs and DenyCIDRs are lists of IP ranges in CIDR notation.
+	AllowCIDRs []string `yaml:"allow_cidrs"`
+	DenyCIDRs  []string `yaml:"deny_cidrs"`
+
+	// DenyPrivate denies the private IP ranges that are
+	// also denied by the PrivateIPValidator.
+	DenyPrivate bool `yaml:"deny_private"`
+}
+
+// PolicyConfig configures a PolicyValidator.
+type PolicyConfig struct {
+	// Default is the policy for functions without their own policy.
+	Default Policy `yaml:"default"`
+
+	// Functions maps a function to the policy used in place of the default.
+	// Functions are named by their package path and name such as
+	// "http.post", "http/requests.do" or "sql.from".
+	Functions map[string]Policy `yaml:"functions"`
+}
+
+// ParsePolicyConfig reads a YAML encoded PolicyConfig.
+func ParsePolicyConfig(r io.Reader) (PolicyConfig, error) {
+	var config PolicyConfig
+	dec := yaml.NewDecoder(r)
+	dec.SetStrict(true)
+	if err := dec.Decode(&config); err != nil && err != io.EOF {
+		return PolicyConfig{}, errors.Wrap(err, codes.Invalid, "invalid url policy")
+	}
+	return config, nil
+}
+
+// LoadPolicyValidator reads a PolicyConfig from a YAML file
+// and creates a PolicyValidator from it.
+func LoadPolicyValidator(path string) (*PolicyValidator, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	config, err := ParsePolicyConfig(f)
+	if err != nil {
+		return nil, err
+	}
+	return NewPolicyValidator(config)
+}
+
+// PolicyValidator validates URLs using a configurable Policy
+// that may be different for each function.
+type PolicyValidator struct {
+	policy    *compiledPolicy
+	functions map[string]*PolicyValidator
+}
+
+// NewPolicyValidator creates a PolicyValidator from the config.
+func NewPolicyValidator(config PolicyConfig) (*PolicyValidator, error) {
+	policy, err := compilePolicy(config.Default)
+	if err != nil {
+		return nil, err
+	}
+	v := &PolicyValidator{
+		policy:    policy,
+		functions: make(map[string]*PolicyValidator, len(config.Functions)),
+	}
+	for name, p := range config.Functions {
+		policy, err := compilePolicy(p)
+		if err != nil {
+			return nil, errors.Wrapf(err, codes.Invalid, "invalid url policy for function %q", name)
+		}
+		v.functions[name] = &PolicyValidator{policy: policy}
+	}
+	return v, nil
+}
+
+// ForFunction returns the validator for the named function.
+func (v *PolicyValidator) ForFunction(name string) Validator {
+	if fv, ok := v.functions[name]; ok {
+		return fv
+	}
+	return v
+}
+
+func (v *PolicyValidator) Validate(u *url.URL) error {
+	if !v.policy.allowScheme(u.Scheme) {
+		return errors.Newf(codes.Invalid, "url scheme %q is not allowed", u.Scheme)
+	}
+
+	host := u.Hostname()
+	if ip := net.ParseIP(host); ip != nil {
+		return v.ValidateIP(ip)
+	}
+	if !v.policy.allowHost(host) {
+		// Intentionally return a vague message that we cannot connect to the host.
+		return errors.New(codes.Invalid, "no such host")
+	}
+	if !v.policy.hasIPRules() {
+		return nil
+	}
+
+	ips, err := net.LookupIP(host)
+	if err != nil {
+		return err
+	}
+	for _, ip := range ips {
+		if err := v.ValidateIP(ip); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *PolicyValidator) ValidateIP(ip net.IP) error {
+	if !v.policy.allowIP(ip) {
+		// Intentionally return a vague message that we cannot connect to the host.
+		return errors.New(codes.Invalid, "no such host")
+	}
+	return nil
+}
+
+type compiledPolicy struct {
+	schemes     []string
+	allowHosts  []string
+	denyHosts   []string
+	allowCIDRs  []*net.IPNet
+	denyCIDRs   []*net.IPNet
+	denyPrivate bool
+}
+
+func compilePolicy(p Policy) (*compiledPolicy, error) {
+	cp := &compiledPolicy{
+		schemes:     lowerAll(p.Schemes),
+		allowHosts:  lowerAll(p.AllowHosts),
+		denyHosts:   lowerAll(p.DenyHosts),
+		denyPrivate: p.DenyPrivate,
+	}
+	if err := checkHostPatterns(cp.allowHosts); err != nil {
+		return nil, err
+	}
+	if err := checkHostPatterns(cp.denyHosts); err != nil {
+		return nil, err
+	}
+	var err error
+	if cp.allowCIDRs, err = parseCIDRs(p.AllowCIDRs); err != nil {
+		return nil, err
+	}
+	if cp.denyCIDRs, err = parseCIDRs(p.DenyCIDRs); err != nil {
+		return nil, err
+	}
+	return cp, nil
+}
+
+func lowerAll(ss []string) []string {
+	if len(ss) == 0 {
+		return nil
+	}
+	lower := make([]string, len(ss))
+	for i, s := range ss {
+		lower[i] = strings.ToLower(strings.TrimSuffix(s, "."))
+	}
+	return lower
+}
+
+// checkHostPatterns returns an error for patterns that use "*"
+// other than as the "*." prefix of a name.
+func checkHostPatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		name := strings.TrimPrefix(pattern, "*.")
+		if name == "" || strings.Contains(name, "*") {
+			return errors.Newf(codes.Invalid, "invalid host pattern %q: only a \"*.\" prefix is supported", pattern)
+		}
+	}
+	return nil
+}
+
+func parseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	blocks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, block, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, errors.Newf(codes.Invalid, "invalid CIDR %q: %v", cidr, err)
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks, nil
+}
+
+func (p *compiledPolicy) allowScheme(scheme string) bool {
+	if len(p.schemes) == 0 {
+		return true
+	}
+	scheme = strings.ToLower(scheme)
+	for _, s := range p.schemes {
+		if s == scheme {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *compiledPolicy) allowHost(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if matchHost(p.denyHosts, host) {
+		return false
+	}
+	return len(p.allowHosts) == 0 || matchHost(p.allowHosts, host)
+}
+
+func (p *compiledPolicy) hasIPRules() bool {
+	return len(p.allowCIDRs) > 0 || len(p.denyCIDRs) > 0 || p.denyPrivate
+}
+
+func (p *compiledPolicy) allowIP(ip net.IP) bool {
+	if p.denyPrivate && isPrivateIP(ip) {
+		return false
+	}
+	if containsIP(p.denyCIDRs, ip) {
+		return false
+	}
+	return len(p.allowCIDRs) == 0 || containsIP(p.allowCIDRs, ip)
+}
+
+// matchHost reports whether the host matches any of the patterns.
+func matchHost(patterns []string, host string) bool {
+	for _, pattern := range patterns {
+		if name := strings.TrimPrefix(pattern, "*."); name != pattern {
+			if host == name || strings.HasSuffix(host, "."+name) {
+				return true
+			}
+		} else if pattern == host {
+			return true
+		}
+	}
+	return false
+}
+
+func containsIP(blocks []*net.IPNet, ip net.IP) bool {
+	for _, block := range blocks {
+		if block.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// FunctionValidator is a Validator that applies
+// a different validator to each function.
+type FunctionValidator interface {
+	Validator
+	ForFunction(name string) Validator
+}
+
+// ValidatorFor returns the validator for the named function.
+// Validators that do not implement FunctionValidator are returned unchanged.
+func ValidatorFor(v Validator, name string) Validator {
+	if fv, ok := v.(FunctionValidator); ok && name != "" {
+		return fv.ForFunction(name)
+	}
+	return v
+}
+
+type key int
+
+const functionKey key = iota
+
+// WithFunction records the name of the function that makes
+// network connections with the context.
+func WithFunction(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, functionKey, name)
+}
+
+// FunctionFromContext returns the name of the function recorded
+// with WithFunction or an empty string.
+func FunctionFromContext(ctx context.Context) string {
+	name, _ := ctx.Value(functionKey).(string)
+	return name
+}
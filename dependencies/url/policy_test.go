@@ -0,0 +1,166 @@
+package url_test
+
+import (
+	"net"
+	nurl "net/url"
+	"strings"
+	"testing"
+
+	"github.com/InfluxCommunity/flux/dependencies/url"
+)
+
+const testPolicy = `
+default:
+  schemes: [http, https]
+  deny_hosts: ["*.internal.example.com"]
+  deny_cidrs: ["10.0.0.0/8"]
+  deny_private: true
+functions:
+  sql.from:
+    allow_cidrs: ["10.1.0.0/16"]
+`
+
+func TestPolicyValidator(t *testing.T) {
+	config, err := url.ParsePolicyConfig(strings.NewReader(testPolicy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := url.NewPolicyValidator(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name     string
+		function string
+		url      string
+		valid    bool
+	}{
+		{
+			name:  "public ip",
+			url:   "http://93.184.216.34",
+			valid: true,
+		},
+		{
+			name:  "scheme not allowed",
+			url:   "ftp://93.184.216.34",
+			valid: false,
+		},
+		{
+			name:  "denied host",
+			url:   "https://db.internal.example.com",
+			valid: false,
+		},
+		{
+			name:  "denied cidr",
+			url:   "http://10.1.2.3:5432",
+			valid: false,
+		},
+		{
+			name:  "private ip",
+			url:   "http://192.168.1.1",
+			valid: false,
+		},
+		{
+			name:     "function allowed cidr",
+			function: "sql.from",
+			url:      "postgres://10.1.2.3:5432",
+			valid:    true,
+		},
+		{
+			name:     "function outside allowed cidr",
+			function: "sql.from",
+			url:      "postgres://93.184.216.34:5432",
+			valid:    false,
+		},
+		{
+			name:     "function without policy",
+			function: "http.post",
+			url:      "http://10.1.2.3",
+			valid:    false,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			u, err := nurl.Parse(tc.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = url.ValidatorFor(v, tc.function).Validate(u)
+			if tc.valid && err != nil {
+				t.Errorf("expected %s to be valid, got error: %s", tc.url, err)
+			} else if !tc.valid && err == nil {
+				t.Errorf("expected %s to be invalid", tc.url)
+			}
+		})
+	}
+}
+
+func TestPolicyValidator_AllowHosts(t *testing.T) {
+	v, err := url.NewPolicyValidator(url.PolicyConfig{
+		Default: url.Policy{
+			AllowHosts: []string{"api.example.com", "*.hooks.example.com"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for host, valid := range map[string]bool{
+		"api.example.com":       true,
+		"API.example.com.":      true,
+		"a.hooks.example.com":   true,
+		"hooks.example.com":     true,
+		"evilhooks.example.com": false,
+		"other.example.com":     false,
+		"api.example.com.other": false,
+	} {
+		err := v.Validate(&nurl.URL{Scheme: "https", Host: host})
+		if valid && err != nil {
+			t.Errorf("expected %s to be valid, got error: %s", host, err)
+		} else if !valid && err == nil {
+			t.Errorf("expected %s to be invalid", host)
+		}
+	}
+}
+
+func TestPolicyValidator_ValidateIP(t *testing.T) {
+	v, err := url.NewPolicyValidator(url.PolicyConfig{
+		Default: url.Policy{
+			AllowCIDRs: []string{"172.16.0.0/12"},
+			DenyCIDRs:  []string{"172.16.1.0/24"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for ip, valid := range map[string]bool{
+		"172.16.0.1": true,
+		"172.16.1.1": false,
+		"8.8.8.8":    false,
+	} {
+		err := v.ValidateIP(net.ParseIP(ip))
+		if valid && err != nil {
+			t.Errorf("expected %s to be valid, got error: %s", ip, err)
+		} else if !valid && err == nil {
+			t.Errorf("expected %s to be invalid", ip)
+		}
+	}
+}
+
+func TestParsePolicyConfig_Invalid(t *testing.T) {
+	for name, policy := range map[string]string{
+		"unknown field": "default:\n  allow: [a]\n",
+		"invalid cidr":  "default:\n  deny_cidrs: [10.0.0.0]\n",
+		"invalid host":  "default:\n  allow_hosts: [\"*example.com\"]\n",
+		"empty host":    "default:\n  deny_hosts: [\"*.\"]\n",
+	} {
+		config, err := url.ParsePolicyConfig(strings.NewReader(policy))
+		if err == nil {
+			_, err = url.NewPolicyValidator(config)
+		}
+		if err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
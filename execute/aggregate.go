@@ -407,9 +407,17 @@ func (c SimpleAggregateConfig) PassThroughAttribute(attrKey string) bool {
 
 func NewSimpleAggregateTransformation(ctx context.Context, id DatasetID, agg SimpleAggregate, config SimpleAggregateConfig, mem memory.Allocator) (Transformation, Dataset, error) {
 	if feature.AggregateTransformationTransport().Enabled(ctx) {
+		columns := make([]AggregateColumn, len(config.Columns))
+		for i, label := range config.Columns {
+			columns[i] = AggregateColumn{
+				Column: label,
+				Label:  label,
+				Agg:    agg,
+			}
+		}
 		tr := &simpleAggregateTransformation2{
-			agg:    agg,
-			config: config,
+			aggs:    []SimpleAggregate{agg},
+			columns: columns,
 		}
 		return NewAggregateTransformation(id, tr, mem)
 	}
@@ -592,9 +600,32 @@ func (t *simpleAggregateTransformation) Finish(id DatasetID, err error) {
 	t.d.Finish(err)
 }
 
+// AggregateColumn is an aggregate of a column of the input
+// and the label of the output column with its value.
+type AggregateColumn struct {
+	Column string
+	Label  string
+	Agg    SimpleAggregate
+}
+
+// NewMultiAggregateTransformation constructs a Transformation and Dataset
+// that computes the aggregates of the columns of each table in a single pass
+// and outputs a row with all of them for each table.
+func NewMultiAggregateTransformation(id DatasetID, columns []AggregateColumn, mem memory.Allocator) (Transformation, Dataset, error) {
+	tr := &simpleAggregateTransformation2{
+		aggs:    make([]SimpleAggregate, len(columns)),
+		columns: columns,
+	}
+	for i, c := range columns {
+		tr.aggs[i] = c.Agg
+	}
+	return NewAggregateTransformation(id, tr, mem)
+}
+
 type simpleAggregateTransformation2 struct {
-	agg    SimpleAggregate
-	config SimpleAggregateConfig
+	// aggs holds the aggregates to close with the transformation.
+	aggs    []SimpleAggregate
+	columns []AggregateColumn
 }
 
 type aggregateState struct {
@@ -626,28 +657,31 @@ func (t *simpleAggregateTransformation2) initializeState(chunk table.Chunk, curr
 		return current.(aggregateStateList), nil
 	}
 
-	state := make(aggregateStateList, len(t.config.Columns))
-	for i, label := range t.config.Columns {
+	state := make(aggregateStateList, len(t.columns))
+	for i, c := range t.columns {
+		label := c.Column
 		j := chunk.Index(label)
 		if j < 0 {
 			return nil, errors.Newf(codes.FailedPrecondition, "column %q does not exist", label)
 		} else if chunk.Key().HasCol(label) {
 			return nil, errors.New(codes.FailedPrecondition, "cannot aggregate columns that are part of the group key")
+		} else if chunk.Key().HasCol(c.Label) {
+			return nil, errors.Newf(codes.FailedPrecondition, "aggregate column %q conflicts with a group key column", c.Label)
 		}
 
 		var vf ValueFunc
 		col := chunk.Col(j)
 		switch col.Type {
 		case flux.TBool:
-			vf = t.agg.NewBoolAgg()
+			vf = c.Agg.NewBoolAgg()
 		case flux.TInt:
-			vf = t.agg.NewIntAgg()
+			vf = c.Agg.NewIntAgg()
 		case flux.TUInt:
-			vf = t.agg.NewUIntAgg()
+			vf = c.Agg.NewUIntAgg()
 		case flux.TFloat:
-			vf = t.agg.NewFloatAgg()
+			vf = c.Agg.NewFloatAgg()
 		case flux.TString:
-			vf = t.agg.NewStringAgg()
+			vf = c.Agg.NewStringAgg()
 		default:
 			return nil, errors.Newf(codes.FailedPrecondition, "unsupported aggregate column type %v", col.Type)
 		}
@@ -666,7 +700,8 @@ func (t *simpleAggregateTransformation2) Aggregate(chunk table.Chunk, state inte
 		return nil, false, err
 	}
 
-	for j, label := range t.config.Columns {
+	for j, ac := range t.columns {
+		label := ac.Column
 		idx := chunk.Index(label)
 		if idx < 0 {
 			return nil, false, errors.Newf(codes.FailedPrecondition, "column %q does not exist", label)
@@ -708,7 +743,7 @@ func (t *simpleAggregateTransformation2) Compute(key flux.GroupKey, state interf
 	buffer.Columns = append(buffer.Columns, key.Cols()...)
 	for i, s := range aggregates {
 		buffer.Columns = append(buffer.Columns, flux.ColMeta{
-			Label: t.config.Columns[i],
+			Label: t.columns[i].Label,
 			Type:  s.agg.Type(),
 		})
 	}
@@ -735,6 +770,10 @@ func (t *simpleAggregateTransformation2) Compute(key flux.GroupKey, state interf
 			v := s.agg.(FloatValueFunc).ValueFloat()
 			arr = array.FloatRepeat(v, isNull, 1, mem)
 		case flux.TString:
+			if isNull {
+				arr = arrow.Nulls(flux.TString, 1, mem)
+				break
+			}
 			v := s.agg.(StringValueFunc).ValueString()
 			arr = array.StringRepeat(v, 1, mem)
 		}
@@ -749,11 +788,13 @@ func (t *simpleAggregateTransformation2) Compute(key flux.GroupKey, state interf
 	return d.Process(out)
 }
 
-func (t *simpleAggregateTransformation2) Close() error {
-	if closer, ok := t.agg.(Closer); ok {
-		return closer.Close()
+func (t *simpleAggregateTransformation2) Close() (err error) {
+	for _, agg := range t.aggs {
+		if closer, ok := agg.(Closer); ok {
+			err = Close(err, closer)
+		}
 	}
-	return nil
+	return err
 }
 
 type SimpleAggregate interface {
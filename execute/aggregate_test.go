@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/array"
 	"github.com/InfluxCommunity/flux/arrow"
 	"github.com/InfluxCommunity/flux/codes"
 	"github.com/InfluxCommunity/flux/dependency"
@@ -16,6 +17,9 @@ import (
 	"github.com/InfluxCommunity/flux/execute/table"
 	"github.com/InfluxCommunity/flux/execute/table/static"
 	"github.com/InfluxCommunity/flux/internal/errors"
+	fluxfeature "github.com/InfluxCommunity/flux/internal/feature"
+	"github.com/InfluxCommunity/flux/internal/pkg/feature"
+	fluxmemory "github.com/InfluxCommunity/flux/memory"
 	"github.com/InfluxCommunity/flux/mock"
 	"github.com/InfluxCommunity/flux/plan"
 	"github.com/InfluxCommunity/flux/stdlib/universe"
@@ -1376,6 +1380,88 @@ func TestSimpleAggregate_Process_UnsupportedColumnType(t *testing.T) {
 	}
 }
 
+func TestSimpleAggregate_Process_NullString(t *testing.T) {
+	for _, transport := range []bool{false, true} {
+		transport := transport
+		name := "Transformation"
+		if transport {
+			name = "AggregateTransformationTransport"
+		}
+		t.Run(name, func(t *testing.T) {
+			data := []flux.Table{&executetest.Table{
+				KeyCols: []string{"_start", "_stop"},
+				ColMeta: []flux.ColMeta{
+					{Label: "_start", Type: flux.TTime},
+					{Label: "_stop", Type: flux.TTime},
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TString},
+				},
+				Data: [][]interface{}{
+					{execute.Time(0), execute.Time(100), execute.Time(0), nil},
+					{execute.Time(0), execute.Time(100), execute.Time(10), nil},
+				},
+			}}
+			want := []*executetest.Table{{
+				KeyCols: []string{"_start", "_stop"},
+				ColMeta: []flux.ColMeta{
+					{Label: "_start", Type: flux.TTime},
+					{Label: "_stop", Type: flux.TTime},
+					{Label: "_value", Type: flux.TString},
+				},
+				Data: [][]interface{}{
+					{execute.Time(0), execute.Time(100), nil},
+				},
+			}}
+			executetest.ProcessTestHelper2(
+				t,
+				data,
+				want,
+				nil,
+				func(id execute.DatasetID, alloc fluxmemory.Allocator) (execute.Transformation, execute.Dataset) {
+					ctx, deps := dependency.Inject(context.Background(), executetest.NewTestExecuteDependencies())
+					t.Cleanup(deps.Finish)
+
+					flagger := executetest.TestFlagger{}
+					flagger[fluxfeature.AggregateTransformationTransport().Key()] = transport
+					ctx = feature.Inject(ctx, flagger)
+
+					tr, d, err := execute.NewSimpleAggregateTransformation(ctx, id, lastStringAgg{}, execute.DefaultSimpleAggregateConfig, alloc)
+					if err != nil {
+						t.Fatal(err)
+					}
+					return tr, d
+				},
+			)
+		})
+	}
+}
+
+// lastStringAgg is a SimpleAggregate that selects the last
+// non-null string and is null when there is none.
+type lastStringAgg struct{}
+
+func (lastStringAgg) NewBoolAgg() execute.DoBoolAgg     { return nil }
+func (lastStringAgg) NewIntAgg() execute.DoIntAgg       { return nil }
+func (lastStringAgg) NewUIntAgg() execute.DoUIntAgg     { return nil }
+func (lastStringAgg) NewFloatAgg() execute.DoFloatAgg   { return nil }
+func (lastStringAgg) NewStringAgg() execute.DoStringAgg { return &lastStringState{} }
+
+type lastStringState struct {
+	value string
+	ok    bool
+}
+
+func (s *lastStringState) DoString(vs *array.String) {
+	for i := 0; i < vs.Len(); i++ {
+		if vs.IsValid(i) {
+			s.value, s.ok = vs.Value(i), true
+		}
+	}
+}
+func (s *lastStringState) Type() flux.ColType  { return flux.TString }
+func (s *lastStringState) IsNull() bool        { return !s.ok }
+func (s *lastStringState) ValueString() string { return s.value }
+
 type mockState struct {
 	value        string
 	disposeCount *int
@@ -21,6 +21,10 @@ const executionDependenciesKey key = iota
 type ExecutionOptions struct {
 	OperatorProfiler *OperatorProfiler
 	Profilers        []Profiler
+
+	// SourceCache, if set, keeps the output of cacheable sources
+	// so that it can be reused by later executions.
+	SourceCache *SourceCache
 }
 
 // ExecutionDependencies represents the dependencies that a function call
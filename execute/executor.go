@@ -13,10 +13,11 @@ import (
 	"github.com/InfluxCommunity/flux/codes"
 	"github.com/InfluxCommunity/flux/internal/errors"
 	"github.com/InfluxCommunity/flux/internal/feature"
+	"github.com/InfluxCommunity/flux/internal/telemetry"
 	"github.com/InfluxCommunity/flux/memory"
 	"github.com/InfluxCommunity/flux/metadata"
 	"github.com/InfluxCommunity/flux/plan"
-	"github.com/opentracing/opentracing-go"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 )
 
@@ -69,6 +70,11 @@ type executionState struct {
 
 	transports []AsyncTransport
 
+	// sourceProfiles and transportProfiles hold the profile of the node
+	// that is reported with the profile of a source or transport.
+	sourceProfiles    map[Source]*nodeProfile
+	transportProfiles map[AsyncTransport]*nodeProfile
+
 	dispatcher *poolDispatcher
 	logger     *zap.Logger
 }
@@ -91,13 +97,17 @@ func (e *executor) createExecutionState(ctx context.Context, p *plan.Spec, a mem
 		alloc:     a,
 		resources: p.Resources,
 		results:   make(map[string]flux.Result),
+
+		sourceProfiles:    make(map[Source]*nodeProfile),
+		transportProfiles: make(map[AsyncTransport]*nodeProfile),
 		// TODO(nathanielc): Have the planner specify the dispatcher throughput
 		dispatcher: newPoolDispatcher(10, e.logger),
 		logger:     e.logger,
 	}
 	v := &createExecutionNodeVisitor{
-		es:    es,
-		nodes: make(map[plan.Node][]Node),
+		es:       es,
+		nodes:    make(map[plan.Node][]Node),
+		profiles: make(map[Node]*nodeProfile),
 	}
 
 	if err := p.BottomUpWalk(v.Visit); err != nil {
@@ -121,8 +131,9 @@ func (e *executor) createExecutionState(ctx context.Context, p *plan.Spec, a mem
 // createExecutionNodeVisitor visits each node in a physical query plan
 // and creates a node responsible for executing that physical operation.
 type createExecutionNodeVisitor struct {
-	es    *executionState
-	nodes map[plan.Node][]Node
+	es       *executionState
+	nodes    map[plan.Node][]Node
+	profiles map[Node]*nodeProfile
 }
 
 func skipYields(pn plan.Node) plan.Node {
@@ -200,11 +211,14 @@ func (v *createExecutionNodeVisitor) Visit(node plan.Node) error {
 		predCopies = attr.(plan.ParallelMergeAttribute).Factor
 	}
 
-	// Build execution context for each copy.
+	// Build execution context and profile for each copy.
 	ec := make([]executionContext, copies)
+	profiles := make([]*nodeProfile, copies)
 	for i := 0; i < copies; i++ {
+		profiles[i] = newNodeProfile(v.es.alloc)
 		ec[i] = executionContext{
 			es:            v.es,
+			alloc:         profiles[i].allocator(),
 			parents:       make([]DatasetID, len(node.Predecessors())*predCopies),
 			streamContext: streamContext,
 			parallelOpts:  ParallelOpts{Group: i, Factor: copies},
@@ -226,10 +240,22 @@ func (v *createExecutionNodeVisitor) Visit(node plan.Node) error {
 			return fmt.Errorf("unsupported source kind %v", kind)
 		}
 
+		// Parallel copies read part of the output each so they are not cached.
+		cache := v.es.sourceCache()
+		cacheable, isCacheable := spec.(CacheableProcedureSpec)
+
 		for i := 0; i < copies; i++ {
 			id := datasetIDFromNodeID(node.ID(), i)
 
-			source, err := createSourceFn(spec, id, ec[i])
+			var (
+				source Source
+				err    error
+			)
+			if cache != nil && isCacheable && copies == 1 {
+				source, err = cache.createSource(cacheable, id, ec[i], createSourceFn)
+			} else {
+				source, err = createSourceFn(spec, id, ec[i])
+			}
 
 			if err != nil {
 				return err
@@ -237,7 +263,9 @@ func (v *createExecutionNodeVisitor) Visit(node plan.Node) error {
 
 			source.SetLabel(string(node.ID()))
 			v.es.sources = append(v.es.sources, source)
+			v.es.sourceProfiles[source] = profiles[i]
 			v.nodes[node][i] = source
+			v.profiles[source] = profiles[i]
 		}
 	} else {
 		// If node is internal, create a transformation. For each
@@ -266,7 +294,11 @@ func (v *createExecutionNodeVisitor) Visit(node plan.Node) error {
 			}
 			ds.SetTriggerSpec(ppn.TriggerSpec)
 			v.nodes[node][i] = ds
+			v.profiles[ds] = profiles[i]
 
+			// The profile of the node is reported
+			// with the profile of its first transport.
+			reported := false
 			for _, p := range nonYieldPredecessors(node) {
 				// In case (1) above, both copies and predCopies are 1. We link
 				// forward from the only copy of the predecessor node.
@@ -283,8 +315,13 @@ func (v *createExecutionNodeVisitor) Visit(node plan.Node) error {
 				for j := 0; j < predCopies; j++ {
 					// Either i == 0 && j == 0: we are either iterating i, or we are iterating j.
 					executionNode := v.nodes[p][i+j]
-					transport := newConsecutiveTransport(v.es.ctx, v.es.dispatcher, tr, node, v.es.logger, v.es.alloc)
+					transport := newConsecutiveTransport(v.es.ctx, v.es.dispatcher, tr, node, v.es.logger, ec[i].Allocator())
+					transport.upstream = v.profiles[executionNode].countOutput()
 					v.es.transports = append(v.es.transports, transport)
+					if !reported {
+						v.es.transportProfiles[transport] = profiles[i]
+						reported = true
+					}
 					executionNode.AddTransformation(transport)
 				}
 			}
@@ -327,7 +364,9 @@ func (v *createExecutionNodeVisitor) generateResult(resultName string, node plan
 	}
 	r := newResult(resultName)
 	v.es.results[resultName] = r
-	v.nodes[skipYields(node)][idx].AddTransformation(r)
+	executionNode := v.nodes[skipYields(node)][idx]
+	r.upstream = v.profiles[executionNode].countOutput()
+	executionNode.AddTransformation(r)
 	return nil
 }
 
@@ -489,6 +528,18 @@ func (es *executionState) chooseDefaultResources(ctx context.Context, p *plan.Sp
 	}
 }
 
+// sourceCache returns the source cache of the execution dependencies, if any.
+func (es *executionState) sourceCache() *SourceCache {
+	if !HaveExecutionDependencies(es.ctx) {
+		return nil
+	}
+	opts := GetExecutionDependencies(es.ctx).ExecutionOptions
+	if opts == nil {
+		return nil
+	}
+	return opts.SourceCache
+}
+
 func (es *executionState) abort(err error) {
 	for _, r := range es.results {
 		r.(*result).abort(err)
@@ -501,6 +552,9 @@ func (es *executionState) do() {
 		wg      sync.WaitGroup
 		stats   flux.Statistics
 		statsMu sync.Mutex
+		// nodes holds the node profile that is
+		// reported with each of the source profiles.
+		nodes []*nodeProfile
 	)
 
 	updateStats := func(fn func(stats *flux.Statistics)) {
@@ -523,10 +577,8 @@ func (es *executionState) do() {
 			}
 			profileSpan := profile.StartSpan()
 
-			if span, spanCtx := opentracing.StartSpanFromContext(ctx, opName, opentracing.Tag{Key: "label", Value: src.Label()}); span != nil {
-				ctx = spanCtx
-				defer span.Finish()
-			}
+			ctx, span := telemetry.StartSpan(ctx, opName, attribute.String("label", src.Label()))
+			defer span.End()
 
 			defer wg.Done()
 
@@ -537,6 +589,7 @@ func (es *executionState) do() {
 
 			updateStats(func(stats *flux.Statistics) {
 				stats.Profiles = append(stats.Profiles, profile)
+				nodes = append(nodes, es.sourceProfiles[src])
 				if mdn, ok := src.(MetadataNode); ok {
 					stats.Metadata.AddAll(mdn.Metadata())
 				}
@@ -550,6 +603,7 @@ func (es *executionState) do() {
 	// Keep the transport profiles in a separate array from the source profiles.
 	// This ensures that sources are before transformations.
 	profiles := make([]flux.TransportProfile, 0, len(es.transports))
+	transportNodes := make([]*nodeProfile, 0, len(es.transports))
 	go func() {
 		defer wg.Done()
 
@@ -559,6 +613,7 @@ func (es *executionState) do() {
 			case <-t.Finished():
 				tp := t.TransportProfile()
 				profiles = append(profiles, tp)
+				transportNodes = append(transportNodes, es.transportProfiles[t])
 			case <-es.ctx.Done():
 				es.abort(es.ctx.Err())
 			case err := <-es.dispatcher.Err():
@@ -582,6 +637,13 @@ func (es *executionState) do() {
 		// by the sources.
 		stats.Profiles = append(stats.Profiles, profiles...)
 
+		// The output of a node is counted downstream of it so the
+		// node profiles are only complete once every node is done.
+		nodes = append(nodes, transportNodes...)
+		for i, np := range nodes {
+			np.fill(&stats.Profiles[i])
+		}
+
 		es.statsCh <- stats
 	}()
 }
@@ -594,6 +656,7 @@ type ParallelOpts struct {
 // Need a unique stream context per execution context
 type executionContext struct {
 	es            *executionState
+	alloc         memory.Allocator
 	parents       []DatasetID
 	streamContext streamContext
 	parallelOpts  ParallelOpts
@@ -616,7 +679,7 @@ func (ec executionContext) StreamContext() StreamContext {
 }
 
 func (ec executionContext) Allocator() memory.Allocator {
-	return ec.es.alloc
+	return ec.alloc
 }
 
 func (ec executionContext) Parents() []DatasetID {
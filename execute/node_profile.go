@@ -0,0 +1,159 @@
+package execute
+
+import (
+	"sync/atomic"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/execute/table"
+	"github.com/InfluxCommunity/flux/memory"
+)
+
+// nodeProfile collects the statistics of an execution node that are
+// not known by the node itself. The output of a node is counted by
+// the transformation downstream of it that reads it first and the
+// memory of the node is counted by the allocator it is given.
+type nodeProfile struct {
+	outputTables int64
+	outputRows   int64
+	outputBytes  int64
+
+	// counted is set once a downstream transformation
+	// has been chosen to count the output of the node.
+	counted bool
+
+	mem *nodeAllocator
+}
+
+func newNodeProfile(alloc memory.Allocator) *nodeProfile {
+	p := &nodeProfile{}
+	if alloc != nil {
+		p.mem = &nodeAllocator{Allocator: alloc}
+	}
+	return p
+}
+
+// allocator returns the allocator that should be used by the node.
+func (p *nodeProfile) allocator() memory.Allocator {
+	if p.mem == nil {
+		return nil
+	}
+	return p.mem
+}
+
+// countOutput returns p if the next transformation added to the node
+// should count its output and nil otherwise. Every transformation of
+// a node receives the same tables so only the first one counts them.
+func (p *nodeProfile) countOutput() *nodeProfile {
+	if p == nil || p.counted {
+		return nil
+	}
+	p.counted = true
+	return p
+}
+
+func (p *nodeProfile) addTables(n int64) {
+	if p == nil {
+		return
+	}
+	atomic.AddInt64(&p.outputTables, n)
+}
+
+func (p *nodeProfile) addRows(rows, bytes int64) {
+	if p == nil {
+		return
+	}
+	atomic.AddInt64(&p.outputRows, rows)
+	atomic.AddInt64(&p.outputBytes, bytes)
+}
+
+// addBufferedRows counts the rows of tbl if they can
+// be read without consuming the table.
+func (p *nodeProfile) addBufferedRows(tbl flux.Table) {
+	if p == nil {
+		return
+	}
+	switch tbl := tbl.(type) {
+	case *table.BufferedTable:
+		for _, cr := range tbl.Buffers {
+			p.addRows(int64(cr.Len()), colReaderBytes(cr))
+		}
+	case flux.BufferedTable:
+		for i, n := 0, tbl.BufferN(); i < n; i++ {
+			cr := tbl.Buffer(i)
+			p.addRows(int64(cr.Len()), colReaderBytes(cr))
+		}
+	}
+}
+
+// fill adds the statistics of the node to the profile.
+func (p *nodeProfile) fill(profile *flux.TransportProfile) {
+	if p == nil {
+		return
+	}
+	profile.OutputTables = atomic.LoadInt64(&p.outputTables)
+	profile.OutputRows = atomic.LoadInt64(&p.outputRows)
+	profile.OutputBytes = atomic.LoadInt64(&p.outputBytes)
+	if p.mem != nil {
+		profile.PeakBytes = p.mem.maxAllocated()
+	}
+}
+
+// colReaderBytes returns the size of the buffers that hold the columns of cr.
+func colReaderBytes(cr flux.ColReader) int64 {
+	var size int64
+	for j := range cr.Cols() {
+		size += arrowBytes(table.Values(cr, j))
+	}
+	return size
+}
+
+// nodeAllocator tracks the memory allocated by a single execution node
+// and passes the allocations on to the allocator of the query.
+type nodeAllocator struct {
+	memory.Allocator
+
+	allocated int64
+	peak      int64
+}
+
+func (a *nodeAllocator) Allocate(size int) []byte {
+	b := a.Allocator.Allocate(size)
+	a.count(size)
+	return b
+}
+
+func (a *nodeAllocator) Reallocate(size int, b []byte) []byte {
+	diff := size - cap(b)
+	b = a.Allocator.Reallocate(size, b)
+	a.count(diff)
+	return b
+}
+
+func (a *nodeAllocator) Free(b []byte) {
+	size := len(b)
+	a.Allocator.Free(b)
+	a.count(-size)
+}
+
+func (a *nodeAllocator) Account(size int) error {
+	if err := a.Allocator.Account(size); err != nil {
+		return err
+	}
+	a.count(size)
+	return nil
+}
+
+func (a *nodeAllocator) count(size int) {
+	c := atomic.AddInt64(&a.allocated, int64(size))
+	for max := atomic.LoadInt64(&a.peak); c > max; max = atomic.LoadInt64(&a.peak) {
+		if atomic.CompareAndSwapInt64(&a.peak, max, c) {
+			break
+		}
+	}
+}
+
+// maxAllocated reports the maximum amount of memory
+// the node had allocated at any point.
+func (a *nodeAllocator) maxAllocated() int64 {
+	return atomic.LoadInt64(&a.peak)
+}
@@ -0,0 +1,63 @@
+package execute
+
+import (
+	"testing"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/memory"
+)
+
+func TestNodeAllocator(t *testing.T) {
+	query := &memory.ResourceAllocator{}
+	p := newNodeProfile(query)
+	mem := p.allocator()
+
+	a := mem.Allocate(64)
+	b := mem.Allocate(32)
+	mem.Free(a)
+	b = mem.Reallocate(48, b)
+	if err := mem.Account(16); err != nil {
+		t.Fatal(err)
+	}
+
+	var profile flux.TransportProfile
+	p.fill(&profile)
+	if want, got := int64(96), profile.PeakBytes; want != got {
+		t.Errorf("unexpected peak bytes -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+	if want, got := int64(64), p.mem.allocated; want != got {
+		t.Errorf("unexpected allocated bytes -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+
+	// The allocations are passed on to the allocator of the query.
+	if want, got := int64(64), query.Allocated(); want != got {
+		t.Errorf("unexpected query allocated bytes -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+	mem.Free(b)
+	_ = mem.Account(-16)
+	if got := query.Allocated(); got != 0 {
+		t.Errorf("expected the memory to be released, got %d bytes", got)
+	}
+}
+
+func TestNodeProfile_CountOutput(t *testing.T) {
+	p := newNodeProfile(nil)
+	if p.allocator() != nil {
+		t.Fatal("expected no allocator without a query allocator")
+	}
+
+	first, second := p.countOutput(), p.countOutput()
+	if first != p || second != nil {
+		t.Fatal("expected only the first transformation to count the output")
+	}
+	first.addTables(2)
+	first.addRows(10, 80)
+	second.addTables(2)
+	second.addRows(10, 80)
+
+	var profile flux.TransportProfile
+	p.fill(&profile)
+	if profile.OutputTables != 2 || profile.OutputRows != 10 || profile.OutputBytes != 80 {
+		t.Errorf("unexpected output counts: %+v", profile)
+	}
+}
@@ -119,6 +119,34 @@ func (o *OperatorProfiler) getTableBuilder(stats flux.Statistics, alloc memory.A
 			Label: "MeanDuration",
 			Type:  flux.TFloat,
 		},
+		{
+			Label: "InputTables",
+			Type:  flux.TInt,
+		},
+		{
+			Label: "InputRows",
+			Type:  flux.TInt,
+		},
+		{
+			Label: "InputBytes",
+			Type:  flux.TInt,
+		},
+		{
+			Label: "OutputTables",
+			Type:  flux.TInt,
+		},
+		{
+			Label: "OutputRows",
+			Type:  flux.TInt,
+		},
+		{
+			Label: "OutputBytes",
+			Type:  flux.TInt,
+		},
+		{
+			Label: "PeakBytes",
+			Type:  flux.TInt,
+		},
 	}
 	for _, col := range colMeta {
 		if _, err := b.AddCol(col); err != nil {
@@ -135,6 +163,13 @@ func (o *OperatorProfiler) getTableBuilder(stats flux.Statistics, alloc memory.A
 		b.AppendInt(5, profile.Max)
 		b.AppendInt(6, profile.Sum)
 		b.AppendFloat(7, profile.Mean)
+		b.AppendInt(8, profile.InputTables)
+		b.AppendInt(9, profile.InputRows)
+		b.AppendInt(10, profile.InputBytes)
+		b.AppendInt(11, profile.OutputTables)
+		b.AppendInt(12, profile.OutputRows)
+		b.AppendInt(13, profile.OutputBytes)
+		b.AppendInt(14, profile.PeakBytes)
 	}
 	return b, nil
 }
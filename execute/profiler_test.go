@@ -42,22 +42,22 @@ func TestOperatorProfiler_GetResult(t *testing.T) {
 	// Build the "want" table.
 	var wantStr bytes.Buffer
 	wantStr.WriteString(`
-#datatype,string,long,string,string,string,long,long,long,long,double
-#group,false,false,true,false,false,false,false,false,false,false
-#default,_profiler,,,,,,,,,
-,result,table,_measurement,Type,Label,Count,MinDuration,MaxDuration,DurationSum,MeanDuration
+#datatype,string,long,string,string,string,long,long,long,long,double,long,long,long,long,long,long,long
+#group,false,false,true,false,false,false,false,false,false,false,false,false,false,false,false,false,false
+#default,_profiler,,,,,,,,,,,,,,,,
+,result,table,_measurement,Type,Label,Count,MinDuration,MaxDuration,DurationSum,MeanDuration,InputTables,InputRows,InputBytes,OutputTables,OutputRows,OutputBytes,PeakBytes
 `)
-	fmt.Fprintf(&wantStr, ",,0,profiler/operator,%s,%s,%d,%d,%d,%d,%f\n",
-		"type0", "lab0", 4, 1000, 1606, 5212, 1303.0,
+	fmt.Fprintf(&wantStr, ",,0,profiler/operator,%s,%s,%d,%d,%d,%d,%f,%d,%d,%d,%d,%d,%d,%d\n",
+		"type0", "lab0", 4, 1000, 1606, 5212, 1303.0, 1, 10, 80, 2, 20, 160, 1024,
 	)
-	fmt.Fprintf(&wantStr, ",,0,profiler/operator,%s,%s,%d,%d,%d,%d,%f\n",
-		"type1", "lab0", 4, 1101, 1707, 5616, 1404.0,
+	fmt.Fprintf(&wantStr, ",,0,profiler/operator,%s,%s,%d,%d,%d,%d,%f,%d,%d,%d,%d,%d,%d,%d\n",
+		"type1", "lab0", 4, 1101, 1707, 5616, 1404.0, 3, 30, 240, 4, 40, 320, 3072,
 	)
-	fmt.Fprintf(&wantStr, ",,0,profiler/operator,%s,%s,%d,%d,%d,%d,%f\n",
-		"type0", "lab1", 4, 1808, 2414, 8444, 2111.0,
+	fmt.Fprintf(&wantStr, ",,0,profiler/operator,%s,%s,%d,%d,%d,%d,%f,%d,%d,%d,%d,%d,%d,%d\n",
+		"type0", "lab1", 4, 1808, 2414, 8444, 2111.0, 2, 20, 160, 3, 30, 240, 2048,
 	)
-	fmt.Fprintf(&wantStr, ",,0,profiler/operator,%s,%s,%d,%d,%d,%d,%f\n",
-		"type1", "lab1", 4, 1909, 2515, 8848, 2212.0,
+	fmt.Fprintf(&wantStr, ",,0,profiler/operator,%s,%s,%d,%d,%d,%d,%f,%d,%d,%d,%d,%d,%d,%d\n",
+		"type1", "lab1", 4, 1909, 2515, 8848, 2212.0, 4, 40, 320, 5, 50, 400, 4096,
 	)
 	count := 16
 
@@ -66,9 +66,17 @@ func TestOperatorProfiler_GetResult(t *testing.T) {
 	}
 	for i := 0; i < 2; i++ {
 		for j := 0; j < 2; j++ {
+			n := int64(i*2 + j + 1)
 			stats.Profiles = append(stats.Profiles, flux.TransportProfile{
-				NodeType: fmt.Sprintf("type%d", i),
-				Label:    fmt.Sprintf("lab%d", j),
+				NodeType:     fmt.Sprintf("type%d", i),
+				Label:        fmt.Sprintf("lab%d", j),
+				InputTables:  n,
+				InputRows:    n * 10,
+				InputBytes:   n * 80,
+				OutputTables: n + 1,
+				OutputRows:   (n + 1) * 10,
+				OutputBytes:  (n + 1) * 80,
+				PeakBytes:    n * 1024,
 			})
 		}
 	}
@@ -17,6 +17,10 @@ type result struct {
 
 	abortErr chan error
 	aborted  chan struct{}
+
+	// upstream is the profile of the node that sends tables
+	// to this result if the result counts the output of that node.
+	upstream *nodeProfile
 }
 
 type resultMessage struct {
@@ -43,6 +47,10 @@ func (s *result) RetractTable(DatasetID, flux.GroupKey) error {
 }
 
 func (s *result) Process(id DatasetID, tbl flux.Table) error {
+	// The tables may be read after the statistics of the query have been
+	// collected so only the rows of buffered tables are counted.
+	s.upstream.addTables(1)
+	s.upstream.addBufferedRows(tbl)
 	select {
 	case s.tables <- resultMessage{
 		table: tbl,
@@ -0,0 +1,668 @@
+package execute
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/execute/table"
+	"github.com/InfluxCommunity/flux/memory"
+	"github.com/InfluxCommunity/flux/metadata"
+	"github.com/InfluxCommunity/flux/plan"
+	"github.com/InfluxCommunity/flux/values"
+)
+
+// SourceCacheMetadataKey is the metadata key that reports whether
+// a source was read from the cache. Its values are "hit", "partial"
+// or "miss".
+const SourceCacheMetadataKey = "flux/source-cache"
+
+// CacheableProcedureSpec is a source procedure spec
+// whose output can be kept in a SourceCache.
+type CacheableProcedureSpec interface {
+	plan.ProcedureSpec
+
+	// CacheKey returns a key that identifies the data read by the source.
+	// Sources of the same kind with the same key must produce the same
+	// tables. The key of a BoundedCacheableProcedureSpec must not include
+	// the bounds. CacheKey returns false if the output cannot be cached.
+	CacheKey() (string, bool)
+}
+
+// BoundedCacheableProcedureSpec is a cacheable source that reads the rows
+// whose _time is within its bounds and sets the _start and _stop columns
+// to these bounds.
+//
+// The cache answers a read from the output of an earlier read whose
+// bounds overlap and only reads the part of the bounds it is missing.
+type BoundedCacheableProcedureSpec interface {
+	CacheableProcedureSpec
+
+	// CacheBounds returns the bounds that the source reads.
+	CacheBounds() Bounds
+
+	// WithCacheBounds returns a copy of the spec that reads the given bounds.
+	WithCacheBounds(bounds Bounds) plan.ProcedureSpec
+}
+
+// SourceCacheConfig configures a SourceCache.
+type SourceCacheConfig struct {
+	// TTL is how long the output of a source is reused after it was read.
+	// The output of a read that only fetched part of its bounds expires
+	// with the oldest data it contains. Zero means that output does not expire.
+	TTL time.Duration
+
+	// LateData is how long data may arrive after its _time. A read whose
+	// bounds end after the cached bounds also reads the last LateData
+	// of the cached bounds again so that late points are not missed.
+	// Zero means that the cached data is complete up to its bounds.
+	LateData time.Duration
+
+	// MaxMemory is the number of bytes that the cache may hold.
+	// The least recently used output is evicted to stay under it.
+	// Zero means that the memory is not limited.
+	MaxMemory int64
+}
+
+// SourceCacheStats reports the usage of a SourceCache.
+type SourceCacheStats struct {
+	// Hits counts the reads answered from the cache alone.
+	Hits int64
+	// PartialHits counts the reads that read part of their bounds from the source.
+	PartialHits int64
+	// Misses counts the reads that read all of their output from the source.
+	Misses int64
+	// Entries is the number of cached outputs.
+	Entries int
+	// Memory is the number of bytes held by the cached outputs.
+	Memory int64
+}
+
+// SourceCache keeps the output of sources so that queries which are
+// run repeatedly, such as those of a dashboard, do not read the same
+// data from slow sources again.
+//
+// The cache is used by the executor when it is set in the ExecutionOptions
+// of the execution dependencies. Only sources whose procedure spec
+// implements CacheableProcedureSpec are cached. A SourceCache is safe
+// for concurrent use by multiple queries.
+//
+// The output is reused without creating the source, so checks that are
+// made when a source is created, such as validating its URL, are skipped.
+// Queries that run with different dependencies should not share a cache.
+type SourceCache struct {
+	config SourceCacheConfig
+	now    func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*sourceCacheEntry
+	lru     *list.List
+	stats   SourceCacheStats
+}
+
+// NewSourceCache creates an empty SourceCache.
+func NewSourceCache(config SourceCacheConfig) *SourceCache {
+	return &SourceCache{
+		config:  config,
+		now:     time.Now,
+		entries: make(map[string]*sourceCacheEntry),
+		lru:     list.New(),
+	}
+}
+
+// sourceCacheEntry is the cached output of a source.
+// An entry is not modified once it is stored.
+type sourceCacheEntry struct {
+	key    string
+	bounds *Bounds
+	tables []flux.BufferedTable
+	size   int64
+	readAt time.Time
+	elem   *list.Element
+}
+
+func (e *sourceCacheEntry) release() {
+	for _, tbl := range e.tables {
+		tbl.Done()
+	}
+}
+
+// copyTables returns copies of the cached tables
+// that can be read independently of the entry.
+func (e *sourceCacheEntry) copyTables() []flux.BufferedTable {
+	tables := make([]flux.BufferedTable, len(e.tables))
+	for i, tbl := range e.tables {
+		tables[i] = tbl.Copy()
+	}
+	return tables
+}
+
+// Invalidate removes the cached output of the source.
+func (c *SourceCache) Invalidate(spec CacheableProcedureSpec) {
+	key, ok := sourceCacheKey(spec)
+	if !ok {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[key]; ok {
+		c.remove(e)
+	}
+}
+
+// Purge removes all cached output.
+func (c *SourceCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, e := range c.entries {
+		c.remove(e)
+	}
+}
+
+// Stats returns the usage of the cache.
+func (c *SourceCache) Stats() SourceCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Entries = len(c.entries)
+	return stats
+}
+
+func sourceCacheKey(spec CacheableProcedureSpec) (string, bool) {
+	key, ok := spec.CacheKey()
+	if !ok {
+		return "", false
+	}
+	// The key may contain credentials so only its hash is kept.
+	sum := sha256.Sum256([]byte(string(spec.Kind()) + "\x00" + key))
+	return hex.EncodeToString(sum[:]), true
+}
+
+// createSource creates a source that reads the output of spec from
+// the cache and reads what is missing with a source created by create.
+func (c *SourceCache) createSource(spec CacheableProcedureSpec, id DatasetID, a Administration, create CreateSource) (Source, error) {
+	key, ok := sourceCacheKey(spec)
+	if !ok {
+		return create(spec, id, a)
+	}
+	var bounds *Bounds
+	if bs, ok := spec.(BoundedCacheableProcedureSpec); ok {
+		b := bs.CacheBounds()
+		bounds = &b
+	}
+
+	s := &cachedSource{
+		id:     id,
+		cache:  c,
+		key:    key,
+		bounds: bounds,
+		mem:    a.Allocator(),
+	}
+
+	c.mu.Lock()
+	e := c.lookup(key)
+	switch {
+	case e != nil && (bounds == nil || (e.bounds.Start <= bounds.Start && bounds.Stop <= e.bounds.Stop)):
+		c.stats.Hits++
+		s.status = "hit"
+		s.cached = e.copyTables()
+		s.readAt = e.readAt
+		if bounds != nil && bounds.Equal(*e.bounds) {
+			// The cached tables already have these bounds.
+			s.bounds = nil
+		}
+	case e != nil && bounds != nil && e.bounds.Overlaps(*bounds):
+		c.stats.PartialHits++
+		s.status = "partial"
+		s.cached = e.copyTables()
+		s.cachedBounds = *e.bounds
+		s.readAt = e.readAt
+		if bounds.Start < e.bounds.Start {
+			s.deltas = append(s.deltas, Bounds{Start: bounds.Start, Stop: e.bounds.Start})
+		}
+		if e.bounds.Stop < bounds.Stop {
+			if c.config.LateData > 0 {
+				// Read the end of the cached bounds again for late data.
+				stop := e.bounds.Stop.Add(values.ConvertDurationNsecs(-c.config.LateData))
+				s.cachedBounds.Stop = max(stop, e.bounds.Start, bounds.Start)
+			}
+			s.deltas = append(s.deltas, Bounds{Start: s.cachedBounds.Stop, Stop: bounds.Stop})
+		}
+	default:
+		c.stats.Misses++
+		s.status = "miss"
+		s.readAt = c.now()
+	}
+	c.mu.Unlock()
+
+	switch s.status {
+	case "miss":
+		src, err := create(spec, id, a)
+		if err != nil {
+			return nil, err
+		}
+		s.sources = append(s.sources, src)
+	case "partial":
+		for _, delta := range s.deltas {
+			src, err := create(spec.(BoundedCacheableProcedureSpec).WithCacheBounds(delta), id, a)
+			if err != nil {
+				s.release()
+				return nil, err
+			}
+			s.sources = append(s.sources, src)
+		}
+	}
+	return s, nil
+}
+
+// lookup returns the entry for the key if it has not expired
+// and marks it as the most recently used.
+func (c *SourceCache) lookup(key string) *sourceCacheEntry {
+	e, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	if c.config.TTL > 0 && c.now().Sub(e.readAt) >= c.config.TTL {
+		c.remove(e)
+		return nil
+	}
+	c.lru.MoveToFront(e.elem)
+	return e
+}
+
+// store replaces the entry for its key and evicts the least
+// recently used entries until the cache is within its memory limit.
+func (c *SourceCache) store(e *sourceCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if old, ok := c.entries[e.key]; ok {
+		c.remove(old)
+	}
+	if c.config.MaxMemory > 0 && e.size > c.config.MaxMemory {
+		e.release()
+		return
+	}
+	for c.config.MaxMemory > 0 && c.stats.Memory+e.size > c.config.MaxMemory {
+		c.remove(c.lru.Back().Value.(*sourceCacheEntry))
+	}
+	e.elem = c.lru.PushFront(e)
+	c.entries[e.key] = e
+	c.stats.Memory += e.size
+}
+
+func (c *SourceCache) remove(e *sourceCacheEntry) {
+	delete(c.entries, e.key)
+	c.lru.Remove(e.elem)
+	c.stats.Memory -= e.size
+	e.release()
+}
+
+// cachedSource produces the output of a source from the cache and
+// from the sources that read the parts missing from the cache.
+type cachedSource struct {
+	ExecutionNode
+	id    DatasetID
+	ts    TransformationSet
+	cache *SourceCache
+	key   string
+	mem   memory.Allocator
+
+	// status is hit, partial or miss.
+	status string
+	// bounds are the bounds of the read, if the source is bounded.
+	// On a hit, they are nil if the cached tables have the same bounds.
+	bounds *Bounds
+	cached []flux.BufferedTable
+	// cachedBounds are the bounds of the cached tables.
+	cachedBounds Bounds
+	readAt       time.Time
+	// deltas are the bounds that are missing from the cache
+	// and sources are the sources that read them.
+	deltas  []Bounds
+	sources []Source
+}
+
+func (s *cachedSource) AddTransformation(t Transformation) {
+	s.ts = append(s.ts, t)
+}
+
+func (s *cachedSource) Metadata() metadata.Metadata {
+	md := make(metadata.Metadata)
+	md.Add(SourceCacheMetadataKey, s.status)
+	for _, src := range s.sources {
+		if mdn, ok := src.(MetadataNode); ok {
+			md.AddAll(mdn.Metadata())
+		}
+	}
+	return md
+}
+
+func (s *cachedSource) Run(ctx context.Context) {
+	var err error
+	switch s.status {
+	case "hit":
+		err = s.runHit()
+	case "partial":
+		err = s.runPartial(ctx)
+	default:
+		err = s.runMiss(ctx)
+	}
+	s.release()
+	s.ts.Finish(s.id, err)
+}
+
+// release releases the cached tables that have not been read.
+func (s *cachedSource) release() {
+	for _, tbl := range s.cached {
+		if tbl != nil {
+			tbl.Done()
+		}
+	}
+	s.cached = nil
+}
+
+// runHit produces the cached tables, restricted to the bounds
+// of the read if they are narrower than the cached bounds.
+func (s *cachedSource) runHit() error {
+	if s.bounds == nil {
+		for i, tbl := range s.cached {
+			s.cached[i] = nil
+			if err := s.ts.Process(s.id, tbl); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	b := newSourceCacheBuilder(s.bounds, s.mem)
+	defer b.release()
+	for i, tbl := range s.cached {
+		s.cached[i] = nil
+		if err := b.add(tbl); err != nil {
+			return err
+		}
+	}
+	tables, err := b.tables()
+	if err != nil {
+		return err
+	}
+	for _, tbl := range tables {
+		if err := s.ts.Process(s.id, tbl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runMiss produces the output of the source and stores a copy of it.
+func (s *cachedSource) runMiss(ctx context.Context) error {
+	mem := memory.NewResourceAllocator(nil)
+	b := newSourceCacheBuilder(s.bounds, mem)
+	defer b.release()
+
+	tee := &sourceCacheWriter{
+		process: func(tbl flux.Table) error {
+			buf, err := CopyTable(tbl)
+			if err != nil {
+				return err
+			}
+			if err := b.add(buf.Copy()); err != nil {
+				buf.Done()
+				return err
+			}
+			return s.ts.Process(s.id, buf)
+		},
+	}
+	if err := tee.run(ctx, s.sources[0]); err != nil {
+		return err
+	}
+	s.store(b, mem)
+	return nil
+}
+
+// runPartial reads the bounds that are missing from the cache,
+// merges them with the cached tables and stores the result.
+func (s *cachedSource) runPartial(ctx context.Context) error {
+	mem := memory.NewResourceAllocator(nil)
+	b := newSourceCacheBuilder(s.bounds, mem)
+	defer b.release()
+
+	// Rows are added in time order, assuming that the source
+	// produces each table in time order. The deltas are before
+	// and after the cached bounds.
+	add := func(tbl flux.Table) error { return b.add(tbl) }
+	for i, src := range s.sources {
+		if s.deltas[i].Start >= s.cachedBounds.Stop {
+			if err := s.addCached(b); err != nil {
+				return err
+			}
+		}
+		if err := (&sourceCacheWriter{process: add}).run(ctx, src); err != nil {
+			return err
+		}
+	}
+	if err := s.addCached(b); err != nil {
+		return err
+	}
+
+	tables, err := b.tables()
+	if err != nil {
+		return err
+	}
+	for _, tbl := range tables {
+		if err := s.ts.Process(s.id, tbl.Copy()); err != nil {
+			for _, tbl := range tables {
+				tbl.Done()
+			}
+			return err
+		}
+	}
+	s.storeTables(tables, mem)
+	return nil
+}
+
+func (s *cachedSource) addCached(b *sourceCacheBuilder) error {
+	for i, tbl := range s.cached {
+		s.cached[i] = nil
+		if err := b.addWithin(tbl, &s.cachedBounds); err != nil {
+			return err
+		}
+	}
+	s.cached = nil
+	return nil
+}
+
+func (s *cachedSource) store(b *sourceCacheBuilder, mem *memory.ResourceAllocator) {
+	tables, err := b.tables()
+	if err != nil {
+		// The output was produced so only the caching failed.
+		return
+	}
+	s.storeTables(tables, mem)
+}
+
+func (s *cachedSource) storeTables(tables []flux.BufferedTable, mem *memory.ResourceAllocator) {
+	s.cache.store(&sourceCacheEntry{
+		key:    s.key,
+		bounds: s.bounds,
+		tables: tables,
+		size:   mem.Allocated(),
+		readAt: s.readAt,
+	})
+}
+
+// sourceCacheWriter is the transformation that receives
+// the output of a source that is read by a cachedSource.
+type sourceCacheWriter struct {
+	process func(tbl flux.Table) error
+	err     error
+}
+
+// run runs the source and returns its error.
+func (w *sourceCacheWriter) run(ctx context.Context, src Source) error {
+	src.AddTransformation(w)
+	src.Run(ctx)
+	return w.err
+}
+
+func (w *sourceCacheWriter) RetractTable(id DatasetID, key flux.GroupKey) error {
+	return nil
+}
+
+func (w *sourceCacheWriter) Process(id DatasetID, tbl flux.Table) error {
+	return w.process(tbl)
+}
+
+func (w *sourceCacheWriter) UpdateWatermark(id DatasetID, t Time) error {
+	return nil
+}
+
+func (w *sourceCacheWriter) UpdateProcessingTime(id DatasetID, t Time) error {
+	return nil
+}
+
+func (w *sourceCacheWriter) Finish(id DatasetID, err error) {
+	w.err = err
+}
+
+// sourceCacheBuilder merges tables with the same group key.
+// When it has bounds, it keeps only the rows whose _time is
+// within them and sets the _start and _stop columns to them.
+type sourceCacheBuilder struct {
+	bounds   *Bounds
+	mem      memory.Allocator
+	builders *GroupLookup
+}
+
+func newSourceCacheBuilder(bounds *Bounds, mem memory.Allocator) *sourceCacheBuilder {
+	return &sourceCacheBuilder{
+		bounds:   bounds,
+		mem:      mem,
+		builders: NewGroupLookup(),
+	}
+}
+
+func (b *sourceCacheBuilder) add(tbl flux.Table) error {
+	return b.addWithin(tbl, b.bounds)
+}
+
+// addWithin adds the rows of the table whose _time
+// is within both the given bounds and those of the builder.
+func (b *sourceCacheBuilder) addWithin(tbl flux.Table, bounds *Bounds) error {
+	key, err := b.key(tbl.Key())
+	if err != nil {
+		tbl.Done()
+		return err
+	}
+	builder := b.builders.LookupOrCreate(key, func() interface{} {
+		return NewColListTableBuilder(key, b.mem)
+	}).(*ColListTableBuilder)
+
+	colMap, err := AddNewTableCols(tbl, builder, nil)
+	if err != nil {
+		tbl.Done()
+		return err
+	}
+	timeIdx := -1
+	if b.bounds != nil {
+		if j := ColIdx(DefaultTimeColLabel, tbl.Cols()); j >= 0 && tbl.Cols()[j].Type == flux.TTime {
+			timeIdx = j
+		}
+		if bounds != b.bounds {
+			within := bounds.Intersect(*b.bounds)
+			bounds = &within
+		}
+	}
+	return tbl.Do(func(cr flux.ColReader) error {
+		for i := 0; i < cr.Len(); i++ {
+			if timeIdx >= 0 {
+				ts := cr.Times(timeIdx)
+				if ts.IsNull(i) || !bounds.Contains(Time(ts.Value(i))) {
+					continue
+				}
+			}
+			if err := AppendMappedRecordWithNulls(i, cr, builder, colMap); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// key returns the group key with the _start and _stop values set to the bounds.
+func (b *sourceCacheBuilder) key(key flux.GroupKey) (flux.GroupKey, error) {
+	if b.bounds == nil {
+		return key, nil
+	}
+	gkb := NewGroupKeyBuilder(key)
+	for label, t := range map[string]Time{
+		DefaultStartColLabel: b.bounds.Start,
+		DefaultStopColLabel:  b.bounds.Stop,
+	} {
+		if j := ColIdx(label, key.Cols()); j >= 0 && key.Cols()[j].Type == flux.TTime {
+			gkb.SetKeyValue(label, values.NewTime(t))
+		}
+	}
+	return gkb.Build()
+}
+
+// tables returns the merged tables and releases the builders
+// so that only the memory of the tables remains allocated.
+func (b *sourceCacheBuilder) tables() ([]flux.BufferedTable, error) {
+	var tables []flux.BufferedTable
+	defer b.release()
+	err := b.builders.Range(func(key flux.GroupKey, value interface{}) error {
+		builder := value.(*ColListTableBuilder)
+		if b.bounds != nil {
+			if err := b.setBounds(builder); err != nil {
+				return err
+			}
+		}
+		tbl, err := builder.Table()
+		if err != nil {
+			return err
+		}
+		buf, err := table.Copy(tbl)
+		if err != nil {
+			return err
+		}
+		tables = append(tables, buf)
+		return nil
+	})
+	if err != nil {
+		for _, tbl := range tables {
+			tbl.Done()
+		}
+		return nil, err
+	}
+	return tables, nil
+}
+
+func (b *sourceCacheBuilder) setBounds(builder *ColListTableBuilder) error {
+	for label, t := range map[string]Time{
+		DefaultStartColLabel: b.bounds.Start,
+		DefaultStopColLabel:  b.bounds.Stop,
+	} {
+		j := ColIdx(label, builder.Cols())
+		if j < 0 || builder.Cols()[j].Type != flux.TTime {
+			continue
+		}
+		for i := 0; i < builder.NRows(); i++ {
+			if err := builder.SetTime(i, j, t); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (b *sourceCacheBuilder) release() {
+	_ = b.builders.Range(func(key flux.GroupKey, value interface{}) error {
+		value.(*ColListTableBuilder).Release()
+		return nil
+	})
+	b.builders.Clear()
+}
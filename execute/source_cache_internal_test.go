@@ -0,0 +1,355 @@
+package execute
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/memory"
+	"github.com/InfluxCommunity/flux/plan"
+	"github.com/InfluxCommunity/flux/values"
+	"github.com/google/go-cmp/cmp"
+)
+
+const cacheTestKind = "cacheTest"
+
+// cacheTestSpec is a bounded source that produces one row
+// for every nanosecond within its bounds.
+type cacheTestSpec struct {
+	plan.DefaultCost
+	key    string
+	bounds Bounds
+	reads  *[]Bounds
+}
+
+func (s *cacheTestSpec) Kind() plan.ProcedureKind { return cacheTestKind }
+
+func (s *cacheTestSpec) Copy() plan.ProcedureSpec {
+	ns := *s
+	return &ns
+}
+
+func (s *cacheTestSpec) CacheKey() (string, bool) { return s.key, s.key != "" }
+
+func (s *cacheTestSpec) CacheBounds() Bounds { return s.bounds }
+
+func (s *cacheTestSpec) WithCacheBounds(bounds Bounds) plan.ProcedureSpec {
+	ns := *s
+	ns.bounds = bounds
+	return &ns
+}
+
+func createCacheTestSource(ps plan.ProcedureSpec, id DatasetID, a Administration) (Source, error) {
+	spec := ps.(*cacheTestSpec)
+	*spec.reads = append(*spec.reads, spec.bounds)
+	return CreateSourceFromIterator(cacheTestIterator{bounds: spec.bounds, mem: a.Allocator()}, id)
+}
+
+type cacheTestIterator struct {
+	bounds Bounds
+	mem    memory.Allocator
+}
+
+func (it cacheTestIterator) Do(ctx context.Context, f func(flux.Table) error) error {
+	key := NewGroupKeyBuilder(nil).
+		AddKeyValue(DefaultStartColLabel, values.NewTime(it.bounds.Start)).
+		AddKeyValue(DefaultStopColLabel, values.NewTime(it.bounds.Stop)).
+		AddKeyValue("t0", values.NewString("a"))
+	gk, err := key.Build()
+	if err != nil {
+		return err
+	}
+	b := NewColListTableBuilder(gk, it.mem)
+	defer b.Release()
+	if err := AddTableKeyCols(gk, b); err != nil {
+		return err
+	}
+	timeIdx, _ := b.AddCol(flux.ColMeta{Label: DefaultTimeColLabel, Type: flux.TTime})
+	valueIdx, _ := b.AddCol(flux.ColMeta{Label: DefaultValueColLabel, Type: flux.TInt})
+	for t := it.bounds.Start; t < it.bounds.Stop; t++ {
+		if err := AppendKeyValues(gk, b); err != nil {
+			return err
+		}
+		_ = b.AppendTime(timeIdx, t)
+		_ = b.AppendInt(valueIdx, int64(t))
+	}
+	tbl, err := b.Table()
+	if err != nil {
+		return err
+	}
+	return f(tbl)
+}
+
+type cacheTestAdministration struct {
+	mem memory.Allocator
+}
+
+func (a cacheTestAdministration) Context() context.Context      { return context.Background() }
+func (a cacheTestAdministration) ResolveTime(qt flux.Time) Time { return resolveTime(qt, time.Time{}) }
+func (a cacheTestAdministration) StreamContext() StreamContext  { return streamContext{} }
+func (a cacheTestAdministration) Allocator() memory.Allocator   { return a.mem }
+func (a cacheTestAdministration) Parents() []DatasetID          { return nil }
+func (a cacheTestAdministration) ParallelOpts() ParallelOpts    { return ParallelOpts{Factor: 1} }
+
+// readCached reads the source through the cache and returns
+// its rows as start,stop,time,value.
+func readCached(t *testing.T, c *SourceCache, spec *cacheTestSpec) []string {
+	t.Helper()
+	mem := memory.NewResourceAllocator(nil)
+	src, err := c.createSource(spec, DatasetID{}, cacheTestAdministration{mem: mem}, createCacheTestSource)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rows []string
+	w := &sourceCacheWriter{
+		process: func(tbl flux.Table) error {
+			return tbl.Do(func(cr flux.ColReader) error {
+				for i := 0; i < cr.Len(); i++ {
+					rows = append(rows, fmt.Sprintf("%d,%d,%d,%d",
+						cr.Times(ColIdx(DefaultStartColLabel, cr.Cols())).Value(i),
+						cr.Times(ColIdx(DefaultStopColLabel, cr.Cols())).Value(i),
+						cr.Times(ColIdx(DefaultTimeColLabel, cr.Cols())).Value(i),
+						cr.Ints(ColIdx(DefaultValueColLabel, cr.Cols())).Value(i),
+					))
+				}
+				return nil
+			})
+		},
+	}
+	if err := w.run(context.Background(), src); err != nil {
+		t.Fatal(err)
+	}
+	if got := mem.Allocated(); got != 0 {
+		t.Errorf("query memory was not released: %d bytes allocated", got)
+	}
+	return rows
+}
+
+func cacheTestRows(start, stop Time) []string {
+	var rows []string
+	for t := start; t < stop; t++ {
+		rows = append(rows, fmt.Sprintf("%d,%d,%d,%d", start, stop, t, t))
+	}
+	return rows
+}
+
+func TestSourceCache(t *testing.T) {
+	var reads []Bounds
+	spec := func(start, stop Time) *cacheTestSpec {
+		return &cacheTestSpec{key: "a", bounds: Bounds{Start: start, Stop: stop}, reads: &reads}
+	}
+	c := NewSourceCache(SourceCacheConfig{})
+
+	for _, tc := range []struct {
+		name      string
+		start     Time
+		stop      Time
+		wantReads []Bounds
+		wantStats SourceCacheStats
+	}{
+		{
+			name:      "miss",
+			start:     0,
+			stop:      10,
+			wantReads: []Bounds{{Start: 0, Stop: 10}},
+			wantStats: SourceCacheStats{Misses: 1},
+		},
+		{
+			name:      "hit",
+			start:     0,
+			stop:      10,
+			wantStats: SourceCacheStats{Hits: 1, Misses: 1},
+		},
+		{
+			name:      "narrower hit",
+			start:     2,
+			stop:      8,
+			wantStats: SourceCacheStats{Hits: 2, Misses: 1},
+		},
+		{
+			name:      "later bounds",
+			start:     5,
+			stop:      15,
+			wantReads: []Bounds{{Start: 10, Stop: 15}},
+			wantStats: SourceCacheStats{Hits: 2, PartialHits: 1, Misses: 1},
+		},
+		{
+			name:      "wider bounds",
+			start:     3,
+			stop:      17,
+			wantReads: []Bounds{{Start: 3, Stop: 5}, {Start: 15, Stop: 17}},
+			wantStats: SourceCacheStats{Hits: 2, PartialHits: 2, Misses: 1},
+		},
+		{
+			name:      "disjoint bounds",
+			start:     20,
+			stop:      25,
+			wantReads: []Bounds{{Start: 20, Stop: 25}},
+			wantStats: SourceCacheStats{Hits: 2, PartialHits: 2, Misses: 2},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			reads = nil
+			got := readCached(t, c, spec(tc.start, tc.stop))
+			if want := cacheTestRows(tc.start, tc.stop); !cmp.Equal(want, got) {
+				t.Errorf("unexpected rows -want/+got:\n%s", cmp.Diff(want, got))
+			}
+			if !cmp.Equal(tc.wantReads, reads) {
+				t.Errorf("unexpected reads -want/+got:\n%s", cmp.Diff(tc.wantReads, reads))
+			}
+			stats := c.Stats()
+			if stats.Entries != 1 || stats.Memory <= 0 {
+				t.Errorf("expected one entry that holds memory, got %d entries with %d bytes", stats.Entries, stats.Memory)
+			}
+			stats.Entries, stats.Memory = 0, 0
+			if !cmp.Equal(tc.wantStats, stats) {
+				t.Errorf("unexpected stats -want/+got:\n%s", cmp.Diff(tc.wantStats, stats))
+			}
+		})
+	}
+
+	c.Purge()
+	if stats := c.Stats(); stats.Entries != 0 || stats.Memory != 0 {
+		t.Errorf("expected an empty cache after purge, got %d entries with %d bytes", stats.Entries, stats.Memory)
+	}
+}
+
+func TestSourceCache_TTL(t *testing.T) {
+	var reads []Bounds
+	spec := &cacheTestSpec{key: "a", bounds: Bounds{Start: 0, Stop: 10}, reads: &reads}
+	now := time.Unix(0, 0)
+	c := NewSourceCache(SourceCacheConfig{TTL: time.Minute})
+	c.now = func() time.Time { return now }
+
+	readCached(t, c, spec)
+	now = now.Add(30 * time.Second)
+	readCached(t, c, spec)
+	if len(reads) != 1 {
+		t.Fatalf("expected the second read to be cached, got reads %v", reads)
+	}
+
+	// The later read only fetches the delta so the
+	// cached data keeps the time of the first read.
+	readCached(t, c, &cacheTestSpec{key: "a", bounds: Bounds{Start: 5, Stop: 15}, reads: &reads})
+	now = now.Add(30 * time.Second)
+	readCached(t, c, spec)
+	if want := []Bounds{{Start: 0, Stop: 10}, {Start: 10, Stop: 15}, {Start: 0, Stop: 10}}; !cmp.Equal(want, reads) {
+		t.Errorf("unexpected reads -want/+got:\n%s", cmp.Diff(want, reads))
+	}
+}
+
+func TestSourceCache_LateData(t *testing.T) {
+	var reads []Bounds
+	spec := func(start, stop Time) *cacheTestSpec {
+		return &cacheTestSpec{key: "a", bounds: Bounds{Start: start, Stop: stop}, reads: &reads}
+	}
+	c := NewSourceCache(SourceCacheConfig{LateData: 3})
+
+	for _, tc := range []struct {
+		name      string
+		start     Time
+		stop      Time
+		wantReads []Bounds
+	}{
+		{
+			name:      "miss",
+			start:     0,
+			stop:      10,
+			wantReads: []Bounds{{Start: 0, Stop: 10}},
+		},
+		{
+			name:  "hit",
+			start: 0,
+			stop:  10,
+		},
+		{
+			name:      "later bounds",
+			start:     0,
+			stop:      15,
+			wantReads: []Bounds{{Start: 7, Stop: 15}},
+		},
+		{
+			name:      "later start",
+			start:     13,
+			stop:      20,
+			wantReads: []Bounds{{Start: 13, Stop: 20}},
+		},
+		{
+			name:      "wider bounds",
+			start:     10,
+			stop:      22,
+			wantReads: []Bounds{{Start: 10, Stop: 13}, {Start: 17, Stop: 22}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			reads = nil
+			got := readCached(t, c, spec(tc.start, tc.stop))
+			if want := cacheTestRows(tc.start, tc.stop); !cmp.Equal(want, got) {
+				t.Errorf("unexpected rows -want/+got:\n%s", cmp.Diff(want, got))
+			}
+			if !cmp.Equal(tc.wantReads, reads) {
+				t.Errorf("unexpected reads -want/+got:\n%s", cmp.Diff(tc.wantReads, reads))
+			}
+		})
+	}
+}
+
+func TestSourceCache_MaxMemory(t *testing.T) {
+	var reads []Bounds
+	spec := func(key string) *cacheTestSpec {
+		return &cacheTestSpec{key: key, bounds: Bounds{Start: 0, Stop: 10}, reads: &reads}
+	}
+
+	c := NewSourceCache(SourceCacheConfig{})
+	readCached(t, c, spec("a"))
+	size := c.Stats().Memory
+
+	// The cache holds two outputs so reading
+	// a third evicts the least recently used.
+	c = NewSourceCache(SourceCacheConfig{MaxMemory: 2 * size})
+	readCached(t, c, spec("a"))
+	readCached(t, c, spec("b"))
+	readCached(t, c, spec("a"))
+	readCached(t, c, spec("c"))
+	if stats := c.Stats(); stats.Entries != 2 || stats.Memory != 2*size {
+		t.Errorf("expected two entries with %d bytes, got %d entries with %d bytes", 2*size, stats.Entries, stats.Memory)
+	}
+
+	reads = nil
+	readCached(t, c, spec("a"))
+	readCached(t, c, spec("b"))
+	if want := []Bounds{{Start: 0, Stop: 10}}; !cmp.Equal(want, reads) {
+		t.Errorf("expected only b to be read again -want/+got:\n%s", cmp.Diff(want, reads))
+	}
+
+	// Output larger than the cache is not kept.
+	c = NewSourceCache(SourceCacheConfig{MaxMemory: size - 1})
+	readCached(t, c, spec("a"))
+	if stats := c.Stats(); stats.Entries != 0 || stats.Memory != 0 {
+		t.Errorf("expected an empty cache, got %d entries with %d bytes", stats.Entries, stats.Memory)
+	}
+}
+
+func TestSourceCache_Invalidate(t *testing.T) {
+	var reads []Bounds
+	spec := &cacheTestSpec{key: "a", bounds: Bounds{Start: 0, Stop: 10}, reads: &reads}
+	c := NewSourceCache(SourceCacheConfig{})
+
+	readCached(t, c, spec)
+	c.Invalidate(spec)
+	readCached(t, c, spec)
+	if len(reads) != 2 {
+		t.Errorf("expected the source to be read again after it was invalidated, got reads %v", reads)
+	}
+
+	// Sources without a key are not cached.
+	reads = nil
+	uncached := &cacheTestSpec{bounds: Bounds{Start: 0, Stop: 10}, reads: &reads}
+	readCached(t, c, uncached)
+	readCached(t, c, uncached)
+	if len(reads) != 2 {
+		t.Errorf("expected a source without a key to be read every time, got reads %v", reads)
+	}
+}
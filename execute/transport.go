@@ -8,16 +8,17 @@ import (
 	"sync/atomic"
 
 	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/array"
 	"github.com/InfluxCommunity/flux/arrow"
 	"github.com/InfluxCommunity/flux/codes"
 	"github.com/InfluxCommunity/flux/execute/table"
 	"github.com/InfluxCommunity/flux/internal/errors"
-	"github.com/InfluxCommunity/flux/internal/jaeger"
+	"github.com/InfluxCommunity/flux/internal/telemetry"
 	"github.com/InfluxCommunity/flux/interpreter"
 	"github.com/InfluxCommunity/flux/plan"
 	"github.com/apache/arrow/go/v7/arrow/memory"
-	"github.com/opentracing/opentracing-go"
-	"github.com/opentracing/opentracing-go/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
@@ -63,8 +64,16 @@ type consecutiveTransport struct {
 	inflight       int32
 	totalMsgs      int32
 
+	// chunkKeys holds the group keys of the table chunks
+	// that have been received so each table is counted once.
+	chunkKeys *RandomAccessGroupLookup
+
+	// upstream is the profile of the node that sends data to this
+	// transport if the transport counts the output of that node.
+	upstream *nodeProfile
+
 	initSpanOnce sync.Once
-	span         opentracing.Span
+	span         trace.Span
 }
 
 func newConsecutiveTransport(ctx context.Context, dispatcher Dispatcher, t Transformation, n plan.Node, logger *zap.Logger, mem memory.Allocator) *consecutiveTransport {
@@ -231,13 +240,13 @@ func (t *consecutiveTransport) transition(new int32) {
 
 func (t *consecutiveTransport) initSpan(ctx context.Context) {
 	t.initSpanOnce.Do(func() {
-		t.span, _ = opentracing.StartSpanFromContext(ctx, t.profile.NodeType, opentracing.Tag{Key: "label", Value: t.profile.Label})
+		_, t.span = telemetry.StartSpan(ctx, t.profile.NodeType, attribute.String("label", t.profile.Label))
 	})
 }
 
 func (t *consecutiveTransport) finishSpan(err error) {
-	t.span.LogFields(log.Int("messages_processed", int(atomic.LoadInt32(&t.totalMsgs))), log.Error(err))
-	t.span.Finish()
+	t.span.SetAttributes(attribute.Int("messages_processed", int(atomic.LoadInt32(&t.totalMsgs))))
+	telemetry.FinishSpan(t.span, err)
 }
 
 func (t *consecutiveTransport) processMessages(ctx context.Context, throughput int) {
@@ -291,6 +300,7 @@ PROCESS:
 // processMessage processes the message on t.
 // The return value is true if the message was a FinishMsg.
 func (t *consecutiveTransport) processMessage(m Message) (finished bool, err error) {
+	t.countInput(m)
 	span := t.profile.StartSpan()
 	defer span.Finish()
 
@@ -301,6 +311,57 @@ func (t *consecutiveTransport) processMessage(m Message) (finished bool, err err
 	return finished, nil
 }
 
+// countInput records the tables received by the transport.
+// The rows of a whole table are counted as the table is read.
+func (t *consecutiveTransport) countInput(m Message) {
+	switch m := m.(type) {
+	case ProcessMsg:
+		t.countTables(1)
+	case ProcessChunkMsg:
+		chunk := m.TableChunk()
+		if t.chunkKeys == nil {
+			t.chunkKeys = NewRandomAccessGroupLookup()
+		}
+		if _, ok := t.chunkKeys.Lookup(chunk.Key()); !ok {
+			t.chunkKeys.Set(chunk.Key(), true)
+			t.countTables(1)
+		}
+		var size int64
+		for j, n := 0, chunk.NCols(); j < n; j++ {
+			size += arrowBytes(chunk.Values(j))
+		}
+		t.countRows(int64(chunk.Len()), size)
+	}
+}
+
+func (t *consecutiveTransport) countTables(n int64) {
+	atomic.AddInt64(&t.profile.InputTables, n)
+	t.upstream.addTables(n)
+}
+
+func (t *consecutiveTransport) countRows(rows, bytes int64) {
+	atomic.AddInt64(&t.profile.InputRows, rows)
+	atomic.AddInt64(&t.profile.InputBytes, bytes)
+	t.upstream.addRows(rows, bytes)
+}
+
+// arrowBytes returns the size of the buffers that hold the array.
+// Arrays that are not backed by arrow data, such as a string
+// array that repeats a single value, are not counted.
+func arrowBytes(arr array.Array) int64 {
+	data := arr.Data()
+	if data == nil {
+		return 0
+	}
+	var size int64
+	for _, buf := range data.Buffers() {
+		if buf != nil {
+			size += int64(buf.Len())
+		}
+	}
+	return size
+}
+
 // Message is a message sent from one Dataset to another.
 type Message interface {
 	// Type returns the MessageType for this Message.
@@ -552,6 +613,7 @@ func (t *consecutiveTransportTable) Cols() []flux.ColMeta {
 
 func (t *consecutiveTransportTable) Do(f func(flux.ColReader) error) error {
 	return t.tbl.Do(func(cr flux.ColReader) error {
+		t.count(cr)
 		if err := t.validate(cr); err != nil {
 			fields := []zap.Field{
 				zap.String("source", t.transport.sourceInfo()),
@@ -559,13 +621,11 @@ func (t *consecutiveTransportTable) Do(f func(flux.ColReader) error) error {
 			}
 
 			ctx, logger := t.transport.ctx, t.transport.logger
-			if span := opentracing.SpanFromContext(ctx); span != nil {
-				if traceID, sampled, found := jaeger.InfoFromSpan(span); found {
-					fields = append(fields,
-						zap.String("tracing/id", traceID),
-						zap.Bool("tracing/sampled", sampled),
-					)
-				}
+			if traceID, sampled, found := telemetry.InfoFromContext(ctx); found {
+				fields = append(fields,
+					zap.String("tracing/id", traceID),
+					zap.Bool("tracing/sampled", sampled),
+				)
 			}
 			logger.Info("Invalid column reader received from predecessor", fields...)
 		}
@@ -581,6 +641,11 @@ func (t *consecutiveTransportTable) Empty() bool {
 	return t.tbl.Empty()
 }
 
+// count records the rows that were read from the table.
+func (t *consecutiveTransportTable) count(cr flux.ColReader) {
+	t.transport.countRows(int64(cr.Len()), colReaderBytes(cr))
+}
+
 func (t *consecutiveTransportTable) validate(cr flux.ColReader) error {
 	if len(cr.Cols()) == 0 {
 		return nil
@@ -0,0 +1,148 @@
+// Package anomaly implements streaming detectors that score each
+// value of a series by how far it is from the values before it.
+package anomaly
+
+import "math"
+
+// Detector scores the values of a series in order.
+//
+// A score is a signed distance from what is normal for the series in
+// units of its dispersion, so a value is anomalous when the absolute
+// value of its score is greater than a threshold.
+type Detector interface {
+	// Next scores x against the values before it and then adds x
+	// to the state of the detector. It reports false while there
+	// are too few values to score x.
+	Next(x float64) (score float64, ok bool)
+}
+
+// standardize returns the distance of x from center in units of scale.
+// A series without dispersion makes any other value infinitely far.
+func standardize(x, center, scale float64) float64 {
+	switch {
+	case scale > 0:
+		return (x - center) / scale
+	case x > center:
+		return math.Inf(1)
+	case x < center:
+		return math.Inf(-1)
+	default:
+		return 0
+	}
+}
+
+// EWMA scores values against an exponentially weighted moving average
+// and standard deviation, which form a control band around the series.
+type EWMA struct {
+	alpha    float64
+	warmup   int
+	count    int
+	mean     float64
+	variance float64
+
+	// center and scale are the band of the last scored value.
+	center, scale float64
+}
+
+// NewEWMA returns an EWMA detector where each value has a weight of alpha,
+// between 0 exclusive and 1. Values are scored after the span of the
+// average, 2/alpha - 1 values, has been seen and at least two values.
+func NewEWMA(alpha float64) *EWMA {
+	return &EWMA{
+		alpha:  alpha,
+		warmup: int(math.Max(2, math.Ceil(2/alpha-1))),
+	}
+}
+
+func (e *EWMA) Next(x float64) (score float64, ok bool) {
+	if e.count >= e.warmup {
+		e.center, e.scale = e.mean, math.Sqrt(e.variance)
+		score, ok = standardize(x, e.center, e.scale), true
+	}
+
+	if e.count == 0 {
+		e.mean = x
+	} else {
+		diff := x - e.mean
+		incr := e.alpha * diff
+		e.mean += incr
+		e.variance = (1 - e.alpha) * (e.variance + diff*incr)
+	}
+	e.count++
+	return score, ok
+}
+
+// Band returns the average and the standard deviation
+// that the last scored value was scored against.
+func (e *EWMA) Band() (center, scale float64) {
+	return e.center, e.scale
+}
+
+// CUSUM detects changes of the level of a series with the tabular
+// cumulative sum of the deviations of the values from a reference level.
+//
+// The reference mean and standard deviation are estimated from the first
+// n values and estimated again from the n values after each change.
+// Deviations are standardized and reduced by drift before they are summed,
+// so the sums only grow when the level has shifted by more than drift
+// standard deviations. The score is the larger of the upper sum and the
+// negated lower sum, and both sums restart once the score exceeds threshold.
+type CUSUM struct {
+	n         int
+	drift     float64
+	threshold float64
+
+	reference []float64
+	mean, sd  float64
+	upper     float64
+	lower     float64
+}
+
+// NewCUSUM returns a CUSUM detector with a reference of n values,
+// which must be at least 2.
+func NewCUSUM(n int, drift, threshold float64) *CUSUM {
+	return &CUSUM{
+		n:         n,
+		drift:     drift,
+		threshold: threshold,
+		reference: make([]float64, 0, n),
+	}
+}
+
+func (c *CUSUM) Next(x float64) (score float64, ok bool) {
+	if len(c.reference) < c.n {
+		c.reference = append(c.reference, x)
+		if len(c.reference) == c.n {
+			c.mean, c.sd = meanStddev(c.reference)
+		}
+		return 0, false
+	}
+
+	z := standardize(x, c.mean, c.sd)
+	c.upper = math.Max(0, c.upper+z-c.drift)
+	c.lower = math.Max(0, c.lower-z-c.drift)
+	score = c.upper
+	if c.lower > c.upper {
+		score = -c.lower
+	}
+	if math.Abs(score) > c.threshold {
+		// The level has changed so start over
+		// with a reference at the new level.
+		c.reference = c.reference[:0]
+		c.upper, c.lower = 0, 0
+	}
+	return score, true
+}
+
+// meanStddev returns the mean and the sample standard deviation of vs.
+func meanStddev(vs []float64) (mean, sd float64) {
+	for _, v := range vs {
+		mean += v
+	}
+	mean /= float64(len(vs))
+	var ss float64
+	for _, v := range vs {
+		ss += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(ss / float64(len(vs)-1))
+}
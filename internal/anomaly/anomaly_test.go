@@ -0,0 +1,127 @@
+package anomaly_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/InfluxCommunity/flux/internal/anomaly"
+)
+
+// scores returns the scores of a detector for the values,
+// with NaN for values that were not scored.
+func scores(d anomaly.Detector, vs []float64) []float64 {
+	s := make([]float64, len(vs))
+	for i, v := range vs {
+		score, ok := d.Next(v)
+		if !ok {
+			score = math.NaN()
+		}
+		s[i] = score
+	}
+	return s
+}
+
+func checkScores(t *testing.T, got, want []float64) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("unexpected number of scores: got %d, want %d", len(got), len(want))
+	}
+	for i := range got {
+		if math.IsNaN(want[i]) {
+			if !math.IsNaN(got[i]) {
+				t.Fatalf("unexpected score at %d: got %v, want none", i, got[i])
+			}
+			continue
+		}
+		if got[i] != want[i] && math.Abs(got[i]-want[i]) > 1e-9 {
+			t.Fatalf("unexpected score at %d: got %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestDetectors(t *testing.T) {
+	nan, inf := math.NaN(), math.Inf(1)
+	for _, tc := range []struct {
+		name string
+		d    anomaly.Detector
+		vs   []float64
+		want []float64
+	}{
+		{
+			name: "zscore",
+			d:    anomaly.NewZScore(3),
+			vs:   []float64{1, 2, 3, 4, 2, 10},
+			// The window of the 4 is 1, 2, 3 with a mean of 2
+			// and a standard deviation of 1.
+			want: []float64{nan, nan, nan, 2, -1, 7},
+		},
+		{
+			name: "zscore constant",
+			d:    anomaly.NewZScore(2),
+			vs:   []float64{5, 5, 5, 6, 4},
+			want: []float64{nan, nan, 0, inf, -1.5 * math.Sqrt2},
+		},
+		{
+			name: "mad",
+			d:    anomaly.NewMAD(5),
+			vs:   []float64{1, 2, 3, 4, 100, 5, 6},
+			// The median of 1, 2, 3, 4, 100 is 3 and
+			// the median of the deviations 2, 1, 0, 1, 97 is 1.
+			want: []float64{nan, nan, nan, nan, nan, 2 * 0.6745, 2 * 0.6745},
+		},
+		{
+			name: "iqr",
+			d:    anomaly.NewIQR(5),
+			vs:   []float64{1, 2, 3, 4, 5, 3.5, 10, -4},
+			// The quartiles of 1, 2, 3, 4, 5 are 2 and 4,
+			// then 3 and 4, then 3.5 and 5.
+			want: []float64{nan, nan, nan, nan, nan, 0, 6, -5},
+		},
+		{
+			name: "ewma",
+			d:    anomaly.NewEWMA(0.5),
+			vs:   []float64{2, 4, 3, 3},
+			// Values are scored after a span of 3 values,
+			// where the average is 3.
+			want: []float64{nan, nan, nan, 0},
+		},
+		{
+			name: "cusum",
+			d:    anomaly.NewCUSUM(4, 0.5, 4),
+			vs:   []float64{9, 11, 9, 11, 10, 12, 12, 12, 12, 12, 12},
+			// The reference has a mean of 10 and a standard deviation
+			// of 2/sqrt(3), so each 12 adds sqrt(3)-0.5 to the sum
+			// until it is greater than 4 and a new reference starts.
+			want: []float64{
+				nan, nan, nan, nan,
+				0,
+				math.Sqrt(3) - 0.5,
+				2 * (math.Sqrt(3) - 0.5),
+				3 * (math.Sqrt(3) - 0.5),
+				4 * (math.Sqrt(3) - 0.5),
+				nan, nan,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			checkScores(t, scores(tc.d, tc.vs), tc.want)
+		})
+	}
+}
+
+func TestEWMA_Band(t *testing.T) {
+	e := anomaly.NewEWMA(0.5)
+	// The average is 2, 3, 3 and the variance is 0, 1, 0.5.
+	for _, v := range []float64{2, 4, 3} {
+		if _, ok := e.Next(v); ok {
+			t.Fatal("unexpected score during warmup")
+		}
+	}
+	score, ok := e.Next(4)
+	if !ok || math.Abs(score-math.Sqrt2) > 1e-9 {
+		t.Fatalf("unexpected score: got (%v, %v), want (%v, true)", score, ok, math.Sqrt2)
+	}
+	if center, scale := e.Band(); center != 3 || math.Abs(scale-math.Sqrt(0.5)) > 1e-9 {
+		t.Fatalf("unexpected band: got (%v, %v), want (3, %v)", center, scale, math.Sqrt(0.5))
+	}
+}
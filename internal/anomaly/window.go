@@ -0,0 +1,139 @@
+package anomaly
+
+import (
+	"math"
+	"sort"
+)
+
+// madScale makes the median absolute deviation of a normal
+// distribution comparable to its standard deviation.
+const madScale = 0.6745
+
+// window holds the last n values of a series
+// in the order they were added and sorted.
+type window struct {
+	values []float64
+	sorted []float64
+	next   int
+}
+
+func newWindow(n int) window {
+	return window{
+		values: make([]float64, 0, n),
+		sorted: make([]float64, 0, n),
+	}
+}
+
+func (w *window) full() bool {
+	return len(w.values) == cap(w.values)
+}
+
+// add adds x to the window and removes the oldest value if it is full.
+func (w *window) add(x float64) {
+	if w.full() {
+		old := w.values[w.next]
+		i := sort.SearchFloat64s(w.sorted, old)
+		w.sorted = append(w.sorted[:i], w.sorted[i+1:]...)
+		w.values[w.next] = x
+		w.next = (w.next + 1) % len(w.values)
+	} else {
+		w.values = append(w.values, x)
+	}
+	i := sort.SearchFloat64s(w.sorted, x)
+	w.sorted = append(w.sorted, 0)
+	copy(w.sorted[i+1:], w.sorted[i:])
+	w.sorted[i] = x
+}
+
+// quantile returns the q-th quantile of the sorted values
+// interpolated linearly between the closest ranks.
+func quantile(sorted []float64, q float64) float64 {
+	h := q * float64(len(sorted)-1)
+	lo := int(math.Floor(h))
+	if lo+1 >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[lo] + (h-float64(lo))*(sorted[lo+1]-sorted[lo])
+}
+
+// ZScore scores values by their distance from the mean
+// of the previous n values in standard deviations.
+type ZScore struct {
+	w window
+}
+
+// NewZScore returns a z-score detector over windows of n values,
+// which must be at least 2.
+func NewZScore(n int) *ZScore {
+	return &ZScore{w: newWindow(n)}
+}
+
+func (z *ZScore) Next(x float64) (score float64, ok bool) {
+	if z.w.full() {
+		mean, sd := meanStddev(z.w.values)
+		score, ok = standardize(x, mean, sd), true
+	}
+	z.w.add(x)
+	return score, ok
+}
+
+// MAD scores values with the modified z-score of Iglewicz and Hoaglin,
+// the distance from the median of the previous n values in units of
+// their median absolute deviation. Unlike the mean and the standard
+// deviation, neither is skewed by the outliers in the window.
+type MAD struct {
+	w          window
+	deviations []float64
+}
+
+// NewMAD returns a MAD detector over windows of n values,
+// which must be at least 2.
+func NewMAD(n int) *MAD {
+	return &MAD{
+		w:          newWindow(n),
+		deviations: make([]float64, n),
+	}
+}
+
+func (m *MAD) Next(x float64) (score float64, ok bool) {
+	if m.w.full() {
+		median := quantile(m.w.sorted, 0.5)
+		for i, v := range m.w.sorted {
+			m.deviations[i] = math.Abs(v - median)
+		}
+		sort.Float64s(m.deviations)
+		mad := quantile(m.deviations, 0.5)
+		score, ok = madScale*standardize(x, median, mad), true
+	}
+	m.w.add(x)
+	return score, ok
+}
+
+// IQR scores values by their distance outside of the interquartile range
+// of the previous n values in units of the range. Values between the
+// quartiles have a score of 0, so a threshold of 1.5 flags the outliers
+// of Tukey's fences.
+type IQR struct {
+	w window
+}
+
+// NewIQR returns an IQR detector over windows of n values,
+// which must be at least 2.
+func NewIQR(n int) *IQR {
+	return &IQR{w: newWindow(n)}
+}
+
+func (d *IQR) Next(x float64) (score float64, ok bool) {
+	if d.w.full() {
+		q1, q3 := quantile(d.w.sorted, 0.25), quantile(d.w.sorted, 0.75)
+		switch {
+		case x < q1:
+			score = standardize(x, q1, q3-q1)
+		case x > q3:
+			score = standardize(x, q3, q3-q1)
+		}
+		ok = true
+	}
+	d.w.add(x)
+	return score, ok
+}
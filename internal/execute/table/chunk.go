@@ -2,8 +2,11 @@ package table
 
 import (
 	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/array"
 	"github.com/InfluxCommunity/flux/arrow"
+	"github.com/InfluxCommunity/flux/codes"
 	"github.com/InfluxCommunity/flux/execute/table"
+	"github.com/InfluxCommunity/flux/internal/errors"
 )
 
 type Chunk = table.Chunk
@@ -15,3 +18,48 @@ func ChunkFromBuffer(buf arrow.TableBuffer) Chunk {
 func ChunkFromReader(cr flux.ColReader) Chunk {
 	return table.ChunkFromReader(cr)
 }
+
+// AppendColumns returns the chunk with the columns added to it.
+// Columns of the chunk with the same label are replaced, unless
+// they are part of the group key. The returned chunk takes
+// ownership of the arrays, which are released on error.
+func AppendColumns(chunk Chunk, cols []flux.ColMeta, arrs []array.Array) (Chunk, error) {
+	newCols := make([]flux.ColMeta, 0, chunk.NCols()+len(cols))
+	vs := make([]array.Array, 0, chunk.NCols()+len(cols))
+	for i, col := range chunk.Cols() {
+		if containsCol(cols, col.Label) {
+			if chunk.Key().HasCol(col.Label) {
+				for _, arr := range arrs {
+					arr.Release()
+				}
+				for _, arr := range vs {
+					arr.Release()
+				}
+				return Chunk{}, errors.Newf(codes.FailedPrecondition, "cannot replace column %s that is part of the group key", col.Label)
+			}
+			continue
+		}
+		arr := chunk.Values(i)
+		arr.Retain()
+		newCols = append(newCols, col)
+		vs = append(vs, arr)
+	}
+	newCols = append(newCols, cols...)
+	vs = append(vs, arrs...)
+
+	buffer := arrow.TableBuffer{
+		GroupKey: chunk.Key(),
+		Columns:  newCols,
+		Values:   vs,
+	}
+	return ChunkFromBuffer(buffer), nil
+}
+
+func containsCol(cols []flux.ColMeta, label string) bool {
+	for _, col := range cols {
+		if col.Label == label {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package table_test
+
+import (
+	"testing"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/array"
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/execute/table/static"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/internal/execute/table"
+	"github.com/InfluxCommunity/flux/memory"
+	"github.com/google/go-cmp/cmp"
+)
+
+// floats returns an array with the values.
+func floats(vs ...float64) array.Array {
+	b := array.NewFloatBuilder(memory.DefaultAllocator)
+	for _, v := range vs {
+		b.Append(v)
+	}
+	return b.NewArray()
+}
+
+func TestAppendColumns(t *testing.T) {
+	in := static.Table{
+		static.StringKey("t0", "a"),
+		static.Ints("_value", 1, 2, 3),
+		static.Floats("score", 0.0, 0.0, 0.0),
+	}
+	if err := in.Do(func(tbl flux.Table) error {
+		return tbl.Do(func(cr flux.ColReader) error {
+			// The existing score column is replaced.
+			out, err := table.AppendColumns(table.ChunkFromReader(cr),
+				[]flux.ColMeta{
+					{Label: "score", Type: flux.TFloat},
+					{Label: "fitted", Type: flux.TFloat},
+				},
+				[]array.Array{floats(0.5, 1.5, 2.5), floats(1.0, 2.0, 3.0)},
+			)
+			if err != nil {
+				return err
+			}
+			defer out.Release()
+
+			wantCols := []flux.ColMeta{
+				{Label: "t0", Type: flux.TString},
+				{Label: "_value", Type: flux.TInt},
+				{Label: "score", Type: flux.TFloat},
+				{Label: "fitted", Type: flux.TFloat},
+			}
+			if !cmp.Equal(wantCols, out.Cols()) {
+				t.Errorf("unexpected columns -want/+got:\n%s", cmp.Diff(wantCols, out.Cols()))
+			}
+			for label, want := range map[string][]float64{
+				"score":  {0.5, 1.5, 2.5},
+				"fitted": {1.0, 2.0, 3.0},
+			} {
+				if got := out.Floats(out.Index(label)).Float64Values(); !cmp.Equal(want, got) {
+					t.Errorf("unexpected %s values -want/+got:\n%s", label, cmp.Diff(want, got))
+				}
+			}
+			return nil
+		})
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAppendColumns_GroupKey(t *testing.T) {
+	in := static.Table{
+		static.StringKey("t0", "a"),
+		static.Ints("_value", 1, 2, 3),
+	}
+	err := in.Do(func(tbl flux.Table) error {
+		return tbl.Do(func(cr flux.ColReader) error {
+			_, err := table.AppendColumns(table.ChunkFromReader(cr),
+				[]flux.ColMeta{{Label: "t0", Type: flux.TFloat}},
+				[]array.Array{floats(0.5, 1.5, 2.5)},
+			)
+			return err
+		})
+	})
+	if err == nil {
+		t.Fatal("expected an error when replacing a group key column")
+	}
+	if got, want := errors.Code(err), codes.FailedPrecondition; got != want {
+		t.Errorf("unexpected error code: got %v, want %v", got, want)
+	}
+}
@@ -1,8 +1,11 @@
 package jaeger
 
 import (
+	"encoding/binary"
+
 	"github.com/opentracing/opentracing-go"
 	"github.com/uber/jaeger-client-go"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // InfoFromSpan returns the traceID and if it was sampled from the span, given
@@ -20,3 +23,33 @@ func InfoFromSpan(span opentracing.Span) (traceID string, sampled bool, found bo
 	}
 	return
 }
+
+// SpanContextFromSpan returns the OpenTelemetry span context for
+// the span, given it is a jaeger span. It returns whether the span
+// was a jaeger span.
+func SpanContextFromSpan(span opentracing.Span) (trace.SpanContext, bool) {
+	type ctxWithIDs interface {
+		TraceID() jaeger.TraceID
+		SpanID() jaeger.SpanID
+		IsSampled() bool
+	}
+	ctx, ok := span.Context().(ctxWithIDs)
+	if !ok {
+		return trace.SpanContext{}, false
+	}
+
+	var traceID trace.TraceID
+	binary.BigEndian.PutUint64(traceID[:8], ctx.TraceID().High)
+	binary.BigEndian.PutUint64(traceID[8:], ctx.TraceID().Low)
+	var spanID trace.SpanID
+	binary.BigEndian.PutUint64(spanID[:], uint64(ctx.SpanID()))
+	var flags trace.TraceFlags
+	if ctx.IsSampled() {
+		flags = trace.FlagsSampled
+	}
+	return trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: flags,
+	}), true
+}
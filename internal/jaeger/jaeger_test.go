@@ -33,6 +33,34 @@ func TestTracingInfo(t *testing.T) {
 	}
 }
 
+func TestSpanContextFromSpan(t *testing.T) {
+	span := mockSpan{
+		MockSpan: mocktracer.New().StartSpan("test").(*mocktracer.MockSpan),
+	}
+	span.SpanContext.Sampled = true
+	span.SpanContext.TraceID = traceIDDecimal
+	span.SpanContext.SpanID = 11
+	span.ctx = mockSpanContext{span.SpanContext}
+
+	sc, found := SpanContextFromSpan(span)
+	if !found {
+		t.Fatal("span context not found in span")
+	}
+	if want, got := "0000000000000000"+traceIDHex, sc.TraceID().String(); want != got {
+		t.Fatalf("trace ID does not match actual=%v expected=%v", got, want)
+	}
+	if want, got := "000000000000000b", sc.SpanID().String(); want != got {
+		t.Fatalf("span ID does not match actual=%v expected=%v", got, want)
+	}
+	if !sc.IsSampled() {
+		t.Fatal("span context found but not sampled")
+	}
+
+	if _, found := SpanContextFromSpan(mocktracer.New().StartSpan("test")); found {
+		t.Fatal("unexpected span context for a span that is not a jaeger span")
+	}
+}
+
 type mockSpan struct {
 	ctx mockSpanContext
 	*mocktracer.MockSpan
@@ -50,6 +78,10 @@ func (m mockSpanContext) TraceID() jaeger.TraceID {
 	return jaeger.TraceID{High: 0, Low: uint64(m.MockSpanContext.TraceID)}
 }
 
+func (m mockSpanContext) SpanID() jaeger.SpanID {
+	return jaeger.SpanID(m.MockSpanContext.SpanID)
+}
+
 func (m mockSpanContext) IsSampled() bool {
 	return m.MockSpanContext.Sampled
 }
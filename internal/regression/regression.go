@@ -0,0 +1,145 @@
+// Package regression fits models to paired values by least squares.
+package regression
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Linear accumulates the sums of a simple linear regression of y on x
+// so values can be added one at a time without being kept.
+type Linear struct {
+	n            int
+	sumX, sumY   float64
+	sumXY, sumX2 float64
+}
+
+// Add adds a pair of values to the regression.
+func (l *Linear) Add(x, y float64) {
+	l.n++
+	l.sumX += x
+	l.sumY += y
+	l.sumXY += x * y
+	l.sumX2 += x * x
+}
+
+// N returns the number of pairs added to the regression.
+func (l *Linear) N() int {
+	return l.n
+}
+
+// Fit returns the slope and the intercept of the line fitted to the pairs.
+// The slope is NaN or infinite if all x values are the same.
+func (l *Linear) Fit() (slope, intercept float64) {
+	n := float64(l.n)
+	covXY := l.sumXY - l.sumX*l.sumY/n
+	varX := l.sumX2 - l.sumX*l.sumX/n
+
+	slope = covXY / varX
+	intercept = l.sumY/n - slope*l.sumX/n
+	return slope, intercept
+}
+
+// Polynomial returns the coefficients of the polynomial of the degree
+// fitted to the pairs, in order of increasing power of x.
+// It returns false if there are fewer pairs than coefficients
+// or the x values do not determine a single polynomial.
+func Polynomial(x, y []float64, degree int) ([]float64, bool) {
+	n, m := len(x), degree+1
+	if degree < 0 || n < m {
+		return nil, false
+	}
+
+	// Powers of large x values are fitted with little precision,
+	// so the polynomial is fitted to x centered on its mean and scaled
+	// to [-1, 1], then its coefficients are expanded back to x.
+	var center, scale float64
+	for _, v := range x {
+		center += v
+	}
+	center /= float64(n)
+	for _, v := range x {
+		scale = math.Max(scale, math.Abs(v-center))
+	}
+	if scale == 0 {
+		if degree > 0 {
+			return nil, false
+		}
+		scale = 1
+	}
+
+	a := mat.NewDense(n, m, nil)
+	for i, v := range x {
+		u := (v - center) / scale
+		p := 1.0
+		for j := 0; j < m; j++ {
+			a.Set(i, j, p)
+			p *= u
+		}
+	}
+	var qr mat.QR
+	qr.Factorize(a)
+	var c mat.Dense
+	if err := qr.SolveTo(&c, false, mat.NewDense(n, 1, append([]float64(nil), y...))); err != nil {
+		return nil, false
+	}
+
+	// Expand each term c_k ((x - center) / scale)^k
+	// with the binomial theorem.
+	coeffs := make([]float64, m)
+	for k := 0; k < m; k++ {
+		ck := c.At(k, 0) / math.Pow(scale, float64(k))
+		binom := 1.0
+		for j := k; j >= 0; j-- {
+			coeffs[j] += ck * binom * math.Pow(-center, float64(k-j))
+			binom = binom * float64(j) / float64(k-j+1)
+		}
+	}
+	return coeffs, true
+}
+
+// Eval returns the value of the polynomial with the coefficients at x.
+func Eval(coeffs []float64, x float64) float64 {
+	var v float64
+	for i := len(coeffs) - 1; i >= 0; i-- {
+		v = v*x + coeffs[i]
+	}
+	return v
+}
+
+// RSquared returns the coefficient of determination of the fitted values,
+// the proportion of the variance of y explained by the model.
+// It is NaN if all y values are the same.
+func RSquared(y, fitted []float64) float64 {
+	var mean float64
+	for _, v := range y {
+		mean += v
+	}
+	mean /= float64(len(y))
+
+	var ssRes, ssTot float64
+	for i, v := range y {
+		ssRes += (v - fitted[i]) * (v - fitted[i])
+		ssTot += (v - mean) * (v - mean)
+	}
+	if ssTot == 0 {
+		return math.NaN()
+	}
+	return 1 - ssRes/ssTot
+}
+
+// StandardError returns the standard error of the residuals of the
+// fitted values of a model with the number of coefficients.
+// It is NaN if there are no more values than coefficients.
+func StandardError(y, fitted []float64, coefficients int) float64 {
+	df := len(y) - coefficients
+	if df <= 0 {
+		return math.NaN()
+	}
+	var ssRes float64
+	for i, v := range y {
+		ssRes += (v - fitted[i]) * (v - fitted[i])
+	}
+	return math.Sqrt(ssRes / float64(df))
+}
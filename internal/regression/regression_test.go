@@ -0,0 +1,72 @@
+package regression_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/InfluxCommunity/flux/internal/regression"
+)
+
+func TestLinear(t *testing.T) {
+	var l regression.Linear
+	for _, p := range [][2]float64{{1, 3}, {2, 5}, {3, 7.5}, {4, 8.5}} {
+		l.Add(p[0], p[1])
+	}
+	if l.N() != 4 {
+		t.Fatalf("unexpected number of pairs: got %d, want 4", l.N())
+	}
+	// The covariance sum is 9.5 and the variance sum of x is 5.
+	slope, intercept := l.Fit()
+	if math.Abs(slope-1.9) > 1e-9 || math.Abs(intercept-1.25) > 1e-9 {
+		t.Fatalf("unexpected fit: got (%v, %v), want (1.9, 1.25)", slope, intercept)
+	}
+}
+
+func TestPolynomial(t *testing.T) {
+	// y = 2 - 3x + 0.5x^2 sampled far from the origin.
+	var x, y []float64
+	for i := 0; i < 10; i++ {
+		v := 1e6 + 10*float64(i)
+		x = append(x, v)
+		y = append(y, 2-3*(v-1e6)+0.5*(v-1e6)*(v-1e6))
+	}
+	coeffs, ok := regression.Polynomial(x, y, 2)
+	if !ok {
+		t.Fatal("expected a fit")
+	}
+	for i, v := range x {
+		if got := regression.Eval(coeffs, v); math.Abs(got-y[i]) > 1e-3 {
+			t.Fatalf("unexpected fitted value at %d: got %v, want %v", i, got, y[i])
+		}
+	}
+
+	coeffs, ok = regression.Polynomial([]float64{0, 1, 2}, []float64{1, 3, 5}, 1)
+	if !ok || math.Abs(coeffs[0]-1) > 1e-9 || math.Abs(coeffs[1]-2) > 1e-9 {
+		t.Fatalf("unexpected coefficients: got (%v, %v), want [1 2]", coeffs, ok)
+	}
+
+	if _, ok := regression.Polynomial([]float64{0, 1}, []float64{1, 3}, 2); ok {
+		t.Fatal("expected no fit with fewer pairs than coefficients")
+	}
+	if _, ok := regression.Polynomial([]float64{1, 1, 1}, []float64{1, 2, 3}, 1); ok {
+		t.Fatal("expected no fit with constant x")
+	}
+}
+
+func TestGoodness(t *testing.T) {
+	y := []float64{1, 2, 3, 4}
+	fitted := []float64{1.5, 1.5, 3.5, 3.5}
+	// The residuals are all 0.5 and the total sum of squares is 5.
+	if got := regression.RSquared(y, fitted); math.Abs(got-0.8) > 1e-9 {
+		t.Fatalf("unexpected r squared: got %v, want 0.8", got)
+	}
+	if got := regression.StandardError(y, fitted, 2); math.Abs(got-math.Sqrt(0.5)) > 1e-9 {
+		t.Fatalf("unexpected standard error: got %v, want %v", got, math.Sqrt(0.5))
+	}
+	if got := regression.RSquared([]float64{2, 2}, []float64{2, 2}); !math.IsNaN(got) {
+		t.Fatalf("unexpected r squared of constant values: got %v, want NaN", got)
+	}
+	if got := regression.StandardError(y, fitted, 4); !math.IsNaN(got) {
+		t.Fatalf("unexpected standard error without residual freedom: got %v, want NaN", got)
+	}
+}
@@ -0,0 +1,233 @@
+package scheduler
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/internal/errors"
+)
+
+// Schedule determines the times a task is scheduled for.
+type Schedule interface {
+	// Next returns the first time the task is scheduled for after t.
+	// It returns the zero time if the task is never scheduled again.
+	Next(t time.Time) time.Time
+}
+
+// EverySchedule schedules a task at every multiple of a duration
+// since the unix epoch.
+type EverySchedule time.Duration
+
+func (s EverySchedule) Next(t time.Time) time.Time {
+	every := int64(s)
+	ns := t.UnixNano()
+	rem := ns % every
+	if rem < 0 {
+		rem += every
+	}
+	return time.Unix(0, ns-rem+every).UTC()
+}
+
+// cronField describes the range of the values of a field of a cron expression.
+type cronField struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	cronSeconds = cronField{name: "second", min: 0, max: 59}
+	cronMinutes = cronField{name: "minute", min: 0, max: 59}
+	cronHours   = cronField{name: "hour", min: 0, max: 23}
+	cronDays    = cronField{name: "day of month", min: 1, max: 31}
+	cronMonths  = cronField{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	cronWeekdays = cronField{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// cronSearchLimit is how many years ahead a cron schedule is
+// searched before it is assumed to never match again.
+const cronSearchLimit = 5
+
+// CronSchedule schedules a task with a cron expression.
+// The times are matched in UTC.
+type CronSchedule struct {
+	seconds, minutes, hours, days, months, weekdays uint64
+
+	// Whether the day of the month or the day of the week were
+	// restricted. When both are, a day matches if either matches.
+	anyDay, anyWeekday bool
+}
+
+// ParseCron parses a cron expression with five fields for the minute,
+// hour, day of month, month and day of week. An optional sixth field
+// at the start of the expression specifies the second. The descriptors
+// @yearly, @annually, @monthly, @weekly, @daily, @midnight and @hourly
+// are also accepted.
+func ParseCron(expr string) (*CronSchedule, error) {
+	spec := strings.TrimSpace(expr)
+	if strings.HasPrefix(spec, "@") {
+		s, ok := cronDescriptors[strings.ToLower(spec)]
+		if !ok {
+			return nil, errors.Newf(codes.Invalid, "unknown cron descriptor %q", spec)
+		}
+		spec = s
+	}
+
+	fields := strings.Fields(spec)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, errors.Newf(codes.Invalid, "cron expression %q must have 5 or 6 fields, found %d", expr, len(fields))
+	}
+
+	s := &CronSchedule{}
+	for i, f := range []struct {
+		field cronField
+		bits  *uint64
+	}{
+		{field: cronSeconds, bits: &s.seconds},
+		{field: cronMinutes, bits: &s.minutes},
+		{field: cronHours, bits: &s.hours},
+		{field: cronDays, bits: &s.days},
+		{field: cronMonths, bits: &s.months},
+		{field: cronWeekdays, bits: &s.weekdays},
+	} {
+		bits, err := parseCronField(fields[i], f.field)
+		if err != nil {
+			return nil, errors.Wrapf(err, codes.Invalid, "invalid cron expression %q", expr)
+		}
+		*f.bits = bits
+	}
+	// Sunday may be written as either 0 or 7.
+	if s.weekdays&(1<<7) != 0 {
+		s.weekdays |= 1
+	}
+	s.anyDay = fields[3] == "*" || fields[3] == "?"
+	s.anyWeekday = fields[5] == "*" || fields[5] == "?"
+	return s, nil
+}
+
+// parseCronField parses a comma separated list of values, ranges
+// and steps into a bit set of the values that match.
+func parseCronField(expr string, field cronField) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(expr, ",") {
+		rangeExpr, step := part, 1
+		if i := strings.IndexByte(part, '/'); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return 0, errors.Newf(codes.Invalid, "invalid step %q for %s", part[i+1:], field.name)
+			}
+			rangeExpr, step = part[:i], n
+		}
+
+		var lo, hi int
+		switch {
+		case rangeExpr == "*" || rangeExpr == "?":
+			lo, hi = field.min, field.max
+		case strings.Contains(rangeExpr, "-"):
+			i := strings.IndexByte(rangeExpr, '-')
+			var err error
+			if lo, err = parseCronValue(rangeExpr[:i], field); err != nil {
+				return 0, err
+			}
+			if hi, err = parseCronValue(rangeExpr[i+1:], field); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, errors.Newf(codes.Invalid, "invalid range %q for %s", rangeExpr, field.name)
+			}
+		default:
+			v, err := parseCronValue(rangeExpr, field)
+			if err != nil {
+				return 0, err
+			}
+			lo, hi = v, v
+			if step > 1 {
+				// A value with a step starts a range that ends at the maximum.
+				hi = field.max
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func parseCronValue(s string, field cronField) (int, error) {
+	if v, ok := field.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, errors.Newf(codes.Invalid, "invalid value %q for %s", s, field.name)
+	}
+	if v < field.min || v > field.max {
+		return 0, errors.Newf(codes.Invalid, "value %d for %s is out of range [%d, %d]", v, field.name, field.min, field.max)
+	}
+	return v, nil
+}
+
+func (s *CronSchedule) Next(t time.Time) time.Time {
+	t = t.UTC().Truncate(time.Second).Add(time.Second)
+	limit := t.Year() + cronSearchLimit
+	for t.Year() <= limit {
+		if s.months&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if !s.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if s.hours&(1<<uint(t.Hour())) == 0 {
+			t = t.Truncate(time.Hour).Add(time.Hour)
+			continue
+		}
+		if s.minutes&(1<<uint(t.Minute())) == 0 {
+			t = t.Truncate(time.Minute).Add(time.Minute)
+			continue
+		}
+		if s.seconds&(1<<uint(t.Second())) == 0 {
+			t = t.Add(time.Second)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (s *CronSchedule) matchDay(t time.Time) bool {
+	day := s.days&(1<<uint(t.Day())) != 0
+	weekday := s.weekdays&(1<<uint(t.Weekday())) != 0
+	switch {
+	case s.anyDay && s.anyWeekday:
+		return true
+	case s.anyDay:
+		return weekday
+	case s.anyWeekday:
+		return day
+	default:
+		return day || weekday
+	}
+}
@@ -0,0 +1,134 @@
+package scheduler_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/InfluxCommunity/flux/internal/scheduler"
+)
+
+func mustParseTime(s string) time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestEverySchedule(t *testing.T) {
+	s := scheduler.EverySchedule(time.Hour)
+	for _, tt := range []struct {
+		from string
+		want string
+	}{
+		{from: "2021-03-04T10:30:00Z", want: "2021-03-04T11:00:00Z"},
+		{from: "2021-03-04T10:00:00Z", want: "2021-03-04T11:00:00Z"},
+		{from: "2021-03-04T23:59:59Z", want: "2021-03-05T00:00:00Z"},
+	} {
+		if got, want := s.Next(mustParseTime(tt.from)), mustParseTime(tt.want); !got.Equal(want) {
+			t.Errorf("unexpected next time after %s -want/+got:\n\t- %s\n\t+ %s", tt.from, want, got)
+		}
+	}
+}
+
+func TestCronSchedule(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		cron string
+		from string
+		want []string
+	}{
+		{
+			name: "every minute",
+			cron: "* * * * *",
+			from: "2021-03-04T10:30:15Z",
+			want: []string{"2021-03-04T10:31:00Z", "2021-03-04T10:32:00Z"},
+		},
+		{
+			name: "step",
+			cron: "*/15 * * * *",
+			from: "2021-03-04T10:50:00Z",
+			want: []string{"2021-03-04T11:00:00Z", "2021-03-04T11:15:00Z"},
+		},
+		{
+			name: "list and range",
+			cron: "0 9-10,17 * * *",
+			from: "2021-03-04T10:00:00Z",
+			want: []string{"2021-03-04T17:00:00Z", "2021-03-05T09:00:00Z", "2021-03-05T10:00:00Z"},
+		},
+		{
+			name: "weekdays by name",
+			cron: "30 8 * * MON-FRI",
+			from: "2021-03-05T09:00:00Z", // Friday
+			want: []string{"2021-03-08T08:30:00Z", "2021-03-09T08:30:00Z"},
+		},
+		{
+			name: "day of month or day of week",
+			cron: "0 0 1 * SUN",
+			from: "2021-02-26T00:00:00Z",
+			want: []string{"2021-02-28T00:00:00Z", "2021-03-01T00:00:00Z", "2021-03-07T00:00:00Z"},
+		},
+		{
+			name: "seconds",
+			cron: "*/20 * * * * *",
+			from: "2021-03-04T10:30:15Z",
+			want: []string{"2021-03-04T10:30:20Z", "2021-03-04T10:30:40Z", "2021-03-04T10:31:00Z"},
+		},
+		{
+			name: "leap day",
+			cron: "0 12 29 2 *",
+			from: "2021-03-01T00:00:00Z",
+			want: []string{"2024-02-29T12:00:00Z"},
+		},
+		{
+			name: "descriptor",
+			cron: "@monthly",
+			from: "2021-12-15T00:00:00Z",
+			want: []string{"2022-01-01T00:00:00Z", "2022-02-01T00:00:00Z"},
+		},
+		{
+			name: "sunday as seven",
+			cron: "0 0 * * 7",
+			from: "2021-03-04T00:00:00Z",
+			want: []string{"2021-03-07T00:00:00Z"},
+		},
+		{
+			name: "never",
+			cron: "0 0 31 2 *",
+			from: "2021-03-04T00:00:00Z",
+			want: []string{"0001-01-01T00:00:00Z"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := scheduler.ParseCron(tt.cron)
+			if err != nil {
+				t.Fatal(err)
+			}
+			next := mustParseTime(tt.from)
+			for _, want := range tt.want {
+				next = s.Next(next)
+				if !next.Equal(mustParseTime(want)) {
+					t.Fatalf("unexpected next time -want/+got:\n\t- %s\n\t+ %s", want, next)
+				}
+			}
+		})
+	}
+}
+
+func TestParseCron_Errors(t *testing.T) {
+	for _, cron := range []string{
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * MON-",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"@sometimes",
+	} {
+		if _, err := scheduler.ParseCron(cron); err == nil {
+			t.Errorf("expected an error for %q", cron)
+		}
+	}
+}
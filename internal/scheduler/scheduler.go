@@ -0,0 +1,190 @@
+// Package scheduler runs Flux scripts that set the task option
+// on their schedule outside of InfluxDB.
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DefaultRetryInterval is the default time waited before
+// the first retry of a failed run.
+const DefaultRetryInterval = 10 * time.Second
+
+// RunRequest describes a run of a task.
+type RunRequest struct {
+	Task *Task
+	// ScheduledFor is the time the run was scheduled for.
+	// It is used as the now time of the script.
+	ScheduledFor time.Time
+	// LastSuccessTime is the time the last successful run of the
+	// task was scheduled for. It is nil if the task never succeeded.
+	LastSuccessTime *time.Time
+	// Log adds a message to the logs of the run.
+	Log func(msg string)
+}
+
+// RunFunc executes a run of a task.
+type RunFunc func(ctx context.Context, req RunRequest) error
+
+// Scheduler runs tasks on their schedule and records
+// the history of their runs in a Store.
+type Scheduler struct {
+	store  *Store
+	run    RunFunc
+	logger *zap.Logger
+
+	// MaxRetries is the number of times a failed run is retried.
+	MaxRetries int
+	// RetryInterval is the time waited before the first retry.
+	// It doubles with every following retry.
+	RetryInterval time.Duration
+
+	now func() time.Time
+}
+
+// New creates a Scheduler that executes runs with fn.
+func New(store *Store, fn RunFunc, logger *zap.Logger) *Scheduler {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Scheduler{
+		store:         store,
+		run:           fn,
+		logger:        logger,
+		RetryInterval: DefaultRetryInterval,
+		now:           time.Now,
+	}
+}
+
+// Run schedules the tasks until the context is canceled. Each task
+// runs at most once at a time and its runs are scheduled after the
+// time Run was called, so runs that were missed while the scheduler
+// was not running are not made up.
+func (s *Scheduler) Run(ctx context.Context, tasks []*Task) error {
+	states := make([]*TaskState, len(tasks))
+	for i, t := range tasks {
+		state, err := s.store.State(t.Name)
+		if err != nil {
+			return err
+		}
+		states[i] = state
+	}
+
+	var wg sync.WaitGroup
+	for i, t := range tasks {
+		wg.Add(1)
+		go func(t *Task, state *TaskState) {
+			defer wg.Done()
+			s.schedule(ctx, t, state)
+		}(t, states[i])
+	}
+	wg.Wait()
+	return nil
+}
+
+func (s *Scheduler) schedule(ctx context.Context, t *Task, state *TaskState) {
+	logger := s.logger.With(zap.String("task", t.Name))
+	from := s.now()
+	if state.LastScheduledFor.After(from) {
+		from = state.LastScheduledFor
+	}
+	for {
+		scheduledFor := t.Schedule().Next(from)
+		if scheduledFor.IsZero() {
+			logger.Info("Task is not scheduled again")
+			return
+		}
+		logger.Debug("Scheduled run", zap.Time("scheduled_for", scheduledFor))
+		if !s.wait(ctx, scheduledFor.Add(t.Offset).Sub(s.now())) {
+			return
+		}
+
+		next, err := s.RunTask(ctx, t, scheduledFor, state.LastSuccessTime)
+		if err != nil {
+			logger.Error("Failed to record run", zap.Error(err))
+		} else {
+			state = next
+		}
+		from = scheduledFor
+	}
+}
+
+// RunTask runs the task for the scheduled time, retrying it when it
+// fails, and records the run in the store. It returns the new state
+// of the task.
+func (s *Scheduler) RunTask(ctx context.Context, t *Task, scheduledFor time.Time, lastSuccess *time.Time) (*TaskState, error) {
+	logger := s.logger.With(zap.String("task", t.Name), zap.Time("scheduled_for", scheduledFor))
+	run := Run{
+		ScheduledFor: scheduledFor,
+		StartedAt:    s.now(),
+	}
+	var mu sync.Mutex
+	log := func(msg string) {
+		mu.Lock()
+		defer mu.Unlock()
+		run.Logs = append(run.Logs, RunLog{Time: s.now(), Message: msg})
+	}
+
+	retryInterval := s.RetryInterval
+	for {
+		run.Attempts++
+		log(fmt.Sprintf("Started attempt %d", run.Attempts))
+		err := s.run(ctx, RunRequest{
+			Task:            t,
+			ScheduledFor:    scheduledFor,
+			LastSuccessTime: lastSuccess,
+			Log:             log,
+		})
+		if err == nil {
+			run.Status, run.Error = RunSuccess, ""
+			log("Completed successfully")
+			logger.Info("Run succeeded", zap.Int("attempts", run.Attempts))
+			break
+		}
+
+		run.Error = err.Error()
+		if ctx.Err() != nil {
+			run.Status = RunCanceled
+			log("Canceled: " + err.Error())
+			logger.Info("Run canceled", zap.Error(err))
+			break
+		}
+		run.Status = RunFailed
+		if run.Attempts > s.MaxRetries {
+			log("Failed: " + err.Error())
+			logger.Error("Run failed", zap.Int("attempts", run.Attempts), zap.Error(err))
+			break
+		}
+		log(fmt.Sprintf("Failed: %s; retrying in %s", err, retryInterval))
+		logger.Warn("Run failed, retrying", zap.Duration("retry_in", retryInterval), zap.Error(err))
+		if !s.wait(ctx, retryInterval) {
+			run.Status = RunCanceled
+			log("Canceled while waiting to retry")
+			break
+		}
+		retryInterval *= 2
+	}
+	run.FinishedAt = s.now()
+	return s.store.AddRun(t.Name, run)
+}
+
+// wait waits for d to pass and reports whether
+// it passed before the context was canceled.
+func (s *Scheduler) wait(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,234 @@
+package scheduler_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/InfluxCommunity/flux/ast"
+	"github.com/InfluxCommunity/flux/internal/scheduler"
+	"github.com/google/go-cmp/cmp"
+)
+
+// taskFile builds the file of a script with the given task option and imports.
+func taskFile(props map[string]ast.Expression, imports ...*ast.ImportDeclaration) *ast.File {
+	obj := &ast.ObjectExpression{}
+	for k, v := range props {
+		obj.Properties = append(obj.Properties, &ast.Property{
+			Key:   &ast.Identifier{Name: k},
+			Value: v,
+		})
+	}
+	return &ast.File{
+		Imports: imports,
+		Body: []ast.Statement{
+			&ast.OptionStatement{
+				Assignment: &ast.VariableAssignment{
+					ID:   &ast.Identifier{Name: scheduler.TaskOption},
+					Init: obj,
+				},
+			},
+		},
+	}
+}
+
+func duration(mag int64, unit string) *ast.DurationLiteral {
+	return &ast.DurationLiteral{Values: []ast.Duration{{Magnitude: mag, Unit: unit}}}
+}
+
+func TestNewTask(t *testing.T) {
+	task, err := scheduler.NewTask("a.flux", "", taskFile(map[string]ast.Expression{
+		"name":   &ast.StringLiteral{Value: "downsample"},
+		"every":  duration(1, "h"),
+		"offset": duration(5, "m"),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.Name != "downsample" || task.Every != time.Hour || task.Offset != 5*time.Minute {
+		t.Errorf("unexpected task: %+v", task)
+	}
+	if got, want := task.Schedule().Next(mustParseTime("2021-03-04T10:30:00Z")), mustParseTime("2021-03-04T11:00:00Z"); !got.Equal(want) {
+		t.Errorf("unexpected next time: %s", got)
+	}
+
+	for name, props := range map[string]map[string]ast.Expression{
+		"missing name":   {"every": duration(1, "h")},
+		"missing every":  {"name": &ast.StringLiteral{Value: "a"}},
+		"every and cron": {"name": &ast.StringLiteral{Value: "a"}, "every": duration(1, "h"), "cron": &ast.StringLiteral{Value: "@daily"}},
+		"invalid cron":   {"name": &ast.StringLiteral{Value: "a"}, "cron": &ast.StringLiteral{Value: "* *"}},
+		"invalid every":  {"name": &ast.StringLiteral{Value: "a"}, "every": &ast.StringLiteral{Value: "1h"}},
+	} {
+		if _, err := scheduler.NewTask("a.flux", "", taskFile(props)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestTask_File(t *testing.T) {
+	task, err := scheduler.NewTask("a.flux", "", taskFile(
+		map[string]ast.Expression{
+			"name":  &ast.StringLiteral{Value: "a"},
+			"every": duration(1, "h"),
+		},
+		&ast.ImportDeclaration{
+			As:   &ast.Identifier{Name: "t"},
+			Path: &ast.StringLiteral{Value: "influxdata/influxdb/tasks"},
+		},
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := task.File(nil); len(got.Body) != 1 {
+		t.Fatalf("expected the script to be unchanged without a last success time, got %d statements", len(got.Body))
+	}
+
+	lastSuccess := mustParseTime("2021-03-04T10:00:00Z")
+	file := task.File(&lastSuccess)
+	if len(file.Body) != 2 {
+		t.Fatalf("expected the lastSuccessTime option to be added, got %d statements", len(file.Body))
+	}
+	assign, ok := file.Body[0].(*ast.OptionStatement).Assignment.(*ast.MemberAssignment)
+	if !ok {
+		t.Fatalf("unexpected option statement: %v", file.Body[0])
+	}
+	if obj := assign.Member.Object.(*ast.Identifier).Name; obj != "t" {
+		t.Errorf("expected the option to use the import alias, got %s", obj)
+	}
+	if got := assign.Init.(*ast.DateTimeLiteral).Value; !got.Equal(lastSuccess) {
+		t.Errorf("unexpected last success time: %s", got)
+	}
+	if got := task.File(nil); len(got.Body) != 1 {
+		t.Fatal("expected the parsed script to not be modified")
+	}
+}
+
+func TestScheduler_RunTask(t *testing.T) {
+	store, err := scheduler.OpenStore(t.TempDir(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	task, err := scheduler.NewTask("a.flux", "", taskFile(map[string]ast.Expression{
+		"name":  &ast.StringLiteral{Value: "a"},
+		"every": duration(1, "h"),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		calls       int
+		failures    int
+		lastSuccess []*time.Time
+	)
+	s := scheduler.New(store, func(ctx context.Context, req scheduler.RunRequest) error {
+		calls++
+		lastSuccess = append(lastSuccess, req.LastSuccessTime)
+		req.Log("running")
+		if failures > 0 {
+			failures--
+			return errors.New("expected failure")
+		}
+		return nil
+	}, nil)
+	s.MaxRetries = 1
+	s.RetryInterval = time.Millisecond
+
+	first := mustParseTime("2021-03-04T10:00:00Z")
+	state, err := s.RunTask(context.Background(), task, first, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.LastSuccessTime == nil || !state.LastSuccessTime.Equal(first) {
+		t.Fatalf("unexpected last success time: %v", state.LastSuccessTime)
+	}
+
+	// The run succeeds after being retried once.
+	failures = 1
+	second := first.Add(time.Hour)
+	state, err = s.RunTask(context.Background(), task, second, state.LastSuccessTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := state.Runs[len(state.Runs)-1]
+	if run.Status != scheduler.RunSuccess || run.Attempts != 2 {
+		t.Errorf("unexpected run: %+v", run)
+	}
+
+	// The run fails once the retries are exhausted
+	// and the last success time is kept.
+	failures = 2
+	third := second.Add(time.Hour)
+	state, err = s.RunTask(context.Background(), task, third, state.LastSuccessTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	run = state.Runs[len(state.Runs)-1]
+	if run.Status != scheduler.RunFailed || run.Attempts != 2 || run.Error != "expected failure" {
+		t.Errorf("unexpected run: %+v", run)
+	}
+	if !state.LastSuccessTime.Equal(second) || !state.LastScheduledFor.Equal(third) {
+		t.Errorf("unexpected state: %+v", state)
+	}
+	if calls != 5 {
+		t.Errorf("expected 5 attempts, got %d", calls)
+	}
+	if want := []*time.Time{nil, &first, &first, &second, &second}; !cmp.Equal(want, lastSuccess) {
+		t.Errorf("unexpected last success times -want/+got:\n%s", cmp.Diff(want, lastSuccess))
+	}
+
+	// Only the two most recent runs are kept and the state
+	// can be read back from the store.
+	state, err = store.State("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.Runs) != 2 || state.Runs[0].ID != 2 || state.Runs[1].ID != 3 {
+		t.Errorf("unexpected runs: %+v", state.Runs)
+	}
+	if len(state.Runs[1].Logs) == 0 || state.Runs[1].Logs[1].Message != "running" {
+		t.Errorf("unexpected logs: %+v", state.Runs[1].Logs)
+	}
+	if names, err := store.Tasks(); err != nil || !cmp.Equal(names, []string{"a"}) {
+		t.Errorf("unexpected tasks: %v %v", names, err)
+	}
+}
+
+func TestScheduler_Run(t *testing.T) {
+	store, err := scheduler.OpenStore(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	task, err := scheduler.NewTask("a.flux", "", taskFile(map[string]ast.Expression{
+		"name":  &ast.StringLiteral{Value: "a"},
+		"every": duration(10, "ms"),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var scheduled []time.Time
+	s := scheduler.New(store, func(ctx context.Context, req scheduler.RunRequest) error {
+		scheduled = append(scheduled, req.ScheduledFor)
+		if len(scheduled) == 3 {
+			cancel()
+		}
+		return nil
+	}, nil)
+	if err := s.Run(ctx, []*scheduler.Task{task}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(scheduled) != 3 {
+		t.Fatalf("expected 3 runs, got %d", len(scheduled))
+	}
+	for i := 1; i < len(scheduled); i++ {
+		if d := scheduled[i].Sub(scheduled[i-1]); d != 10*time.Millisecond {
+			t.Errorf("expected runs to be scheduled 10ms apart, got %s", d)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/internal/errors"
+)
+
+// RunStatus is the outcome of a run.
+type RunStatus string
+
+const (
+	RunSuccess  RunStatus = "success"
+	RunFailed   RunStatus = "failed"
+	RunCanceled RunStatus = "canceled"
+)
+
+// DefaultMaxRuns is the default number of runs kept in the history of a task.
+const DefaultMaxRuns = 100
+
+// RunLog is a message logged while a task was running.
+type RunLog struct {
+	Time    time.Time `json:"time"`
+	Message string    `json:"message"`
+}
+
+// Run records one run of a task.
+type Run struct {
+	ID           int64     `json:"id"`
+	ScheduledFor time.Time `json:"scheduledFor"`
+	StartedAt    time.Time `json:"startedAt"`
+	FinishedAt   time.Time `json:"finishedAt"`
+	Status       RunStatus `json:"status"`
+	Attempts     int       `json:"attempts"`
+	Error        string    `json:"error,omitempty"`
+	Logs         []RunLog  `json:"logs,omitempty"`
+}
+
+// TaskState is the state of a task that is kept between runs.
+type TaskState struct {
+	Name string `json:"name"`
+	// LastSuccessTime is the time the last successful run was scheduled for.
+	LastSuccessTime *time.Time `json:"lastSuccessTime,omitempty"`
+	// LastScheduledFor is the time the last run was scheduled for.
+	LastScheduledFor time.Time `json:"lastScheduledFor"`
+	// Runs is the history of the task with the most recent run last.
+	Runs []Run `json:"runs"`
+}
+
+// Store persists the state of tasks in a directory with
+// a JSON file for each task.
+type Store struct {
+	dir     string
+	maxRuns int
+
+	mu sync.Mutex
+}
+
+// OpenStore opens the store in dir and creates the directory if it
+// does not exist. At most maxRuns runs are kept for each task.
+func OpenStore(dir string, maxRuns int) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	if maxRuns <= 0 {
+		maxRuns = DefaultMaxRuns
+	}
+	return &Store{dir: dir, maxRuns: maxRuns}, nil
+}
+
+func (s *Store) path(name string) string {
+	return filepath.Join(s.dir, url.PathEscape(name)+".json")
+}
+
+// State returns the state of the named task.
+// A task that has never run has an empty state.
+func (s *Store) State(name string) (*TaskState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.read(name)
+}
+
+func (s *Store) read(name string) (*TaskState, error) {
+	data, err := os.ReadFile(s.path(name))
+	if os.IsNotExist(err) {
+		return &TaskState{Name: name}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var state TaskState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, errors.Wrapf(err, codes.Internal, "corrupt state for task %q", name)
+	}
+	return &state, nil
+}
+
+// AddRun records a finished run of the named task and returns its state.
+// The run is assigned the next ID of the task.
+func (s *Store) AddRun(name string, run Run) (*TaskState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, err := s.read(name)
+	if err != nil {
+		return nil, err
+	}
+	run.ID = 1
+	if n := len(state.Runs); n > 0 {
+		run.ID = state.Runs[n-1].ID + 1
+	}
+	state.Runs = append(state.Runs, run)
+	if n := len(state.Runs); n > s.maxRuns {
+		state.Runs = append(state.Runs[:0], state.Runs[n-s.maxRuns:]...)
+	}
+	if run.ScheduledFor.After(state.LastScheduledFor) {
+		state.LastScheduledFor = run.ScheduledFor
+	}
+	if run.Status == RunSuccess {
+		if state.LastSuccessTime == nil || run.ScheduledFor.After(*state.LastSuccessTime) {
+			t := run.ScheduledFor
+			state.LastSuccessTime = &t
+		}
+	}
+	if err := s.write(state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// write replaces the state file so a crash
+// never leaves a partially written file.
+func (s *Store) write(state *TaskState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(s.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), s.path(state.Name))
+}
+
+// Tasks returns the names of the tasks that have a state in the store.
+func (s *Store) Tasks() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || strings.HasPrefix(name, ".") || filepath.Ext(name) != ".json" {
+			continue
+		}
+		if name, err = url.PathUnescape(strings.TrimSuffix(name, ".json")); err == nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
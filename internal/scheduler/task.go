@@ -0,0 +1,182 @@
+package scheduler
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/InfluxCommunity/flux/ast"
+	"github.com/InfluxCommunity/flux/ast/edit"
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/parser"
+)
+
+// TaskOption is the name of the option that configures a task.
+const TaskOption = "task"
+
+// tasksPackage is the path of the package with the lastSuccessTime option.
+const tasksPackage = "influxdata/influxdb/tasks"
+
+// Task is a Flux script that is run on a schedule.
+type Task struct {
+	// Name is the name of the task from the task option.
+	Name string
+	// Path is the file the script was read from.
+	Path string
+	// Script is the source of the script.
+	Script string
+
+	// Every is the interval the task runs at, if it was set.
+	Every time.Duration
+	// Cron is the cron expression the task runs at, if it was set.
+	Cron string
+	// Offset delays each run of the task after the time it is scheduled for.
+	Offset time.Duration
+
+	file     *ast.File
+	schedule Schedule
+}
+
+// Schedule returns the schedule of the task.
+func (t *Task) Schedule() Schedule {
+	return t.schedule
+}
+
+// File returns a copy of the parsed script. When the script imports
+// the tasks package and lastSuccess is not nil, the copy sets the
+// tasks.lastSuccessTime option to it.
+func (t *Task) File(lastSuccess *time.Time) *ast.File {
+	file := t.file.Copy().(*ast.File)
+	if lastSuccess == nil {
+		return file
+	}
+	for _, imp := range file.Imports {
+		if imp.Path == nil || imp.Path.Value != tasksPackage {
+			continue
+		}
+		name := "tasks"
+		if imp.As != nil {
+			name = imp.As.Name
+		}
+		opt := &ast.OptionStatement{
+			Assignment: &ast.MemberAssignment{
+				Member: &ast.MemberExpression{
+					Object:   &ast.Identifier{Name: name},
+					Property: &ast.Identifier{Name: "lastSuccessTime"},
+				},
+				Init: &ast.DateTimeLiteral{Value: lastSuccess.UTC()},
+			},
+		}
+		file.Body = append([]ast.Statement{opt}, file.Body...)
+		break
+	}
+	return file
+}
+
+// NewTask reads the task option of a parsed script.
+// The task option must set the name and exactly one of every or cron.
+func NewTask(path, script string, file *ast.File) (*Task, error) {
+	expr, err := edit.GetOption(file, TaskOption)
+	if err != nil {
+		return nil, errors.Newf(codes.Invalid, "%s: script does not set option task", path)
+	}
+	obj, ok := expr.(*ast.ObjectExpression)
+	if !ok {
+		return nil, errors.Newf(codes.Invalid, "%s: option task must be a record, got %s", path, expr.Type())
+	}
+
+	t := &Task{Path: path, Script: script, file: file}
+	for _, p := range obj.Properties {
+		var err error
+		switch key := p.Key.Key(); key {
+		case "name":
+			t.Name, err = stringProperty(key, p.Value)
+		case "every":
+			t.Every, err = durationProperty(key, p.Value)
+		case "cron":
+			t.Cron, err = stringProperty(key, p.Value)
+		case "offset":
+			t.Offset, err = durationProperty(key, p.Value)
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, codes.Invalid, "%s", path)
+		}
+	}
+
+	switch {
+	case t.Name == "":
+		return nil, errors.Newf(codes.Invalid, "%s: option task must set a name", path)
+	case t.Every != 0 && t.Cron != "":
+		return nil, errors.Newf(codes.Invalid, "%s: option task must not set both every and cron", path)
+	case t.Every < 0:
+		return nil, errors.Newf(codes.Invalid, "%s: task every must be positive", path)
+	case t.Every > 0:
+		t.schedule = EverySchedule(t.Every)
+	case t.Cron != "":
+		s, err := ParseCron(t.Cron)
+		if err != nil {
+			return nil, errors.Wrapf(err, codes.Invalid, "%s", path)
+		}
+		t.schedule = s
+	default:
+		return nil, errors.Newf(codes.Invalid, "%s: option task must set every or cron", path)
+	}
+	if t.Offset < 0 {
+		return nil, errors.Newf(codes.Invalid, "%s: task offset must not be negative", path)
+	}
+	return t, nil
+}
+
+func stringProperty(key string, expr ast.Expression) (string, error) {
+	lit, ok := expr.(*ast.StringLiteral)
+	if !ok {
+		return "", errors.Newf(codes.Invalid, "task %s must be a string literal, got %s", key, expr.Type())
+	}
+	return lit.Value, nil
+}
+
+func durationProperty(key string, expr ast.Expression) (time.Duration, error) {
+	lit, ok := expr.(*ast.DurationLiteral)
+	if !ok {
+		return 0, errors.Newf(codes.Invalid, "task %s must be a duration literal, got %s", key, expr.Type())
+	}
+	return ast.DurationFrom(lit, time.Time{})
+}
+
+// LoadTasks parses the scripts ending in .flux in dir and returns
+// the tasks they define. The names of the tasks must be unique.
+func LoadTasks(dir string) ([]*Task, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.flux"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	tasks := make([]*Task, 0, len(paths))
+	names := make(map[string]string, len(paths))
+	for _, path := range paths {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		pkg := parser.ParseSourceWithFileName(string(src), filepath.Base(path))
+		if ast.Check(pkg) > 0 {
+			return nil, errors.Wrapf(ast.GetError(pkg), codes.Invalid, "%s", path)
+		}
+		t, err := NewTask(path, string(src), pkg.Files[0])
+		if err != nil {
+			return nil, err
+		}
+		if other, ok := names[t.Name]; ok {
+			return nil, errors.Newf(codes.Invalid, "task %q is defined by both %s and %s", t.Name, other, path)
+		}
+		names[t.Name] = path
+		tasks = append(tasks, t)
+	}
+	if len(tasks) == 0 {
+		return nil, errors.Newf(codes.NotFound, "no tasks found in %s", dir)
+	}
+	return tasks, nil
+}
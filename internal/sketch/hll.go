@@ -0,0 +1,309 @@
+// Package sketch implements probabilistic summaries of data that
+// can be serialized and merged.
+package sketch
+
+import (
+	"encoding/binary"
+	"math"
+	"math/bits"
+	"sort"
+
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/cespare/xxhash/v2"
+)
+
+const (
+	// MinPrecision and MaxPrecision limit the number of
+	// index bits of a HyperLogLog sketch.
+	MinPrecision = 4
+	MaxPrecision = 18
+
+	// DefaultPrecision uses 2^14 registers, about 16KiB,
+	// for a standard error of 0.81%.
+	DefaultPrecision = 14
+
+	// sparsePrecision is the number of index bits used by
+	// the sparse representation.
+	sparsePrecision = 25
+)
+
+// HLL is a HyperLogLog++ sketch that estimates the number of
+// distinct values it has seen.
+//
+// Small sketches keep a sparse list of the registers that were set
+// with a higher precision and switch to dense registers once the list
+// would be larger than the registers. Estimates use linear counting
+// in the sparse representation and the estimator of Ertl, "New
+// cardinality estimation algorithms for HyperLogLog sketches", in
+// the dense representation, which corrects the bias of the original
+// estimator without the empirical tables of HyperLogLog++.
+type HLL struct {
+	p uint8
+	// sparse holds the rank of each set register
+	// at sparsePrecision by its index.
+	sparse map[uint32]uint8
+	// registers holds the rank of each register once dense.
+	registers []uint8
+}
+
+// NewHLL creates an empty sketch with 2^p registers.
+func NewHLL(p int) (*HLL, error) {
+	if p < MinPrecision || p > MaxPrecision {
+		return nil, errors.Newf(codes.Invalid, "precision must be between %d and %d, got %d", MinPrecision, MaxPrecision, p)
+	}
+	return &HLL{
+		p:      uint8(p),
+		sparse: make(map[uint32]uint8),
+	}, nil
+}
+
+// Precision returns the number of index bits of the sketch.
+func (h *HLL) Precision() int {
+	return int(h.p)
+}
+
+// AddString adds a string value to the sketch.
+func (h *HLL) AddString(v string) {
+	h.AddHash(xxhash.Sum64String(v))
+}
+
+// AddUint64 adds the bits of a fixed size value to the sketch.
+func (h *HLL) AddUint64(v uint64) {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+	h.AddHash(xxhash.Sum64(buf[:]))
+}
+
+// AddHash adds a hashed value to the sketch.
+func (h *HLL) AddHash(x uint64) {
+	if h.registers != nil {
+		h.set(uint32(x>>(64-h.p)), rank(x, h.p))
+		return
+	}
+	idx, r := uint32(x>>(64-sparsePrecision)), rank(x, sparsePrecision)
+	if r > h.sparse[idx] {
+		h.sparse[idx] = r
+	}
+	h.checkSparse()
+}
+
+// rank returns the position of the first set bit after the
+// p index bits of the hash.
+func rank(x uint64, p uint8) uint8 {
+	q := 64 - int(p)
+	lz := bits.LeadingZeros64(x << p)
+	if lz > q {
+		lz = q
+	}
+	return uint8(lz + 1)
+}
+
+func (h *HLL) set(idx uint32, r uint8) {
+	if r > h.registers[idx] {
+		h.registers[idx] = r
+	}
+}
+
+// fold converts the index and rank of a register with
+// precision from to the lower precision to.
+func fold(idx uint32, r uint8, from, to uint8) (uint32, uint8) {
+	shift := from - to
+	if shift == 0 {
+		return idx, r
+	}
+	dropped := idx & (1<<shift - 1)
+	if dropped == 0 {
+		return idx >> shift, r + shift
+	}
+	return idx >> shift, uint8(bits.LeadingZeros32(dropped<<(32-shift))) + 1
+}
+
+// checkSparse switches to dense registers once the sparse
+// registers would take more space.
+func (h *HLL) checkSparse() {
+	if h.registers == nil && len(h.sparse)*4 > 1<<h.p {
+		h.toDense()
+	}
+}
+
+func (h *HLL) toDense() {
+	h.registers = make([]uint8, 1<<h.p)
+	for idx, r := range h.sparse {
+		h.set(fold(idx, r, sparsePrecision, h.p))
+	}
+	h.sparse = nil
+}
+
+// Merge adds the values seen by another sketch to this sketch.
+// The precision of the sketch is lowered to the precision of
+// the other sketch if it is lower.
+func (h *HLL) Merge(o *HLL) {
+	if o.p < h.p {
+		h.reduce(o.p)
+	}
+	if o.registers == nil && h.registers == nil {
+		for idx, r := range o.sparse {
+			if r > h.sparse[idx] {
+				h.sparse[idx] = r
+			}
+		}
+		h.checkSparse()
+		return
+	}
+	if h.registers == nil {
+		h.toDense()
+	}
+	if o.registers == nil {
+		for idx, r := range o.sparse {
+			h.set(fold(idx, r, sparsePrecision, h.p))
+		}
+		return
+	}
+	for idx, r := range o.registers {
+		if r > 0 {
+			h.set(fold(uint32(idx), r, o.p, h.p))
+		}
+	}
+}
+
+// reduce lowers the precision of the sketch.
+func (h *HLL) reduce(p uint8) {
+	if h.registers != nil {
+		registers := h.registers
+		h.registers = make([]uint8, 1<<p)
+		for idx, r := range registers {
+			if r > 0 {
+				h.set(fold(uint32(idx), r, h.p, p))
+			}
+		}
+	}
+	h.p = p
+	h.checkSparse()
+}
+
+// Estimate returns the estimated number of distinct values.
+func (h *HLL) Estimate() uint64 {
+	if h.registers == nil {
+		m := float64(uint64(1) << sparsePrecision)
+		return uint64(math.Round(m * math.Log(m/(m-float64(len(h.sparse))))))
+	}
+
+	q := 64 - int(h.p)
+	m := float64(len(h.registers))
+	counts := make([]float64, q+2)
+	for _, r := range h.registers {
+		counts[r]++
+	}
+	z := m * tau(1-counts[q+1]/m)
+	for k := q; k >= 1; k-- {
+		z = 0.5 * (z + counts[k])
+	}
+	z += m * sigma(counts[0]/m)
+	return uint64(math.Round(m * m / (2 * math.Ln2 * z)))
+}
+
+func sigma(x float64) float64 {
+	if x == 1 {
+		return math.Inf(1)
+	}
+	y, z := 1.0, x
+	for {
+		x *= x
+		prev := z
+		z += x * y
+		y += y
+		if z == prev {
+			return z
+		}
+	}
+}
+
+func tau(x float64) float64 {
+	if x == 0 || x == 1 {
+		return 0
+	}
+	y, z := 1.0, 1-x
+	for {
+		x = math.Sqrt(x)
+		prev := z
+		y *= 0.5
+		z -= (1 - x) * (1 - x) * y
+		if z == prev {
+			return z / 3
+		}
+	}
+}
+
+// MarshalBinary encodes the sketch.
+func (h *HLL) MarshalBinary() ([]byte, error) {
+	buf := []byte{kindHLL, version, h.p}
+	if h.registers != nil {
+		buf = append(buf, 0)
+		return append(buf, h.registers...), nil
+	}
+
+	indexes := make([]uint32, 0, len(h.sparse))
+	for idx := range h.sparse {
+		indexes = append(indexes, idx)
+	}
+	sort.Slice(indexes, func(i, j int) bool {
+		return indexes[i] < indexes[j]
+	})
+	buf = append(buf, 1)
+	buf = binary.AppendUvarint(buf, uint64(len(indexes)))
+	var last uint32
+	for _, idx := range indexes {
+		buf = binary.AppendUvarint(buf, uint64(idx-last))
+		buf = append(buf, h.sparse[idx])
+		last = idx
+	}
+	return buf, nil
+}
+
+func unmarshalHLL(data []byte) (*HLL, error) {
+	if len(data) < 2 {
+		return nil, errInvalid("HyperLogLog")
+	}
+	p, sparse := data[0], data[1]
+	h, err := NewHLL(int(p))
+	if err != nil {
+		return nil, err
+	}
+	data = data[2:]
+
+	if sparse == 0 {
+		if len(data) != 1<<p {
+			return nil, errInvalid("HyperLogLog")
+		}
+		for _, r := range data {
+			if r > 65-p {
+				return nil, errInvalid("HyperLogLog")
+			}
+		}
+		h.sparse = nil
+		h.registers = append([]uint8(nil), data...)
+		return h, nil
+	}
+
+	n, k := binary.Uvarint(data)
+	if k <= 0 {
+		return nil, errInvalid("HyperLogLog")
+	}
+	data = data[k:]
+	var last uint64
+	for i := uint64(0); i < n; i++ {
+		d, k := binary.Uvarint(data)
+		if k <= 0 || k >= len(data) || d >= 1<<sparsePrecision-last ||
+			data[k] == 0 || data[k] > 65-sparsePrecision {
+			return nil, errInvalid("HyperLogLog")
+		}
+		last += d
+		h.sparse[uint32(last)] = data[k]
+		data = data[k+1:]
+	}
+	if len(data) != 0 {
+		return nil, errInvalid("HyperLogLog")
+	}
+	return h, nil
+}
@@ -0,0 +1,149 @@
+package sketch
+
+import (
+	"encoding"
+	"encoding/base64"
+	"encoding/binary"
+	"math"
+
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/influxdata/tdigest"
+)
+
+// Sketches are encoded as a kind and a version
+// followed by the state of the sketch.
+const (
+	kindHLL     byte = 1
+	kindTDigest byte = 2
+
+	version byte = 1
+)
+
+// Sketch is a summary of data that can be merged
+// with other sketches of the same kind.
+type Sketch interface {
+	encoding.BinaryMarshaler
+	// Kind returns the name of the kind of sketch.
+	Kind() string
+}
+
+// Kind returns the name of the kind of sketch.
+func (h *HLL) Kind() string {
+	return "HyperLogLog"
+}
+
+// TDigest is a t-digest sketch of a distribution, the same sketch
+// used to estimate quantiles by quantile().
+type TDigest struct {
+	*tdigest.TDigest
+}
+
+// NewTDigest creates an empty t-digest with the compression.
+func NewTDigest(compression float64) *TDigest {
+	return &TDigest{TDigest: tdigest.NewWithCompression(compression)}
+}
+
+// Kind returns the name of the kind of sketch.
+func (t *TDigest) Kind() string {
+	return "t-digest"
+}
+
+// Merge adds the distribution of another t-digest to this t-digest.
+func (t *TDigest) Merge(o *TDigest) {
+	t.TDigest.Merge(o.TDigest)
+}
+
+// MarshalBinary encodes the compression and centroids of the t-digest.
+func (t *TDigest) MarshalBinary() ([]byte, error) {
+	centroids := t.Centroids(nil)
+	buf := make([]byte, 0, 2+8+binary.MaxVarintLen64+16*len(centroids))
+	buf = append(buf, kindTDigest, version)
+	buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(t.Compression))
+	buf = binary.AppendUvarint(buf, uint64(len(centroids)))
+	for _, c := range centroids {
+		buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(c.Mean))
+		buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(c.Weight))
+	}
+	return buf, nil
+}
+
+func unmarshalTDigest(data []byte) (*TDigest, error) {
+	if len(data) < 8 {
+		return nil, errInvalid("t-digest")
+	}
+	compression := math.Float64frombits(binary.LittleEndian.Uint64(data))
+	if !(compression > 0) || math.IsInf(compression, 1) {
+		return nil, errInvalid("t-digest")
+	}
+	data = data[8:]
+	// The count is compared to the length of the data before it is
+	// multiplied so that a corrupt count cannot overflow the size.
+	n, k := binary.Uvarint(data)
+	if k <= 0 || n > uint64(len(data)-k)/16 || uint64(len(data)-k) != 16*n {
+		return nil, errInvalid("t-digest")
+	}
+	data = data[k:]
+
+	t := NewTDigest(compression)
+	centroids := make(tdigest.CentroidList, n)
+	for i := range centroids {
+		centroids[i] = tdigest.Centroid{
+			Mean:   math.Float64frombits(binary.LittleEndian.Uint64(data[16*i:])),
+			Weight: math.Float64frombits(binary.LittleEndian.Uint64(data[16*i+8:])),
+		}
+	}
+	t.AddCentroidList(centroids)
+	return t, nil
+}
+
+// Encode encodes a sketch as a base64 string so it can be
+// stored in a table column.
+func Encode(s Sketch) (string, error) {
+	data, err := s.MarshalBinary()
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// Decode decodes a sketch encoded by Encode.
+func Decode(s string) (Sketch, error) {
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil || len(data) < 2 {
+		return nil, errors.New(codes.Invalid, "invalid sketch: not an encoded sketch")
+	}
+	if data[1] != version {
+		return nil, errors.Newf(codes.Invalid, "invalid sketch: unsupported version %d", data[1])
+	}
+	switch data[0] {
+	case kindHLL:
+		return unmarshalHLL(data[2:])
+	case kindTDigest:
+		return unmarshalTDigest(data[2:])
+	default:
+		return nil, errors.Newf(codes.Invalid, "invalid sketch: unknown kind %d", data[0])
+	}
+}
+
+// Merge merges the sketch from into the sketch into.
+// Both sketches must be of the same kind.
+func Merge(into, from Sketch) error {
+	switch into := into.(type) {
+	case *HLL:
+		if from, ok := from.(*HLL); ok {
+			into.Merge(from)
+			return nil
+		}
+	case *TDigest:
+		if from, ok := from.(*TDigest); ok {
+			into.Merge(from)
+			return nil
+		}
+	}
+	return errors.Newf(codes.Invalid, "cannot merge %s sketch with %s sketch", into.Kind(), from.Kind())
+}
+
+func errInvalid(kind string) error {
+	return errors.Newf(codes.Invalid, "invalid sketch: malformed %s sketch", kind)
+}
@@ -0,0 +1,210 @@
+package sketch_test
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/internal/sketch"
+)
+
+func newHLL(t *testing.T, p int, start, n int) *sketch.HLL {
+	t.Helper()
+	h, err := sketch.NewHLL(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := start; i < start+n; i++ {
+		h.AddString(fmt.Sprintf("value-%d", i))
+	}
+	return h
+}
+
+func checkEstimate(t *testing.T, h *sketch.HLL, want int, tolerance float64) {
+	t.Helper()
+	got := float64(h.Estimate())
+	if err := math.Abs(got-float64(want)) / float64(want); err > tolerance {
+		t.Errorf("unexpected estimate: got %v, want %d (error %.4f > %.4f)", got, want, err, tolerance)
+	}
+}
+
+func TestHLL_Estimate(t *testing.T) {
+	for _, tc := range []struct {
+		p         int
+		n         int
+		tolerance float64
+	}{
+		{p: 14, n: 0},
+		{p: 14, n: 1},
+		{p: 14, n: 100},
+		{p: 14, n: 1000, tolerance: 0.001},
+		{p: 14, n: 10000, tolerance: 0.03},
+		{p: 14, n: 200000, tolerance: 0.03},
+		{p: 10, n: 200000, tolerance: 0.1},
+		{p: 4, n: 50, tolerance: 0.5},
+		{p: 18, n: 1000000, tolerance: 0.01},
+	} {
+		t.Run(fmt.Sprintf("p=%d/n=%d", tc.p, tc.n), func(t *testing.T) {
+			h := newHLL(t, tc.p, 0, tc.n)
+			// Adding values again does not change the estimate.
+			for i := 0; i < tc.n && i < 1000; i++ {
+				h.AddString(fmt.Sprintf("value-%d", i))
+			}
+			if tc.n == 0 {
+				if got := h.Estimate(); got != 0 {
+					t.Fatalf("unexpected estimate: got %d, want 0", got)
+				}
+				return
+			}
+			checkEstimate(t, h, tc.n, tc.tolerance)
+		})
+	}
+}
+
+func TestHLL_Merge(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		p1, p2 int
+		n1, n2 int
+	}{
+		{name: "sparse", p1: 14, p2: 14, n1: 100, n2: 100},
+		{name: "dense", p1: 14, p2: 14, n1: 50000, n2: 50000},
+		{name: "sparse into dense", p1: 14, p2: 14, n1: 50000, n2: 100},
+		{name: "dense into sparse", p1: 14, p2: 14, n1: 100, n2: 50000},
+		{name: "lower precision", p1: 14, p2: 12, n1: 50000, n2: 50000},
+		{name: "higher precision", p1: 12, p2: 14, n1: 50000, n2: 50000},
+		{name: "lower precision sparse", p1: 14, p2: 12, n1: 100, n2: 100},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// The second sketch starts n2/2 values before
+			// the end of the first so the sketches overlap.
+			start := tc.n1 - tc.n2/2
+			h1 := newHLL(t, tc.p1, 0, tc.n1)
+			h2 := newHLL(t, tc.p2, start, tc.n2)
+			h1.Merge(h2)
+			if got, want := h1.Precision(), tc.p2; tc.p2 < tc.p1 && got != want {
+				t.Fatalf("unexpected precision: got %d, want %d", got, want)
+			}
+			want := start + tc.n2
+			if start < 0 {
+				want = tc.n2
+			}
+			checkEstimate(t, h1, want, 0.05)
+		})
+	}
+}
+
+func TestEncode_HLL(t *testing.T) {
+	for _, n := range []int{0, 10, 100000} {
+		t.Run(fmt.Sprint(n), func(t *testing.T) {
+			h := newHLL(t, 12, 0, n)
+			s, err := sketch.Encode(h)
+			if err != nil {
+				t.Fatal(err)
+			}
+			decoded, err := sketch.Decode(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, ok := decoded.(*sketch.HLL)
+			if !ok {
+				t.Fatalf("unexpected sketch type %T", decoded)
+			}
+			if got.Precision() != h.Precision() {
+				t.Fatalf("unexpected precision: got %d, want %d", got.Precision(), h.Precision())
+			}
+			if got, want := got.Estimate(), h.Estimate(); got != want {
+				t.Fatalf("unexpected estimate: got %d, want %d", got, want)
+			}
+			if again, _ := sketch.Encode(got); again != s {
+				t.Fatal("sketch encoding is not stable")
+			}
+		})
+	}
+}
+
+func TestEncode_TDigest(t *testing.T) {
+	td := sketch.NewTDigest(100)
+	for i := 0; i < 10000; i++ {
+		td.Add(float64(i), 1)
+	}
+	s, err := sketch.Encode(td)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := sketch.Decode(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, ok := decoded.(*sketch.TDigest)
+	if !ok {
+		t.Fatalf("unexpected sketch type %T", decoded)
+	}
+	if got.Compression != td.Compression {
+		t.Fatalf("unexpected compression: got %v, want %v", got.Compression, td.Compression)
+	}
+	if got.Count() != td.Count() {
+		t.Fatalf("unexpected count: got %v, want %v", got.Count(), td.Count())
+	}
+	for _, q := range []float64{0, 0.25, 0.5, 0.99, 1} {
+		if got, want := got.Quantile(q), td.Quantile(q); math.Abs(got-want) > 1 {
+			t.Errorf("unexpected quantile %v: got %v, want %v", q, got, want)
+		}
+	}
+
+	if err := sketch.Merge(got, td); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := got.Count(), 2*td.Count(); got != want {
+		t.Fatalf("unexpected count after merge: got %v, want %v", got, want)
+	}
+}
+
+func TestDecode_Invalid(t *testing.T) {
+	h := newHLL(t, 10, 0, 10)
+	valid, err := h.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		"",
+		"not a sketch",
+		"AQ==",
+		"AwE=",
+		"AQI=",
+		"AQEK",
+		"AQEDAA==",
+		base64.StdEncoding.EncodeToString(valid[:len(valid)-1]),
+		// A t-digest whose number of centroids overflows their size.
+		base64.StdEncoding.EncodeToString(binary.AppendUvarint(
+			binary.LittleEndian.AppendUint64([]byte{2, 1}, math.Float64bits(100)), 1<<60)),
+		// A sparse HyperLogLog whose second index overflows to before the first.
+		base64.StdEncoding.EncodeToString(append(binary.AppendUvarint(
+			[]byte{1, 1, 10, 1, 2, 5, 1}, math.MaxUint64), 1)),
+	} {
+		t.Run(s, func(t *testing.T) {
+			_, err := sketch.Decode(s)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if got, want := errors.Code(err), codes.Invalid; got != want {
+				t.Fatalf("unexpected error code: got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestMerge_DifferentKinds(t *testing.T) {
+	h := newHLL(t, 10, 0, 10)
+	err := sketch.Merge(h, sketch.NewTDigest(100))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if got, want := err.Error(), "cannot merge HyperLogLog sketch with t-digest sketch"; got != want {
+		t.Fatalf("unexpected error: got %q, want %q", got, want)
+	}
+}
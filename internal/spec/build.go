@@ -10,9 +10,10 @@ import (
 	"github.com/InfluxCommunity/flux/execute"
 	"github.com/InfluxCommunity/flux/internal/errors"
 	"github.com/InfluxCommunity/flux/internal/operation"
+	"github.com/InfluxCommunity/flux/internal/telemetry"
 	"github.com/InfluxCommunity/flux/interpreter"
 	"github.com/InfluxCommunity/flux/plan"
-	"github.com/opentracing/opentracing-go"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type ider struct {
@@ -72,10 +73,9 @@ func FromEvaluation(ctx context.Context, ses []interpreter.SideEffect, now time.
 
 	for _, se := range ses {
 		if op, ok := se.Value.(*flux.TableObject); ok {
-			s, cctx := opentracing.StartSpanFromContext(ctx, "toSpec")
-			s.SetTag("opKind", op.Kind)
+			cctx, s := telemetry.StartSpan(ctx, "toSpec", attribute.String("opKind", string(op.Kind)))
 			if se.Node != nil {
-				s.SetTag("loc", se.Node.Location().String())
+				s.SetAttributes(attribute.String("loc", se.Node.Location().String()))
 			}
 
 			if !isDuplicateTableObject(cctx, op, objs) {
@@ -88,7 +88,7 @@ func FromEvaluation(ctx context.Context, ses []interpreter.SideEffect, now time.
 				objs = append(objs, op)
 			}
 
-			s.Finish()
+			s.End()
 		}
 	}
 
@@ -113,8 +113,8 @@ func FromEvaluation(ctx context.Context, ses []interpreter.SideEffect, now time.
 }
 
 func isDuplicateTableObject(ctx context.Context, op *flux.TableObject, objs []*flux.TableObject) bool {
-	s, _ := opentracing.StartSpanFromContext(ctx, "isDuplicate")
-	defer s.Finish()
+	_, s := telemetry.StartSpan(ctx, "isDuplicate")
+	defer s.End()
 
 	for _, tableObject := range objs {
 		if op == tableObject {
@@ -125,10 +125,9 @@ func isDuplicateTableObject(ctx context.Context, op *flux.TableObject, objs []*f
 }
 
 func buildSpecWithTrace(ctx context.Context, t *flux.TableObject, ider *ider, spec *operation.Spec, visited map[*flux.TableObject]bool, skipYields bool) {
-	s, cctx := opentracing.StartSpanFromContext(ctx, "buildSpec")
-	s.SetTag("opKind", t.Kind)
+	cctx, s := telemetry.StartSpan(ctx, "buildSpec", attribute.String("opKind", string(t.Kind)))
 	buildSpec(cctx, t, ider, spec, visited, skipYields)
-	s.Finish()
+	s.End()
 }
 
 func buildSpec(ctx context.Context, t *flux.TableObject, ider *ider, spec *operation.Spec, visited map[*flux.TableObject]bool, skipYields bool) {
@@ -200,25 +199,25 @@ func FromTableObject(ctx context.Context, to *flux.TableObject, now time.Time) (
 // This is duplicate logic for what happens when a flux.Program runs.
 // This function is used in tests that compare flux.Specs (e.g. in planner tests).
 func FromScript(ctx context.Context, runtime flux.Runtime, now time.Time, script string) (*operation.Spec, error) {
-	s, _ := opentracing.StartSpanFromContext(ctx, "parse")
+	_, s := telemetry.StartSpan(ctx, "parse")
 	astPkg, err := runtime.Parse(ctx, script)
 	if err != nil {
 		return nil, err
 	}
-	s.Finish()
+	s.End()
 
 	deps := execute.NewExecutionDependencies(nil, &now, nil)
 	ctx = deps.Inject(ctx)
 
-	s, cctx := opentracing.StartSpanFromContext(ctx, "eval")
+	cctx, s := telemetry.StartSpan(ctx, "eval")
 	sideEffects, scope, err := runtime.Eval(cctx, astPkg, nil, flux.SetNowOption(now))
 	if err != nil {
 		return nil, err
 	}
-	s.Finish()
+	s.End()
 
-	s, cctx = opentracing.StartSpanFromContext(ctx, "compile")
-	defer s.Finish()
+	cctx, s = telemetry.StartSpan(ctx, "compile")
+	defer s.End()
 	nowOpt, ok := scope.Lookup(interpreter.NowOption)
 	if !ok {
 		return nil, fmt.Errorf("%q option not set", interpreter.NowOption)
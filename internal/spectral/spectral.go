@@ -0,0 +1,155 @@
+// Package spectral implements the frequency spectrum and the
+// correlation functions of regularly sampled series.
+package spectral
+
+import (
+	"math"
+	"math/cmplx"
+
+	"gonum.org/v1/gonum/dsp/fourier"
+)
+
+// Windows that taper a series before its spectrum is computed
+// to reduce the leakage of a frequency into its neighbors.
+const (
+	WindowNone     = "none"
+	WindowHann     = "hann"
+	WindowHamming  = "hamming"
+	WindowBlackman = "blackman"
+)
+
+// IsWindow reports whether name is the name of a window.
+func IsWindow(name string) bool {
+	switch name {
+	case WindowNone, WindowHann, WindowHamming, WindowBlackman:
+		return true
+	}
+	return false
+}
+
+// window returns the weights of the named window over n points.
+// The windows are periodic so they are suited to spectral analysis.
+func window(name string, n int) []float64 {
+	w := make([]float64, n)
+	for i := range w {
+		a := 2 * math.Pi * float64(i) / float64(n)
+		switch name {
+		case WindowHann:
+			w[i] = 0.5 - 0.5*math.Cos(a)
+		case WindowHamming:
+			w[i] = 0.54 - 0.46*math.Cos(a)
+		case WindowBlackman:
+			w[i] = 0.42 - 0.5*math.Cos(a) + 0.08*math.Cos(2*a)
+		default:
+			w[i] = 1
+		}
+	}
+	return w
+}
+
+// Spectrum returns the one-sided amplitude spectrum of the series x
+// tapered by the named window, with a magnitude and a phase in radians
+// for each of the len(x)/2 + 1 frequencies from 0 to the Nyquist frequency.
+// Frequency k is k cycles over the length of the series.
+//
+// Magnitudes are scaled by the gain of the window so that a sinusoid
+// with an amplitude of A at frequency k has a magnitude of A at k.
+func Spectrum(x []float64, windowName string) (magnitude, phase []float64) {
+	n := len(x)
+	w := window(windowName, n)
+	var gain float64
+	tapered := make([]float64, n)
+	for i, v := range x {
+		tapered[i] = v * w[i]
+		gain += w[i]
+	}
+
+	coeffs := fourier.NewFFT(n).Coefficients(nil, tapered)
+	magnitude = make([]float64, len(coeffs))
+	phase = make([]float64, len(coeffs))
+	for k, c := range coeffs {
+		// The negative frequencies of a real series mirror
+		// the positive ones, so their amplitude is added to them
+		// except for the frequencies that have no mirror.
+		scale := 2 / gain
+		if k == 0 || 2*k == n {
+			scale = 1 / gain
+		}
+		magnitude[k] = cmplx.Abs(c) * scale
+		phase[k] = cmplx.Phase(c)
+	}
+	return magnitude, phase
+}
+
+// Autocorrelation returns the correlation of the series x with itself
+// lagged by 0 up to maxLag points, at most len(x)-1.
+// The correlations are NaN if the series is constant.
+func Autocorrelation(x []float64, maxLag int) []float64 {
+	return correlate(x, x, 0, maxLag)
+}
+
+// CrossCorrelation returns the correlation of the series x with the
+// series y lagged by -maxLag up to maxLag points, at most len(x)-1
+// points either way. At a positive lag k, each value of x is paired
+// with the value of y k points after it, so the correlation peaks at k
+// when y follows x with a delay of k points.
+// The series must have the same length.
+// The correlations are NaN if either series is constant.
+func CrossCorrelation(x, y []float64, maxLag int) []float64 {
+	return correlate(x, y, -maxLag, maxLag)
+}
+
+// correlate returns the correlations of x and y lagged from minLag to
+// maxLag, both limited to the length of the series.
+//
+// The covariance of each lag is summed over the overlapping values and
+// divided by the variance of the whole series, which is the standard
+// estimator of the correlation function of a stationary series.
+func correlate(x, y []float64, minLag, maxLag int) []float64 {
+	n := len(x)
+	if maxLag > n-1 {
+		maxLag = n - 1
+	}
+	if minLag < -(n - 1) {
+		minLag = -(n - 1)
+	}
+	if n == 0 || maxLag < minLag {
+		return nil
+	}
+
+	dx, dy := deviations(x), deviations(y)
+	var ssx, ssy float64
+	for i := range dx {
+		ssx += dx[i] * dx[i]
+		ssy += dy[i] * dy[i]
+	}
+	norm := math.Sqrt(ssx * ssy)
+
+	r := make([]float64, 0, maxLag-minLag+1)
+	for k := minLag; k <= maxLag; k++ {
+		if norm == 0 {
+			r = append(r, math.NaN())
+			continue
+		}
+		var sum float64
+		for i := max(0, -k); i < n && i+k < n; i++ {
+			sum += dx[i] * dy[i+k]
+		}
+		r = append(r, sum/norm)
+	}
+	return r
+}
+
+// deviations returns the deviations of the values from their mean.
+func deviations(x []float64) []float64 {
+	var mean float64
+	for _, v := range x {
+		mean += v
+	}
+	mean /= float64(len(x))
+	d := make([]float64, len(x))
+	for i, v := range x {
+		d[i] = v - mean
+	}
+	return d
+}
@@ -0,0 +1,92 @@
+package spectral_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/InfluxCommunity/flux/internal/spectral"
+)
+
+func checkClose(t *testing.T, name string, got, want []float64) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("unexpected %s length: got %d, want %d", name, len(got), len(want))
+	}
+	for i := range got {
+		if math.IsNaN(want[i]) && math.IsNaN(got[i]) {
+			continue
+		}
+		if math.Abs(got[i]-want[i]) > 1e-9 {
+			t.Fatalf("unexpected %s at %d: got %v, want %v", name, i, got[i], want[i])
+		}
+	}
+}
+
+func TestSpectrum(t *testing.T) {
+	// A mean of 1, a cosine of amplitude 2 at frequency 2
+	// and a sine of amplitude 0.5 at frequency 4.
+	const n = 16
+	x := make([]float64, n)
+	for i := range x {
+		a := 2 * math.Pi * float64(i) / n
+		x[i] = 1 + 2*math.Cos(2*a) + 0.5*math.Sin(4*a)
+	}
+
+	magnitude, phase := spectral.Spectrum(x, spectral.WindowNone)
+	want := make([]float64, n/2+1)
+	want[0], want[2], want[4] = 1, 2, 0.5
+	checkClose(t, "magnitude", magnitude, want)
+	checkClose(t, "phase", []float64{phase[0], phase[2], phase[4]}, []float64{0, 0, -math.Pi / 2})
+
+	// A window spreads a frequency into its neighbors
+	// but keeps the magnitude of its peak.
+	magnitude, _ = spectral.Spectrum(x, spectral.WindowHann)
+	checkClose(t, "hann magnitude", []float64{magnitude[0], magnitude[2], magnitude[4]}, []float64{1, 2, 0.5})
+	if magnitude[1] == 0 || magnitude[3] == 0 {
+		t.Fatalf("expected leakage with a window: %v", magnitude)
+	}
+}
+
+func TestAutocorrelation(t *testing.T) {
+	x := []float64{1, 2, 3, 4, 5}
+	// The deviations are -2, -1, 0, 1, 2 with a sum of squares of 10.
+	checkClose(t, "autocorrelation", spectral.Autocorrelation(x, 10), []float64{1, 0.4, -0.1, -0.4, -0.4})
+	checkClose(t, "autocorrelation", spectral.Autocorrelation(x, 1), []float64{1, 0.4})
+
+	r := spectral.Autocorrelation([]float64{3, 3, 3}, 1)
+	checkClose(t, "constant autocorrelation", r, []float64{math.NaN(), math.NaN()})
+}
+
+func TestCrossCorrelation(t *testing.T) {
+	// y follows x one point later.
+	x := []float64{0, 1, 0, 0, 0, 0}
+	y := []float64{0, 0, 1, 0, 0, 0}
+	r := spectral.CrossCorrelation(x, y, 2)
+	if len(r) != 5 {
+		t.Fatalf("unexpected number of lags: got %d, want 5", len(r))
+	}
+	peak := 0
+	for i := range r {
+		if r[i] > r[peak] {
+			peak = i
+		}
+	}
+	if lag := peak - 2; lag != 1 {
+		t.Fatalf("unexpected lag of the peak: got %d, want 1", lag)
+	}
+
+	// The cross-correlation of a series with itself is symmetric.
+	x = []float64{1, 3, 2, 5, 4}
+	r = spectral.CrossCorrelation(x, x, 4)
+	checkClose(t, "cross-correlation", r, []float64{
+		spectral.Autocorrelation(x, 4)[4],
+		spectral.Autocorrelation(x, 4)[3],
+		spectral.Autocorrelation(x, 4)[2],
+		spectral.Autocorrelation(x, 4)[1],
+		1,
+		spectral.Autocorrelation(x, 4)[1],
+		spectral.Autocorrelation(x, 4)[2],
+		spectral.Autocorrelation(x, 4)[3],
+		spectral.Autocorrelation(x, 4)[4],
+	})
+}
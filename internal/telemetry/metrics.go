@@ -0,0 +1,170 @@
+package telemetry
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/InfluxCommunity/flux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	statusKey    = attribute.Key("flux.query.status")
+	phaseKey     = attribute.Key("flux.query.phase")
+	operatorKey  = attribute.Key("flux.operator.type")
+	directionKey = attribute.Key("flux.operator.direction")
+)
+
+// instruments holds the instruments that the statistics of a query are recorded with.
+type instruments struct {
+	queries           metric.Int64Counter
+	queryDuration     metric.Float64Histogram
+	maxAllocated      metric.Int64Histogram
+	totalAllocated    metric.Int64Histogram
+	concurrency       metric.Int64Histogram
+	operatorDuration  metric.Float64Histogram
+	operatorRows      metric.Int64Counter
+	operatorBytes     metric.Int64Counter
+	operatorPeakBytes metric.Int64Histogram
+}
+
+var (
+	instrumentsOnce sync.Once
+	instrumentsErr  error
+	globalInstr     instruments
+)
+
+// getInstruments creates the instruments the first time it is called.
+// The global meter provider delegates to the provider that is set later
+// so the instruments may be created before the program configures it.
+func getInstruments() (*instruments, error) {
+	instrumentsOnce.Do(func() {
+		globalInstr, instrumentsErr = newInstruments(otel.Meter(instrumentationName))
+	})
+	return &globalInstr, instrumentsErr
+}
+
+func newInstruments(meter metric.Meter) (instruments, error) {
+	var (
+		instr instruments
+		err   error
+	)
+	if instr.queries, err = meter.Int64Counter("flux.queries",
+		metric.WithDescription("Number of queries that finished executing."),
+		metric.WithUnit("{query}"),
+	); err != nil {
+		return instr, err
+	}
+	if instr.queryDuration, err = meter.Float64Histogram("flux.query.duration",
+		metric.WithDescription("Duration of each phase of a query."),
+		metric.WithUnit("s"),
+	); err != nil {
+		return instr, err
+	}
+	if instr.maxAllocated, err = meter.Int64Histogram("flux.query.memory.max",
+		metric.WithDescription("Maximum number of bytes a query had allocated at the same time."),
+		metric.WithUnit("By"),
+	); err != nil {
+		return instr, err
+	}
+	if instr.totalAllocated, err = meter.Int64Histogram("flux.query.memory.total",
+		metric.WithDescription("Total number of bytes a query allocated."),
+		metric.WithUnit("By"),
+	); err != nil {
+		return instr, err
+	}
+	if instr.concurrency, err = meter.Int64Histogram("flux.query.concurrency",
+		metric.WithDescription("Number of goroutines that executed a query."),
+		metric.WithUnit("{goroutine}"),
+	); err != nil {
+		return instr, err
+	}
+	if instr.operatorDuration, err = meter.Float64Histogram("flux.operator.duration",
+		metric.WithDescription("Time an operation spent processing the data of a query."),
+		metric.WithUnit("s"),
+	); err != nil {
+		return instr, err
+	}
+	if instr.operatorRows, err = meter.Int64Counter("flux.operator.rows",
+		metric.WithDescription("Number of rows received and produced by operations."),
+		metric.WithUnit("{row}"),
+	); err != nil {
+		return instr, err
+	}
+	if instr.operatorBytes, err = meter.Int64Counter("flux.operator.bytes",
+		metric.WithDescription("Number of bytes of data received and produced by operations."),
+		metric.WithUnit("By"),
+	); err != nil {
+		return instr, err
+	}
+	if instr.operatorPeakBytes, err = meter.Int64Histogram("flux.operator.memory.peak",
+		metric.WithDescription("Maximum number of bytes an operation had allocated at the same time."),
+		metric.WithUnit("By"),
+	); err != nil {
+		return instr, err
+	}
+	return instr, nil
+}
+
+// RecordStatistics records the statistics of a query that
+// finished executing with the global meter provider.
+func RecordStatistics(ctx context.Context, stats flux.Statistics, err error) {
+	instr, ierr := getInstruments()
+	if ierr != nil {
+		otel.Handle(ierr)
+		return
+	}
+	instr.record(ctx, stats, err)
+}
+
+func (instr *instruments) record(ctx context.Context, stats flux.Statistics, err error) {
+	status := "success"
+	if err != nil {
+		status = "error"
+	}
+	instr.queries.Add(ctx, 1, metric.WithAttributes(statusKey.String(status)))
+
+	for _, phase := range []struct {
+		name string
+		d    time.Duration
+	}{
+		{name: "total", d: stats.TotalDuration},
+		{name: "compile", d: stats.CompileDuration},
+		{name: "queue", d: stats.QueueDuration},
+		{name: "plan", d: stats.PlanDuration},
+		{name: "requeue", d: stats.RequeueDuration},
+		{name: "execute", d: stats.ExecuteDuration},
+	} {
+		// A phase that was not measured is not recorded
+		// so it does not skew the distribution.
+		if phase.d <= 0 {
+			continue
+		}
+		instr.queryDuration.Record(ctx, phase.d.Seconds(), metric.WithAttributes(phaseKey.String(phase.name)))
+	}
+	instr.maxAllocated.Record(ctx, stats.MaxAllocated)
+	instr.totalAllocated.Record(ctx, stats.TotalAllocated)
+	if stats.Concurrency > 0 {
+		instr.concurrency.Record(ctx, int64(stats.Concurrency))
+	}
+
+	for _, p := range stats.Profiles {
+		operator := operatorKey.String(p.NodeType)
+		instr.operatorDuration.Record(ctx, time.Duration(p.Sum).Seconds(), metric.WithAttributes(operator))
+
+		input := metric.WithAttributes(operator, directionKey.String("input"))
+		instr.operatorRows.Add(ctx, p.InputRows, input)
+		instr.operatorBytes.Add(ctx, p.InputBytes, input)
+
+		output := metric.WithAttributes(operator, directionKey.String("output"))
+		instr.operatorRows.Add(ctx, p.OutputRows, output)
+		instr.operatorBytes.Add(ctx, p.OutputBytes, output)
+
+		if p.PeakBytes > 0 {
+			instr.operatorPeakBytes.Record(ctx, p.PeakBytes, metric.WithAttributes(operator))
+		}
+	}
+}
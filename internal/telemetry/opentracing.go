@@ -0,0 +1,140 @@
+package telemetry
+
+import (
+	"context"
+
+	"github.com/InfluxCommunity/flux/internal/jaeger"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/embedded"
+)
+
+// NewOpenTracingProvider returns a TracerProvider that records spans
+// with an opentracing tracer.
+//
+// The spans it starts are also added to the context as opentracing
+// spans so code that still uses opentracing starts children of them.
+func NewOpenTracingProvider(tracer opentracing.Tracer) trace.TracerProvider {
+	return &openTracingProvider{tracer: tracer}
+}
+
+type openTracingProvider struct {
+	embedded.TracerProvider
+	tracer opentracing.Tracer
+}
+
+func (p *openTracingProvider) Tracer(name string, options ...trace.TracerOption) trace.Tracer {
+	return &openTracingTracer{provider: p}
+}
+
+type openTracingTracer struct {
+	embedded.Tracer
+	provider *openTracingProvider
+}
+
+func (t *openTracingTracer) Start(ctx context.Context, spanName string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	config := trace.NewSpanStartConfig(opts...)
+
+	var startOpts []opentracing.StartSpanOption
+	if parent := opentracing.SpanFromContext(ctx); parent != nil && !config.NewRoot() {
+		startOpts = append(startOpts, opentracing.ChildOf(parent.Context()))
+	}
+	if ts := config.Timestamp(); !ts.IsZero() {
+		startOpts = append(startOpts, opentracing.StartTime(ts))
+	}
+	if attrs := config.Attributes(); len(attrs) > 0 {
+		tags := make(opentracing.Tags, len(attrs))
+		for _, kv := range attrs {
+			tags[string(kv.Key)] = kv.Value.AsInterface()
+		}
+		startOpts = append(startOpts, tags)
+	}
+
+	s := t.provider.tracer.StartSpan(spanName, startOpts...)
+	span := &openTracingSpan{
+		span:     s,
+		provider: t.provider,
+	}
+	span.ctx, _ = jaeger.SpanContextFromSpan(s)
+
+	ctx = opentracing.ContextWithSpan(ctx, s)
+	return trace.ContextWithSpan(ctx, span), span
+}
+
+// openTracingSpan implements trace.Span with an opentracing span.
+type openTracingSpan struct {
+	embedded.Span
+	span     opentracing.Span
+	ctx      trace.SpanContext
+	provider *openTracingProvider
+}
+
+func (s *openTracingSpan) End(options ...trace.SpanEndOption) {
+	config := trace.NewSpanEndConfig(options...)
+	if ts := config.Timestamp(); !ts.IsZero() {
+		s.span.FinishWithOptions(opentracing.FinishOptions{FinishTime: ts})
+		return
+	}
+	s.span.Finish()
+}
+
+func (s *openTracingSpan) AddEvent(name string, options ...trace.EventOption) {
+	config := trace.NewEventConfig(options...)
+	s.span.LogFields(logFields(log.String("event", name), config.Attributes())...)
+}
+
+// AddLink is not supported by opentracing once a span is started.
+func (s *openTracingSpan) AddLink(link trace.Link) {}
+
+func (s *openTracingSpan) IsRecording() bool {
+	return true
+}
+
+func (s *openTracingSpan) RecordError(err error, options ...trace.EventOption) {
+	if err == nil {
+		return
+	}
+	config := trace.NewEventConfig(options...)
+	s.span.LogFields(logFields(log.Error(err), config.Attributes())...)
+}
+
+func (s *openTracingSpan) SpanContext() trace.SpanContext {
+	return s.ctx
+}
+
+func (s *openTracingSpan) SetStatus(code codes.Code, description string) {
+	if code != codes.Error {
+		return
+	}
+	ext.Error.Set(s.span, true)
+	if description != "" {
+		s.span.LogFields(log.String("message", description))
+	}
+}
+
+func (s *openTracingSpan) SetName(name string) {
+	s.span.SetOperationName(name)
+}
+
+func (s *openTracingSpan) SetAttributes(kv ...attribute.KeyValue) {
+	for _, kv := range kv {
+		s.span.SetTag(string(kv.Key), kv.Value.AsInterface())
+	}
+}
+
+func (s *openTracingSpan) TracerProvider() trace.TracerProvider {
+	return s.provider
+}
+
+func logFields(first log.Field, attrs []attribute.KeyValue) []log.Field {
+	fields := make([]log.Field, 0, len(attrs)+1)
+	fields = append(fields, first)
+	for _, kv := range attrs {
+		fields = append(fields, log.Object(string(kv.Key), kv.Value.AsInterface()))
+	}
+	return fields
+}
@@ -0,0 +1,72 @@
+// Package telemetry records traces and metrics for the execution of
+// queries with OpenTelemetry.
+//
+// The spans and instruments are created with the global tracer and
+// meter providers of the otel package so nothing is recorded until
+// a program configures them. A tracer that implements opentracing
+// can be used with NewOpenTracingProvider.
+//
+// Programs that still register a global opentracing tracer keep
+// receiving the spans: until the global tracer provider of the otel
+// package is set, the spans are recorded with the global opentracing
+// tracer when one is registered. Migrating to OpenTelemetry only
+// requires setting the tracer provider with otel.SetTracerProvider.
+package telemetry
+
+import (
+	"context"
+
+	"github.com/opentracing/opentracing-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName is the name of the tracer and meter used by flux.
+const instrumentationName = "github.com/InfluxCommunity/flux"
+
+// defaultTracerProvider is the global tracer provider of the otel
+// package before a program sets its own.
+var defaultTracerProvider = otel.GetTracerProvider()
+
+// tracer returns the tracer used to start the spans. It uses the global
+// opentracing tracer when one is registered and the otel package has
+// not been configured.
+func tracer() trace.Tracer {
+	tp := otel.GetTracerProvider()
+	if tp == defaultTracerProvider && opentracing.IsGlobalTracerRegistered() {
+		tp = NewOpenTracingProvider(opentracing.GlobalTracer())
+	}
+	return tp.Tracer(instrumentationName)
+}
+
+// StartSpan starts a span with the given name as a child
+// of the span in the context, if there is one.
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	var opts []trace.SpanStartOption
+	if len(attrs) > 0 {
+		opts = append(opts, trace.WithAttributes(attrs...))
+	}
+	return tracer().Start(ctx, name, opts...)
+}
+
+// FinishSpan records the error, if there is one, and ends the span.
+func FinishSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// InfoFromContext returns the trace ID of the span in the context
+// and whether it was sampled. It returns whether a span associated
+// with the context has been found.
+func InfoFromContext(ctx context.Context) (traceID string, sampled bool, found bool) {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return "", false, false
+	}
+	return sc.TraceID().String(), sc.IsSampled(), true
+}
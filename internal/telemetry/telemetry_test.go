@@ -0,0 +1,132 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestOpenTracingProvider(t *testing.T) {
+	tracer := mocktracer.New()
+	otelTracer := NewOpenTracingProvider(tracer).Tracer(instrumentationName)
+
+	ctx, parent := otelTracer.Start(context.Background(), "parent")
+	if opentracing.SpanFromContext(ctx) == nil {
+		t.Fatal("expected the opentracing span to be added to the context")
+	}
+	_, child := otelTracer.Start(ctx, "child")
+	child.SetAttributes(attribute.String("label", "from0"))
+	child.SetStatus(codes.Error, "failed")
+	child.End()
+	parent.End()
+
+	spans := tracer.FinishedSpans()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 finished spans, got %d", len(spans))
+	}
+	c, p := spans[0], spans[1]
+	if c.OperationName != "child" || p.OperationName != "parent" {
+		t.Fatalf("unexpected span names %q and %q", c.OperationName, p.OperationName)
+	}
+	if c.ParentID != p.SpanContext.SpanID {
+		t.Errorf("expected child to be a child of parent")
+	}
+	if got := c.Tag("label"); got != "from0" {
+		t.Errorf("unexpected label tag: %v", got)
+	}
+	if got := c.Tag("error"); got != true {
+		t.Errorf("expected the error tag to be set, got %v", got)
+	}
+}
+
+func TestStartSpan_GlobalOpenTracingTracer(t *testing.T) {
+	tracer := mocktracer.New()
+	opentracing.SetGlobalTracer(tracer)
+	defer opentracing.SetGlobalTracer(opentracing.NoopTracer{})
+
+	parent := tracer.StartSpan("query")
+	ctx := opentracing.ContextWithSpan(context.Background(), parent)
+	_, span := StartSpan(ctx, "execute")
+	FinishSpan(span, errors.New("expected error"))
+	parent.Finish()
+
+	spans := tracer.FinishedSpans()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 finished spans, got %d", len(spans))
+	}
+	if got := spans[0].OperationName; got != "execute" {
+		t.Fatalf("unexpected span name %q", got)
+	}
+	if spans[0].ParentID != parent.Context().(mocktracer.MockSpanContext).SpanID {
+		t.Errorf("expected the span to be a child of the opentracing span in the context")
+	}
+	if got := spans[0].Tag("error"); got != true {
+		t.Errorf("expected the error tag to be set, got %v", got)
+	}
+}
+
+func TestRecordStatistics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	instr, err := newInstruments(mp.Meter(instrumentationName))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	instr.record(ctx, flux.Statistics{
+		TotalDuration:   3 * time.Second,
+		ExecuteDuration: 2 * time.Second,
+		MaxAllocated:    1024,
+		Profiles: []flux.TransportProfile{{
+			NodeType:   "*universe.filterTransformation",
+			InputRows:  10,
+			OutputRows: 4,
+		}},
+	}, nil)
+	instr.record(ctx, flux.Statistics{}, errors.New("expected"))
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(ctx, &rm); err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]metricdata.Aggregation)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			got[m.Name] = m.Data
+		}
+	}
+
+	queries := got["flux.queries"].(metricdata.Sum[int64])
+	if len(queries.DataPoints) != 2 {
+		t.Errorf("expected a data point per status, got %d", len(queries.DataPoints))
+	}
+
+	// Only the phases that were measured are recorded.
+	duration := got["flux.query.duration"].(metricdata.Histogram[float64])
+	if len(duration.DataPoints) != 2 {
+		t.Errorf("expected a data point per measured phase, got %d", len(duration.DataPoints))
+	}
+
+	rows := got["flux.operator.rows"].(metricdata.Sum[int64])
+	want := map[string]int64{"input": 10, "output": 4}
+	for _, dp := range rows.DataPoints {
+		dir, _ := dp.Attributes.Value(directionKey)
+		if want[dir.AsString()] != dp.Value {
+			t.Errorf("unexpected %s rows: %d", dir.AsString(), dp.Value)
+		}
+		delete(want, dir.AsString())
+	}
+	if len(want) > 0 {
+		t.Errorf("missing rows for %v", want)
+	}
+}
@@ -0,0 +1,221 @@
+// Package watch runs a Flux program repeatedly and
+// reports the rows that each run adds to its results.
+package watch
+
+import (
+	"context"
+	"crypto/sha256"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/execute/table"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/memory"
+	"github.com/InfluxCommunity/flux/values"
+)
+
+// RunFunc executes a run of the program with the given now time.
+type RunFunc func(ctx context.Context, now time.Time) error
+
+// Run calls fn once immediately and then every interval until the
+// context is canceled. Runs do not overlap, so ticks that occur
+// while a run is executing are skipped. Run returns the first
+// error returned by fn, or nil once the context is canceled.
+func Run(ctx context.Context, every time.Duration, fn RunFunc) error {
+	if every <= 0 {
+		return errors.New(codes.Invalid, "watch interval must be positive")
+	}
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	now := time.Now()
+	for {
+		if err := fn(ctx, now); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case now = <-ticker.C:
+		}
+	}
+}
+
+// Tracker remembers the rows of the results that were reported
+// so that only the rows added by later runs are reported.
+//
+// Rows are identified by their result, their group key without the
+// _start and _stop columns, which change with every run, and their
+// _time. Each series has a watermark, the greatest _time reported,
+// and only rows after it are new. Rows that arrive late, with a _time
+// at or before the watermark, are therefore not reported. Tables
+// without a _time column are reported whenever their rows change.
+type Tracker struct {
+	watermarks map[string]values.Time
+	digests    map[string][sha256.Size]byte
+}
+
+// NewTracker creates a Tracker that has not reported any rows.
+func NewTracker() *Tracker {
+	return &Tracker{
+		watermarks: make(map[string]values.Time),
+		digests:    make(map[string][sha256.Size]byte),
+	}
+}
+
+// Filter reads the results of a run and returns the rows
+// that were not reported by earlier runs.
+// Results and tables without new rows are omitted.
+func (t *Tracker) Filter(results flux.ResultIterator, mem memory.Allocator) ([]flux.Result, error) {
+	defer results.Release()
+
+	// The watermarks of this run are only used by the next run
+	// so that tables of the same series can come in any order.
+	watermarks := make(map[string]values.Time, len(t.watermarks))
+	for k, v := range t.watermarks {
+		watermarks[k] = v
+	}
+
+	var filtered []flux.Result
+	for results.More() {
+		res := results.Next()
+		out := &result{name: res.Name()}
+		ordinals := make(map[string]int)
+		if err := res.Tables().Do(func(tbl flux.Table) error {
+			series := seriesKey(res.Name(), tbl.Key())
+			var (
+				newTbl flux.Table
+				err    error
+			)
+			if j := execute.ColIdx(execute.DefaultTimeColLabel, tbl.Cols()); j >= 0 && tbl.Cols()[j].Type == flux.TTime {
+				newTbl, err = t.filterTimes(tbl, j, series, watermarks, mem)
+			} else {
+				// The n-th table of a series is compared with
+				// the n-th table of the series in the last run.
+				n := ordinals[series]
+				ordinals[series]++
+				newTbl, err = t.filterChanged(tbl, series+"\x00"+strconv.Itoa(n))
+			}
+			if err != nil || newTbl == nil {
+				return err
+			}
+			out.tables = append(out.tables, newTbl)
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+		if len(out.tables) > 0 {
+			filtered = append(filtered, out)
+		}
+	}
+	results.Release()
+	if err := results.Err(); err != nil {
+		return nil, err
+	}
+	t.watermarks = watermarks
+	return filtered, nil
+}
+
+// filterTimes returns the rows of tbl after the watermark of the series
+// or nil if there are none, and raises the watermark to the latest row.
+func (t *Tracker) filterTimes(tbl flux.Table, timeIdx int, series string, watermarks map[string]values.Time, mem memory.Allocator) (flux.Table, error) {
+	watermark, seen := t.watermarks[series]
+	builder := execute.NewColListTableBuilder(tbl.Key(), mem)
+	defer builder.Release()
+	if err := execute.AddTableCols(tbl, builder); err != nil {
+		return nil, err
+	}
+	if err := tbl.Do(func(cr flux.ColReader) error {
+		times := cr.Times(timeIdx)
+		for i := 0; i < cr.Len(); i++ {
+			if times.IsNull(i) {
+				continue
+			}
+			ts := values.Time(times.Value(i))
+			if seen && ts <= watermark {
+				continue
+			}
+			if latest, ok := watermarks[series]; !ok || ts > latest {
+				watermarks[series] = ts
+			}
+			if err := execute.AppendRecord(i, cr, builder); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	if builder.NRows() == 0 {
+		return nil, nil
+	}
+	return builder.Table()
+}
+
+// filterChanged returns tbl if its rows differ from the rows
+// of the series that were reported last, or nil otherwise.
+func (t *Tracker) filterChanged(tbl flux.Table, series string) (flux.Table, error) {
+	buf, err := table.Copy(tbl)
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.New()
+	for i := 0; i < buf.BufferN(); i++ {
+		cr := buf.Buffer(i)
+		for r := 0; r < cr.Len(); r++ {
+			for j := range cr.Cols() {
+				v := execute.ValueForRow(cr, r, j)
+				if v.IsNull() {
+					_, _ = h.Write([]byte{0})
+				} else {
+					_, _ = h.Write([]byte(values.DisplayString(v)))
+				}
+				_, _ = h.Write([]byte{1})
+			}
+		}
+	}
+	var digest [sha256.Size]byte
+	copy(digest[:], h.Sum(nil))
+	if last, ok := t.digests[series]; ok && last == digest {
+		buf.Done()
+		return nil, nil
+	}
+	t.digests[series] = digest
+	return buf, nil
+}
+
+// seriesKey identifies the series of a table in a result
+// by its group key without the _start and _stop columns.
+func seriesKey(name string, key flux.GroupKey) string {
+	var sb strings.Builder
+	sb.WriteString(name)
+	for j, c := range key.Cols() {
+		if c.Label == execute.DefaultStartColLabel || c.Label == execute.DefaultStopColLabel {
+			continue
+		}
+		sb.WriteByte(0)
+		sb.WriteString(c.Label)
+		sb.WriteByte('=')
+		if v := key.Value(j); !v.IsNull() {
+			sb.WriteString(values.DisplayString(v))
+		}
+	}
+	return sb.String()
+}
+
+type result struct {
+	name   string
+	tables []flux.Table
+}
+
+func (r *result) Name() string {
+	return r.name
+}
+
+func (r *result) Tables() flux.TableIterator {
+	return table.Iterator(r.tables)
+}
@@ -0,0 +1,212 @@
+package watch_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/execute/table"
+	"github.com/InfluxCommunity/flux/internal/watch"
+	"github.com/InfluxCommunity/flux/memory"
+	"github.com/InfluxCommunity/flux/values"
+	"github.com/google/go-cmp/cmp"
+)
+
+type testResult struct {
+	name   string
+	tables []flux.Table
+}
+
+func (r *testResult) Name() string               { return r.name }
+func (r *testResult) Tables() flux.TableIterator { return table.Iterator(r.tables) }
+
+// timeTable builds a table of the series with a _start and _stop
+// that change with every run and a row for each of the times.
+func timeTable(t *testing.T, mem memory.Allocator, stop int64, tag string, times ...int64) flux.Table {
+	t.Helper()
+	key := execute.NewGroupKeyBuilder(nil).
+		AddKeyValue(execute.DefaultStartColLabel, values.NewTime(0)).
+		AddKeyValue(execute.DefaultStopColLabel, values.NewTime(values.Time(stop))).
+		AddKeyValue("t0", values.NewString(tag))
+	gk, err := key.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := execute.NewColListTableBuilder(gk, mem)
+	defer b.Release()
+	if err := execute.AddTableKeyCols(gk, b); err != nil {
+		t.Fatal(err)
+	}
+	timeIdx, _ := b.AddCol(flux.ColMeta{Label: execute.DefaultTimeColLabel, Type: flux.TTime})
+	valueIdx, _ := b.AddCol(flux.ColMeta{Label: execute.DefaultValueColLabel, Type: flux.TInt})
+	for _, ts := range times {
+		_ = execute.AppendKeyValues(gk, b)
+		_ = b.AppendTime(timeIdx, values.Time(ts))
+		_ = b.AppendInt(valueIdx, ts)
+	}
+	tbl, err := b.Table()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tbl
+}
+
+// countTable builds a table without a _time column.
+func countTable(t *testing.T, mem memory.Allocator, count int64) flux.Table {
+	t.Helper()
+	b := execute.NewColListTableBuilder(execute.NewGroupKey(nil, nil), mem)
+	defer b.Release()
+	idx, _ := b.AddCol(flux.ColMeta{Label: execute.DefaultValueColLabel, Type: flux.TInt})
+	_ = b.AppendInt(idx, count)
+	tbl, err := b.Table()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tbl
+}
+
+// rows reads the results as result,t0,_time or result,_value.
+func rows(t *testing.T, results []flux.Result) []string {
+	t.Helper()
+	var rows []string
+	for _, res := range results {
+		if err := res.Tables().Do(func(tbl flux.Table) error {
+			return tbl.Do(func(cr flux.ColReader) error {
+				for i := 0; i < cr.Len(); i++ {
+					if j := execute.ColIdx(execute.DefaultTimeColLabel, cr.Cols()); j >= 0 {
+						tag := cr.Strings(execute.ColIdx("t0", cr.Cols())).Value(i)
+						rows = append(rows, fmt.Sprintf("%s,%s,%d", res.Name(), tag, cr.Times(j).Value(i)))
+					} else {
+						rows = append(rows, fmt.Sprintf("%s,%d", res.Name(), cr.Ints(0).Value(i)))
+					}
+				}
+				return nil
+			})
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return rows
+}
+
+func TestTracker(t *testing.T) {
+	mem := memory.NewResourceAllocator(nil)
+	tracker := watch.NewTracker()
+
+	for i, tc := range []struct {
+		results func() []flux.Result
+		want    []string
+	}{
+		{
+			results: func() []flux.Result {
+				return []flux.Result{
+					&testResult{name: "a", tables: []flux.Table{
+						timeTable(t, mem, 10, "x", 1, 2, 3),
+						timeTable(t, mem, 10, "y", 2),
+					}},
+					&testResult{name: "b", tables: []flux.Table{countTable(t, mem, 4)}},
+				}
+			},
+			want: []string{"a,x,1", "a,x,2", "a,x,3", "a,y,2", "b,4"},
+		},
+		{
+			// The bounds moved, the rows of x up to 3 were reported and
+			// the row of y at 1 is late. The count did not change.
+			results: func() []flux.Result {
+				return []flux.Result{
+					&testResult{name: "a", tables: []flux.Table{
+						timeTable(t, mem, 20, "x", 2, 3, 4, 5),
+						timeTable(t, mem, 20, "y", 1, 2),
+						timeTable(t, mem, 20, "z", 3),
+					}},
+					&testResult{name: "b", tables: []flux.Table{countTable(t, mem, 4)}},
+				}
+			},
+			want: []string{"a,x,4", "a,x,5", "a,z,3"},
+		},
+		{
+			results: func() []flux.Result {
+				return []flux.Result{
+					&testResult{name: "a", tables: []flux.Table{timeTable(t, mem, 30, "x", 5)}},
+					&testResult{name: "b", tables: []flux.Table{countTable(t, mem, 5)}},
+				}
+			},
+			want: []string{"b,5"},
+		},
+	} {
+		filtered, err := tracker.Filter(flux.NewSliceResultIterator(tc.results()), mem)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := rows(t, filtered); !cmp.Equal(tc.want, got) {
+			t.Errorf("run %d: unexpected rows -want/+got:\n%s", i, cmp.Diff(tc.want, got))
+		}
+	}
+	if got := mem.Allocated(); got != 0 {
+		t.Errorf("memory was not released: %d bytes allocated", got)
+	}
+}
+
+// Tables of a series are compared with the watermark of the
+// last run so they can arrive in any order within a run.
+func TestTracker_SeriesOrder(t *testing.T) {
+	mem := memory.NewResourceAllocator(nil)
+	tracker := watch.NewTracker()
+
+	run := func(tables ...flux.Table) []string {
+		results := []flux.Result{&testResult{name: "a", tables: tables}}
+		filtered, err := tracker.Filter(flux.NewSliceResultIterator(results), mem)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return rows(t, filtered)
+	}
+
+	run(timeTable(t, mem, 10, "x", 1))
+	got := run(
+		timeTable(t, mem, 20, "x", 5),
+		timeTable(t, mem, 20, "x", 3),
+	)
+	if want := []string{"a,x,5", "a,x,3"}; !cmp.Equal(want, got) {
+		t.Errorf("unexpected rows -want/+got:\n%s", cmp.Diff(want, got))
+	}
+}
+
+func TestRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var runs []time.Time
+	err := watch.Run(ctx, time.Millisecond, func(ctx context.Context, now time.Time) error {
+		runs = append(runs, now)
+		if len(runs) == 3 {
+			cancel()
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 3 {
+		t.Fatalf("expected 3 runs, got %d", len(runs))
+	}
+	for i := 1; i < len(runs); i++ {
+		if !runs[i].After(runs[i-1]) {
+			t.Errorf("expected the time of run %d to be after the last run: %v <= %v", i, runs[i], runs[i-1])
+		}
+	}
+
+	want := fmt.Errorf("run failed")
+	if got := watch.Run(context.Background(), time.Millisecond, func(context.Context, time.Time) error {
+		return want
+	}); got != want {
+		t.Errorf("expected the error of the run, got %v", got)
+	}
+
+	if err := watch.Run(context.Background(), 0, nil); err == nil {
+		t.Error("expected an error for an interval of zero")
+	}
+}
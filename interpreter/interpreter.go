@@ -802,6 +802,7 @@ func (itrp *Interpreter) doCall(ctx context.Context, call *semantic.CallExpressi
 	// arguments as this source location information is only
 	// for the currently called function.
 	fname := functionName(call)
+	recordCall(ctx, call, callee, scope)
 	ctx = withStackEntry(ctx, fname, call.Location())
 	value, err := f.Call(ctx, argObj)
 	if err != nil {
@@ -1779,6 +1780,7 @@ type contextKey int
 
 const (
 	callStackKey contextKey = iota
+	callRecorderKey
 )
 
 // StackEntry describes a single entry in the call stack.
@@ -1828,3 +1830,68 @@ func withStackEntry(ctx context.Context, name string, loc ast.SourceLocation) co
 	}
 	return context.WithValue(ctx, callStackKey, stack)
 }
+
+// CalledFunction describes a package function that was called
+// while a program was evaluated.
+type CalledFunction struct {
+	// Package is the import path of the package the function
+	// was accessed from. It is empty for functions of the prelude.
+	Package string
+	// Name is the name of the function within its package.
+	Name string
+	// Builtin reports whether the function is implemented
+	// in Go instead of Flux.
+	Builtin bool
+}
+
+// WithCallRecorder returns a context that causes fn to be invoked
+// for every call to a member of an imported package or to a function
+// of the prelude evaluated with the context. Calls to functions
+// defined by the program itself are not recorded.
+func WithCallRecorder(ctx context.Context, fn func(CalledFunction)) context.Context {
+	return context.WithValue(ctx, callRecorderKey, fn)
+}
+
+// recordCall passes the called function to the call recorder
+// attached to the context, if there is one.
+func recordCall(ctx context.Context, call *semantic.CallExpression, callee values.Value, scope values.Scope) {
+	fn, ok := ctx.Value(callRecorderKey).(func(CalledFunction))
+	if !ok {
+		return
+	}
+
+	var cf CalledFunction
+	switch c := call.Callee.(type) {
+	case *semantic.MemberExpression:
+		obj, ok := c.Object.(*semantic.IdentifierExpression)
+		if !ok {
+			return
+		}
+		v, ok := scope.Lookup(obj.Name.Name())
+		if !ok {
+			return
+		}
+		pkg, ok := v.(values.Package)
+		if !ok {
+			return
+		}
+		cf.Package, cf.Name = pkg.Path(), c.Property.Name()
+	case *semantic.IdentifierExpression:
+		// The prelude is the outermost scope. The function is only
+		// a prelude function if it was not shadowed by the program.
+		root := scope
+		for parent := root.Pop(); parent != nil; parent = root.Pop() {
+			root = parent
+		}
+		v, ok := root.LocalLookup(c.Name.Name())
+		if !ok || !v.Equal(callee) {
+			return
+		}
+		cf.Name = c.Name.Name()
+	default:
+		return
+	}
+	_, isFlux := callee.Function().(function)
+	cf.Builtin = !isFlux
+	fn(cf)
+}
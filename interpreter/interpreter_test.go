@@ -902,3 +902,39 @@ func TestStack(t *testing.T) {
 		t.Fatalf("unexpected stack -want/+got:\n%s", cmp.Diff(want, got))
 	}
 }
+
+func TestCallRecorder(t *testing.T) {
+	src := `
+import "strings"
+
+f = (v) => strings.toUpper(v: v)
+sum = (v) => v
+
+f(v: "a")
+sum(v: 1)
+from(bucket: "telegraf") |> range(start: -5m) |> aggregateWindow(every: 1m, fn: mean)
+`
+	ctx, deps := dependency.Inject(context.Background(), dependenciestest.Default())
+	defer deps.Finish()
+
+	var got []interpreter.CalledFunction
+	ctx = interpreter.WithCallRecorder(ctx, func(cf interpreter.CalledFunction) {
+		got = append(got, cf)
+	})
+	if _, _, err := runtime.Eval(ctx, src); err != nil {
+		t.Fatal(err)
+	}
+
+	// Functions defined by the script are not recorded, even when
+	// they shadow a prelude function, but the package functions
+	// they call are.
+	want := []interpreter.CalledFunction{
+		{Package: "strings", Name: "toUpper", Builtin: true},
+		{Name: "from", Builtin: true},
+		{Name: "range", Builtin: true},
+		{Name: "aggregateWindow", Builtin: false},
+	}
+	if !cmp.Equal(want, got) {
+		t.Fatalf("unexpected calls -want/+got:\n%s", cmp.Diff(want, got))
+	}
+}
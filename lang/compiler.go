@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"strings"
 	"time"
 
 	"github.com/InfluxCommunity/flux"
@@ -11,9 +13,9 @@ import (
 	"github.com/InfluxCommunity/flux/dependency"
 	"github.com/InfluxCommunity/flux/execute"
 	"github.com/InfluxCommunity/flux/internal/errors"
-	"github.com/InfluxCommunity/flux/internal/jaeger"
 	"github.com/InfluxCommunity/flux/internal/operation"
 	"github.com/InfluxCommunity/flux/internal/spec"
+	"github.com/InfluxCommunity/flux/internal/telemetry"
 	"github.com/InfluxCommunity/flux/interpreter"
 	"github.com/InfluxCommunity/flux/libflux/go/libflux"
 	"github.com/InfluxCommunity/flux/memory"
@@ -21,7 +23,7 @@ import (
 	"github.com/InfluxCommunity/flux/plan"
 	"github.com/InfluxCommunity/flux/semantic"
 	"github.com/InfluxCommunity/flux/values"
-	"github.com/opentracing/opentracing-go"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
@@ -30,6 +32,10 @@ const (
 	ASTCompilerType  = "ast"
 )
 
+// ExplainMetadataKey is the metadata key for the description
+// of the plan added when the planner.explain option is set.
+const ExplainMetadataKey = "flux/explain"
+
 // AddCompilerMappings adds the Flux specific compiler mappings.
 func AddCompilerMappings(mappings flux.CompilerMappings) error {
 	if err := mappings.Add(FluxCompilerType, func() flux.Compiler {
@@ -53,6 +59,12 @@ type CompileOption func(*compileOptions)
 type compileOptions struct {
 	extern flux.ASTHandle
 
+	// explain adds a description of the plan to the query metadata.
+	explain bool
+
+	// sourceCache keeps the output of sources across executions.
+	sourceCache *execute.SourceCache
+
 	planOptions struct {
 		logical  []plan.LogicalOption
 		physical []plan.PhysicalOption
@@ -69,6 +81,15 @@ func WithPhysPlanOpts(popts ...plan.PhysicalOption) CompileOption {
 		o.planOptions.physical = append(o.planOptions.physical, popts...)
 	}
 }
+
+// WithSourceCache executes the program with the source cache so that
+// the output of its sources can be reused by later executions.
+func WithSourceCache(cache *execute.SourceCache) CompileOption {
+	return func(o *compileOptions) {
+		o.sourceCache = cache
+	}
+}
+
 func WithExtern(extern flux.ASTHandle) CompileOption {
 	return func(o *compileOptions) {
 		o.extern = extern
@@ -133,8 +154,8 @@ func CompileTableObject(ctx context.Context, to *flux.TableObject, now time.Time
 }
 
 func buildPlan(ctx context.Context, spec *operation.Spec, opts *compileOptions) (*plan.Spec, error) {
-	s, _ := opentracing.StartSpanFromContext(ctx, "plan")
-	defer s.Finish()
+	_, s := telemetry.StartSpan(ctx, "plan")
+	defer s.End()
 
 	if spec.HasConflict {
 		execute.RecordEvent(ctx, "table-find/disjoint-plan")
@@ -268,11 +289,21 @@ func (p *Program) SetLogger(logger *zap.Logger) {
 }
 
 func (p *Program) Start(ctx context.Context, alloc memory.Allocator) (flux.Query, error) {
+	q, err := p.start(ctx, alloc)
+	if err != nil {
+		return nil, err
+	}
+	q.recordStats = true
+	return q, nil
+}
+
+func (p *Program) start(ctx context.Context, alloc memory.Allocator) (*query, error) {
 	ctx, cancel := context.WithCancel(ctx)
+	start := time.Now()
 
 	// This span gets closed by the query when it is done.
-	var s opentracing.Span
-	s, ctx = opentracing.StartSpanFromContext(ctx, "execute")
+	var s trace.Span
+	ctx, s = telemetry.StartSpan(ctx, "execute")
 	results := make(chan flux.Result)
 
 	resourceAlloc, ok := alloc.(*memory.ResourceAllocator)
@@ -289,13 +320,14 @@ func (p *Program) Start(ctx context.Context, alloc memory.Allocator) (flux.Query
 		results: results,
 		alloc:   resourceAlloc,
 		span:    s,
+		start:   start,
 		cancel:  cancel,
 		stats: flux.Statistics{
 			Metadata: make(metadata.Metadata),
 		},
 	}
 
-	if traceID, sampled, found := jaeger.InfoFromSpan(s); found {
+	if traceID, sampled, found := telemetry.InfoFromContext(ctx); found {
 		q.stats.Metadata.Add("tracing/id", traceID)
 		q.stats.Metadata.Add("tracing/sampled", sampled)
 	}
@@ -306,7 +338,8 @@ func (p *Program) Start(ctx context.Context, alloc memory.Allocator) (flux.Query
 	e := execute.NewExecutor(p.Logger)
 	resultMap, statsCh, err := e.Execute(ctx, p.PlanSpec, q.alloc)
 	if err != nil {
-		s.Finish()
+		telemetry.FinishSpan(s, err)
+		cancel()
 		return nil, err
 	}
 
@@ -432,7 +465,7 @@ func (p *AstProgram) getSpec(ctx context.Context, alloc memory.Allocator) (*oper
 		return nil, nil, astErr
 	}
 
-	s, cctx := opentracing.StartSpanFromContext(ctx, "eval")
+	cctx, s := telemetry.StartSpan(ctx, "eval")
 
 	// Set the now option to our own default and capture the option itself
 	// to allow us to find it after the run. A user might overwrite the
@@ -453,12 +486,13 @@ func (p *AstProgram) getSpec(ctx context.Context, alloc memory.Allocator) (*oper
 		},
 	)
 	if err != nil {
+		telemetry.FinishSpan(s, err)
 		return nil, nil, err
 	}
-	s.Finish()
+	s.End()
 
-	s, cctx = opentracing.StartSpanFromContext(ctx, "compile")
-	defer s.Finish()
+	cctx, s = telemetry.StartSpan(ctx, "compile")
+	defer s.End()
 	nowTime, err := nowOpt.Function().Call(ctx, nil)
 	if err != nil {
 		return nil, nil, errors.Wrap(err, codes.Inherit, "error in evaluating AST while starting program")
@@ -475,36 +509,56 @@ func (p *AstProgram) Start(ctx context.Context, alloc memory.Allocator) (flux.Qu
 	// The program must inject execution dependencies to make it available to
 	// function calls during the evaluation phase (see `tableFind`).
 	deps := execute.NewExecutionDependencies(alloc, &p.Now, p.Logger)
+	if p.opts != nil {
+		deps.ExecutionOptions.SourceCache = p.opts.sourceCache
+	}
 
 	ctx, span := dependency.Inject(ctx, deps)
 	nextPlanNodeID := new(int)
 	ctx = context.WithValue(ctx, plan.NextPlanNodeIDKey, nextPlanNodeID)
 
 	// Evaluation.
+	start := time.Now()
 	sp, scope, err := p.getSpec(ctx, alloc)
 	if err != nil {
 		return nil, err
 	}
+	compileDuration := time.Since(start)
 
 	// Planning.
-	s, cctx := opentracing.StartSpanFromContext(ctx, "plan")
+	planStart := time.Now()
+	cctx, s := telemetry.StartSpan(ctx, "plan")
 	if err := p.updateOpts(scope); err != nil {
 		return nil, errors.Wrap(err, codes.Inherit, "error in reading options while starting program")
 	}
 	if err := p.updateProfilers(ctx, scope); err != nil {
 		return nil, errors.Wrap(err, codes.Inherit, "error in reading profiler settings while starting program")
 	}
+	explanation := plan.ExplanationFromContext(cctx)
+	if explanation == nil && p.opts.explain {
+		explanation = &plan.Explanation{}
+		cctx = plan.WithExplanation(cctx, explanation)
+	}
 	ps, err := buildPlan(cctx, sp, p.opts)
 	if err != nil {
 		return nil, errors.Wrap(err, codes.Inherit, "error in building plan while starting program")
 	}
 	p.PlanSpec = ps
-	s.Finish()
+	s.End()
+	planDuration := time.Since(planStart)
+
+	if p.opts.explain {
+		var sb strings.Builder
+		if err := explanation.Format(&sb, ps, nil); err != nil {
+			return nil, err
+		}
+		deps.Metadata.Add(ExplainMetadataKey, sb.String())
+	}
 
 	// Execution.
-	s, cctx = opentracing.StartSpanFromContext(ctx, "start-program")
-	defer s.Finish()
-	q, err := p.Program.Start(cctx, alloc)
+	cctx, s = telemetry.StartSpan(ctx, "start-program")
+	defer s.End()
+	q, err := p.Program.start(cctx, alloc)
 	if err != nil {
 		span.Finish()
 		return nil, err
@@ -513,9 +567,71 @@ func (p *AstProgram) Start(ctx context.Context, alloc memory.Allocator) (flux.Qu
 		Query:    q,
 		span:     span,
 		metadata: deps.Metadata,
+		start:    start,
+		stats: flux.Statistics{
+			CompileDuration: compileDuration,
+			PlanDuration:    planDuration,
+		},
 	}, nil
 }
 
+// Explain plans the program and writes a description of the plan to w.
+// When analyze is set, the program is also executed. Its results are
+// read and discarded and the description includes the runtime
+// statistics of each plan node.
+func (p *AstProgram) Explain(ctx context.Context, w io.Writer, alloc memory.Allocator, analyze bool) error {
+	e := &plan.Explanation{}
+	ctx = plan.WithExplanation(ctx, e)
+	if analyze {
+		return p.explainAnalyze(ctx, w, e, alloc)
+	}
+
+	deps := execute.NewExecutionDependencies(alloc, &p.Now, p.Logger)
+	ctx, span := dependency.Inject(ctx, deps)
+	defer span.Finish()
+	ctx = context.WithValue(ctx, plan.NextPlanNodeIDKey, new(int))
+
+	sp, scope, err := p.getSpec(ctx, alloc)
+	if err != nil {
+		return err
+	}
+	if err := p.updateOpts(scope); err != nil {
+		return errors.Wrap(err, codes.Inherit, "error in reading options while explaining program")
+	}
+	ps, err := buildPlan(ctx, sp, p.opts)
+	if err != nil {
+		return errors.Wrap(err, codes.Inherit, "error in building plan while explaining program")
+	}
+	return e.Format(w, ps, nil)
+}
+
+func (p *AstProgram) explainAnalyze(ctx context.Context, w io.Writer, e *plan.Explanation, alloc memory.Allocator) error {
+	q, err := p.Start(ctx, alloc)
+	if err != nil {
+		return err
+	}
+	for res := range q.Results() {
+		if err := res.Tables().Do(func(tbl flux.Table) error {
+			return tbl.Do(func(flux.ColReader) error { return nil })
+		}); err != nil {
+			q.Cancel()
+			break
+		}
+	}
+	q.Done()
+	if err := q.Err(); err != nil {
+		return err
+	}
+
+	stats := q.Statistics()
+	if err := e.Format(w, p.PlanSpec, stats.Profiles); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "\nExecution:\n  duration: %v\n  concurrency: %d\n  max allocated: %d bytes\n  total allocated: %d bytes\n",
+		stats.ExecuteDuration, stats.Concurrency, stats.MaxAllocated, stats.TotalAllocated)
+	return err
+}
+
 func (p *AstProgram) updateProfilers(ctx context.Context, scope values.Scope) error {
 	if execute.HaveExecutionDependencies(ctx) {
 		deps := execute.GetExecutionDependencies(ctx)
@@ -540,6 +656,11 @@ func (p *AstProgram) updateOpts(scope values.Scope) error {
 	if po != nil {
 		p.opts.planOptions.physical = append(p.opts.planOptions.physical, po)
 	}
+	if pkg.Type().Nature() == semantic.Object {
+		if v, ok := pkg.Object().Get("explain"); ok && v.Type().Nature() == semantic.Bool {
+			p.opts.explain = v.Bool()
+		}
+	}
 	return nil
 }
 
@@ -548,16 +669,24 @@ type spanQuery struct {
 	span     *dependency.Span
 	stats    flux.Statistics
 	metadata *metadata.SyncMetadata
+	start    time.Time
+	done     bool
 }
 
 func (q *spanQuery) Done() {
 	q.Query.Done()
+	if q.done {
+		return
+	}
+	q.done = true
 	q.stats.Metadata = make(metadata.Metadata)
 	q.metadata.ReadView(func(meta metadata.Metadata) {
 		q.stats.Metadata.AddAll(meta)
 	})
 	q.stats.Merge(q.Query.Statistics())
+	q.stats.TotalDuration = time.Since(q.start)
 	q.span.Finish()
+	telemetry.RecordStatistics(context.Background(), q.stats, q.Err())
 }
 
 func (q *spanQuery) Statistics() flux.Statistics {
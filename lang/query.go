@@ -3,11 +3,13 @@ package lang
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/InfluxCommunity/flux"
 	"github.com/InfluxCommunity/flux/dependencies/testing"
+	"github.com/InfluxCommunity/flux/internal/telemetry"
 	"github.com/InfluxCommunity/flux/memory"
-	"github.com/opentracing/opentracing-go"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // query implements the flux.Query interface.
@@ -16,10 +18,16 @@ type query struct {
 	results chan flux.Result
 	stats   flux.Statistics
 	alloc   *memory.ResourceAllocator
-	span    opentracing.Span
+	span    trace.Span
+	start   time.Time
 	cancel  func()
 	err     error
 	wg      sync.WaitGroup
+
+	// recordStats is set when the statistics of the query should be
+	// recorded as metrics when it is done. It is not set when the
+	// query is wrapped by a query that records them instead.
+	recordStats bool
 }
 
 func (q *query) Results() <-chan flux.Result {
@@ -31,9 +39,9 @@ func (q *query) Done() {
 	q.wg.Wait()
 	q.stats.MaxAllocated = q.alloc.MaxAllocated()
 	q.stats.TotalAllocated = q.alloc.TotalAllocated()
-	if q.span != nil {
-		q.span.Finish()
-		q.span = nil
+	if q.span == nil {
+		// The query has already been marked as done.
+		return
 	}
 
 	// Note: it is safe to read and write to q.err because we have explicitly
@@ -43,6 +51,14 @@ func (q *query) Done() {
 		// If the testing framework was configured, verify all expectations.
 		q.err = testing.Check(q.ctx)
 	}
+
+	q.stats.ExecuteDuration = time.Since(q.start)
+	telemetry.FinishSpan(q.span, q.err)
+	q.span = nil
+	if q.recordStats {
+		q.stats.TotalDuration = q.stats.ExecuteDuration
+		telemetry.RecordStatistics(context.Background(), q.stats, q.err)
+	}
 }
 
 func (q *query) Cancel() {
@@ -42,10 +42,14 @@ func (p *planner) Plan(ctx context.Context, fspec *operation.Spec) (*Spec, error
 	if err != nil {
 		return nil, err
 	}
+	e := ExplanationFromContext(ctx)
+	e.setPhase(LogicalPhase)
 	lp, err := p.lp.Plan(ctx, ip)
 	if err != nil {
 		return nil, err
 	}
+	e.recordLogicalPlan(lp)
+	e.setPhase(PhysicalPhase)
 	pp, err := p.pp.Plan(ctx, lp)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,288 @@
+package plan
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/InfluxCommunity/flux"
+)
+
+const (
+	// LogicalPhase is the phase of the planner that applies logical rules.
+	LogicalPhase = "logical"
+	// PhysicalPhase is the phase of the planner that applies physical rules.
+	PhysicalPhase = "physical"
+)
+
+// RuleApplication records that a rule rewrote the plan.
+type RuleApplication struct {
+	// Phase is the planner phase the rule was applied in.
+	Phase string
+	// Rule is the name of the rule.
+	Rule string
+	// Node is the ID of the node the rule produced.
+	Node NodeID
+}
+
+// Explanation records how the planner produced a plan.
+// The planner records the logical plan and the rules it applies
+// to the Explanation in its context, if there is one.
+type Explanation struct {
+	mu      sync.Mutex
+	phase   string
+	logical string
+	rules   []RuleApplication
+}
+
+type explanationKey struct{}
+
+// WithExplanation returns a context that makes the
+// planner record how it plans into e.
+func WithExplanation(ctx context.Context, e *Explanation) context.Context {
+	return context.WithValue(ctx, explanationKey{}, e)
+}
+
+// ExplanationFromContext returns the Explanation
+// in the context or nil if there is none.
+func ExplanationFromContext(ctx context.Context) *Explanation {
+	e, _ := ctx.Value(explanationKey{}).(*Explanation)
+	return e
+}
+
+func (e *Explanation) setPhase(phase string) {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.phase = phase
+}
+
+func (e *Explanation) recordLogicalPlan(p *Spec) {
+	if e == nil {
+		return
+	}
+	var sb strings.Builder
+	_ = FormatTree(&sb, p, nil)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.logical = sb.String()
+}
+
+func (e *Explanation) recordRule(rule string, node NodeID) {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.rules = append(e.rules, RuleApplication{
+		Phase: e.phase,
+		Rule:  rule,
+		Node:  node,
+	})
+}
+
+// LogicalPlan returns the logical plan formatted as a tree.
+func (e *Explanation) LogicalPlan() string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.logical
+}
+
+// RuleApplications returns the rules that were applied in order.
+func (e *Explanation) RuleApplications() []RuleApplication {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	rules := make([]RuleApplication, len(e.rules))
+	copy(rules, e.rules)
+	return rules
+}
+
+// Format writes the logical plan, the physical plan and the rules that
+// were applied to w. When profiles are given, the physical plan
+// includes the runtime statistics of each node.
+func (e *Explanation) Format(w io.Writer, physical *Spec, profiles []flux.TransportProfile) error {
+	rules := e.RuleApplications()
+	nodeRules := make(map[NodeID][]string)
+	for _, r := range rules {
+		nodeRules[r.Node] = append(nodeRules[r.Node], r.Rule)
+	}
+	nodeProfiles := make(map[NodeID]flux.TransportProfile)
+	for _, p := range profiles {
+		id := NodeID(p.Label)
+		np := nodeProfiles[id]
+		np.Count += p.Count
+		np.Sum += p.Sum
+		np.InputTables += p.InputTables
+		np.InputRows += p.InputRows
+		np.InputBytes += p.InputBytes
+		np.OutputTables += p.OutputTables
+		np.OutputRows += p.OutputRows
+		np.OutputBytes += p.OutputBytes
+		np.PeakBytes += p.PeakBytes
+		nodeProfiles[id] = np
+	}
+
+	if logical := e.LogicalPlan(); logical != "" {
+		if _, err := fmt.Fprintf(w, "Logical plan:\n%s\n", logical); err != nil {
+			return err
+		}
+	}
+	if _, err := io.WriteString(w, "Physical plan:\n"); err != nil {
+		return err
+	}
+	err := FormatTree(w, physical, func(n Node) []string {
+		var lines []string
+		if names, ok := nodeRules[n.ID()]; ok {
+			lines = append(lines, "rules: "+strings.Join(names, ", "))
+		}
+		if p, ok := nodeProfiles[n.ID()]; ok {
+			var mean time.Duration
+			if p.Count > 0 {
+				mean = time.Duration(p.Sum / p.Count)
+			}
+			lines = append(lines, fmt.Sprintf("time: total=%v calls=%d mean=%v", time.Duration(p.Sum), p.Count, mean))
+			if p.InputTables > 0 || p.InputRows > 0 {
+				lines = append(lines, fmt.Sprintf("input: tables=%d rows=%d bytes=%d", p.InputTables, p.InputRows, p.InputBytes))
+			}
+			if p.OutputTables > 0 || p.OutputRows > 0 {
+				lines = append(lines, fmt.Sprintf("output: tables=%d rows=%d bytes=%d", p.OutputTables, p.OutputRows, p.OutputBytes))
+			}
+			if p.PeakBytes > 0 {
+				lines = append(lines, fmt.Sprintf("memory: peak=%d", p.PeakBytes))
+			}
+		}
+		return lines
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(w, "\nRules applied:\n"); err != nil {
+		return err
+	}
+	if len(rules) == 0 {
+		_, err := io.WriteString(w, "  (none)\n")
+		return err
+	}
+	for _, r := range rules {
+		if _, err := fmt.Fprintf(w, "  %-8s  %s -> %s\n", r.Phase, r.Rule, r.Node); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FormatTree writes the plan to w as a tree that starts at the roots
+// and descends to the sources. Each node is written with its kind,
+// its physical attributes and its details. The annotate function,
+// if not nil, may add more lines for a node.
+//
+// A node with more than one successor is written in full only once.
+func FormatTree(w io.Writer, p *Spec, annotate func(n Node) []string) error {
+	roots := make([]Node, 0, len(p.Roots))
+	for root := range p.Roots {
+		roots = append(roots, root)
+	}
+	sort.Slice(roots, func(i, j int) bool {
+		return roots[i].ID() < roots[j].ID()
+	})
+
+	t := treeFormatter{
+		w:        w,
+		annotate: annotate,
+		seen:     make(map[Node]bool),
+	}
+	for _, root := range roots {
+		t.format(root, "", "")
+	}
+	return t.err
+}
+
+type treeFormatter struct {
+	w        io.Writer
+	annotate func(n Node) []string
+	seen     map[Node]bool
+	err      error
+}
+
+func (t *treeFormatter) printf(format string, args ...interface{}) {
+	if t.err != nil {
+		return
+	}
+	_, t.err = fmt.Fprintf(t.w, format, args...)
+}
+
+// format writes the node and its predecessors.
+// The first line of the node is prefixed with head and
+// every other line is prefixed with body.
+func (t *treeFormatter) format(n Node, head, body string) {
+	line := fmt.Sprintf("%s (%s)", n.ID(), n.Kind())
+	if ppn, ok := n.(*PhysicalPlanNode); ok {
+		if attrs := formatAttributes(ppn.outputAttrs()); attrs != "" {
+			line += " " + attrs
+		}
+	}
+	if t.seen[n] {
+		t.printf("%s%s (see above)\n", head, line)
+		return
+	}
+	t.seen[n] = true
+	t.printf("%s%s\n", head, line)
+
+	preds := n.Predecessors()
+	indent := body + "   "
+	if len(preds) > 0 {
+		indent = body + "│  "
+	}
+	for _, detail := range nodeDetails(n, t.annotate) {
+		t.printf("%s%s\n", indent, detail)
+	}
+	for i, pred := range preds {
+		if i == len(preds)-1 {
+			t.format(pred, body+"└─ ", body+"   ")
+		} else {
+			t.format(pred, body+"├─ ", body+"│  ")
+		}
+	}
+}
+
+// nodeDetails returns the details of the procedure spec
+// and the lines added by annotate for the node.
+func nodeDetails(n Node, annotate func(n Node) []string) []string {
+	var lines []string
+	if d, ok := n.ProcedureSpec().(Detailer); ok {
+		for _, line := range strings.Split(strings.TrimSpace(d.PlanDetails()), "\n") {
+			if line != "" {
+				lines = append(lines, line)
+			}
+		}
+	}
+	if annotate != nil {
+		lines = append(lines, annotate(n)...)
+	}
+	return lines
+}
+
+// formatAttributes formats the physical attributes sorted by key.
+func formatAttributes(attrs PhysicalAttributes) string {
+	if len(attrs) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(attrs))
+	for key := range attrs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = attrs[key].String()
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
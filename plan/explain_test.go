@@ -0,0 +1,106 @@
+package plan_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/plan"
+	"github.com/InfluxCommunity/flux/plan/plantest"
+	"github.com/InfluxCommunity/flux/plan/plantest/spec"
+	"github.com/andreyvit/diff"
+)
+
+func TestFormatTree(t *testing.T) {
+	ps := plantest.CreatePlanSpec(&plantest.PlanSpec{
+		Nodes: []plan.Node{
+			plantest.CreatePhysicalNode("source", spec.MockProcedureSpec{
+				OutputAttributesFn: func() plan.PhysicalAttributes {
+					return plan.PhysicalAttributes{plan.ParallelMergeKey: plan.ParallelMergeAttribute{Factor: 8}}
+				},
+				PlanDetailsFn: func() string {
+					return "*** spec details ***"
+				},
+			}),
+			plantest.CreatePhysicalNode("left", spec.MockProcedureSpec{}),
+			plantest.CreatePhysicalNode("right", spec.MockProcedureSpec{}),
+			plantest.CreatePhysicalNode("join", spec.MockProcedureSpec{}),
+		},
+		Edges: [][2]int{
+			{0, 1},
+			{0, 2},
+			{1, 3},
+			{2, 3},
+		},
+	})
+
+	var sb strings.Builder
+	if err := plan.FormatTree(&sb, ps, nil); err != nil {
+		t.Fatal(err)
+	}
+	want := `join (mock)
+├─ left (mock)
+│  └─ source (mock) [parallel-merge{Factor: 8}]
+│        *** spec details ***
+└─ right (mock)
+   └─ source (mock) [parallel-merge{Factor: 8}] (see above)
+`
+	if got := sb.String(); want != got {
+		t.Fatalf("unexpected output: -want/+got:\n%v", diff.LineDiff(want, got))
+	}
+}
+
+func TestExplanation(t *testing.T) {
+	ps := plantest.CreatePlanSpec(&plantest.PlanSpec{
+		Nodes: []plan.Node{
+			plan.CreateLogicalNode("from", spec.MockProcedureSpec{}),
+			plan.CreateLogicalNode("filter", spec.MockProcedureSpec{}),
+		},
+		Edges: [][2]int{
+			{0, 1},
+		},
+	})
+
+	applied := false
+	rule := &plantest.FunctionRule{
+		RewriteFn: func(ctx context.Context, node plan.Node) (plan.Node, bool, error) {
+			if applied || node.ID() != "filter" {
+				return node, false, nil
+			}
+			applied = true
+			return node, true, nil
+		},
+	}
+
+	e := &plan.Explanation{}
+	ctx := plan.WithExplanation(context.Background(), e)
+	ps, err := plan.NewLogicalPlanner(plan.OnlyLogicalRules(rule)).Plan(ctx, ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sb strings.Builder
+	profiles := []flux.TransportProfile{
+		{Label: "filter", Count: 1, Sum: 1000, InputTables: 1, InputRows: 5, InputBytes: 40, OutputTables: 1, OutputRows: 2, OutputBytes: 16, PeakBytes: 64},
+		{Label: "filter", Count: 1, Sum: 2000, InputTables: 1, InputRows: 5, InputBytes: 40},
+	}
+	if err := e.Format(&sb, ps, profiles); err != nil {
+		t.Fatal(err)
+	}
+	want := `Physical plan:
+filter (mock)
+│  rules: function
+│  time: total=3µs calls=2 mean=1.5µs
+│  input: tables=2 rows=10 bytes=80
+│  output: tables=1 rows=2 bytes=16
+│  memory: peak=64
+└─ from (mock)
+
+Rules applied:
+            function -> filter
+`
+	if got := sb.String(); want != got {
+		t.Fatalf("unexpected output: -want/+got:\n%v", diff.LineDiff(want, got))
+	}
+}
@@ -56,6 +56,7 @@ func applyRule(ctx context.Context, spec *Spec, rule Rule, node Node) (Node, boo
 			)
 		}
 		testing.MarkInvokedPlannerRule(ctx, rule.Name())
+		ExplanationFromContext(ctx).recordRule(rule.Name(), newNode.ID())
 		if err := updateSuccessors(spec, node, newNode); err != nil {
 			return node, false, errors.Wrap(
 				err,
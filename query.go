@@ -132,6 +132,28 @@ type TransportProfile struct {
 
 	// Mean is the mean span time of this profile.
 	Mean float64 `json:"mean"`
+
+	// InputTables holds the number of tables received by the node.
+	InputTables int64 `json:"input_tables"`
+
+	// InputRows holds the number of rows received by the node.
+	InputRows int64 `json:"input_rows"`
+
+	// InputBytes holds the number of bytes of arrow data received by the node.
+	InputBytes int64 `json:"input_bytes"`
+
+	// OutputTables holds the number of tables produced by the node.
+	OutputTables int64 `json:"output_tables"`
+
+	// OutputRows holds the number of rows produced by the node.
+	OutputRows int64 `json:"output_rows"`
+
+	// OutputBytes holds the number of bytes of arrow data produced by the node.
+	OutputBytes int64 `json:"output_bytes"`
+
+	// PeakBytes holds the maximum number of bytes of memory
+	// that were allocated by the node at the same time.
+	PeakBytes int64 `json:"peak_bytes"`
 }
 
 // StartSpan will start a profile span to be recorded.
@@ -0,0 +1,231 @@
+package repl
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/dependency"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/lang"
+	"github.com/InfluxCommunity/flux/libflux/go/libflux"
+	"github.com/InfluxCommunity/flux/plan"
+	"github.com/InfluxCommunity/flux/runtime"
+	"github.com/InfluxCommunity/flux/semantic"
+)
+
+// typeVariable is the variable that :type assigns the expression to
+// so that its type can be read from the analyzed package.
+const typeVariable = "_replType"
+
+type command struct {
+	usage string
+	help  string
+	run   func(r *REPL, arg string) (*libflux.FluxError, error)
+}
+
+// commands are the meta-commands of the REPL. A line that starts
+// with a colon followed by the name of a command runs the command.
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"help": {
+			usage: ":help",
+			help:  "Show the available commands",
+			run:   (*REPL).helpCommand,
+		},
+		"load": {
+			usage: ":load <file>",
+			help:  "Evaluate the Flux file",
+			run:   (*REPL).loadCommand,
+		},
+		"reload": {
+			usage: ":reload",
+			help:  "Evaluate the file last loaded with :load again",
+			run:   (*REPL).reloadCommand,
+		},
+		"type": {
+			usage: ":type <expression>",
+			help:  "Show the inferred type of the expression",
+			run:   (*REPL).typeCommand,
+		},
+		"plan": {
+			usage: ":plan <expression>",
+			help:  "Show the logical and physical plan of the query",
+			run:   (*REPL).planCommand,
+		},
+		"profile": {
+			usage: ":profile on|off",
+			help:  "Show the plan with the statistics of each node after every query",
+			run:   (*REPL).profileCommand,
+		},
+		"history": {
+			usage: ":history [n]",
+			help:  "Show the last n lines that were entered, or all of them",
+			run:   (*REPL).historyCommand,
+		},
+		"format": {
+			usage: ":format cli|csv|json",
+			help:  "Set the format that the results of queries are written in",
+			run:   (*REPL).formatCommand,
+		},
+	}
+}
+
+// isCommand reports whether the line runs a meta-command.
+// No Flux statement starts with a colon.
+func isCommand(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), ":")
+}
+
+// executeCommand runs the meta-command on the line.
+func (r *REPL) executeCommand(line string) (*libflux.FluxError, error) {
+	name, arg, _ := strings.Cut(strings.TrimSpace(line)[1:], " ")
+	cmd, ok := commands[name]
+	if !ok {
+		return nil, errors.Newf(codes.Invalid, "unknown command %q, use :help to list the commands", ":"+name)
+	}
+	return cmd.run(r, strings.TrimSpace(arg))
+}
+
+func (r *REPL) helpCommand(string) (*libflux.FluxError, error) {
+	names := make([]string, 0, len(commands))
+	width := 0
+	for name, cmd := range commands {
+		names = append(names, name)
+		if len(cmd.usage) > width {
+			width = len(cmd.usage)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		cmd := commands[name]
+		fmt.Printf("  %-*s  %s\n", width, cmd.usage, cmd.help)
+	}
+	fmt.Println("\nLines that end with an unclosed bracket or an operator, such as |>, start a multiline input.")
+	fmt.Println("The following lines, such as those that start with |>, are added to it until an empty line is entered.")
+	return nil, nil
+}
+
+func (r *REPL) loadCommand(path string) (*libflux.FluxError, error) {
+	if path == "" {
+		return nil, errors.New(codes.Invalid, "usage: :load <file>")
+	}
+	r.loaded = path
+	return r.reloadCommand("")
+}
+
+func (r *REPL) reloadCommand(string) (*libflux.FluxError, error) {
+	if r.loaded == "" {
+		return nil, errors.New(codes.FailedPrecondition, "no file has been loaded, use :load <file>")
+	}
+	src, err := os.ReadFile(r.loaded)
+	if err != nil {
+		return nil, err
+	}
+	return r.execute(string(src))
+}
+
+func (r *REPL) typeCommand(expr string) (*libflux.FluxError, error) {
+	if expr == "" {
+		return nil, errors.New(codes.Invalid, "usage: :type <expression>")
+	}
+	pkg, fluxError, err := r.analyzeLine(typeVariable + " = " + expr)
+	if err != nil {
+		return fluxError, err
+	}
+	for _, file := range pkg.Files {
+		for _, stmt := range file.Body {
+			if va, ok := stmt.(*semantic.NativeVariableAssignment); ok && va.Identifier.Name.Name() == typeVariable {
+				fmt.Println(va.Typ.String())
+				return nil, nil
+			}
+		}
+	}
+	return nil, errors.New(codes.Internal, "the type of the expression was not found")
+}
+
+func (r *REPL) planCommand(expr string) (*libflux.FluxError, error) {
+	if expr == "" {
+		return nil, errors.New(codes.Invalid, "usage: :plan <expression>")
+	}
+	ses, fluxError, err := r.evalWithFluxError(expr)
+	if err != nil {
+		return fluxError, err
+	}
+	for _, se := range ses {
+		to, ok := se.Value.(*flux.TableObject)
+		if !ok {
+			continue
+		}
+		spec, err := r.tableSpec(to)
+		if err != nil {
+			return nil, err
+		}
+		e := &plan.Explanation{}
+		ctx := plan.WithExplanation(context.WithValue(r.ctx, plan.NextPlanNodeIDKey, new(int)), e)
+		ctx, span := dependency.Inject(ctx, execute.DefaultExecutionDependencies())
+		program, err := Compiler{Spec: spec}.Compile(ctx, runtime.Default)
+		span.Finish()
+		if err != nil {
+			return nil, err
+		}
+		if err := e.Format(os.Stdout, program.(*lang.Program).PlanSpec, nil); err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+func (r *REPL) profileCommand(arg string) (*libflux.FluxError, error) {
+	switch arg {
+	case "on":
+		r.profile = true
+	case "off":
+		r.profile = false
+	case "":
+		state := "off"
+		if r.profile {
+			state = "on"
+		}
+		fmt.Println("profile", state)
+	default:
+		return nil, errors.New(codes.Invalid, "usage: :profile on|off")
+	}
+	return nil, nil
+}
+
+func (r *REPL) historyCommand(arg string) (*libflux.FluxError, error) {
+	n := 0
+	if arg != "" {
+		var err error
+		if n, err = strconv.Atoi(arg); err != nil || n < 0 {
+			return nil, errors.New(codes.Invalid, "usage: :history [n]")
+		}
+	}
+	lines := r.history.last(n)
+	first := len(r.history.lines) - len(lines) + 1
+	for i, line := range lines {
+		fmt.Printf("%5d  %s\n", first+i, line)
+	}
+	return nil, nil
+}
+
+func (r *REPL) formatCommand(arg string) (*libflux.FluxError, error) {
+	if arg == "" {
+		fmt.Println("format", r.format)
+		return nil, nil
+	}
+	if !validFormat(arg) {
+		return nil, errors.Newf(codes.Invalid, "unknown format %q, valid formats are %v", arg, Formats)
+	}
+	r.format = arg
+	return nil, nil
+}
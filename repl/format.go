@@ -0,0 +1,139 @@
+package repl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/csv"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/semantic"
+	"github.com/InfluxCommunity/flux/values"
+)
+
+// The formats that results can be written in.
+const (
+	// FormatCLI writes each table as a human readable grid.
+	FormatCLI = "cli"
+	// FormatCSV writes the results as annotated CSV.
+	FormatCSV = "csv"
+	// FormatJSON writes each table as a JSON object on its own line.
+	FormatJSON = "json"
+)
+
+// Formats lists the supported output formats.
+var Formats = []string{FormatCLI, FormatCSV, FormatJSON}
+
+func validFormat(format string) bool {
+	for _, f := range Formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteResults writes the results to w in the given format.
+func WriteResults(w io.Writer, results flux.ResultIterator, format string) error {
+	switch format {
+	case FormatCLI:
+		for results.More() {
+			res := results.Next()
+			fmt.Fprintln(w, "Result:", res.Name())
+			if err := res.Tables().Do(func(tbl flux.Table) error {
+				_, err := execute.NewFormatter(tbl, nil).WriteTo(w)
+				return err
+			}); err != nil {
+				return err
+			}
+		}
+	case FormatCSV:
+		encoder := csv.NewMultiResultEncoder(csv.DefaultEncoderConfig())
+		if _, err := encoder.Encode(w, results); err != nil {
+			return err
+		}
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		for results.More() {
+			res := results.Next()
+			table := 0
+			if err := res.Tables().Do(func(tbl flux.Table) error {
+				t, err := newJSONTable(res.Name(), table, tbl)
+				if err != nil {
+					return err
+				}
+				table++
+				return enc.Encode(t)
+			}); err != nil {
+				return err
+			}
+		}
+	default:
+		return errors.Newf(codes.Invalid, "unknown format %q, valid formats are %v", format, Formats)
+	}
+	return results.Err()
+}
+
+type jsonTable struct {
+	Result  string          `json:"result"`
+	Table   int             `json:"table"`
+	Columns []jsonColumn    `json:"columns"`
+	Rows    [][]interface{} `json:"rows"`
+}
+
+type jsonColumn struct {
+	Label string `json:"label"`
+	Type  string `json:"type"`
+	Group bool   `json:"group"`
+}
+
+func newJSONTable(result string, table int, tbl flux.Table) (*jsonTable, error) {
+	t := &jsonTable{
+		Result:  result,
+		Table:   table,
+		Columns: make([]jsonColumn, len(tbl.Cols())),
+		Rows:    [][]interface{}{},
+	}
+	for j, c := range tbl.Cols() {
+		t.Columns[j] = jsonColumn{
+			Label: c.Label,
+			Type:  c.Type.String(),
+			Group: tbl.Key().HasCol(c.Label),
+		}
+	}
+	err := tbl.Do(func(cr flux.ColReader) error {
+		for i := 0; i < cr.Len(); i++ {
+			row := make([]interface{}, len(cr.Cols()))
+			for j := range cr.Cols() {
+				row[j] = jsonValue(execute.ValueForRow(cr, i, j))
+			}
+			t.Rows = append(t.Rows, row)
+		}
+		return nil
+	})
+	return t, err
+}
+
+// jsonValue converts a column value to the value it is encoded as.
+// Times are encoded in RFC3339 with nanoseconds and floats that
+// JSON cannot represent, such as NaN, are encoded as strings.
+func jsonValue(v values.Value) interface{} {
+	if v.IsNull() {
+		return nil
+	}
+	switch v.Type().Nature() {
+	case semantic.Time:
+		return v.Time().Time().Format(time.RFC3339Nano)
+	case semantic.Float:
+		if f := v.Float(); math.IsNaN(f) || math.IsInf(f, 0) {
+			return strconv.FormatFloat(f, 'f', -1, 64)
+		}
+	}
+	return values.Unwrap(v)
+}
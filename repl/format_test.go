@@ -0,0 +1,50 @@
+package repl
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/execute/executetest"
+	"github.com/InfluxCommunity/flux/values"
+)
+
+func TestWriteResults_JSON(t *testing.T) {
+	result := &executetest.Result{
+		Nm: "_result",
+		Tbls: []*executetest.Table{{
+			KeyCols: []string{"t0"},
+			ColMeta: []flux.ColMeta{
+				{Label: "_time", Type: flux.TTime},
+				{Label: "t0", Type: flux.TString},
+				{Label: "_value", Type: flux.TFloat},
+			},
+			Data: [][]interface{}{
+				{values.ConvertTime(time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)), "a", 1.5},
+				{values.ConvertTime(time.Date(2021, 1, 1, 0, 0, 10, 0, time.UTC)), "a", math.NaN()},
+				{nil, "a", nil},
+			},
+		}},
+	}
+
+	var buf bytes.Buffer
+	results := flux.NewSliceResultIterator([]flux.Result{result})
+	if err := WriteResults(&buf, results, FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"result":"_result","table":0,"columns":[{"label":"_time","type":"time","group":false},{"label":"t0","type":"string","group":true},{"label":"_value","type":"float","group":false}],"rows":[["2021-01-01T00:00:00Z","a",1.5],["2021-01-01T00:00:10Z","a","NaN"],[null,"a",null]]}
+`
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected output:\nwant: %s\ngot:  %s", want, got)
+	}
+}
+
+func TestWriteResults_UnknownFormat(t *testing.T) {
+	results := flux.NewSliceResultIterator(nil)
+	if err := WriteResults(&bytes.Buffer{}, results, "xml"); err == nil {
+		t.Fatal("expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,75 @@
+package repl
+
+import (
+	"bufio"
+	"errors"
+	"io/fs"
+	"os"
+)
+
+// maxHistory is the number of lines loaded from the history file.
+const maxHistory = 1000
+
+// history keeps the lines entered in the REPL.
+// The lines are appended to a file, if it is set,
+// so that they are available in the next session.
+type history struct {
+	path  string
+	lines []string
+}
+
+// loadHistory reads the most recent lines of the history file.
+// A missing file is treated as an empty history.
+func loadHistory(path string) (*history, error) {
+	h := &history{path: path}
+	if path == "" {
+		return h, nil
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return h, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		h.lines = append(h.lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(h.lines) > maxHistory {
+		h.lines = h.lines[len(h.lines)-maxHistory:]
+	}
+	return h, nil
+}
+
+// add records a line and appends it to the history file.
+func (h *history) add(line string) error {
+	if line == "" {
+		return nil
+	}
+	h.lines = append(h.lines, line)
+	if h.path == "" {
+		return nil
+	}
+	f, err := os.OpenFile(h.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(line + "\n"); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// last returns the n most recent lines or all of them if n is not positive.
+func (h *history) last(n int) []string {
+	if n <= 0 || n > len(h.lines) {
+		return h.lines
+	}
+	return h.lines[len(h.lines)-n:]
+}
@@ -0,0 +1,64 @@
+package repl
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".flux_history")
+
+	h, err := loadHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(h.lines) != 0 {
+		t.Fatalf("unexpected lines in new history: %v", h.lines)
+	}
+	for _, line := range []string{`import "array"`, ``, `x = 1`, `x + 1`} {
+		if err := h.add(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	h, err = loadHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := []string{`import "array"`, `x = 1`, `x + 1`}, h.lines; !cmp.Equal(want, got) {
+		t.Errorf("unexpected lines -want/+got:\n%s", cmp.Diff(want, got))
+	}
+	if want, got := []string{`x = 1`, `x + 1`}, h.last(2); !cmp.Equal(want, got) {
+		t.Errorf("unexpected last lines -want/+got:\n%s", cmp.Diff(want, got))
+	}
+	if want, got := 3, len(h.last(0)); want != got {
+		t.Errorf("unexpected number of lines: want %d, got %d", want, got)
+	}
+}
+
+func TestHistory_Limit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".flux_history")
+	var sb strings.Builder
+	for i := 0; i < maxHistory+10; i++ {
+		sb.WriteString(strconv.Itoa(i) + "\n")
+	}
+	if err := os.WriteFile(path, []byte(sb.String()), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	h, err := loadHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := maxHistory, len(h.lines); want != got {
+		t.Fatalf("unexpected number of lines: want %d, got %d", want, got)
+	}
+	if want, got := "10", h.lines[0]; want != got {
+		t.Errorf("unexpected first line: want %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,43 @@
+package repl
+
+import (
+	"strings"
+
+	"github.com/InfluxCommunity/flux/libflux/go/libflux"
+)
+
+// isIncomplete reports whether src is the beginning of a Flux program
+// that continues on the next line. This is the case when the parser
+// only finds errors at the end of the input, such as brackets or strings
+// that are not closed yet or an operator or keyword that needs an
+// expression after it.
+//
+// Programs with any other error are not incomplete
+// so that the error is reported.
+func isIncomplete(src string) bool {
+	if strings.TrimSpace(src) == "" {
+		return false
+	}
+
+	// The errors are read from the libflux AST because the Go AST
+	// drops the invalid expressions that are missing at the end.
+	pkg := libflux.ParseString(src)
+	defer pkg.Free()
+	err := pkg.GetError(libflux.Options{})
+	if err == nil {
+		return false
+	}
+	for _, msg := range strings.Split(err.Error(), "\n\n") {
+		if !isEOFError(strings.TrimSpace(msg)) {
+			return false
+		}
+	}
+	return true
+}
+
+// isEOFError reports whether the parser error is caused
+// by reaching the end of the input.
+func isEOFError(msg string) bool {
+	return strings.HasSuffix(msg, "got EOF") ||
+		strings.HasSuffix(msg, "primary expression: EOF")
+}
@@ -0,0 +1,45 @@
+package repl
+
+import "testing"
+
+func TestIsIncomplete(t *testing.T) {
+	for _, tc := range []struct {
+		src  string
+		want bool
+	}{
+		{src: `x = 1`, want: false},
+		{src: `from(bucket: "b")`, want: false},
+		{src: `from(bucket: "b") |>`, want: true},
+		{src: "from(bucket: \"b\")\n  |> range(start: -1h) |> ", want: true},
+		{src: `from(`, want: true},
+		{src: `f = (r) => {`, want: true},
+		{src: `f = (r) =>`, want: true},
+		{src: `x = [1, 2,`, want: true},
+		{src: `x = `, want: true},
+		{src: `x = 1 and`, want: true},
+		{src: `x = band`, want: false},
+		{src: `x = if true then 1 else`, want: true},
+		{src: `x = "abc`, want: true},
+		{src: `x = "a\"bc`, want: true},
+		{src: `x = "a\"bc"`, want: false},
+		{src: `x = "${y`, want: true},
+		{src: `x = "${y}"`, want: false},
+		{src: `x = "${"}"}"`, want: false},
+		{src: `x = "(" // (`, want: false},
+		{src: `x = 1 // |>`, want: false},
+		{src: `x = /\(/`, want: false},
+		{src: `x = /[(]/`, want: false},
+		{src: `x = /"/`, want: false},
+		{src: `x = 1 -`, want: true},
+		{src: `x = 2 *`, want: true},
+		{src: `x = -`, want: true},
+		{src: `x = 1 - 2`, want: false},
+		{src: `x = (1 +) * 2`, want: false},
+		{src: `x = 1)`, want: false},
+		{src: `x = 1 }`, want: false},
+	} {
+		if got := isIncomplete(tc.src); got != tc.want {
+			t.Errorf("isIncomplete(%q) = %v, want %v", tc.src, got, tc.want)
+		}
+	}
+}
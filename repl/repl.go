@@ -18,6 +18,7 @@ import (
 	"github.com/InfluxCommunity/flux/execute"
 	"github.com/InfluxCommunity/flux/internal/operation"
 	"github.com/InfluxCommunity/flux/internal/spec"
+	"github.com/InfluxCommunity/flux/internal/telemetry"
 	"github.com/InfluxCommunity/flux/interpreter"
 	"github.com/InfluxCommunity/flux/lang"
 	"github.com/InfluxCommunity/flux/libflux/go/libflux"
@@ -27,7 +28,7 @@ import (
 	"github.com/InfluxCommunity/flux/semantic"
 	"github.com/InfluxCommunity/flux/values"
 	"github.com/c-bata/go-prompt"
-	"github.com/opentracing/opentracing-go"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type REPL struct {
@@ -44,6 +45,11 @@ type REPL struct {
 	lineBuf string
 
 	enableSuggestions bool
+
+	format  string
+	profile bool
+	loaded  string
+	history *history
 }
 
 type Option interface {
@@ -72,6 +78,8 @@ func New(ctx context.Context, opts ...Option) *REPL {
 		itrp:     interpreter.NewInterpreter(nil, &lang.ExecOptsConfig{}),
 		analyzer: analyzer,
 		importer: importer,
+		format:   FormatCLI,
+		history:  &history{},
 	}
 	for _, opt := range opts {
 		opt.applyOption(repl)
@@ -84,7 +92,9 @@ func (r *REPL) Run() {
 		r.input,
 		r.completer,
 		prompt.OptionPrefix("> "),
+		prompt.OptionLivePrefix(r.prefix),
 		prompt.OptionTitle("flux"),
+		prompt.OptionHistory(r.history.lines),
 	)
 	sigs := make(chan os.Signal, 1)
 	signal.Notify(sigs, syscall.SIGINT)
@@ -96,6 +106,14 @@ func (r *REPL) Run() {
 	p.Run()
 }
 
+// prefix returns the prompt for lines that continue an unfinished input.
+func (r *REPL) prefix() (string, bool) {
+	if r.lineBuf != "" {
+		return "... ", true
+	}
+	return "", false
+}
+
 func (r *REPL) cancel() {
 	r.cancelMu.Lock()
 	defer r.cancelMu.Unlock()
@@ -156,11 +174,19 @@ func (r *REPL) Input(t string) (*libflux.FluxError, error) {
 
 // input processes a line of input and prints the result.
 func (r *REPL) input(t string) {
-	// Create a root span
-	span := opentracing.StartSpan("REPL.input")
-	r.ctx = opentracing.ContextWithSpan(r.ctx, span)
-	defer span.Finish()
+	// Create a root span for the line and restore
+	// the context once the line has been processed.
+	ctx := r.ctx
+	var span trace.Span
+	r.ctx, span = telemetry.StartSpan(ctx, "REPL.input")
+	defer func() {
+		span.End()
+		r.ctx = ctx
+	}()
 
+	if err := r.history.add(t); err != nil {
+		fmt.Println("Error: failed to save history:", err)
+	}
 	if fluxError, err := r.executeLine(t); err != nil {
 		if fluxError != nil {
 			fluxError.Print()
@@ -202,14 +228,38 @@ func (r *REPL) evalWithFluxError(t string) ([]interpreter.SideEffect, *libflux.F
 
 // executeLine processes a line of input.
 // If the input evaluates to a valid value, that value is returned.
+//
+// Lines that end with a backslash or that leave the input incomplete,
+// such as an unclosed bracket or a trailing pipe, start a multiline input.
+// The lines that follow, including those that start with a pipe, are added
+// to it until an empty line is entered, which evaluates the input.
 func (r *REPL) executeLine(t string) (*libflux.FluxError, error) {
-	t = r.lineBuf + t
+	if r.lineBuf == "" && isCommand(t) {
+		return r.executeCommand(t)
+	}
+
+	if r.lineBuf != "" {
+		if strings.TrimSpace(t) == "" {
+			t, r.lineBuf = r.lineBuf, ""
+			return r.execute(t)
+		}
+		r.lineBuf += strings.TrimSuffix(t, "\\") + "\n"
+		return nil, nil
+	}
 	if strings.HasSuffix(t, "\\") {
 		r.lineBuf = t[:len(t)-1] + "\n"
 		return nil, nil
 	}
-	r.lineBuf = ""
+	if isIncomplete(t) {
+		r.lineBuf = t + "\n"
+		return nil, nil
+	}
+	return r.execute(t)
+}
 
+// execute evaluates the Flux source, runs the queries of
+// its expression statements and displays their other values.
+func (r *REPL) execute(t string) (*libflux.FluxError, error) {
 	ses, fluxError, err := r.evalWithFluxError(t)
 	if err != nil {
 		return fluxError, err
@@ -218,15 +268,7 @@ func (r *REPL) executeLine(t string) (*libflux.FluxError, error) {
 	for _, se := range ses {
 		if _, ok := se.Node.(*semantic.ExpressionStatement); ok {
 			if t, ok := se.Value.(*flux.TableObject); ok {
-				now, ok := r.scope.Lookup("now")
-				if !ok {
-					return nil, fmt.Errorf("now option not set")
-				}
-				nowTime, err := now.Function().Call(r.ctx, nil)
-				if err != nil {
-					return nil, err
-				}
-				s, err := spec.FromTableObject(r.ctx, t, nowTime.Time().Time())
+				s, err := r.tableSpec(t)
 				if err != nil {
 					return nil, err
 				}
@@ -242,6 +284,19 @@ func (r *REPL) executeLine(t string) (*libflux.FluxError, error) {
 	return nil, nil
 }
 
+// tableSpec builds the query spec of a table object using the now option.
+func (r *REPL) tableSpec(t *flux.TableObject) (*operation.Spec, error) {
+	now, ok := r.scope.Lookup("now")
+	if !ok {
+		return nil, fmt.Errorf("now option not set")
+	}
+	nowTime, err := now.Function().Call(r.ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return spec.FromTableObject(r.ctx, t, nowTime.Time().Time())
+}
+
 func (r *REPL) analyzeLine(t string) (*semantic.Package, *libflux.FluxError, error) {
 	pkg, fluxError := r.analyzer.AnalyzeString(t)
 	if fluxError != nil {
@@ -266,6 +321,12 @@ func (r *REPL) doQuery(ctx context.Context, spec *operation.Spec) error {
 	defer cancelFunc()
 	defer r.clearCancel()
 
+	var e *plan.Explanation
+	if r.profile {
+		e = &plan.Explanation{}
+		ctx = plan.WithExplanation(ctx, e)
+	}
+
 	c := Compiler{
 		Spec: spec,
 	}
@@ -280,20 +341,31 @@ func (r *REPL) doQuery(ctx context.Context, spec *operation.Spec) error {
 	if err != nil {
 		return err
 	}
-	defer qry.Done()
+	results := flux.NewResultIteratorFromQuery(qry)
+	defer results.Release()
 
-	for result := range qry.Results() {
-		tables := result.Tables()
-		fmt.Println("Result:", result.Name())
-		if err := tables.Do(func(tbl flux.Table) error {
-			_, err := execute.NewFormatter(tbl, nil).WriteTo(os.Stdout)
-			return err
-		}); err != nil {
-			return err
-		}
+	if err := WriteResults(os.Stdout, results, r.format); err != nil {
+		return err
+	}
+	results.Release()
+	if err := results.Err(); err != nil {
+		return err
+	}
+	if e != nil {
+		return writeProfile(os.Stdout, e, program.(*lang.Program).PlanSpec, results.Statistics())
+	}
+	return nil
+}
+
+// writeProfile writes the plan of a query with the statistics of each node
+// followed by the statistics of the whole execution.
+func writeProfile(w io.Writer, e *plan.Explanation, ps *plan.Spec, stats flux.Statistics) error {
+	if err := e.Format(w, ps, stats.Profiles); err != nil {
+		return err
 	}
-	qry.Done()
-	return qry.Err()
+	_, err := fmt.Fprintf(w, "\nExecution:\n  duration: %v\n  concurrency: %d\n  max allocated: %d bytes\n  total allocated: %d bytes\n",
+		stats.ExecuteDuration, stats.Concurrency, stats.MaxAllocated, stats.TotalAllocated)
+	return err
 }
 
 func getFluxFiles(path string) ([]string, error) {
@@ -351,3 +423,25 @@ func EnableSuggestions() Option {
 		r.enableSuggestions = true
 	})
 }
+
+// WithHistoryFile loads the history from the file
+// and appends the lines that are entered to it.
+func WithHistoryFile(path string) Option {
+	return option(func(r *REPL) {
+		h, err := loadHistory(path)
+		if err != nil {
+			fmt.Println("Error: failed to load history:", err)
+			h = &history{path: path}
+		}
+		r.history = h
+	})
+}
+
+// WithFormat sets the format that the results of queries are written in.
+func WithFormat(format string) Option {
+	return option(func(r *REPL) {
+		if validFormat(format) {
+			r.format = format
+		}
+	})
+}
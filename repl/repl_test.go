@@ -4,7 +4,7 @@ import (
 	"context"
 	"testing"
 
-	"github.com/influxdata/flux/fluxinit"
+	"github.com/InfluxCommunity/flux/fluxinit"
 	"github.com/stretchr/testify/require"
 )
 
@@ -25,3 +25,78 @@ func TestReplNewLine(t *testing.T) {
 	require.Nil(t, errI)
 	require.Nil(t, err)
 }
+
+func TestReplMultiline(t *testing.T) {
+	ctx := context.TODO()
+	fluxinit.FluxInit()
+
+	r := New(ctx)
+
+	// A complete line is evaluated right away.
+	errI, err := r.executeLine(`import "sampledata"`)
+	require.Nil(t, errI)
+	require.Nil(t, err)
+	require.Empty(t, r.lineBuf)
+
+	// An incomplete line starts a multiline input that is
+	// evaluated once an empty line is entered, even when
+	// the lines before it form a complete program.
+	for _, line := range []string{
+		`f = (x) => {`,
+		`return x + 1 }`,
+		`sampledata.int() |>`,
+		`  filter(fn: (r) =>`,
+		`    r._value > 0)`,
+		`  |> sum()`,
+		`  |> map(fn: (r) => ({r with _value: f(x: r._value)}))`,
+	} {
+		errI, err := r.executeLine(line)
+		require.Nil(t, errI)
+		require.Nil(t, err)
+		require.NotEmpty(t, r.lineBuf, line)
+	}
+	errI, err = r.executeLine(``)
+	require.Nil(t, errI)
+	require.Nil(t, err)
+	require.Empty(t, r.lineBuf)
+
+	// A line that ends with a backslash also starts a multiline input.
+	for _, line := range []string{`x = 1 +\`, `  2`} {
+		errI, err := r.executeLine(line)
+		require.Nil(t, errI)
+		require.Nil(t, err)
+		require.NotEmpty(t, r.lineBuf, line)
+	}
+	errI, err = r.executeLine(`  `)
+	require.Nil(t, errI)
+	require.Nil(t, err)
+	require.Empty(t, r.lineBuf)
+}
+
+func TestReplCommands(t *testing.T) {
+	ctx := context.TODO()
+	fluxinit.FluxInit()
+
+	r := New(ctx)
+	for _, line := range []string{
+		`:format json`,
+		`:profile on`,
+		`:type 1 + 2`,
+		`import "sampledata"`,
+		`:plan sampledata.int() |> sum()`,
+		`sampledata.int() |> sum()`,
+	} {
+		errI, err := r.executeLine(line)
+		require.Nil(t, errI)
+		require.Nil(t, err, line)
+	}
+	require.Equal(t, FormatJSON, r.format)
+	require.True(t, r.profile)
+
+	_, err := r.executeLine(`:format xml`)
+	require.Error(t, err)
+	_, err = r.executeLine(`:unknown`)
+	require.Error(t, err)
+	_, err = r.executeLine(`:reload`)
+	require.Error(t, err)
+}
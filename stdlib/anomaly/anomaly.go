@@ -0,0 +1,333 @@
+package anomaly
+
+import (
+	"math"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/array"
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/internal/anomaly"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/internal/execute/table"
+	"github.com/InfluxCommunity/flux/plan"
+	"github.com/InfluxCommunity/flux/runtime"
+	"github.com/apache/arrow/go/v7/arrow/memory"
+)
+
+const pkgpath = "anomaly"
+
+// Methods are the names of the detectors of the package.
+// Each detector is its own kind of operation.
+const (
+	MethodZScore = "zscore"
+	MethodEWMA   = "ewma"
+	MethodMAD    = "mad"
+	MethodIQR    = "iqr"
+	MethodCUSUM  = "cusum"
+)
+
+// Labels of the columns added by the detectors.
+const (
+	ScoreColLabel   = "score"
+	AnomalyColLabel = "anomaly"
+	LowerColLabel   = "lower"
+	UpperColLabel   = "upper"
+)
+
+// defaultThresholds are the default thresholds of the scores of each method.
+var defaultThresholds = map[string]float64{
+	MethodZScore: 3,
+	MethodEWMA:   3,
+	// Iglewicz and Hoaglin recommend 3.5 for modified z-scores.
+	MethodMAD: 3.5,
+	// Tukey's fences.
+	MethodIQR:   1.5,
+	MethodCUSUM: 5,
+}
+
+// DefaultDrift is the default drift of CUSUM in standard deviations.
+const DefaultDrift = 0.5
+
+var methods = []string{
+	MethodZScore,
+	MethodEWMA,
+	MethodMAD,
+	MethodIQR,
+	MethodCUSUM,
+}
+
+func init() {
+	for _, method := range methods {
+		kind := Kind(method)
+		signature := runtime.MustLookupBuiltinType(pkgpath, method)
+		runtime.RegisterPackageValue(pkgpath, method, flux.MustValue(flux.FunctionValue(method, newCreateOpSpec(method), signature)))
+		plan.RegisterProcedureSpec(plan.ProcedureKind(kind), newDetectProcedure, kind)
+		execute.RegisterTransformation(plan.ProcedureKind(kind), createDetectTransformation)
+	}
+}
+
+// Kind returns the kind of operation of a detector of the package.
+func Kind(method string) flux.OperationKind {
+	return flux.OperationKind(pkgpath + "." + method)
+}
+
+type DetectOpSpec struct {
+	Method    string  `json:"method"`
+	Column    string  `json:"column"`
+	N         int64   `json:"n"`
+	Alpha     float64 `json:"alpha"`
+	Drift     float64 `json:"drift"`
+	Threshold float64 `json:"threshold"`
+}
+
+func newCreateOpSpec(method string) flux.CreateOperationSpec {
+	return func(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
+		return createDetectOpSpec(method, args, a)
+	}
+}
+
+func createDetectOpSpec(method string, args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
+	if err := a.AddParentFromArgs(args); err != nil {
+		return nil, err
+	}
+
+	spec := &DetectOpSpec{
+		Method:    method,
+		Column:    execute.DefaultValueColLabel,
+		Threshold: defaultThresholds[method],
+	}
+	if col, ok, err := args.GetString("column"); err != nil {
+		return nil, err
+	} else if ok {
+		spec.Column = col
+	}
+	if t, ok, err := args.GetFloat("threshold"); err != nil {
+		return nil, err
+	} else if ok {
+		if !(t >= 0) {
+			return nil, errors.Newf(codes.Invalid, "threshold must not be negative, got %v", t)
+		}
+		spec.Threshold = t
+	}
+
+	switch method {
+	case MethodEWMA:
+		alpha, err := args.GetRequiredFloat("alpha")
+		if err != nil {
+			return nil, err
+		}
+		if !(alpha > 0 && alpha <= 1) {
+			return nil, errors.Newf(codes.Invalid, "alpha must be greater than 0 and at most 1, got %v", alpha)
+		}
+		spec.Alpha = alpha
+	default:
+		n, err := args.GetRequiredInt("n")
+		if err != nil {
+			return nil, err
+		}
+		if n < 2 {
+			return nil, errors.Newf(codes.Invalid, "n must be at least 2, got %d", n)
+		}
+		spec.N = n
+	}
+
+	if method == MethodCUSUM {
+		spec.Drift = DefaultDrift
+		if d, ok, err := args.GetFloat("drift"); err != nil {
+			return nil, err
+		} else if ok {
+			if !(d >= 0) {
+				return nil, errors.Newf(codes.Invalid, "drift must not be negative, got %v", d)
+			}
+			spec.Drift = d
+		}
+	}
+	return spec, nil
+}
+
+func (s *DetectOpSpec) Kind() flux.OperationKind {
+	return Kind(s.Method)
+}
+
+type DetectProcedureSpec struct {
+	plan.DefaultCost
+	Method    string
+	Column    string
+	N         int
+	Alpha     float64
+	Drift     float64
+	Threshold float64
+}
+
+func newDetectProcedure(qs flux.OperationSpec, pa plan.Administration) (plan.ProcedureSpec, error) {
+	spec, ok := qs.(*DetectOpSpec)
+	if !ok {
+		return nil, errors.Newf(codes.Internal, "invalid spec type %T", qs)
+	}
+	return &DetectProcedureSpec{
+		Method:    spec.Method,
+		Column:    spec.Column,
+		N:         int(spec.N),
+		Alpha:     spec.Alpha,
+		Drift:     spec.Drift,
+		Threshold: spec.Threshold,
+	}, nil
+}
+
+func (s *DetectProcedureSpec) Kind() plan.ProcedureKind {
+	return plan.ProcedureKind(Kind(s.Method))
+}
+
+func (s *DetectProcedureSpec) Copy() plan.ProcedureSpec {
+	ns := new(DetectProcedureSpec)
+	*ns = *s
+	return ns
+}
+
+// TriggerSpec implements plan.TriggerAwareProcedureSpec
+func (s *DetectProcedureSpec) TriggerSpec() plan.TriggerSpec {
+	return plan.NarrowTransformationTriggerSpec{}
+}
+
+func createDetectTransformation(id execute.DatasetID, mode execute.AccumulationMode, spec plan.ProcedureSpec, a execute.Administration) (execute.Transformation, execute.Dataset, error) {
+	s, ok := spec.(*DetectProcedureSpec)
+	if !ok {
+		return nil, nil, errors.Newf(codes.Internal, "invalid spec type %T", spec)
+	}
+	return NewDetectTransformation(id, s, a.Allocator())
+}
+
+// detectTransformation scores each row of the column with a detector
+// for each table and flags the rows whose score exceeds the threshold.
+type detectTransformation struct {
+	spec *DetectProcedureSpec
+}
+
+func NewDetectTransformation(id execute.DatasetID, spec *DetectProcedureSpec, mem memory.Allocator) (execute.Transformation, execute.Dataset, error) {
+	switch spec.Method {
+	case MethodZScore, MethodEWMA, MethodMAD, MethodIQR, MethodCUSUM:
+	default:
+		return nil, nil, errors.Newf(codes.Internal, "unknown anomaly detection method %q", spec.Method)
+	}
+	t := &detectTransformation{spec: spec}
+	return execute.NewNarrowStateTransformation[anomaly.Detector](id, t, mem)
+}
+
+func (t *detectTransformation) newDetector() anomaly.Detector {
+	switch t.spec.Method {
+	case MethodZScore:
+		return anomaly.NewZScore(t.spec.N)
+	case MethodEWMA:
+		return anomaly.NewEWMA(t.spec.Alpha)
+	case MethodMAD:
+		return anomaly.NewMAD(t.spec.N)
+	case MethodIQR:
+		return anomaly.NewIQR(t.spec.N)
+	default:
+		return anomaly.NewCUSUM(t.spec.N, t.spec.Drift, t.spec.Threshold)
+	}
+}
+
+func (t *detectTransformation) Process(chunk table.Chunk, state anomaly.Detector, d *execute.TransportDataset, mem memory.Allocator) (anomaly.Detector, bool, error) {
+	idx := chunk.Index(t.spec.Column)
+	if idx < 0 {
+		return nil, false, errors.Newf(codes.FailedPrecondition, "cannot find column %s", t.spec.Column)
+	}
+	switch typ := chunk.Col(idx).Type; typ {
+	case flux.TInt, flux.TUInt, flux.TFloat:
+	default:
+		return nil, false, errors.Newf(codes.FailedPrecondition, "anomaly.%s can work only on numerical types, got %s", t.spec.Method, typ)
+	}
+	if state == nil {
+		state = t.newDetector()
+	}
+	ewma, _ := state.(*anomaly.EWMA)
+
+	n := chunk.Len()
+	scores := array.NewFloatBuilder(mem)
+	scores.Resize(n)
+	flags := array.NewBooleanBuilder(mem)
+	flags.Resize(n)
+	var lower, upper *array.FloatBuilder
+	if ewma != nil {
+		lower = array.NewFloatBuilder(mem)
+		lower.Resize(n)
+		upper = array.NewFloatBuilder(mem)
+		upper.Resize(n)
+	}
+
+	vs := chunk.Values(idx)
+	for i := 0; i < n; i++ {
+		if vs.IsNull(i) {
+			// Null values are not scored and do
+			// not change the state of the detector.
+			scores.AppendNull()
+			flags.Append(false)
+			if ewma != nil {
+				lower.AppendNull()
+				upper.AppendNull()
+			}
+			continue
+		}
+
+		var v float64
+		switch vs := vs.(type) {
+		case *array.Int:
+			v = float64(vs.Value(i))
+		case *array.Uint:
+			v = float64(vs.Value(i))
+		case *array.Float:
+			v = vs.Value(i)
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				scores.Release()
+				flags.Release()
+				if ewma != nil {
+					lower.Release()
+					upper.Release()
+				}
+				return nil, false, errors.New(codes.Invalid, "NaN/Inf in input")
+			}
+		}
+
+		score, ok := state.Next(v)
+		if !ok {
+			scores.AppendNull()
+			flags.Append(false)
+			if ewma != nil {
+				lower.AppendNull()
+				upper.AppendNull()
+			}
+			continue
+		}
+		scores.Append(score)
+		flags.Append(math.Abs(score) > t.spec.Threshold)
+		if ewma != nil {
+			center, scale := ewma.Band()
+			lower.Append(center - t.spec.Threshold*scale)
+			upper.Append(center + t.spec.Threshold*scale)
+		}
+	}
+
+	cols := []flux.ColMeta{
+		{Label: ScoreColLabel, Type: flux.TFloat},
+		{Label: AnomalyColLabel, Type: flux.TBool},
+	}
+	arrs := []array.Array{scores.NewArray(), flags.NewArray()}
+	if ewma != nil {
+		cols = append(cols,
+			flux.ColMeta{Label: LowerColLabel, Type: flux.TFloat},
+			flux.ColMeta{Label: UpperColLabel, Type: flux.TFloat},
+		)
+		arrs = append(arrs, lower.NewArray(), upper.NewArray())
+	}
+	out, err := table.AppendColumns(chunk, cols, arrs)
+	if err != nil {
+		return nil, false, err
+	}
+	return state, true, d.Process(out)
+}
+
+func (t *detectTransformation) Close() error {
+	return nil
+}
@@ -0,0 +1,298 @@
+package anomaly_test
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/execute/executetest"
+	"github.com/InfluxCommunity/flux/memory"
+	"github.com/InfluxCommunity/flux/stdlib/anomaly"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestDetect_Process(t *testing.T) {
+	inCols := []flux.ColMeta{
+		{Label: "_time", Type: flux.TTime},
+		{Label: "t0", Type: flux.TString},
+		{Label: "_value", Type: flux.TInt},
+	}
+	outCols := append(inCols[:len(inCols):len(inCols)],
+		flux.ColMeta{Label: "score", Type: flux.TFloat},
+		flux.ColMeta{Label: "anomaly", Type: flux.TBool},
+	)
+	for _, tc := range []struct {
+		name    string
+		spec    *anomaly.DetectProcedureSpec
+		data    []flux.Table
+		want    []*executetest.Table
+		wantErr error
+	}{
+		{
+			name: "zscore",
+			spec: &anomaly.DetectProcedureSpec{
+				Method:    anomaly.MethodZScore,
+				N:         3,
+				Threshold: 3,
+			},
+			data: []flux.Table{&executetest.Table{
+				KeyCols: []string{"t0"},
+				ColMeta: inCols,
+				Data: [][]interface{}{
+					{execute.Time(1), "a", int64(1)},
+					{execute.Time(2), "a", int64(2)},
+					{execute.Time(3), "a", nil},
+					{execute.Time(4), "a", int64(3)},
+					{execute.Time(5), "a", int64(4)},
+					{execute.Time(6), "a", int64(2)},
+					{execute.Time(7), "a", int64(10)},
+				},
+			}, &executetest.Table{
+				KeyCols: []string{"t0"},
+				ColMeta: inCols,
+				Data: [][]interface{}{
+					{execute.Time(1), "b", int64(5)},
+					{execute.Time(2), "b", int64(5)},
+					{execute.Time(3), "b", int64(5)},
+					{execute.Time(4), "b", int64(5)},
+					{execute.Time(5), "b", int64(6)},
+				},
+			}},
+			want: []*executetest.Table{
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: outCols,
+					Data: [][]interface{}{
+						{execute.Time(1), "a", int64(1), nil, false},
+						{execute.Time(2), "a", int64(2), nil, false},
+						{execute.Time(3), "a", nil, nil, false},
+						{execute.Time(4), "a", int64(3), nil, false},
+						{execute.Time(5), "a", int64(4), 2.0, false},
+						{execute.Time(6), "a", int64(2), -1.0, false},
+						{execute.Time(7), "a", int64(10), 7.0, true},
+					},
+				},
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: outCols,
+					Data: [][]interface{}{
+						{execute.Time(1), "b", int64(5), nil, false},
+						{execute.Time(2), "b", int64(5), nil, false},
+						{execute.Time(3), "b", int64(5), nil, false},
+						{execute.Time(4), "b", int64(5), 0.0, false},
+						{execute.Time(5), "b", int64(6), math.Inf(1), true},
+					},
+				},
+			},
+		},
+		{
+			name: "ewma",
+			spec: &anomaly.DetectProcedureSpec{
+				Method:    anomaly.MethodEWMA,
+				Alpha:     0.5,
+				Threshold: 2,
+			},
+			data: []flux.Table{&executetest.Table{
+				KeyCols: []string{"t0"},
+				ColMeta: inCols,
+				Data: [][]interface{}{
+					{execute.Time(1), "a", int64(2)},
+					{execute.Time(2), "a", int64(4)},
+					{execute.Time(3), "a", int64(3)},
+					{execute.Time(4), "a", int64(4)},
+				},
+			}},
+			want: []*executetest.Table{{
+				KeyCols: []string{"t0"},
+				ColMeta: append(outCols[:len(outCols):len(outCols)],
+					flux.ColMeta{Label: "lower", Type: flux.TFloat},
+					flux.ColMeta{Label: "upper", Type: flux.TFloat},
+				),
+				Data: [][]interface{}{
+					{execute.Time(1), "a", int64(2), nil, false, nil, nil},
+					{execute.Time(2), "a", int64(4), nil, false, nil, nil},
+					{execute.Time(3), "a", int64(3), nil, false, nil, nil},
+					{execute.Time(4), "a", int64(4), math.Sqrt2, false, 3 - math.Sqrt2, 3 + math.Sqrt2},
+				},
+			}},
+		},
+		{
+			name: "mad",
+			spec: &anomaly.DetectProcedureSpec{
+				Method:    anomaly.MethodMAD,
+				N:         5,
+				Threshold: 3.5,
+			},
+			data: []flux.Table{&executetest.Table{
+				KeyCols: []string{"t0"},
+				ColMeta: inCols,
+				Data: [][]interface{}{
+					{execute.Time(1), "a", int64(1)},
+					{execute.Time(2), "a", int64(2)},
+					{execute.Time(3), "a", int64(3)},
+					{execute.Time(4), "a", int64(4)},
+					{execute.Time(5), "a", int64(100)},
+					{execute.Time(6), "a", int64(5)},
+					{execute.Time(7), "a", int64(60)},
+				},
+			}},
+			want: []*executetest.Table{{
+				KeyCols: []string{"t0"},
+				ColMeta: outCols,
+				Data: [][]interface{}{
+					{execute.Time(1), "a", int64(1), nil, false},
+					{execute.Time(2), "a", int64(2), nil, false},
+					{execute.Time(3), "a", int64(3), nil, false},
+					{execute.Time(4), "a", int64(4), nil, false},
+					{execute.Time(5), "a", int64(100), nil, false},
+					{execute.Time(6), "a", int64(5), 1.349, false},
+					{execute.Time(7), "a", int64(60), 37.772, true},
+				},
+			}},
+		},
+		{
+			name: "iqr",
+			spec: &anomaly.DetectProcedureSpec{
+				Method:    anomaly.MethodIQR,
+				N:         5,
+				Threshold: 1.5,
+			},
+			data: []flux.Table{&executetest.Table{
+				KeyCols: []string{"t0"},
+				ColMeta: inCols,
+				Data: [][]interface{}{
+					{execute.Time(1), "a", int64(1)},
+					{execute.Time(2), "a", int64(2)},
+					{execute.Time(3), "a", int64(3)},
+					{execute.Time(4), "a", int64(4)},
+					{execute.Time(5), "a", int64(5)},
+					{execute.Time(6), "a", int64(3)},
+					{execute.Time(7), "a", int64(10)},
+				},
+			}},
+			want: []*executetest.Table{{
+				KeyCols: []string{"t0"},
+				ColMeta: outCols,
+				Data: [][]interface{}{
+					{execute.Time(1), "a", int64(1), nil, false},
+					{execute.Time(2), "a", int64(2), nil, false},
+					{execute.Time(3), "a", int64(3), nil, false},
+					{execute.Time(4), "a", int64(4), nil, false},
+					{execute.Time(5), "a", int64(5), nil, false},
+					{execute.Time(6), "a", int64(3), 0.0, false},
+					{execute.Time(7), "a", int64(10), 6.0, true},
+				},
+			}},
+		},
+		{
+			name: "cusum",
+			spec: &anomaly.DetectProcedureSpec{
+				Method:    anomaly.MethodCUSUM,
+				N:         4,
+				Drift:     0.5,
+				Threshold: 4,
+			},
+			data: []flux.Table{&executetest.Table{
+				KeyCols: []string{"t0"},
+				ColMeta: inCols,
+				Data: [][]interface{}{
+					{execute.Time(1), "a", int64(9)},
+					{execute.Time(2), "a", int64(11)},
+					{execute.Time(3), "a", int64(9)},
+					{execute.Time(4), "a", int64(11)},
+					{execute.Time(5), "a", int64(10)},
+					{execute.Time(6), "a", int64(12)},
+					{execute.Time(7), "a", int64(12)},
+					{execute.Time(8), "a", int64(12)},
+					{execute.Time(9), "a", int64(12)},
+					{execute.Time(10), "a", int64(12)},
+				},
+			}},
+			want: []*executetest.Table{{
+				KeyCols: []string{"t0"},
+				ColMeta: outCols,
+				Data: [][]interface{}{
+					{execute.Time(1), "a", int64(9), nil, false},
+					{execute.Time(2), "a", int64(11), nil, false},
+					{execute.Time(3), "a", int64(9), nil, false},
+					{execute.Time(4), "a", int64(11), nil, false},
+					{execute.Time(5), "a", int64(10), 0.0, false},
+					{execute.Time(6), "a", int64(12), math.Sqrt(3) - 0.5, false},
+					{execute.Time(7), "a", int64(12), 2 * (math.Sqrt(3) - 0.5), false},
+					{execute.Time(8), "a", int64(12), 3 * (math.Sqrt(3) - 0.5), false},
+					{execute.Time(9), "a", int64(12), 4 * (math.Sqrt(3) - 0.5), true},
+					{execute.Time(10), "a", int64(12), nil, false},
+				},
+			}},
+		},
+		{
+			name: "replace score",
+			spec: &anomaly.DetectProcedureSpec{
+				Method:    anomaly.MethodIQR,
+				N:         2,
+				Threshold: 1.5,
+			},
+			data: []flux.Table{&executetest.Table{
+				ColMeta: []flux.ColMeta{
+					{Label: "_value", Type: flux.TFloat},
+					{Label: "score", Type: flux.TFloat},
+				},
+				Data: [][]interface{}{
+					{1.0, 0.5},
+					{2.0, 0.5},
+					{4.0, 0.5},
+				},
+			}},
+			want: []*executetest.Table{{
+				ColMeta: []flux.ColMeta{
+					{Label: "_value", Type: flux.TFloat},
+					{Label: "score", Type: flux.TFloat},
+					{Label: "anomaly", Type: flux.TBool},
+				},
+				Data: [][]interface{}{
+					{1.0, nil, false},
+					{2.0, nil, false},
+					{4.0, 4.5, true},
+				},
+			}},
+		},
+		{
+			name: "non-numeric column",
+			spec: &anomaly.DetectProcedureSpec{
+				Method:    anomaly.MethodZScore,
+				N:         2,
+				Threshold: 3,
+			},
+			data: []flux.Table{&executetest.Table{
+				ColMeta: []flux.ColMeta{
+					{Label: "_value", Type: flux.TString},
+				},
+				Data: [][]interface{}{
+					{"a"},
+				},
+			}},
+			wantErr: errors.New("anomaly.zscore can work only on numerical types, got string"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			spec := *tc.spec
+			spec.Column = execute.DefaultValueColLabel
+			executetest.ProcessTestHelper2(
+				t,
+				tc.data,
+				tc.want,
+				tc.wantErr,
+				func(id execute.DatasetID, alloc memory.Allocator) (execute.Transformation, execute.Dataset) {
+					tr, d, err := anomaly.NewDetectTransformation(id, &spec, alloc)
+					if err != nil {
+						t.Fatal(err)
+					}
+					return tr, d
+				},
+				cmpopts.EquateApprox(0, 1e-9),
+			)
+		})
+	}
+}
@@ -11,12 +11,14 @@ import (
 
 	"github.com/InfluxCommunity/flux"
 	"github.com/InfluxCommunity/flux/codes"
+	fluxurl "github.com/InfluxCommunity/flux/dependencies/url"
 	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/internal/telemetry"
 	"github.com/InfluxCommunity/flux/runtime"
 	"github.com/InfluxCommunity/flux/semantic"
+	"github.com/InfluxCommunity/flux/stdlib/http/oauth2"
 	"github.com/InfluxCommunity/flux/values"
-	"github.com/opentracing/opentracing-go"
-	"github.com/opentracing/opentracing-go/log"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // http get mirrors the http post originally completed for alerts & notifications
@@ -38,6 +40,7 @@ var get = values.NewFunction(
 		if err != nil {
 			return nil, err
 		}
+		validator = fluxurl.ValidatorFor(validator, "experimental/http.get")
 		if err := validator.Validate(u); err != nil {
 			return nil, errors.New(codes.Invalid, "no such host")
 		}
@@ -80,11 +83,14 @@ var get = values.NewFunction(
 		if err != nil {
 			return nil, errors.Wrap(err, codes.Aborted, "missing client in http.get")
 		}
+		dc, err = oauth2.WithToken(ctx, dc)
+		if err != nil {
+			return nil, err
+		}
 
 		statusCode, body, headers, err := func(req *http.Request) (int, []byte, values.Object, error) {
-			s, cctx := opentracing.StartSpanFromContext(ctx, "http.get")
-			s.SetTag("url", req.URL.String())
-			defer s.Finish()
+			cctx, s := telemetry.StartSpan(fluxurl.WithFunction(ctx, "experimental/http.get"), "http.get", attribute.String("url", req.URL.String()))
+			defer s.End()
 
 			ccctx, cncl := context.WithTimeout(cctx, theTimeout.Duration())
 			defer cncl()
@@ -105,9 +111,9 @@ var get = values.NewFunction(
 			if err != nil {
 				return 0, nil, nil, err
 			}
-			s.LogFields(
-				log.Int("statusCode", response.StatusCode),
-				log.Int("responseSize", len(body)),
+			s.SetAttributes(
+				attribute.Int("statusCode", response.StatusCode),
+				attribute.Int("responseSize", len(body)),
 			)
 			return response.StatusCode, body, headerToObject(response.Header), nil
 		}(req)
@@ -12,14 +12,15 @@ import (
 	"github.com/InfluxCommunity/flux/execute"
 	"github.com/InfluxCommunity/flux/execute/table"
 	"github.com/InfluxCommunity/flux/internal/errors"
+
 	"github.com/InfluxCommunity/flux/internal/function"
+	"github.com/InfluxCommunity/flux/internal/telemetry"
 	"github.com/InfluxCommunity/flux/memory"
 	"github.com/InfluxCommunity/flux/plan"
 	stdarrow "github.com/apache/arrow/go/v7/arrow"
 	arrowarray "github.com/apache/arrow/go/v7/arrow/array"
-	"github.com/opentracing/opentracing-go"
-	"github.com/opentracing/opentracing-go/ext"
-	"github.com/opentracing/opentracing-go/log"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
 )
 
 const SqlKind = "experimental/iox.sql"
@@ -119,9 +120,8 @@ func (s *sqlSource) createSchema(schema *stdarrow.Schema) ([]flux.ColMeta, error
 }
 
 func (s *sqlSource) run(ctx context.Context) error {
-	span, ctx := opentracing.StartSpanFromContext(ctx, "sqlSouce.run")
-	defer span.Finish()
-	span.LogFields(log.String("query", s.query))
+	ctx, span := telemetry.StartSpan(ctx, "sqlSouce.run", attribute.String("query", s.query))
+	defer span.End()
 
 	// Note: query args are not actually supported yet, see
 	// https://github.com/influxdata/influxdb_iox/issues/3718
@@ -140,13 +140,15 @@ func (s *sqlSource) run(ctx context.Context) error {
 	hasMore, err := nextRecordBatch(rr)
 	for hasMore && err == nil {
 		if err := s.produce(key, cols, rr.Record()); err != nil {
-			ext.LogError(span, err)
+			span.RecordError(err)
+			span.SetStatus(otelcodes.Error, err.Error())
 			return err
 		}
 		hasMore, err = nextRecordBatch(rr)
 	}
 	if err != nil {
-		ext.LogError(span, err)
+		span.RecordError(err)
+		span.SetStatus(otelcodes.Error, err.Error())
 		return err
 	}
 
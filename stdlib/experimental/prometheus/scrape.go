@@ -18,6 +18,7 @@ import (
 	// Flux packages
 	"github.com/InfluxCommunity/flux"
 	"github.com/InfluxCommunity/flux/codes"
+	fluxurl "github.com/InfluxCommunity/flux/dependencies/url"
 	"github.com/InfluxCommunity/flux/execute"
 	"github.com/InfluxCommunity/flux/internal/errors"
 	"github.com/InfluxCommunity/flux/plan"
@@ -145,6 +146,7 @@ func (p *PrometheusIterator) Connect(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	validator = fluxurl.ValidatorFor(validator, "experimental/prometheus.scrape")
 	if err := validator.Validate(u); err != nil {
 		return err
 	}
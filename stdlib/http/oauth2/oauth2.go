@@ -0,0 +1,377 @@
+package oauth2
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/codes"
+	fhttp "github.com/InfluxCommunity/flux/dependencies/http"
+	fluxurl "github.com/InfluxCommunity/flux/dependencies/url"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/interpreter"
+	"github.com/InfluxCommunity/flux/runtime"
+	"github.com/InfluxCommunity/flux/semantic"
+	"github.com/InfluxCommunity/flux/values"
+)
+
+const pkgpath = "http/oauth2"
+
+// expiryDelta is how long before its expiry a cached token is refreshed.
+const expiryDelta = 10 * time.Second
+
+// maxTokenResponse is the maximum size of a token response we will read.
+const maxTokenResponse = 1024 * 1024
+
+// Config holds the client credentials used to obtain a token.
+type Config struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scope        string
+
+	// URLs are the URL prefixes of the requests that are authorized
+	// with the token. The token is never sent with other requests.
+	URLs []*url.URL
+}
+
+// cacheKey identifies the token of a config. It includes a hash of
+// the client secret so that a token is only returned from the cache
+// to a config that could have obtained it from the token endpoint.
+type cacheKey struct {
+	tokenURL   string
+	clientID   string
+	scope      string
+	secretHash [sha256.Size]byte
+}
+
+func (c Config) key() cacheKey {
+	return cacheKey{
+		tokenURL:   c.TokenURL,
+		clientID:   c.ClientID,
+		scope:      c.Scope,
+		secretHash: sha256.Sum256([]byte(c.ClientSecret)),
+	}
+}
+
+// authorizes reports whether requests to u are authorized with the token.
+// A request matches a prefix when it has the same scheme and host and
+// its path is the prefix path or a path below it.
+func (c Config) authorizes(u *url.URL) bool {
+	for _, prefix := range c.URLs {
+		if !strings.EqualFold(prefix.Scheme, u.Scheme) || !strings.EqualFold(prefix.Host, u.Host) {
+			continue
+		}
+		path := strings.TrimSuffix(prefix.EscapedPath(), "/")
+		if reqPath := u.EscapedPath(); reqPath == path || strings.HasPrefix(reqPath, path+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// token is an access token returned by a token endpoint.
+type token struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+
+	expiry time.Time
+}
+
+func (t *token) valid(now time.Time) bool {
+	if t == nil || t.AccessToken == "" {
+		return false
+	}
+	return t.expiry.IsZero() || now.Add(expiryDelta).Before(t.expiry)
+}
+
+// header returns the value of the Authorization header for the token.
+func (t *token) header() string {
+	typ := t.TokenType
+	if typ == "" || strings.EqualFold(typ, "bearer") {
+		typ = "Bearer"
+	}
+	return typ + " " + t.AccessToken
+}
+
+type cacheEntry struct {
+	mu    sync.Mutex
+	token *token
+}
+
+// tokenCache caches tokens by token URL, client credentials and scope.
+type tokenCache struct {
+	mu      sync.Mutex
+	entries map[cacheKey]*cacheEntry
+}
+
+func newTokenCache() *tokenCache {
+	return &tokenCache{entries: make(map[cacheKey]*cacheEntry)}
+}
+
+// defaultCache is shared by all queries in the process so tokens
+// are reused until they expire.
+var defaultCache = newTokenCache()
+
+func (c *tokenCache) entry(key cacheKey) *cacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		e = &cacheEntry{}
+		c.entries[key] = e
+	}
+	return e
+}
+
+// Token returns a valid token for the config, obtaining a new one
+// from the token endpoint when the cached token is missing or expired.
+func (c *tokenCache) Token(ctx context.Context, client fhttp.Client, conf Config) (*token, error) {
+	e := c.entry(conf.key())
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.token.valid(time.Now()) {
+		return e.token, nil
+	}
+	tok, err := fetchToken(ctx, client, conf)
+	if err != nil {
+		return nil, err
+	}
+	e.token = tok
+	return tok, nil
+}
+
+// invalidate removes the given token from the cache if it is still cached.
+func (c *tokenCache) invalidate(key cacheKey, tok *token) {
+	e := c.entry(key)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.token == tok {
+		e.token = nil
+	}
+}
+
+// fetchToken requests a token from the token endpoint using the client credentials grant.
+func fetchToken(ctx context.Context, client fhttp.Client, conf Config) (*token, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if conf.Scope != "" {
+		form.Set("scope", conf.Scope)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", conf.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(conf.ClientID), url.QueryEscape(conf.ClientSecret))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, codes.Unavailable, "failed to obtain oauth2 token")
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxTokenResponse))
+	if err != nil {
+		return nil, errors.Wrap(err, codes.Unavailable, "failed to read oauth2 token response")
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, errors.Newf(codes.Unauthenticated, "failed to obtain oauth2 token: token endpoint returned status %d", resp.StatusCode)
+	}
+
+	var tok token
+	if err := json.Unmarshal(body, &tok); err != nil {
+		return nil, errors.Wrap(err, codes.Invalid, "failed to decode oauth2 token response")
+	}
+	if tok.AccessToken == "" {
+		return nil, errors.New(codes.Invalid, "oauth2 token response is missing the access token")
+	}
+	if tok.ExpiresIn > 0 {
+		tok.expiry = time.Now().Add(time.Duration(tok.ExpiresIn) * time.Second)
+	}
+	return &tok, nil
+}
+
+// authorizedClient is a client that sets the Authorization header
+// of each request with a token obtained using the client credentials.
+type authorizedClient struct {
+	client fhttp.Client
+	conf   Config
+	cache  *tokenCache
+}
+
+// Do sends the request with a token when its URL is one of the configured
+// URL prefixes. When the request is rejected with a 401 status code,
+// the token is refreshed and a request whose body can be recreated
+// is sent once more with the new token.
+func (c *authorizedClient) Do(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Authorization") != "" || !c.conf.authorizes(req.URL) {
+		return c.client.Do(req)
+	}
+	tok, err := c.cache.Token(req.Context(), c.client, c.conf)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(withAuthorization(req, tok))
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	// The token may have been revoked so do not reuse it.
+	c.cache.invalidate(c.conf.key(), tok)
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return resp, nil
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxTokenResponse))
+	_ = resp.Body.Close()
+
+	if tok, err = c.cache.Token(req.Context(), c.client, c.conf); err != nil {
+		return nil, err
+	}
+	retry := withAuthorization(req, tok)
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+	return c.client.Do(retry)
+}
+
+// withAuthorization returns a copy of the request that sends the token.
+func withAuthorization(req *http.Request, tok *token) *http.Request {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", tok.header())
+	return req
+}
+
+// WithToken returns a client that authorizes requests using the
+// clientCredentials option of the http/oauth2 package.
+// The client is returned unchanged when the option is not set.
+func WithToken(ctx context.Context, c fhttp.Client) (fhttp.Client, error) {
+	conf, ok, err := configFromOption(ctx)
+	if err != nil || !ok {
+		return c, err
+	}
+	return &authorizedClient{client: c, conf: conf, cache: defaultCache}, nil
+}
+
+// configFromOption reads the clientCredentials option.
+// It reports false when the package was not imported or
+// the token URL is not set.
+func configFromOption(ctx context.Context) (Config, bool, error) {
+	v, ok := interpreter.GetOption(ctx, pkgpath, "clientCredentials")
+	if !ok || v.Type().Nature() != semantic.Object {
+		return Config{}, false, nil
+	}
+	obj := v.Object()
+	var conf Config
+	for name, dst := range map[string]*string{
+		"tokenURL":     &conf.TokenURL,
+		"clientID":     &conf.ClientID,
+		"clientSecret": &conf.ClientSecret,
+		"scope":        &conf.Scope,
+	} {
+		fv, ok := obj.Get(name)
+		if !ok || fv.IsNull() {
+			continue
+		}
+		if fv.Type().Nature() != semantic.String {
+			return Config{}, false, errors.Newf(codes.Invalid, "option %q of oauth2.clientCredentials must be a string", name)
+		}
+		*dst = fv.Str()
+	}
+	if conf.TokenURL == "" {
+		return Config{}, false, nil
+	}
+
+	if fv, ok := obj.Get("urls"); ok && !fv.IsNull() {
+		if fv.Type().Nature() != semantic.Array {
+			return Config{}, false, errors.New(codes.Invalid, "option \"urls\" of oauth2.clientCredentials must be an array of strings")
+		}
+		var err error
+		fv.Array().Range(func(i int, v values.Value) {
+			if err != nil {
+				return
+			}
+			if v.Type().Nature() != semantic.String {
+				err = errors.New(codes.Invalid, "option \"urls\" of oauth2.clientCredentials must be an array of strings")
+				return
+			}
+			u, perr := url.Parse(v.Str())
+			if perr != nil || u.Scheme == "" || u.Host == "" {
+				err = errors.Newf(codes.Invalid, "invalid URL prefix %q in oauth2.clientCredentials", v.Str())
+				return
+			}
+			conf.URLs = append(conf.URLs, u)
+		})
+		if err != nil {
+			return Config{}, false, err
+		}
+	}
+	if len(conf.URLs) == 0 {
+		return Config{}, false, errors.New(codes.Invalid, "oauth2.clientCredentials requires urls to limit the requests that are sent the token")
+	}
+	return conf, true, nil
+}
+
+func init() {
+	runtime.RegisterPackageValue(pkgpath, "token", values.NewFunction(
+		"token",
+		runtime.MustLookupBuiltinType(pkgpath, "token"),
+		func(ctx context.Context, args values.Object) (values.Value, error) {
+			return interpreter.DoFunctionCallContext(tokenFunc, ctx, args)
+		},
+		true, // token performs an HTTP request
+	))
+}
+
+func tokenFunc(ctx context.Context, args interpreter.Arguments) (values.Value, error) {
+	var conf Config
+	var err error
+	if conf.TokenURL, err = args.GetRequiredString("tokenURL"); err != nil {
+		return nil, err
+	}
+	if conf.ClientID, err = args.GetRequiredString("clientID"); err != nil {
+		return nil, err
+	}
+	if conf.ClientSecret, err = args.GetRequiredString("clientSecret"); err != nil {
+		return nil, err
+	}
+	if scope, ok, err := args.GetString("scope"); err != nil {
+		return nil, err
+	} else if ok {
+		conf.Scope = scope
+	}
+
+	u, err := url.Parse(conf.TokenURL)
+	if err != nil {
+		return nil, errors.Wrap(err, codes.Invalid, "invalid token URL")
+	}
+	deps := flux.GetDependencies(ctx)
+	validator, err := deps.URLValidator()
+	if err != nil {
+		return nil, err
+	}
+	validator = fluxurl.ValidatorFor(validator, "http/oauth2.token")
+	if err := validator.Validate(u); err != nil {
+		return nil, errors.New(codes.Invalid, "no such host")
+	}
+	client, err := deps.HTTPClient()
+	if err != nil {
+		return nil, errors.Wrap(err, codes.Aborted, "missing client in oauth2.token")
+	}
+
+	tok, err := defaultCache.Token(fluxurl.WithFunction(ctx, "http/oauth2.token"), client, conf)
+	if err != nil {
+		return nil, err
+	}
+	return values.NewString(tok.AccessToken), nil
+}
@@ -0,0 +1,313 @@
+package oauth2
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	neturl "net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	fhttp "github.com/InfluxCommunity/flux/dependencies/http"
+	"github.com/InfluxCommunity/flux/dependencies/url"
+)
+
+func newTokenServer(t *testing.T, issued *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("unexpected error parsing token request: %s", err)
+		}
+		if want, got := "client_credentials", r.PostForm.Get("grant_type"); want != got {
+			t.Errorf("unexpected grant type -want/+got:\n\t- %q\n\t+ %q", want, got)
+		}
+		if id, secret, ok := r.BasicAuth(); !ok || id != "id" || secret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		n := atomic.AddInt32(issued, 1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"access_token":"token%d","token_type":"bearer","expires_in":3600}`, n)
+	}))
+}
+
+func mustParseURLs(t *testing.T, urls ...string) []*neturl.URL {
+	t.Helper()
+	us := make([]*neturl.URL, len(urls))
+	for i, u := range urls {
+		var err error
+		if us[i], err = neturl.Parse(u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return us
+}
+
+func TestAuthorizedClient(t *testing.T) {
+	var issued int32
+	tokenServer := newTokenServer(t, &issued)
+	defer tokenServer.Close()
+
+	var authorization []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = append(authorization, r.Header.Get("Authorization"))
+		if r.Header.Get("Authorization") == "Bearer token1" && len(authorization) > 2 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	client := &authorizedClient{
+		client: fhttp.NewDefaultClient(url.PassValidator{}),
+		conf: Config{
+			TokenURL:     tokenServer.URL,
+			ClientID:     "id",
+			ClientSecret: "secret",
+			URLs:         mustParseURLs(t, ts.URL),
+		},
+		cache: newTokenCache(),
+	}
+	for i := 0; i < 4; i++ {
+		req, err := http.NewRequest("GET", ts.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+	}
+
+	// The first token is reused until it is rejected. The rejected
+	// request is then sent again with a refreshed token.
+	want := []string{"Bearer token1", "Bearer token1", "Bearer token1", "Bearer token2", "Bearer token2"}
+	if len(authorization) != len(want) {
+		t.Fatalf("unexpected number of requests -want/+got:\n\t- %d\n\t+ %d", len(want), len(authorization))
+	}
+	for i := range want {
+		if want[i] != authorization[i] {
+			t.Errorf("unexpected authorization for request %d -want/+got:\n\t- %q\n\t+ %q", i, want[i], authorization[i])
+		}
+	}
+}
+
+func TestAuthorizedClient_ExistingAuthorization(t *testing.T) {
+	var issued int32
+	tokenServer := newTokenServer(t, &issued)
+	defer tokenServer.Close()
+
+	var authorization string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+	}))
+	defer ts.Close()
+
+	client := &authorizedClient{
+		client: fhttp.NewDefaultClient(url.PassValidator{}),
+		conf: Config{
+			TokenURL:     tokenServer.URL,
+			ClientID:     "id",
+			ClientSecret: "secret",
+			URLs:         mustParseURLs(t, ts.URL),
+		},
+		cache: newTokenCache(),
+	}
+	req, err := http.NewRequest("GET", ts.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Token mine")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+
+	if want, got := "Token mine", authorization; want != got {
+		t.Errorf("unexpected authorization -want/+got:\n\t- %q\n\t+ %q", want, got)
+	}
+	if got := atomic.LoadInt32(&issued); got != 0 {
+		t.Errorf("expected no token to be requested, got %d", got)
+	}
+}
+
+func TestFetchToken_Unauthorized(t *testing.T) {
+	var issued int32
+	tokenServer := newTokenServer(t, &issued)
+	defer tokenServer.Close()
+
+	_, err := fetchToken(context.Background(), fhttp.NewDefaultClient(url.PassValidator{}), Config{
+		TokenURL:     tokenServer.URL,
+		ClientID:     "id",
+		ClientSecret: "wrong",
+	})
+	if err == nil {
+		t.Fatal("expected an error when the token endpoint rejects the credentials")
+	}
+}
+
+func TestAuthorizedClient_RetryWithBody(t *testing.T) {
+	var issued int32
+	tokenServer := newTokenServer(t, &issued)
+	defer tokenServer.Close()
+
+	var bodies []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, r.Header.Get("Authorization")+" "+string(body))
+		if r.Header.Get("Authorization") == "Bearer token1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	client := &authorizedClient{
+		client: fhttp.NewDefaultClient(url.PassValidator{}),
+		conf: Config{
+			TokenURL:     tokenServer.URL,
+			ClientID:     "id",
+			ClientSecret: "secret",
+			URLs:         mustParseURLs(t, ts.URL),
+		},
+		cache: newTokenCache(),
+	}
+
+	// A request whose body can be recreated is sent again.
+	req, err := http.NewRequest("POST", ts.URL, strings.NewReader("data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if want, got := http.StatusNoContent, resp.StatusCode; want != got {
+		t.Errorf("unexpected status code -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+	want := []string{"Bearer token1 data", "Bearer token2 data"}
+	if len(bodies) != len(want) {
+		t.Fatalf("unexpected requests -want/+got:\n\t- %q\n\t+ %q", want, bodies)
+	}
+	for i := range want {
+		if want[i] != bodies[i] {
+			t.Errorf("unexpected request %d -want/+got:\n\t- %q\n\t+ %q", i, want[i], bodies[i])
+		}
+	}
+
+	// A request whose body cannot be recreated is not sent again
+	// and the 401 response is returned.
+	client.cache = newTokenCache()
+	atomic.StoreInt32(&issued, 0)
+	bodies = nil
+	req, err = http.NewRequest("POST", ts.URL, io.NopCloser(strings.NewReader("data")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if want, got := http.StatusUnauthorized, resp.StatusCode; want != got {
+		t.Errorf("unexpected status code -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+	if want, got := 1, len(bodies); want != got {
+		t.Errorf("unexpected number of requests -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+}
+
+func TestAuthorizedClient_OtherHost(t *testing.T) {
+	var issued int32
+	tokenServer := newTokenServer(t, &issued)
+	defer tokenServer.Close()
+
+	var authorization []string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = append(authorization, r.Header.Get("Authorization"))
+	})
+	api := httptest.NewServer(handler)
+	defer api.Close()
+	other := httptest.NewServer(handler)
+	defer other.Close()
+
+	client := &authorizedClient{
+		client: fhttp.NewDefaultClient(url.PassValidator{}),
+		conf: Config{
+			TokenURL:     tokenServer.URL,
+			ClientID:     "id",
+			ClientSecret: "secret",
+			URLs:         mustParseURLs(t, api.URL+"/v1"),
+		},
+		cache: newTokenCache(),
+	}
+	for _, u := range []string{
+		api.URL + "/v1/status",
+		other.URL + "/v1/status",
+		api.URL + "/v10/status",
+		api.URL + "/v1",
+	} {
+		req, err := http.NewRequest("GET", u, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+	}
+
+	want := []string{"Bearer token1", "", "", "Bearer token1"}
+	if len(authorization) != len(want) {
+		t.Fatalf("unexpected number of requests -want/+got:\n\t- %d\n\t+ %d", len(want), len(authorization))
+	}
+	for i := range want {
+		if want[i] != authorization[i] {
+			t.Errorf("unexpected authorization for request %d -want/+got:\n\t- %q\n\t+ %q", i, want[i], authorization[i])
+		}
+	}
+}
+
+func TestTokenCache_Credentials(t *testing.T) {
+	var issued int32
+	tokenServer := newTokenServer(t, &issued)
+	defer tokenServer.Close()
+
+	ctx := context.Background()
+	client := fhttp.NewDefaultClient(url.PassValidator{})
+	cache := newTokenCache()
+	conf := Config{
+		TokenURL:     tokenServer.URL,
+		ClientID:     "id",
+		ClientSecret: "secret",
+	}
+	if _, err := cache.Token(ctx, client, conf); err != nil {
+		t.Fatal(err)
+	}
+
+	// The cached token is not returned without the client secret.
+	wrong := conf
+	wrong.ClientSecret = "wrong"
+	if _, err := cache.Token(ctx, client, wrong); err == nil {
+		t.Fatal("expected an error when the client secret is wrong")
+	}
+
+	// A token is requested for each scope.
+	scoped := conf
+	scoped.Scope = "write"
+	tok, err := cache.Token(ctx, client, scoped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := "token2", tok.AccessToken; want != got {
+		t.Errorf("unexpected token -want/+got:\n\t- %q\n\t+ %q", want, got)
+	}
+}
@@ -10,13 +10,15 @@ import (
 
 	"github.com/InfluxCommunity/flux"
 	"github.com/InfluxCommunity/flux/codes"
+	fluxurl "github.com/InfluxCommunity/flux/dependencies/url"
 	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/internal/telemetry"
 	"github.com/InfluxCommunity/flux/iocounter"
 	"github.com/InfluxCommunity/flux/runtime"
 	"github.com/InfluxCommunity/flux/semantic"
+	"github.com/InfluxCommunity/flux/stdlib/http/oauth2"
 	"github.com/InfluxCommunity/flux/values"
-	"github.com/opentracing/opentracing-go"
-	"github.com/opentracing/opentracing-go/log"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 func init() {
@@ -37,8 +39,22 @@ func init() {
 				data = dataV.Bytes()
 			}
 
+			u, err := url.Parse(uV.Str())
+			if err != nil {
+				return nil, err
+			}
+			deps := flux.GetDependencies(ctx)
+			validator, err := deps.URLValidator()
+			if err != nil {
+				return nil, errors.Wrap(err, codes.Aborted, "missing url validator in http.post")
+			}
+			validator = fluxurl.ValidatorFor(validator, "http.post")
+			if err := validator.Validate(u); err != nil {
+				return nil, err
+			}
+
 			// Construct HTTP request
-			req, err := http.NewRequest("POST", uV.Str(), bytes.NewReader(data))
+			req, err := http.NewRequest("POST", u.String(), bytes.NewReader(data))
 			if err != nil {
 				return nil, err
 			}
@@ -60,16 +76,18 @@ func init() {
 			}
 
 			// Perform request
-			deps := flux.GetDependencies(ctx)
 			dc, err := deps.HTTPClient()
 			if err != nil {
 				return nil, errors.Wrap(err, codes.Aborted, "missing client in http.post")
 			}
+			dc, err = oauth2.WithToken(ctx, dc)
+			if err != nil {
+				return nil, err
+			}
 
 			statusCode, err := func(req *http.Request) (int, error) {
-				s, cctx := opentracing.StartSpanFromContext(ctx, "http.post")
-				s.SetTag("url", req.URL.String())
-				defer s.Finish()
+				cctx, s := telemetry.StartSpan(fluxurl.WithFunction(ctx, "http.post"), "http.post", attribute.String("url", req.URL.String()))
+				defer s.End()
 
 				req = req.WithContext(cctx)
 				response, err := dc.Do(req)
@@ -94,9 +112,9 @@ func init() {
 				wc := iocounter.Writer{Writer: io.Discard}
 				_, _ = io.Copy(&wc, response.Body)
 				_ = response.Body.Close()
-				s.LogFields(
-					log.Int("statusCode", response.StatusCode),
-					log.Int64("responseSize", wc.Count()),
+				s.SetAttributes(
+					attribute.Int("statusCode", response.StatusCode),
+					attribute.Int64("responseSize", wc.Count()),
 				)
 				return response.StatusCode, nil
 			}(req)
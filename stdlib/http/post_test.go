@@ -92,3 +92,39 @@ http.post(url:"http://127.1.1.1/path/a/b/c", headers: {x:"a",y:"b",z:"c"}, data:
 		t.Errorf("unexpected error code. Wanted %q got %q", codes.Invalid, code)
 	}
 }
+
+func TestPost_PolicyDenied(t *testing.T) {
+	var called bool
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(204)
+	}))
+	defer ts.Close()
+
+	script := fmt.Sprintf(`
+import "http"
+
+http.post(url:"%s/path/a/b/c", data: bytes(v: "body"))
+`, ts.URL)
+
+	validator, err := url.NewPolicyValidator(url.PolicyConfig{
+		Functions: map[string]url.Policy{
+			"http.post": {DenyCIDRs: []string{"127.0.0.0/8"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	deps := flux.NewDefaultDependencies()
+	deps.Deps.HTTPClient = fluxhttp.NewDefaultClient(url.PassValidator{})
+	deps.Deps.URLValidator = validator
+	ctx := deps.Inject(context.Background())
+	if _, _, err := runtime.Eval(ctx, script); err == nil {
+		t.Fatal("expected failure")
+	} else if !strings.Contains(err.Error(), "no such host") {
+		t.Errorf("unexpected cause of failure, got err: %v", err)
+	}
+	if called {
+		t.Error("unexpected request to a denied host")
+	}
+}
@@ -13,12 +13,15 @@ import (
 	"github.com/InfluxCommunity/flux"
 	"github.com/InfluxCommunity/flux/codes"
 	fhttp "github.com/InfluxCommunity/flux/dependencies/http"
+	fluxurl "github.com/InfluxCommunity/flux/dependencies/url"
 	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/internal/telemetry"
 	"github.com/InfluxCommunity/flux/runtime"
 	"github.com/InfluxCommunity/flux/semantic"
+	"github.com/InfluxCommunity/flux/stdlib/http/oauth2"
 	"github.com/InfluxCommunity/flux/values"
-	"github.com/opentracing/opentracing-go"
-	"github.com/opentracing/opentracing-go/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // http get mirrors the http post originally completed for alerts & notifications
@@ -98,8 +101,18 @@ var do = values.NewFunction(
 			body = bytes.NewReader(bodyV.Bytes())
 		}
 
+		deps := flux.GetDependencies(ctx)
+		validator, err := deps.URLValidator()
+		if err != nil {
+			return nil, errors.Wrap(err, codes.Aborted, "missing url validator in http.request")
+		}
+		validator = fluxurl.ValidatorFor(validator, "http/requests.do")
+		if err := validator.Validate(u); err != nil {
+			return nil, err
+		}
+
 		// Construct HTTP request
-		req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+		req, err := http.NewRequestWithContext(fluxurl.WithFunction(ctx, "http/requests.do"), method, u.String(), body)
 		if err != nil {
 			return nil, err
 		}
@@ -125,7 +138,6 @@ var do = values.NewFunction(
 		}
 
 		// Get Client and configure it
-		deps := flux.GetDependencies(ctx)
 		dc, err := deps.HTTPClient()
 		if err != nil {
 			return nil, errors.Wrap(err, codes.Aborted, "missing client in http.request")
@@ -156,17 +168,18 @@ var do = values.NewFunction(
 				return nil, err
 			}
 		}
+		dc, err = oauth2.WithToken(ctx, dc)
+		if err != nil {
+			return nil, err
+		}
 
 		// Do request, using local anonymous functions to facilitate timing the request
 		statusCode, responseBody, headers, duration, err := func(req *http.Request) (statusCode int, body []byte, headers values.Dictionary, duration time.Duration, err error) {
 			startTime := time.Now()
-			s, cctx := opentracing.StartSpanFromContext(req.Context(), "requests._do", opentracing.StartTime(startTime))
-			s.SetTag("url", req.URL.String())
+			cctx, s := telemetry.StartSpan(req.Context(), "requests._do", attribute.String("url", req.URL.String()))
 			defer func() {
 				finishTime := time.Now()
-				s.FinishWithOptions(opentracing.FinishOptions{
-					FinishTime: finishTime,
-				})
+				s.End(trace.WithTimestamp(finishTime))
 				// set duration to return
 				duration = finishTime.Sub(startTime)
 			}()
@@ -188,9 +201,9 @@ var do = values.NewFunction(
 			if err != nil {
 				return
 			}
-			s.LogFields(
-				log.Int("statusCode", response.StatusCode),
-				log.Int("responseSize", len(body)),
+			s.SetAttributes(
+				attribute.Int("statusCode", response.StatusCode),
+				attribute.Int("responseSize", len(body)),
 			)
 			headers, err = headerToDict(response.Header)
 			if err != nil {
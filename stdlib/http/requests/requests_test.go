@@ -131,6 +131,42 @@ requests.do(method: "GET", url:"http://127.1.1.1:8888/path/a/b/c", headers: ["x"
 	}
 }
 
+func TestDo_PolicyDenied(t *testing.T) {
+	var called bool
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+		called = true
+		w.WriteHeader(204)
+	}))
+	defer ts.Close()
+
+	script := fmt.Sprintf(`
+import "http/requests"
+
+requests.do(method: "GET", url:"%s/path/a/b/c")
+`, ts.URL)
+
+	validator, err := url.NewPolicyValidator(url.PolicyConfig{
+		Functions: map[string]url.Policy{
+			"http/requests.do": {DenyCIDRs: []string{"127.0.0.0/8"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	deps := flux.NewDefaultDependencies()
+	deps.Deps.HTTPClient = fhttp.NewLimitedDefaultClient(url.PassValidator{})
+	deps.Deps.URLValidator = validator
+	ctx := deps.Inject(context.Background())
+	if _, _, err := runtime.Eval(ctx, script); err == nil {
+		t.Fatal("expected failure")
+	} else if !strings.Contains(err.Error(), "no such host") {
+		t.Errorf("unexpected cause of failure, got err: %v", err)
+	}
+	if called {
+		t.Error("unexpected request to a denied host")
+	}
+}
+
 func TestDo_DNSFail(t *testing.T) {
 	script := `
 import "http/requests"
@@ -5,6 +5,9 @@
 package influxdb
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/InfluxCommunity/flux"
 	"github.com/InfluxCommunity/flux/codes"
 	"github.com/InfluxCommunity/flux/dependencies/influxdb"
@@ -12,6 +15,7 @@ import (
 	"github.com/InfluxCommunity/flux/internal/errors"
 	"github.com/InfluxCommunity/flux/plan"
 	"github.com/InfluxCommunity/flux/runtime"
+	"github.com/InfluxCommunity/flux/semantic"
 )
 
 const (
@@ -33,6 +37,12 @@ type (
 
 	// PredicateSet holds a set of predicates that will filter the results.
 	PredicateSet = influxdb.PredicateSet
+
+	// Transformation is a function call applied by the influxdb instance.
+	Transformation = influxdb.Transformation
+
+	// Transformations holds the transformations applied after the predicates.
+	Transformations = influxdb.Transformations
 )
 
 type FromOpSpec struct {
@@ -52,6 +62,11 @@ func init() {
 		FromRemoteRule{},
 		MergeRemoteRangeRule{},
 		MergeRemoteFilterRule{},
+		MergeRemoteAggregateWindowRule{},
+		MergeRemoteGroupRule{},
+		MergeRemoteSelectorRule{},
+		MergeRemoteCountRule{},
+		MergeRemoteKeepRule{},
 	)
 }
 
@@ -167,6 +182,10 @@ type FromRemoteProcedureSpec struct {
 	influxdb.Config
 	Bounds       flux.Bounds
 	PredicateSet influxdb.PredicateSet
+
+	// Transformations are applied by the remote host
+	// after the predicates.
+	Transformations influxdb.Transformations
 }
 
 func (s *FromRemoteProcedureSpec) Kind() plan.ProcedureKind {
@@ -191,6 +210,7 @@ func (s *FromRemoteProcedureSpec) Copy() plan.ProcedureSpec {
 	ns := new(FromRemoteProcedureSpec)
 	*ns = *s
 	ns.PredicateSet = s.PredicateSet.Copy()
+	ns.Transformations = s.Transformations.Copy()
 	return ns
 }
 
@@ -207,6 +227,50 @@ func (s *FromRemoteProcedureSpec) PostPhysicalValidate(id plan.NodeID) error {
 	return nil
 }
 
+var _ execute.BoundedCacheableProcedureSpec = (*FromRemoteProcedureSpec)(nil)
+
+// CacheKey implements execute.CacheableProcedureSpec.
+// The key identifies the remote host, the bucket and the
+// predicates that the host applies.
+//
+// Specs with transformations are not cached. Their results, such as
+// the windows of an aggregate, depend on the bounds of the read so the
+// results for adjacent bounds cannot be merged.
+func (s *FromRemoteProcedureSpec) CacheKey() (string, bool) {
+	if len(s.Transformations) > 0 {
+		return "", false
+	}
+
+	var sb strings.Builder
+	for _, v := range []string{s.Org.Name, s.Org.ID, s.Bucket.Name, s.Bucket.ID, s.Host, s.Token} {
+		sb.WriteString(v)
+		sb.WriteByte(0)
+	}
+	for _, p := range s.PredicateSet {
+		if p.Fn == nil {
+			return "", false
+		}
+		fmt.Fprintf(&sb, "%v\x00%t\x00", semantic.Formatted(p.Fn), p.KeepEmpty)
+	}
+	return sb.String(), true
+}
+
+// CacheBounds implements execute.BoundedCacheableProcedureSpec.
+func (s *FromRemoteProcedureSpec) CacheBounds() execute.Bounds {
+	return execute.FromFluxBounds(s.Bounds)
+}
+
+// WithCacheBounds implements execute.BoundedCacheableProcedureSpec.
+func (s *FromRemoteProcedureSpec) WithCacheBounds(bounds execute.Bounds) plan.ProcedureSpec {
+	ns := s.Copy().(*FromRemoteProcedureSpec)
+	ns.Bounds = flux.Bounds{
+		Start: flux.Time{Absolute: bounds.Start.Time()},
+		Stop:  flux.Time{Absolute: bounds.Stop.Time()},
+		Now:   s.Bounds.Now,
+	}
+	return ns
+}
+
 func createFromSource(ps plan.ProcedureSpec, id execute.DatasetID, a execute.Administration) (execute.Source, error) {
 	spec := ps.(*FromRemoteProcedureSpec)
 	if spec.Bounds.IsEmpty() {
@@ -214,7 +278,19 @@ func createFromSource(ps plan.ProcedureSpec, id execute.DatasetID, a execute.Adm
 	}
 
 	provider := influxdb.GetProvider(a.Context())
-	reader, err := provider.ReaderFor(a.Context(), spec.Config, spec.Bounds, spec.PredicateSet)
+	var (
+		reader influxdb.Reader
+		err    error
+	)
+	if len(spec.Transformations) > 0 {
+		tp, ok := provider.(influxdb.TransformedReaderProvider)
+		if !ok {
+			return nil, errors.New(codes.Unimplemented, "influxdb transformed reader has not been implemented")
+		}
+		reader, err = tp.TransformedReaderFor(a.Context(), spec.Config, spec.Bounds, spec.PredicateSet, spec.Transformations)
+	} else {
+		reader, err = provider.ReaderFor(a.Context(), spec.Config, spec.Bounds, spec.PredicateSet)
+	}
 	if err != nil {
 		return nil, err
 	}
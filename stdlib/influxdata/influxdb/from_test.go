@@ -474,3 +474,59 @@ func mustParseTime(v string) time.Time {
 	}
 	return t
 }
+
+func TestFromRemoteProcedureSpec_Cache(t *testing.T) {
+	now := mustParseTime("2020-10-22T09:30:00Z")
+	spec := &influxdb.FromRemoteProcedureSpec{
+		Config: influxdb.Config{
+			Org:    influxdb.NameOrID{Name: "influxdata"},
+			Bucket: influxdb.NameOrID{Name: "telegraf"},
+			Token:  "mytoken",
+		},
+		Bounds: flux.Bounds{
+			Start: flux.Time{IsRelative: true, Relative: -time.Minute},
+			Stop:  flux.Time{IsRelative: true},
+			Now:   now,
+		},
+	}
+
+	bounds := spec.CacheBounds()
+	if want := (execute.Bounds{
+		Start: values.ConvertTime(now.Add(-time.Minute)),
+		Stop:  values.ConvertTime(now),
+	}); !bounds.Equal(want) {
+		t.Fatalf("unexpected bounds: want %v, got %v", want, bounds)
+	}
+
+	// Reading other bounds does not change the key.
+	key, ok := spec.CacheKey()
+	if !ok {
+		t.Fatal("expected the spec to be cacheable")
+	}
+	later := execute.Bounds{Start: bounds.Stop, Stop: bounds.Stop.Add(values.ConvertDurationNsecs(time.Minute))}
+	other := spec.WithCacheBounds(later).(*influxdb.FromRemoteProcedureSpec)
+	if got := other.CacheBounds(); !got.Equal(later) {
+		t.Errorf("unexpected bounds: want %v, got %v", later, got)
+	}
+	if otherKey, _ := other.CacheKey(); otherKey != key {
+		t.Errorf("expected the key to be independent of the bounds")
+	}
+	if got := spec.CacheBounds(); !got.Equal(bounds) {
+		t.Errorf("expected the spec to be unchanged, got bounds %v", got)
+	}
+
+	// Another bucket has another key.
+	other = spec.Copy().(*influxdb.FromRemoteProcedureSpec)
+	other.Bucket = influxdb.NameOrID{Name: "other"}
+	if otherKey, _ := other.CacheKey(); otherKey == key {
+		t.Errorf("expected the key to depend on the bucket")
+	}
+
+	// The results of transformations depend on the bounds so
+	// they cannot be merged and are not cached.
+	other = spec.Copy().(*influxdb.FromRemoteProcedureSpec)
+	other.Transformations = influxdb.Transformations{{Kind: "last"}}
+	if _, ok := other.CacheKey(); ok {
+		t.Errorf("expected a spec with transformations not to be cacheable")
+	}
+}
@@ -3,7 +3,10 @@ package influxdb
 import (
 	"context"
 
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/ast"
 	"github.com/InfluxCommunity/flux/dependencies/influxdb"
+	"github.com/InfluxCommunity/flux/execute"
 	"github.com/InfluxCommunity/flux/plan"
 	"github.com/InfluxCommunity/flux/stdlib/universe"
 )
@@ -82,7 +85,7 @@ func (p MergeRemoteFilterRule) Pattern() plan.Pattern {
 func (p MergeRemoteFilterRule) Rewrite(ctx context.Context, node plan.Node) (plan.Node, bool, error) {
 	fromNode := node.Predecessors()[0]
 	fromSpec := fromNode.ProcedureSpec().(*FromRemoteProcedureSpec)
-	if fromSpec.Bounds.IsEmpty() {
+	if fromSpec.Bounds.IsEmpty() || len(fromSpec.Transformations) > 0 {
 		return node, false, nil
 	}
 	filterSpec := node.ProcedureSpec().(*universe.FilterProcedureSpec)
@@ -111,6 +114,315 @@ func (p MergeRemoteFilterRule) Rewrite(ctx context.Context, node plan.Node) (pla
 	return n, true, nil
 }
 
+// mergeRemoteTransformation merges the node into its remote from
+// predecessor so the transformation is applied by the remote host.
+// The node is left unchanged when the from has not been bounded
+// or the provider does not implement influxdb.TransformedReaderProvider.
+func mergeRemoteTransformation(ctx context.Context, node plan.Node, t influxdb.Transformation) (plan.Node, bool, error) {
+	fromNode := node.Predecessors()[0]
+	fromSpec := fromNode.ProcedureSpec().(*FromRemoteProcedureSpec)
+	if fromSpec.Bounds.IsEmpty() {
+		return node, false, nil
+	}
+	if _, ok := influxdb.GetProvider(ctx).(influxdb.TransformedReaderProvider); !ok {
+		return node, false, nil
+	}
+
+	fromSpec = fromSpec.Copy().(*FromRemoteProcedureSpec)
+	fromSpec.Transformations = append(fromSpec.Transformations, t)
+
+	n, err := plan.MergeToPhysicalNode(node, fromNode, fromSpec)
+	if err != nil {
+		return nil, false, err
+	}
+	return n, true, nil
+}
+
+func property(key string, value ast.Expression) *ast.Property {
+	return &ast.Property{
+		Key:   &ast.Identifier{Name: key},
+		Value: value,
+	}
+}
+
+func stringArrayLiteral(values []string) *ast.ArrayExpression {
+	elements := make([]ast.Expression, len(values))
+	for i, v := range values {
+		elements[i] = ast.StringLiteralFromValue(v)
+	}
+	return &ast.ArrayExpression{Elements: elements}
+}
+
+func durationLiteral(d flux.Duration) ast.Expression {
+	if d.IsZero() {
+		return &ast.DurationLiteral{
+			Values: []ast.Duration{{Magnitude: 0, Unit: ast.SecondUnit}},
+		}
+	}
+
+	// The values of a duration are always positive
+	// so a negative duration is negated explicitly.
+	lit := &ast.DurationLiteral{Values: d.AsValues()}
+	if d.IsNegative() {
+		return &ast.UnaryExpression{
+			Operator: ast.SubtractionOperator,
+			Argument: lit,
+		}
+	}
+	return lit
+}
+
+// MergeRemoteAggregateWindowRule merges an aggregateWindow
+// into a remote from so windows are aggregated by the remote host.
+type MergeRemoteAggregateWindowRule struct{}
+
+func (p MergeRemoteAggregateWindowRule) Name() string {
+	return "influxdata/influxdb.MergeRemoteAggregateWindowRule"
+}
+
+func (p MergeRemoteAggregateWindowRule) Pattern() plan.Pattern {
+	return plan.MultiSuccessor(universe.AggregateWindowKind, plan.SingleSuccessor(FromRemoteKind))
+}
+
+func (p MergeRemoteAggregateWindowRule) Rewrite(ctx context.Context, node plan.Node) (plan.Node, bool, error) {
+	spec := node.ProcedureSpec().(*universe.AggregateWindowProcedureSpec)
+	windowSpec := spec.WindowSpec
+
+	// A non-UTC location cannot be reconstructed as a literal
+	// and an aggregate that is forced without creating empty
+	// windows does not correspond to any call to aggregateWindow.
+	if !windowSpec.Window.Location.IsUTC() || windowSpec.CreateEmpty != spec.ForceAggregate {
+		return node, false, nil
+	}
+
+	var fn string
+	switch spec.AggregateKind {
+	case universe.CountKind:
+		fn = "count"
+	case universe.SumKind:
+		fn = "sum"
+	case universe.MeanKind:
+		fn = "mean"
+	default:
+		return node, false, nil
+	}
+
+	timeSrc := execute.DefaultStopColLabel
+	if spec.UseStart {
+		timeSrc = execute.DefaultStartColLabel
+	}
+
+	args := []*ast.Property{
+		property("every", durationLiteral(windowSpec.Window.Every)),
+	}
+	if !windowSpec.Window.Period.IsZero() {
+		args = append(args, property("period", durationLiteral(windowSpec.Window.Period)))
+	}
+	if !windowSpec.Window.Offset.IsZero() {
+		args = append(args, property("offset", durationLiteral(windowSpec.Window.Offset)))
+	}
+	args = append(args,
+		property("fn", &ast.Identifier{Name: fn}),
+		property("column", ast.StringLiteralFromValue(spec.ValueCol)),
+		property("timeSrc", ast.StringLiteralFromValue(timeSrc)),
+		property("createEmpty", ast.BooleanLiteralFromValue(windowSpec.CreateEmpty)),
+	)
+	return mergeRemoteTransformation(ctx, node, influxdb.Transformation{
+		Kind:      "aggregateWindow",
+		Arguments: args,
+	})
+}
+
+// MergeRemoteGroupRule merges a group into a remote from.
+type MergeRemoteGroupRule struct{}
+
+func (p MergeRemoteGroupRule) Name() string {
+	return "influxdata/influxdb.MergeRemoteGroupRule"
+}
+
+func (p MergeRemoteGroupRule) Pattern() plan.Pattern {
+	return plan.MultiSuccessor(universe.GroupKind, plan.SingleSuccessor(FromRemoteKind))
+}
+
+func (p MergeRemoteGroupRule) Rewrite(ctx context.Context, node plan.Node) (plan.Node, bool, error) {
+	spec := node.ProcedureSpec().(*universe.GroupProcedureSpec)
+
+	var mode string
+	switch spec.GroupMode {
+	case flux.GroupModeBy:
+		mode = "by"
+	case flux.GroupModeExcept:
+		mode = "except"
+	default:
+		return node, false, nil
+	}
+	return mergeRemoteTransformation(ctx, node, influxdb.Transformation{
+		Kind: "group",
+		Arguments: []*ast.Property{
+			property("columns", stringArrayLiteral(spec.GroupKeys)),
+			property("mode", ast.StringLiteralFromValue(mode)),
+		},
+	})
+}
+
+// MergeRemoteSelectorRule merges a first or last into a remote from.
+type MergeRemoteSelectorRule struct{}
+
+func (p MergeRemoteSelectorRule) Name() string {
+	return "influxdata/influxdb.MergeRemoteSelectorRule"
+}
+
+func (p MergeRemoteSelectorRule) Pattern() plan.Pattern {
+	return plan.MultiSuccessorOneOf([]plan.ProcedureKind{universe.FirstKind, universe.LastKind},
+		plan.SingleSuccessor(FromRemoteKind))
+}
+
+func (p MergeRemoteSelectorRule) Rewrite(ctx context.Context, node plan.Node) (plan.Node, bool, error) {
+	var (
+		kind   string
+		config execute.SelectorConfig
+	)
+	switch spec := node.ProcedureSpec().(type) {
+	case *universe.FirstProcedureSpec:
+		kind, config = "first", spec.SelectorConfig
+	case *universe.LastProcedureSpec:
+		kind, config = "last", spec.SelectorConfig
+	default:
+		return node, false, nil
+	}
+	return mergeRemoteTransformation(ctx, node, influxdb.Transformation{
+		Kind: kind,
+		Arguments: []*ast.Property{
+			property("column", ast.StringLiteralFromValue(config.Column)),
+		},
+	})
+}
+
+// MergeRemoteCountRule merges a count into a remote from.
+type MergeRemoteCountRule struct{}
+
+func (p MergeRemoteCountRule) Name() string {
+	return "influxdata/influxdb.MergeRemoteCountRule"
+}
+
+func (p MergeRemoteCountRule) Pattern() plan.Pattern {
+	return plan.MultiSuccessor(universe.CountKind, plan.SingleSuccessor(FromRemoteKind))
+}
+
+func (p MergeRemoteCountRule) Rewrite(ctx context.Context, node plan.Node) (plan.Node, bool, error) {
+	spec := node.ProcedureSpec().(*universe.CountProcedureSpec)
+	if len(spec.Columns) != 1 {
+		return node, false, nil
+	}
+	return mergeRemoteTransformation(ctx, node, influxdb.Transformation{
+		Kind: "count",
+		Arguments: []*ast.Property{
+			property("column", ast.StringLiteralFromValue(spec.Columns[0])),
+		},
+	})
+}
+
+// MergeRemoteAggregateRule merges an aggregate into a remote from
+// so the aggregates of each table are computed by the remote host.
+//
+// This rule is not added by default because remote hosts only
+// support aggregate() when they run this version of Flux, such as
+// flux serve. A process that only queries such hosts may add it.
+type MergeRemoteAggregateRule struct{}
+
+func (p MergeRemoteAggregateRule) Name() string {
+	return "influxdata/influxdb.MergeRemoteAggregateRule"
+}
+
+func (p MergeRemoteAggregateRule) Pattern() plan.Pattern {
+	return plan.MultiSuccessor(universe.AggregateKind, plan.SingleSuccessor(FromRemoteKind))
+}
+
+func (p MergeRemoteAggregateRule) Rewrite(ctx context.Context, node plan.Node) (plan.Node, bool, error) {
+	spec := node.ProcedureSpec().(*universe.AggregateProcedureSpec)
+
+	fns := make([]*ast.Property, len(spec.Fns))
+	for i, fn := range spec.Fns {
+		var value ast.Expression
+		switch fn.Kind {
+		case universe.CountKind, universe.SumKind, universe.MeanKind,
+			universe.SpreadKind, universe.SkewKind,
+			universe.MinKind, universe.MaxKind,
+			universe.FirstKind, universe.LastKind:
+			value = &ast.Identifier{Name: string(fn.Kind)}
+		case universe.StddevKind:
+			value = aggregateFunction("stddev",
+				property("mode", ast.StringLiteralFromValue(fn.Mode)),
+			)
+		case universe.QuantileKind:
+			value = aggregateFunction("quantile",
+				property("q", ast.FloatLiteralFromValue(fn.Quantile)),
+				property("compression", ast.FloatLiteralFromValue(fn.Compression)),
+			)
+		default:
+			return node, false, nil
+		}
+		fns[i] = &ast.Property{
+			Key:   ast.StringLiteralFromValue(fn.Name),
+			Value: value,
+		}
+	}
+	return mergeRemoteTransformation(ctx, node, influxdb.Transformation{
+		Kind: "aggregate",
+		Arguments: []*ast.Property{
+			property("columns", stringArrayLiteral(spec.Columns)),
+			property("fns", &ast.ObjectExpression{Properties: fns}),
+		},
+	})
+}
+
+// aggregateFunction returns the function
+// (tables=<-, column) => tables |> name(args..., column: column).
+func aggregateFunction(name string, args ...*ast.Property) *ast.FunctionExpression {
+	args = append(args, property("column", &ast.Identifier{Name: "column"}))
+	return &ast.FunctionExpression{
+		Params: []*ast.Property{
+			property("tables", &ast.PipeLiteral{}),
+			{Key: &ast.Identifier{Name: "column"}},
+		},
+		Body: &ast.PipeExpression{
+			Argument: &ast.Identifier{Name: "tables"},
+			Call: &ast.CallExpression{
+				Callee:    &ast.Identifier{Name: name},
+				Arguments: []ast.Expression{&ast.ObjectExpression{Properties: args}},
+			},
+		},
+	}
+}
+
+// MergeRemoteKeepRule merges a keep with a list of columns into a remote from.
+type MergeRemoteKeepRule struct{}
+
+func (p MergeRemoteKeepRule) Name() string {
+	return "influxdata/influxdb.MergeRemoteKeepRule"
+}
+
+func (p MergeRemoteKeepRule) Pattern() plan.Pattern {
+	return plan.MultiSuccessor(universe.SchemaMutationKind, plan.SingleSuccessor(FromRemoteKind))
+}
+
+func (p MergeRemoteKeepRule) Rewrite(ctx context.Context, node plan.Node) (plan.Node, bool, error) {
+	spec := node.ProcedureSpec().(*universe.SchemaMutationProcedureSpec)
+	if len(spec.Mutations) != 1 {
+		return node, false, nil
+	}
+	keepSpec, ok := spec.Mutations[0].(*universe.KeepOpSpec)
+	if !ok || keepSpec.Predicate.Fn != nil {
+		return node, false, nil
+	}
+	return mergeRemoteTransformation(ctx, node, influxdb.Transformation{
+		Kind: "keep",
+		Arguments: []*ast.Property{
+			property("columns", stringArrayLiteral(keepSpec.Columns)),
+		},
+	})
+}
+
 type BucketsRemoteRule struct{}
 
 func (p BucketsRemoteRule) Name() string {
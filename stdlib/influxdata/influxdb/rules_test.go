@@ -6,8 +6,10 @@ import (
 	"time"
 
 	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/ast"
 	"github.com/InfluxCommunity/flux/codes"
 	influxdeps "github.com/InfluxCommunity/flux/dependencies/influxdb"
+	"github.com/InfluxCommunity/flux/execute"
 	"github.com/InfluxCommunity/flux/execute/executetest"
 	"github.com/InfluxCommunity/flux/internal/errors"
 	"github.com/InfluxCommunity/flux/interpreter"
@@ -352,6 +354,351 @@ func TestMergeRemoteFilterRule(t *testing.T) {
 	plantest.PhysicalRuleTestHelper(t, &tc)
 }
 
+func TestMergeRemoteTransformationRules(t *testing.T) {
+	deps := flux.NewDefaultDependencies()
+	ctx := deps.Inject(context.Background())
+	ctx = influxdeps.Dependency{
+		Provider: influxdeps.HttpProvider{},
+	}.Inject(ctx)
+
+	fromSpec := influxdb.FromProcedureSpec{
+		Bucket: influxdb.NameOrID{Name: "telegraf"},
+		Host:   stringPtr("http://localhost:8086"),
+	}
+	rangeSpec := universe.RangeProcedureSpec{
+		Bounds: flux.Bounds{
+			Start: flux.Time{
+				IsRelative: true,
+				Relative:   -time.Hour,
+			},
+			Stop: flux.Time{
+				IsRelative: true,
+			},
+		},
+	}
+	aggregateWindowSpec := universe.AggregateWindowProcedureSpec{
+		WindowSpec: &universe.WindowProcedureSpec{
+			Window: plan.WindowSpec{
+				Every:  flux.ConvertDuration(time.Minute),
+				Period: flux.ConvertDuration(time.Minute),
+				Offset: flux.ConvertDuration(-30 * time.Second),
+			},
+			TimeColumn:  "_time",
+			StartColumn: "_start",
+			StopColumn:  "_stop",
+			CreateEmpty: true,
+		},
+		AggregateKind:  universe.MeanKind,
+		ValueCol:       "_value",
+		ForceAggregate: true,
+	}
+	groupSpec := universe.GroupProcedureSpec{
+		GroupMode: flux.GroupModeBy,
+		GroupKeys: []string{"host"},
+	}
+	lastSpec := universe.LastProcedureSpec{
+		SelectorConfig: execute.SelectorConfig{Column: "_value"},
+	}
+
+	tc := plantest.RuleTestCase{
+		Name:    "MergeRemoteTransformations",
+		Context: ctx,
+		Rules: []plan.Rule{
+			influxdb.FromRemoteRule{},
+			influxdb.MergeRemoteRangeRule{},
+			influxdb.MergeRemoteAggregateWindowRule{},
+			influxdb.MergeRemoteGroupRule{},
+			influxdb.MergeRemoteSelectorRule{},
+		},
+		Before: &plantest.PlanSpec{
+			Nodes: []plan.Node{
+				plan.CreateLogicalNode("from", &fromSpec),
+				plan.CreateLogicalNode("range", &rangeSpec),
+				plan.CreatePhysicalNode("aggregateWindow", &aggregateWindowSpec),
+				plan.CreateLogicalNode("group", &groupSpec),
+				plan.CreateLogicalNode("last", &lastSpec),
+			},
+			Edges: [][2]int{
+				{0, 1},
+				{1, 2},
+				{2, 3},
+				{3, 4},
+			},
+		},
+		After: &plantest.PlanSpec{
+			Nodes: []plan.Node{
+				plan.CreatePhysicalNode("merged_fromRemote_range_aggregateWindow_group_last", &influxdb.FromRemoteProcedureSpec{
+					Config: influxdb.Config{
+						Bucket: fromSpec.Bucket,
+						Host:   *fromSpec.Host,
+					},
+					Bounds: rangeSpec.Bounds,
+					Transformations: influxdb.Transformations{
+						{
+							Kind: "aggregateWindow",
+							Arguments: []*ast.Property{
+								property("every", &ast.DurationLiteral{Values: []ast.Duration{{Magnitude: 1, Unit: ast.MinuteUnit}}}),
+								property("period", &ast.DurationLiteral{Values: []ast.Duration{{Magnitude: 1, Unit: ast.MinuteUnit}}}),
+								property("offset", &ast.UnaryExpression{
+									Operator: ast.SubtractionOperator,
+									Argument: &ast.DurationLiteral{Values: []ast.Duration{{Magnitude: 30, Unit: ast.SecondUnit}}},
+								}),
+								property("fn", &ast.Identifier{Name: "mean"}),
+								property("column", &ast.StringLiteral{Value: "_value"}),
+								property("timeSrc", &ast.StringLiteral{Value: "_stop"}),
+								property("createEmpty", &ast.BooleanLiteral{Value: true}),
+							},
+						},
+						{
+							Kind: "group",
+							Arguments: []*ast.Property{
+								property("columns", &ast.ArrayExpression{
+									Elements: []ast.Expression{&ast.StringLiteral{Value: "host"}},
+								}),
+								property("mode", &ast.StringLiteral{Value: "by"}),
+							},
+						},
+						{
+							Kind: "last",
+							Arguments: []*ast.Property{
+								property("column", &ast.StringLiteral{Value: "_value"}),
+							},
+						},
+					},
+				}),
+			},
+		},
+	}
+	plantest.PhysicalRuleTestHelper(t, &tc)
+}
+
+func TestMergeRemoteTransformationRules_WithoutRange(t *testing.T) {
+	deps := flux.NewDefaultDependencies()
+	ctx := deps.Inject(context.Background())
+	ctx = influxdeps.Dependency{
+		Provider: influxdeps.HttpProvider{},
+	}.Inject(ctx)
+
+	tc := plantest.RuleTestCase{
+		Name:    "WithoutRange",
+		Context: ctx,
+		Rules: []plan.Rule{
+			influxdb.FromRemoteRule{},
+			influxdb.MergeRemoteCountRule{},
+		},
+		Before: &plantest.PlanSpec{
+			Nodes: []plan.Node{
+				plan.CreateLogicalNode("from", &influxdb.FromProcedureSpec{
+					Bucket: influxdb.NameOrID{Name: "telegraf"},
+					Host:   stringPtr("http://localhost:8086"),
+				}),
+				plan.CreateLogicalNode("count", &universe.CountProcedureSpec{
+					SimpleAggregateConfig: execute.SimpleAggregateConfig{Columns: []string{"_value"}},
+				}),
+			},
+			Edges: [][2]int{{0, 1}},
+		},
+		After: &plantest.PlanSpec{
+			Nodes: []plan.Node{
+				plan.CreatePhysicalNode("fromRemote", &influxdb.FromRemoteProcedureSpec{
+					Config: influxdb.Config{
+						Bucket: influxdb.NameOrID{Name: "telegraf"},
+						Host:   "http://localhost:8086",
+					},
+				}),
+				plan.CreateLogicalNode("count", &universe.CountProcedureSpec{
+					SimpleAggregateConfig: execute.SimpleAggregateConfig{Columns: []string{"_value"}},
+				}),
+			},
+			Edges: [][2]int{{0, 1}},
+		},
+		SkipValidation: true,
+	}
+	plantest.PhysicalRuleTestHelper(t, &tc)
+}
+
+func TestMergeRemoteTransformationRules_Unsupported(t *testing.T) {
+	deps := flux.NewDefaultDependencies()
+	ctx := deps.Inject(context.Background())
+	ctx = influxdeps.Dependency{
+		// Embedding the Provider interface hides TransformedReaderFor.
+		Provider: struct{ influxdeps.Provider }{influxdeps.HttpProvider{}},
+	}.Inject(ctx)
+
+	fromSpec := influxdb.FromProcedureSpec{
+		Bucket: influxdb.NameOrID{Name: "telegraf"},
+		Host:   stringPtr("http://localhost:8086"),
+	}
+	rangeSpec := universe.RangeProcedureSpec{
+		Bounds: flux.Bounds{
+			Start: flux.Time{
+				IsRelative: true,
+				Relative:   -time.Hour,
+			},
+			Stop: flux.Time{
+				IsRelative: true,
+			},
+		},
+	}
+	countSpec := universe.CountProcedureSpec{
+		SimpleAggregateConfig: execute.SimpleAggregateConfig{Columns: []string{"_value"}},
+	}
+
+	tc := plantest.RuleTestCase{
+		Name:    "Unsupported",
+		Context: ctx,
+		Rules: []plan.Rule{
+			influxdb.FromRemoteRule{},
+			influxdb.MergeRemoteRangeRule{},
+			influxdb.MergeRemoteCountRule{},
+		},
+		Before: &plantest.PlanSpec{
+			Nodes: []plan.Node{
+				plan.CreateLogicalNode("from", &fromSpec),
+				plan.CreateLogicalNode("range", &rangeSpec),
+				plan.CreateLogicalNode("count", &countSpec),
+			},
+			Edges: [][2]int{{0, 1}, {1, 2}},
+		},
+		After: &plantest.PlanSpec{
+			Nodes: []plan.Node{
+				plan.CreatePhysicalNode("merged_fromRemote_range", &influxdb.FromRemoteProcedureSpec{
+					Config: influxdb.Config{
+						Bucket: fromSpec.Bucket,
+						Host:   *fromSpec.Host,
+					},
+					Bounds: rangeSpec.Bounds,
+				}),
+				plan.CreateLogicalNode("count", &countSpec),
+			},
+			Edges: [][2]int{{0, 1}},
+		},
+	}
+	plantest.PhysicalRuleTestHelper(t, &tc)
+}
+
+func TestMergeRemoteAggregateRule(t *testing.T) {
+	deps := flux.NewDefaultDependencies()
+	ctx := deps.Inject(context.Background())
+	ctx = influxdeps.Dependency{
+		Provider: influxdeps.HttpProvider{},
+	}.Inject(ctx)
+
+	fromSpec := influxdb.FromProcedureSpec{
+		Bucket: influxdb.NameOrID{Name: "telegraf"},
+		Host:   stringPtr("http://localhost:8086"),
+	}
+	rangeSpec := universe.RangeProcedureSpec{
+		Bounds: flux.Bounds{
+			Start: flux.Time{
+				IsRelative: true,
+				Relative:   -time.Hour,
+			},
+			Stop: flux.Time{
+				IsRelative: true,
+			},
+		},
+	}
+	aggregateSpec := universe.AggregateProcedureSpec{
+		Columns: []string{"_value", "used"},
+		Fns: []universe.AggregateFunction{
+			{Name: "mean", Kind: universe.MeanKind},
+			{Name: "sd", Kind: universe.StddevKind, Mode: "population"},
+			{Name: "p99.9", Kind: universe.QuantileKind, Quantile: 0.999, Compression: 1000},
+		},
+	}
+
+	// aggregateFunction is (tables=<-, column) => tables |> name(args..., column: column).
+	aggregateFunction := func(name string, args ...*ast.Property) *ast.FunctionExpression {
+		return &ast.FunctionExpression{
+			Params: []*ast.Property{
+				property("tables", &ast.PipeLiteral{}),
+				{Key: &ast.Identifier{Name: "column"}},
+			},
+			Body: &ast.PipeExpression{
+				Argument: &ast.Identifier{Name: "tables"},
+				Call: &ast.CallExpression{
+					Callee: &ast.Identifier{Name: name},
+					Arguments: []ast.Expression{&ast.ObjectExpression{
+						Properties: append(args, property("column", &ast.Identifier{Name: "column"})),
+					}},
+				},
+			},
+		}
+	}
+
+	tc := plantest.RuleTestCase{
+		Name:    "MergeRemoteAggregate",
+		Context: ctx,
+		Rules: []plan.Rule{
+			influxdb.FromRemoteRule{},
+			influxdb.MergeRemoteRangeRule{},
+			influxdb.MergeRemoteAggregateRule{},
+		},
+		Before: &plantest.PlanSpec{
+			Nodes: []plan.Node{
+				plan.CreateLogicalNode("from", &fromSpec),
+				plan.CreateLogicalNode("range", &rangeSpec),
+				plan.CreateLogicalNode("aggregate", &aggregateSpec),
+			},
+			Edges: [][2]int{{0, 1}, {1, 2}},
+		},
+		After: &plantest.PlanSpec{
+			Nodes: []plan.Node{
+				plan.CreatePhysicalNode("merged_fromRemote_range_aggregate", &influxdb.FromRemoteProcedureSpec{
+					Config: influxdb.Config{
+						Bucket: fromSpec.Bucket,
+						Host:   *fromSpec.Host,
+					},
+					Bounds: rangeSpec.Bounds,
+					Transformations: influxdb.Transformations{
+						{
+							Kind: "aggregate",
+							Arguments: []*ast.Property{
+								property("columns", &ast.ArrayExpression{
+									Elements: []ast.Expression{
+										&ast.StringLiteral{Value: "_value"},
+										&ast.StringLiteral{Value: "used"},
+									},
+								}),
+								property("fns", &ast.ObjectExpression{
+									Properties: []*ast.Property{
+										{
+											Key:   &ast.StringLiteral{Value: "mean"},
+											Value: &ast.Identifier{Name: "mean"},
+										},
+										{
+											Key: &ast.StringLiteral{Value: "sd"},
+											Value: aggregateFunction("stddev",
+												property("mode", &ast.StringLiteral{Value: "population"}),
+											),
+										},
+										{
+											Key: &ast.StringLiteral{Value: "p99.9"},
+											Value: aggregateFunction("quantile",
+												property("q", &ast.FloatLiteral{Value: 0.999}),
+												property("compression", &ast.FloatLiteral{Value: 1000}),
+											),
+										},
+									},
+								}),
+							},
+						},
+					},
+				}),
+			},
+		},
+	}
+	plantest.PhysicalRuleTestHelper(t, &tc)
+}
+
+func property(key string, value ast.Expression) *ast.Property {
+	return &ast.Property{
+		Key:   &ast.Identifier{Name: key},
+		Value: value,
+	}
+}
+
 func TestDefaultFromAttributes(t *testing.T) {
 	for _, tc := range []plantest.RuleTestCase{
 		{
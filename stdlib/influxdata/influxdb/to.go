@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"math"
 	"sort"
+	"time"
 
 	"github.com/InfluxCommunity/flux"
 	"github.com/InfluxCommunity/flux/array"
@@ -15,6 +16,7 @@ import (
 	"github.com/InfluxCommunity/flux/execute/table"
 	"github.com/InfluxCommunity/flux/internal/arrowutil"
 	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/internal/telemetry"
 	"github.com/InfluxCommunity/flux/interpreter"
 	"github.com/InfluxCommunity/flux/plan"
 	"github.com/InfluxCommunity/flux/runtime"
@@ -22,7 +24,7 @@ import (
 	"github.com/InfluxCommunity/flux/values"
 	"github.com/apache/arrow/go/v7/arrow/bitutil"
 	"github.com/apache/arrow/go/v7/arrow/memory"
-	"github.com/opentracing/opentracing-go"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // ToKind is the kind for the `to` flux function
@@ -66,7 +68,7 @@ type toTransformation struct {
 	implicitTagColumns bool
 	tagColumns         []string
 	writer             influxdb.Writer
-	span               opentracing.Span
+	span               trace.Span
 }
 
 // NewToTransformation returns a new *ToTransformation with the appropriate fields set.
@@ -85,8 +87,8 @@ func NewToTransformation(ctx context.Context, id execute.DatasetID, spec *ToProc
 		Name: spec.Spec.Bucket,
 	}
 
-	var span opentracing.Span
-	span, ctx = opentracing.StartSpanFromContext(ctx, "ToTransformation.Process")
+	var span trace.Span
+	ctx, span = telemetry.StartSpan(ctx, "ToTransformation.Process")
 
 	conf := influxdb.Config{
 		Org:    org,
@@ -94,8 +96,9 @@ func NewToTransformation(ctx context.Context, id execute.DatasetID, spec *ToProc
 		Host:   spec.Spec.Host,
 		Token:  spec.Spec.Token,
 	}
-	writer, err := deps.WriterFor(ctx, conf)
+	writer, err := writerFor(ctx, deps, conf, spec.Spec.WriteOptions())
 	if err != nil {
+		telemetry.FinishSpan(span, err)
 		return nil, nil, err
 	}
 
@@ -344,8 +347,79 @@ func (t *toTransformation) filterNulls(chunk table.Chunk, mem memory.Allocator)
 }
 
 func (t *toTransformation) Close() error {
-	defer t.span.Finish()
-	return t.writer.Close()
+	err := t.writer.Close()
+	addWriteSummary(t.ctx, t.writer)
+	telemetry.FinishSpan(t.span, err)
+	return err
+}
+
+// writerFor returns a writer for the config. The provider is only asked for
+// a writer with options when options were set so providers without support
+// for write options can still be used with the defaults.
+func writerFor(ctx context.Context, provider influxdb.Provider, conf influxdb.Config, opts influxdb.WriteOptions) (influxdb.Writer, error) {
+	if opts.IsZero() {
+		return provider.WriterFor(ctx, conf)
+	}
+	wp, ok := provider.(influxdb.WriterWithOptionsProvider)
+	if !ok {
+		return nil, errors.New(codes.Unimplemented, "influxdb writer with options has not been implemented")
+	}
+	return wp.WriterWithOptionsFor(ctx, conf, opts)
+}
+
+// WriteSummaryMetadataKey is the metadata key used to report
+// the number of points written and rejected for each measurement.
+// The summary is reported as metadata rather than as a table because
+// to and wideTo output their input tables.
+const WriteSummaryMetadataKey = "influxdata/influxdb/write-summary"
+
+// addWriteSummary adds the summary of a closed writer to the query metadata.
+func addWriteSummary(ctx context.Context, w influxdb.Writer) {
+	sw, ok := w.(influxdb.SummaryWriter)
+	if !ok || !execute.HaveExecutionDependencies(ctx) {
+		return
+	}
+	deps := execute.GetExecutionDependencies(ctx)
+	for _, s := range sw.Summary() {
+		deps.Metadata.Add(WriteSummaryMetadataKey, s)
+	}
+}
+
+// readWriteOptions reads the write options that are shared by to and wideTo.
+func readWriteOptions(args flux.Arguments) (opts influxdb.WriteOptions, err error) {
+	if batchSize, ok, err := args.GetInt("batchSize"); err != nil {
+		return opts, err
+	} else if ok {
+		if batchSize <= 0 {
+			return opts, errors.New(codes.Invalid, "batchSize must be greater than zero")
+		}
+		opts.BatchSize = int(batchSize)
+	}
+
+	if flushInterval, ok, err := args.GetDuration("flushInterval"); err != nil {
+		return opts, err
+	} else if ok {
+		if flushInterval.IsNegative() || flushInterval.Months() != 0 {
+			return opts, errors.New(codes.Invalid, "flushInterval must be a positive duration without months")
+		}
+		opts.FlushInterval = flushInterval.Duration()
+	}
+
+	if gzip, ok, err := args.GetBool("gzip"); err != nil {
+		return opts, err
+	} else if ok {
+		opts.Gzip = gzip
+	}
+
+	if maxRetries, ok, err := args.GetInt("maxRetries"); err != nil {
+		return opts, err
+	} else if ok {
+		if maxRetries < 0 {
+			return opts, errors.New(codes.Invalid, "maxRetries must not be negative")
+		}
+		opts.MaxRetries = int(maxRetries)
+	}
+	return opts, nil
 }
 
 // fieldFunctionVisitor implements semantic.Visitor.
@@ -433,6 +507,20 @@ type ToOpSpec struct {
 	MeasurementColumn string                       `json:"measurementColumn"`
 	TagColumns        []string                     `json:"tagColumns"`
 	FieldFn           interpreter.ResolvedFunction `json:"fieldFn"`
+	BatchSize         int                          `json:"batchSize"`
+	FlushInterval     time.Duration                `json:"flushInterval"`
+	Gzip              bool                         `json:"gzip"`
+	MaxRetries        int                          `json:"maxRetries"`
+}
+
+// WriteOptions returns the options used to write the points.
+func (o *ToOpSpec) WriteOptions() influxdb.WriteOptions {
+	return influxdb.WriteOptions{
+		BatchSize:     o.BatchSize,
+		FlushInterval: o.FlushInterval,
+		Gzip:          o.Gzip,
+		MaxRetries:    o.MaxRetries,
+	}
 }
 
 // ToProcedureSpec is the procedure spec for the `to` flux function.
@@ -469,6 +557,10 @@ func (o *ToProcedureSpec) Copy() plan.ProcedureSpec {
 			MeasurementColumn: s.MeasurementColumn,
 			TagColumns:        append([]string(nil), s.TagColumns...),
 			FieldFn:           s.FieldFn.Copy(),
+			BatchSize:         s.BatchSize,
+			FlushInterval:     s.FlushInterval,
+			Gzip:              s.Gzip,
+			MaxRetries:        s.MaxRetries,
 		},
 	}
 	return res
@@ -542,7 +634,15 @@ func (o *ToOpSpec) ReadArgs(args flux.Arguments) error {
 		}
 	}
 
-	return err
+	opts, err := readWriteOptions(args)
+	if err != nil {
+		return err
+	}
+	o.BatchSize = opts.BatchSize
+	o.FlushInterval = opts.FlushInterval
+	o.Gzip = opts.Gzip
+	o.MaxRetries = opts.MaxRetries
+	return nil
 }
 
 func createToOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
@@ -28,10 +28,11 @@ func init() {
 
 // WideToOpSpec is the flux.OperationSpec for the `to` flux function.
 type WideToOpSpec struct {
-	Org    NameOrID
-	Bucket NameOrID
-	Host   string
-	Token  string
+	Org          NameOrID
+	Bucket       NameOrID
+	Host         string
+	Token        string
+	WriteOptions influxdb.WriteOptions
 }
 
 // ReadArgs reads the args from flux.Arguments into the op spec
@@ -61,6 +62,12 @@ func (s *WideToOpSpec) ReadArgs(args flux.Arguments) error {
 	} else if ok {
 		s.Token = token
 	}
+
+	opts, err := readWriteOptions(args)
+	if err != nil {
+		return err
+	}
+	s.WriteOptions = opts
 	return nil
 }
 
@@ -84,7 +91,8 @@ func (WideToOpSpec) Kind() flux.OperationKind {
 // WideToProcedureSpec is the procedure spec for the `to` flux function.
 type WideToProcedureSpec struct {
 	plan.DefaultCost
-	Config Config
+	Config       Config
+	WriteOptions influxdb.WriteOptions
 }
 
 // Kind returns the kind for the procedure spec for the `to` flux function.
@@ -110,6 +118,7 @@ func newWideToProcedure(qs flux.OperationSpec, a plan.Administration) (plan.Proc
 			Host:   spec.Host,
 			Token:  spec.Token,
 		},
+		WriteOptions: spec.WriteOptions,
 	}, nil
 }
 
@@ -145,7 +154,7 @@ func (t *WideToTransformation) RetractTable(id execute.DatasetID, key flux.Group
 // NewWideToTransformation returns a new *WideToTransformation with the appropriate fields set.
 func NewWideToTransformation(ctx context.Context, d execute.Dataset, cache execute.TableBuilderCache, s *WideToProcedureSpec) (*WideToTransformation, error) {
 	provider := GetProvider(ctx)
-	writer, err := provider.WriterFor(ctx, s.Config)
+	writer, err := writerFor(ctx, provider, s.Config, s.WriteOptions)
 	if err != nil {
 		return nil, err
 	}
@@ -175,6 +184,7 @@ func (t *WideToTransformation) UpdateProcessingTime(id execute.DatasetID, pt exe
 // Finish is called after the `to` flux function's transformation is done processing.
 func (t *WideToTransformation) Finish(id execute.DatasetID, err error) {
 	writeErr := t.writer.Close()
+	addWriteSummary(t.ctx, t.writer)
 	if err == nil {
 		err = writeErr
 	}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/InfluxCommunity/flux"
 	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/internal/regression"
 	"github.com/InfluxCommunity/flux/plan"
 	"github.com/InfluxCommunity/flux/runtime"
 	"github.com/InfluxCommunity/flux/semantic"
@@ -150,10 +151,8 @@ func (t *linearRegressionTransformation) Process(id execute.DatasetID, tbl flux.
 	}
 
 	var (
-		numVals      int
-		sumX, sumY   float64
-		sumXY, sumX2 float64
-		firstTime    time.Time
+		lr        regression.Linear
+		firstTime time.Time
 	)
 	err := tbl.Do(func(cr flux.ColReader) error {
 		vs := cr.Floats(valIdx)
@@ -166,7 +165,7 @@ func (t *linearRegressionTransformation) Process(id execute.DatasetID, tbl flux.
 			v := vs.Value(i)
 			ts := values.Time(times.Value(i)).Time()
 
-			if numVals == 0 {
+			if lr.N() == 0 {
 				// Subtle difference between deriv() and predict_linear() intercept time.
 				if t.predict {
 					firstTime = key.ValueTime(stopIdx).Time()
@@ -176,11 +175,7 @@ func (t *linearRegressionTransformation) Process(id execute.DatasetID, tbl flux.
 			}
 
 			x := float64(ts.Sub(firstTime).Seconds())
-			numVals++
-			sumY += v
-			sumX += x
-			sumXY += x * v
-			sumX2 += x * x
+			lr.Add(x, v)
 		}
 		return nil
 	})
@@ -189,19 +184,13 @@ func (t *linearRegressionTransformation) Process(id execute.DatasetID, tbl flux.
 	}
 
 	// Omit output table if there are not at least two samples to compute a rate from.
-	if numVals < 2 {
+	if lr.N() < 2 {
 		return nil
 	}
 
-	n := float64(numVals)
-	covXY := sumXY - sumX*sumY/n
-	varX := sumX2 - sumX*sumX/n
-
-	slope := covXY / varX
-
+	slope, intercept := lr.Fit()
 	resultValue := slope
 	if t.predict {
-		intercept := sumY/n - slope*sumX/n
 		resultValue = slope*t.fromNow + intercept
 	}
 
@@ -0,0 +1,119 @@
+package interpolate
+
+import "math"
+
+// curve computes interpolated values between the rows of a table.
+type curve interface {
+	// at returns the value of the curve at x,
+	// which lies between the points k and k+1.
+	at(k int, x float64) float64
+}
+
+// linearCurve joins the points with straight lines.
+type linearCurve struct {
+	xs, ys []float64
+}
+
+func (c linearCurve) at(k int, x float64) float64 {
+	m := (c.ys[k+1] - c.ys[k]) / (c.xs[k+1] - c.xs[k])
+	return c.ys[k] + m*(x-c.xs[k])
+}
+
+// cubicSpline is a natural cubic spline through the points.
+type cubicSpline struct {
+	xs, ys []float64
+	// ms are the second derivatives at the points.
+	ms []float64
+}
+
+func newCubicSpline(xs, ys []float64) cubicSpline {
+	n := len(xs)
+	ms := make([]float64, n)
+	if n < 3 {
+		return cubicSpline{xs: xs, ys: ys, ms: ms}
+	}
+
+	// Solve the tridiagonal system for the second derivatives
+	// of the inner points with the Thomas algorithm.
+	// The second derivatives of the first and last points are zero.
+	c := make([]float64, n)
+	d := make([]float64, n)
+	for i := 1; i < n-1; i++ {
+		h0, h1 := xs[i]-xs[i-1], xs[i+1]-xs[i]
+		a, bb, cc := h0, 2*(h0+h1), h1
+		r := 6 * ((ys[i+1]-ys[i])/h1 - (ys[i]-ys[i-1])/h0)
+		if i > 1 {
+			bb -= a * c[i-1]
+			r -= a * d[i-1]
+		}
+		c[i] = cc / bb
+		d[i] = r / bb
+	}
+	ms[n-2] = d[n-2]
+	for i := n - 3; i >= 1; i-- {
+		ms[i] = d[i] - c[i]*ms[i+1]
+	}
+	return cubicSpline{xs: xs, ys: ys, ms: ms}
+}
+
+func (s cubicSpline) at(k int, x float64) float64 {
+	h := s.xs[k+1] - s.xs[k]
+	a := (s.xs[k+1] - x) / h
+	b := (x - s.xs[k]) / h
+	return a*s.ys[k] + b*s.ys[k+1] +
+		((a*a*a-a)*s.ms[k]+(b*b*b-b)*s.ms[k+1])*h*h/6
+}
+
+// akimaSpline is a piecewise cubic Hermite spline through the points
+// whose slopes are computed from the slopes of the nearby segments.
+type akimaSpline struct {
+	xs, ys []float64
+	// ms are the slopes of the segments and
+	// ts are the slopes of the curve at the points.
+	ms, ts []float64
+}
+
+func newAkimaSpline(xs, ys []float64) akimaSpline {
+	n := len(xs)
+	if n < 2 {
+		return akimaSpline{xs: xs, ys: ys}
+	}
+
+	// The slopes of the segments are extended with two
+	// segments on each side so every point has four
+	// segments around it.
+	m := make([]float64, n+3)
+	for i := 0; i < n-1; i++ {
+		m[i+2] = (ys[i+1] - ys[i]) / (xs[i+1] - xs[i])
+	}
+	if n == 2 {
+		m[1], m[0] = m[2], m[2]
+		m[3], m[4] = m[2], m[2]
+	} else {
+		m[1] = 2*m[2] - m[3]
+		m[0] = 2*m[1] - m[2]
+		m[n+1] = 2*m[n] - m[n-1]
+		m[n+2] = 2*m[n+1] - m[n]
+	}
+
+	ts := make([]float64, n)
+	for i := range ts {
+		w1 := math.Abs(m[i+3] - m[i+2])
+		w2 := math.Abs(m[i+1] - m[i])
+		if w1+w2 == 0 {
+			ts[i] = (m[i+1] + m[i+2]) / 2
+		} else {
+			ts[i] = (w1*m[i+1] + w2*m[i+2]) / (w1 + w2)
+		}
+	}
+	return akimaSpline{xs: xs, ys: ys, ms: m[2 : n+1], ts: ts}
+}
+
+func (s akimaSpline) at(k int, x float64) float64 {
+	h := s.xs[k+1] - s.xs[k]
+	dx := x - s.xs[k]
+	m, t0, t1 := s.ms[k], s.ts[k], s.ts[k+1]
+	c2 := (3*m - 2*t0 - t1) / h
+	c3 := (t0 + t1 - 2*m) / (h * h)
+	return s.ys[k] + dx*(t0+dx*(c2+dx*c3))
+}
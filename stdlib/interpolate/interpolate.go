@@ -0,0 +1,530 @@
+package interpolate
+
+import (
+	"math"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/interpreter"
+	"github.com/InfluxCommunity/flux/plan"
+	"github.com/InfluxCommunity/flux/runtime"
+	"github.com/InfluxCommunity/flux/semantic"
+	"github.com/InfluxCommunity/flux/values"
+)
+
+const InterpolateKind = "interpolate"
+
+// LinearInterpolateKind is the kind of the interpolate operations.
+//
+// Deprecated: Use InterpolateKind.
+const LinearInterpolateKind = InterpolateKind
+
+// Interpolation methods.
+const (
+	MethodLinear      = "linear"
+	MethodPrevious    = "previous"
+	MethodNearest     = "nearest"
+	MethodCubicSpline = "cubicSpline"
+	MethodAkima       = "akima"
+)
+
+type InterpolateOpSpec struct {
+	Method  string        `json:"method"`
+	Every   flux.Duration `json:"every"`
+	Columns []string      `json:"columns"`
+	MaxGap  flux.Duration `json:"maxGap"`
+}
+
+func init() {
+	for _, method := range []string{
+		MethodLinear,
+		MethodPrevious,
+		MethodNearest,
+		MethodCubicSpline,
+		MethodAkima,
+	} {
+		runtime.RegisterPackageValue("interpolate", method,
+			flux.MustValue(flux.FunctionValue(method,
+				newCreateInterpolateOpSpec(method),
+				runtime.MustLookupBuiltinType("interpolate", method),
+			)),
+		)
+	}
+	plan.RegisterProcedureSpec(
+		InterpolateKind,
+		newInterpolateProcedure,
+		InterpolateKind,
+	)
+	execute.RegisterTransformation(
+		InterpolateKind,
+		createInterpolateTransformation,
+	)
+}
+
+func newCreateInterpolateOpSpec(method string) flux.CreateOperationSpec {
+	return func(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
+		if err := a.AddParentFromArgs(args); err != nil {
+			return nil, err
+		}
+
+		spec := &InterpolateOpSpec{
+			Method:  method,
+			Columns: []string{execute.DefaultValueColLabel},
+		}
+
+		every, err := args.GetRequiredDuration("every")
+		if err != nil {
+			return nil, err
+		}
+		spec.Every = every
+
+		if columns, ok, err := args.GetArray("columns", semantic.String); err != nil {
+			return nil, err
+		} else if ok {
+			spec.Columns, err = interpreter.ToStringArray(columns)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if maxGap, ok, err := args.GetDuration("maxGap"); err != nil {
+			return nil, err
+		} else if ok {
+			if maxGap.IsNegative() {
+				return nil, errors.New(codes.Invalid, "maxGap must not be negative")
+			}
+			spec.MaxGap = maxGap
+		}
+		return spec, nil
+	}
+}
+
+// LinearInterpolateOpSpec is the operation spec of interpolate.linear.
+//
+// Deprecated: Use InterpolateOpSpec.
+type LinearInterpolateOpSpec = InterpolateOpSpec
+
+func (s *InterpolateOpSpec) Kind() flux.OperationKind {
+	return InterpolateKind
+}
+
+type InterpolateProcedureSpec struct {
+	plan.DefaultCost
+	Method  string        `json:"method"`
+	Every   flux.Duration `json:"every"`
+	Columns []string      `json:"columns"`
+	MaxGap  flux.Duration `json:"maxGap"`
+}
+
+// LinearInterpolateProcedureSpec is the procedure spec of interpolate.linear.
+//
+// Deprecated: Use InterpolateProcedureSpec.
+type LinearInterpolateProcedureSpec = InterpolateProcedureSpec
+
+func newInterpolateProcedure(qs flux.OperationSpec, pa plan.Administration) (plan.ProcedureSpec, error) {
+	spec, ok := qs.(*InterpolateOpSpec)
+	if !ok {
+		return nil, errors.Newf(codes.Internal, "invalid spec type %T", qs)
+	}
+
+	return &InterpolateProcedureSpec{
+		Method:  spec.Method,
+		Every:   spec.Every,
+		Columns: spec.Columns,
+		MaxGap:  spec.MaxGap,
+	}, nil
+}
+
+func (s *InterpolateProcedureSpec) Kind() plan.ProcedureKind {
+	return InterpolateKind
+}
+func (s *InterpolateProcedureSpec) Copy() plan.ProcedureSpec {
+	ns := *s
+	ns.Columns = append([]string(nil), s.Columns...)
+	return &ns
+}
+
+// TriggerSpec implements plan.TriggerAwareProcedureSpec
+func (s *InterpolateProcedureSpec) TriggerSpec() plan.TriggerSpec {
+	return plan.NarrowTransformationTriggerSpec{}
+}
+
+func createInterpolateTransformation(
+	id execute.DatasetID,
+	mode execute.AccumulationMode,
+	spec plan.ProcedureSpec,
+	a execute.Administration) (execute.Transformation, execute.Dataset, error) {
+	s, ok := spec.(*InterpolateProcedureSpec)
+	if !ok {
+		return nil, nil, errors.Newf(codes.Internal, "invalid spec type %T", spec)
+	}
+	cache := execute.NewTableBuilderCache(a.Allocator())
+	d := execute.NewDataset(id, mode, cache)
+	t := NewInterpolateTransformation(d, cache, s)
+	return t, d, nil
+}
+
+type interpolateTransformation struct {
+	execute.ExecutionNode
+	d      execute.Dataset
+	cache  execute.TableBuilderCache
+	spec   InterpolateProcedureSpec
+	window execute.Window
+}
+
+func NewInterpolateTransformation(d execute.Dataset, cache execute.TableBuilderCache, spec *InterpolateProcedureSpec) *interpolateTransformation {
+	t := &interpolateTransformation{
+		d:     d,
+		cache: cache,
+		spec:  *spec,
+		window: execute.Window{
+			Every:  spec.Every,
+			Period: spec.Every,
+		},
+	}
+	if t.spec.Method == "" {
+		t.spec.Method = MethodLinear
+	}
+	if len(t.spec.Columns) == 0 {
+		t.spec.Columns = []string{execute.DefaultValueColLabel}
+	}
+	return t
+}
+
+func (t *interpolateTransformation) RetractTable(id execute.DatasetID, key flux.GroupKey) error {
+	return t.d.RetractTable(key)
+}
+
+// interpolatedColumn holds the values of a column
+// for the rows that are buffered by the transformation.
+type interpolatedColumn struct {
+	idx    int
+	typ    flux.ColType
+	values []values.Value
+	// floats holds the values of float, int and uint columns as floats.
+	floats []float64
+}
+
+// splinePoints is the number of rows on each side of a segment that are
+// used to compute a cubic spline between two rows. The influence of a row
+// on the spline decreases by a factor of about 3.7 for each row between
+// them, so the spline through these rows agrees with the spline through
+// all rows of the table to within floating point precision.
+const splinePoints = 32
+
+// segmentPoints returns the number of rows before and after a row that
+// are needed to interpolate the values between the row and the next one.
+func (t *interpolateTransformation) segmentPoints() (before, after int) {
+	switch t.spec.Method {
+	case MethodCubicSpline:
+		return splinePoints, splinePoints + 1
+	case MethodAkima:
+		return 2, 3
+	default:
+		return 0, 1
+	}
+}
+
+func (t *interpolateTransformation) Process(id execute.DatasetID, tbl flux.Table) error {
+	key, columns := tbl.Key(), tbl.Cols()
+
+	interpolated := make(map[string]bool, len(t.spec.Columns))
+	for _, label := range t.spec.Columns {
+		interpolated[label] = true
+	}
+	for _, c := range columns {
+		if key.HasCol(c.Label) {
+			continue
+		}
+		if c.Label == execute.DefaultTimeColLabel {
+			continue
+		}
+		if interpolated[c.Label] {
+			continue
+		}
+		return errors.Newf(codes.FailedPrecondition,
+			"interpolate.%s requires column %q to be in group key", t.spec.Method, c.Label,
+		)
+	}
+
+	b, created := t.cache.TableBuilder(tbl.Key())
+	if !created {
+		return errors.Newf(codes.FailedPrecondition,
+			"duplicate table with key: %v", tbl.Key(),
+		)
+	}
+
+	if err := execute.AddTableCols(tbl, b); err != nil {
+		return err
+	}
+
+	ti := execute.ColIdx("_time", columns)
+	if ti < 0 {
+		return errors.New(codes.FailedPrecondition,
+			"_time column does not exist",
+		)
+	}
+
+	cols := make([]*interpolatedColumn, len(t.spec.Columns))
+	for j, label := range t.spec.Columns {
+		idx := execute.ColIdx(label, columns)
+		if idx < 0 {
+			return errors.Newf(codes.FailedPrecondition,
+				"%s column does not exist", label,
+			)
+		}
+		if key.HasCol(label) {
+			return errors.Newf(codes.FailedPrecondition,
+				"cannot interpolate column %q that is part of the group key", label,
+			)
+		}
+		ty := columns[idx].Type
+		switch ty {
+		case flux.TFloat, flux.TInt, flux.TUInt:
+		default:
+			if t.spec.Method != MethodPrevious && t.spec.Method != MethodNearest {
+				return errors.Newf(codes.FailedPrecondition,
+					"cannot interpolate %v values; expected float, int or uint values", ty,
+				)
+			}
+		}
+		cols[j] = &interpolatedColumn{idx: idx, typ: ty}
+	}
+
+	// Rows are buffered until the rows needed to interpolate after them
+	// have been read. The rows that are no longer needed are dropped
+	// so only a bounded number of rows is buffered for each table.
+	before, after := t.segmentPoints()
+	s := &interpolateState{
+		t:    t,
+		b:    b,
+		key:  key,
+		ti:   ti,
+		cols: cols,
+	}
+	if err := tbl.Do(func(cr flux.ColReader) error {
+		tc := cr.Times(ti)
+		for i := 0; i < cr.Len(); i++ {
+			if tc.IsNull(i) {
+				return errors.Newf(codes.FailedPrecondition,
+					"null _time found during %s interpolation", t.spec.Method,
+				)
+			}
+			x := tc.Value(i)
+			if n := len(s.times); n > 0 && x <= s.times[n-1] &&
+				(t.spec.Method == MethodCubicSpline || t.spec.Method == MethodAkima) {
+				return errors.Newf(codes.FailedPrecondition,
+					"interpolate.%s requires rows sorted by _time with unique times", t.spec.Method,
+				)
+			}
+			s.times = append(s.times, x)
+			for _, col := range cols {
+				v := execute.ValueForRow(cr, i, col.idx)
+				if v.IsNull() {
+					return errors.Newf(codes.FailedPrecondition,
+						"null %s found during %s interpolation", columns[col.idx].Label, t.spec.Method,
+					)
+				}
+				col.values = append(col.values, v)
+				if f, ok := floatValue(v); ok {
+					col.floats = append(col.floats, f)
+				}
+			}
+			if len(s.times) > s.next+after {
+				if err := s.writeRow(before, after); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	for s.next < len(s.times) {
+		if err := s.writeRow(before, after); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// interpolateState holds the rows of a table that are buffered
+// while it is interpolated.
+type interpolateState struct {
+	t    *interpolateTransformation
+	b    execute.TableBuilder
+	key  flux.GroupKey
+	ti   int
+	cols []*interpolatedColumn
+
+	times []int64
+	// next is the index of the next buffered row to write.
+	next int
+}
+
+// writeRow writes the next buffered row and the rows inserted after it.
+// Rows that are no longer needed for the rows after it are dropped.
+func (s *interpolateState) writeRow(before, after int) error {
+	k := s.next
+	xk := s.times[k]
+	if err := s.appendRow(xk, func(_ int, col *interpolatedColumn) values.Value {
+		return col.values[k]
+	}); err != nil {
+		return err
+	}
+	if k+1 < len(s.times) {
+		if err := s.writeSegment(k, before, after); err != nil {
+			return err
+		}
+	}
+
+	s.next++
+	if drop := s.next - before; drop > 0 {
+		n := copy(s.times, s.times[drop:])
+		s.times = s.times[:n]
+		for _, col := range s.cols {
+			n := copy(col.values, col.values[drop:])
+			col.values = col.values[:n]
+			if len(col.floats) > 0 {
+				n := copy(col.floats, col.floats[drop:])
+				col.floats = col.floats[:n]
+			}
+		}
+		s.next -= drop
+	}
+	return nil
+}
+
+// writeSegment writes the rows inserted between the buffered rows k and k+1.
+func (s *interpolateState) writeSegment(k, before, after int) error {
+	t := s.t
+	xk, xn := s.times[k], s.times[k+1]
+	xi := int64(t.window.GetEarliestBounds(values.Time(xk)).Stop)
+	if xi >= xn {
+		return nil
+	}
+	if !t.spec.MaxGap.IsZero() && execute.Time(xk).Add(t.spec.MaxGap) < execute.Time(xn) {
+		for ; xi < xn; xi = int64(execute.Time(xi).Add(t.window.Every)) {
+			if err := s.appendRow(xi, func(int, *interpolatedColumn) values.Value {
+				return values.Null
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// The curves are computed from the rows around the segment
+	// with times relative to the first of them.
+	lo, hi := k-before, k+after+1
+	if lo < 0 {
+		lo = 0
+	}
+	if hi > len(s.times) {
+		hi = len(s.times)
+	}
+	x0 := s.times[lo]
+	xs := make([]float64, hi-lo)
+	for i := range xs {
+		xs[i] = float64(s.times[lo+i] - x0)
+	}
+	curves := make([]curve, len(s.cols))
+	for j, col := range s.cols {
+		if len(col.floats) == 0 {
+			continue
+		}
+		ys := col.floats[lo:hi]
+		switch t.spec.Method {
+		case MethodLinear:
+			curves[j] = linearCurve{xs: xs, ys: ys}
+		case MethodCubicSpline:
+			curves[j] = newCubicSpline(xs, ys)
+		case MethodAkima:
+			curves[j] = newAkimaSpline(xs, ys)
+		}
+	}
+
+	for ; xi < xn; xi = int64(execute.Time(xi).Add(t.window.Every)) {
+		if err := s.appendRow(xi, func(j int, col *interpolatedColumn) values.Value {
+			switch t.spec.Method {
+			case MethodPrevious:
+				return col.values[k]
+			case MethodNearest:
+				if xn-xi < xi-xk {
+					return col.values[k+1]
+				}
+				return col.values[k]
+			}
+			return fromFloat(col.typ, curves[j].at(k-lo, float64(xi-x0)))
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendRow appends a row at the time with the value of each
+// interpolated column and the key values.
+func (s *interpolateState) appendRow(x int64, fn func(j int, col *interpolatedColumn) values.Value) error {
+	if err := s.b.AppendTime(s.ti, execute.Time(x)); err != nil {
+		return err
+	}
+	for j, col := range s.cols {
+		if err := s.b.AppendValue(col.idx, fn(j, col)); err != nil {
+			return err
+		}
+	}
+	return execute.AppendKeyValues(s.key, s.b)
+}
+
+// floatValue returns a float, int or uint value as a float.
+func floatValue(v values.Value) (float64, bool) {
+	switch v.Type().Nature() {
+	case semantic.Float:
+		return v.Float(), true
+	case semantic.Int:
+		return float64(v.Int()), true
+	case semantic.UInt:
+		return float64(v.UInt()), true
+	default:
+		return 0, false
+	}
+}
+
+// fromFloat converts an interpolated value to the type of the column.
+// Integers are rounded to the nearest value, with halves rounded away
+// from zero, and limited to the range of the type.
+func fromFloat(typ flux.ColType, f float64) values.Value {
+	switch typ {
+	case flux.TInt:
+		f = math.Round(f)
+		if f >= math.MaxInt64 {
+			return values.NewInt(math.MaxInt64)
+		} else if f <= math.MinInt64 {
+			return values.NewInt(math.MinInt64)
+		}
+		return values.NewInt(int64(f))
+	case flux.TUInt:
+		f = math.Round(f)
+		if f >= math.MaxUint64 {
+			return values.NewUInt(math.MaxUint64)
+		} else if f <= 0 {
+			return values.NewUInt(0)
+		}
+		return values.NewUInt(uint64(f))
+	default:
+		return values.NewFloat(f)
+	}
+}
+
+func (t *interpolateTransformation) UpdateWatermark(id execute.DatasetID, mark execute.Time) error {
+	return t.d.UpdateWatermark(mark)
+}
+func (t *interpolateTransformation) UpdateProcessingTime(id execute.DatasetID, pt execute.Time) error {
+	return t.d.UpdateProcessingTime(pt)
+}
+func (t *interpolateTransformation) Finish(id execute.DatasetID, err error) {
+	t.d.Finish(err)
+}
@@ -15,15 +15,16 @@ import (
 func TestLinearInterpolate(t *testing.T) {
 	testCases := []struct {
 		name    string
-		spec    *interpolate.LinearInterpolateProcedureSpec
+		spec    *interpolate.InterpolateProcedureSpec
 		data    []flux.Table
 		want    []*executetest.Table
 		wantErr error
 	}{
 		{
 			name: "basic0",
-			spec: &interpolate.LinearInterpolateProcedureSpec{
-				Every: flux.ConvertDuration(5 * time.Nanosecond),
+			spec: &interpolate.InterpolateProcedureSpec{
+				Method: interpolate.MethodLinear,
+				Every:  flux.ConvertDuration(5 * time.Nanosecond),
 			},
 			data: []flux.Table{&executetest.Table{
 				ColMeta: []flux.ColMeta{
@@ -49,8 +50,9 @@ func TestLinearInterpolate(t *testing.T) {
 		},
 		{
 			name: "basic1",
-			spec: &interpolate.LinearInterpolateProcedureSpec{
-				Every: flux.ConvertDuration(5 * time.Nanosecond),
+			spec: &interpolate.InterpolateProcedureSpec{
+				Method: interpolate.MethodLinear,
+				Every:  flux.ConvertDuration(5 * time.Nanosecond),
 			},
 			data: []flux.Table{&executetest.Table{
 				ColMeta: []flux.ColMeta{
@@ -88,8 +90,9 @@ func TestLinearInterpolate(t *testing.T) {
 		},
 		{
 			name: "basic2",
-			spec: &interpolate.LinearInterpolateProcedureSpec{
-				Every: flux.ConvertDuration(5 * time.Nanosecond),
+			spec: &interpolate.InterpolateProcedureSpec{
+				Method: interpolate.MethodLinear,
+				Every:  flux.ConvertDuration(5 * time.Nanosecond),
 			},
 			data: []flux.Table{&executetest.Table{
 				KeyCols: []string{"_field"},
@@ -119,8 +122,9 @@ func TestLinearInterpolate(t *testing.T) {
 		},
 		{
 			name: "group key error",
-			spec: &interpolate.LinearInterpolateProcedureSpec{
-				Every: flux.ConvertDuration(5 * time.Nanosecond),
+			spec: &interpolate.InterpolateProcedureSpec{
+				Method: interpolate.MethodLinear,
+				Every:  flux.ConvertDuration(5 * time.Nanosecond),
 			},
 			data: []flux.Table{&executetest.Table{
 				ColMeta: []flux.ColMeta{
@@ -137,8 +141,9 @@ func TestLinearInterpolate(t *testing.T) {
 		},
 		{
 			name: "ints",
-			spec: &interpolate.LinearInterpolateProcedureSpec{
-				Every: flux.ConvertDuration(5 * time.Nanosecond),
+			spec: &interpolate.InterpolateProcedureSpec{
+				Method: interpolate.MethodLinear,
+				Every:  flux.ConvertDuration(5 * time.Nanosecond),
 			},
 			data: []flux.Table{&executetest.Table{
 				ColMeta: []flux.ColMeta{
@@ -150,12 +155,23 @@ func TestLinearInterpolate(t *testing.T) {
 					{execute.Time(9), int64(2)},
 				},
 			}},
-			wantErr: fmt.Errorf("cannot interpolate int values; expected float values"),
+			want: []*executetest.Table{{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TInt},
+				},
+				Data: [][]interface{}{
+					{execute.Time(1), int64(1)},
+					{execute.Time(5), int64(2)},
+					{execute.Time(9), int64(2)},
+				},
+			}},
 		},
 		{
 			name: "nulls",
-			spec: &interpolate.LinearInterpolateProcedureSpec{
-				Every: flux.ConvertDuration(5 * time.Nanosecond),
+			spec: &interpolate.InterpolateProcedureSpec{
+				Method: interpolate.MethodLinear,
+				Every:  flux.ConvertDuration(5 * time.Nanosecond),
 			},
 			data: []flux.Table{&executetest.Table{
 				ColMeta: []flux.ColMeta{
@@ -172,8 +188,9 @@ func TestLinearInterpolate(t *testing.T) {
 		},
 		{
 			name: "no extrapolation",
-			spec: &interpolate.LinearInterpolateProcedureSpec{
-				Every: flux.ConvertDuration(5 * time.Nanosecond),
+			spec: &interpolate.InterpolateProcedureSpec{
+				Method: interpolate.MethodLinear,
+				Every:  flux.ConvertDuration(5 * time.Nanosecond),
 			},
 			data: []flux.Table{&executetest.Table{
 				ColMeta: []flux.ColMeta{
@@ -215,8 +232,9 @@ func TestLinearInterpolate(t *testing.T) {
 		},
 		{
 			name: "empty periods",
-			spec: &interpolate.LinearInterpolateProcedureSpec{
-				Every: flux.ConvertDuration(10 * time.Nanosecond),
+			spec: &interpolate.InterpolateProcedureSpec{
+				Method: interpolate.MethodLinear,
+				Every:  flux.ConvertDuration(10 * time.Nanosecond),
 			},
 			data: []flux.Table{&executetest.Table{
 				ColMeta: []flux.ColMeta{
@@ -249,8 +267,9 @@ func TestLinearInterpolate(t *testing.T) {
 		},
 		{
 			name: "no points",
-			spec: &interpolate.LinearInterpolateProcedureSpec{
-				Every: flux.ConvertDuration(5 * time.Nanosecond),
+			spec: &interpolate.InterpolateProcedureSpec{
+				Method: interpolate.MethodLinear,
+				Every:  flux.ConvertDuration(5 * time.Nanosecond),
 			},
 			data: []flux.Table{&executetest.Table{
 				ColMeta: []flux.ColMeta{
@@ -267,8 +286,9 @@ func TestLinearInterpolate(t *testing.T) {
 		},
 		{
 			name: "one point",
-			spec: &interpolate.LinearInterpolateProcedureSpec{
-				Every: flux.ConvertDuration(5 * time.Nanosecond),
+			spec: &interpolate.InterpolateProcedureSpec{
+				Method: interpolate.MethodLinear,
+				Every:  flux.ConvertDuration(5 * time.Nanosecond),
 			},
 			data: []flux.Table{&executetest.Table{
 				ColMeta: []flux.ColMeta{
@@ -291,8 +311,9 @@ func TestLinearInterpolate(t *testing.T) {
 		},
 		{
 			name: "identity",
-			spec: &interpolate.LinearInterpolateProcedureSpec{
-				Every: flux.ConvertDuration(10 * time.Nanosecond),
+			spec: &interpolate.InterpolateProcedureSpec{
+				Method: interpolate.MethodLinear,
+				Every:  flux.ConvertDuration(10 * time.Nanosecond),
 			},
 			data: []flux.Table{&executetest.Table{
 				ColMeta: []flux.ColMeta{
@@ -343,7 +364,8 @@ func TestLinearInterpolate(t *testing.T) {
 		},
 		{
 			name: "calendar duration",
-			spec: &interpolate.LinearInterpolateProcedureSpec{
+			spec: &interpolate.InterpolateProcedureSpec{
+				Method: interpolate.MethodLinear,
 				Every: func() values.Duration {
 					d, _ := values.ParseDuration("3mo")
 					return d
@@ -396,7 +418,8 @@ func TestLinearInterpolate(t *testing.T) {
 		},
 		{
 			name: "calendar duration",
-			spec: &interpolate.LinearInterpolateProcedureSpec{
+			spec: &interpolate.InterpolateProcedureSpec{
+				Method: interpolate.MethodLinear,
 				Every: func() values.Duration {
 					d, _ := values.ParseDuration("1mo")
 					return d
@@ -459,3 +482,268 @@ func TestLinearInterpolate(t *testing.T) {
 		})
 	}
 }
+
+func TestInterpolate_Methods(t *testing.T) {
+	floatData := func() []flux.Table {
+		return []flux.Table{&executetest.Table{
+			ColMeta: []flux.ColMeta{
+				{Label: "_time", Type: flux.TTime},
+				{Label: "_value", Type: flux.TFloat},
+			},
+			Data: [][]interface{}{
+				{execute.Time(0), 0.0},
+				{execute.Time(10), 10.0},
+				{execute.Time(20), 0.0},
+				{execute.Time(30), 10.0},
+			},
+		}}
+	}
+	testCases := []struct {
+		name    string
+		spec    *interpolate.InterpolateProcedureSpec
+		data    []flux.Table
+		want    []*executetest.Table
+		wantErr error
+	}{
+		{
+			name: "previous",
+			spec: &interpolate.InterpolateProcedureSpec{
+				Method: interpolate.MethodPrevious,
+				Every:  flux.ConvertDuration(4 * time.Nanosecond),
+			},
+			data: floatData(),
+			want: []*executetest.Table{{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TFloat},
+				},
+				Data: [][]interface{}{
+					{execute.Time(0), 0.0},
+					{execute.Time(4), 0.0},
+					{execute.Time(8), 0.0},
+					{execute.Time(10), 10.0},
+					{execute.Time(12), 10.0},
+					{execute.Time(16), 10.0},
+					{execute.Time(20), 0.0},
+					{execute.Time(24), 0.0},
+					{execute.Time(28), 0.0},
+					{execute.Time(30), 10.0},
+				},
+			}},
+		},
+		{
+			// Halfway points use the earlier row and columns
+			// of any type can be interpolated.
+			name: "nearest columns",
+			spec: &interpolate.InterpolateProcedureSpec{
+				Method:  interpolate.MethodNearest,
+				Every:   flux.ConvertDuration(5 * time.Nanosecond),
+				Columns: []string{"_value", "state"},
+			},
+			data: []flux.Table{&executetest.Table{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TFloat},
+					{Label: "state", Type: flux.TString},
+				},
+				Data: [][]interface{}{
+					{execute.Time(0), 0.0, "off"},
+					{execute.Time(10), 10.0, "on"},
+					{execute.Time(13), 0.0, "off"},
+				},
+			}},
+			want: []*executetest.Table{{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TFloat},
+					{Label: "state", Type: flux.TString},
+				},
+				Data: [][]interface{}{
+					{execute.Time(0), 0.0, "off"},
+					{execute.Time(5), 0.0, "off"},
+					{execute.Time(10), 10.0, "on"},
+					{execute.Time(13), 0.0, "off"},
+				},
+			}},
+		},
+		{
+			name: "cubic spline",
+			spec: &interpolate.InterpolateProcedureSpec{
+				Method: interpolate.MethodCubicSpline,
+				Every:  flux.ConvertDuration(5 * time.Nanosecond),
+			},
+			data: floatData(),
+			want: []*executetest.Table{{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TFloat},
+				},
+				Data: [][]interface{}{
+					{execute.Time(0), 0.0},
+					{execute.Time(5), 7.5},
+					{execute.Time(10), 10.0},
+					{execute.Time(15), 5.0},
+					{execute.Time(20), 0.0},
+					{execute.Time(25), 2.5},
+					{execute.Time(30), 10.0},
+				},
+			}},
+		},
+		{
+			// Interpolated integers are rounded half away from zero.
+			name: "akima ints",
+			spec: &interpolate.InterpolateProcedureSpec{
+				Method: interpolate.MethodAkima,
+				Every:  flux.ConvertDuration(5 * time.Nanosecond),
+			},
+			data: []flux.Table{&executetest.Table{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TInt},
+				},
+				Data: [][]interface{}{
+					{execute.Time(0), int64(0)},
+					{execute.Time(10), int64(10)},
+					{execute.Time(20), int64(0)},
+					{execute.Time(30), int64(10)},
+				},
+			}},
+			want: []*executetest.Table{{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TInt},
+				},
+				Data: [][]interface{}{
+					{execute.Time(0), int64(0)},
+					{execute.Time(5), int64(8)},
+					{execute.Time(10), int64(10)},
+					{execute.Time(15), int64(5)},
+					{execute.Time(20), int64(0)},
+					{execute.Time(25), int64(3)},
+					{execute.Time(30), int64(10)},
+				},
+			}},
+		},
+		{
+			// Tables longer than the rows used for each segment
+			// are interpolated as they are read.
+			name: "cubic spline long table",
+			spec: &interpolate.InterpolateProcedureSpec{
+				Method: interpolate.MethodCubicSpline,
+				Every:  flux.ConvertDuration(5 * time.Nanosecond),
+			},
+			data: []flux.Table{&executetest.Table{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TInt},
+				},
+				Data: func() [][]interface{} {
+					data := make([][]interface{}, 0, 100)
+					for i := 0; i < 100; i++ {
+						data = append(data, []interface{}{execute.Time(10 * i), int64(20 * i)})
+					}
+					return data
+				}(),
+			}},
+			want: []*executetest.Table{{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TInt},
+				},
+				Data: func() [][]interface{} {
+					data := make([][]interface{}, 0, 199)
+					for i := 0; i < 199; i++ {
+						data = append(data, []interface{}{execute.Time(5 * i), int64(10 * i)})
+					}
+					return data
+				}(),
+			}},
+		},
+		{
+			name: "max gap",
+			spec: &interpolate.InterpolateProcedureSpec{
+				Method: interpolate.MethodLinear,
+				Every:  flux.ConvertDuration(5 * time.Nanosecond),
+				MaxGap: flux.ConvertDuration(10 * time.Nanosecond),
+			},
+			data: []flux.Table{&executetest.Table{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TUInt},
+				},
+				Data: [][]interface{}{
+					{execute.Time(0), uint64(0)},
+					{execute.Time(10), uint64(3)},
+					{execute.Time(30), uint64(5)},
+				},
+			}},
+			want: []*executetest.Table{{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TUInt},
+				},
+				Data: [][]interface{}{
+					{execute.Time(0), uint64(0)},
+					{execute.Time(5), uint64(2)},
+					{execute.Time(10), uint64(3)},
+					{execute.Time(15), nil},
+					{execute.Time(20), nil},
+					{execute.Time(25), nil},
+					{execute.Time(30), uint64(5)},
+				},
+			}},
+		},
+		{
+			name: "strings",
+			spec: &interpolate.InterpolateProcedureSpec{
+				Method:  interpolate.MethodLinear,
+				Every:   flux.ConvertDuration(5 * time.Nanosecond),
+				Columns: []string{"state"},
+			},
+			data: []flux.Table{&executetest.Table{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "state", Type: flux.TString},
+				},
+				Data: [][]interface{}{
+					{execute.Time(0), "off"},
+					{execute.Time(10), "on"},
+				},
+			}},
+			wantErr: fmt.Errorf("cannot interpolate string values; expected float, int or uint values"),
+		},
+		{
+			name: "spline duplicate times",
+			spec: &interpolate.InterpolateProcedureSpec{
+				Method: interpolate.MethodCubicSpline,
+				Every:  flux.ConvertDuration(5 * time.Nanosecond),
+			},
+			data: []flux.Table{&executetest.Table{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TFloat},
+				},
+				Data: [][]interface{}{
+					{execute.Time(0), 0.0},
+					{execute.Time(10), 1.0},
+					{execute.Time(10), 2.0},
+				},
+			}},
+			wantErr: fmt.Errorf("interpolate.cubicSpline requires rows sorted by _time with unique times"),
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			executetest.ProcessTestHelper(
+				t,
+				tc.data,
+				tc.want,
+				tc.wantErr,
+				func(d execute.Dataset, c execute.TableBuilderCache) execute.Transformation {
+					return interpolate.NewInterpolateTransformation(d, c, tc.spec)
+				},
+			)
+		})
+	}
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/InfluxCommunity/flux"
 	"github.com/InfluxCommunity/flux/codes"
+	fluxurl "github.com/InfluxCommunity/flux/dependencies/url"
 	"github.com/InfluxCommunity/flux/execute"
 	"github.com/InfluxCommunity/flux/internal/errors"
 	"github.com/InfluxCommunity/flux/internal/pkg/syncutil"
@@ -232,6 +233,7 @@ func NewToKafkaTransformation(d execute.Dataset, deps flux.Dependencies, cache e
 	if err != nil {
 		return nil, err
 	}
+	validator = fluxurl.ValidatorFor(validator, "kafka.to")
 	for _, b := range spec.Spec.Brokers {
 		u, err := url.Parse(b)
 		if err != nil {
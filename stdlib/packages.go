@@ -6,6 +6,7 @@
 package stdlib
 
 import (
+	_ "github.com/InfluxCommunity/flux/stdlib/anomaly"
 	_ "github.com/InfluxCommunity/flux/stdlib/array"
 	_ "github.com/InfluxCommunity/flux/stdlib/bitwise"
 	_ "github.com/InfluxCommunity/flux/stdlib/contrib/RohanSreerama5/naiveBayesClassifier"
@@ -58,6 +59,7 @@ import (
 	_ "github.com/InfluxCommunity/flux/stdlib/experimental/usage"
 	_ "github.com/InfluxCommunity/flux/stdlib/generate"
 	_ "github.com/InfluxCommunity/flux/stdlib/http"
+	_ "github.com/InfluxCommunity/flux/stdlib/http/oauth2"
 	_ "github.com/InfluxCommunity/flux/stdlib/http/requests"
 	_ "github.com/InfluxCommunity/flux/stdlib/influxdata/influxdb"
 	_ "github.com/InfluxCommunity/flux/stdlib/influxdata/influxdb/monitor"
@@ -84,8 +86,10 @@ import (
 	_ "github.com/InfluxCommunity/flux/stdlib/profiler"
 	_ "github.com/InfluxCommunity/flux/stdlib/pushbullet"
 	_ "github.com/InfluxCommunity/flux/stdlib/regexp"
+	_ "github.com/InfluxCommunity/flux/stdlib/regression"
 	_ "github.com/InfluxCommunity/flux/stdlib/runtime"
 	_ "github.com/InfluxCommunity/flux/stdlib/sampledata"
+	_ "github.com/InfluxCommunity/flux/stdlib/sketch"
 	_ "github.com/InfluxCommunity/flux/stdlib/slack"
 	_ "github.com/InfluxCommunity/flux/stdlib/socket"
 	_ "github.com/InfluxCommunity/flux/stdlib/sql"
@@ -104,4 +108,5 @@ import (
 	_ "github.com/InfluxCommunity/flux/stdlib/timezone"
 	_ "github.com/InfluxCommunity/flux/stdlib/types"
 	_ "github.com/InfluxCommunity/flux/stdlib/universe"
+	_ "github.com/InfluxCommunity/flux/stdlib/window"
 )
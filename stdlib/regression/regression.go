@@ -0,0 +1,409 @@
+package regression
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/array"
+	"github.com/InfluxCommunity/flux/arrow"
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/internal/execute/table"
+	"github.com/InfluxCommunity/flux/internal/regression"
+	"github.com/InfluxCommunity/flux/plan"
+	"github.com/InfluxCommunity/flux/runtime"
+	"github.com/apache/arrow/go/v7/arrow/memory"
+)
+
+const pkgpath = "regression"
+
+// Methods are the names of the models of the package.
+// Each model is its own kind of operation.
+const (
+	MethodLinear     = "linear"
+	MethodPolynomial = "polynomial"
+)
+
+// Labels of the columns added by the models.
+const (
+	SlopeColLabel     = "slope"
+	InterceptColLabel = "intercept"
+	RSquaredColLabel  = "r2"
+	StdErrColLabel    = "stderr"
+	FittedColLabel    = "fitted"
+	ResidualColLabel  = "residual"
+)
+
+var methods = []string{
+	MethodLinear,
+	MethodPolynomial,
+}
+
+func init() {
+	for _, method := range methods {
+		kind := Kind(method)
+		signature := runtime.MustLookupBuiltinType(pkgpath, method)
+		runtime.RegisterPackageValue(pkgpath, method, flux.MustValue(flux.FunctionValue(method, newCreateOpSpec(method), signature)))
+		plan.RegisterProcedureSpec(plan.ProcedureKind(kind), newRegressionProcedure, kind)
+		execute.RegisterTransformation(plan.ProcedureKind(kind), createRegressionTransformation)
+	}
+}
+
+// Kind returns the kind of operation of a model of the package.
+func Kind(method string) flux.OperationKind {
+	return flux.OperationKind(pkgpath + "." + method)
+}
+
+type RegressionOpSpec struct {
+	Method  string `json:"method"`
+	X       string `json:"x"`
+	Y       string `json:"y"`
+	Degree  int64  `json:"degree"`
+	Predict bool   `json:"predict"`
+}
+
+func newCreateOpSpec(method string) flux.CreateOperationSpec {
+	return func(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
+		return createRegressionOpSpec(method, args, a)
+	}
+}
+
+func createRegressionOpSpec(method string, args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
+	if err := a.AddParentFromArgs(args); err != nil {
+		return nil, err
+	}
+
+	spec := &RegressionOpSpec{
+		Method: method,
+		X:      execute.DefaultTimeColLabel,
+		Y:      execute.DefaultValueColLabel,
+		Degree: 1,
+	}
+	if x, ok, err := args.GetString("x"); err != nil {
+		return nil, err
+	} else if ok {
+		spec.X = x
+	}
+	if y, ok, err := args.GetString("y"); err != nil {
+		return nil, err
+	} else if ok {
+		spec.Y = y
+	}
+	if p, ok, err := args.GetBool("predict"); err != nil {
+		return nil, err
+	} else if ok {
+		spec.Predict = p
+	}
+
+	if method == MethodPolynomial {
+		degree, err := args.GetRequiredInt("degree")
+		if err != nil {
+			return nil, err
+		}
+		if degree < 1 {
+			return nil, errors.Newf(codes.Invalid, "degree must be at least 1, got %d", degree)
+		}
+		spec.Degree = degree
+	}
+	return spec, nil
+}
+
+func (s *RegressionOpSpec) Kind() flux.OperationKind {
+	return Kind(s.Method)
+}
+
+type RegressionProcedureSpec struct {
+	plan.DefaultCost
+	Method  string
+	X       string
+	Y       string
+	Degree  int
+	Predict bool
+}
+
+func newRegressionProcedure(qs flux.OperationSpec, pa plan.Administration) (plan.ProcedureSpec, error) {
+	spec, ok := qs.(*RegressionOpSpec)
+	if !ok {
+		return nil, errors.Newf(codes.Internal, "invalid spec type %T", qs)
+	}
+	return &RegressionProcedureSpec{
+		Method:  spec.Method,
+		X:       spec.X,
+		Y:       spec.Y,
+		Degree:  int(spec.Degree),
+		Predict: spec.Predict,
+	}, nil
+}
+
+func (s *RegressionProcedureSpec) Kind() plan.ProcedureKind {
+	return plan.ProcedureKind(Kind(s.Method))
+}
+
+func (s *RegressionProcedureSpec) Copy() plan.ProcedureSpec {
+	ns := new(RegressionProcedureSpec)
+	*ns = *s
+	return ns
+}
+
+// TriggerSpec implements plan.TriggerAwareProcedureSpec
+func (s *RegressionProcedureSpec) TriggerSpec() plan.TriggerSpec {
+	return plan.NarrowTransformationTriggerSpec{}
+}
+
+func createRegressionTransformation(id execute.DatasetID, mode execute.AccumulationMode, spec plan.ProcedureSpec, a execute.Administration) (execute.Transformation, execute.Dataset, error) {
+	s, ok := spec.(*RegressionProcedureSpec)
+	if !ok {
+		return nil, nil, errors.Newf(codes.Internal, "invalid spec type %T", spec)
+	}
+	return NewRegressionTransformation(id, s, a.Allocator())
+}
+
+// regressionTransformation fits a model of y on x to each table once
+// all of its rows have been read. It outputs the coefficients of the model
+// or, when predicting, the rows of the table with their fitted values.
+type regressionTransformation struct {
+	spec *RegressionProcedureSpec
+}
+
+// regressionState holds the pairs of a table and, when predicting,
+// the chunks of the table to append the fitted values to.
+type regressionState struct {
+	x, y   []float64
+	first  int64
+	times  bool
+	chunks []table.Chunk
+}
+
+func NewRegressionTransformation(id execute.DatasetID, spec *RegressionProcedureSpec, mem memory.Allocator) (execute.Transformation, execute.Dataset, error) {
+	switch spec.Method {
+	case MethodLinear, MethodPolynomial:
+	default:
+		return nil, nil, errors.Newf(codes.Internal, "unknown regression method %q", spec.Method)
+	}
+	t := &regressionTransformation{spec: spec}
+	return execute.NewAggregateTransformation(id, t, mem)
+}
+
+func (t *regressionTransformation) Aggregate(chunk table.Chunk, state interface{}, mem memory.Allocator) (interface{}, bool, error) {
+	s, _ := state.(*regressionState)
+	if s == nil {
+		s = &regressionState{}
+	}
+	xIdx, yIdx, err := t.columns(chunk)
+	if err != nil {
+		return nil, false, err
+	}
+	for i, n := 0, chunk.Len(); i < n; i++ {
+		x, ok := s.xValue(chunk, xIdx, i)
+		if !ok {
+			continue
+		}
+		y, ok := yValue(chunk, yIdx, i)
+		if !ok {
+			continue
+		}
+		s.x = append(s.x, x)
+		s.y = append(s.y, y)
+	}
+	if t.spec.Predict {
+		chunk.Retain()
+		s.chunks = append(s.chunks, chunk)
+	}
+	return s, true, nil
+}
+
+// columns returns the indexes of the x and the y columns of the chunk.
+func (t *regressionTransformation) columns(chunk table.Chunk) (xIdx, yIdx int, err error) {
+	xIdx = chunk.Index(t.spec.X)
+	if xIdx < 0 {
+		return -1, -1, errors.Newf(codes.FailedPrecondition, "cannot find column %s", t.spec.X)
+	}
+	switch typ := chunk.Col(xIdx).Type; typ {
+	case flux.TInt, flux.TUInt, flux.TFloat, flux.TTime:
+	default:
+		return -1, -1, errors.Newf(codes.FailedPrecondition, "regression.%s can work only on numerical or time types, got %s", t.spec.Method, typ)
+	}
+	yIdx = chunk.Index(t.spec.Y)
+	if yIdx < 0 {
+		return -1, -1, errors.Newf(codes.FailedPrecondition, "cannot find column %s", t.spec.Y)
+	}
+	switch typ := chunk.Col(yIdx).Type; typ {
+	case flux.TInt, flux.TUInt, flux.TFloat:
+	default:
+		return -1, -1, errors.Newf(codes.FailedPrecondition, "regression.%s can work only on numerical types, got %s", t.spec.Method, typ)
+	}
+	return xIdx, yIdx, nil
+}
+
+// xValue returns the x value of a row. Times are seconds
+// since the first time of the table.
+func (s *regressionState) xValue(chunk table.Chunk, j, i int) (float64, bool) {
+	if chunk.Col(j).Type != flux.TTime {
+		return yValue(chunk, j, i)
+	}
+	vs := chunk.Ints(j)
+	if vs.IsNull(i) {
+		return 0, false
+	}
+	if !s.times {
+		s.first, s.times = vs.Value(i), true
+	}
+	return float64(vs.Value(i)-s.first) / 1e9, true
+}
+
+// yValue returns the numeric value of a row.
+func yValue(chunk table.Chunk, j, i int) (float64, bool) {
+	switch vs := chunk.Values(j).(type) {
+	case *array.Int:
+		return float64(vs.Value(i)), vs.IsValid(i)
+	case *array.Uint:
+		return float64(vs.Value(i)), vs.IsValid(i)
+	case *array.Float:
+		return vs.Value(i), vs.IsValid(i)
+	}
+	return 0, false
+}
+
+// fit returns the coefficients of the model in order of increasing power of x.
+func (t *regressionTransformation) fit(s *regressionState) ([]float64, bool) {
+	if t.spec.Method == MethodLinear {
+		// The sums of the linear regression are shared with promql.
+		var lr regression.Linear
+		for i := range s.x {
+			lr.Add(s.x[i], s.y[i])
+		}
+		if lr.N() < 2 {
+			return nil, false
+		}
+		slope, intercept := lr.Fit()
+		if math.IsNaN(slope) || math.IsInf(slope, 0) {
+			return nil, false
+		}
+		return []float64{intercept, slope}, true
+	}
+	return regression.Polynomial(s.x, s.y, t.spec.Degree)
+}
+
+func (t *regressionTransformation) Compute(key flux.GroupKey, state interface{}, d *execute.TransportDataset, mem memory.Allocator) error {
+	s := state.(*regressionState)
+	coeffs, ok := t.fit(s)
+	if t.spec.Predict {
+		return t.predict(s, coeffs, ok, d, mem)
+	}
+	if !ok {
+		// Omit the output table if the model cannot be fitted.
+		return nil
+	}
+
+	fitted := make([]float64, len(s.x))
+	for i, x := range s.x {
+		fitted[i] = regression.Eval(coeffs, x)
+	}
+
+	var cols []flux.ColMeta
+	var vs []float64
+	if t.spec.Method == MethodLinear {
+		cols = append(cols,
+			flux.ColMeta{Label: SlopeColLabel, Type: flux.TFloat},
+			flux.ColMeta{Label: InterceptColLabel, Type: flux.TFloat},
+		)
+		vs = append(vs, coeffs[1], coeffs[0])
+	} else {
+		for k, c := range coeffs {
+			cols = append(cols, flux.ColMeta{Label: "c" + strconv.Itoa(k), Type: flux.TFloat})
+			vs = append(vs, c)
+		}
+	}
+	cols = append(cols,
+		flux.ColMeta{Label: RSquaredColLabel, Type: flux.TFloat},
+		flux.ColMeta{Label: StdErrColLabel, Type: flux.TFloat},
+	)
+	vs = append(vs,
+		regression.RSquared(s.y, fitted),
+		regression.StandardError(s.y, fitted, len(coeffs)),
+	)
+
+	buffer := arrow.TableBuffer{
+		GroupKey: key,
+		Columns:  make([]flux.ColMeta, 0, len(key.Cols())+len(cols)),
+		Values:   make([]array.Array, 0, len(key.Cols())+len(cols)),
+	}
+	for j, col := range key.Cols() {
+		buffer.Columns = append(buffer.Columns, col)
+		buffer.Values = append(buffer.Values, arrow.Repeat(col.Type, key.Value(j), 1, mem))
+	}
+	for j, col := range cols {
+		if execute.ColIdx(col.Label, key.Cols()) >= 0 {
+			buffer.Release()
+			return errors.Newf(codes.FailedPrecondition, "cannot replace column %s that is part of the group key", col.Label)
+		}
+		b := array.NewFloatBuilder(mem)
+		if math.IsNaN(vs[j]) {
+			b.AppendNull()
+		} else {
+			b.Append(vs[j])
+		}
+		buffer.Columns = append(buffer.Columns, col)
+		buffer.Values = append(buffer.Values, b.NewArray())
+	}
+	return d.Process(table.ChunkFromBuffer(buffer))
+}
+
+// predict sends the chunks of the table with their fitted values
+// and residuals, which are null if the model could not be fitted.
+func (t *regressionTransformation) predict(s *regressionState, coeffs []float64, ok bool, d *execute.TransportDataset, mem memory.Allocator) error {
+	defer func() {
+		for _, chunk := range s.chunks {
+			chunk.Release()
+		}
+		s.chunks = nil
+	}()
+
+	// The x values are read again with the first time of the table.
+	s.times = false
+	for _, chunk := range s.chunks {
+		xIdx, yIdx, err := t.columns(chunk)
+		if err != nil {
+			return err
+		}
+		n := chunk.Len()
+		fitted := array.NewFloatBuilder(mem)
+		fitted.Resize(n)
+		residuals := array.NewFloatBuilder(mem)
+		residuals.Resize(n)
+		for i := 0; i < n; i++ {
+			x, xOK := s.xValue(chunk, xIdx, i)
+			if !ok || !xOK {
+				fitted.AppendNull()
+				residuals.AppendNull()
+				continue
+			}
+			f := regression.Eval(coeffs, x)
+			fitted.Append(f)
+			if y, yOK := yValue(chunk, yIdx, i); yOK {
+				residuals.Append(y - f)
+			} else {
+				residuals.AppendNull()
+			}
+		}
+
+		cols := []flux.ColMeta{
+			{Label: FittedColLabel, Type: flux.TFloat},
+			{Label: ResidualColLabel, Type: flux.TFloat},
+		}
+		out, err := table.AppendColumns(chunk, cols, []array.Array{fitted.NewArray(), residuals.NewArray()})
+		if err != nil {
+			return err
+		}
+		if err := d.Process(out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *regressionTransformation) Close() error {
+	return nil
+}
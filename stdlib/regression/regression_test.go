@@ -0,0 +1,159 @@
+package regression_test
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/execute/executetest"
+	"github.com/InfluxCommunity/flux/memory"
+	"github.com/InfluxCommunity/flux/stdlib/regression"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestRegression_Process(t *testing.T) {
+	inCols := []flux.ColMeta{
+		{Label: "_time", Type: flux.TTime},
+		{Label: "t0", Type: flux.TString},
+		{Label: "_value", Type: flux.TInt},
+	}
+	second := int64(1e9)
+	data := func() []flux.Table {
+		return []flux.Table{&executetest.Table{
+			KeyCols: []string{"t0"},
+			ColMeta: inCols,
+			Data: [][]interface{}{
+				{execute.Time(10 * second), "a", int64(3)},
+				{execute.Time(11 * second), "a", int64(5)},
+				{execute.Time(12 * second), "a", nil},
+				{execute.Time(13 * second), "a", int64(10)},
+			},
+		}, &executetest.Table{
+			KeyCols: []string{"t0"},
+			ColMeta: inCols,
+			Data: [][]interface{}{
+				{execute.Time(10 * second), "b", int64(1)},
+			},
+		}}
+	}
+	for _, tc := range []struct {
+		name    string
+		spec    *regression.RegressionProcedureSpec
+		data    []flux.Table
+		want    []*executetest.Table
+		wantErr error
+	}{
+		{
+			// The pairs are (0, 3), (1, 5) and (3, 10), with a covariance
+			// sum of 11 and a variance sum of x of 14/3. The sum of squares
+			// of the residuals is 1/14 and the total sum of squares is 26.
+			name: "linear",
+			spec: &regression.RegressionProcedureSpec{
+				Method: regression.MethodLinear,
+				X:      "_time",
+			},
+			data: data(),
+			want: []*executetest.Table{{
+				KeyCols: []string{"t0"},
+				ColMeta: []flux.ColMeta{
+					{Label: "t0", Type: flux.TString},
+					{Label: "slope", Type: flux.TFloat},
+					{Label: "intercept", Type: flux.TFloat},
+					{Label: "r2", Type: flux.TFloat},
+					{Label: "stderr", Type: flux.TFloat},
+				},
+				Data: [][]interface{}{
+					{"a", 33.0 / 14, 40.0 / 14, 1 - 1.0/364, math.Sqrt(1.0 / 14)},
+				},
+			}},
+		},
+		{
+			name: "linear predict",
+			spec: &regression.RegressionProcedureSpec{
+				Method:  regression.MethodLinear,
+				X:       "_time",
+				Predict: true,
+			},
+			data: data(),
+			want: []*executetest.Table{
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: append(inCols[:len(inCols):len(inCols)],
+						flux.ColMeta{Label: "fitted", Type: flux.TFloat},
+						flux.ColMeta{Label: "residual", Type: flux.TFloat},
+					),
+					Data: [][]interface{}{
+						{execute.Time(10 * second), "a", int64(3), 40.0 / 14, 2.0 / 14},
+						{execute.Time(11 * second), "a", int64(5), 73.0 / 14, -3.0 / 14},
+						{execute.Time(12 * second), "a", nil, 106.0 / 14, nil},
+						{execute.Time(13 * second), "a", int64(10), 139.0 / 14, 1.0 / 14},
+					},
+				},
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: append(inCols[:len(inCols):len(inCols)],
+						flux.ColMeta{Label: "fitted", Type: flux.TFloat},
+						flux.ColMeta{Label: "residual", Type: flux.TFloat},
+					),
+					Data: [][]interface{}{
+						{execute.Time(10 * second), "b", int64(1), nil, nil},
+					},
+				},
+			},
+		},
+		{
+			// A quadratic passes through the three pairs.
+			name: "polynomial",
+			spec: &regression.RegressionProcedureSpec{
+				Method: regression.MethodPolynomial,
+				X:      "_time",
+				Degree: 2,
+			},
+			data: data(),
+			want: []*executetest.Table{{
+				KeyCols: []string{"t0"},
+				ColMeta: []flux.ColMeta{
+					{Label: "t0", Type: flux.TString},
+					{Label: "c0", Type: flux.TFloat},
+					{Label: "c1", Type: flux.TFloat},
+					{Label: "c2", Type: flux.TFloat},
+					{Label: "r2", Type: flux.TFloat},
+					{Label: "stderr", Type: flux.TFloat},
+				},
+				Data: [][]interface{}{
+					{"a", 3.0, 11.0 / 6, 1.0 / 6, 1.0, nil},
+				},
+			}},
+		},
+		{
+			name: "non-numeric column",
+			spec: &regression.RegressionProcedureSpec{
+				Method: regression.MethodLinear,
+				X:      "t0",
+			},
+			data:    data(),
+			wantErr: errors.New("regression.linear can work only on numerical or time types, got string"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			spec := *tc.spec
+			spec.Y = execute.DefaultValueColLabel
+			executetest.ProcessTestHelper2(
+				t,
+				tc.data,
+				tc.want,
+				tc.wantErr,
+				func(id execute.DatasetID, alloc memory.Allocator) (execute.Transformation, execute.Dataset) {
+					tr, d, err := regression.NewRegressionTransformation(id, &spec, alloc)
+					if err != nil {
+						t.Fatal(err)
+					}
+					return tr, d
+				},
+				cmpopts.EquateApprox(0, 1e-9),
+			)
+		})
+	}
+}
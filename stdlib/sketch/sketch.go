@@ -0,0 +1,423 @@
+package sketch
+
+import (
+	"math"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/array"
+	"github.com/InfluxCommunity/flux/arrow"
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/execute/table"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/internal/sketch"
+	"github.com/InfluxCommunity/flux/plan"
+	"github.com/InfluxCommunity/flux/runtime"
+	"github.com/InfluxCommunity/flux/values"
+	"github.com/apache/arrow/go/v7/arrow/memory"
+)
+
+const pkgpath = "sketch"
+
+// Methods are the names of the functions of the package.
+// Each function is its own kind of operation.
+const (
+	MethodApproxCountDistinct   = "approxCountDistinct"
+	MethodHLL                   = "hll"
+	MethodTDigest               = "tdigest"
+	MethodMergeSketches         = "mergeSketches"
+	MethodEstimateCountDistinct = "estimateCountDistinct"
+	MethodEstimateQuantile      = "estimateQuantile"
+)
+
+// DefaultCompression is the compression of t-digest sketches,
+// the same as the default of quantile().
+const DefaultCompression = 1000
+
+var methods = []string{
+	MethodApproxCountDistinct,
+	MethodHLL,
+	MethodTDigest,
+	MethodMergeSketches,
+	MethodEstimateCountDistinct,
+	MethodEstimateQuantile,
+}
+
+func init() {
+	for _, method := range methods {
+		kind := Kind(method)
+		signature := runtime.MustLookupBuiltinType(pkgpath, method)
+		runtime.RegisterPackageValue(pkgpath, method, flux.MustValue(flux.FunctionValue(method, newCreateOpSpec(method), signature)))
+		plan.RegisterProcedureSpec(plan.ProcedureKind(kind), newSketchProcedure, kind)
+		execute.RegisterTransformation(plan.ProcedureKind(kind), createSketchTransformation)
+	}
+}
+
+// Kind returns the kind of operation of a function of the package.
+func Kind(method string) flux.OperationKind {
+	return flux.OperationKind(pkgpath + "." + method)
+}
+
+type SketchOpSpec struct {
+	Method      string  `json:"method"`
+	Column      string  `json:"column"`
+	Precision   int64   `json:"precision"`
+	Compression float64 `json:"compression"`
+	Quantile    float64 `json:"quantile"`
+}
+
+func newCreateOpSpec(method string) flux.CreateOperationSpec {
+	return func(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
+		return createSketchOpSpec(method, args, a)
+	}
+}
+
+func createSketchOpSpec(method string, args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
+	if err := a.AddParentFromArgs(args); err != nil {
+		return nil, err
+	}
+
+	spec := &SketchOpSpec{
+		Method: method,
+		Column: execute.DefaultValueColLabel,
+	}
+	if col, ok, err := args.GetString("column"); err != nil {
+		return nil, err
+	} else if ok {
+		spec.Column = col
+	}
+
+	switch method {
+	case MethodApproxCountDistinct, MethodHLL:
+		spec.Precision = sketch.DefaultPrecision
+		if p, ok, err := args.GetInt("precision"); err != nil {
+			return nil, err
+		} else if ok {
+			spec.Precision = p
+		}
+		if spec.Precision < sketch.MinPrecision || spec.Precision > sketch.MaxPrecision {
+			return nil, errors.Newf(codes.Invalid, "precision must be between %d and %d, got %d", sketch.MinPrecision, sketch.MaxPrecision, spec.Precision)
+		}
+	case MethodTDigest:
+		spec.Compression = DefaultCompression
+		if c, ok, err := args.GetFloat("compression"); err != nil {
+			return nil, err
+		} else if ok {
+			spec.Compression = c
+		}
+		if !(spec.Compression > 0) || math.IsInf(spec.Compression, 1) {
+			return nil, errors.Newf(codes.Invalid, "compression must be positive, got %v", spec.Compression)
+		}
+	case MethodEstimateQuantile:
+		q, err := args.GetRequiredFloat("q")
+		if err != nil {
+			return nil, err
+		}
+		if q < 0 || q > 1 {
+			return nil, errors.New(codes.Invalid, "quantile must be between 0 and 1")
+		}
+		spec.Quantile = q
+	}
+	return spec, nil
+}
+
+func (s *SketchOpSpec) Kind() flux.OperationKind {
+	return Kind(s.Method)
+}
+
+type SketchProcedureSpec struct {
+	plan.DefaultCost
+	Method      string
+	Column      string
+	Precision   int
+	Compression float64
+	Quantile    float64
+}
+
+func newSketchProcedure(qs flux.OperationSpec, pa plan.Administration) (plan.ProcedureSpec, error) {
+	spec, ok := qs.(*SketchOpSpec)
+	if !ok {
+		return nil, errors.Newf(codes.Internal, "invalid spec type %T", qs)
+	}
+	return &SketchProcedureSpec{
+		Method:      spec.Method,
+		Column:      spec.Column,
+		Precision:   int(spec.Precision),
+		Compression: spec.Compression,
+		Quantile:    spec.Quantile,
+	}, nil
+}
+
+func (s *SketchProcedureSpec) Kind() plan.ProcedureKind {
+	return plan.ProcedureKind(Kind(s.Method))
+}
+
+func (s *SketchProcedureSpec) Copy() plan.ProcedureSpec {
+	ns := new(SketchProcedureSpec)
+	*ns = *s
+	return ns
+}
+
+// TriggerSpec implements plan.TriggerAwareProcedureSpec
+func (s *SketchProcedureSpec) TriggerSpec() plan.TriggerSpec {
+	return plan.NarrowTransformationTriggerSpec{}
+}
+
+func createSketchTransformation(id execute.DatasetID, mode execute.AccumulationMode, spec plan.ProcedureSpec, a execute.Administration) (execute.Transformation, execute.Dataset, error) {
+	s, ok := spec.(*SketchProcedureSpec)
+	if !ok {
+		return nil, nil, errors.Newf(codes.Internal, "invalid spec type %T", spec)
+	}
+	return NewSketchTransformation(id, s, a.Allocator())
+}
+
+// sketchTransformation builds or merges a sketch of the column of
+// each table and outputs the sketch or an estimate from it.
+type sketchTransformation struct {
+	spec *SketchProcedureSpec
+}
+
+func NewSketchTransformation(id execute.DatasetID, spec *SketchProcedureSpec, mem memory.Allocator) (execute.Transformation, execute.Dataset, error) {
+	switch spec.Method {
+	case MethodApproxCountDistinct, MethodHLL, MethodTDigest,
+		MethodMergeSketches, MethodEstimateCountDistinct, MethodEstimateQuantile:
+	default:
+		return nil, nil, errors.Newf(codes.Internal, "unknown sketch method %q", spec.Method)
+	}
+	t := &sketchTransformation{spec: spec}
+	return execute.NewAggregateTransformation(id, t, mem)
+}
+
+// sketchState is the sketch of a table.
+// The sketch of a merge is nil until a sketch is read.
+type sketchState struct {
+	sketch sketch.Sketch
+}
+
+func (t *sketchTransformation) newState() (*sketchState, error) {
+	switch t.spec.Method {
+	case MethodApproxCountDistinct, MethodHLL:
+		h, err := sketch.NewHLL(t.spec.Precision)
+		if err != nil {
+			return nil, err
+		}
+		return &sketchState{sketch: h}, nil
+	case MethodTDigest:
+		return &sketchState{sketch: sketch.NewTDigest(t.spec.Compression)}, nil
+	default:
+		return &sketchState{}, nil
+	}
+}
+
+func (t *sketchTransformation) Aggregate(chunk table.Chunk, state interface{}, mem memory.Allocator) (interface{}, bool, error) {
+	idx := chunk.Index(t.spec.Column)
+	if idx < 0 {
+		return nil, false, errors.Newf(codes.FailedPrecondition, "column %q does not exist", t.spec.Column)
+	}
+	if chunk.Key().HasCol(t.spec.Column) {
+		return nil, false, errors.New(codes.FailedPrecondition, "cannot aggregate columns that are part of the group key")
+	}
+
+	s, _ := state.(*sketchState)
+	if s == nil {
+		var err error
+		if s, err = t.newState(); err != nil {
+			return nil, false, err
+		}
+	}
+
+	var err error
+	switch t.spec.Method {
+	case MethodApproxCountDistinct, MethodHLL:
+		err = t.addHLL(s.sketch.(*sketch.HLL), chunk, idx)
+	case MethodTDigest:
+		err = t.addTDigest(s.sketch.(*sketch.TDigest), chunk, idx)
+	default:
+		err = t.merge(s, chunk, idx)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return s, true, nil
+}
+
+// addHLL adds the values of the column to a HyperLogLog sketch.
+// Strings are hashed by their bytes and other values by the bits
+// of their 64 bit representation.
+func (t *sketchTransformation) addHLL(h *sketch.HLL, chunk table.Chunk, idx int) error {
+	switch typ := chunk.Col(idx).Type; typ {
+	case flux.TBool:
+		vs := chunk.Bools(idx)
+		for i := 0; i < vs.Len(); i++ {
+			if vs.IsValid(i) {
+				var v uint64
+				if vs.Value(i) {
+					v = 1
+				}
+				h.AddUint64(v)
+			}
+		}
+	case flux.TInt, flux.TTime:
+		vs := chunk.Ints(idx)
+		for i := 0; i < vs.Len(); i++ {
+			if vs.IsValid(i) {
+				h.AddUint64(uint64(vs.Value(i)))
+			}
+		}
+	case flux.TUInt:
+		vs := chunk.Uints(idx)
+		for i := 0; i < vs.Len(); i++ {
+			if vs.IsValid(i) {
+				h.AddUint64(vs.Value(i))
+			}
+		}
+	case flux.TFloat:
+		vs := chunk.Floats(idx)
+		for i := 0; i < vs.Len(); i++ {
+			if vs.IsValid(i) {
+				h.AddUint64(math.Float64bits(vs.Value(i)))
+			}
+		}
+	case flux.TString:
+		vs := chunk.Strings(idx)
+		for i := 0; i < vs.Len(); i++ {
+			if vs.IsValid(i) {
+				h.AddString(vs.Value(i))
+			}
+		}
+	default:
+		return errors.Newf(codes.FailedPrecondition, "unsupported aggregate column type %v", typ)
+	}
+	return nil
+}
+
+// addTDigest adds the values of the column to a t-digest.
+func (t *sketchTransformation) addTDigest(td *sketch.TDigest, chunk table.Chunk, idx int) error {
+	switch typ := chunk.Col(idx).Type; typ {
+	case flux.TInt:
+		vs := chunk.Ints(idx)
+		for i := 0; i < vs.Len(); i++ {
+			if vs.IsValid(i) {
+				td.Add(float64(vs.Value(i)), 1)
+			}
+		}
+	case flux.TUInt:
+		vs := chunk.Uints(idx)
+		for i := 0; i < vs.Len(); i++ {
+			if vs.IsValid(i) {
+				td.Add(float64(vs.Value(i)), 1)
+			}
+		}
+	case flux.TFloat:
+		vs := chunk.Floats(idx)
+		for i := 0; i < vs.Len(); i++ {
+			if vs.IsValid(i) {
+				td.Add(vs.Value(i), 1)
+			}
+		}
+	default:
+		return errors.Newf(codes.FailedPrecondition, "unsupported aggregate column type %v", typ)
+	}
+	return nil
+}
+
+// merge decodes the sketches of the column and merges them
+// into the sketch of the state.
+func (t *sketchTransformation) merge(s *sketchState, chunk table.Chunk, idx int) error {
+	if typ := chunk.Col(idx).Type; typ != flux.TString {
+		return errors.Newf(codes.FailedPrecondition, "sketches must be strings, but column %q is %v", t.spec.Column, typ)
+	}
+	vs := chunk.Strings(idx)
+	for i := 0; i < vs.Len(); i++ {
+		if vs.IsNull(i) {
+			continue
+		}
+		sk, err := sketch.Decode(vs.Value(i))
+		if err != nil {
+			return err
+		}
+		if err := t.checkKind(sk); err != nil {
+			return err
+		}
+		if s.sketch == nil {
+			s.sketch = sk
+			continue
+		}
+		if err := sketch.Merge(s.sketch, sk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkKind checks that a sketch can be used for an estimate.
+func (t *sketchTransformation) checkKind(sk sketch.Sketch) error {
+	var ok bool
+	switch t.spec.Method {
+	case MethodEstimateCountDistinct:
+		_, ok = sk.(*sketch.HLL)
+	case MethodEstimateQuantile:
+		_, ok = sk.(*sketch.TDigest)
+	default:
+		return nil
+	}
+	if !ok {
+		return errors.Newf(codes.Invalid, "%s cannot estimate from a %s sketch", t.spec.Method, sk.Kind())
+	}
+	return nil
+}
+
+func (t *sketchTransformation) Compute(key flux.GroupKey, state interface{}, d *execute.TransportDataset, mem memory.Allocator) error {
+	s := state.(*sketchState)
+
+	var (
+		typ flux.ColType
+		v   values.Value
+	)
+	switch t.spec.Method {
+	case MethodApproxCountDistinct, MethodEstimateCountDistinct:
+		typ, v = flux.TInt, values.Null
+		if h, ok := s.sketch.(*sketch.HLL); ok {
+			v = values.NewInt(int64(h.Estimate()))
+		}
+	case MethodEstimateQuantile:
+		typ, v = flux.TFloat, values.Null
+		if td, ok := s.sketch.(*sketch.TDigest); ok && td.Count() > 0 {
+			v = values.NewFloat(td.Quantile(t.spec.Quantile))
+		}
+	default:
+		typ, v = flux.TString, values.Null
+		if s.sketch != nil {
+			encoded, err := sketch.Encode(s.sketch)
+			if err != nil {
+				return err
+			}
+			v = values.NewString(encoded)
+		}
+	}
+
+	buffer := arrow.TableBuffer{
+		GroupKey: key,
+		Columns:  make([]flux.ColMeta, 0, len(key.Cols())+1),
+	}
+	buffer.Columns = append(buffer.Columns, key.Cols()...)
+	buffer.Columns = append(buffer.Columns, flux.ColMeta{
+		Label: t.spec.Column,
+		Type:  typ,
+	})
+
+	buffer.Values = make([]array.Array, len(key.Cols()), len(buffer.Columns))
+	for j := range key.Cols() {
+		buffer.Values[j] = arrow.Repeat(key.Cols()[j].Type, key.Value(j), 1, mem)
+	}
+	if v.IsNull() {
+		buffer.Values = append(buffer.Values, arrow.Nulls(typ, 1, mem))
+	} else {
+		buffer.Values = append(buffer.Values, arrow.Repeat(typ, v, 1, mem))
+	}
+	return d.Process(table.ChunkFromBuffer(buffer))
+}
+
+func (t *sketchTransformation) Close() error {
+	return nil
+}
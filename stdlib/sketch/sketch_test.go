@@ -0,0 +1,332 @@
+package sketch_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/execute/executetest"
+	isketch "github.com/InfluxCommunity/flux/internal/sketch"
+	"github.com/InfluxCommunity/flux/memory"
+	"github.com/InfluxCommunity/flux/stdlib/sketch"
+)
+
+func encodeHLL(t *testing.T, p int, vs ...string) string {
+	t.Helper()
+	h, err := isketch.NewHLL(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range vs {
+		h.AddString(v)
+	}
+	s, err := isketch.Encode(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func encodeTDigest(t *testing.T, vs ...float64) string {
+	t.Helper()
+	td := isketch.NewTDigest(sketch.DefaultCompression)
+	for _, v := range vs {
+		td.Add(v, 1)
+	}
+	s, err := isketch.Encode(td)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestSketch_Process(t *testing.T) {
+	stringCols := []flux.ColMeta{
+		{Label: "t0", Type: flux.TString},
+		{Label: "_value", Type: flux.TString},
+	}
+	for _, tc := range []struct {
+		name    string
+		spec    *sketch.SketchProcedureSpec
+		data    []flux.Table
+		want    []*executetest.Table
+		wantErr error
+	}{
+		{
+			name: "approxCountDistinct",
+			spec: &sketch.SketchProcedureSpec{
+				Method:    sketch.MethodApproxCountDistinct,
+				Precision: isketch.DefaultPrecision,
+			},
+			data: []flux.Table{
+				&executetest.Table{
+					KeyCols: []string{"t0"},
+					ColMeta: stringCols,
+					Data: [][]interface{}{
+						{"a", "x"},
+						{"a", "y"},
+						{"a", nil},
+						{"a", "x"},
+					},
+				},
+				&executetest.Table{
+					KeyCols: []string{"t0"},
+					ColMeta: stringCols,
+					Data: [][]interface{}{
+						{"b", "x"},
+					},
+				},
+			},
+			want: []*executetest.Table{
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: []flux.ColMeta{
+						{Label: "t0", Type: flux.TString},
+						{Label: "_value", Type: flux.TInt},
+					},
+					Data: [][]interface{}{
+						{"a", int64(2)},
+					},
+				},
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: []flux.ColMeta{
+						{Label: "t0", Type: flux.TString},
+						{Label: "_value", Type: flux.TInt},
+					},
+					Data: [][]interface{}{
+						{"b", int64(1)},
+					},
+				},
+			},
+		},
+		{
+			name: "approxCountDistinct numbers",
+			spec: &sketch.SketchProcedureSpec{
+				Method:    sketch.MethodApproxCountDistinct,
+				Precision: isketch.DefaultPrecision,
+			},
+			data: []flux.Table{&executetest.Table{
+				ColMeta: []flux.ColMeta{
+					{Label: "_value", Type: flux.TFloat},
+				},
+				Data: [][]interface{}{
+					{1.0},
+					{2.0},
+					{1.0},
+					{3.5},
+				},
+			}},
+			want: []*executetest.Table{{
+				ColMeta: []flux.ColMeta{
+					{Label: "_value", Type: flux.TInt},
+				},
+				Data: [][]interface{}{
+					{int64(3)},
+				},
+			}},
+		},
+		{
+			name: "hll",
+			spec: &sketch.SketchProcedureSpec{
+				Method:    sketch.MethodHLL,
+				Precision: 10,
+			},
+			data: []flux.Table{&executetest.Table{
+				KeyCols: []string{"t0"},
+				ColMeta: stringCols,
+				Data: [][]interface{}{
+					{"a", "x"},
+					{"a", "y"},
+				},
+			}},
+			want: []*executetest.Table{{
+				KeyCols: []string{"t0"},
+				ColMeta: stringCols,
+				Data: [][]interface{}{
+					{"a", encodeHLL(t, 10, "x", "y")},
+				},
+			}},
+		},
+		{
+			name: "merge hll",
+			spec: &sketch.SketchProcedureSpec{
+				Method: sketch.MethodMergeSketches,
+			},
+			data: []flux.Table{&executetest.Table{
+				KeyCols: []string{"t0"},
+				ColMeta: stringCols,
+				Data: [][]interface{}{
+					{"a", encodeHLL(t, 10, "x", "y")},
+					{"a", nil},
+					{"a", encodeHLL(t, 10, "y", "z")},
+				},
+			}},
+			want: []*executetest.Table{{
+				KeyCols: []string{"t0"},
+				ColMeta: stringCols,
+				Data: [][]interface{}{
+					{"a", encodeHLL(t, 10, "x", "y", "z")},
+				},
+			}},
+		},
+		{
+			name: "merge no sketches",
+			spec: &sketch.SketchProcedureSpec{
+				Method: sketch.MethodMergeSketches,
+			},
+			data: []flux.Table{&executetest.Table{
+				KeyCols: []string{"t0"},
+				ColMeta: stringCols,
+				Data: [][]interface{}{
+					{"a", nil},
+				},
+			}},
+			want: []*executetest.Table{{
+				KeyCols: []string{"t0"},
+				ColMeta: stringCols,
+				Data: [][]interface{}{
+					{"a", nil},
+				},
+			}},
+		},
+		{
+			name: "estimateCountDistinct",
+			spec: &sketch.SketchProcedureSpec{
+				Method: sketch.MethodEstimateCountDistinct,
+			},
+			data: []flux.Table{&executetest.Table{
+				ColMeta: []flux.ColMeta{
+					{Label: "_value", Type: flux.TString},
+				},
+				Data: [][]interface{}{
+					{encodeHLL(t, 12, "x", "y")},
+					{encodeHLL(t, 10, "y", "z")},
+				},
+			}},
+			want: []*executetest.Table{{
+				ColMeta: []flux.ColMeta{
+					{Label: "_value", Type: flux.TInt},
+				},
+				Data: [][]interface{}{
+					{int64(3)},
+				},
+			}},
+		},
+		{
+			name: "tdigest",
+			spec: &sketch.SketchProcedureSpec{
+				Method:      sketch.MethodTDigest,
+				Compression: sketch.DefaultCompression,
+			},
+			data: []flux.Table{&executetest.Table{
+				ColMeta: []flux.ColMeta{
+					{Label: "_value", Type: flux.TInt},
+				},
+				Data: [][]interface{}{
+					{int64(1)},
+					{int64(2)},
+					{nil},
+				},
+			}},
+			want: []*executetest.Table{{
+				ColMeta: []flux.ColMeta{
+					{Label: "_value", Type: flux.TString},
+				},
+				Data: [][]interface{}{
+					{encodeTDigest(t, 1, 2)},
+				},
+			}},
+		},
+		{
+			name: "estimateQuantile",
+			spec: &sketch.SketchProcedureSpec{
+				Method:   sketch.MethodEstimateQuantile,
+				Quantile: 0.5,
+			},
+			data: []flux.Table{&executetest.Table{
+				ColMeta: []flux.ColMeta{
+					{Label: "_value", Type: flux.TString},
+				},
+				Data: [][]interface{}{
+					{encodeTDigest(t, 1, 2, 3)},
+					{encodeTDigest(t, 4, 5)},
+				},
+			}},
+			want: []*executetest.Table{{
+				ColMeta: []flux.ColMeta{
+					{Label: "_value", Type: flux.TFloat},
+				},
+				Data: [][]interface{}{
+					{3.0},
+				},
+			}},
+		},
+		{
+			name: "estimateQuantile from hll",
+			spec: &sketch.SketchProcedureSpec{
+				Method:   sketch.MethodEstimateQuantile,
+				Quantile: 0.5,
+			},
+			data: []flux.Table{&executetest.Table{
+				ColMeta: []flux.ColMeta{
+					{Label: "_value", Type: flux.TString},
+				},
+				Data: [][]interface{}{
+					{encodeHLL(t, 10, "x")},
+				},
+			}},
+			wantErr: errors.New("estimateQuantile cannot estimate from a HyperLogLog sketch"),
+		},
+		{
+			name: "merge different kinds",
+			spec: &sketch.SketchProcedureSpec{
+				Method: sketch.MethodMergeSketches,
+			},
+			data: []flux.Table{&executetest.Table{
+				ColMeta: []flux.ColMeta{
+					{Label: "_value", Type: flux.TString},
+				},
+				Data: [][]interface{}{
+					{encodeHLL(t, 10, "x")},
+					{encodeTDigest(t, 1)},
+				},
+			}},
+			wantErr: errors.New("cannot merge HyperLogLog sketch with t-digest sketch"),
+		},
+		{
+			name: "invalid sketch",
+			spec: &sketch.SketchProcedureSpec{
+				Method: sketch.MethodMergeSketches,
+			},
+			data: []flux.Table{&executetest.Table{
+				ColMeta: []flux.ColMeta{
+					{Label: "_value", Type: flux.TString},
+				},
+				Data: [][]interface{}{
+					{"x"},
+				},
+			}},
+			wantErr: errors.New("invalid sketch: not an encoded sketch"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			spec := *tc.spec
+			spec.Column = execute.DefaultValueColLabel
+			executetest.ProcessTestHelper2(
+				t,
+				tc.data,
+				tc.want,
+				tc.wantErr,
+				func(id execute.DatasetID, alloc memory.Allocator) (execute.Transformation, execute.Dataset) {
+					tr, d, err := sketch.NewSketchTransformation(id, &spec, alloc)
+					if err != nil {
+						t.Fatal(err)
+					}
+					return tr, d
+				},
+			)
+		})
+	}
+}
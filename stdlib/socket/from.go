@@ -14,6 +14,7 @@ import (
 	"github.com/InfluxCommunity/flux"
 	"github.com/InfluxCommunity/flux/codes"
 	"github.com/InfluxCommunity/flux/csv"
+	fluxurl "github.com/InfluxCommunity/flux/dependencies/url"
 	"github.com/InfluxCommunity/flux/execute"
 	"github.com/InfluxCommunity/flux/internal/errors"
 	"github.com/InfluxCommunity/flux/internal/line"
@@ -136,6 +137,7 @@ func createFromSocketSource(s plan.ProcedureSpec, dsid execute.DatasetID, a exec
 	if err != nil {
 		return nil, err
 	}
+	validator = fluxurl.ValidatorFor(validator, "socket.from")
 	if err := validator.Validate(url); err != nil {
 		return nil, errors.Newf(codes.Invalid, "url did not pass validation: %v", err)
 	}
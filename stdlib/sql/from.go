@@ -6,6 +6,7 @@ import (
 
 	"github.com/InfluxCommunity/flux"
 	"github.com/InfluxCommunity/flux/codes"
+	fluxurl "github.com/InfluxCommunity/flux/dependencies/url"
 	"github.com/InfluxCommunity/flux/execute"
 	"github.com/InfluxCommunity/flux/internal/errors"
 	"github.com/InfluxCommunity/flux/memory"
@@ -90,6 +91,13 @@ func (s *FromSQLProcedureSpec) Copy() plan.ProcedureSpec {
 	return ns
 }
 
+var _ execute.CacheableProcedureSpec = (*FromSQLProcedureSpec)(nil)
+
+// CacheKey implements execute.CacheableProcedureSpec.
+func (s *FromSQLProcedureSpec) CacheKey() (string, bool) {
+	return s.DriverName + "\x00" + s.DataSourceName + "\x00" + s.Query, true
+}
+
 func createFromSQLSource(prSpec plan.ProcedureSpec, dsid execute.DatasetID, a execute.Administration) (execute.Source, error) {
 	spec, ok := prSpec.(*FromSQLProcedureSpec)
 	if !ok {
@@ -102,6 +110,7 @@ func createFromSQLSource(prSpec plan.ProcedureSpec, dsid execute.DatasetID, a ex
 	if err != nil {
 		return nil, err
 	}
+	validator = fluxurl.ValidatorFor(validator, "sql.from")
 	if err := validateDataSource(validator, spec.DriverName, spec.DataSourceName); err != nil {
 		return nil, err
 	}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/InfluxCommunity/flux"
 	"github.com/InfluxCommunity/flux/codes"
+	fluxurl "github.com/InfluxCommunity/flux/dependencies/url"
 	"github.com/InfluxCommunity/flux/execute"
 	"github.com/InfluxCommunity/flux/internal/errors"
 	"github.com/InfluxCommunity/flux/plan"
@@ -152,6 +153,7 @@ func NewToSQLTransformation(d execute.Dataset, deps flux.Dependencies, cache exe
 	if err != nil {
 		return nil, err
 	}
+	validator = fluxurl.ValidatorFor(validator, "sql.to")
 	if err := validateDataSource(validator, spec.Spec.DriverName, spec.Spec.DataSourceName); err != nil {
 		return nil, err
 	}
@@ -0,0 +1,395 @@
+package universe
+
+import (
+	"cmp"
+	"context"
+	"strings"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/array"
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/interpreter"
+	"github.com/InfluxCommunity/flux/memory"
+	"github.com/InfluxCommunity/flux/plan"
+	"github.com/InfluxCommunity/flux/runtime"
+	"github.com/InfluxCommunity/flux/semantic"
+	"github.com/InfluxCommunity/flux/values"
+)
+
+const AggregateKind = "aggregate"
+
+type AggregateOpSpec struct {
+	Columns []string            `json:"columns"`
+	Fns     []AggregateFunction `json:"fns"`
+}
+
+// AggregateFunction is an aggregate computed by aggregate().
+// The aggregate of a column is labeled <column>_<name>.
+type AggregateFunction struct {
+	Name string             `json:"name"`
+	Kind flux.OperationKind `json:"kind"`
+	// Quantile and Compression are the parameters of a quantile.
+	Quantile    float64 `json:"quantile,omitempty"`
+	Compression float64 `json:"compression,omitempty"`
+	// Mode is the mode of a standard deviation.
+	Mode string `json:"mode,omitempty"`
+}
+
+func init() {
+	aggregateSignature := runtime.MustLookupBuiltinType("universe", "aggregate")
+
+	runtime.RegisterPackageValue("universe", AggregateKind, flux.MustValue(flux.FunctionValue(AggregateKind, CreateAggregateOpSpec, aggregateSignature)))
+	plan.RegisterProcedureSpec(AggregateKind, newAggregateProcedure, AggregateKind)
+	execute.RegisterTransformation(AggregateKind, createAggregateTransformation)
+}
+
+func CreateAggregateOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
+	if err := a.AddParentFromArgs(args); err != nil {
+		return nil, err
+	}
+
+	spec := new(AggregateOpSpec)
+	if cols, ok, err := args.GetArray("columns", semantic.String); err != nil {
+		return nil, err
+	} else if ok {
+		if spec.Columns, err = interpreter.ToStringArray(cols); err != nil {
+			return nil, err
+		}
+	} else {
+		spec.Columns = []string{execute.DefaultValueColLabel}
+	}
+	if len(spec.Columns) == 0 {
+		return nil, errors.New(codes.Invalid, "aggregate requires at least one column")
+	}
+
+	fns, err := args.GetRequiredObject("fns")
+	if err != nil {
+		return nil, err
+	}
+	tables, _ := args.Get(flux.TablesParameter)
+	fns.Range(func(name string, v values.Value) {
+		if err != nil {
+			return
+		}
+		var fn AggregateFunction
+		fn, err = newAggregateFunctionFromValue(a.Context(), name, v, tables.(*flux.TableObject), spec.Columns)
+		spec.Fns = append(spec.Fns, fn)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(spec.Fns) == 0 {
+		return nil, errors.New(codes.Invalid, "aggregate requires at least one function")
+	}
+	return spec, nil
+}
+
+// newAggregateFunctionFromValue returns the aggregate computed by
+// the function v. The function is called with the input tables and
+// each of the columns, as the fn of aggregateWindow() is, and must
+// apply the same supported aggregate of the column to the tables.
+func newAggregateFunctionFromValue(ctx context.Context, name string, v values.Value, tables *flux.TableObject, columns []string) (AggregateFunction, error) {
+	if v.Type().Nature() != semantic.Function {
+		return AggregateFunction{}, errors.Newf(codes.Invalid, "aggregate function %q is not a function", name)
+	}
+	fn := v.Function()
+
+	pipe := flux.TablesParameter
+	if arg, err := fn.Type().PipeArgument(); err != nil {
+		return AggregateFunction{}, err
+	} else if arg != nil {
+		pipe = string(arg.Name())
+	}
+
+	var agg AggregateFunction
+	for i, col := range columns {
+		out, err := fn.Call(ctx, values.NewObjectWithValues(map[string]values.Value{
+			pipe:     tables,
+			"column": values.NewString(col),
+		}))
+		if err != nil {
+			return AggregateFunction{}, errors.Wrapf(err, codes.Inherit, "aggregate function %q", name)
+		}
+		to, ok := out.(*flux.TableObject)
+		if !ok || len(to.Parents) != 1 || to.Parents[0] != tables {
+			return AggregateFunction{}, errors.Newf(codes.Invalid, "aggregate function %q must apply a single aggregate to its input", name)
+		}
+		f, err := aggregateFunctionOf(name, to.Spec, col)
+		if err != nil {
+			return AggregateFunction{}, err
+		}
+		if i > 0 && f != agg {
+			return AggregateFunction{}, errors.Newf(codes.Invalid, "aggregate function %q must compute the same aggregate of each column", name)
+		}
+		agg = f
+	}
+	return agg, nil
+}
+
+// aggregateFunctionOf returns the aggregate computed by the operation
+// if it is a supported aggregate of the column.
+func aggregateFunctionOf(name string, spec flux.OperationSpec, column string) (AggregateFunction, error) {
+	fn := AggregateFunction{Name: name, Kind: spec.Kind()}
+	var cols []string
+	switch spec := spec.(type) {
+	case *CountOpSpec:
+		cols = spec.Columns
+	case *SumOpSpec:
+		cols = spec.Columns
+	case *MeanOpSpec:
+		cols = spec.Columns
+	case *SpreadOpSpec:
+		cols = spec.Columns
+	case *SkewOpSpec:
+		cols = spec.Columns
+	case *StddevOpSpec:
+		cols, fn.Mode = spec.Columns, spec.Mode
+	case *QuantileOpSpec:
+		if spec.Method != methodEstimateTdigest {
+			return AggregateFunction{}, errors.Newf(codes.Invalid, "aggregate function %q must use the %s quantile method", name, methodEstimateTdigest)
+		}
+		cols, fn.Quantile, fn.Compression = spec.Columns, spec.Quantile, spec.Compression
+	case *MinOpSpec:
+		cols = []string{spec.Column}
+	case *MaxOpSpec:
+		cols = []string{spec.Column}
+	case *FirstOpSpec:
+		cols = []string{spec.Column}
+	case *LastOpSpec:
+		cols = []string{spec.Column}
+	default:
+		return AggregateFunction{}, errors.Newf(codes.Invalid, "aggregate function %q applies unsupported operation %s", name, spec.Kind())
+	}
+	if len(cols) != 1 || cols[0] != column {
+		return AggregateFunction{}, errors.Newf(codes.Invalid, "aggregate function %q must aggregate the column %q", name, column)
+	}
+	return fn, nil
+}
+
+func (s *AggregateOpSpec) Kind() flux.OperationKind {
+	return AggregateKind
+}
+
+type AggregateProcedureSpec struct {
+	plan.DefaultCost
+	Columns []string
+	Fns     []AggregateFunction
+}
+
+func newAggregateProcedure(qs flux.OperationSpec, pa plan.Administration) (plan.ProcedureSpec, error) {
+	spec, ok := qs.(*AggregateOpSpec)
+	if !ok {
+		return nil, errors.Newf(codes.Internal, "invalid spec type %T", qs)
+	}
+	return &AggregateProcedureSpec{
+		Columns: spec.Columns,
+		Fns:     spec.Fns,
+	}, nil
+}
+
+func (s *AggregateProcedureSpec) Kind() plan.ProcedureKind {
+	return AggregateKind
+}
+
+func (s *AggregateProcedureSpec) Copy() plan.ProcedureSpec {
+	ns := new(AggregateProcedureSpec)
+	*ns = *s
+	ns.Columns = make([]string, len(s.Columns))
+	copy(ns.Columns, s.Columns)
+	ns.Fns = make([]AggregateFunction, len(s.Fns))
+	copy(ns.Fns, s.Fns)
+	return ns
+}
+
+// TriggerSpec implements plan.TriggerAwareProcedureSpec
+func (s *AggregateProcedureSpec) TriggerSpec() plan.TriggerSpec {
+	return plan.NarrowTransformationTriggerSpec{}
+}
+
+// PassThroughAttribute implements the PassThroughAttributer interface used by
+// the planner. Like the other aggregates, there is only one row in each
+// output table so the collation of the input is trivially preserved.
+func (s *AggregateProcedureSpec) PassThroughAttribute(attrKey string) bool {
+	return attrKey == plan.CollationKey
+}
+
+func createAggregateTransformation(id execute.DatasetID, mode execute.AccumulationMode, spec plan.ProcedureSpec, a execute.Administration) (execute.Transformation, execute.Dataset, error) {
+	s, ok := spec.(*AggregateProcedureSpec)
+	if !ok {
+		return nil, nil, errors.Newf(codes.Internal, "invalid spec type %T", spec)
+	}
+	return NewAggregateTransformation(id, s, a.Allocator())
+}
+
+// NewAggregateTransformation constructs a transformation that computes
+// each of the aggregate functions of each of the columns of a table.
+func NewAggregateTransformation(id execute.DatasetID, s *AggregateProcedureSpec, mem memory.Allocator) (execute.Transformation, execute.Dataset, error) {
+	columns := make([]execute.AggregateColumn, 0, len(s.Columns)*len(s.Fns))
+	for _, col := range s.Columns {
+		for _, fn := range s.Fns {
+			agg, err := newAggregate(fn, mem)
+			if err != nil {
+				return nil, nil, err
+			}
+			columns = append(columns, execute.AggregateColumn{
+				Column: col,
+				Label:  col + "_" + fn.Name,
+				Agg:    agg,
+			})
+		}
+	}
+	return execute.NewMultiAggregateTransformation(id, columns, mem)
+}
+
+// newAggregate returns the aggregate that computes the function.
+func newAggregate(fn AggregateFunction, mem memory.Allocator) (execute.SimpleAggregate, error) {
+	switch fn.Kind {
+	case CountKind:
+		return new(CountAgg), nil
+	case SumKind:
+		return new(SumAgg), nil
+	case MeanKind:
+		return new(MeanAgg), nil
+	case SpreadKind:
+		return new(SpreadAgg), nil
+	case StddevKind:
+		return &StddevAgg{Mode: fn.Mode}, nil
+	case SkewKind:
+		return new(SkewAgg), nil
+	case QuantileKind:
+		return NewQuantileAgg(fn.Quantile, fn.Compression, mem, 1), nil
+	case MinKind:
+		return &selectorAgg{replace: func(c int) bool { return c < 0 }}, nil
+	case MaxKind:
+		return &selectorAgg{replace: func(c int) bool { return c > 0 }}, nil
+	case FirstKind:
+		return &selectorAgg{replace: func(c int) bool { return false }}, nil
+	case LastKind:
+		return &selectorAgg{replace: func(c int) bool { return true }}, nil
+	}
+	return nil, errors.Newf(codes.Invalid, "unsupported aggregate function %q of kind %s", fn.Name, fn.Kind)
+}
+
+// selectorAgg is an aggregate that selects one of the non-null values
+// of a column. The value selected so far is replaced by a later value
+// when replace returns true for the comparison of the two.
+type selectorAgg struct {
+	replace func(c int) bool
+}
+
+func (a *selectorAgg) NewBoolAgg() execute.DoBoolAgg {
+	return &boolSelectorState{selectorState: selectorState[bool]{
+		typ:     flux.TBool,
+		replace: a.replace,
+		compare: compareBools,
+	}}
+}
+
+func (a *selectorAgg) NewIntAgg() execute.DoIntAgg {
+	return &intSelectorState{selectorState: selectorState[int64]{
+		typ:     flux.TInt,
+		replace: a.replace,
+		compare: cmp.Compare[int64],
+	}}
+}
+
+func (a *selectorAgg) NewUIntAgg() execute.DoUIntAgg {
+	return &uintSelectorState{selectorState: selectorState[uint64]{
+		typ:     flux.TUInt,
+		replace: a.replace,
+		compare: cmp.Compare[uint64],
+	}}
+}
+
+func (a *selectorAgg) NewFloatAgg() execute.DoFloatAgg {
+	return &floatSelectorState{selectorState: selectorState[float64]{
+		typ:     flux.TFloat,
+		replace: a.replace,
+		compare: cmp.Compare[float64],
+	}}
+}
+
+func (a *selectorAgg) NewStringAgg() execute.DoStringAgg {
+	return &stringSelectorState{selectorState: selectorState[string]{
+		typ:     flux.TString,
+		replace: a.replace,
+		compare: strings.Compare,
+	}}
+}
+
+func compareBools(a, b bool) int {
+	if a == b {
+		return 0
+	} else if b {
+		return -1
+	}
+	return 1
+}
+
+type selectorState[T any] struct {
+	typ     flux.ColType
+	value   T
+	ok      bool
+	replace func(c int) bool
+	compare func(a, b T) int
+}
+
+func (s *selectorState[T]) do(vs interface {
+	Len() int
+	IsValid(i int) bool
+	Value(i int) T
+}) {
+	for i, n := 0, vs.Len(); i < n; i++ {
+		if !vs.IsValid(i) {
+			continue
+		}
+		if v := vs.Value(i); !s.ok || s.replace(s.compare(v, s.value)) {
+			s.value, s.ok = v, true
+		}
+	}
+}
+
+func (s *selectorState[T]) Type() flux.ColType {
+	return s.typ
+}
+
+func (s *selectorState[T]) IsNull() bool {
+	return !s.ok
+}
+
+type boolSelectorState struct {
+	selectorState[bool]
+}
+
+func (s *boolSelectorState) DoBool(vs *array.Boolean) { s.do(vs) }
+func (s *boolSelectorState) ValueBool() bool          { return s.value }
+
+type intSelectorState struct {
+	selectorState[int64]
+}
+
+func (s *intSelectorState) DoInt(vs *array.Int) { s.do(vs) }
+func (s *intSelectorState) ValueInt() int64     { return s.value }
+
+type uintSelectorState struct {
+	selectorState[uint64]
+}
+
+func (s *uintSelectorState) DoUInt(vs *array.Uint) { s.do(vs) }
+func (s *uintSelectorState) ValueUInt() uint64     { return s.value }
+
+type floatSelectorState struct {
+	selectorState[float64]
+}
+
+func (s *floatSelectorState) DoFloat(vs *array.Float) { s.do(vs) }
+func (s *floatSelectorState) ValueFloat() float64     { return s.value }
+
+type stringSelectorState struct {
+	selectorState[string]
+}
+
+func (s *stringSelectorState) DoString(vs *array.String) { s.do(vs) }
+func (s *stringSelectorState) ValueString() string       { return s.value }
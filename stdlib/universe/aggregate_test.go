@@ -0,0 +1,270 @@
+package universe_test
+
+import (
+	"testing"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/execute/executetest"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/internal/operation"
+	"github.com/InfluxCommunity/flux/memory"
+	"github.com/InfluxCommunity/flux/querytest"
+	"github.com/InfluxCommunity/flux/stdlib/influxdata/influxdb"
+	"github.com/InfluxCommunity/flux/stdlib/universe"
+)
+
+func TestAggregate_NewQuery(t *testing.T) {
+	tests := []querytest.NewQueryTestCase{
+		{
+			Name: "aggregate",
+			Raw: `from(bucket:"mydb")
+	|> aggregate(fns: {mean: mean, p95: (tables=<-, column) => tables |> quantile(q: 0.95, column: column)})`,
+			Want: &operation.Spec{
+				Operations: []*operation.Node{
+					{
+						ID: "from0",
+						Spec: &influxdb.FromOpSpec{
+							Bucket: influxdb.NameOrID{Name: "mydb"},
+						},
+					},
+					{
+						ID: "aggregate1",
+						Spec: &universe.AggregateOpSpec{
+							Columns: []string{"_value"},
+							Fns: []universe.AggregateFunction{
+								{Name: "mean", Kind: universe.MeanKind},
+								{Name: "p95", Kind: universe.QuantileKind, Quantile: 0.95, Compression: 1000},
+							},
+						},
+					},
+				},
+				Edges: []operation.Edge{
+					{Parent: "from0", Child: "aggregate1"},
+				},
+			},
+		},
+		{
+			Name: "columns",
+			Raw:  `from(bucket:"mydb") |> aggregate(columns: ["a", "b"], fns: {min: min, sd: stddev})`,
+			Want: &operation.Spec{
+				Operations: []*operation.Node{
+					{
+						ID: "from0",
+						Spec: &influxdb.FromOpSpec{
+							Bucket: influxdb.NameOrID{Name: "mydb"},
+						},
+					},
+					{
+						ID: "aggregate1",
+						Spec: &universe.AggregateOpSpec{
+							Columns: []string{"a", "b"},
+							Fns: []universe.AggregateFunction{
+								{Name: "min", Kind: universe.MinKind},
+								{Name: "sd", Kind: universe.StddevKind, Mode: "sample"},
+							},
+						},
+					},
+				},
+				Edges: []operation.Edge{
+					{Parent: "from0", Child: "aggregate1"},
+				},
+			},
+		},
+		{
+			Name:    "unsupported function",
+			Raw:     `from(bucket:"mydb") |> aggregate(fns: {mode: mode})`,
+			WantErr: true,
+		},
+		{
+			Name:    "exact quantile",
+			Raw:     `from(bucket:"mydb") |> aggregate(fns: {p95: (tables=<-, column) => tables |> quantile(q: 0.95, method: "exact_mean", column: column)})`,
+			WantErr: true,
+		},
+		{
+			Name:    "ignores the column",
+			Raw:     `from(bucket:"mydb") |> aggregate(columns: ["a"], fns: {mean: (tables=<-, column) => tables |> mean()})`,
+			WantErr: true,
+		},
+		{
+			Name:    "multiple operations",
+			Raw:     `from(bucket:"mydb") |> aggregate(fns: {mean: (tables=<-, column) => tables |> filter(fn: (r) => true) |> mean(column: column)})`,
+			WantErr: true,
+		},
+		{
+			Name:    "not a function",
+			Raw:     `from(bucket:"mydb") |> aggregate(fns: {mean: "mean"})`,
+			WantErr: true,
+		},
+		{
+			Name:    "no functions",
+			Raw:     `from(bucket:"mydb") |> aggregate(fns: {})`,
+			WantErr: true,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			querytest.NewQueryTestHelper(t, tc)
+		})
+	}
+}
+
+func TestAggregate_Process(t *testing.T) {
+	testCases := []struct {
+		name    string
+		spec    *universe.AggregateProcedureSpec
+		data    []flux.Table
+		want    []*executetest.Table
+		wantErr error
+	}{
+		{
+			name: "single column",
+			spec: &universe.AggregateProcedureSpec{
+				Columns: []string{"_value"},
+				Fns: []universe.AggregateFunction{
+					{Name: "count", Kind: universe.CountKind},
+					{Name: "sum", Kind: universe.SumKind},
+					{Name: "mean", Kind: universe.MeanKind},
+					{Name: "min", Kind: universe.MinKind},
+					{Name: "max", Kind: universe.MaxKind},
+					{Name: "first", Kind: universe.FirstKind},
+					{Name: "last", Kind: universe.LastKind},
+					{Name: "spread", Kind: universe.SpreadKind},
+					{Name: "median", Kind: universe.QuantileKind, Quantile: 0.5, Compression: 1000},
+				},
+			},
+			data: []flux.Table{&executetest.Table{
+				KeyCols: []string{"t0"},
+				ColMeta: []flux.ColMeta{
+					{Label: "t0", Type: flux.TString},
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TFloat},
+				},
+				Data: [][]interface{}{
+					{"a", execute.Time(1), 4.0},
+					{"a", execute.Time(2), nil},
+					{"a", execute.Time(3), 1.0},
+					{"a", execute.Time(4), 7.0},
+					{"a", execute.Time(5), 2.0},
+				},
+			}, &executetest.Table{
+				KeyCols: []string{"t0"},
+				ColMeta: []flux.ColMeta{
+					{Label: "t0", Type: flux.TString},
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TFloat},
+				},
+				Data: [][]interface{}{
+					{"b", execute.Time(1), nil},
+				},
+			}},
+			want: []*executetest.Table{{
+				KeyCols: []string{"t0"},
+				ColMeta: []flux.ColMeta{
+					{Label: "t0", Type: flux.TString},
+					{Label: "_value_count", Type: flux.TInt},
+					{Label: "_value_sum", Type: flux.TFloat},
+					{Label: "_value_mean", Type: flux.TFloat},
+					{Label: "_value_min", Type: flux.TFloat},
+					{Label: "_value_max", Type: flux.TFloat},
+					{Label: "_value_first", Type: flux.TFloat},
+					{Label: "_value_last", Type: flux.TFloat},
+					{Label: "_value_spread", Type: flux.TFloat},
+					{Label: "_value_median", Type: flux.TFloat},
+				},
+				Data: [][]interface{}{
+					{"a", int64(5), 14.0, 3.5, 1.0, 7.0, 4.0, 2.0, 6.0, 3.0},
+				},
+			}, {
+				KeyCols: []string{"t0"},
+				ColMeta: []flux.ColMeta{
+					{Label: "t0", Type: flux.TString},
+					{Label: "_value_count", Type: flux.TInt},
+					{Label: "_value_sum", Type: flux.TFloat},
+					{Label: "_value_mean", Type: flux.TFloat},
+					{Label: "_value_min", Type: flux.TFloat},
+					{Label: "_value_max", Type: flux.TFloat},
+					{Label: "_value_first", Type: flux.TFloat},
+					{Label: "_value_last", Type: flux.TFloat},
+					{Label: "_value_spread", Type: flux.TFloat},
+					{Label: "_value_median", Type: flux.TFloat},
+				},
+				Data: [][]interface{}{
+					{"b", int64(1), nil, nil, nil, nil, nil, nil, nil, nil},
+				},
+			}},
+		},
+		{
+			name: "multiple columns",
+			spec: &universe.AggregateProcedureSpec{
+				Columns: []string{"n", "s"},
+				Fns: []universe.AggregateFunction{
+					{Name: "min", Kind: universe.MinKind},
+					{Name: "last", Kind: universe.LastKind},
+				},
+			},
+			data: []flux.Table{&executetest.Table{
+				ColMeta: []flux.ColMeta{
+					{Label: "n", Type: flux.TInt},
+					{Label: "s", Type: flux.TString},
+				},
+				Data: [][]interface{}{
+					{int64(3), "b"},
+					{int64(-1), "a"},
+					{int64(2), nil},
+				},
+			}},
+			want: []*executetest.Table{{
+				ColMeta: []flux.ColMeta{
+					{Label: "n_min", Type: flux.TInt},
+					{Label: "n_last", Type: flux.TInt},
+					{Label: "s_min", Type: flux.TString},
+					{Label: "s_last", Type: flux.TString},
+				},
+				Data: [][]interface{}{
+					{int64(-1), int64(2), "a", "a"},
+				},
+			}},
+		},
+		{
+			name: "group key column",
+			spec: &universe.AggregateProcedureSpec{
+				Columns: []string{"_value"},
+				Fns:     []universe.AggregateFunction{{Name: "mean", Kind: universe.MeanKind}},
+			},
+			data: []flux.Table{&executetest.Table{
+				KeyCols: []string{"_value_mean"},
+				ColMeta: []flux.ColMeta{
+					{Label: "_value_mean", Type: flux.TString},
+					{Label: "_value", Type: flux.TFloat},
+				},
+				Data: [][]interface{}{
+					{"a", 1.0},
+				},
+			}},
+			wantErr: errors.New(codes.FailedPrecondition, `aggregate column "_value_mean" conflicts with a group key column`),
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			executetest.ProcessTestHelper2(
+				t,
+				tc.data,
+				tc.want,
+				tc.wantErr,
+				func(id execute.DatasetID, alloc memory.Allocator) (execute.Transformation, execute.Dataset) {
+					tr, d, err := universe.NewAggregateTransformation(id, tc.spec, alloc)
+					if err != nil {
+						t.Fatal(err)
+					}
+					return tr, d
+				},
+				floatOptions,
+			)
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package universe
+
+import (
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/array"
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/execute/table"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/internal/spectral"
+	"github.com/InfluxCommunity/flux/plan"
+	"github.com/InfluxCommunity/flux/runtime"
+	"github.com/apache/arrow/go/v7/arrow/memory"
+)
+
+const AutocorrelationKind = "autocorrelation"
+
+type AutocorrelationOpSpec struct {
+	MaxLag int64  `json:"maxLag"`
+	Column string `json:"column"`
+}
+
+func init() {
+	autocorrelationSignature := runtime.MustLookupBuiltinType("universe", "autocorrelation")
+	runtime.RegisterPackageValue("universe", AutocorrelationKind, flux.MustValue(flux.FunctionValue(AutocorrelationKind, createAutocorrelationOpSpec, autocorrelationSignature)))
+	plan.RegisterProcedureSpec(AutocorrelationKind, newAutocorrelationProcedure, AutocorrelationKind)
+	execute.RegisterTransformation(AutocorrelationKind, createAutocorrelationTransformation)
+}
+
+func createAutocorrelationOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
+	if err := a.AddParentFromArgs(args); err != nil {
+		return nil, err
+	}
+	spec := &AutocorrelationOpSpec{
+		Column: execute.DefaultValueColLabel,
+	}
+	maxLag, err := args.GetRequiredInt("maxLag")
+	if err != nil {
+		return nil, err
+	}
+	if maxLag < 0 {
+		return nil, errors.Newf(codes.Invalid, "maxLag must not be negative, got %d", maxLag)
+	}
+	spec.MaxLag = maxLag
+
+	if col, ok, err := args.GetString("column"); err != nil {
+		return nil, err
+	} else if ok {
+		spec.Column = col
+	}
+	return spec, nil
+}
+
+func (s *AutocorrelationOpSpec) Kind() flux.OperationKind {
+	return AutocorrelationKind
+}
+
+type AutocorrelationProcedureSpec struct {
+	plan.DefaultCost
+	MaxLag int64
+	Column string
+}
+
+func newAutocorrelationProcedure(qs flux.OperationSpec, pa plan.Administration) (plan.ProcedureSpec, error) {
+	spec, ok := qs.(*AutocorrelationOpSpec)
+	if !ok {
+		return nil, errors.Newf(codes.Internal, "invalid spec type %T", qs)
+	}
+	return &AutocorrelationProcedureSpec{
+		MaxLag: spec.MaxLag,
+		Column: spec.Column,
+	}, nil
+}
+
+func (s *AutocorrelationProcedureSpec) Kind() plan.ProcedureKind {
+	return AutocorrelationKind
+}
+
+func (s *AutocorrelationProcedureSpec) Copy() plan.ProcedureSpec {
+	ns := new(AutocorrelationProcedureSpec)
+	*ns = *s
+	return ns
+}
+
+// TriggerSpec implements plan.TriggerAwareProcedureSpec
+func (s *AutocorrelationProcedureSpec) TriggerSpec() plan.TriggerSpec {
+	return plan.NarrowTransformationTriggerSpec{}
+}
+
+func createAutocorrelationTransformation(id execute.DatasetID, mode execute.AccumulationMode, spec plan.ProcedureSpec, a execute.Administration) (execute.Transformation, execute.Dataset, error) {
+	s, ok := spec.(*AutocorrelationProcedureSpec)
+	if !ok {
+		return nil, nil, errors.Newf(codes.Internal, "invalid spec type %T", spec)
+	}
+	return NewAutocorrelationTransformation(id, s, a.Allocator())
+}
+
+// autocorrelationTransformation computes the autocorrelation function
+// of the column of each table once all of its rows have been read.
+type autocorrelationTransformation struct {
+	spec *AutocorrelationProcedureSpec
+}
+
+func NewAutocorrelationTransformation(id execute.DatasetID, spec *AutocorrelationProcedureSpec, mem memory.Allocator) (execute.Transformation, execute.Dataset, error) {
+	t := &autocorrelationTransformation{spec: spec}
+	return execute.NewAggregateTransformation(id, t, mem)
+}
+
+func (t *autocorrelationTransformation) Aggregate(chunk table.Chunk, state interface{}, mem memory.Allocator) (interface{}, bool, error) {
+	values, _ := state.([]float64)
+	values, err := appendFloats("autocorrelation", chunk, t.spec.Column, values)
+	if err != nil {
+		return nil, false, err
+	}
+	return values, true, nil
+}
+
+func (t *autocorrelationTransformation) Compute(key flux.GroupKey, state interface{}, d *execute.TransportDataset, mem memory.Allocator) error {
+	r := spectral.Autocorrelation(state.([]float64), int(t.spec.MaxLag))
+	return d.Process(correlationChunk(key, r, 0, mem))
+}
+
+func (t *autocorrelationTransformation) Close() error {
+	return nil
+}
+
+// correlationChunk returns the correlations by lag, starting at minLag,
+// for the group key. Undefined correlations are null.
+func correlationChunk(key flux.GroupKey, r []float64, minLag int, mem memory.Allocator) table.Chunk {
+	lags := array.NewIntBuilder(mem)
+	lags.Resize(len(r))
+	for i := range r {
+		lags.Append(int64(minLag + i))
+	}
+	cols := []flux.ColMeta{
+		{Label: lagColLabel, Type: flux.TInt},
+		{Label: execute.DefaultValueColLabel, Type: flux.TFloat},
+	}
+	vs := []array.Array{
+		lags.NewArray(),
+		newFloatArray(r, mem),
+	}
+	return keyedChunk(key, cols, vs, mem)
+}
@@ -0,0 +1,153 @@
+package universe_test
+
+import (
+	"testing"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/execute/executetest"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/internal/operation"
+	"github.com/InfluxCommunity/flux/memory"
+	"github.com/InfluxCommunity/flux/querytest"
+	"github.com/InfluxCommunity/flux/stdlib/influxdata/influxdb"
+	"github.com/InfluxCommunity/flux/stdlib/universe"
+)
+
+func TestAutocorrelation_NewQuery(t *testing.T) {
+	tests := []querytest.NewQueryTestCase{
+		{
+			Name: "autocorrelation",
+			Raw:  `from(bucket:"mydb") |> autocorrelation(maxLag: 10, column: "v")`,
+			Want: &operation.Spec{
+				Operations: []*operation.Node{
+					{
+						ID: "from0",
+						Spec: &influxdb.FromOpSpec{
+							Bucket: influxdb.NameOrID{Name: "mydb"},
+						},
+					},
+					{
+						ID: "autocorrelation1",
+						Spec: &universe.AutocorrelationOpSpec{
+							MaxLag: 10,
+							Column: "v",
+						},
+					},
+				},
+				Edges: []operation.Edge{
+					{Parent: "from0", Child: "autocorrelation1"},
+				},
+			},
+		},
+		{
+			Name:    "negative lag",
+			Raw:     `from(bucket:"mydb") |> autocorrelation(maxLag: -1)`,
+			WantErr: true,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			querytest.NewQueryTestHelper(t, tc)
+		})
+	}
+}
+
+func TestAutocorrelation_Process(t *testing.T) {
+	inCols := []flux.ColMeta{
+		{Label: "t0", Type: flux.TString},
+		{Label: "_value", Type: flux.TInt},
+	}
+	outCols := []flux.ColMeta{
+		{Label: "t0", Type: flux.TString},
+		{Label: "lag", Type: flux.TInt},
+		{Label: "_value", Type: flux.TFloat},
+	}
+	testCases := []struct {
+		name    string
+		maxLag  int64
+		data    []flux.Table
+		want    []*executetest.Table
+		wantErr error
+	}{
+		{
+			name:   "autocorrelation",
+			maxLag: 2,
+			data: []flux.Table{&executetest.Table{
+				KeyCols: []string{"t0"},
+				ColMeta: inCols,
+				Data: [][]interface{}{
+					{"a", int64(1)},
+					{"a", int64(2)},
+					{"a", int64(3)},
+					{"a", int64(4)},
+					{"a", int64(5)},
+				},
+			}, &executetest.Table{
+				KeyCols: []string{"t0"},
+				ColMeta: inCols,
+				Data: [][]interface{}{
+					{"b", int64(3)},
+					{"b", int64(3)},
+				},
+			}},
+			want: []*executetest.Table{
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: outCols,
+					Data: [][]interface{}{
+						{"a", int64(0), 1.0},
+						{"a", int64(1), 0.4},
+						{"a", int64(2), -0.1},
+					},
+				},
+				{
+					KeyCols: []string{"t0"},
+					ColMeta: outCols,
+					Data: [][]interface{}{
+						{"b", int64(0), nil},
+						{"b", int64(1), nil},
+					},
+				},
+			},
+		},
+		{
+			name:   "null values",
+			maxLag: 2,
+			data: []flux.Table{&executetest.Table{
+				KeyCols: []string{"t0"},
+				ColMeta: inCols,
+				Data: [][]interface{}{
+					{"a", int64(1)},
+					{"a", nil},
+				},
+			}},
+			wantErr: errors.New(codes.FailedPrecondition, "autocorrelation requires a value in every row; fill null values before autocorrelation()"),
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			executetest.ProcessTestHelper2(
+				t,
+				tc.data,
+				tc.want,
+				tc.wantErr,
+				func(id execute.DatasetID, alloc memory.Allocator) (execute.Transformation, execute.Dataset) {
+					tr, d, err := universe.NewAutocorrelationTransformation(id, &universe.AutocorrelationProcedureSpec{
+						MaxLag: tc.maxLag,
+						Column: execute.DefaultValueColLabel,
+					}, alloc)
+					if err != nil {
+						t.Fatal(err)
+					}
+					return tr, d
+				},
+				floatOptions,
+			)
+		})
+	}
+}
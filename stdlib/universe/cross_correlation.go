@@ -0,0 +1,145 @@
+package universe
+
+import (
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/execute/table"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/internal/spectral"
+	"github.com/InfluxCommunity/flux/interpreter"
+	"github.com/InfluxCommunity/flux/plan"
+	"github.com/InfluxCommunity/flux/runtime"
+	"github.com/InfluxCommunity/flux/semantic"
+	"github.com/apache/arrow/go/v7/arrow/memory"
+)
+
+const CrossCorrelationKind = "_crossCorrelation"
+
+type CrossCorrelationOpSpec struct {
+	MaxLag  int64    `json:"maxLag"`
+	Columns []string `json:"columns"`
+}
+
+func init() {
+	crossCorrelationSignature := runtime.MustLookupBuiltinType("universe", CrossCorrelationKind)
+	runtime.RegisterPackageValue("universe", CrossCorrelationKind, flux.MustValue(flux.FunctionValue(CrossCorrelationKind, createCrossCorrelationOpSpec, crossCorrelationSignature)))
+	plan.RegisterProcedureSpec(CrossCorrelationKind, newCrossCorrelationProcedure, CrossCorrelationKind)
+	execute.RegisterTransformation(CrossCorrelationKind, createCrossCorrelationTransformation)
+}
+
+func createCrossCorrelationOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
+	if err := a.AddParentFromArgs(args); err != nil {
+		return nil, err
+	}
+	spec := new(CrossCorrelationOpSpec)
+	maxLag, err := args.GetRequiredInt("maxLag")
+	if err != nil {
+		return nil, err
+	}
+	if maxLag < 0 {
+		return nil, errors.Newf(codes.Invalid, "maxLag must not be negative, got %d", maxLag)
+	}
+	spec.MaxLag = maxLag
+
+	cols, err := args.GetRequiredArray("columns", semantic.String)
+	if err != nil {
+		return nil, err
+	}
+	columns, err := interpreter.ToStringArray(cols)
+	if err != nil {
+		return nil, err
+	}
+	if len(columns) != 2 {
+		return nil, errors.New(codes.Invalid, "must provide exactly two columns")
+	}
+	spec.Columns = columns
+	return spec, nil
+}
+
+func (s *CrossCorrelationOpSpec) Kind() flux.OperationKind {
+	return CrossCorrelationKind
+}
+
+type CrossCorrelationProcedureSpec struct {
+	plan.DefaultCost
+	MaxLag  int64
+	Columns []string
+}
+
+func newCrossCorrelationProcedure(qs flux.OperationSpec, pa plan.Administration) (plan.ProcedureSpec, error) {
+	spec, ok := qs.(*CrossCorrelationOpSpec)
+	if !ok {
+		return nil, errors.Newf(codes.Internal, "invalid spec type %T", qs)
+	}
+	return &CrossCorrelationProcedureSpec{
+		MaxLag:  spec.MaxLag,
+		Columns: append([]string(nil), spec.Columns...),
+	}, nil
+}
+
+func (s *CrossCorrelationProcedureSpec) Kind() plan.ProcedureKind {
+	return CrossCorrelationKind
+}
+
+func (s *CrossCorrelationProcedureSpec) Copy() plan.ProcedureSpec {
+	ns := new(CrossCorrelationProcedureSpec)
+	*ns = *s
+	ns.Columns = append([]string(nil), s.Columns...)
+	return ns
+}
+
+// TriggerSpec implements plan.TriggerAwareProcedureSpec
+func (s *CrossCorrelationProcedureSpec) TriggerSpec() plan.TriggerSpec {
+	return plan.NarrowTransformationTriggerSpec{}
+}
+
+func createCrossCorrelationTransformation(id execute.DatasetID, mode execute.AccumulationMode, spec plan.ProcedureSpec, a execute.Administration) (execute.Transformation, execute.Dataset, error) {
+	s, ok := spec.(*CrossCorrelationProcedureSpec)
+	if !ok {
+		return nil, nil, errors.Newf(codes.Internal, "invalid spec type %T", spec)
+	}
+	return NewCrossCorrelationTransformation(id, s, a.Allocator())
+}
+
+// crossCorrelationTransformation computes the cross-correlation function
+// of the paired columns of each table once all of its rows have been read.
+type crossCorrelationTransformation struct {
+	spec *CrossCorrelationProcedureSpec
+}
+
+type crossCorrelationState struct {
+	x, y []float64
+}
+
+func NewCrossCorrelationTransformation(id execute.DatasetID, spec *CrossCorrelationProcedureSpec, mem memory.Allocator) (execute.Transformation, execute.Dataset, error) {
+	t := &crossCorrelationTransformation{spec: spec}
+	return execute.NewAggregateTransformation(id, t, mem)
+}
+
+func (t *crossCorrelationTransformation) Aggregate(chunk table.Chunk, state interface{}, mem memory.Allocator) (interface{}, bool, error) {
+	s, _ := state.(*crossCorrelationState)
+	if s == nil {
+		s = &crossCorrelationState{}
+	}
+	x, err := appendFloats("crossCorrelation", chunk, t.spec.Columns[0], s.x)
+	if err != nil {
+		return nil, false, err
+	}
+	y, err := appendFloats("crossCorrelation", chunk, t.spec.Columns[1], s.y)
+	if err != nil {
+		return nil, false, err
+	}
+	s.x, s.y = x, y
+	return s, true, nil
+}
+
+func (t *crossCorrelationTransformation) Compute(key flux.GroupKey, state interface{}, d *execute.TransportDataset, mem memory.Allocator) error {
+	s := state.(*crossCorrelationState)
+	r := spectral.CrossCorrelation(s.x, s.y, int(t.spec.MaxLag))
+	return d.Process(correlationChunk(key, r, -len(r)/2, mem))
+}
+
+func (t *crossCorrelationTransformation) Close() error {
+	return nil
+}
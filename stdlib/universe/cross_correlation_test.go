@@ -0,0 +1,102 @@
+package universe_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/execute/executetest"
+	"github.com/InfluxCommunity/flux/internal/operation"
+	"github.com/InfluxCommunity/flux/memory"
+	"github.com/InfluxCommunity/flux/querytest"
+	"github.com/InfluxCommunity/flux/stdlib/influxdata/influxdb"
+	"github.com/InfluxCommunity/flux/stdlib/universe"
+)
+
+func TestCrossCorrelation_NewQuery(t *testing.T) {
+	tests := []querytest.NewQueryTestCase{
+		{
+			Name: "cross-correlation",
+			Raw:  `from(bucket:"mydb") |> _crossCorrelation(maxLag: 5, columns: ["x", "y"])`,
+			Want: &operation.Spec{
+				Operations: []*operation.Node{
+					{
+						ID: "from0",
+						Spec: &influxdb.FromOpSpec{
+							Bucket: influxdb.NameOrID{Name: "mydb"},
+						},
+					},
+					{
+						ID: "_crossCorrelation1",
+						Spec: &universe.CrossCorrelationOpSpec{
+							MaxLag:  5,
+							Columns: []string{"x", "y"},
+						},
+					},
+				},
+				Edges: []operation.Edge{
+					{Parent: "from0", Child: "_crossCorrelation1"},
+				},
+			},
+		},
+		{
+			Name:    "one column",
+			Raw:     `from(bucket:"mydb") |> _crossCorrelation(maxLag: 5, columns: ["x"])`,
+			WantErr: true,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			querytest.NewQueryTestHelper(t, tc)
+		})
+	}
+}
+
+func TestCrossCorrelation_Process(t *testing.T) {
+	// The deviations of x are -2, 0, -1, 2, 1 and the deviations of y
+	// are 1.8, -2.2, -0.2, -1.2, 1.8, with sums of squares of 10 and 12.8.
+	norm := math.Sqrt(10 * 12.8)
+	executetest.ProcessTestHelper2(
+		t,
+		[]flux.Table{&executetest.Table{
+			ColMeta: []flux.ColMeta{
+				{Label: "_time", Type: flux.TTime},
+				{Label: "x", Type: flux.TFloat},
+				{Label: "y", Type: flux.TInt},
+			},
+			Data: [][]interface{}{
+				{execute.Time(1), 1.0, int64(5)},
+				{execute.Time(2), 3.0, int64(1)},
+				{execute.Time(3), 2.0, int64(3)},
+				{execute.Time(4), 5.0, int64(2)},
+				{execute.Time(5), 4.0, int64(5)},
+			},
+		}},
+		[]*executetest.Table{{
+			ColMeta: []flux.ColMeta{
+				{Label: "lag", Type: flux.TInt},
+				{Label: "_value", Type: flux.TFloat},
+			},
+			Data: [][]interface{}{
+				{int64(-1), 0.6 / norm},
+				{int64(0), -4 / norm},
+				{int64(1), 9.2 / norm},
+			},
+		}},
+		nil,
+		func(id execute.DatasetID, alloc memory.Allocator) (execute.Transformation, execute.Dataset) {
+			tr, d, err := universe.NewCrossCorrelationTransformation(id, &universe.CrossCorrelationProcedureSpec{
+				MaxLag:  1,
+				Columns: []string{"x", "y"},
+			}, alloc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return tr, d
+		},
+		floatOptions,
+	)
+}
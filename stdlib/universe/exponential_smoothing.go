@@ -0,0 +1,192 @@
+package universe
+
+import (
+	"math"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/plan"
+	"github.com/InfluxCommunity/flux/runtime"
+	"github.com/InfluxCommunity/flux/stdlib/universe/forecast"
+)
+
+const ExponentialSmoothingKind = "exponentialSmoothing"
+
+type ExponentialSmoothingOpSpec struct {
+	// Alpha and Beta are nil when they are fitted to the data.
+	Alpha  *float64 `json:"alpha,omitempty"`
+	Beta   *float64 `json:"beta,omitempty"`
+	Trend  bool     `json:"trend"`
+	Column string   `json:"column"`
+}
+
+func init() {
+	esSignature := runtime.MustLookupBuiltinType("universe", "exponentialSmoothing")
+	runtime.RegisterPackageValue("universe", ExponentialSmoothingKind, flux.MustValue(flux.FunctionValue(ExponentialSmoothingKind, createExponentialSmoothingOpSpec, esSignature)))
+	plan.RegisterProcedureSpec(ExponentialSmoothingKind, newExponentialSmoothingProcedure, ExponentialSmoothingKind)
+	execute.RegisterTransformation(ExponentialSmoothingKind, createExponentialSmoothingTransformation)
+}
+
+func createExponentialSmoothingOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
+	if err := a.AddParentFromArgs(args); err != nil {
+		return nil, err
+	}
+	spec := &ExponentialSmoothingOpSpec{
+		Column: execute.DefaultValueColLabel,
+	}
+	if t, ok, err := args.GetBool("trend"); err != nil {
+		return nil, err
+	} else if ok {
+		spec.Trend = t
+	}
+	for _, param := range []struct {
+		name string
+		v    **float64
+	}{
+		{name: "alpha", v: &spec.Alpha},
+		{name: "beta", v: &spec.Beta},
+	} {
+		v, ok, err := args.GetFloat(param.name)
+		if err != nil {
+			return nil, err
+		} else if !ok {
+			continue
+		}
+		if v < 0 || v > 1 {
+			return nil, errors.Newf(codes.Invalid, "%s must be between 0 and 1, got %v", param.name, v)
+		}
+		*param.v = &v
+	}
+	if !spec.Trend && spec.Beta != nil {
+		return nil, errors.New(codes.Invalid, "beta is only valid with trend")
+	}
+	if col, ok, err := args.GetString("column"); err != nil {
+		return nil, err
+	} else if ok {
+		spec.Column = col
+	}
+	return spec, nil
+}
+
+func (s *ExponentialSmoothingOpSpec) Kind() flux.OperationKind {
+	return ExponentialSmoothingKind
+}
+
+type ExponentialSmoothingProcedureSpec struct {
+	plan.DefaultCost
+	// Alpha and Beta are NaN when they are fitted to the data.
+	Alpha  float64
+	Beta   float64
+	Trend  bool
+	Column string
+}
+
+func newExponentialSmoothingProcedure(qs flux.OperationSpec, pa plan.Administration) (plan.ProcedureSpec, error) {
+	spec, ok := qs.(*ExponentialSmoothingOpSpec)
+	if !ok {
+		return nil, errors.Newf(codes.Internal, "invalid spec type %T", qs)
+	}
+	ps := &ExponentialSmoothingProcedureSpec{
+		Alpha:  math.NaN(),
+		Beta:   math.NaN(),
+		Trend:  spec.Trend,
+		Column: spec.Column,
+	}
+	if spec.Alpha != nil {
+		ps.Alpha = *spec.Alpha
+	}
+	if spec.Beta != nil {
+		ps.Beta = *spec.Beta
+	}
+	return ps, nil
+}
+
+func (s *ExponentialSmoothingProcedureSpec) Kind() plan.ProcedureKind {
+	return ExponentialSmoothingKind
+}
+
+func (s *ExponentialSmoothingProcedureSpec) Copy() plan.ProcedureSpec {
+	ns := new(ExponentialSmoothingProcedureSpec)
+	*ns = *s
+	return ns
+}
+
+// TriggerSpec implements plan.TriggerAwareProcedureSpec
+func (s *ExponentialSmoothingProcedureSpec) TriggerSpec() plan.TriggerSpec {
+	return plan.NarrowTransformationTriggerSpec{}
+}
+
+func createExponentialSmoothingTransformation(id execute.DatasetID, mode execute.AccumulationMode, spec plan.ProcedureSpec, a execute.Administration) (execute.Transformation, execute.Dataset, error) {
+	s, ok := spec.(*ExponentialSmoothingProcedureSpec)
+	if !ok {
+		return nil, nil, errors.Newf(codes.Internal, "invalid spec type %T", spec)
+	}
+	cache := execute.NewTableBuilderCache(a.Allocator())
+	d := execute.NewDataset(id, mode, cache)
+	t := NewExponentialSmoothingTransformation(d, cache, s)
+	return t, d, nil
+}
+
+type exponentialSmoothingTransformation struct {
+	execute.ExecutionNode
+	d     execute.Dataset
+	cache execute.TableBuilderCache
+	spec  ExponentialSmoothingProcedureSpec
+}
+
+func NewExponentialSmoothingTransformation(d execute.Dataset, cache execute.TableBuilderCache, spec *ExponentialSmoothingProcedureSpec) *exponentialSmoothingTransformation {
+	return &exponentialSmoothingTransformation{
+		d:     d,
+		cache: cache,
+		spec:  *spec,
+	}
+}
+
+func (t *exponentialSmoothingTransformation) Process(id execute.DatasetID, tbl flux.Table) error {
+	builder, created := t.cache.TableBuilder(tbl.Key())
+	if !created {
+		return errors.Newf(codes.FailedPrecondition, "exponentialSmoothing found duplicate table with key: %v", tbl.Key())
+	}
+	colIdx, err := seriesColumn("exponentialSmoothing", tbl, t.spec.Column)
+	if err != nil {
+		return err
+	}
+	for j, col := range tbl.Cols() {
+		if j == colIdx {
+			col.Type = flux.TFloat
+		}
+		if _, err := builder.AddCol(col); err != nil {
+			return err
+		}
+	}
+
+	series, err := readSeries(tbl, builder, colIdx, false, -1)
+	if err != nil {
+		return err
+	}
+	y := series.values
+	// Series with too few values to fit are already smooth.
+	smoothed := y
+	if fit, ok := forecast.FitSmoothing(y, t.spec.Alpha, t.spec.Beta, t.spec.Trend); ok {
+		smoothed = fit.Smoothed
+	}
+	return appendSeries(builder, colIdx, smoothed)
+}
+
+func (t *exponentialSmoothingTransformation) RetractTable(id execute.DatasetID, key flux.GroupKey) error {
+	return t.d.RetractTable(key)
+}
+
+func (t *exponentialSmoothingTransformation) UpdateWatermark(id execute.DatasetID, mark execute.Time) error {
+	return t.d.UpdateWatermark(mark)
+}
+
+func (t *exponentialSmoothingTransformation) UpdateProcessingTime(id execute.DatasetID, pt execute.Time) error {
+	return t.d.UpdateProcessingTime(pt)
+}
+
+func (t *exponentialSmoothingTransformation) Finish(id execute.DatasetID, err error) {
+	t.d.Finish(err)
+}
@@ -0,0 +1,210 @@
+package universe_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/execute/executetest"
+	"github.com/InfluxCommunity/flux/internal/operation"
+	"github.com/InfluxCommunity/flux/querytest"
+	"github.com/InfluxCommunity/flux/stdlib/influxdata/influxdb"
+	"github.com/InfluxCommunity/flux/stdlib/universe"
+)
+
+func TestExponentialSmoothing_NewQuery(t *testing.T) {
+	tests := []querytest.NewQueryTestCase{
+		{
+			Name: "exponentialSmoothing defaults",
+			Raw:  `from(bucket:"mydb") |> exponentialSmoothing()`,
+			Want: &operation.Spec{
+				Operations: []*operation.Node{
+					{
+						ID: "from0",
+						Spec: &influxdb.FromOpSpec{
+							Bucket: influxdb.NameOrID{Name: "mydb"},
+						},
+					},
+					{
+						ID: "exponentialSmoothing1",
+						Spec: &universe.ExponentialSmoothingOpSpec{
+							Column: execute.DefaultValueColLabel,
+						},
+					},
+				},
+				Edges: []operation.Edge{
+					{Parent: "from0", Child: "exponentialSmoothing1"},
+				},
+			},
+		},
+		{
+			Name: "exponentialSmoothing no defaults",
+			Raw:  `from(bucket:"mydb") |> exponentialSmoothing(alpha: 0.3, beta: 0.1, trend: true, column: "v")`,
+			Want: &operation.Spec{
+				Operations: []*operation.Node{
+					{
+						ID: "from0",
+						Spec: &influxdb.FromOpSpec{
+							Bucket: influxdb.NameOrID{Name: "mydb"},
+						},
+					},
+					{
+						ID: "exponentialSmoothing1",
+						Spec: &universe.ExponentialSmoothingOpSpec{
+							Alpha:  floatPtr(0.3),
+							Beta:   floatPtr(0.1),
+							Trend:  true,
+							Column: "v",
+						},
+					},
+				},
+				Edges: []operation.Edge{
+					{Parent: "from0", Child: "exponentialSmoothing1"},
+				},
+			},
+		},
+		{
+			Name:    "alpha out of range",
+			Raw:     `from(bucket:"mydb") |> exponentialSmoothing(alpha: 1.5)`,
+			WantErr: true,
+		},
+		{
+			Name:    "beta without trend",
+			Raw:     `from(bucket:"mydb") |> exponentialSmoothing(beta: 0.5)`,
+			WantErr: true,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			querytest.NewQueryTestHelper(t, tc)
+		})
+	}
+}
+
+func TestExponentialSmoothing_Process(t *testing.T) {
+	testCases := []struct {
+		name string
+		spec *universe.ExponentialSmoothingProcedureSpec
+		data []flux.Table
+		want []*executetest.Table
+	}{
+		{
+			name: "trend with nulls",
+			spec: &universe.ExponentialSmoothingProcedureSpec{
+				Alpha:  0.5,
+				Beta:   0.5,
+				Trend:  true,
+				Column: "_value",
+			},
+			data: []flux.Table{&executetest.Table{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TInt},
+				},
+				Data: [][]interface{}{
+					{execute.Time(10), int64(1)},
+					{execute.Time(20), int64(2)},
+					{execute.Time(30), nil},
+					{execute.Time(40), int64(5)},
+					{execute.Time(50), int64(4)},
+				},
+			}},
+			want: []*executetest.Table{{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TFloat},
+				},
+				Data: [][]interface{}{
+					{execute.Time(10), 1.0},
+					{execute.Time(20), 2.0},
+					{execute.Time(30), nil},
+					{execute.Time(40), 4.5},
+					{execute.Time(50), 4.875},
+				},
+			}},
+		},
+		{
+			name: "fitted",
+			spec: &universe.ExponentialSmoothingProcedureSpec{
+				Alpha:  math.NaN(),
+				Beta:   math.NaN(),
+				Trend:  true,
+				Column: "_value",
+			},
+			data: []flux.Table{&executetest.Table{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TFloat},
+				},
+				Data: [][]interface{}{
+					{execute.Time(10), 1.0},
+					{execute.Time(20), 3.0},
+					{execute.Time(30), 5.0},
+					{execute.Time(40), 7.0},
+				},
+			}},
+			want: []*executetest.Table{{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TFloat},
+				},
+				Data: [][]interface{}{
+					{execute.Time(10), 1.0},
+					{execute.Time(20), 3.0},
+					{execute.Time(30), 5.0},
+					{execute.Time(40), 7.0},
+				},
+			}},
+		},
+		{
+			name: "too few values",
+			spec: &universe.ExponentialSmoothingProcedureSpec{
+				Alpha:  0.5,
+				Beta:   math.NaN(),
+				Column: "_value",
+			},
+			data: []flux.Table{&executetest.Table{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TInt},
+				},
+				Data: [][]interface{}{
+					{execute.Time(10), nil},
+					{execute.Time(20), int64(2)},
+				},
+			}},
+			want: []*executetest.Table{{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TFloat},
+				},
+				Data: [][]interface{}{
+					{execute.Time(10), nil},
+					{execute.Time(20), 2.0},
+				},
+			}},
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			executetest.ProcessTestHelper(
+				t,
+				tc.data,
+				tc.want,
+				nil,
+				func(d execute.Dataset, c execute.TableBuilderCache) execute.Transformation {
+					return universe.NewExponentialSmoothingTransformation(d, c, tc.spec)
+				},
+				floatOptions,
+			)
+		})
+	}
+}
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
@@ -0,0 +1,191 @@
+package universe
+
+import (
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/array"
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/execute/table"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/internal/spectral"
+	"github.com/InfluxCommunity/flux/plan"
+	"github.com/InfluxCommunity/flux/runtime"
+	"github.com/apache/arrow/go/v7/arrow/memory"
+)
+
+const FFTKind = "fft"
+
+const (
+	fftFrequencyColLabel = "frequency"
+	fftMagnitudeColLabel = "magnitude"
+	fftPhaseColLabel     = "phase"
+)
+
+type FFTOpSpec struct {
+	Column     string `json:"column"`
+	TimeColumn string `json:"timeColumn"`
+	Window     string `json:"window"`
+}
+
+func init() {
+	fftSignature := runtime.MustLookupBuiltinType("universe", "fft")
+	runtime.RegisterPackageValue("universe", FFTKind, flux.MustValue(flux.FunctionValue(FFTKind, createFFTOpSpec, fftSignature)))
+	plan.RegisterProcedureSpec(FFTKind, newFFTProcedure, FFTKind)
+	execute.RegisterTransformation(FFTKind, createFFTTransformation)
+}
+
+func createFFTOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
+	if err := a.AddParentFromArgs(args); err != nil {
+		return nil, err
+	}
+	spec := &FFTOpSpec{
+		Column:     execute.DefaultValueColLabel,
+		TimeColumn: execute.DefaultTimeColLabel,
+		Window:     spectral.WindowNone,
+	}
+	if col, ok, err := args.GetString("column"); err != nil {
+		return nil, err
+	} else if ok {
+		spec.Column = col
+	}
+	if col, ok, err := args.GetString("timeColumn"); err != nil {
+		return nil, err
+	} else if ok {
+		spec.TimeColumn = col
+	}
+	if w, ok, err := args.GetString("window"); err != nil {
+		return nil, err
+	} else if ok {
+		if !spectral.IsWindow(w) {
+			return nil, errors.Newf(codes.Invalid, "window must be one of %q, %q, %q or %q, got %q",
+				spectral.WindowNone, spectral.WindowHann, spectral.WindowHamming, spectral.WindowBlackman, w)
+		}
+		spec.Window = w
+	}
+	return spec, nil
+}
+
+func (s *FFTOpSpec) Kind() flux.OperationKind {
+	return FFTKind
+}
+
+type FFTProcedureSpec struct {
+	plan.DefaultCost
+	Column     string
+	TimeColumn string
+	Window     string
+}
+
+func newFFTProcedure(qs flux.OperationSpec, pa plan.Administration) (plan.ProcedureSpec, error) {
+	spec, ok := qs.(*FFTOpSpec)
+	if !ok {
+		return nil, errors.Newf(codes.Internal, "invalid spec type %T", qs)
+	}
+	return &FFTProcedureSpec{
+		Column:     spec.Column,
+		TimeColumn: spec.TimeColumn,
+		Window:     spec.Window,
+	}, nil
+}
+
+func (s *FFTProcedureSpec) Kind() plan.ProcedureKind {
+	return FFTKind
+}
+
+func (s *FFTProcedureSpec) Copy() plan.ProcedureSpec {
+	ns := new(FFTProcedureSpec)
+	*ns = *s
+	return ns
+}
+
+// TriggerSpec implements plan.TriggerAwareProcedureSpec
+func (s *FFTProcedureSpec) TriggerSpec() plan.TriggerSpec {
+	return plan.NarrowTransformationTriggerSpec{}
+}
+
+func createFFTTransformation(id execute.DatasetID, mode execute.AccumulationMode, spec plan.ProcedureSpec, a execute.Administration) (execute.Transformation, execute.Dataset, error) {
+	s, ok := spec.(*FFTProcedureSpec)
+	if !ok {
+		return nil, nil, errors.Newf(codes.Internal, "invalid spec type %T", spec)
+	}
+	return NewFFTTransformation(id, s, a.Allocator())
+}
+
+// fftTransformation computes the spectrum of the column of each table
+// once all of its rows have been read.
+type fftTransformation struct {
+	spec *FFTProcedureSpec
+}
+
+type fftState struct {
+	times  []int64
+	values []float64
+}
+
+func NewFFTTransformation(id execute.DatasetID, spec *FFTProcedureSpec, mem memory.Allocator) (execute.Transformation, execute.Dataset, error) {
+	t := &fftTransformation{spec: spec}
+	return execute.NewAggregateTransformation(id, t, mem)
+}
+
+func (t *fftTransformation) Aggregate(chunk table.Chunk, state interface{}, mem memory.Allocator) (interface{}, bool, error) {
+	s, _ := state.(*fftState)
+	if s == nil {
+		s = &fftState{}
+	}
+
+	idx := chunk.Index(t.spec.TimeColumn)
+	if idx < 0 {
+		return nil, false, errors.Newf(codes.FailedPrecondition, "cannot find column %s", t.spec.TimeColumn)
+	}
+	if typ := chunk.Col(idx).Type; typ != flux.TTime {
+		return nil, false, errors.Newf(codes.FailedPrecondition, "fft requires column %s to be of type time, got %s", t.spec.TimeColumn, typ)
+	}
+	ts := chunk.Values(idx).(*array.Int)
+	if ts.NullN() > 0 {
+		return nil, false, errors.New(codes.FailedPrecondition, "fft requires a time in every row; filter null times before fft()")
+	}
+	values, err := appendFloats("fft", chunk, t.spec.Column, s.values)
+	if err != nil {
+		return nil, false, err
+	}
+	s.values = values
+	s.times = append(s.times, ts.Int64Values()...)
+	return s, true, nil
+}
+
+func (t *fftTransformation) Compute(key flux.GroupKey, state interface{}, d *execute.TransportDataset, mem memory.Allocator) error {
+	s := state.(*fftState)
+	n := len(s.values)
+
+	var frequency, magnitude, phase []float64
+	if n >= 2 {
+		// The series is assumed to be sampled at a regular interval
+		// so the interval is the average of all of them.
+		elapsed := s.times[n-1] - s.times[0]
+		if elapsed <= 0 {
+			return errors.New(codes.FailedPrecondition, "fft requires rows sorted by increasing time")
+		}
+		interval := float64(elapsed) / 1e9 / float64(n-1)
+		magnitude, phase = spectral.Spectrum(s.values, t.spec.Window)
+		frequency = make([]float64, len(magnitude))
+		for k := range frequency {
+			frequency[k] = float64(k) / (float64(n) * interval)
+		}
+	}
+
+	cols := []flux.ColMeta{
+		{Label: fftFrequencyColLabel, Type: flux.TFloat},
+		{Label: fftMagnitudeColLabel, Type: flux.TFloat},
+		{Label: fftPhaseColLabel, Type: flux.TFloat},
+	}
+	vs := []array.Array{
+		newFloatArray(frequency, mem),
+		newFloatArray(magnitude, mem),
+		newFloatArray(phase, mem),
+	}
+	return d.Process(keyedChunk(key, cols, vs, mem))
+}
+
+func (t *fftTransformation) Close() error {
+	return nil
+}
@@ -0,0 +1,201 @@
+package universe_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/execute/executetest"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/internal/operation"
+	"github.com/InfluxCommunity/flux/memory"
+	"github.com/InfluxCommunity/flux/querytest"
+	"github.com/InfluxCommunity/flux/stdlib/influxdata/influxdb"
+	"github.com/InfluxCommunity/flux/stdlib/universe"
+)
+
+func TestFFT_NewQuery(t *testing.T) {
+	tests := []querytest.NewQueryTestCase{
+		{
+			Name: "fft defaults",
+			Raw:  `from(bucket:"mydb") |> fft()`,
+			Want: &operation.Spec{
+				Operations: []*operation.Node{
+					{
+						ID: "from0",
+						Spec: &influxdb.FromOpSpec{
+							Bucket: influxdb.NameOrID{Name: "mydb"},
+						},
+					},
+					{
+						ID: "fft1",
+						Spec: &universe.FFTOpSpec{
+							Column:     execute.DefaultValueColLabel,
+							TimeColumn: execute.DefaultTimeColLabel,
+							Window:     "none",
+						},
+					},
+				},
+				Edges: []operation.Edge{
+					{Parent: "from0", Child: "fft1"},
+				},
+			},
+		},
+		{
+			Name: "fft no defaults",
+			Raw:  `from(bucket:"mydb") |> fft(column: "v", timeColumn: "t", window: "hann")`,
+			Want: &operation.Spec{
+				Operations: []*operation.Node{
+					{
+						ID: "from0",
+						Spec: &influxdb.FromOpSpec{
+							Bucket: influxdb.NameOrID{Name: "mydb"},
+						},
+					},
+					{
+						ID: "fft1",
+						Spec: &universe.FFTOpSpec{
+							Column:     "v",
+							TimeColumn: "t",
+							Window:     "hann",
+						},
+					},
+				},
+				Edges: []operation.Edge{
+					{Parent: "from0", Child: "fft1"},
+				},
+			},
+		},
+		{
+			Name:    "unknown window",
+			Raw:     `from(bucket:"mydb") |> fft(window: "kaiser")`,
+			WantErr: true,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			querytest.NewQueryTestHelper(t, tc)
+		})
+	}
+}
+
+func TestFFT_Process(t *testing.T) {
+	inCols := []flux.ColMeta{
+		{Label: "_time", Type: flux.TTime},
+		{Label: "t0", Type: flux.TString},
+		{Label: "_value", Type: flux.TInt},
+	}
+	outCols := []flux.ColMeta{
+		{Label: "t0", Type: flux.TString},
+		{Label: "frequency", Type: flux.TFloat},
+		{Label: "magnitude", Type: flux.TFloat},
+		{Label: "phase", Type: flux.TFloat},
+	}
+	second := int64(1e9)
+	testCases := []struct {
+		name    string
+		spec    *universe.FFTProcedureSpec
+		data    []flux.Table
+		want    []*executetest.Table
+		wantErr error
+	}{
+		{
+			name: "spectrum",
+			spec: &universe.FFTProcedureSpec{Window: "none"},
+			data: []flux.Table{&executetest.Table{
+				KeyCols: []string{"t0"},
+				ColMeta: inCols,
+				Data: [][]interface{}{
+					{execute.Time(0), "a", int64(1)},
+					{execute.Time(second), "a", int64(2)},
+					{execute.Time(2 * second), "a", int64(3)},
+					{execute.Time(3 * second), "a", int64(4)},
+				},
+			}},
+			want: []*executetest.Table{{
+				KeyCols: []string{"t0"},
+				ColMeta: outCols,
+				Data: [][]interface{}{
+					{"a", 0.0, 2.5, 0.0},
+					{"a", 0.25, math.Sqrt2, 3 * math.Pi / 4},
+					{"a", 0.5, 0.5, math.Pi},
+				},
+			}},
+		},
+		{
+			name: "hann window",
+			spec: &universe.FFTProcedureSpec{Window: "hann"},
+			data: []flux.Table{&executetest.Table{
+				KeyCols: []string{"t0"},
+				ColMeta: inCols,
+				Data: [][]interface{}{
+					{execute.Time(0), "a", int64(1)},
+					{execute.Time(2 * second), "a", int64(2)},
+					{execute.Time(4 * second), "a", int64(3)},
+					{execute.Time(6 * second), "a", int64(4)},
+				},
+			}},
+			want: []*executetest.Table{{
+				KeyCols: []string{"t0"},
+				ColMeta: outCols,
+				Data: [][]interface{}{
+					{"a", 0.0, 3.0, 0.0},
+					{"a", 0.125, math.Sqrt(10), math.Pi - math.Atan(1.0/3)},
+					{"a", 0.25, 0.0, math.Pi},
+				},
+			}},
+		},
+		{
+			name: "unsorted",
+			spec: &universe.FFTProcedureSpec{Window: "none"},
+			data: []flux.Table{&executetest.Table{
+				KeyCols: []string{"t0"},
+				ColMeta: inCols,
+				Data: [][]interface{}{
+					{execute.Time(second), "a", int64(1)},
+					{execute.Time(0), "a", int64(2)},
+				},
+			}},
+			wantErr: errors.New(codes.FailedPrecondition, "fft requires rows sorted by increasing time"),
+		},
+		{
+			name: "null values",
+			spec: &universe.FFTProcedureSpec{Window: "none"},
+			data: []flux.Table{&executetest.Table{
+				KeyCols: []string{"t0"},
+				ColMeta: inCols,
+				Data: [][]interface{}{
+					{execute.Time(0), "a", int64(1)},
+					{execute.Time(second), "a", nil},
+				},
+			}},
+			wantErr: errors.New(codes.FailedPrecondition, "fft requires a value in every row; fill null values before fft()"),
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			spec := *tc.spec
+			spec.Column = execute.DefaultValueColLabel
+			spec.TimeColumn = execute.DefaultTimeColLabel
+			executetest.ProcessTestHelper2(
+				t,
+				tc.data,
+				tc.want,
+				tc.wantErr,
+				func(id execute.DatasetID, alloc memory.Allocator) (execute.Transformation, execute.Dataset) {
+					tr, d, err := universe.NewFFTTransformation(id, &spec, alloc)
+					if err != nil {
+						t.Fatal(err)
+					}
+					return tr, d
+				},
+				floatOptions,
+			)
+		})
+	}
+}
@@ -15,37 +15,38 @@ import (
 	arrowmem "github.com/apache/arrow/go/v7/arrow/memory"
 )
 
-func (t *fillTransformation) fillColumn(typ flux.ColType, arr array.Array, fillValue *interface{}, mem arrowmem.Allocator) array.Array {
+func (t *fillTransformation) fillColumn(typ flux.ColType, arr array.Array, times *array.Int, state *fillState, mem arrowmem.Allocator) array.Array {
 	switch typ {
 	case flux.TInt:
-		return t.fillIntColumn(arr.(*array.Int), fillValue, mem)
+		return t.fillIntColumn(arr.(*array.Int), times, state, mem)
 	case flux.TUInt:
-		return t.fillUintColumn(arr.(*array.Uint), fillValue, mem)
+		return t.fillUintColumn(arr.(*array.Uint), times, state, mem)
 	case flux.TFloat:
-		return t.fillFloatColumn(arr.(*array.Float), fillValue, mem)
+		return t.fillFloatColumn(arr.(*array.Float), times, state, mem)
 	case flux.TBool:
-		return t.fillBooleanColumn(arr.(*array.Boolean), fillValue, mem)
+		return t.fillBooleanColumn(arr.(*array.Boolean), times, state, mem)
 	case flux.TString:
-		return t.fillStringColumn(arr.(*array.String), fillValue, mem)
+		return t.fillStringColumn(arr.(*array.String), times, state, mem)
 	case flux.TTime:
-		return t.fillTimeColumn(arr.(*array.Int), fillValue, mem)
+		return t.fillTimeColumn(arr.(*array.Int), times, state, mem)
 
 	default:
 		panic(fmt.Errorf("unsupported array data type: %s", arr.DataType()))
 	}
 }
 
-func (t *fillTransformation) fillIntColumn(arr *array.Int, fillValue *interface{}, mem arrowmem.Allocator) array.Array {
-	fillValueNull := *fillValue == nil
+func (t *fillTransformation) fillIntColumn(arr *array.Int, times *array.Int, state *fillState, mem arrowmem.Allocator) array.Array {
+	fillValueNull := state.fillValue == nil
 	var fillValueInt int64
 	if !fillValueNull {
-		fillValueInt = (*fillValue).(int64)
+		fillValueInt = state.fillValue.(int64)
 	}
+	fillTime := state.fillTime
 	b := array.NewIntBuilder(mem)
 	b.Resize(arr.Len())
 	for i := 0; i < arr.Len(); i++ {
 		if arr.IsNull(i) {
-			if fillValueNull {
+			if fillValueNull || t.isStale(times, i, fillTime) {
 				b.AppendNull()
 			} else {
 				b.Append(fillValueInt)
@@ -56,26 +57,36 @@ func (t *fillTransformation) fillIntColumn(arr *array.Int, fillValue *interface{
 			if t.spec.UsePrevious {
 				fillValueInt = v
 				fillValueNull = false
+				if times != nil {
+					// A value without a time cannot be aged, so it is not used to fill later rows.
+					fillValueNull, fillTime = times.IsNull(i), times.Value(i)
+				}
 			}
 		}
 	}
-	if t.spec.UsePrevious && !fillValueNull {
-		*fillValue = fillValueInt
+	if t.spec.UsePrevious {
+		if fillValueNull {
+			state.fillValue = nil
+		} else {
+			state.fillValue = fillValueInt
+			state.fillTime = fillTime
+		}
 	}
 	return b.NewArray()
 }
 
-func (t *fillTransformation) fillUintColumn(arr *array.Uint, fillValue *interface{}, mem arrowmem.Allocator) array.Array {
-	fillValueNull := *fillValue == nil
+func (t *fillTransformation) fillUintColumn(arr *array.Uint, times *array.Int, state *fillState, mem arrowmem.Allocator) array.Array {
+	fillValueNull := state.fillValue == nil
 	var fillValueUint uint64
 	if !fillValueNull {
-		fillValueUint = (*fillValue).(uint64)
+		fillValueUint = state.fillValue.(uint64)
 	}
+	fillTime := state.fillTime
 	b := array.NewUintBuilder(mem)
 	b.Resize(arr.Len())
 	for i := 0; i < arr.Len(); i++ {
 		if arr.IsNull(i) {
-			if fillValueNull {
+			if fillValueNull || t.isStale(times, i, fillTime) {
 				b.AppendNull()
 			} else {
 				b.Append(fillValueUint)
@@ -86,26 +97,36 @@ func (t *fillTransformation) fillUintColumn(arr *array.Uint, fillValue *interfac
 			if t.spec.UsePrevious {
 				fillValueUint = v
 				fillValueNull = false
+				if times != nil {
+					// A value without a time cannot be aged, so it is not used to fill later rows.
+					fillValueNull, fillTime = times.IsNull(i), times.Value(i)
+				}
 			}
 		}
 	}
-	if t.spec.UsePrevious && !fillValueNull {
-		*fillValue = fillValueUint
+	if t.spec.UsePrevious {
+		if fillValueNull {
+			state.fillValue = nil
+		} else {
+			state.fillValue = fillValueUint
+			state.fillTime = fillTime
+		}
 	}
 	return b.NewArray()
 }
 
-func (t *fillTransformation) fillFloatColumn(arr *array.Float, fillValue *interface{}, mem arrowmem.Allocator) array.Array {
-	fillValueNull := *fillValue == nil
+func (t *fillTransformation) fillFloatColumn(arr *array.Float, times *array.Int, state *fillState, mem arrowmem.Allocator) array.Array {
+	fillValueNull := state.fillValue == nil
 	var fillValueFloat float64
 	if !fillValueNull {
-		fillValueFloat = (*fillValue).(float64)
+		fillValueFloat = state.fillValue.(float64)
 	}
+	fillTime := state.fillTime
 	b := array.NewFloatBuilder(mem)
 	b.Resize(arr.Len())
 	for i := 0; i < arr.Len(); i++ {
 		if arr.IsNull(i) {
-			if fillValueNull {
+			if fillValueNull || t.isStale(times, i, fillTime) {
 				b.AppendNull()
 			} else {
 				b.Append(fillValueFloat)
@@ -116,26 +137,36 @@ func (t *fillTransformation) fillFloatColumn(arr *array.Float, fillValue *interf
 			if t.spec.UsePrevious {
 				fillValueFloat = v
 				fillValueNull = false
+				if times != nil {
+					// A value without a time cannot be aged, so it is not used to fill later rows.
+					fillValueNull, fillTime = times.IsNull(i), times.Value(i)
+				}
 			}
 		}
 	}
-	if t.spec.UsePrevious && !fillValueNull {
-		*fillValue = fillValueFloat
+	if t.spec.UsePrevious {
+		if fillValueNull {
+			state.fillValue = nil
+		} else {
+			state.fillValue = fillValueFloat
+			state.fillTime = fillTime
+		}
 	}
 	return b.NewArray()
 }
 
-func (t *fillTransformation) fillBooleanColumn(arr *array.Boolean, fillValue *interface{}, mem arrowmem.Allocator) array.Array {
-	fillValueNull := *fillValue == nil
+func (t *fillTransformation) fillBooleanColumn(arr *array.Boolean, times *array.Int, state *fillState, mem arrowmem.Allocator) array.Array {
+	fillValueNull := state.fillValue == nil
 	var fillValueBoolean bool
 	if !fillValueNull {
-		fillValueBoolean = (*fillValue).(bool)
+		fillValueBoolean = state.fillValue.(bool)
 	}
+	fillTime := state.fillTime
 	b := array.NewBooleanBuilder(mem)
 	b.Resize(arr.Len())
 	for i := 0; i < arr.Len(); i++ {
 		if arr.IsNull(i) {
-			if fillValueNull {
+			if fillValueNull || t.isStale(times, i, fillTime) {
 				b.AppendNull()
 			} else {
 				b.Append(fillValueBoolean)
@@ -146,26 +177,36 @@ func (t *fillTransformation) fillBooleanColumn(arr *array.Boolean, fillValue *in
 			if t.spec.UsePrevious {
 				fillValueBoolean = v
 				fillValueNull = false
+				if times != nil {
+					// A value without a time cannot be aged, so it is not used to fill later rows.
+					fillValueNull, fillTime = times.IsNull(i), times.Value(i)
+				}
 			}
 		}
 	}
-	if t.spec.UsePrevious && !fillValueNull {
-		*fillValue = fillValueBoolean
+	if t.spec.UsePrevious {
+		if fillValueNull {
+			state.fillValue = nil
+		} else {
+			state.fillValue = fillValueBoolean
+			state.fillTime = fillTime
+		}
 	}
 	return b.NewArray()
 }
 
-func (t *fillTransformation) fillStringColumn(arr *array.String, fillValue *interface{}, mem arrowmem.Allocator) array.Array {
-	fillValueNull := *fillValue == nil
+func (t *fillTransformation) fillStringColumn(arr *array.String, times *array.Int, state *fillState, mem arrowmem.Allocator) array.Array {
+	fillValueNull := state.fillValue == nil
 	var fillValueString string
 	if !fillValueNull {
-		fillValueString = (*fillValue).(string)
+		fillValueString = state.fillValue.(string)
 	}
+	fillTime := state.fillTime
 	b := array.NewStringBuilder(mem)
 	b.Resize(arr.Len())
 	for i := 0; i < arr.Len(); i++ {
 		if arr.IsNull(i) {
-			if fillValueNull {
+			if fillValueNull || t.isStale(times, i, fillTime) {
 				b.AppendNull()
 			} else {
 				b.Append(fillValueString)
@@ -176,26 +217,36 @@ func (t *fillTransformation) fillStringColumn(arr *array.String, fillValue *inte
 			if t.spec.UsePrevious {
 				fillValueString = v
 				fillValueNull = false
+				if times != nil {
+					// A value without a time cannot be aged, so it is not used to fill later rows.
+					fillValueNull, fillTime = times.IsNull(i), times.Value(i)
+				}
 			}
 		}
 	}
-	if t.spec.UsePrevious && !fillValueNull {
-		*fillValue = fillValueString
+	if t.spec.UsePrevious {
+		if fillValueNull {
+			state.fillValue = nil
+		} else {
+			state.fillValue = fillValueString
+			state.fillTime = fillTime
+		}
 	}
 	return b.NewArray()
 }
 
-func (t *fillTransformation) fillTimeColumn(arr *array.Int, fillValue *interface{}, mem arrowmem.Allocator) array.Array {
-	fillValueNull := *fillValue == nil
+func (t *fillTransformation) fillTimeColumn(arr *array.Int, times *array.Int, state *fillState, mem arrowmem.Allocator) array.Array {
+	fillValueNull := state.fillValue == nil
 	var fillValueTime int64
 	if !fillValueNull {
-		fillValueTime = int64((*fillValue).(values.Time))
+		fillValueTime = int64(state.fillValue.(values.Time))
 	}
+	fillTime := state.fillTime
 	b := array.NewIntBuilder(mem)
 	b.Resize(arr.Len())
 	for i := 0; i < arr.Len(); i++ {
 		if arr.IsNull(i) {
-			if fillValueNull {
+			if fillValueNull || t.isStale(times, i, fillTime) {
 				b.AppendNull()
 			} else {
 				b.Append(fillValueTime)
@@ -206,11 +257,20 @@ func (t *fillTransformation) fillTimeColumn(arr *array.Int, fillValue *interface
 			if t.spec.UsePrevious {
 				fillValueTime = v
 				fillValueNull = false
+				if times != nil {
+					// A value without a time cannot be aged, so it is not used to fill later rows.
+					fillValueNull, fillTime = times.IsNull(i), times.Value(i)
+				}
 			}
 		}
 	}
-	if t.spec.UsePrevious && !fillValueNull {
-		*fillValue = values.Time(fillValueTime)
+	if t.spec.UsePrevious {
+		if fillValueNull {
+			state.fillValue = nil
+		} else {
+			state.fillValue = values.Time(fillValueTime)
+			state.fillTime = fillTime
+		}
 	}
 	return b.NewArray()
 }
@@ -26,10 +26,11 @@ import (
 const FillKind = "fill"
 
 type FillOpSpec struct {
-	Column      string `json:"column"`
-	Type        string `json:"type"`
-	Value       string `json:"value"`
-	UsePrevious bool   `json:"use_previous"`
+	Column      string        `json:"column"`
+	Type        string        `json:"type"`
+	Value       string        `json:"value"`
+	UsePrevious bool          `json:"use_previous"`
+	MaxAge      flux.Duration `json:"max_age"`
 }
 
 func init() {
@@ -90,6 +91,17 @@ func CreateFillOpSpec(args flux.Arguments, a *flux.Administration) (flux.Operati
 		spec.UsePrevious = usePrevious
 	}
 
+	if maxAge, ok, err := args.GetDuration("maxAge"); err != nil {
+		return nil, err
+	} else if ok {
+		if !spec.UsePrevious {
+			return nil, errors.New(codes.Invalid, "fill maxAge requires usePrevious")
+		} else if !maxAge.IsPositive() || maxAge.Months() != 0 {
+			return nil, errors.Newf(codes.Invalid, "fill maxAge must be a positive duration without months or years, got %v", maxAge)
+		}
+		spec.MaxAge = maxAge
+	}
+
 	return spec, nil
 }
 
@@ -102,6 +114,9 @@ type FillProcedureSpec struct {
 	Column      string
 	Value       values.Value
 	UsePrevious bool
+	// MaxAge is how long after it was observed a previous value
+	// may be used to fill a row. Zero means there is no limit.
+	MaxAge flux.Duration
 }
 
 func newFillProcedure(qs flux.OperationSpec, pa plan.Administration) (plan.ProcedureSpec, error) {
@@ -113,6 +128,7 @@ func newFillProcedure(qs flux.OperationSpec, pa plan.Administration) (plan.Proce
 	pspec := &FillProcedureSpec{
 		Column:      spec.Column,
 		UsePrevious: spec.UsePrevious,
+		MaxAge:      spec.MaxAge,
 	}
 	if !spec.UsePrevious {
 		switch spec.Type {
@@ -226,12 +242,12 @@ func (t *fillTransformation) Process(id execute.DatasetID, tbl flux.Table) error
 		}
 	}
 
-	var fillValue interface{}
+	state := new(fillState)
 	if !t.spec.UsePrevious {
 		if colIdx > -1 && tbl.Cols()[colIdx].Type != flux.ColumnType(t.spec.Value.Type()) {
 			return errors.Newf(codes.FailedPrecondition, "fill column type mismatch: %s/%s", tbl.Cols()[colIdx].Type.String(), flux.ColumnType(t.spec.Value.Type()).String())
 		}
-		fillValue = values.Unwrap(t.spec.Value)
+		state.fillValue = values.Unwrap(t.spec.Value)
 	}
 
 	// In case of missing fill column, add it to the existing columns
@@ -249,7 +265,7 @@ func (t *fillTransformation) Process(id execute.DatasetID, tbl flux.Table) error
 
 	table, err := table.StreamWithContext(t.ctx, key, tableCols, func(ctx context.Context, w *table.StreamWriter) error {
 		return tbl.Do(func(cr flux.ColReader) error {
-			return t.fillTable(w, cr, colIdx, &fillValue)
+			return t.fillTable(w, cr, colIdx, state)
 		})
 	})
 	if err != nil {
@@ -285,6 +301,8 @@ func NewNarrowFillTransformation(ctx context.Context, spec *FillProcedureSpec, i
 
 type fillState struct {
 	fillValue interface{}
+	// fillTime is the time of the previous value when maxAge is set.
+	fillTime int64
 }
 
 func (t *fillTransformationAdapter) Process(chunk table.Chunk, state *fillState, d *execute.TransportDataset, mem arrowmem.Allocator) (*fillState, bool, error) {
@@ -362,7 +380,7 @@ func (t *fillTransformation) adaptedProcess(chunk table.Chunk, state *fillState,
 		Values:   make([]array.Array, len(chunk.Cols())),
 	}
 
-	if err := t.fillChunk(&buffer, chunk, colIdx, &state.fillValue, mem); err != nil {
+	if err := t.fillChunk(&buffer, chunk, colIdx, state, mem); err != nil {
 		return nil, false, err
 	}
 
@@ -375,10 +393,18 @@ func (t *fillTransformation) adaptedProcess(chunk table.Chunk, state *fillState,
 
 func (t *fillTransformationAdapter) Close() error { return nil }
 
-func (t *fillTransformation) fillChunk(buffer *arrow.TableBuffer, chunk table.Chunk, colIdx int, fillValue *interface{}, mem arrowmem.Allocator) error {
+func (t *fillTransformation) fillChunk(buffer *arrow.TableBuffer, chunk table.Chunk, colIdx int, state *fillState, mem arrowmem.Allocator) error {
 	l := chunk.Len()
 	vs := make([]array.Array, len(buffer.Cols()))
 
+	var times *array.Int
+	if l > 0 {
+		var err error
+		if times, err = t.fillTimes(chunk.Cols(), chunk.Values); err != nil {
+			return err
+		}
+	}
+
 	// Iterate over the existing columns and if column already exist(colIdx matches with i) call fillColumn on it
 	for i, col := range chunk.Cols() {
 		if l == 0 {
@@ -390,7 +416,7 @@ func (t *fillTransformation) fillChunk(buffer *arrow.TableBuffer, chunk table.Ch
 				vs[i].Retain()
 				continue
 			}
-			vs[i] = t.fillColumn(col.Type, arr, fillValue, mem)
+			vs[i] = t.fillColumn(col.Type, arr, times, state, mem)
 		}
 	}
 
@@ -399,17 +425,23 @@ func (t *fillTransformation) fillChunk(buffer *arrow.TableBuffer, chunk table.Ch
 		colType := flux.ColumnType(t.spec.Value.Type())
 		arr := t.addNullColumn(colType, chunk.Len(), mem)
 		defer arr.Release()
-		vs[colIdx] = t.fillColumn(colType, arr, fillValue, mem)
+		vs[colIdx] = t.fillColumn(colType, arr, times, state, mem)
 	}
 	buffer.Values = vs
 	return nil
 }
 
-func (t *fillTransformation) fillTable(w *table.StreamWriter, cr flux.ColReader, colIdx int, fillValue *interface{}) error {
+func (t *fillTransformation) fillTable(w *table.StreamWriter, cr flux.ColReader, colIdx int, state *fillState) error {
 	crLen := cr.Len()
 	if crLen == 0 {
 		return nil
 	}
+	times, err := t.fillTimes(cr.Cols(), func(j int) array.Array {
+		return table.Values(cr, j)
+	})
+	if err != nil {
+		return err
+	}
 	vs := make([]array.Array, len(w.Cols()))
 
 	// Iterate over the existing columns and if column already exist(colIdx matches with i) call fillColumn on it
@@ -420,7 +452,7 @@ func (t *fillTransformation) fillTable(w *table.StreamWriter, cr flux.ColReader,
 			vs[i].Retain()
 			continue
 		}
-		vs[i] = t.fillColumn(col.Type, arr, fillValue, t.alloc)
+		vs[i] = t.fillColumn(col.Type, arr, times, state, t.alloc)
 	}
 
 	// If the fill column is new, create a completely null column and call fillColumn on it
@@ -428,7 +460,7 @@ func (t *fillTransformation) fillTable(w *table.StreamWriter, cr flux.ColReader,
 		colType := flux.ColumnType(t.spec.Value.Type())
 		arr := t.addNullColumn(colType, crLen, t.alloc)
 		defer arr.Release()
-		vs[colIdx] = t.fillColumn(colType, arr, fillValue, t.alloc)
+		vs[colIdx] = t.fillColumn(colType, arr, times, state, t.alloc)
 	}
 	return w.Write(vs)
 }
@@ -441,3 +473,24 @@ func (t *fillTransformation) addNullColumn(typ flux.ColType, l int, mem arrowmem
 	}
 	return builder.NewArray()
 }
+
+// fillTimes returns the times of the rows that are used to age
+// previous values when maxAge is set, or nil if it is not.
+func (t *fillTransformation) fillTimes(cols []flux.ColMeta, values func(j int) array.Array) (*array.Int, error) {
+	if t.spec.MaxAge.IsZero() {
+		return nil, nil
+	}
+	j := execute.ColIdx(execute.DefaultTimeColLabel, cols)
+	if j < 0 {
+		return nil, errors.Newf(codes.FailedPrecondition, "fill maxAge requires a %q column", execute.DefaultTimeColLabel)
+	} else if cols[j].Type != flux.TTime {
+		return nil, errors.Newf(codes.FailedPrecondition, "fill maxAge requires %q to be a time column", execute.DefaultTimeColLabel)
+	}
+	return values(j).(*array.Int), nil
+}
+
+// isStale reports whether a previous value observed at time prev
+// is too old to fill row i.
+func (t *fillTransformation) isStale(times *array.Int, i int, prev int64) bool {
+	return times != nil && (times.IsNull(i) || times.Value(i)-prev > t.spec.MaxAge.Nanoseconds())
+}
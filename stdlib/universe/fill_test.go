@@ -69,6 +69,41 @@ func TestFill_NewQuery(t *testing.T) {
 				},
 			},
 		},
+		{
+			Name: "fill previous with max age",
+			Raw:  `from(bucket:"mydb") |> fill(usePrevious: true, maxAge: 5m)`,
+			Want: &operation.Spec{
+				Operations: []*operation.Node{
+					{
+						ID: "from0",
+						Spec: &influxdb.FromOpSpec{
+							Bucket: influxdb.NameOrID{Name: "mydb"},
+						},
+					},
+					{
+						ID: "fill1",
+						Spec: &universe.FillOpSpec{
+							Column:      "_value",
+							UsePrevious: true,
+							MaxAge:      flux.ConvertDuration(5 * time.Minute),
+						},
+					},
+				},
+				Edges: []operation.Edge{
+					{Parent: "from0", Child: "fill1"},
+				},
+			},
+		},
+		{
+			Name:    "max age without use previous",
+			Raw:     `from(bucket:"mydb") |> fill(value: 0.0, maxAge: 5m)`,
+			WantErr: true,
+		},
+		{
+			Name:    "negative max age",
+			Raw:     `from(bucket:"mydb") |> fill(usePrevious: true, maxAge: -5m)`,
+			WantErr: true,
+		},
 	}
 	for _, tc := range tests {
 		tc := tc
@@ -603,6 +638,53 @@ func TestFill_Process(t *testing.T) {
 				},
 			}},
 		},
+		{
+			name: "fill previous max age",
+			spec: &universe.FillProcedureSpec{
+				DefaultCost: plan.DefaultCost{},
+				Column:      "_value",
+				UsePrevious: true,
+				MaxAge:      flux.ConvertDuration(2),
+			},
+			data: func() []flux.Table {
+				return []flux.Table{&executetest.RowWiseTable{
+					Table: &executetest.Table{
+						ColMeta: []flux.ColMeta{
+							{Label: "_time", Type: flux.TTime},
+							{Label: "_value", Type: flux.TFloat},
+						},
+						Data: [][]interface{}{
+							{execute.Time(1), 1.0},
+							{execute.Time(2), nil},
+							{execute.Time(3), nil},
+							{execute.Time(4), nil},
+							{nil, 2.0},
+							{execute.Time(5), nil},
+							{execute.Time(6), 3.0},
+							{nil, nil},
+							{execute.Time(8), nil},
+						},
+					},
+				}}
+			},
+			want: []*executetest.Table{{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TFloat},
+				},
+				Data: [][]interface{}{
+					{execute.Time(1), 1.0},
+					{execute.Time(2), 1.0},
+					{execute.Time(3), 1.0},
+					{execute.Time(4), nil},
+					{nil, 2.0},
+					{execute.Time(5), nil},
+					{execute.Time(6), 3.0},
+					{nil, nil},
+					{execute.Time(8), 3.0},
+				},
+			}},
+		},
 		{
 			name: "fill previous multiple buffers",
 			spec: &universe.FillProcedureSpec{
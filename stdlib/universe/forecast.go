@@ -0,0 +1,442 @@
+package universe
+
+import (
+	"math"
+	"time"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/plan"
+	"github.com/InfluxCommunity/flux/runtime"
+	"github.com/InfluxCommunity/flux/stdlib/universe/forecast"
+	"github.com/InfluxCommunity/flux/values"
+	"gonum.org/v1/gonum/stat/distuv"
+)
+
+const ForecastKind = "forecast"
+
+const (
+	ForecastMethodSES  = "ses"
+	ForecastMethodHolt = "holt"
+	ForecastMethodSTL  = "stl"
+
+	defaultForecastLevel = 0.95
+
+	forecastLowerColLabel = "lower"
+	forecastUpperColLabel = "upper"
+)
+
+type ForecastOpSpec struct {
+	N          int64         `json:"n"`
+	Method     string        `json:"method"`
+	Period     int64         `json:"period"`
+	Interval   flux.Duration `json:"interval"`
+	Level      float64       `json:"level"`
+	Column     string        `json:"column"`
+	TimeColumn string        `json:"timeColumn"`
+}
+
+func init() {
+	forecastSignature := runtime.MustLookupBuiltinType("universe", "forecast")
+	runtime.RegisterPackageValue("universe", ForecastKind, flux.MustValue(flux.FunctionValue(ForecastKind, createForecastOpSpec, forecastSignature)))
+	plan.RegisterProcedureSpec(ForecastKind, newForecastProcedure, ForecastKind)
+	execute.RegisterTransformation(ForecastKind, createForecastTransformation)
+}
+
+func createForecastOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
+	if err := a.AddParentFromArgs(args); err != nil {
+		return nil, err
+	}
+	spec := &ForecastOpSpec{
+		Method:     ForecastMethodHolt,
+		Level:      defaultForecastLevel,
+		Column:     execute.DefaultValueColLabel,
+		TimeColumn: execute.DefaultTimeColLabel,
+	}
+	n, err := args.GetRequiredInt("n")
+	if err != nil {
+		return nil, err
+	}
+	if n <= 0 {
+		return nil, errors.Newf(codes.Invalid, "n must be positive, got %d", n)
+	}
+	spec.N = n
+
+	if m, ok, err := args.GetString("method"); err != nil {
+		return nil, err
+	} else if ok {
+		spec.Method = m
+	}
+	if p, ok, err := args.GetInt("period"); err != nil {
+		return nil, err
+	} else if ok {
+		spec.Period = p
+	}
+	switch spec.Method {
+	case ForecastMethodSES, ForecastMethodHolt:
+		if spec.Period != 0 {
+			return nil, errors.Newf(codes.Invalid, "period is only valid for method %s", ForecastMethodSTL)
+		}
+	case ForecastMethodSTL:
+		if spec.Period < 2 {
+			return nil, errors.Newf(codes.Invalid, "method %s requires a period of at least 2, got %d", ForecastMethodSTL, spec.Period)
+		}
+	default:
+		return nil, errors.Newf(codes.Invalid, "unknown method %s", spec.Method)
+	}
+
+	if i, ok, err := args.GetDuration("interval"); err != nil {
+		return nil, err
+	} else if ok {
+		if !i.IsPositive() {
+			return nil, errors.Newf(codes.Invalid, "interval must be positive, got %v", i)
+		}
+		spec.Interval = i
+	}
+	if l, ok, err := args.GetFloat("level"); err != nil {
+		return nil, err
+	} else if ok {
+		if l <= 0 || l >= 1 {
+			return nil, errors.Newf(codes.Invalid, "level must be between 0 and 1, got %v", l)
+		}
+		spec.Level = l
+	}
+	if col, ok, err := args.GetString("column"); err != nil {
+		return nil, err
+	} else if ok {
+		spec.Column = col
+	}
+	if col, ok, err := args.GetString("timeColumn"); err != nil {
+		return nil, err
+	} else if ok {
+		spec.TimeColumn = col
+	}
+	return spec, nil
+}
+
+func (s *ForecastOpSpec) Kind() flux.OperationKind {
+	return ForecastKind
+}
+
+type ForecastProcedureSpec struct {
+	plan.DefaultCost
+	N          int64
+	Method     string
+	Period     int64
+	Interval   flux.Duration
+	Level      float64
+	Column     string
+	TimeColumn string
+}
+
+func newForecastProcedure(qs flux.OperationSpec, pa plan.Administration) (plan.ProcedureSpec, error) {
+	spec, ok := qs.(*ForecastOpSpec)
+	if !ok {
+		return nil, errors.Newf(codes.Internal, "invalid spec type %T", qs)
+	}
+	return &ForecastProcedureSpec{
+		N:          spec.N,
+		Method:     spec.Method,
+		Period:     spec.Period,
+		Interval:   spec.Interval,
+		Level:      spec.Level,
+		Column:     spec.Column,
+		TimeColumn: spec.TimeColumn,
+	}, nil
+}
+
+func (s *ForecastProcedureSpec) Kind() plan.ProcedureKind {
+	return ForecastKind
+}
+
+func (s *ForecastProcedureSpec) Copy() plan.ProcedureSpec {
+	ns := new(ForecastProcedureSpec)
+	*ns = *s
+	return ns
+}
+
+// TriggerSpec implements plan.TriggerAwareProcedureSpec
+func (s *ForecastProcedureSpec) TriggerSpec() plan.TriggerSpec {
+	return plan.NarrowTransformationTriggerSpec{}
+}
+
+func createForecastTransformation(id execute.DatasetID, mode execute.AccumulationMode, spec plan.ProcedureSpec, a execute.Administration) (execute.Transformation, execute.Dataset, error) {
+	s, ok := spec.(*ForecastProcedureSpec)
+	if !ok {
+		return nil, nil, errors.Newf(codes.Internal, "invalid spec type %T", spec)
+	}
+	cache := execute.NewTableBuilderCache(a.Allocator())
+	d := execute.NewDataset(id, mode, cache)
+	t := NewForecastTransformation(d, cache, s)
+	return t, d, nil
+}
+
+type forecastTransformation struct {
+	execute.ExecutionNode
+	d     execute.Dataset
+	cache execute.TableBuilderCache
+	spec  ForecastProcedureSpec
+}
+
+func NewForecastTransformation(d execute.Dataset, cache execute.TableBuilderCache, spec *ForecastProcedureSpec) *forecastTransformation {
+	return &forecastTransformation{
+		d:     d,
+		cache: cache,
+		spec:  *spec,
+	}
+}
+
+func (t *forecastTransformation) Process(id execute.DatasetID, tbl flux.Table) error {
+	builder, created := t.cache.TableBuilder(tbl.Key())
+	if !created {
+		return errors.Newf(codes.FailedPrecondition, "forecast found duplicate table with key: %v", tbl.Key())
+	}
+	cols := tbl.Cols()
+	colIdx, err := seriesColumn("forecast", tbl, t.spec.Column)
+	if err != nil {
+		return err
+	}
+	timeIdx := execute.ColIdx(t.spec.TimeColumn, cols)
+	if timeIdx < 0 {
+		return errors.Newf(codes.FailedPrecondition, "cannot find time column %s", t.spec.TimeColumn)
+	}
+	if cols[timeIdx].Type != flux.TTime {
+		return errors.Newf(codes.FailedPrecondition, "time column %s must be of type time, got %s", t.spec.TimeColumn, cols[timeIdx].Type)
+	}
+	if tbl.Key().HasCol(t.spec.TimeColumn) {
+		return errors.Newf(codes.FailedPrecondition, "cannot forecast when %s is part of the group key", t.spec.TimeColumn)
+	}
+
+	for j, col := range cols {
+		if j == colIdx {
+			col.Type = flux.TFloat
+		}
+		if _, err := builder.AddCol(col); err != nil {
+			return err
+		}
+	}
+	lowerIdx, err := builder.AddCol(flux.ColMeta{Label: forecastLowerColLabel, Type: flux.TFloat})
+	if err != nil {
+		return err
+	}
+	upperIdx, err := builder.AddCol(flux.ColMeta{Label: forecastUpperColLabel, Type: flux.TFloat})
+	if err != nil {
+		return err
+	}
+
+	series, err := readSeries(tbl, builder, colIdx, false, timeIdx)
+	if err != nil {
+		return err
+	}
+	y := series.values
+	if err := appendSeries(builder, colIdx, y); err != nil {
+		return err
+	}
+	for i := 0; i < len(y); i++ {
+		if err := builder.AppendNil(lowerIdx); err != nil {
+			return err
+		}
+		if err := builder.AppendNil(upperIdx); err != nil {
+			return err
+		}
+	}
+
+	mean, variance, ok, err := t.forecast(y)
+	if err != nil || !ok {
+		return err
+	}
+
+	n := len(series.times)
+	if n == 0 {
+		// There is no time to forecast from.
+		return nil
+	}
+	interval := values.Duration(t.spec.Interval)
+	if interval.IsZero() {
+		if n < 2 {
+			return errors.New(codes.FailedPrecondition, "forecast cannot infer the interval from less than two times; specify an interval")
+		}
+		interval = values.ConvertDurationNsecs(time.Duration(series.times[n-1] - series.times[n-2]))
+		if !interval.IsPositive() {
+			return errors.Newf(codes.FailedPrecondition, "forecast cannot infer the interval from the last two times %v and %v; sort the rows by time or specify an interval", series.times[n-2], series.times[n-1])
+		}
+	}
+
+	z := distuv.UnitNormal.Quantile((1 + t.spec.Level) / 2)
+	next := series.times[n-1]
+	for i := range mean {
+		next = next.Add(interval)
+		for j, col := range builder.Cols() {
+			var err error
+			switch {
+			case j == timeIdx:
+				err = builder.AppendTime(j, next)
+			case j == colIdx:
+				err = builder.AppendFloat(j, mean[i])
+			case j == lowerIdx:
+				err = builder.AppendFloat(j, mean[i]-z*math.Sqrt(variance[i]))
+			case j == upperIdx:
+				err = builder.AppendFloat(j, mean[i]+z*math.Sqrt(variance[i]))
+			case tbl.Key().HasCol(col.Label):
+				err = builder.AppendValue(j, tbl.Key().LabelValue(col.Label))
+			default:
+				err = builder.AppendNil(j)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// forecast returns the forecasts and the variance of their errors.
+// It reports false if the series has too few values for the method.
+func (t *forecastTransformation) forecast(y []float64) (mean, variance []float64, ok bool, err error) {
+	h := int(t.spec.N)
+	switch t.spec.Method {
+	case ForecastMethodSES, ForecastMethodHolt:
+		fit, ok := forecast.FitSmoothing(y, math.NaN(), math.NaN(), t.spec.Method == ForecastMethodHolt)
+		if !ok {
+			return nil, nil, false, nil
+		}
+		mean, variance = fit.Forecast(h)
+		return mean, variance, true, nil
+	default:
+		// Forecast the seasonally adjusted series
+		// and add the last season back.
+		period := int(t.spec.Period)
+		if len(y) < 2*period {
+			return nil, nil, false, nil
+		}
+		for _, v := range y {
+			if math.IsNaN(v) {
+				return nil, nil, false, errors.Newf(codes.FailedPrecondition, "forecast with method %s requires a value in every row; fill null values before forecast()", ForecastMethodSTL)
+			}
+		}
+		d := forecast.STL(y, period, forecast.DefaultSeasonalSpan, false)
+		adjusted := make([]float64, len(y))
+		for i := range y {
+			adjusted[i] = y[i] - d.Seasonal[i]
+		}
+		fit, ok := forecast.FitSmoothing(adjusted, math.NaN(), math.NaN(), true)
+		if !ok {
+			return nil, nil, false, nil
+		}
+		mean, variance = fit.Forecast(h)
+		for i := range mean {
+			mean[i] += d.Seasonal[len(y)-period+i%period]
+		}
+		return mean, variance, true, nil
+	}
+}
+
+func (t *forecastTransformation) RetractTable(id execute.DatasetID, key flux.GroupKey) error {
+	return t.d.RetractTable(key)
+}
+
+func (t *forecastTransformation) UpdateWatermark(id execute.DatasetID, mark execute.Time) error {
+	return t.d.UpdateWatermark(mark)
+}
+
+func (t *forecastTransformation) UpdateProcessingTime(id execute.DatasetID, pt execute.Time) error {
+	return t.d.UpdateProcessingTime(pt)
+}
+
+func (t *forecastTransformation) Finish(id execute.DatasetID, err error) {
+	t.d.Finish(err)
+}
+
+// seriesColumn returns the index of the numeric column
+// a forecasting function operates on.
+func seriesColumn(name string, tbl flux.Table, column string) (int, error) {
+	idx := execute.ColIdx(column, tbl.Cols())
+	if idx < 0 {
+		return -1, errors.Newf(codes.FailedPrecondition, "cannot find column %s", column)
+	}
+	switch typ := tbl.Cols()[idx].Type; typ {
+	case flux.TInt, flux.TUInt, flux.TFloat:
+	default:
+		return -1, errors.Newf(codes.FailedPrecondition, "%s can work only on numerical types, got %s", name, typ)
+	}
+	if tbl.Key().HasCol(column) {
+		return -1, errors.Newf(codes.FailedPrecondition, "cannot %s a column that is part of the group key", name)
+	}
+	return idx, nil
+}
+
+// series is a column of a table read as floats.
+type series struct {
+	// values holds the value of each row or NaN for null values.
+	values []float64
+	// times holds the non-null times of the time column.
+	times []values.Time
+}
+
+// readSeries appends the rows of the table to the builder and reads
+// the values of the column at colIdx and the times of the column at
+// timeIdx, if it is not negative. The column at colIdx is only
+// appended to the builder if copyColumn is true.
+func readSeries(tbl flux.Table, builder execute.TableBuilder, colIdx int, copyColumn bool, timeIdx int) (series, error) {
+	var s series
+	err := tbl.Do(func(cr flux.ColReader) error {
+		for j := range cr.Cols() {
+			if j == colIdx && !copyColumn {
+				continue
+			}
+			if err := execute.AppendCol(j, j, cr, builder); err != nil {
+				return err
+			}
+		}
+		for i := 0; i < cr.Len(); i++ {
+			v := math.NaN()
+			switch cr.Cols()[colIdx].Type {
+			case flux.TInt:
+				if vs := cr.Ints(colIdx); vs.IsValid(i) {
+					v = float64(vs.Value(i))
+				}
+			case flux.TUInt:
+				if vs := cr.UInts(colIdx); vs.IsValid(i) {
+					v = float64(vs.Value(i))
+				}
+			case flux.TFloat:
+				if vs := cr.Floats(colIdx); vs.IsValid(i) {
+					v = vs.Value(i)
+					if math.IsNaN(v) || math.IsInf(v, 0) {
+						return errors.New(codes.Invalid, "NaN/Inf in input")
+					}
+				}
+			}
+			s.values = append(s.values, v)
+		}
+		if timeIdx >= 0 {
+			ts := cr.Times(timeIdx)
+			for i := 0; i < ts.Len(); i++ {
+				if ts.IsValid(i) {
+					s.times = append(s.times, values.Time(ts.Value(i)))
+				}
+			}
+		}
+		return nil
+	})
+	return s, err
+}
+
+// appendSeries appends floats to a column of the builder
+// where NaN values are appended as null values.
+func appendSeries(builder execute.TableBuilder, j int, vs []float64) error {
+	for _, v := range vs {
+		var err error
+		if math.IsNaN(v) {
+			err = builder.AppendNil(j)
+		} else {
+			err = builder.AppendFloat(j, v)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package forecast_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/InfluxCommunity/flux/stdlib/universe/forecast"
+)
+
+var pattern = []float64{3, 1, -2, -2}
+
+// seasonalSeries returns a linear trend plus a repeating pattern.
+func seasonalSeries(n int) (y, trend, seasonal []float64) {
+	y = make([]float64, n)
+	trend = make([]float64, n)
+	seasonal = make([]float64, n)
+	for i := range y {
+		trend[i] = 10 + 0.5*float64(i)
+		seasonal[i] = pattern[i%len(pattern)]
+		y[i] = trend[i] + seasonal[i]
+	}
+	return y, trend, seasonal
+}
+
+func checkClose(t *testing.T, name string, got, want []float64, tolerance float64) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("unexpected %s length: got %d, want %d", name, len(got), len(want))
+	}
+	for i := range got {
+		if math.Abs(got[i]-want[i]) > tolerance {
+			t.Fatalf("unexpected %s at %d: got %v, want %v", name, i, got[i], want[i])
+		}
+	}
+}
+
+func TestSTL(t *testing.T) {
+	y, trend, seasonal := seasonalSeries(40)
+	d := forecast.STL(y, len(pattern), forecast.DefaultSeasonalSpan, false)
+	checkClose(t, "trend", d.Trend, trend, 1e-6)
+	checkClose(t, "seasonal", d.Seasonal, seasonal, 1e-6)
+	checkClose(t, "residual", d.Residual, make([]float64, len(y)), 1e-6)
+}
+
+func TestSTL_Robust(t *testing.T) {
+	y, trend, seasonal := seasonalSeries(40)
+	rnd := rand.New(rand.NewSource(1))
+	for i := range y {
+		y[i] += 0.2*rnd.Float64() - 0.1
+	}
+	y[21] += 50
+
+	d := forecast.STL(y, len(pattern), forecast.DefaultSeasonalSpan, true)
+	checkClose(t, "trend", d.Trend, trend, 0.3)
+	checkClose(t, "seasonal", d.Seasonal, seasonal, 0.3)
+	if r := d.Residual[21]; math.Abs(r-50) > 0.3 {
+		t.Fatalf("unexpected residual of outlier: got %v, want 50", r)
+	}
+
+	// Without robustness weights the outlier
+	// leaks into the other components.
+	d = forecast.STL(y, len(pattern), forecast.DefaultSeasonalSpan, false)
+	if r := d.Residual[21]; math.Abs(r-50) < 1 {
+		t.Fatalf("unexpected residual of outlier: got %v, want less than 49", r)
+	}
+}
+
+func TestSmoothing_Apply(t *testing.T) {
+	nan := math.NaN()
+	for _, tc := range []struct {
+		name  string
+		m     forecast.Smoothing
+		y     []float64
+		want  []float64
+		level float64
+		slope float64
+	}{
+		{
+			name:  "simple",
+			m:     forecast.Smoothing{Alpha: 0.5},
+			y:     []float64{nan, 2, 4, nan, 8},
+			want:  []float64{nan, 2, 3, nan, 5.5},
+			level: 5.5,
+		},
+		{
+			name:  "trend",
+			m:     forecast.Smoothing{Alpha: 0.5, Beta: 0.5, Trend: true},
+			y:     []float64{1, 2, 5, 4},
+			want:  []float64{1, 2, 4, 4.75},
+			level: 4.75,
+			slope: 1.125,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f, ok := tc.m.Apply(tc.y)
+			if !ok {
+				t.Fatal("expected a fit")
+			}
+			for i := range tc.want {
+				if got, want := f.Smoothed[i], tc.want[i]; got != want && !(math.IsNaN(got) && math.IsNaN(want)) {
+					t.Fatalf("unexpected smoothed value at %d: got %v, want %v", i, got, want)
+				}
+			}
+			if f.Level != tc.level || f.Slope != tc.slope {
+				t.Fatalf("unexpected state: got (%v, %v), want (%v, %v)", f.Level, f.Slope, tc.level, tc.slope)
+			}
+		})
+	}
+
+	if _, ok := (forecast.Smoothing{Trend: true}).Apply([]float64{1, nan, 2}); ok {
+		t.Fatal("expected too few points")
+	}
+}
+
+func TestFitSmoothing(t *testing.T) {
+	// A line is forecast exactly with a trend.
+	y := []float64{1, 3, 5, 7, 9, 11}
+	f, ok := forecast.FitSmoothing(y, math.NaN(), math.NaN(), true)
+	if !ok {
+		t.Fatal("expected a fit")
+	}
+	mean, variance := f.Forecast(3)
+	checkClose(t, "mean", mean, []float64{13, 15, 17}, 1e-9)
+	checkClose(t, "variance", variance, []float64{0, 0, 0}, 1e-9)
+
+	// A constant with noise is fit with a small alpha.
+	y = []float64{10, 12, 9, 11, 10, 8, 12, 10, 11, 9, 10, 10}
+	f, ok = forecast.FitSmoothing(y, math.NaN(), math.NaN(), false)
+	if !ok {
+		t.Fatal("expected a fit")
+	}
+	if f.Alpha > 0.3 {
+		t.Fatalf("unexpected alpha: got %v, want at most 0.3", f.Alpha)
+	}
+	mean, variance = f.Forecast(2)
+	if math.Abs(mean[0]-10) > 0.5 || mean[1] != mean[0] {
+		t.Fatalf("unexpected forecast: %v", mean)
+	}
+	if !(variance[1] >= variance[0] && variance[0] > 0) {
+		t.Fatalf("unexpected variance: %v", variance)
+	}
+
+	// Fixed parameters are kept.
+	f, _ = forecast.FitSmoothing(y, 0.7, math.NaN(), false)
+	if f.Alpha != 0.7 {
+		t.Fatalf("unexpected alpha: got %v, want 0.7", f.Alpha)
+	}
+}
@@ -0,0 +1,221 @@
+package forecast
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/optimize"
+)
+
+const (
+	// Epsilon value and maximum iterations of the minimization
+	// of the squared errors when fitting smoothing parameters.
+	fitEpsilon       = 1.0e-6
+	fitMaxIterations = 200
+)
+
+// Initial guesses of the smoothing parameters.
+var (
+	alphaGuesses = []float64{0.2, 0.5, 0.8}
+	betaGuesses  = []float64{0.1, 0.5}
+)
+
+// Smoothing is an exponential smoothing model.
+//
+// Without a trend, it is simple exponential smoothing, where the level
+// of the series is updated by each value with a weight of Alpha.
+// With a trend, it is Holt's linear method, where the slope of the
+// series is also updated by each change of the level with a weight of Beta.
+type Smoothing struct {
+	Alpha float64
+	Beta  float64
+	Trend bool
+}
+
+// SmoothingFit is a smoothing model applied to a series.
+type SmoothingFit struct {
+	Smoothing
+	// Smoothed holds the level of the series after each point,
+	// or NaN where the series is NaN.
+	Smoothed []float64
+	// Level and Slope are the state after the last point.
+	Level float64
+	Slope float64
+	// Variance is the mean of the squared one-step errors.
+	Variance float64
+
+	sse    float64
+	errors int
+}
+
+// MinPoints returns the number of values needed to fit a model.
+func (m Smoothing) MinPoints() int {
+	if m.Trend {
+		return 3
+	}
+	return 2
+}
+
+// Apply smooths the series y, where NaN values are missing, and reports
+// false if the series has less than MinPoints values.
+//
+// The level starts at the first value and the slope at the slope
+// between the first two values. Missing values are skipped by
+// extrapolating the level without updating it.
+func (m Smoothing) Apply(y []float64) (*SmoothingFit, bool) {
+	first, second := -1, -1
+	values := 0
+	for i, v := range y {
+		if math.IsNaN(v) {
+			continue
+		}
+		switch {
+		case first < 0:
+			first = i
+		case second < 0:
+			second = i
+		}
+		values++
+	}
+	if values < m.MinPoints() {
+		return nil, false
+	}
+
+	f := &SmoothingFit{
+		Smoothing: m,
+		Smoothed:  make([]float64, len(y)),
+		Level:     y[first],
+	}
+	start := first
+	if m.Trend {
+		f.Slope = (y[second] - y[first]) / float64(second-first)
+		// The first two values define the slope so
+		// the prediction of the second one is exact.
+		start = second
+	}
+	for i := range y {
+		if i <= first {
+			f.Smoothed[i] = math.NaN()
+			continue
+		}
+		predicted := f.Level + f.Slope
+		if math.IsNaN(y[i]) {
+			f.Level = predicted
+			f.Smoothed[i] = math.NaN()
+			continue
+		}
+		if i > start {
+			e := y[i] - predicted
+			f.sse += e * e
+			f.errors++
+		}
+		level := m.Alpha*y[i] + (1-m.Alpha)*predicted
+		if m.Trend {
+			f.Slope = m.Beta*(level-f.Level) + (1-m.Beta)*f.Slope
+		}
+		f.Level = level
+		f.Smoothed[i] = level
+	}
+	f.Smoothed[first] = y[first]
+	if f.errors > 0 {
+		f.Variance = f.sse / float64(f.errors)
+	}
+	return f, true
+}
+
+// FitSmoothing applies the smoothing model with the parameters that
+// minimize the squared one-step errors of the series y.
+// Parameters that are not NaN are kept and only the others are fitted.
+// It reports false if the series has too few values.
+func FitSmoothing(y []float64, alpha, beta float64, trend bool) (*SmoothingFit, bool) {
+	m := Smoothing{Alpha: alpha, Beta: beta, Trend: trend}
+	if !trend {
+		m.Beta = 0
+	}
+	if _, ok := m.Apply(y); !ok {
+		return nil, false
+	}
+
+	// params maps the parameters of the minimization
+	// to the parameters of the model.
+	var params []*float64
+	if math.IsNaN(m.Alpha) {
+		params = append(params, &m.Alpha)
+	}
+	if math.IsNaN(m.Beta) {
+		params = append(params, &m.Beta)
+	}
+	if len(params) > 0 {
+		problem := optimize.Problem{
+			Func: func(x []float64) float64 {
+				for i, p := range params {
+					*p = constrain(x[i])
+				}
+				f, _ := m.Apply(y)
+				return f.sse
+			},
+		}
+		settings := optimize.Settings{Converger: &optimize.FunctionConverge{Absolute: fitEpsilon, Iterations: fitMaxIterations}}
+
+		minSSE := math.Inf(1)
+		var best []float64
+		for _, guess := range guesses(len(params)) {
+			result, err := optimize.Minimize(problem, guess, &settings, &optimize.NelderMead{})
+			if err != nil {
+				continue
+			}
+			if result.F < minSSE || best == nil {
+				minSSE = result.F
+				best = result.X
+			}
+		}
+		if best == nil {
+			best = guesses(len(params))[0]
+		}
+		for i, p := range params {
+			*p = constrain(best[i])
+		}
+	}
+	return m.Apply(y)
+}
+
+// guesses returns the initial guesses of n parameters,
+// where the first parameter is alpha if there are two.
+func guesses(n int) [][]float64 {
+	var gs [][]float64
+	for _, alpha := range alphaGuesses {
+		if n == 1 {
+			gs = append(gs, []float64{alpha})
+			continue
+		}
+		for _, beta := range betaGuesses {
+			gs = append(gs, []float64{alpha, beta})
+		}
+	}
+	return gs
+}
+
+// constrain limits a smoothing parameter to [0, 1].
+func constrain(x float64) float64 {
+	return math.Max(0, math.Min(1, x))
+}
+
+// Forecast returns the forecasts of the next h points of the series
+// and the variance of their errors.
+func (f *SmoothingFit) Forecast(h int) (mean, variance []float64) {
+	mean = make([]float64, h)
+	variance = make([]float64, h)
+	// The error of a forecast accumulates the errors of
+	// each of the steps before it, weighted by how much
+	// each step would have changed the forecast.
+	sum := 1.0
+	for i := 0; i < h; i++ {
+		mean[i] = f.Level + float64(i+1)*f.Slope
+		variance[i] = f.Variance * sum
+		c := f.Alpha
+		if f.Trend {
+			c *= 1 + float64(i+1)*f.Beta
+		}
+		sum += c * c
+	}
+	return mean, variance
+}
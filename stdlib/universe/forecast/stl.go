@@ -0,0 +1,258 @@
+package forecast
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	// DefaultSeasonalSpan is the default span of the smoother
+	// of each cycle-subseries.
+	DefaultSeasonalSpan = 7
+
+	// Inner and outer iterations of STL without and with
+	// robustness weights.
+	stlInner       = 2
+	stlRobustInner = 1
+	stlRobustOuter = 15
+)
+
+// Decomposition is the seasonal-trend decomposition of a series.
+// The series is the sum of the components at each point.
+type Decomposition struct {
+	Trend    []float64
+	Seasonal []float64
+	Residual []float64
+}
+
+// STL decomposes a series into trend, seasonal and residual components
+// with the STL procedure of Cleveland et al., "STL: A Seasonal-Trend
+// Decomposition Procedure Based on Loess".
+//
+// The period is the number of points in a season and span is the odd
+// span of the loess smoother of each cycle-subseries; larger spans give
+// smoother seasonal components. Robust decompositions downweight
+// outliers so they end up in the residual.
+// The series must not contain NaN values and must cover at least
+// two periods.
+func STL(y []float64, period, span int, robust bool) Decomposition {
+	n := len(y)
+	if span%2 == 0 {
+		span++
+	}
+	s := stl{
+		y:      y,
+		period: period,
+		ns:     span,
+		nt:     nextOdd(int(math.Ceil(1.5 * float64(period) / (1 - 1.5/float64(span))))),
+		nl:     nextOdd(period + 1),
+		rw:     make([]float64, n),
+		trend:  make([]float64, n),
+		season: make([]float64, n),
+	}
+	for i := range s.rw {
+		s.rw[i] = 1
+	}
+
+	inner, outer := stlInner, 0
+	if robust {
+		inner, outer = stlRobustInner, stlRobustOuter
+	}
+	for k := 0; ; k++ {
+		for i := 0; i < inner; i++ {
+			s.innerLoop()
+		}
+		if k >= outer {
+			break
+		}
+		s.robustnessWeights()
+	}
+
+	residual := make([]float64, n)
+	for i := range residual {
+		residual[i] = y[i] - s.trend[i] - s.season[i]
+	}
+	return Decomposition{
+		Trend:    s.trend,
+		Seasonal: s.season,
+		Residual: residual,
+	}
+}
+
+// stl holds the state of a decomposition.
+type stl struct {
+	y          []float64
+	period     int
+	ns, nt, nl int
+	// rw holds the robustness weight of each point.
+	rw     []float64
+	trend  []float64
+	season []float64
+}
+
+func nextOdd(x int) int {
+	if x%2 == 0 {
+		return x + 1
+	}
+	return x
+}
+
+func (s *stl) innerLoop() {
+	n, np := len(s.y), s.period
+
+	// Smooth each cycle-subseries of the detrended series and
+	// extend it by one point at each end.
+	c := make([]float64, n+2*np)
+	for k := 0; k < np; k++ {
+		m := (n - k + np - 1) / np
+		sub := make([]float64, m)
+		rw := make([]float64, m)
+		for j := range sub {
+			sub[j] = s.y[k+j*np] - s.trend[k+j*np]
+			rw[j] = s.rw[k+j*np]
+		}
+		for j := -1; j <= m; j++ {
+			v, ok := loess(sub, rw, s.ns, float64(j))
+			if !ok {
+				v = sub[clamp(j, 0, m-1)]
+			}
+			c[(j+1)*np+k] = v
+		}
+	}
+
+	// Remove the low frequencies the cycle-subseries picked up.
+	l := movingAverage(movingAverage(movingAverage(c, np), np), 3)
+	ones := make([]float64, n)
+	for i := range ones {
+		ones[i] = 1
+	}
+	for i := 0; i < n; i++ {
+		v, ok := loess(l, ones, s.nl, float64(i))
+		if !ok {
+			v = l[i]
+		}
+		s.season[i] = c[np+i] - v
+	}
+
+	// Smooth the deseasonalized series for the trend.
+	deseasonalized := make([]float64, n)
+	for i := range deseasonalized {
+		deseasonalized[i] = s.y[i] - s.season[i]
+	}
+	for i := 0; i < n; i++ {
+		v, ok := loess(deseasonalized, s.rw, s.nt, float64(i))
+		if !ok {
+			v = deseasonalized[i]
+		}
+		s.trend[i] = v
+	}
+}
+
+// robustnessWeights computes the bisquare weights of the residuals.
+func (s *stl) robustnessWeights() {
+	n := len(s.y)
+	r := make([]float64, n)
+	var scale float64
+	for i := range r {
+		r[i] = math.Abs(s.y[i] - s.trend[i] - s.season[i])
+		scale = math.Max(scale, math.Abs(s.y[i]))
+	}
+	sorted := append([]float64(nil), r...)
+	sort.Float64s(sorted)
+	h := 6 * (sorted[(n-1)/2] + sorted[n/2]) / 2
+	// Residuals within the precision of the series are exact fits
+	// and must not be weighted against each other.
+	h = math.Max(h, 1e-9*scale)
+	for i, v := range r {
+		switch u := v / h; {
+		case u <= 0.001:
+			s.rw[i] = 1
+		case u <= 0.999:
+			s.rw[i] = (1 - u*u) * (1 - u*u)
+		default:
+			s.rw[i] = 0
+		}
+	}
+}
+
+// loess fits a locally weighted line to the q points nearest to x,
+// where the points are at positions 0 to len(y)-1, and evaluates it
+// at x. It reports false if all of the points have no weight.
+func loess(y, rw []float64, q int, x float64) (float64, bool) {
+	n := len(y)
+	left, right := 0, n-1
+	if q < n {
+		left = clamp(int(math.Floor(x-float64(q-1)/2+0.5)), 0, n-q)
+		right = left + q - 1
+	}
+	h := math.Max(x-float64(left), float64(right)-x)
+	if q > n {
+		h += float64((q - n) / 2)
+	}
+
+	w := make([]float64, right-left+1)
+	var sum float64
+	for j := left; j <= right; j++ {
+		switch r := math.Abs(float64(j) - x); {
+		case r <= 0.001*h:
+			w[j-left] = rw[j]
+		case r <= 0.999*h:
+			u := r / h
+			u = 1 - u*u*u
+			w[j-left] = u * u * u * rw[j]
+		}
+		sum += w[j-left]
+	}
+	if sum <= 0 {
+		return 0, false
+	}
+
+	var a float64
+	for j := left; j <= right; j++ {
+		w[j-left] /= sum
+		a += w[j-left] * float64(j)
+	}
+	var c float64
+	for j := left; j <= right; j++ {
+		d := float64(j) - a
+		c += w[j-left] * d * d
+	}
+	if math.Sqrt(c) > 0.001*float64(n-1) {
+		b := (x - a) / c
+		for j := left; j <= right; j++ {
+			w[j-left] *= b*(float64(j)-a) + 1
+		}
+	}
+
+	var v float64
+	for j := left; j <= right; j++ {
+		v += w[j-left] * y[j]
+	}
+	return v, true
+}
+
+// movingAverage returns the averages of each run of k values.
+func movingAverage(y []float64, k int) []float64 {
+	out := make([]float64, len(y)-k+1)
+	var sum float64
+	for i, v := range y {
+		sum += v
+		if i >= k {
+			sum -= y[i-k]
+		}
+		if i >= k-1 {
+			out[i-k+1] = sum / float64(k)
+		}
+	}
+	return out
+}
+
+func clamp(x, lo, hi int) int {
+	if x < lo {
+		return lo
+	}
+	if x > hi {
+		return hi
+	}
+	return x
+}
@@ -0,0 +1,335 @@
+package universe_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/execute/executetest"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/internal/operation"
+	"github.com/InfluxCommunity/flux/querytest"
+	"github.com/InfluxCommunity/flux/stdlib/influxdata/influxdb"
+	"github.com/InfluxCommunity/flux/stdlib/universe"
+)
+
+func TestForecast_NewQuery(t *testing.T) {
+	tests := []querytest.NewQueryTestCase{
+		{
+			Name: "forecast defaults",
+			Raw:  `from(bucket:"mydb") |> forecast(n: 3)`,
+			Want: &operation.Spec{
+				Operations: []*operation.Node{
+					{
+						ID: "from0",
+						Spec: &influxdb.FromOpSpec{
+							Bucket: influxdb.NameOrID{Name: "mydb"},
+						},
+					},
+					{
+						ID: "forecast1",
+						Spec: &universe.ForecastOpSpec{
+							N:          3,
+							Method:     universe.ForecastMethodHolt,
+							Level:      0.95,
+							Column:     execute.DefaultValueColLabel,
+							TimeColumn: execute.DefaultTimeColLabel,
+						},
+					},
+				},
+				Edges: []operation.Edge{
+					{Parent: "from0", Child: "forecast1"},
+				},
+			},
+		},
+		{
+			Name: "forecast no defaults",
+			Raw:  `from(bucket:"mydb") |> forecast(n: 3, method: "stl", period: 24, interval: 1h, level: 0.8, column: "v", timeColumn: "t")`,
+			Want: &operation.Spec{
+				Operations: []*operation.Node{
+					{
+						ID: "from0",
+						Spec: &influxdb.FromOpSpec{
+							Bucket: influxdb.NameOrID{Name: "mydb"},
+						},
+					},
+					{
+						ID: "forecast1",
+						Spec: &universe.ForecastOpSpec{
+							N:          3,
+							Method:     universe.ForecastMethodSTL,
+							Period:     24,
+							Interval:   flux.ConvertDuration(time.Hour),
+							Level:      0.8,
+							Column:     "v",
+							TimeColumn: "t",
+						},
+					},
+				},
+				Edges: []operation.Edge{
+					{Parent: "from0", Child: "forecast1"},
+				},
+			},
+		},
+		{
+			Name:    "unknown method",
+			Raw:     `from(bucket:"mydb") |> forecast(n: 3, method: "arima")`,
+			WantErr: true,
+		},
+		{
+			Name:    "stl without period",
+			Raw:     `from(bucket:"mydb") |> forecast(n: 3, method: "stl")`,
+			WantErr: true,
+		},
+		{
+			Name:    "period without stl",
+			Raw:     `from(bucket:"mydb") |> forecast(n: 3, period: 4)`,
+			WantErr: true,
+		},
+		{
+			Name:    "level out of range",
+			Raw:     `from(bucket:"mydb") |> forecast(n: 3, level: 1.0)`,
+			WantErr: true,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			querytest.NewQueryTestHelper(t, tc)
+		})
+	}
+}
+
+func TestForecast_Process(t *testing.T) {
+	testCases := []struct {
+		name    string
+		spec    *universe.ForecastProcedureSpec
+		data    []flux.Table
+		want    []*executetest.Table
+		wantErr error
+	}{
+		{
+			name: "holt",
+			spec: &universe.ForecastProcedureSpec{
+				N:          2,
+				Method:     universe.ForecastMethodHolt,
+				Level:      0.95,
+				Column:     "_value",
+				TimeColumn: "_time",
+			},
+			data: []flux.Table{&executetest.Table{
+				KeyCols: []string{"t0"},
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TInt},
+					{Label: "t0", Type: flux.TString},
+					{Label: "t1", Type: flux.TString},
+				},
+				Data: [][]interface{}{
+					{execute.Time(10), int64(1), "a", "x"},
+					{execute.Time(20), int64(3), "a", "x"},
+					{execute.Time(30), int64(5), "a", "x"},
+					{execute.Time(40), int64(7), "a", "x"},
+					{execute.Time(50), int64(9), "a", "x"},
+					{execute.Time(60), int64(11), "a", "x"},
+				},
+			}},
+			want: []*executetest.Table{{
+				KeyCols: []string{"t0"},
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TFloat},
+					{Label: "t0", Type: flux.TString},
+					{Label: "t1", Type: flux.TString},
+					{Label: "lower", Type: flux.TFloat},
+					{Label: "upper", Type: flux.TFloat},
+				},
+				Data: [][]interface{}{
+					{execute.Time(10), 1.0, "a", "x", nil, nil},
+					{execute.Time(20), 3.0, "a", "x", nil, nil},
+					{execute.Time(30), 5.0, "a", "x", nil, nil},
+					{execute.Time(40), 7.0, "a", "x", nil, nil},
+					{execute.Time(50), 9.0, "a", "x", nil, nil},
+					{execute.Time(60), 11.0, "a", "x", nil, nil},
+					{execute.Time(70), 13.0, "a", nil, 13.0, 13.0},
+					{execute.Time(80), 15.0, "a", nil, 15.0, 15.0},
+				},
+			}},
+		},
+		{
+			name: "ses with nulls",
+			spec: &universe.ForecastProcedureSpec{
+				N:          2,
+				Method:     universe.ForecastMethodSES,
+				Interval:   flux.ConvertDuration(5),
+				Level:      0.8,
+				Column:     "_value",
+				TimeColumn: "_time",
+			},
+			data: []flux.Table{&executetest.Table{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TInt},
+				},
+				Data: [][]interface{}{
+					{execute.Time(10), int64(10)},
+					{execute.Time(20), int64(12)},
+					{execute.Time(30), nil},
+					{execute.Time(40), int64(9)},
+					{execute.Time(50), int64(11)},
+					{execute.Time(60), int64(10)},
+				},
+			}},
+			want: []*executetest.Table{{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TFloat},
+					{Label: "lower", Type: flux.TFloat},
+					{Label: "upper", Type: flux.TFloat},
+				},
+				Data: [][]interface{}{
+					{execute.Time(10), 10.0, nil, nil},
+					{execute.Time(20), 12.0, nil, nil},
+					{execute.Time(30), nil, nil, nil},
+					{execute.Time(40), 9.0, nil, nil},
+					{execute.Time(50), 11.0, nil, nil},
+					{execute.Time(60), 10.0, nil, nil},
+					{execute.Time(65), 10.0, 8.430426292675389, 11.569573707324611},
+					{execute.Time(70), 10.0, 8.430426292675389, 11.569573707324611},
+				},
+			}},
+		},
+		{
+			name: "stl",
+			spec: &universe.ForecastProcedureSpec{
+				N:          5,
+				Method:     universe.ForecastMethodSTL,
+				Period:     4,
+				Level:      0.95,
+				Column:     "_value",
+				TimeColumn: "_time",
+			},
+			data: []flux.Table{&executetest.Table{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TFloat},
+				},
+				Data: [][]interface{}{
+					{execute.Time(10), 13.0},
+					{execute.Time(20), 12.0},
+					{execute.Time(30), 10.0},
+					{execute.Time(40), 11.0},
+					{execute.Time(50), 17.0},
+					{execute.Time(60), 16.0},
+					{execute.Time(70), 14.0},
+					{execute.Time(80), 15.0},
+					{execute.Time(90), 21.0},
+					{execute.Time(100), 20.0},
+					{execute.Time(110), 18.0},
+					{execute.Time(120), 19.0},
+				},
+			}},
+			want: []*executetest.Table{{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TFloat},
+					{Label: "lower", Type: flux.TFloat},
+					{Label: "upper", Type: flux.TFloat},
+				},
+				Data: [][]interface{}{
+					{execute.Time(10), 13.0, nil, nil},
+					{execute.Time(20), 12.0, nil, nil},
+					{execute.Time(30), 10.0, nil, nil},
+					{execute.Time(40), 11.0, nil, nil},
+					{execute.Time(50), 17.0, nil, nil},
+					{execute.Time(60), 16.0, nil, nil},
+					{execute.Time(70), 14.0, nil, nil},
+					{execute.Time(80), 15.0, nil, nil},
+					{execute.Time(90), 21.0, nil, nil},
+					{execute.Time(100), 20.0, nil, nil},
+					{execute.Time(110), 18.0, nil, nil},
+					{execute.Time(120), 19.0, nil, nil},
+					{execute.Time(130), 25.0, 25.0, 25.0},
+					{execute.Time(140), 24.0, 24.0, 24.0},
+					{execute.Time(150), 22.0, 22.0, 22.0},
+					{execute.Time(160), 23.0, 23.0, 23.0},
+					{execute.Time(170), 29.0, 29.0, 29.0},
+				},
+			}},
+		},
+		{
+			name: "too few values",
+			spec: &universe.ForecastProcedureSpec{
+				N:          2,
+				Method:     universe.ForecastMethodHolt,
+				Level:      0.95,
+				Column:     "_value",
+				TimeColumn: "_time",
+			},
+			data: []flux.Table{&executetest.Table{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TInt},
+				},
+				Data: [][]interface{}{
+					{execute.Time(10), int64(1)},
+					{execute.Time(20), nil},
+				},
+			}},
+			want: []*executetest.Table{{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TFloat},
+					{Label: "lower", Type: flux.TFloat},
+					{Label: "upper", Type: flux.TFloat},
+				},
+				Data: [][]interface{}{
+					{execute.Time(10), 1.0, nil, nil},
+					{execute.Time(20), nil, nil, nil},
+				},
+			}},
+		},
+		{
+			name: "stl with nulls",
+			spec: &universe.ForecastProcedureSpec{
+				N:          2,
+				Method:     universe.ForecastMethodSTL,
+				Period:     2,
+				Level:      0.95,
+				Column:     "_value",
+				TimeColumn: "_time",
+			},
+			data: []flux.Table{&executetest.Table{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TFloat},
+				},
+				Data: [][]interface{}{
+					{execute.Time(10), 1.0},
+					{execute.Time(20), nil},
+					{execute.Time(30), 3.0},
+					{execute.Time(40), 4.0},
+				},
+			}},
+			wantErr: errors.New(codes.FailedPrecondition, "forecast with method stl requires a value in every row; fill null values before forecast()"),
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			executetest.ProcessTestHelper(
+				t,
+				tc.data,
+				tc.want,
+				tc.wantErr,
+				func(d execute.Dataset, c execute.TableBuilderCache) execute.Transformation {
+					return universe.NewForecastTransformation(d, c, tc.spec)
+				},
+				floatOptions,
+			)
+		})
+	}
+}
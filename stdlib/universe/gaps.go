@@ -0,0 +1,223 @@
+package universe
+
+import (
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/array"
+	"github.com/InfluxCommunity/flux/arrow"
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/execute/table"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/plan"
+	"github.com/InfluxCommunity/flux/runtime"
+	"github.com/apache/arrow/go/v7/arrow/memory"
+)
+
+const (
+	GapsKind = "gaps"
+
+	gapStartColLabel = "start"
+	gapStopColLabel  = "stop"
+)
+
+type GapsOpSpec struct {
+	Every     flux.Duration `json:"every"`
+	Tolerance flux.Duration `json:"tolerance"`
+}
+
+func init() {
+	gapsSignature := runtime.MustLookupBuiltinType("universe", GapsKind)
+
+	runtime.RegisterPackageValue("universe", GapsKind, flux.MustValue(flux.FunctionValue(GapsKind, CreateGapsOpSpec, gapsSignature)))
+	plan.RegisterProcedureSpec(GapsKind, newGapsProcedure, GapsKind)
+	execute.RegisterTransformation(GapsKind, createGapsTransformation)
+}
+
+func CreateGapsOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
+	if err := a.AddParentFromArgs(args); err != nil {
+		return nil, err
+	}
+
+	spec := new(GapsOpSpec)
+	var err error
+	if spec.Every, err = args.GetRequiredDuration("every"); err != nil {
+		return nil, err
+	} else if !spec.Every.IsPositive() || spec.Every.Months() != 0 {
+		return nil, errors.Newf(codes.Invalid, "gaps every must be a positive duration without months or years, got %v", spec.Every)
+	}
+
+	if tolerance, ok, err := args.GetDuration("tolerance"); err != nil {
+		return nil, err
+	} else if ok {
+		if tolerance.IsNegative() || tolerance.Months() != 0 {
+			return nil, errors.Newf(codes.Invalid, "gaps tolerance must be a non-negative duration without months or years, got %v", tolerance)
+		}
+		spec.Tolerance = tolerance
+	}
+	return spec, nil
+}
+
+func (s *GapsOpSpec) Kind() flux.OperationKind {
+	return GapsKind
+}
+
+type GapsProcedureSpec struct {
+	plan.DefaultCost
+	Every     flux.Duration
+	Tolerance flux.Duration
+}
+
+func newGapsProcedure(qs flux.OperationSpec, pa plan.Administration) (plan.ProcedureSpec, error) {
+	spec, ok := qs.(*GapsOpSpec)
+	if !ok {
+		return nil, errors.Newf(codes.Internal, "invalid spec type %T", qs)
+	}
+	return &GapsProcedureSpec{
+		Every:     spec.Every,
+		Tolerance: spec.Tolerance,
+	}, nil
+}
+
+func (s *GapsProcedureSpec) Kind() plan.ProcedureKind {
+	return GapsKind
+}
+
+func (s *GapsProcedureSpec) Copy() plan.ProcedureSpec {
+	ns := new(GapsProcedureSpec)
+	*ns = *s
+	return ns
+}
+
+// TriggerSpec implements plan.TriggerAwareProcedureSpec
+func (s *GapsProcedureSpec) TriggerSpec() plan.TriggerSpec {
+	return plan.NarrowTransformationTriggerSpec{}
+}
+
+func createGapsTransformation(id execute.DatasetID, mode execute.AccumulationMode, spec plan.ProcedureSpec, a execute.Administration) (execute.Transformation, execute.Dataset, error) {
+	s, ok := spec.(*GapsProcedureSpec)
+	if !ok {
+		return nil, nil, errors.Newf(codes.Internal, "invalid spec type %T", spec)
+	}
+	return NewGapsTransformation(id, s, a.Allocator())
+}
+
+// NewGapsTransformation constructs a transformation that outputs
+// the intervals of each table that are missing rows.
+func NewGapsTransformation(id execute.DatasetID, spec *GapsProcedureSpec, mem memory.Allocator) (execute.Transformation, execute.Dataset, error) {
+	tr := &gapsTransformation{
+		maxInterval: spec.Every.Nanoseconds() + spec.Tolerance.Nanoseconds(),
+	}
+	return execute.NewAggregateTransformation(id, tr, mem)
+}
+
+type gapsTransformation struct {
+	// maxInterval is the longest time between rows that is not a gap.
+	maxInterval int64
+}
+
+type gapsState struct {
+	// last is the time of the last row, or the start of
+	// the table's time range before the first row.
+	last int64
+	ok   bool
+
+	starts, stops []int64
+}
+
+func (s *gapsState) add(t int64, maxInterval int64) {
+	if s.ok && t-s.last > maxInterval {
+		s.starts = append(s.starts, s.last)
+		s.stops = append(s.stops, t)
+	}
+	s.last, s.ok = t, true
+}
+
+func (t *gapsTransformation) Aggregate(chunk table.Chunk, state interface{}, mem memory.Allocator) (interface{}, bool, error) {
+	var s *gapsState
+	if state != nil {
+		s = state.(*gapsState)
+	} else {
+		key := chunk.Key()
+		if key.HasCol(gapStartColLabel) || key.HasCol(gapStopColLabel) {
+			return nil, false, errors.Newf(codes.FailedPrecondition, "gaps cannot output the %q and %q columns because one of them is part of the group key", gapStartColLabel, gapStopColLabel)
+		}
+		s = new(gapsState)
+		if start, ok := keyTime(key, execute.DefaultStartColLabel); ok {
+			s.last, s.ok = start, true
+		}
+	}
+
+	if chunk.Len() == 0 {
+		return s, true, nil
+	}
+
+	idx := chunk.Index(execute.DefaultTimeColLabel)
+	if idx < 0 {
+		return nil, false, errors.Newf(codes.FailedPrecondition, "gaps requires a %q column", execute.DefaultTimeColLabel)
+	} else if typ := chunk.Col(idx).Type; typ != flux.TTime {
+		return nil, false, errors.Newf(codes.FailedPrecondition, "gaps requires %q to be a time column, got %v", execute.DefaultTimeColLabel, typ)
+	}
+
+	times := chunk.Ints(idx)
+	for i, n := 0, times.Len(); i < n; i++ {
+		if times.IsNull(i) {
+			continue
+		}
+		ts := times.Value(i)
+		if s.ok && ts < s.last {
+			return nil, false, errors.New(codes.FailedPrecondition, "gaps requires rows sorted by increasing time")
+		}
+		s.add(ts, t.maxInterval)
+	}
+	return s, true, nil
+}
+
+func (t *gapsTransformation) Compute(key flux.GroupKey, state interface{}, d *execute.TransportDataset, mem memory.Allocator) error {
+	s := state.(*gapsState)
+	if stop, ok := keyTime(key, execute.DefaultStopColLabel); ok && s.ok {
+		s.add(stop, t.maxInterval)
+	}
+
+	n := len(s.starts)
+	buffer := arrow.TableBuffer{
+		GroupKey: key,
+		Columns:  make([]flux.ColMeta, 0, len(key.Cols())+2),
+		Values:   make([]array.Array, 0, len(key.Cols())+2),
+	}
+	for j, c := range key.Cols() {
+		buffer.Columns = append(buffer.Columns, c)
+		buffer.Values = append(buffer.Values, arrow.Repeat(c.Type, key.Value(j), n, mem))
+	}
+	buffer.Columns = append(buffer.Columns,
+		flux.ColMeta{Label: gapStartColLabel, Type: flux.TTime},
+		flux.ColMeta{Label: gapStopColLabel, Type: flux.TTime},
+	)
+	buffer.Values = append(buffer.Values,
+		newTimeArray(s.starts, mem),
+		newTimeArray(s.stops, mem),
+	)
+	if err := buffer.Validate(); err != nil {
+		return err
+	}
+	return d.Process(table.ChunkFromBuffer(buffer))
+}
+
+func (t *gapsTransformation) Close() error { return nil }
+
+// keyTime returns the value of a non-null time column in the group key.
+func keyTime(key flux.GroupKey, label string) (int64, bool) {
+	j := execute.ColIdx(label, key.Cols())
+	if j < 0 || key.Cols()[j].Type != flux.TTime || key.IsNull(j) {
+		return 0, false
+	}
+	return int64(key.ValueTime(j)), true
+}
+
+func newTimeArray(vs []int64, mem memory.Allocator) array.Array {
+	b := array.NewIntBuilder(mem)
+	b.Resize(len(vs))
+	for _, v := range vs {
+		b.Append(v)
+	}
+	return b.NewArray()
+}
@@ -0,0 +1,228 @@
+package universe_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/execute/executetest"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/internal/operation"
+	"github.com/InfluxCommunity/flux/memory"
+	"github.com/InfluxCommunity/flux/querytest"
+	"github.com/InfluxCommunity/flux/stdlib/influxdata/influxdb"
+	"github.com/InfluxCommunity/flux/stdlib/universe"
+)
+
+func TestGaps_NewQuery(t *testing.T) {
+	tests := []querytest.NewQueryTestCase{
+		{
+			Name: "gaps",
+			Raw:  `from(bucket:"mydb") |> gaps(every: 10s, tolerance: 1s)`,
+			Want: &operation.Spec{
+				Operations: []*operation.Node{
+					{
+						ID: "from0",
+						Spec: &influxdb.FromOpSpec{
+							Bucket: influxdb.NameOrID{Name: "mydb"},
+						},
+					},
+					{
+						ID: "gaps1",
+						Spec: &universe.GapsOpSpec{
+							Every:     flux.ConvertDuration(10 * time.Second),
+							Tolerance: flux.ConvertDuration(time.Second),
+						},
+					},
+				},
+				Edges: []operation.Edge{
+					{Parent: "from0", Child: "gaps1"},
+				},
+			},
+		},
+		{
+			Name:    "zero every",
+			Raw:     `from(bucket:"mydb") |> gaps(every: 0s)`,
+			WantErr: true,
+		},
+		{
+			Name:    "every in months",
+			Raw:     `from(bucket:"mydb") |> gaps(every: 1mo)`,
+			WantErr: true,
+		},
+		{
+			Name:    "negative tolerance",
+			Raw:     `from(bucket:"mydb") |> gaps(every: 10s, tolerance: -1s)`,
+			WantErr: true,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			querytest.NewQueryTestHelper(t, tc)
+		})
+	}
+}
+
+func TestGaps_Process(t *testing.T) {
+	inCols := []flux.ColMeta{
+		{Label: "_start", Type: flux.TTime},
+		{Label: "_stop", Type: flux.TTime},
+		{Label: "host", Type: flux.TString},
+		{Label: "_time", Type: flux.TTime},
+		{Label: "_value", Type: flux.TFloat},
+	}
+	outCols := []flux.ColMeta{
+		{Label: "_start", Type: flux.TTime},
+		{Label: "_stop", Type: flux.TTime},
+		{Label: "host", Type: flux.TString},
+		{Label: "start", Type: flux.TTime},
+		{Label: "stop", Type: flux.TTime},
+	}
+	testCases := []struct {
+		name    string
+		spec    *universe.GapsProcedureSpec
+		data    []flux.Table
+		want    []*executetest.Table
+		wantErr error
+	}{
+		{
+			name: "gaps",
+			spec: &universe.GapsProcedureSpec{
+				Every:     flux.ConvertDuration(10),
+				Tolerance: flux.ConvertDuration(1),
+			},
+			data: []flux.Table{&executetest.Table{
+				KeyCols: []string{"_start", "_stop", "host"},
+				ColMeta: inCols,
+				Data: [][]interface{}{
+					{execute.Time(0), execute.Time(100), "a", execute.Time(5), 1.0},
+					{execute.Time(0), execute.Time(100), "a", execute.Time(16), 2.0},
+					{execute.Time(0), execute.Time(100), "a", execute.Time(40), 3.0},
+					{execute.Time(0), execute.Time(100), "a", nil, 4.0},
+					{execute.Time(0), execute.Time(100), "a", execute.Time(50), 5.0},
+					{execute.Time(0), execute.Time(100), "a", execute.Time(80), 6.0},
+				},
+			}, &executetest.Table{
+				KeyCols: []string{"_start", "_stop", "host"},
+				ColMeta: inCols,
+				Data: [][]interface{}{
+					{execute.Time(0), execute.Time(100), "b", execute.Time(0), 1.0},
+					{execute.Time(0), execute.Time(100), "b", execute.Time(10), 2.0},
+				},
+			}, &executetest.Table{
+				KeyCols:   []string{"_start", "_stop", "host"},
+				KeyValues: []interface{}{execute.Time(0), execute.Time(100), "c"},
+				ColMeta:   inCols,
+			}, &executetest.Table{
+				KeyCols: []string{"_start", "_stop", "host"},
+				ColMeta: inCols,
+				Data: [][]interface{}{
+					{execute.Time(0), execute.Time(100), "d", execute.Time(0), 1.0},
+					{execute.Time(0), execute.Time(100), "d", execute.Time(50), 2.0},
+					{execute.Time(0), execute.Time(100), "d", execute.Time(91), 3.0},
+				},
+			}},
+			want: []*executetest.Table{
+				{
+					KeyCols: []string{"_start", "_stop", "host"},
+					ColMeta: outCols,
+					Data: [][]interface{}{
+						{execute.Time(0), execute.Time(100), "a", execute.Time(16), execute.Time(40)},
+						{execute.Time(0), execute.Time(100), "a", execute.Time(50), execute.Time(80)},
+						{execute.Time(0), execute.Time(100), "a", execute.Time(80), execute.Time(100)},
+					},
+				},
+				{
+					KeyCols: []string{"_start", "_stop", "host"},
+					ColMeta: outCols,
+					Data: [][]interface{}{
+						{execute.Time(0), execute.Time(100), "b", execute.Time(10), execute.Time(100)},
+					},
+				},
+				{
+					KeyCols: []string{"_start", "_stop", "host"},
+					ColMeta: outCols,
+					Data: [][]interface{}{
+						{execute.Time(0), execute.Time(100), "c", execute.Time(0), execute.Time(100)},
+					},
+				},
+				{
+					KeyCols: []string{"_start", "_stop", "host"},
+					ColMeta: outCols,
+					Data: [][]interface{}{
+						{execute.Time(0), execute.Time(100), "d", execute.Time(0), execute.Time(50)},
+					},
+				},
+			},
+		},
+		{
+			name: "without time bounds",
+			spec: &universe.GapsProcedureSpec{
+				Every: flux.ConvertDuration(10),
+			},
+			data: []flux.Table{&executetest.Table{
+				KeyCols: []string{"host"},
+				ColMeta: []flux.ColMeta{
+					{Label: "host", Type: flux.TString},
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TFloat},
+				},
+				Data: [][]interface{}{
+					{"a", execute.Time(20), 1.0},
+					{"a", execute.Time(30), 2.0},
+					{"a", execute.Time(60), 3.0},
+				},
+			}},
+			want: []*executetest.Table{{
+				KeyCols: []string{"host"},
+				ColMeta: []flux.ColMeta{
+					{Label: "host", Type: flux.TString},
+					{Label: "start", Type: flux.TTime},
+					{Label: "stop", Type: flux.TTime},
+				},
+				Data: [][]interface{}{
+					{"a", execute.Time(30), execute.Time(60)},
+				},
+			}},
+		},
+		{
+			name: "unsorted",
+			spec: &universe.GapsProcedureSpec{
+				Every: flux.ConvertDuration(10),
+			},
+			data: []flux.Table{&executetest.Table{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TFloat},
+				},
+				Data: [][]interface{}{
+					{execute.Time(20), 1.0},
+					{execute.Time(10), 2.0},
+				},
+			}},
+			wantErr: errors.New(codes.FailedPrecondition, "gaps requires rows sorted by increasing time"),
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			executetest.ProcessTestHelper2(
+				t,
+				tc.data,
+				tc.want,
+				tc.wantErr,
+				func(id execute.DatasetID, alloc memory.Allocator) (execute.Transformation, execute.Dataset) {
+					tr, d, err := universe.NewGapsTransformation(id, tc.spec, alloc)
+					if err != nil {
+						t.Fatal(err)
+					}
+					return tr, d
+				},
+			)
+		})
+	}
+}
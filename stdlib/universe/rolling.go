@@ -0,0 +1,545 @@
+package universe
+
+import (
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/array"
+	"github.com/InfluxCommunity/flux/arrow"
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/execute/table"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/interval"
+	"github.com/InfluxCommunity/flux/plan"
+	"github.com/InfluxCommunity/flux/runtime"
+	"github.com/InfluxCommunity/flux/values"
+	"github.com/apache/arrow/go/v7/arrow/memory"
+)
+
+const RollingWindowKind = "_rollingWindow"
+
+type RollingWindowOpSpec struct {
+	N           int64         `json:"n"`
+	Period      flux.Duration `json:"period"`
+	Every       flux.Duration `json:"every"`
+	TimeColumn  string        `json:"timeColumn"`
+	StartColumn string        `json:"startColumn"`
+	StopColumn  string        `json:"stopColumn"`
+}
+
+func init() {
+	rollingWindowSignature := runtime.MustLookupBuiltinType("universe", RollingWindowKind)
+
+	runtime.RegisterPackageValue("universe", RollingWindowKind, flux.MustValue(flux.FunctionValue(RollingWindowKind, createRollingWindowOpSpec, rollingWindowSignature)))
+	plan.RegisterProcedureSpec(RollingWindowKind, newRollingWindowProcedure, RollingWindowKind)
+	execute.RegisterTransformation(RollingWindowKind, createRollingWindowTransformation)
+}
+
+func createRollingWindowOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
+	if err := a.AddParentFromArgs(args); err != nil {
+		return nil, err
+	}
+
+	spec := new(RollingWindowOpSpec)
+	var err error
+	if spec.N, err = args.GetRequiredInt("n"); err != nil {
+		return nil, err
+	}
+	if spec.Period, err = args.GetRequiredDuration("period"); err != nil {
+		return nil, err
+	}
+	if spec.Every, err = args.GetRequiredDuration("every"); err != nil {
+		return nil, err
+	}
+	if spec.TimeColumn, err = args.GetRequiredString("timeColumn"); err != nil {
+		return nil, err
+	}
+	if spec.StartColumn, err = args.GetRequiredString("startColumn"); err != nil {
+		return nil, err
+	}
+	if spec.StopColumn, err = args.GetRequiredString("stopColumn"); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case spec.N < 0:
+		return nil, errors.Newf(codes.Invalid, "rolling window must have a positive number of rows, got %d", spec.N)
+	case spec.Period.IsNegative() || spec.Every.IsNegative():
+		return nil, errors.New(codes.Invalid, "rolling window period and every must not be negative")
+	case spec.N > 0 && !(spec.Period.IsZero() && spec.Every.IsZero()):
+		return nil, errors.New(codes.Invalid, "rolling window takes either n or period, not both")
+	case spec.N == 0 && spec.Period.IsZero():
+		return nil, errors.New(codes.Invalid, "rolling window requires n or period")
+	}
+	return spec, nil
+}
+
+func (s *RollingWindowOpSpec) Kind() flux.OperationKind {
+	return RollingWindowKind
+}
+
+type RollingWindowProcedureSpec struct {
+	plan.DefaultCost
+	N           int64
+	Period      flux.Duration
+	Every       flux.Duration
+	TimeColumn  string
+	StartColumn string
+	StopColumn  string
+}
+
+func newRollingWindowProcedure(qs flux.OperationSpec, pa plan.Administration) (plan.ProcedureSpec, error) {
+	spec, ok := qs.(*RollingWindowOpSpec)
+	if !ok {
+		return nil, errors.Newf(codes.Internal, "invalid spec type %T", qs)
+	}
+	return &RollingWindowProcedureSpec{
+		N:           spec.N,
+		Period:      spec.Period,
+		Every:       spec.Every,
+		TimeColumn:  spec.TimeColumn,
+		StartColumn: spec.StartColumn,
+		StopColumn:  spec.StopColumn,
+	}, nil
+}
+
+func (s *RollingWindowProcedureSpec) Kind() plan.ProcedureKind {
+	return RollingWindowKind
+}
+
+func (s *RollingWindowProcedureSpec) Copy() plan.ProcedureSpec {
+	ns := new(RollingWindowProcedureSpec)
+	*ns = *s
+	return ns
+}
+
+// TriggerSpec implements plan.TriggerAwareProcedureSpec
+func (s *RollingWindowProcedureSpec) TriggerSpec() plan.TriggerSpec {
+	return plan.NarrowTransformationTriggerSpec{}
+}
+
+// hopping reports whether the windows start at regular intervals
+// instead of ending at every row.
+func (s *RollingWindowProcedureSpec) hopping() bool {
+	return !s.Every.IsZero()
+}
+
+func createRollingWindowTransformation(id execute.DatasetID, mode execute.AccumulationMode, spec plan.ProcedureSpec, a execute.Administration) (execute.Transformation, execute.Dataset, error) {
+	s, ok := spec.(*RollingWindowProcedureSpec)
+	if !ok {
+		return nil, nil, errors.Newf(codes.Internal, "invalid spec type %T", spec)
+	}
+	return NewRollingWindowTransformation(id, s, a.StreamContext().Bounds(), a.Allocator())
+}
+
+// rollingTransformation reads the rows of each table in order and
+// keeps the rows of the windows that are still open. The rolling
+// window and rolling aggregate transformations differ in what
+// they send downstream when a window ends.
+type rollingTransformation struct {
+	d      *execute.TransportDataset
+	spec   RollingWindowProcedureSpec
+	bounds *execute.Bounds
+	mem    memory.Allocator
+	window interval.Window
+
+	// newState creates the state of a table.
+	newState func(key flux.GroupKey, cols []flux.ColMeta) (rollingState, error)
+}
+
+// rollingState is the state of a table in a rolling transformation.
+type rollingState interface {
+	// push adds a row to the windows.
+	push(row rollingRow)
+	// evict removes the oldest row from the windows.
+	evict()
+	// emit sends the window with the given bounds downstream.
+	emit(start, stop values.Time) error
+	// base returns the rows of the windows.
+	base() *rollingBase
+}
+
+// rollingRow is a row of the input.
+type rollingRow struct {
+	time   values.Time
+	values []values.Value
+}
+
+// rollingBase holds the rows of the open windows of a table.
+type rollingBase struct {
+	key     flux.GroupKey
+	inCols  []flux.ColMeta
+	keyCols []flux.ColMeta
+	cols    []flux.ColMeta
+	rows    []rollingRow
+
+	// pending is set when the last row may be
+	// followed by rows with the same time.
+	pending bool
+	// next are the bounds of the earliest hopping
+	// window that has not been sent.
+	next interval.Bounds
+}
+
+func (b *rollingBase) base() *rollingBase { return b }
+
+func (b *rollingBase) last() values.Time {
+	return b.rows[len(b.rows)-1].time
+}
+
+// newKey returns the group key of the window with the given bounds.
+func (b *rollingBase) newKey(spec *RollingWindowProcedureSpec, start, stop values.Time) flux.GroupKey {
+	vs := make([]values.Value, len(b.keyCols))
+	for i, col := range b.keyCols {
+		switch col.Label {
+		case spec.StartColumn:
+			vs[i] = values.NewTime(start)
+		case spec.StopColumn:
+			vs[i] = values.NewTime(stop)
+		default:
+			vs[i] = b.key.LabelValue(col.Label)
+		}
+	}
+	return execute.NewGroupKey(b.keyCols, vs)
+}
+
+func NewRollingWindowTransformation(id execute.DatasetID, spec *RollingWindowProcedureSpec, bounds *execute.Bounds, mem memory.Allocator) (execute.Transformation, execute.Dataset, error) {
+	t, err := newRollingTransformation(id, spec, bounds, mem)
+	if err != nil {
+		return nil, nil, err
+	}
+	t.newState = func(key flux.GroupKey, cols []flux.ColMeta) (rollingState, error) {
+		return &rollingWindowState{
+			rollingBase: t.newBase(key, cols, true),
+			t:           t,
+		}, nil
+	}
+	return execute.NewTransformationFromTransport(t), t.d, nil
+}
+
+func newRollingTransformation(id execute.DatasetID, spec *RollingWindowProcedureSpec, bounds *execute.Bounds, mem memory.Allocator) (*rollingTransformation, error) {
+	t := &rollingTransformation{
+		d:      execute.NewTransportDataset(id, mem),
+		spec:   *spec,
+		bounds: bounds,
+		mem:    mem,
+	}
+	if spec.hopping() {
+		period := spec.Period
+		if period.IsZero() {
+			period = spec.Every
+		}
+		w, err := interval.NewWindow(spec.Every, period, flux.ConvertDuration(0))
+		if err != nil {
+			return nil, err
+		}
+		t.window = w
+	}
+	return t, nil
+}
+
+// newBase creates the rows of a table. When withRows is set,
+// the schema of the windows holds all of the columns of the table.
+// Otherwise it only holds the group key.
+func (t *rollingTransformation) newBase(key flux.GroupKey, cols []flux.ColMeta, withRows bool) rollingBase {
+	b := rollingBase{
+		key:     key,
+		inCols:  cols,
+		keyCols: t.createSchema(key.Cols()),
+	}
+	if withRows {
+		b.cols = t.createSchema(cols)
+	} else {
+		b.cols = b.keyCols
+	}
+	return b
+}
+
+// createSchema adds the start and stop columns to the columns
+// if they are missing, as window() does.
+func (t *rollingTransformation) createSchema(cols []flux.ColMeta) []flux.ColMeta {
+	newCols := make([]flux.ColMeta, 0, len(cols)+2)
+	for _, col := range cols {
+		if col.Label == t.spec.StartColumn || col.Label == t.spec.StopColumn {
+			col.Type = flux.TTime
+		}
+		newCols = append(newCols, col)
+	}
+	if execute.ColIdx(t.spec.StartColumn, newCols) < 0 {
+		newCols = append(newCols, flux.ColMeta{Label: t.spec.StartColumn, Type: flux.TTime})
+	}
+	if execute.ColIdx(t.spec.StopColumn, newCols) < 0 {
+		newCols = append(newCols, flux.ColMeta{Label: t.spec.StopColumn, Type: flux.TTime})
+	}
+	return newCols
+}
+
+func (t *rollingTransformation) ProcessMessage(m execute.Message) error {
+	defer m.Ack()
+
+	switch m := m.(type) {
+	case execute.FinishMsg:
+		err := m.Error()
+		if err == nil {
+			err = t.d.Range(func(key flux.GroupKey, value interface{}) error {
+				return t.flush(value.(rollingState))
+			})
+		}
+		t.d.Finish(err)
+		return nil
+	case execute.ProcessChunkMsg:
+		return t.processChunk(m.TableChunk())
+	case execute.FlushKeyMsg:
+		v, ok := t.d.Delete(m.Key())
+		if !ok {
+			return nil
+		}
+		return t.flush(v.(rollingState))
+	case execute.ProcessMsg:
+		panic("unreachable")
+	}
+	return nil
+}
+
+func (t *rollingTransformation) processChunk(chunk table.Chunk) error {
+	timeIdx := chunk.Index(t.spec.TimeColumn)
+	if timeIdx < 0 {
+		return errors.Newf(codes.FailedPrecondition, "no time column: %s", t.spec.TimeColumn)
+	}
+	if typ := chunk.Col(timeIdx).Type; typ != flux.TTime {
+		return errors.Newf(codes.FailedPrecondition, "time column is not a time value: %s", typ)
+	}
+
+	var state rollingState
+	if v, ok := t.d.Lookup(chunk.Key()); ok {
+		state = v.(rollingState)
+		if !equalColMeta(state.base().inCols, chunk.Cols()) {
+			return errors.Newf(codes.FailedPrecondition, "schema collision detected in table %v", chunk.Key())
+		}
+	} else {
+		var err error
+		if state, err = t.newState(chunk.Key(), chunk.Cols()); err != nil {
+			return err
+		}
+		t.d.Set(chunk.Key(), state)
+	}
+
+	buffer := chunk.Buffer()
+	ts := chunk.Ints(timeIdx)
+	for i, n := 0, chunk.Len(); i < n; i++ {
+		if ts.IsNull(i) {
+			continue
+		}
+		row := rollingRow{
+			time:   values.Time(ts.Value(i)),
+			values: make([]values.Value, chunk.NCols()),
+		}
+		for j := range row.values {
+			row.values[j] = execute.ValueForRow(&buffer, i, j)
+		}
+		if err := t.push(state, row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// push adds the row to the windows and sends the windows that end before it.
+func (t *rollingTransformation) push(state rollingState, row rollingRow) error {
+	b := state.base()
+	if len(b.rows) > 0 {
+		if last := b.last(); row.time < last {
+			return errors.Newf(codes.FailedPrecondition,
+				"rolling requires rows sorted by %q, found %v after %v; use sort() before rolling()",
+				t.spec.TimeColumn, row.time, last,
+			)
+		} else if row.time == last {
+			t.add(state, row)
+			return nil
+		}
+	}
+
+	if t.spec.hopping() {
+		if len(b.rows) == 0 {
+			b.next = t.firstBounds(row.time)
+		}
+		for len(b.rows) > 0 && b.next.Stop() <= row.time {
+			if err := t.emitHop(state); err != nil {
+				return err
+			}
+		}
+		if len(b.rows) == 0 && b.next.Stop() <= row.time {
+			b.next = t.firstBounds(row.time)
+		}
+		t.add(state, row)
+		return nil
+	}
+
+	if b.pending {
+		if err := t.emitLast(state); err != nil {
+			return err
+		}
+	}
+	t.add(state, row)
+	b.pending = true
+	return nil
+}
+
+func (t *rollingTransformation) add(state rollingState, row rollingRow) {
+	state.push(row)
+	b := state.base()
+	// Rows with the same time may push the window over n rows.
+	if !t.spec.hopping() && t.spec.N > 0 && int64(len(b.rows)) > t.spec.N {
+		state.evict()
+	}
+}
+
+// emitLast sends the window that ends with the last row.
+func (t *rollingTransformation) emitLast(state rollingState) error {
+	b := state.base()
+	b.pending = false
+	last := b.last()
+	if t.spec.N > 0 {
+		if int64(len(b.rows)) < t.spec.N {
+			return nil
+		}
+		return state.emit(b.rows[0].time, last)
+	}
+	start := last.Add(t.spec.Period.Mul(-1))
+	for b.rows[0].time <= start {
+		state.evict()
+	}
+	return state.emit(start, last)
+}
+
+// emitHop sends the earliest hopping window that has not been
+// sent if it holds any rows and evicts the rows that no later
+// window holds.
+func (t *rollingTransformation) emitHop(state rollingState) error {
+	b := state.base()
+	bounds := b.next
+	b.next = t.window.NextBounds(bounds)
+	for len(b.rows) > 0 && b.rows[0].time < bounds.Start() {
+		state.evict()
+	}
+	n := 0
+	for n < len(b.rows) && b.rows[n].time < bounds.Stop() {
+		n++
+	}
+	if n > 0 {
+		start, stop := bounds.Start(), bounds.Stop()
+		if t.bounds != nil {
+			if start < t.bounds.Start {
+				start = t.bounds.Start
+			}
+			if stop > t.bounds.Stop {
+				stop = t.bounds.Stop
+			}
+		}
+		// Rolling aggregates are only planned without every,
+		// so the state of a hopping window always holds its rows.
+		if err := state.(*rollingWindowState).emitRows(start, stop, n); err != nil {
+			return err
+		}
+	}
+	for len(b.rows) > 0 && b.rows[0].time < b.next.Start() {
+		state.evict()
+	}
+	return nil
+}
+
+// firstBounds returns the earliest window that holds the time.
+func (t *rollingTransformation) firstBounds(tm values.Time) interval.Bounds {
+	bounds := t.window.GetLatestBounds(tm)
+	for prev := t.window.PrevBounds(bounds); prev.Contains(tm); prev = t.window.PrevBounds(prev) {
+		bounds = prev
+	}
+	return bounds
+}
+
+// flush sends the windows that end with the table.
+func (t *rollingTransformation) flush(state rollingState) error {
+	b := state.base()
+	if t.spec.hopping() {
+		for len(b.rows) > 0 {
+			if err := t.emitHop(state); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if b.pending {
+		return t.emitLast(state)
+	}
+	return nil
+}
+
+func (t *rollingTransformation) Close() error {
+	return nil
+}
+
+// rollingWindowState sends the rows of each window as a table.
+type rollingWindowState struct {
+	rollingBase
+	t *rollingTransformation
+}
+
+func (s *rollingWindowState) push(row rollingRow) {
+	s.rows = append(s.rows, row)
+}
+
+func (s *rollingWindowState) evict() {
+	s.rows[0] = rollingRow{}
+	s.rows = s.rows[1:]
+}
+
+func (s *rollingWindowState) emit(start, stop values.Time) error {
+	return s.emitRows(start, stop, len(s.rows))
+}
+
+// emitRows sends the first n rows as the window with the given bounds.
+func (s *rollingWindowState) emitRows(start, stop values.Time, n int) error {
+	t := s.t
+	key := s.newKey(&t.spec, start, stop)
+	buffer := arrow.TableBuffer{
+		GroupKey: key,
+		Columns:  s.cols,
+		Values:   make([]array.Array, len(s.cols)),
+	}
+	for j, col := range s.cols {
+		switch col.Label {
+		case t.spec.StartColumn:
+			buffer.Values[j] = arrow.Repeat(flux.TTime, values.NewTime(start), n, t.mem)
+		case t.spec.StopColumn:
+			buffer.Values[j] = arrow.Repeat(flux.TTime, values.NewTime(stop), n, t.mem)
+		default:
+			idx := execute.ColIdx(col.Label, s.inCols)
+			b := arrow.NewBuilder(col.Type, t.mem)
+			b.Reserve(n)
+			for _, row := range s.rows[:n] {
+				if err := arrow.AppendValue(b, row.values[idx]); err != nil {
+					b.Release()
+					buffer.Release()
+					return err
+				}
+			}
+			buffer.Values[j] = b.NewArray()
+			b.Release()
+		}
+	}
+	if err := t.d.Process(table.ChunkFromBuffer(buffer)); err != nil {
+		return err
+	}
+	return t.d.FlushKey(key)
+}
+
+func equalColMeta(a, b []flux.ColMeta) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
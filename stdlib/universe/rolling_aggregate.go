@@ -0,0 +1,416 @@
+package universe
+
+import (
+	"context"
+	"math"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/array"
+	"github.com/InfluxCommunity/flux/arrow"
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/execute/table"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/plan"
+	"github.com/InfluxCommunity/flux/values"
+	"github.com/apache/arrow/go/v7/arrow/memory"
+)
+
+const RollingAggregateKind = "rollingAggregate"
+
+func init() {
+	plan.RegisterPhysicalRules(RollingAggregateRule{})
+	execute.RegisterTransformation(RollingAggregateKind, createRollingAggregateTransformation)
+}
+
+// RollingAggregateProcedureSpec computes an aggregate or selector
+// over rolling windows without materializing each window.
+type RollingAggregateProcedureSpec struct {
+	plan.DefaultCost
+	RollingSpec   *RollingWindowProcedureSpec
+	AggregateKind plan.ProcedureKind
+	Column        string
+}
+
+func (s *RollingAggregateProcedureSpec) Kind() plan.ProcedureKind {
+	return RollingAggregateKind
+}
+
+func (s *RollingAggregateProcedureSpec) Copy() plan.ProcedureSpec {
+	ns := *s
+	ns.RollingSpec = ns.RollingSpec.Copy().(*RollingWindowProcedureSpec)
+	return &ns
+}
+
+// TriggerSpec implements plan.TriggerAwareProcedureSpec
+func (s *RollingAggregateProcedureSpec) TriggerSpec() plan.TriggerSpec {
+	return plan.NarrowTransformationTriggerSpec{}
+}
+
+// RollingAggregateRule replaces an aggregate or selector that follows
+// a rolling window with a rolling aggregate that updates the result
+// as rows enter and leave the window.
+type RollingAggregateRule struct{}
+
+func (r RollingAggregateRule) Name() string {
+	return "RollingAggregateRule"
+}
+
+func (r RollingAggregateRule) Pattern() plan.Pattern {
+	return plan.MultiSuccessorOneOf(
+		[]plan.ProcedureKind{SumKind, CountKind, MeanKind, MinKind, MaxKind},
+		plan.SingleSuccessor(RollingWindowKind),
+	)
+}
+
+func (r RollingAggregateRule) Rewrite(ctx context.Context, node plan.Node) (plan.Node, bool, error) {
+	rollingNode := node.Predecessors()[0]
+	rollingSpec := rollingNode.ProcedureSpec().(*RollingWindowProcedureSpec)
+	if rollingSpec.hopping() {
+		return node, false, nil
+	}
+
+	var column string
+	switch spec := node.ProcedureSpec().(type) {
+	case *SumProcedureSpec:
+		column = r.singleColumn(spec.Columns)
+	case *CountProcedureSpec:
+		column = r.singleColumn(spec.Columns)
+	case *MeanProcedureSpec:
+		column = r.singleColumn(spec.Columns)
+	case *MinProcedureSpec:
+		column = spec.Column
+	case *MaxProcedureSpec:
+		column = spec.Column
+	}
+	if column == "" {
+		return node, false, nil
+	}
+
+	newSpec := &RollingAggregateProcedureSpec{
+		RollingSpec:   rollingSpec,
+		AggregateKind: node.Kind(),
+		Column:        column,
+	}
+	newNode := plan.ReplacePhysicalNodes(ctx, node, rollingNode, RollingAggregateKind, newSpec)
+	return newNode, true, nil
+}
+
+func (r RollingAggregateRule) singleColumn(columns []string) string {
+	if len(columns) != 1 {
+		return ""
+	}
+	return columns[0]
+}
+
+func createRollingAggregateTransformation(id execute.DatasetID, mode execute.AccumulationMode, spec plan.ProcedureSpec, a execute.Administration) (execute.Transformation, execute.Dataset, error) {
+	s, ok := spec.(*RollingAggregateProcedureSpec)
+	if !ok {
+		return nil, nil, errors.Newf(codes.Internal, "invalid spec type %T", spec)
+	}
+	return NewRollingAggregateTransformation(id, s, a.StreamContext().Bounds(), a.Allocator())
+}
+
+func NewRollingAggregateTransformation(id execute.DatasetID, spec *RollingAggregateProcedureSpec, bounds *execute.Bounds, mem memory.Allocator) (execute.Transformation, execute.Dataset, error) {
+	if spec.RollingSpec.hopping() {
+		return nil, nil, errors.New(codes.Internal, "rolling aggregate does not support hopping windows")
+	}
+	switch spec.AggregateKind {
+	case SumKind, CountKind, MeanKind, MinKind, MaxKind:
+	default:
+		return nil, nil, errors.Newf(codes.Internal, "cannot use %q for rolling aggregate", spec.AggregateKind)
+	}
+
+	t, err := newRollingTransformation(id, spec.RollingSpec, bounds, mem)
+	if err != nil {
+		return nil, nil, err
+	}
+	t.newState = func(key flux.GroupKey, cols []flux.ColMeta) (rollingState, error) {
+		return newRollingAggregateState(t, spec, key, cols)
+	}
+	return execute.NewTransformationFromTransport(t), t.d, nil
+}
+
+// rollingAggregateState keeps the aggregate of the rows of the
+// window of a table. Sums and counts are updated as rows enter
+// and leave the window. Float sums are recomputed from the rows
+// of the window when removing a row may have made them inexact.
+// Minimums and maximums are kept in a monotonic deque of the rows
+// that may still be selected.
+type rollingAggregateState struct {
+	rollingBase
+	t      *rollingTransformation
+	kind   plan.ProcedureKind
+	idx    int
+	inType flux.ColType
+
+	// evicted is the number of rows that have left the window.
+	// Positions in the deque include the evicted rows.
+	evicted int
+	deque   []int
+
+	count int64
+	isum  int64
+	usum  uint64
+	fsum  float64
+
+	// fremoved is the number of rows removed from fsum
+	// since it was computed from the rows of the window.
+	fremoved int
+	fstale   bool
+}
+
+func newRollingAggregateState(t *rollingTransformation, spec *RollingAggregateProcedureSpec, key flux.GroupKey, cols []flux.ColMeta) (*rollingAggregateState, error) {
+	idx := execute.ColIdx(spec.Column, cols)
+	if idx < 0 {
+		return nil, errors.Newf(codes.FailedPrecondition, "column %q does not exist", spec.Column)
+	}
+	inType := cols[idx].Type
+
+	s := &rollingAggregateState{
+		t:      t,
+		kind:   spec.AggregateKind,
+		idx:    idx,
+		inType: inType,
+	}
+
+	var outType flux.ColType
+	switch s.kind {
+	case CountKind:
+		outType = flux.TInt
+	case MeanKind:
+		outType = flux.TFloat
+	default:
+		outType = inType
+	}
+	switch inType {
+	case flux.TInt, flux.TUInt, flux.TFloat:
+	case flux.TBool, flux.TString:
+		if s.kind != CountKind {
+			inType = flux.TInvalid
+		}
+	default:
+		inType = flux.TInvalid
+	}
+	if inType == flux.TInvalid {
+		if s.isSelector() {
+			return nil, errors.Newf(codes.Invalid, "unsupported selector type %v", cols[idx].Type)
+		}
+		return nil, errors.Newf(codes.FailedPrecondition, "unsupported aggregate column type %v", cols[idx].Type)
+	}
+
+	if s.isSelector() {
+		s.rollingBase = t.newBase(key, cols, true)
+		return s, nil
+	}
+	if key.HasCol(spec.Column) {
+		return nil, errors.New(codes.FailedPrecondition, "cannot aggregate columns that are part of the group key")
+	}
+	s.rollingBase = t.newBase(key, cols, false)
+	s.cols = append(s.cols[:len(s.cols):len(s.cols)], flux.ColMeta{Label: spec.Column, Type: outType})
+	return s, nil
+}
+
+func (s *rollingAggregateState) isSelector() bool {
+	return s.kind == MinKind || s.kind == MaxKind
+}
+
+func (s *rollingAggregateState) push(row rollingRow) {
+	s.rows = append(s.rows, row)
+	v := row.values[s.idx]
+	if v.IsNull() {
+		return
+	}
+	s.count++
+	switch s.kind {
+	case SumKind, MeanKind:
+		switch s.inType {
+		case flux.TInt:
+			s.isum += v.Int()
+			s.fsum += float64(v.Int())
+		case flux.TUInt:
+			s.usum += v.UInt()
+			s.fsum += float64(v.UInt())
+		case flux.TFloat:
+			s.fsum += v.Float()
+		}
+	case MinKind, MaxKind:
+		// Rows that come later and are not more extreme than the
+		// last row of the deque are kept so that ties select the
+		// first row.
+		for len(s.deque) > 0 && s.replaces(v, s.at(s.deque[len(s.deque)-1])) {
+			s.deque = s.deque[:len(s.deque)-1]
+		}
+		s.deque = append(s.deque, s.evicted+len(s.rows)-1)
+	}
+}
+
+func (s *rollingAggregateState) evict() {
+	v := s.rows[0].values[s.idx]
+	if !v.IsNull() {
+		s.count--
+		switch s.kind {
+		case SumKind, MeanKind:
+			switch s.inType {
+			case flux.TInt:
+				s.isum -= v.Int()
+				s.removeFloat(float64(v.Int()))
+			case flux.TUInt:
+				s.usum -= v.UInt()
+				s.removeFloat(float64(v.UInt()))
+			case flux.TFloat:
+				s.removeFloat(v.Float())
+			}
+		case MinKind, MaxKind:
+			if len(s.deque) > 0 && s.deque[0] == s.evicted {
+				s.deque = s.deque[1:]
+			}
+		}
+	}
+	s.rows[0] = rollingRow{}
+	s.rows = s.rows[1:]
+	s.evicted++
+}
+
+// removeFloat removes a value from the float sum. The sum is marked
+// to be recomputed when the value or the sum is not finite, when the
+// value is larger than what remains of the sum so the sum may have
+// lost its precision, and after as many rows as the window holds
+// have been removed to limit the rounding errors that build up.
+func (s *rollingAggregateState) removeFloat(f float64) {
+	s.fsum -= f
+	s.fremoved++
+	if math.IsInf(f, 0) || math.IsNaN(f) ||
+		math.IsInf(s.fsum, 0) || math.IsNaN(s.fsum) ||
+		math.Abs(f) > math.Abs(s.fsum) ||
+		s.fremoved >= len(s.rows) {
+		s.fstale = true
+	}
+}
+
+// floatSum returns the float sum of the window and
+// recomputes it from the rows of the window if needed.
+func (s *rollingAggregateState) floatSum() float64 {
+	if !s.fstale {
+		return s.fsum
+	}
+	s.fsum = 0
+	for _, row := range s.rows {
+		v := row.values[s.idx]
+		if v.IsNull() {
+			continue
+		}
+		switch s.inType {
+		case flux.TInt:
+			s.fsum += float64(v.Int())
+		case flux.TUInt:
+			s.fsum += float64(v.UInt())
+		case flux.TFloat:
+			s.fsum += v.Float()
+		}
+	}
+	s.fremoved, s.fstale = 0, false
+	return s.fsum
+}
+
+// at returns the value of the row at the position.
+func (s *rollingAggregateState) at(pos int) values.Value {
+	return s.rows[pos-s.evicted].values[s.idx]
+}
+
+// replaces reports whether v is strictly more extreme than w.
+func (s *rollingAggregateState) replaces(v, w values.Value) bool {
+	switch s.inType {
+	case flux.TInt:
+		if s.kind == MinKind {
+			return v.Int() < w.Int()
+		}
+		return v.Int() > w.Int()
+	case flux.TUInt:
+		if s.kind == MinKind {
+			return v.UInt() < w.UInt()
+		}
+		return v.UInt() > w.UInt()
+	case flux.TFloat:
+		if s.kind == MinKind {
+			return v.Float() < w.Float()
+		}
+		return v.Float() > w.Float()
+	}
+	return false
+}
+
+func (s *rollingAggregateState) emit(start, stop values.Time) error {
+	t := s.t
+	key := s.newKey(&t.spec, start, stop)
+
+	var row []values.Value
+	if s.isSelector() {
+		if len(s.deque) > 0 {
+			row = s.rows[s.deque[0]-s.evicted].values
+		}
+	} else {
+		row = make([]values.Value, len(s.cols))
+		for j, col := range s.keyCols {
+			row[j] = key.LabelValue(col.Label)
+		}
+		row[len(row)-1] = s.value()
+	}
+
+	buffer := arrow.TableBuffer{
+		GroupKey: key,
+		Columns:  s.cols,
+		Values:   make([]array.Array, len(s.cols)),
+	}
+	for j, col := range s.cols {
+		b := arrow.NewBuilder(col.Type, t.mem)
+		if row != nil {
+			var v values.Value
+			switch {
+			case col.Label == t.spec.StartColumn:
+				v = values.NewTime(start)
+			case col.Label == t.spec.StopColumn:
+				v = values.NewTime(stop)
+			case s.isSelector():
+				v = row[execute.ColIdx(col.Label, s.inCols)]
+			default:
+				v = row[j]
+			}
+			if err := arrow.AppendValue(b, v); err != nil {
+				b.Release()
+				buffer.Release()
+				return err
+			}
+		}
+		buffer.Values[j] = b.NewArray()
+		b.Release()
+	}
+	if err := t.d.Process(table.ChunkFromBuffer(buffer)); err != nil {
+		return err
+	}
+	return t.d.FlushKey(key)
+}
+
+// value returns the aggregate of the window.
+func (s *rollingAggregateState) value() values.Value {
+	switch s.kind {
+	case CountKind:
+		return values.NewInt(int64(len(s.rows)))
+	case MeanKind:
+		if s.count == 0 {
+			return values.Null
+		}
+		return values.NewFloat(s.floatSum() / float64(s.count))
+	}
+	if s.count == 0 {
+		return values.Null
+	}
+	switch s.inType {
+	case flux.TInt:
+		return values.NewInt(s.isum)
+	case flux.TUInt:
+		return values.NewUInt(s.usum)
+	default:
+		return values.NewFloat(s.floatSum())
+	}
+}
@@ -0,0 +1,405 @@
+package universe_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/dependency"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/execute/executetest"
+	"github.com/InfluxCommunity/flux/memory"
+	"github.com/InfluxCommunity/flux/plan"
+	"github.com/InfluxCommunity/flux/plan/plantest"
+	"github.com/InfluxCommunity/flux/stdlib/influxdata/influxdb"
+	"github.com/InfluxCommunity/flux/stdlib/universe"
+)
+
+func newRollingWindowSpec(n int64, period, every time.Duration) *universe.RollingWindowProcedureSpec {
+	return &universe.RollingWindowProcedureSpec{
+		N:           n,
+		Period:      flux.ConvertDuration(period),
+		Every:       flux.ConvertDuration(every),
+		TimeColumn:  execute.DefaultTimeColLabel,
+		StartColumn: execute.DefaultStartColLabel,
+		StopColumn:  execute.DefaultStopColLabel,
+	}
+}
+
+func rollingTestData() []flux.Table {
+	return []flux.Table{&executetest.Table{
+		ColMeta: []flux.ColMeta{
+			{Label: "_time", Type: flux.TTime},
+			{Label: "_value", Type: flux.TInt},
+		},
+		Data: [][]interface{}{
+			{execute.Time(1), int64(1)},
+			{execute.Time(2), int64(5)},
+			{execute.Time(2), int64(3)},
+			{execute.Time(4), nil},
+			{execute.Time(5), int64(2)},
+			{execute.Time(9), int64(4)},
+		},
+	}}
+}
+
+func rollingWindowTable(start, stop execute.Time, rows ...[]interface{}) *executetest.Table {
+	tbl := &executetest.Table{
+		KeyCols: []string{"_start", "_stop"},
+		ColMeta: []flux.ColMeta{
+			{Label: "_time", Type: flux.TTime},
+			{Label: "_value", Type: flux.TInt},
+			{Label: "_start", Type: flux.TTime},
+			{Label: "_stop", Type: flux.TTime},
+		},
+	}
+	for _, row := range rows {
+		tbl.Data = append(tbl.Data, append(row, start, stop))
+	}
+	return tbl
+}
+
+func TestRollingWindow_Process(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		spec    *universe.RollingWindowProcedureSpec
+		bounds  *execute.Bounds
+		data    []flux.Table
+		want    []*executetest.Table
+		wantErr error
+	}{
+		{
+			// Rows with the same time end a single window.
+			name: "rows",
+			spec: newRollingWindowSpec(2, 0, 0),
+			data: rollingTestData(),
+			want: []*executetest.Table{
+				rollingWindowTable(2, 2,
+					[]interface{}{execute.Time(2), int64(5)},
+					[]interface{}{execute.Time(2), int64(3)},
+				),
+				rollingWindowTable(2, 4,
+					[]interface{}{execute.Time(2), int64(3)},
+					[]interface{}{execute.Time(4), nil},
+				),
+				rollingWindowTable(4, 5,
+					[]interface{}{execute.Time(4), nil},
+					[]interface{}{execute.Time(5), int64(2)},
+				),
+				rollingWindowTable(5, 9,
+					[]interface{}{execute.Time(5), int64(2)},
+					[]interface{}{execute.Time(9), int64(4)},
+				),
+			},
+		},
+		{
+			name: "period",
+			spec: newRollingWindowSpec(0, 3, 0),
+			data: rollingTestData(),
+			want: []*executetest.Table{
+				rollingWindowTable(-2, 1,
+					[]interface{}{execute.Time(1), int64(1)},
+				),
+				rollingWindowTable(-1, 2,
+					[]interface{}{execute.Time(1), int64(1)},
+					[]interface{}{execute.Time(2), int64(5)},
+					[]interface{}{execute.Time(2), int64(3)},
+				),
+				rollingWindowTable(1, 4,
+					[]interface{}{execute.Time(2), int64(5)},
+					[]interface{}{execute.Time(2), int64(3)},
+					[]interface{}{execute.Time(4), nil},
+				),
+				rollingWindowTable(2, 5,
+					[]interface{}{execute.Time(4), nil},
+					[]interface{}{execute.Time(5), int64(2)},
+				),
+				rollingWindowTable(6, 9,
+					[]interface{}{execute.Time(9), int64(4)},
+				),
+			},
+		},
+		{
+			// Hopping windows are limited to the bounds
+			// and empty windows are skipped.
+			name:   "every",
+			spec:   newRollingWindowSpec(0, 4, 2),
+			bounds: &execute.Bounds{Start: 0, Stop: 10},
+			data:   rollingTestData(),
+			want: []*executetest.Table{
+				rollingWindowTable(0, 2,
+					[]interface{}{execute.Time(1), int64(1)},
+				),
+				rollingWindowTable(0, 4,
+					[]interface{}{execute.Time(1), int64(1)},
+					[]interface{}{execute.Time(2), int64(5)},
+					[]interface{}{execute.Time(2), int64(3)},
+				),
+				rollingWindowTable(2, 6,
+					[]interface{}{execute.Time(2), int64(5)},
+					[]interface{}{execute.Time(2), int64(3)},
+					[]interface{}{execute.Time(4), nil},
+					[]interface{}{execute.Time(5), int64(2)},
+				),
+				rollingWindowTable(4, 8,
+					[]interface{}{execute.Time(4), nil},
+					[]interface{}{execute.Time(5), int64(2)},
+				),
+				rollingWindowTable(6, 10,
+					[]interface{}{execute.Time(9), int64(4)},
+				),
+				rollingWindowTable(8, 10,
+					[]interface{}{execute.Time(9), int64(4)},
+				),
+			},
+		},
+		{
+			name: "unsorted",
+			spec: newRollingWindowSpec(2, 0, 0),
+			data: []flux.Table{&executetest.Table{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TInt},
+				},
+				Data: [][]interface{}{
+					{execute.Time(2), int64(1)},
+					{execute.Time(1), int64(2)},
+				},
+			}},
+			wantErr: errors.New(`rolling requires rows sorted by "_time", found 1970-01-01T00:00:00.000000001Z after 1970-01-01T00:00:00.000000002Z; use sort() before rolling()`),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			executetest.ProcessTestHelper2(
+				t,
+				tc.data,
+				tc.want,
+				tc.wantErr,
+				func(id execute.DatasetID, alloc memory.Allocator) (execute.Transformation, execute.Dataset) {
+					tr, d, err := universe.NewRollingWindowTransformation(id, tc.spec, tc.bounds, alloc)
+					if err != nil {
+						t.Fatal(err)
+					}
+					return tr, d
+				},
+			)
+		})
+	}
+}
+
+func rollingAggregateTable(typ flux.ColType, start, stop execute.Time, v interface{}) *executetest.Table {
+	return &executetest.Table{
+		KeyCols: []string{"_start", "_stop"},
+		ColMeta: []flux.ColMeta{
+			{Label: "_start", Type: flux.TTime},
+			{Label: "_stop", Type: flux.TTime},
+			{Label: "_value", Type: typ},
+		},
+		Data: [][]interface{}{{start, stop, v}},
+	}
+}
+
+func TestRollingAggregate_Process(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		spec *universe.RollingAggregateProcedureSpec
+		want []*executetest.Table
+	}{
+		{
+			name: "sum rows",
+			spec: &universe.RollingAggregateProcedureSpec{
+				RollingSpec:   newRollingWindowSpec(2, 0, 0),
+				AggregateKind: universe.SumKind,
+				Column:        execute.DefaultValueColLabel,
+			},
+			want: []*executetest.Table{
+				rollingAggregateTable(flux.TInt, 2, 2, int64(8)),
+				rollingAggregateTable(flux.TInt, 2, 4, int64(3)),
+				rollingAggregateTable(flux.TInt, 4, 5, int64(2)),
+				rollingAggregateTable(flux.TInt, 5, 9, int64(6)),
+			},
+		},
+		{
+			name: "count period",
+			spec: &universe.RollingAggregateProcedureSpec{
+				RollingSpec:   newRollingWindowSpec(0, 3, 0),
+				AggregateKind: universe.CountKind,
+				Column:        execute.DefaultValueColLabel,
+			},
+			want: []*executetest.Table{
+				rollingAggregateTable(flux.TInt, -2, 1, int64(1)),
+				rollingAggregateTable(flux.TInt, -1, 2, int64(3)),
+				rollingAggregateTable(flux.TInt, 1, 4, int64(3)),
+				rollingAggregateTable(flux.TInt, 2, 5, int64(2)),
+				rollingAggregateTable(flux.TInt, 6, 9, int64(1)),
+			},
+		},
+		{
+			name: "mean period",
+			spec: &universe.RollingAggregateProcedureSpec{
+				RollingSpec:   newRollingWindowSpec(0, 3, 0),
+				AggregateKind: universe.MeanKind,
+				Column:        execute.DefaultValueColLabel,
+			},
+			want: []*executetest.Table{
+				rollingAggregateTable(flux.TFloat, -2, 1, 1.0),
+				rollingAggregateTable(flux.TFloat, -1, 2, 3.0),
+				rollingAggregateTable(flux.TFloat, 1, 4, 4.0),
+				rollingAggregateTable(flux.TFloat, 2, 5, 2.0),
+				rollingAggregateTable(flux.TFloat, 6, 9, 4.0),
+			},
+		},
+		{
+			name: "max period",
+			spec: &universe.RollingAggregateProcedureSpec{
+				RollingSpec:   newRollingWindowSpec(0, 3, 0),
+				AggregateKind: universe.MaxKind,
+				Column:        execute.DefaultValueColLabel,
+			},
+			want: []*executetest.Table{
+				rollingWindowTable(-2, 1, []interface{}{execute.Time(1), int64(1)}),
+				rollingWindowTable(-1, 2, []interface{}{execute.Time(2), int64(5)}),
+				rollingWindowTable(1, 4, []interface{}{execute.Time(2), int64(5)}),
+				rollingWindowTable(2, 5, []interface{}{execute.Time(5), int64(2)}),
+				rollingWindowTable(6, 9, []interface{}{execute.Time(9), int64(4)}),
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			executetest.ProcessTestHelper2(
+				t,
+				rollingTestData(),
+				tc.want,
+				nil,
+				func(id execute.DatasetID, alloc memory.Allocator) (execute.Transformation, execute.Dataset) {
+					tr, d, err := universe.NewRollingAggregateTransformation(id, tc.spec, nil, alloc)
+					if err != nil {
+						t.Fatal(err)
+					}
+					return tr, d
+				},
+			)
+		})
+	}
+}
+
+func TestRollingAggregate_Process_FloatSum(t *testing.T) {
+	// The sums of the windows must match the sums of the rows of each
+	// window even when rows that leave the window are much larger than
+	// the rest or are not finite.
+	data := []flux.Table{&executetest.Table{
+		ColMeta: []flux.ColMeta{
+			{Label: "_time", Type: flux.TTime},
+			{Label: "_value", Type: flux.TFloat},
+		},
+		Data: [][]interface{}{
+			{execute.Time(1), 1e20},
+			{execute.Time(2), 1.0},
+			{execute.Time(3), 1.0},
+			{execute.Time(4), math.Inf(1)},
+			{execute.Time(5), 1.0},
+			{execute.Time(6), 2.0},
+		},
+	}}
+	spec := &universe.RollingAggregateProcedureSpec{
+		RollingSpec:   newRollingWindowSpec(2, 0, 0),
+		AggregateKind: universe.SumKind,
+		Column:        execute.DefaultValueColLabel,
+	}
+	want := []*executetest.Table{
+		rollingAggregateTable(flux.TFloat, 1, 2, 1e20),
+		rollingAggregateTable(flux.TFloat, 2, 3, 2.0),
+		rollingAggregateTable(flux.TFloat, 3, 4, math.Inf(1)),
+		rollingAggregateTable(flux.TFloat, 4, 5, math.Inf(1)),
+		rollingAggregateTable(flux.TFloat, 5, 6, 3.0),
+	}
+	executetest.ProcessTestHelper2(
+		t,
+		data,
+		want,
+		nil,
+		func(id execute.DatasetID, alloc memory.Allocator) (execute.Transformation, execute.Dataset) {
+			tr, d, err := universe.NewRollingAggregateTransformation(id, spec, nil, alloc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return tr, d
+		},
+	)
+}
+
+func TestRollingAggregateRule(t *testing.T) {
+	ctx, deps := dependency.Inject(context.Background(), executetest.NewTestExecuteDependencies())
+	defer deps.Finish()
+
+	from := &influxdb.FromProcedureSpec{
+		Bucket: influxdb.NameOrID{Name: "testbucket"},
+	}
+	rolling := newRollingWindowSpec(10, 0, 0)
+	hopping := newRollingWindowSpec(0, time.Minute, 30*time.Second)
+	sum := &universe.SumProcedureSpec{
+		SimpleAggregateConfig: execute.SimpleAggregateConfig{
+			Columns: []string{execute.DefaultValueColLabel},
+		},
+	}
+
+	tests := []plantest.RuleTestCase{
+		{
+			Name:    "Default",
+			Context: ctx,
+			Rules:   []plan.Rule{universe.RollingAggregateRule{}},
+			Before: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreatePhysicalNode("from0", from),
+					plan.CreatePhysicalNode("rolling1", rolling),
+					plan.CreatePhysicalNode("sum2", sum),
+				},
+				Edges: [][2]int{
+					{0, 1},
+					{1, 2},
+				},
+			},
+			After: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreatePhysicalNode("from0", from),
+					plan.CreatePhysicalNode("merged_rolling1_sum2", &universe.RollingAggregateProcedureSpec{
+						RollingSpec:   rolling,
+						AggregateKind: universe.SumKind,
+						Column:        execute.DefaultValueColLabel,
+					}),
+				},
+				Edges: [][2]int{
+					{0, 1},
+				},
+			},
+			SkipValidation: true,
+		},
+		{
+			Name:    "Hopping",
+			Context: ctx,
+			Rules:   []plan.Rule{universe.RollingAggregateRule{}},
+			Before: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreatePhysicalNode("from0", from),
+					plan.CreatePhysicalNode("rolling1", hopping),
+					plan.CreatePhysicalNode("sum2", sum),
+				},
+				Edges: [][2]int{
+					{0, 1},
+					{1, 2},
+				},
+			},
+			NoChange:       true,
+			SkipValidation: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			plantest.PhysicalRuleTestHelper(t, &tc)
+		})
+	}
+}
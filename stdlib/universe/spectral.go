@@ -0,0 +1,79 @@
+package universe
+
+import (
+	"math"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/array"
+	"github.com/InfluxCommunity/flux/arrow"
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/execute/table"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/apache/arrow/go/v7/arrow/memory"
+)
+
+// lagColLabel is the label of the lag column of the correlation functions.
+const lagColLabel = "lag"
+
+// appendFloats appends the values of a numeric column of the chunk to vs.
+// The spectral functions require a value in every row.
+func appendFloats(fn string, chunk table.Chunk, label string, vs []float64) ([]float64, error) {
+	idx := chunk.Index(label)
+	if idx < 0 {
+		return nil, errors.Newf(codes.FailedPrecondition, "cannot find column %s", label)
+	}
+	arr := chunk.Values(idx)
+	if arr.NullN() > 0 {
+		return nil, errors.Newf(codes.FailedPrecondition, "%s requires a value in every row; fill null values before %s()", fn, fn)
+	}
+	switch arr := arr.(type) {
+	case *array.Float:
+		vs = append(vs, arr.Float64Values()...)
+	case *array.Int:
+		for i, n := 0, arr.Len(); i < n; i++ {
+			vs = append(vs, float64(arr.Value(i)))
+		}
+	case *array.Uint:
+		for i, n := 0, arr.Len(); i < n; i++ {
+			vs = append(vs, float64(arr.Value(i)))
+		}
+	default:
+		return nil, errors.Newf(codes.FailedPrecondition, "%s can work only on numerical types, got %s", fn, chunk.Col(idx).Type)
+	}
+	return vs, nil
+}
+
+// newFloatArray returns the values as an array with nulls in place of NaN.
+func newFloatArray(vs []float64, mem memory.Allocator) array.Array {
+	b := array.NewFloatBuilder(mem)
+	b.Resize(len(vs))
+	for _, v := range vs {
+		if math.IsNaN(v) {
+			b.AppendNull()
+			continue
+		}
+		b.Append(v)
+	}
+	return b.NewArray()
+}
+
+// keyedChunk returns a chunk with the columns of the group key
+// followed by the columns and their values.
+func keyedChunk(key flux.GroupKey, cols []flux.ColMeta, vs []array.Array, mem memory.Allocator) table.Chunk {
+	n := 0
+	if len(vs) > 0 {
+		n = vs[0].Len()
+	}
+	buffer := arrow.TableBuffer{
+		GroupKey: key,
+		Columns:  make([]flux.ColMeta, 0, len(key.Cols())+len(cols)),
+		Values:   make([]array.Array, 0, len(key.Cols())+len(cols)),
+	}
+	for j, col := range key.Cols() {
+		buffer.Columns = append(buffer.Columns, col)
+		buffer.Values = append(buffer.Values, arrow.Repeat(col.Type, key.Value(j), n, mem))
+	}
+	buffer.Columns = append(buffer.Columns, cols...)
+	buffer.Values = append(buffer.Values, vs...)
+	return table.ChunkFromBuffer(buffer)
+}
@@ -0,0 +1,204 @@
+package universe
+
+import (
+	"math"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/plan"
+	"github.com/InfluxCommunity/flux/runtime"
+	"github.com/InfluxCommunity/flux/stdlib/universe/forecast"
+)
+
+const STLKind = "stl"
+
+const (
+	stlTrendColLabel    = "trend"
+	stlSeasonalColLabel = "seasonal"
+	stlResidualColLabel = "residual"
+)
+
+type STLOpSpec struct {
+	Period   int64  `json:"period"`
+	Seasonal int64  `json:"seasonal"`
+	Robust   bool   `json:"robust"`
+	Column   string `json:"column"`
+}
+
+func init() {
+	stlSignature := runtime.MustLookupBuiltinType("universe", "stl")
+	runtime.RegisterPackageValue("universe", STLKind, flux.MustValue(flux.FunctionValue(STLKind, createSTLOpSpec, stlSignature)))
+	plan.RegisterProcedureSpec(STLKind, newSTLProcedure, STLKind)
+	execute.RegisterTransformation(STLKind, createSTLTransformation)
+}
+
+func createSTLOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
+	if err := a.AddParentFromArgs(args); err != nil {
+		return nil, err
+	}
+	spec := &STLOpSpec{
+		Seasonal: forecast.DefaultSeasonalSpan,
+		Column:   execute.DefaultValueColLabel,
+	}
+	p, err := args.GetRequiredInt("period")
+	if err != nil {
+		return nil, err
+	}
+	if p < 2 {
+		return nil, errors.Newf(codes.Invalid, "period must be at least 2, got %d", p)
+	}
+	spec.Period = p
+
+	if s, ok, err := args.GetInt("seasonal"); err != nil {
+		return nil, err
+	} else if ok {
+		if s < 3 || s%2 == 0 {
+			return nil, errors.Newf(codes.Invalid, "seasonal must be an odd number of at least 3, got %d", s)
+		}
+		spec.Seasonal = s
+	}
+	if r, ok, err := args.GetBool("robust"); err != nil {
+		return nil, err
+	} else if ok {
+		spec.Robust = r
+	}
+	if col, ok, err := args.GetString("column"); err != nil {
+		return nil, err
+	} else if ok {
+		spec.Column = col
+	}
+	return spec, nil
+}
+
+func (s *STLOpSpec) Kind() flux.OperationKind {
+	return STLKind
+}
+
+type STLProcedureSpec struct {
+	plan.DefaultCost
+	Period   int64
+	Seasonal int64
+	Robust   bool
+	Column   string
+}
+
+func newSTLProcedure(qs flux.OperationSpec, pa plan.Administration) (plan.ProcedureSpec, error) {
+	spec, ok := qs.(*STLOpSpec)
+	if !ok {
+		return nil, errors.Newf(codes.Internal, "invalid spec type %T", qs)
+	}
+	return &STLProcedureSpec{
+		Period:   spec.Period,
+		Seasonal: spec.Seasonal,
+		Robust:   spec.Robust,
+		Column:   spec.Column,
+	}, nil
+}
+
+func (s *STLProcedureSpec) Kind() plan.ProcedureKind {
+	return STLKind
+}
+
+func (s *STLProcedureSpec) Copy() plan.ProcedureSpec {
+	ns := new(STLProcedureSpec)
+	*ns = *s
+	return ns
+}
+
+// TriggerSpec implements plan.TriggerAwareProcedureSpec
+func (s *STLProcedureSpec) TriggerSpec() plan.TriggerSpec {
+	return plan.NarrowTransformationTriggerSpec{}
+}
+
+func createSTLTransformation(id execute.DatasetID, mode execute.AccumulationMode, spec plan.ProcedureSpec, a execute.Administration) (execute.Transformation, execute.Dataset, error) {
+	s, ok := spec.(*STLProcedureSpec)
+	if !ok {
+		return nil, nil, errors.Newf(codes.Internal, "invalid spec type %T", spec)
+	}
+	cache := execute.NewTableBuilderCache(a.Allocator())
+	d := execute.NewDataset(id, mode, cache)
+	t := NewSTLTransformation(d, cache, s)
+	return t, d, nil
+}
+
+type stlTransformation struct {
+	execute.ExecutionNode
+	d     execute.Dataset
+	cache execute.TableBuilderCache
+	spec  STLProcedureSpec
+}
+
+func NewSTLTransformation(d execute.Dataset, cache execute.TableBuilderCache, spec *STLProcedureSpec) *stlTransformation {
+	return &stlTransformation{
+		d:     d,
+		cache: cache,
+		spec:  *spec,
+	}
+}
+
+func (t *stlTransformation) Process(id execute.DatasetID, tbl flux.Table) error {
+	builder, created := t.cache.TableBuilder(tbl.Key())
+	if !created {
+		return errors.Newf(codes.FailedPrecondition, "stl found duplicate table with key: %v", tbl.Key())
+	}
+	colIdx, err := seriesColumn("stl", tbl, t.spec.Column)
+	if err != nil {
+		return err
+	}
+
+	if err := execute.AddTableCols(tbl, builder); err != nil {
+		return err
+	}
+	var componentIdxs [3]int
+	for i, label := range []string{stlTrendColLabel, stlSeasonalColLabel, stlResidualColLabel} {
+		idx, err := builder.AddCol(flux.ColMeta{Label: label, Type: flux.TFloat})
+		if err != nil {
+			return err
+		}
+		componentIdxs[i] = idx
+	}
+
+	series, err := readSeries(tbl, builder, colIdx, true, -1)
+	if err != nil {
+		return err
+	}
+	y := series.values
+	if len(y) == 0 {
+		return nil
+	}
+	period := int(t.spec.Period)
+	if len(y) < 2*period {
+		return errors.Newf(codes.FailedPrecondition, "stl requires at least two periods of %d rows, got %d rows", period, len(y))
+	}
+	for _, v := range y {
+		if math.IsNaN(v) {
+			return errors.New(codes.FailedPrecondition, "stl requires a value in every row; fill null values before stl()")
+		}
+	}
+
+	d := forecast.STL(y, period, int(t.spec.Seasonal), t.spec.Robust)
+	for i, component := range [][]float64{d.Trend, d.Seasonal, d.Residual} {
+		if err := appendSeries(builder, componentIdxs[i], component); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *stlTransformation) RetractTable(id execute.DatasetID, key flux.GroupKey) error {
+	return t.d.RetractTable(key)
+}
+
+func (t *stlTransformation) UpdateWatermark(id execute.DatasetID, mark execute.Time) error {
+	return t.d.UpdateWatermark(mark)
+}
+
+func (t *stlTransformation) UpdateProcessingTime(id execute.DatasetID, pt execute.Time) error {
+	return t.d.UpdateProcessingTime(pt)
+}
+
+func (t *stlTransformation) Finish(id execute.DatasetID, err error) {
+	t.d.Finish(err)
+}
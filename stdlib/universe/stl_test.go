@@ -0,0 +1,206 @@
+package universe_test
+
+import (
+	"testing"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/execute/executetest"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/internal/operation"
+	"github.com/InfluxCommunity/flux/querytest"
+	"github.com/InfluxCommunity/flux/stdlib/influxdata/influxdb"
+	"github.com/InfluxCommunity/flux/stdlib/universe"
+)
+
+func TestSTL_NewQuery(t *testing.T) {
+	tests := []querytest.NewQueryTestCase{
+		{
+			Name: "stl defaults",
+			Raw:  `from(bucket:"mydb") |> stl(period: 24)`,
+			Want: &operation.Spec{
+				Operations: []*operation.Node{
+					{
+						ID: "from0",
+						Spec: &influxdb.FromOpSpec{
+							Bucket: influxdb.NameOrID{Name: "mydb"},
+						},
+					},
+					{
+						ID: "stl1",
+						Spec: &universe.STLOpSpec{
+							Period:   24,
+							Seasonal: 7,
+							Column:   execute.DefaultValueColLabel,
+						},
+					},
+				},
+				Edges: []operation.Edge{
+					{Parent: "from0", Child: "stl1"},
+				},
+			},
+		},
+		{
+			Name: "stl no defaults",
+			Raw:  `from(bucket:"mydb") |> stl(period: 24, seasonal: 13, robust: true, column: "v")`,
+			Want: &operation.Spec{
+				Operations: []*operation.Node{
+					{
+						ID: "from0",
+						Spec: &influxdb.FromOpSpec{
+							Bucket: influxdb.NameOrID{Name: "mydb"},
+						},
+					},
+					{
+						ID: "stl1",
+						Spec: &universe.STLOpSpec{
+							Period:   24,
+							Seasonal: 13,
+							Robust:   true,
+							Column:   "v",
+						},
+					},
+				},
+				Edges: []operation.Edge{
+					{Parent: "from0", Child: "stl1"},
+				},
+			},
+		},
+		{
+			Name:    "period too small",
+			Raw:     `from(bucket:"mydb") |> stl(period: 1)`,
+			WantErr: true,
+		},
+		{
+			Name:    "even seasonal span",
+			Raw:     `from(bucket:"mydb") |> stl(period: 24, seasonal: 8)`,
+			WantErr: true,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			querytest.NewQueryTestHelper(t, tc)
+		})
+	}
+}
+
+func TestSTL_Process(t *testing.T) {
+	testCases := []struct {
+		name    string
+		spec    *universe.STLProcedureSpec
+		data    []flux.Table
+		want    []*executetest.Table
+		wantErr error
+	}{
+		{
+			name: "trend and season",
+			spec: &universe.STLProcedureSpec{
+				Period:   4,
+				Seasonal: 7,
+				Column:   "_value",
+			},
+			data: []flux.Table{&executetest.Table{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TInt},
+				},
+				Data: [][]interface{}{
+					{execute.Time(10), int64(13)},
+					{execute.Time(20), int64(12)},
+					{execute.Time(30), int64(10)},
+					{execute.Time(40), int64(11)},
+					{execute.Time(50), int64(17)},
+					{execute.Time(60), int64(16)},
+					{execute.Time(70), int64(14)},
+					{execute.Time(80), int64(15)},
+					{execute.Time(90), int64(21)},
+					{execute.Time(100), int64(20)},
+					{execute.Time(110), int64(18)},
+					{execute.Time(120), int64(19)},
+				},
+			}},
+			want: []*executetest.Table{{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TInt},
+					{Label: "trend", Type: flux.TFloat},
+					{Label: "seasonal", Type: flux.TFloat},
+					{Label: "residual", Type: flux.TFloat},
+				},
+				Data: [][]interface{}{
+					{execute.Time(10), int64(13), 10.0, 3.0, 0.0},
+					{execute.Time(20), int64(12), 11.0, 1.0, 0.0},
+					{execute.Time(30), int64(10), 12.0, -2.0, 0.0},
+					{execute.Time(40), int64(11), 13.0, -2.0, 0.0},
+					{execute.Time(50), int64(17), 14.0, 3.0, 0.0},
+					{execute.Time(60), int64(16), 15.0, 1.0, 0.0},
+					{execute.Time(70), int64(14), 16.0, -2.0, 0.0},
+					{execute.Time(80), int64(15), 17.0, -2.0, 0.0},
+					{execute.Time(90), int64(21), 18.0, 3.0, 0.0},
+					{execute.Time(100), int64(20), 19.0, 1.0, 0.0},
+					{execute.Time(110), int64(18), 20.0, -2.0, 0.0},
+					{execute.Time(120), int64(19), 21.0, -2.0, 0.0},
+				},
+			}},
+		},
+		{
+			name: "null values",
+			spec: &universe.STLProcedureSpec{
+				Period:   2,
+				Seasonal: 7,
+				Column:   "_value",
+			},
+			data: []flux.Table{&executetest.Table{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TFloat},
+				},
+				Data: [][]interface{}{
+					{execute.Time(10), 1.0},
+					{execute.Time(20), nil},
+					{execute.Time(30), 3.0},
+					{execute.Time(40), 4.0},
+				},
+			}},
+			wantErr: errors.New(codes.FailedPrecondition, "stl requires a value in every row; fill null values before stl()"),
+		},
+		{
+			name: "less than two periods",
+			spec: &universe.STLProcedureSpec{
+				Period:   4,
+				Seasonal: 7,
+				Column:   "_value",
+			},
+			data: []flux.Table{&executetest.Table{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TFloat},
+				},
+				Data: [][]interface{}{
+					{execute.Time(10), 1.0},
+					{execute.Time(20), 2.0},
+					{execute.Time(30), 3.0},
+				},
+			}},
+			wantErr: errors.New(codes.FailedPrecondition, "stl requires at least two periods of 4 rows, got 3 rows"),
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			executetest.ProcessTestHelper(
+				t,
+				tc.data,
+				tc.want,
+				tc.wantErr,
+				func(d execute.Dataset, c execute.TableBuilderCache) execute.Transformation {
+					return universe.NewSTLTransformation(d, c, tc.spec)
+				},
+				floatOptions,
+			)
+		})
+	}
+}
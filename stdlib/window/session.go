@@ -0,0 +1,415 @@
+package window
+
+import (
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/array"
+	"github.com/InfluxCommunity/flux/arrow"
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/execute/table"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/plan"
+	"github.com/InfluxCommunity/flux/runtime"
+	"github.com/InfluxCommunity/flux/values"
+	"github.com/apache/arrow/go/v7/arrow/memory"
+)
+
+const (
+	pkgpath     = "window"
+	SessionKind = pkgpath + ".session"
+)
+
+type SessionOpSpec struct {
+	Gap         flux.Duration `json:"gap"`
+	MaxDuration flux.Duration `json:"maxDuration"`
+	TimeColumn  string        `json:"timeColumn"`
+	StartColumn string        `json:"startColumn"`
+	StopColumn  string        `json:"stopColumn"`
+}
+
+func init() {
+	sessionSignature := runtime.MustLookupBuiltinType(pkgpath, "session")
+	runtime.RegisterPackageValue(pkgpath, "session", flux.MustValue(flux.FunctionValue("session", createSessionOpSpec, sessionSignature)))
+	plan.RegisterProcedureSpec(SessionKind, newSessionProcedure, SessionKind)
+	execute.RegisterTransformation(SessionKind, createSessionTransformation)
+}
+
+func createSessionOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
+	if err := a.AddParentFromArgs(args); err != nil {
+		return nil, err
+	}
+
+	spec := &SessionOpSpec{
+		TimeColumn:  execute.DefaultTimeColLabel,
+		StartColumn: execute.DefaultStartColLabel,
+		StopColumn:  execute.DefaultStopColLabel,
+	}
+
+	gap, err := args.GetRequiredDuration("gap")
+	if err != nil {
+		return nil, err
+	}
+	if !gap.IsPositive() {
+		return nil, errors.Newf(codes.Invalid, "gap must be positive, got %v", gap)
+	}
+	spec.Gap = gap
+
+	if d, ok, err := args.GetDuration("maxDuration"); err != nil {
+		return nil, err
+	} else if ok {
+		if d.IsNegative() {
+			return nil, errors.Newf(codes.Invalid, "maxDuration must not be negative, got %v", d)
+		}
+		spec.MaxDuration = d
+	}
+
+	if label, ok, err := args.GetString("timeColumn"); err != nil {
+		return nil, err
+	} else if ok {
+		spec.TimeColumn = label
+	}
+	if label, ok, err := args.GetString("startColumn"); err != nil {
+		return nil, err
+	} else if ok {
+		spec.StartColumn = label
+	}
+	if label, ok, err := args.GetString("stopColumn"); err != nil {
+		return nil, err
+	} else if ok {
+		spec.StopColumn = label
+	}
+	return spec, nil
+}
+
+func (s *SessionOpSpec) Kind() flux.OperationKind {
+	return SessionKind
+}
+
+type SessionProcedureSpec struct {
+	plan.DefaultCost
+	Gap         flux.Duration
+	MaxDuration flux.Duration
+	TimeColumn  string
+	StartColumn string
+	StopColumn  string
+}
+
+func newSessionProcedure(qs flux.OperationSpec, pa plan.Administration) (plan.ProcedureSpec, error) {
+	spec, ok := qs.(*SessionOpSpec)
+	if !ok {
+		return nil, errors.Newf(codes.Internal, "invalid spec type %T", qs)
+	}
+	return &SessionProcedureSpec{
+		Gap:         spec.Gap,
+		MaxDuration: spec.MaxDuration,
+		TimeColumn:  spec.TimeColumn,
+		StartColumn: spec.StartColumn,
+		StopColumn:  spec.StopColumn,
+	}, nil
+}
+
+func (s *SessionProcedureSpec) Kind() plan.ProcedureKind {
+	return SessionKind
+}
+
+func (s *SessionProcedureSpec) Copy() plan.ProcedureSpec {
+	ns := new(SessionProcedureSpec)
+	*ns = *s
+	return ns
+}
+
+// TriggerSpec implements plan.TriggerAwareProcedureSpec
+func (s *SessionProcedureSpec) TriggerSpec() plan.TriggerSpec {
+	return plan.NarrowTransformationTriggerSpec{}
+}
+
+func createSessionTransformation(id execute.DatasetID, mode execute.AccumulationMode, spec plan.ProcedureSpec, a execute.Administration) (execute.Transformation, execute.Dataset, error) {
+	s, ok := spec.(*SessionProcedureSpec)
+	if !ok {
+		return nil, nil, errors.Newf(codes.Internal, "invalid spec type %T", spec)
+	}
+	return NewSessionTransformation(id, s, a.StreamContext().Bounds(), a.Allocator())
+}
+
+// sessionTransformation assigns the rows of each table to sessions.
+//
+// The rows of a session are only known once the session ends,
+// so the rows of the open session of each table are retained
+// until a row after the gap arrives or the table is flushed.
+// Each session is then sent as its own table and flushed.
+type sessionTransformation struct {
+	d      *execute.TransportDataset
+	spec   SessionProcedureSpec
+	bounds *execute.Bounds
+	mem    memory.Allocator
+}
+
+func NewSessionTransformation(id execute.DatasetID, spec *SessionProcedureSpec, bounds *execute.Bounds, mem memory.Allocator) (execute.Transformation, execute.Dataset, error) {
+	t := &sessionTransformation{
+		d:      execute.NewTransportDataset(id, mem),
+		spec:   *spec,
+		bounds: bounds,
+		mem:    mem,
+	}
+	return execute.NewTransformationFromTransport(t), t.d, nil
+}
+
+func (t *sessionTransformation) ProcessMessage(m execute.Message) error {
+	defer m.Ack()
+
+	switch m := m.(type) {
+	case execute.FinishMsg:
+		err := m.Error()
+		_ = t.d.Range(func(key flux.GroupKey, value interface{}) error {
+			state := value.(*sessionState)
+			if err == nil {
+				err = t.closeSession(state)
+			}
+			state.release()
+			return nil
+		})
+		t.d.Finish(err)
+		return nil
+	case execute.ProcessChunkMsg:
+		return t.processChunk(m.TableChunk())
+	case execute.FlushKeyMsg:
+		v, ok := t.d.Delete(m.Key())
+		if !ok {
+			return nil
+		}
+		state := v.(*sessionState)
+		defer state.release()
+		return t.closeSession(state)
+	case execute.ProcessMsg:
+		panic("unreachable")
+	}
+	return nil
+}
+
+func (t *sessionTransformation) processChunk(chunk table.Chunk) error {
+	timeIdx := chunk.Index(t.spec.TimeColumn)
+	if timeIdx < 0 {
+		return errors.Newf(codes.FailedPrecondition, "no time column: %s", t.spec.TimeColumn)
+	}
+	if typ := chunk.Col(timeIdx).Type; typ != flux.TTime {
+		return errors.Newf(codes.FailedPrecondition, "time column is not a time value: %s", typ)
+	}
+
+	var state *sessionState
+	if v, ok := t.d.Lookup(chunk.Key()); ok {
+		state = v.(*sessionState)
+		if !equalCols(state.inCols, chunk.Cols()) {
+			return errors.Newf(codes.FailedPrecondition, "schema collision detected in table %v", chunk.Key())
+		}
+	} else {
+		state = t.newSessionState(chunk.Key(), chunk.Cols())
+		t.d.Set(chunk.Key(), state)
+	}
+
+	ts := chunk.Ints(timeIdx)
+	from := 0
+	for i, n := 0, chunk.Len(); i < n; i++ {
+		if ts.IsNull(i) {
+			state.appendRows(chunk, from, i)
+			from = i + 1
+			continue
+		}
+		tm := values.Time(ts.Value(i))
+		if state.open {
+			if tm < state.last {
+				return errors.Newf(codes.FailedPrecondition,
+					"window.session requires rows sorted by %q, found %v after %v; use sort() before window.session()",
+					t.spec.TimeColumn, tm, state.last,
+				)
+			}
+			if t.ends(state, tm) {
+				state.appendRows(chunk, from, i)
+				from = i
+				if err := t.closeSession(state); err != nil {
+					return err
+				}
+			}
+		}
+		if !state.open {
+			state.open = true
+			state.start = tm
+		}
+		state.last = tm
+	}
+	state.appendRows(chunk, from, chunk.Len())
+	return nil
+}
+
+// ends reports whether a row at tm starts a new session.
+func (t *sessionTransformation) ends(state *sessionState, tm values.Time) bool {
+	if tm >= state.last.Add(t.spec.Gap) {
+		return true
+	}
+	return !t.spec.MaxDuration.IsZero() && tm >= state.start.Add(t.spec.MaxDuration)
+}
+
+// closeSession sends the rows of the open session as a table
+// with the bounds of the session in its group key.
+func (t *sessionTransformation) closeSession(state *sessionState) error {
+	if !state.open {
+		return nil
+	}
+	start, stop := state.start, state.last.Add(t.spec.Gap)
+	if !t.spec.MaxDuration.IsZero() {
+		if limit := start.Add(t.spec.MaxDuration); limit < stop {
+			stop = limit
+		}
+	}
+	if t.bounds != nil {
+		if start < t.bounds.Start {
+			start = t.bounds.Start
+		}
+		if stop > t.bounds.Stop {
+			stop = t.bounds.Stop
+		}
+	}
+
+	keyValues := make([]values.Value, len(state.keyCols))
+	for i, col := range state.keyCols {
+		switch col.Label {
+		case t.spec.StartColumn:
+			keyValues[i] = values.NewTime(start)
+		case t.spec.StopColumn:
+			keyValues[i] = values.NewTime(stop)
+		default:
+			keyValues[i] = state.key.LabelValue(col.Label)
+		}
+	}
+	key := execute.NewGroupKey(state.keyCols, keyValues)
+
+	rows := state.rows
+	state.rows = nil
+	state.open = false
+	for i, vs := range rows {
+		buffer := arrow.TableBuffer{
+			GroupKey: key,
+			Columns:  state.cols,
+			Values:   make([]array.Array, len(state.cols)),
+		}
+		n := vs[0].Len()
+		for j, col := range state.cols {
+			switch {
+			case col.Label == t.spec.StartColumn:
+				buffer.Values[j] = arrow.Repeat(flux.TTime, values.NewTime(start), n, t.mem)
+			case col.Label == t.spec.StopColumn:
+				buffer.Values[j] = arrow.Repeat(flux.TTime, values.NewTime(stop), n, t.mem)
+			default:
+				buffer.Values[j] = vs[state.inIdx[j]]
+			}
+		}
+		// The input bounds are replaced by the bounds of the session.
+		for _, j := range state.boundsIdx {
+			vs[j].Release()
+		}
+		if err := t.d.Process(table.ChunkFromBuffer(buffer)); err != nil {
+			// The remaining rows were not handed off.
+			for _, vs := range rows[i+1:] {
+				releaseArrays(vs)
+			}
+			return err
+		}
+	}
+	return t.d.FlushKey(key)
+}
+
+func (t *sessionTransformation) newSessionState(key flux.GroupKey, cols []flux.ColMeta) *sessionState {
+	state := &sessionState{
+		key:     key,
+		keyCols: t.createSchema(key.Cols()),
+		inCols:  cols,
+		cols:    t.createSchema(cols),
+	}
+	state.inIdx = make([]int, len(state.cols))
+	for j, col := range state.cols {
+		state.inIdx[j] = execute.ColIdx(col.Label, cols)
+	}
+	for j, col := range cols {
+		if col.Label == t.spec.StartColumn || col.Label == t.spec.StopColumn {
+			state.boundsIdx = append(state.boundsIdx, j)
+		}
+	}
+	return state
+}
+
+// createSchema adds the start and stop columns to the columns
+// if they are missing, as window() does.
+func (t *sessionTransformation) createSchema(cols []flux.ColMeta) []flux.ColMeta {
+	newCols := make([]flux.ColMeta, 0, len(cols)+2)
+	for _, col := range cols {
+		if col.Label == t.spec.StartColumn || col.Label == t.spec.StopColumn {
+			col.Type = flux.TTime
+		}
+		newCols = append(newCols, col)
+	}
+	if execute.ColIdx(t.spec.StartColumn, newCols) < 0 {
+		newCols = append(newCols, flux.ColMeta{Label: t.spec.StartColumn, Type: flux.TTime})
+	}
+	if execute.ColIdx(t.spec.StopColumn, newCols) < 0 {
+		newCols = append(newCols, flux.ColMeta{Label: t.spec.StopColumn, Type: flux.TTime})
+	}
+	return newCols
+}
+
+func (t *sessionTransformation) Close() error {
+	return nil
+}
+
+// sessionState is the open session of a table.
+type sessionState struct {
+	key     flux.GroupKey
+	keyCols []flux.ColMeta
+	inCols  []flux.ColMeta
+	cols    []flux.ColMeta
+	// inIdx maps the output columns to the input columns.
+	inIdx []int
+	// boundsIdx are the input columns that the bounds replace.
+	boundsIdx []int
+
+	open        bool
+	start, last values.Time
+	// rows are the slices of the input columns
+	// that hold the rows of the open session.
+	rows [][]array.Array
+}
+
+// appendRows retains the rows of the chunk from i to j.
+func (s *sessionState) appendRows(chunk table.Chunk, i, j int) {
+	if i >= j {
+		return
+	}
+	vs := make([]array.Array, chunk.NCols())
+	for k := range vs {
+		vs[k] = arrow.Slice(chunk.Values(k), int64(i), int64(j))
+	}
+	s.rows = append(s.rows, vs)
+}
+
+func (s *sessionState) release() {
+	for _, vs := range s.rows {
+		releaseArrays(vs)
+	}
+	s.rows = nil
+}
+
+func releaseArrays(vs []array.Array) {
+	for _, v := range vs {
+		v.Release()
+	}
+}
+
+func equalCols(a, b []flux.ColMeta) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
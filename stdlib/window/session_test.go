@@ -0,0 +1,233 @@
+package window_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/execute/executetest"
+	"github.com/InfluxCommunity/flux/memory"
+	"github.com/InfluxCommunity/flux/stdlib/window"
+)
+
+func TestSession_Process(t *testing.T) {
+	gap := flux.ConvertDuration(3 * time.Nanosecond)
+	for _, tc := range []struct {
+		name    string
+		spec    *window.SessionProcedureSpec
+		bounds  *execute.Bounds
+		data    []flux.Table
+		want    []*executetest.Table
+		wantErr error
+	}{
+		{
+			name: "gap",
+			spec: &window.SessionProcedureSpec{Gap: gap},
+			data: []flux.Table{
+				&executetest.Table{
+					KeyCols: []string{"t0"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "t0", Type: flux.TString},
+						{Label: "_value", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), "a", 1.0},
+						{execute.Time(2), "a", 2.0},
+						{execute.Time(4), "a", 3.0},
+						{execute.Time(7), "a", 4.0},
+						{execute.Time(9), "a", 5.0},
+					},
+				},
+				&executetest.Table{
+					KeyCols: []string{"t0"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "t0", Type: flux.TString},
+						{Label: "_value", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(2), "b", 6.0},
+					},
+				},
+			},
+			want: []*executetest.Table{
+				{
+					KeyCols: []string{"_start", "_stop", "t0"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "t0", Type: flux.TString},
+						{Label: "_value", Type: flux.TFloat},
+						{Label: "_start", Type: flux.TTime},
+						{Label: "_stop", Type: flux.TTime},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), "a", 1.0, execute.Time(1), execute.Time(7)},
+						{execute.Time(2), "a", 2.0, execute.Time(1), execute.Time(7)},
+						{execute.Time(4), "a", 3.0, execute.Time(1), execute.Time(7)},
+					},
+				},
+				{
+					KeyCols: []string{"_start", "_stop", "t0"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "t0", Type: flux.TString},
+						{Label: "_value", Type: flux.TFloat},
+						{Label: "_start", Type: flux.TTime},
+						{Label: "_stop", Type: flux.TTime},
+					},
+					Data: [][]interface{}{
+						{execute.Time(7), "a", 4.0, execute.Time(7), execute.Time(12)},
+						{execute.Time(9), "a", 5.0, execute.Time(7), execute.Time(12)},
+					},
+				},
+				{
+					KeyCols: []string{"_start", "_stop", "t0"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "t0", Type: flux.TString},
+						{Label: "_value", Type: flux.TFloat},
+						{Label: "_start", Type: flux.TTime},
+						{Label: "_stop", Type: flux.TTime},
+					},
+					Data: [][]interface{}{
+						{execute.Time(2), "b", 6.0, execute.Time(2), execute.Time(5)},
+					},
+				},
+			},
+		},
+		{
+			name: "max duration",
+			spec: &window.SessionProcedureSpec{
+				Gap:         gap,
+				MaxDuration: flux.ConvertDuration(4 * time.Nanosecond),
+			},
+			data: []flux.Table{&executetest.Table{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TFloat},
+				},
+				Data: [][]interface{}{
+					{execute.Time(0), 1.0},
+					{execute.Time(2), 2.0},
+					{execute.Time(4), 3.0},
+					{execute.Time(6), 4.0},
+				},
+			}},
+			want: []*executetest.Table{
+				{
+					KeyCols: []string{"_start", "_stop"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+						{Label: "_start", Type: flux.TTime},
+						{Label: "_stop", Type: flux.TTime},
+					},
+					Data: [][]interface{}{
+						{execute.Time(0), 1.0, execute.Time(0), execute.Time(4)},
+						{execute.Time(2), 2.0, execute.Time(0), execute.Time(4)},
+					},
+				},
+				{
+					KeyCols: []string{"_start", "_stop"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+						{Label: "_start", Type: flux.TTime},
+						{Label: "_stop", Type: flux.TTime},
+					},
+					Data: [][]interface{}{
+						{execute.Time(4), 3.0, execute.Time(4), execute.Time(8)},
+						{execute.Time(6), 4.0, execute.Time(4), execute.Time(8)},
+					},
+				},
+			},
+		},
+		{
+			// Sessions span the buffers of a table and the bounds
+			// of the range are replaced and limit the sessions.
+			name:   "row wise with bounds",
+			spec:   &window.SessionProcedureSpec{Gap: gap},
+			bounds: &execute.Bounds{Start: 0, Stop: 10},
+			data: []flux.Table{&executetest.RowWiseTable{Table: &executetest.Table{
+				KeyCols: []string{"_start", "_stop"},
+				ColMeta: []flux.ColMeta{
+					{Label: "_start", Type: flux.TTime},
+					{Label: "_stop", Type: flux.TTime},
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TFloat},
+				},
+				Data: [][]interface{}{
+					{execute.Time(0), execute.Time(10), execute.Time(1), 1.0},
+					{execute.Time(0), execute.Time(10), nil, 2.0},
+					{execute.Time(0), execute.Time(10), execute.Time(3), 3.0},
+					{execute.Time(0), execute.Time(10), execute.Time(8), 4.0},
+				},
+			}}},
+			want: []*executetest.Table{
+				{
+					KeyCols: []string{"_start", "_stop"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_start", Type: flux.TTime},
+						{Label: "_stop", Type: flux.TTime},
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1), execute.Time(6), execute.Time(1), 1.0},
+						{execute.Time(1), execute.Time(6), execute.Time(3), 3.0},
+					},
+				},
+				{
+					KeyCols: []string{"_start", "_stop"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_start", Type: flux.TTime},
+						{Label: "_stop", Type: flux.TTime},
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(8), execute.Time(10), execute.Time(8), 4.0},
+					},
+				},
+			},
+		},
+		{
+			name: "unsorted",
+			spec: &window.SessionProcedureSpec{Gap: gap},
+			data: []flux.Table{&executetest.Table{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TFloat},
+				},
+				Data: [][]interface{}{
+					{execute.Time(2), 1.0},
+					{execute.Time(1), 2.0},
+				},
+			}},
+			wantErr: errors.New(`window.session requires rows sorted by "_time", found 1970-01-01T00:00:00.000000001Z after 1970-01-01T00:00:00.000000002Z; use sort() before window.session()`),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			spec := *tc.spec
+			spec.TimeColumn = execute.DefaultTimeColLabel
+			spec.StartColumn = execute.DefaultStartColLabel
+			spec.StopColumn = execute.DefaultStopColLabel
+			executetest.ProcessTestHelper2(
+				t,
+				tc.data,
+				tc.want,
+				tc.wantErr,
+				func(id execute.DatasetID, alloc memory.Allocator) (execute.Transformation, execute.Dataset) {
+					tr, d, err := window.NewSessionTransformation(id, &spec, tc.bounds, alloc)
+					if err != nil {
+						t.Fatal(err)
+					}
+					return tr, d
+				},
+			)
+		})
+	}
+}